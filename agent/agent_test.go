@@ -251,7 +251,7 @@ func TestListAppendOnce(t *testing.T) {
 	}
 
 	// List must have exactly one entry.
-	if cnt := readAgentCount(st); cnt != 1 {
+	if cnt := AgentCount(st); cnt != 1 {
 		t.Errorf("agent list count: want 1, got %d", cnt)
 	}
 }
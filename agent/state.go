@@ -5,6 +5,7 @@ import (
 	"math/big"
 
 	"github.com/tos-network/gtos/common"
+	vmtypes "github.com/tos-network/gtos/core/vmtypes"
 	"github.com/tos-network/gtos/crypto"
 	"github.com/tos-network/gtos/params"
 )
@@ -53,7 +54,9 @@ func metadataLenSlot(addr common.Address) common.Hash {
 	return common.BytesToHash(crypto.Keccak256(key))
 }
 
-func readAgentCount(db stateDB) uint64 {
+// AgentCount returns the number of addresses that ever registered as an
+// agent. The agent list is append-only.
+func AgentCount(db stateDB) uint64 {
 	raw := db.GetState(params.AgentRegistryAddress, agentCountSlot)
 	return raw.Big().Uint64()
 }
@@ -64,13 +67,14 @@ func writeAgentCount(db stateDB, n uint64) {
 	db.SetState(params.AgentRegistryAddress, agentCountSlot, val)
 }
 
-func readAgentAt(db stateDB, i uint64) common.Address {
+// AgentAt returns the i-th address to register as an agent.
+func AgentAt(db stateDB, i uint64) common.Address {
 	raw := db.GetState(params.AgentRegistryAddress, agentListSlot(i))
 	return common.BytesToAddress(raw[:])
 }
 
 func appendAgentToList(db stateDB, addr common.Address) {
-	n := readAgentCount(db)
+	n := AgentCount(db)
 	slot := agentListSlot(n)
 	var val common.Hash
 	copy(val[:], addr.Bytes())
@@ -133,6 +137,32 @@ func WriteStatus(db stateDB, addr common.Address, s AgentStatus) {
 	db.SetState(params.AgentRegistryAddress, agentSlot(addr, "status"), val)
 }
 
+// SlashStake removes up to amount from addr's locked stake and pays it from
+// the agent registry account to beneficiary. It returns the amount actually
+// slashed. An agent slashed to zero stake becomes inactive and may register
+// again.
+func SlashStake(db vmtypes.StateDB, addr common.Address, amount *big.Int, beneficiary common.Address) *big.Int {
+	stake := ReadStake(db, addr)
+	slashed := new(big.Int).Set(amount)
+	if slashed.Cmp(stake) > 0 {
+		slashed.Set(stake)
+	}
+	if held := db.GetBalance(params.AgentRegistryAddress); slashed.Cmp(held) > 0 {
+		slashed.Set(held)
+	}
+	if slashed.Sign() <= 0 {
+		return new(big.Int)
+	}
+	db.SubBalance(params.AgentRegistryAddress, slashed)
+	db.AddBalance(beneficiary, slashed)
+	remaining := new(big.Int).Sub(stake, slashed)
+	WriteStake(db, addr, remaining)
+	if remaining.Sign() == 0 {
+		WriteStatus(db, addr, AgentInactive)
+	}
+	return slashed
+}
+
 // MetadataOf returns the metadata URI stored for addr.
 // The URI is reconstructed from multiple 32-byte storage slots.
 func MetadataOf(db stateDB, addr common.Address) string {
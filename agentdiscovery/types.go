@@ -133,18 +133,21 @@ type SearchResult struct {
 }
 
 type ProviderTrustSummary struct {
-	Registered           bool   `json:"registered"`
-	Suspended            bool   `json:"suspended"`
-	Stake                string `json:"stake"`
-	StakeBucket          string `json:"stakeBucket,omitempty"`
-	Reputation           string `json:"reputation"`
-	ReputationBucket     string `json:"reputationBucket,omitempty"`
-	RatingCount          string `json:"ratingCount"`
-	CapabilityRegistered bool   `json:"capabilityRegistered"`
-	CapabilityBit        *uint8 `json:"capabilityBit,omitempty"`
-	HasOnchainCapability bool   `json:"hasOnchainCapability"`
-	LocalRankScore       int64  `json:"localRankScore,omitempty"`
-	LocalRankReason      string `json:"localRankReason,omitempty"`
+	Registered                 bool   `json:"registered"`
+	Suspended                  bool   `json:"suspended"`
+	Stake                      string `json:"stake"`
+	StakeBucket                string `json:"stakeBucket,omitempty"`
+	Reputation                 string `json:"reputation"`
+	ReputationBucket           string `json:"reputationBucket,omitempty"`
+	RatingCount                string `json:"ratingCount"`
+	CapabilityRegistered       bool   `json:"capabilityRegistered"`
+	CapabilityBit              *uint8 `json:"capabilityBit,omitempty"`
+	HasOnchainCapability       bool   `json:"hasOnchainCapability"`
+	CapabilityReputation       string `json:"capabilityReputation,omitempty"`       // decayed, weighted score scaled by 10^4
+	CapabilityConfidence       uint64 `json:"capabilityConfidence,omitempty"`       // basis points
+	CapabilityReputationBucket string `json:"capabilityReputationBucket,omitempty"` // none, low, medium, high, negative
+	LocalRankScore             int64  `json:"localRankScore,omitempty"`
+	LocalRankReason            string `json:"localRankReason,omitempty"`
 }
 
 type CardResponse struct {
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/crypto"
+	"github.com/tos-network/gtos/gateway"
+	"github.com/tos-network/gtos/log"
+	"github.com/tos-network/gtos/tosclient"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/time/rate"
+)
+
+var (
+	gatewayRPCURLFlag = &cli.StringFlag{
+		Name:  "rpc",
+		Usage: "TOS RPC endpoint used to read gateway state and submit transactions",
+		Value: "http://localhost:8545",
+	}
+	gatewayListenFlag = &cli.StringFlag{
+		Name:  "listen",
+		Usage: "HTTP listen address of the relay API",
+		Value: ":8650",
+	}
+	gatewaySponsorKeyFlag = &cli.StringFlag{
+		Name:     "sponsor.key",
+		Usage:    "File holding the hex secp256k1 key of the registered gateway agent",
+		Required: true,
+	}
+	gatewayPolicyHashFlag = &cli.StringFlag{
+		Name:  "sponsor.policyhash",
+		Usage: "Sponsor policy hash relayed transactions must carry",
+	}
+	gatewayMaxExpiryFlag = &cli.DurationFlag{
+		Name:  "sponsor.maxexpiry",
+		Usage: "Longest sponsor expiry accepted on relayed transactions",
+		Value: gateway.DefaultRelayMaxExpiry,
+	}
+	gatewayRateLimitFlag = &cli.Float64Flag{
+		Name:  "ratelimit",
+		Usage: "Requests per second allowed per client IP",
+		Value: gateway.DefaultRelayRateLimit,
+	}
+	gatewayRateBurstFlag = &cli.IntFlag{
+		Name:  "ratelimit.burst",
+		Usage: "Request burst allowed per client IP",
+		Value: gateway.DefaultRelayRateBurst,
+	}
+	gatewayCommand = &cli.Command{
+		Name:  "gateway",
+		Usage: "Gateway relay operations",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "serve",
+				Usage: "Run a signer/paymaster relay for a registered gateway",
+				Flags: []cli.Flag{
+					gatewayRPCURLFlag,
+					gatewayListenFlag,
+					gatewaySponsorKeyFlag,
+					gatewayPolicyHashFlag,
+					gatewayMaxExpiryFlag,
+					gatewayRateLimitFlag,
+					gatewayRateBurstFlag,
+				},
+				Action: serveGatewayRelay,
+				Description: `
+The relay exposes an HTTP API that co-signs user-signed sponsored SignerTx
+payloads as the gateway and submits them. It enforces the gateway's on-chain
+max_relay_gas and fee policy; the gateway must be active and advertise the
+"paymaster" kind. Clients fetch sponsor fields from GET /v1/quote and submit
+to POST /v1/relay.`,
+			},
+		},
+	}
+)
+
+func serveGatewayRelay(ctx *cli.Context) error {
+	key, err := crypto.LoadECDSA(ctx.String(gatewaySponsorKeyFlag.Name))
+	if err != nil {
+		return fmt.Errorf("load sponsor key: %w", err)
+	}
+	client, err := tosclient.Dial(ctx.String(gatewayRPCURLFlag.Name))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	relay, err := gateway.NewRelay(ctx.Context, client, gateway.RelayConfig{
+		SponsorKey: key,
+		PolicyHash: common.HexToHash(ctx.String(gatewayPolicyHashFlag.Name)),
+		MaxExpiry:  ctx.Duration(gatewayMaxExpiryFlag.Name),
+	})
+	if err != nil {
+		return err
+	}
+	server := &http.Server{
+		Addr: ctx.String(gatewayListenFlag.Name),
+		Handler: gateway.NewRelayHandler(relay, gateway.RelayServerConfig{
+			RateLimit: rate.Limit(ctx.Float64(gatewayRateLimitFlag.Name)),
+			RateBurst: ctx.Int(gatewayRateBurstFlag.Name),
+		}),
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       15 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       60 * time.Second,
+	}
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigc)
+	go func() {
+		<-sigc
+		log.Info("Shutting down gateway relay")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Info("Gateway relay started", "listen", server.Addr, "sponsor", relay.Sponsor())
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -66,6 +66,8 @@ var (
 		utils.GCModeFlag,
 		utils.SnapshotFlag,
 		utils.TxLookupLimitFlag,
+		utils.PrivTxIndexFlag,
+		utils.PrivECDLPTableFlag,
 		utils.LightKDFFlag,
 		utils.TOSRequiredBlocksFlag,
 		utils.LegacyWhitelistFlag,
@@ -190,6 +192,8 @@ func init() {
 		versionCheckCommand,
 		licenseCommand,
 		voteCommand,
+		// See gatewaycmd.go
+		gatewayCommand,
 		// See config.go
 		dumpConfigCommand,
 		// see dbcmd.go
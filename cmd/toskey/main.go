@@ -32,6 +32,7 @@ func init() {
 		commandPrivShield,
 		commandPrivUnshield,
 		commandPrivGenerateTable,
+		commandPrivFetchTable,
 		commandPrivDisclose,
 		commandPrivGenerateToken,
 		commandPrivDecryptToken,
@@ -33,7 +33,11 @@ var (
 	}
 	privTableFlag = &cli.StringFlag{
 		Name:  "table",
-		Usage: "path to a precomputed BSGS table file (from priv-generate-table)",
+		Usage: "path to a precomputed BSGS table file (from priv-generate-table or priv-fetch-table)",
+	}
+	privKangarooFlag = &cli.BoolFlag{
+		Name:  "kangaroo",
+		Usage: "solve with Pollard's kangaroo method (constant memory, any --max-balance up to 2^64-1)",
 	}
 )
 
@@ -190,7 +194,9 @@ current encrypted balance via tos_privGetBalance. You can also pass --ct with a
 64-byte ciphertext blob (commitment||handle) to decrypt explicit balance data.
 
 The discrete log search is bounded by --max-balance. Increase it if the command
-reports that the balance exceeds the current search window.
+reports that the balance exceeds the current search window. Large windows are
+best served by a --table; --kangaroo needs no table and constant memory and can
+search the full 64-bit range, at ~6·sqrt(max-balance) point additions.
 `,
 	Flags: []cli.Flag{
 		passphraseFlag,
@@ -199,6 +205,7 @@ reports that the balance exceeds the current search window.
 		privCiphertextFlag,
 		privMaxBalanceFlag,
 		privTableFlag,
+		privKangarooFlag,
 	},
 	Action: func(ctx *cli.Context) error {
 		keyfilePath := ctx.Args().First()
@@ -243,15 +250,22 @@ reports that the balance exceeds the current search window.
 		}
 		maxBalance := ctx.Uint64(privMaxBalanceFlag.Name)
 
-		var table *ecdlptable.Table
-		if tablePath := ctx.String(privTableFlag.Name); tablePath != "" {
-			table, err = ecdlptable.Load(tablePath)
-			if err != nil {
-				return fmt.Errorf("failed to load BSGS table: %w", err)
+		var (
+			plaintextBalance uint64
+			ok               bool
+		)
+		if ctx.Bool(privKangarooFlag.Name) {
+			plaintextBalance, ok, err = cryptopriv.SolveDiscreteLogKangaroo(msgPoint, maxBalance)
+		} else {
+			var table *ecdlptable.Table
+			if tablePath := ctx.String(privTableFlag.Name); tablePath != "" {
+				table, err = ecdlptable.Load(tablePath)
+				if err != nil {
+					return fmt.Errorf("failed to load BSGS table: %w", err)
+				}
 			}
+			plaintextBalance, ok, err = cryptopriv.SolveDiscreteLogWithTable(table, msgPoint, maxBalance)
 		}
-
-		plaintextBalance, ok, err := cryptopriv.SolveDiscreteLogWithTable(table, msgPoint, maxBalance)
 		if err != nil {
 			return fmt.Errorf("failed to solve plaintext balance: %w", err)
 		}
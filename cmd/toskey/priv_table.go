@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/common/hexutil"
 	"github.com/tos-network/gtos/crypto/priv/ecdlptable"
+	"github.com/tos-network/gtos/rpc"
 	"github.com/urfave/cli/v2"
 )
 
@@ -22,6 +25,9 @@ The --l1 flag controls table size and decode range per giant step:
   L1=22  →    2 M entries, ~21 MB   (medium: covers ~4B with ~1K giant steps)
   L1=26  →   33 M entries, ~350 MB  (high: covers ~4.5e15 with ~67M giant steps)
 
+The file carries sha256 checksums per 1 MiB chunk and is verified on load.
+A node can serve it to wallets with: gtos --priv.ecdlptable <file>
+
 Use with: toskey priv-balance --table <file> <keyfile>
 `,
 	Flags: []cli.Flag{
@@ -69,3 +75,88 @@ Use with: toskey priv-balance --table <file> <keyfile>
 		return nil
 	},
 }
+
+type rpcECDLPTableManifest struct {
+	L1        hexutil.Uint   `json:"l1"`
+	TableLen  hexutil.Uint64 `json:"tableLen"`
+	ChunkSize hexutil.Uint64 `json:"chunkSize"`
+	Root      common.Hash    `json:"root"`
+	Chunks    []common.Hash  `json:"chunks"`
+}
+
+var commandPrivFetchTable = &cli.Command{
+	Name:      "priv-fetch-table",
+	Usage:     "download a precomputed BSGS table from a node",
+	ArgsUsage: "<output-file>",
+	Description: `
+Downloads the BSGS table served by a node started with --priv.ecdlptable,
+chunk by chunk via priv_getECDLPTableManifest / priv_getECDLPTableChunk.
+Every chunk is checked against the manifest's sha256 hashes. An interrupted
+download resumes where it stopped when run again with the same output file.
+
+Use with: toskey priv-balance --table <file> <keyfile>
+`,
+	Flags: []cli.Flag{
+		rpcURLFlag,
+	},
+	Action: func(ctx *cli.Context) error {
+		outPath := ctx.Args().First()
+		if outPath == "" {
+			return fmt.Errorf("missing output file argument")
+		}
+		client, err := rpc.DialContext(ctx.Context, ctx.String(rpcURLFlag.Name))
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		var res rpcECDLPTableManifest
+		if err := client.CallContext(ctx.Context, &res, "priv_getECDLPTableManifest"); err != nil {
+			return fmt.Errorf("priv_getECDLPTableManifest failed: %w", err)
+		}
+		manifest := &ecdlptable.Manifest{
+			L1:        uint(res.L1),
+			TableLen:  uint32(res.TableLen),
+			ChunkSize: uint32(res.ChunkSize),
+			Chunks:    make([][32]byte, len(res.Chunks)),
+		}
+		for i, c := range res.Chunks {
+			manifest.Chunks[i] = c
+		}
+		if manifest.Root() != res.Root {
+			return fmt.Errorf("manifest root mismatch")
+		}
+		asm, err := ecdlptable.CreateAssembly(outPath, manifest)
+		if err != nil {
+			return err
+		}
+		missing, err := asm.Missing()
+		if err != nil {
+			asm.Close()
+			return err
+		}
+		fmt.Printf("Fetching BSGS table: L1=%d, %d of %d chunks missing\n", manifest.L1, len(missing), len(manifest.Chunks))
+
+		lastReport := time.Now()
+		for n, i := range missing {
+			var chunk hexutil.Bytes
+			if err := client.CallContext(ctx.Context, &chunk, "priv_getECDLPTableChunk", hexutil.Uint(i)); err != nil {
+				asm.Close()
+				return fmt.Errorf("priv_getECDLPTableChunk(%d) failed: %w", i, err)
+			}
+			if err := asm.WriteChunk(i, chunk); err != nil {
+				asm.Close()
+				return err
+			}
+			if time.Since(lastReport) >= 2*time.Second {
+				fmt.Printf("  %d/%d chunks\n", n+1, len(missing))
+				lastReport = time.Now()
+			}
+		}
+		if err := asm.Close(); err != nil {
+			return err
+		}
+		fmt.Println("Done.")
+		return nil
+	},
+}
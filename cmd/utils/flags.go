@@ -200,6 +200,16 @@ var (
 		Value:    tosconfig.Defaults.TxLookupLimit,
 		Category: flags.TOSCategory,
 	}
+	PrivTxIndexFlag = &cli.BoolFlag{
+		Name:     "priv.txindex",
+		Usage:    "Maintain an index of privacy transactions by ElGamal public key (enables priv_getTransactionsByKey)",
+		Category: flags.TOSCategory,
+	}
+	PrivECDLPTableFlag = &cli.StringFlag{
+		Name:     "priv.ecdlptable",
+		Usage:    "Path of a precomputed BSGS table to serve to wallets (enables priv_getECDLPTableManifest/priv_getECDLPTableChunk)",
+		Category: flags.TOSCategory,
+	}
 	LightKDFFlag = &cli.BoolFlag{
 		Name:     "lightkdf",
 		Usage:    "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
@@ -1557,6 +1567,12 @@ func SetTOSConfig(ctx *cli.Context, stack *node.Node, cfg *tosconfig.Config) {
 	if ctx.IsSet(TxLookupLimitFlag.Name) {
 		cfg.TxLookupLimit = ctx.Uint64(TxLookupLimitFlag.Name)
 	}
+	if ctx.IsSet(PrivTxIndexFlag.Name) {
+		cfg.PrivTxIndex = ctx.Bool(PrivTxIndexFlag.Name)
+	}
+	if ctx.IsSet(PrivECDLPTableFlag.Name) {
+		cfg.PrivECDLPTable = ctx.String(PrivECDLPTableFlag.Name)
+	}
 	if ctx.IsSet(CacheFlag.Name) || ctx.IsSet(CacheTrieFlag.Name) {
 		cfg.TrieCleanCache = ctx.Int(CacheFlag.Name) * ctx.Int(CacheTrieFlag.Name) / 100
 	}
@@ -1689,7 +1689,10 @@ func (d *DPoS) VerifyFinalizedState(header *types.Header, st *state.StateDB) err
 func (d *DPoS) Finalize(chain consensus.ChainHeaderReader, header *types.Header,
 	st *state.StateDB, txs []*types.Transaction, uncles []*types.Header) {
 
-	lease.RunPruneSweep(st, header.Number.Uint64(), &params.ChainConfig{DPoS: d.config})
+	leaseConfig := &params.ChainConfig{DPoS: d.config}
+	lease.RunSeedSweep(st, header.Number.Uint64(), leaseConfig)
+	lease.ChargeStorage(st, st.StorageSlotDeltas(), header.Number.Uint64(), leaseConfig)
+	lease.RunPruneSweep(st, header.Number.Uint64(), leaseConfig)
 	st.AddBalance(header.Coinbase, params.DPoSBlockReward)
 	header.Root = st.IntermediateRoot(true)
 	header.UncleHash = types.EmptyUncleHash
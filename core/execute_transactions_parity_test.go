@@ -338,7 +338,7 @@ func TestExecuteTransactionsMixedPrivacyAndPublicParity(t *testing.T) {
 	publicTx, publicMsg := makePublicTx(0, publicSender, publicRecipient, 77)
 	balanceAfterTx0 := senderBalance - amount0 - fee
 	stateAfterTx0 := baseState.Copy()
-	prepared0, err := preparePrivacyTxState(config.ChainID, stateAfterTx0, tx0)
+	prepared0, err := preparePrivacyTxState(config.ChainID, 0, stateAfterTx0, tx0)
 	if err != nil {
 		t.Fatalf("preparePrivacyTxState(tx0): %v", err)
 	}
@@ -469,7 +469,7 @@ func TestExecuteTransactionsPrivacyBatchFallbackParity(t *testing.T) {
 	tx0 := mustMakePrivTransferTx(t, config.ChainID, senderPub, senderPriv, receiverPub, 0, fee, fee, amount0, startBalance, senderCt0)
 
 	stateAfterTx0 := baseState.Copy()
-	prepared0, err := preparePrivacyTxState(config.ChainID, stateAfterTx0, tx0)
+	prepared0, err := preparePrivacyTxState(config.ChainID, 0, stateAfterTx0, tx0)
 	if err != nil {
 		t.Fatalf("preparePrivacyTxState(tx0): %v", err)
 	}
@@ -481,7 +481,7 @@ func TestExecuteTransactionsPrivacyBatchFallbackParity(t *testing.T) {
 	validTx1 := mustMakePrivTransferTx(t, config.ChainID, senderPub, senderPriv, receiverPub, 1, fee, fee, amount1, balance1, senderCt1)
 
 	stateAfterTx1 := stateAfterTx0.Copy()
-	prepared1, err := preparePrivacyTxState(config.ChainID, stateAfterTx1, validTx1)
+	prepared1, err := preparePrivacyTxState(config.ChainID, 0, stateAfterTx1, validTx1)
 	if err != nil {
 		t.Fatalf("preparePrivacyTxState(validTx1): %v", err)
 	}
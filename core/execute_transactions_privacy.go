@@ -68,7 +68,7 @@ func executeTransactionsSerial(
 				// inputState always reflects the current on-chain
 				// state, not the speculative pendingState copy.
 				// Proofs were already batch-verified above.
-				fresh, prepErr := preparePrivacyTxState(config.ChainID, statedb, candidate.tx)
+				fresh, prepErr := preparePrivacyTxState(config.ChainID, privacyBlockNumber(blockNumber), statedb, candidate.tx)
 				if prepErr != nil {
 					fallbackFrom = idx
 					break
@@ -95,7 +95,7 @@ func executeTransactionsSerial(
 			// Re-prepare against the current statedb to ensure
 			// the fallback path uses fresh state, not stale
 			// prepared state from the speculative pendingState.
-			prepared, err := preparePrivacyTxState(config.ChainID, statedb, candidate.tx)
+			prepared, err := preparePrivacyTxState(config.ChainID, privacyBlockNumber(blockNumber), statedb, candidate.tx)
 			if err == nil {
 				err = prepared.VerifyProofs()
 			}
@@ -133,7 +133,7 @@ func executeTransactionsSerial(
 			if pendingState == nil {
 				pendingState = statedb.Copy()
 			}
-			prepared, err := preparePrivacyTxState(config.ChainID, pendingState, tx)
+			prepared, err := preparePrivacyTxState(config.ChainID, privacyBlockNumber(blockNumber), pendingState, tx)
 			if err != nil {
 				if err := flushPrivacyBatch(); err != nil {
 					return nil, nil, 0, err
@@ -254,7 +254,7 @@ func executeSinglePrivacyTx(
 	cumulativeGasUsed uint64,
 ) *types.Receipt {
 	statedb.Prepare(tx.Hash(), txIndex)
-	prepared, err := preparePrivacyTxState(chainID, statedb, tx)
+	prepared, err := preparePrivacyTxState(chainID, privacyBlockNumber(blockNumber), statedb, tx)
 	if err == nil {
 		err = prepared.VerifyProofs()
 	}
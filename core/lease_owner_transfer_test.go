@@ -0,0 +1,112 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/core/rawdb"
+	"github.com/tos-network/gtos/core/state"
+	"github.com/tos-network/gtos/core/types"
+	"github.com/tos-network/gtos/core/vm"
+	"github.com/tos-network/gtos/lease"
+	"github.com/tos-network/gtos/params"
+	"github.com/tos-network/gtos/sysaction"
+)
+
+// TestLeaseTransferOwnerStateTransition runs the two-step lease ownership
+// transfer through ApplyMessage. Contract senders are rejected before reaching
+// the lease handler, so a contract new owner accepts with
+// tos.lease_accept_owner instead.
+func TestLeaseTransferOwnerStateTransition(t *testing.T) {
+	config := &params.ChainConfig{
+		ChainID: big.NewInt(1),
+		DPoS: &params.DPoSConfig{
+			PeriodMs:      3000,
+			Epoch:         208,
+			MaxValidators: 21,
+			TurnLength:    params.DPoSTurnLength,
+		},
+	}
+	owner := common.HexToAddress("0xAA20")
+	newOwner := common.HexToAddress("0xAA21")
+	multisig := common.HexToAddress("0xCC21")
+	contractAddr := common.HexToAddress("0xCC20")
+
+	st, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatalf("state.New: %v", err)
+	}
+	funds := new(big.Int).Mul(big.NewInt(1000), new(big.Int).SetUint64(params.TOS))
+	for _, addr := range []common.Address{owner, newOwner, multisig} {
+		st.AddBalance(addr, new(big.Int).Set(funds))
+	}
+	code := []byte(`tos.emit("Ping")`)
+	st.SetCode(contractAddr, code)
+	deposit, err := lease.DepositFor(uint64(len(code)), 100)
+	if err != nil {
+		t.Fatalf("DepositFor: %v", err)
+	}
+	if _, err := lease.Activate(st, contractAddr, owner, 10, 100, uint64(len(code)), deposit, config); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	st.AddBalance(params.LeaseRegistryAddress, deposit)
+	st.SetCode(multisig, []byte(`if not tos.lease_accept_owner("`+contractAddr.Hex()+`") then error("accept failed") end`))
+	st.Finalise(false)
+
+	blockCtx := vm.BlockContext{
+		CanTransfer: CanTransfer,
+		Transfer:    Transfer,
+		Coinbase:    common.HexToAddress("0xCAFE"),
+		BlockNumber: big.NewInt(20),
+		Time:        big.NewInt(60),
+		GasLimit:    30_000_000,
+	}
+	apply := func(from common.Address, kind sysaction.ActionKind, payload interface{}) (*ExecutionResult, error) {
+		t.Helper()
+		data, err := sysaction.MakeSysAction(kind, payload)
+		if err != nil {
+			t.Fatalf("MakeSysAction(%s): %v", kind, err)
+		}
+		to := params.SystemActionAddress
+		msg := types.NewMessage(from, &to, st.GetNonce(from), big.NewInt(0), 1_000_000,
+			params.TxPrice(), params.TxPrice(), params.TxPrice(), data, nil, false)
+		gp := new(GasPool).AddGas(msg.Gas())
+		return ApplyMessage(context.Background(), blockCtx, config, msg, gp, st)
+	}
+
+	res, err := apply(owner, sysaction.ActionLeaseTransferOwner, lease.TransferOwnerAction{ContractAddr: contractAddr, NewOwner: newOwner})
+	if err != nil || res.Err != nil {
+		t.Fatalf("propose: err=%v vmerr=%v", err, res.Err)
+	}
+	res, err = apply(newOwner, sysaction.ActionLeaseAcceptOwner, lease.AcceptOwnerAction{ContractAddr: contractAddr})
+	if err != nil || res.Err != nil {
+		t.Fatalf("accept: err=%v vmerr=%v", err, res.Err)
+	}
+	meta, ok := lease.ReadMeta(st, contractAddr)
+	if !ok || meta.LeaseOwner != newOwner || meta.PendingOwner != (common.Address{}) {
+		t.Fatalf("after accept: owner=%v pending=%v", meta.LeaseOwner, meta.PendingOwner)
+	}
+
+	// A contract can be proposed too. It cannot send LEASE_ACCEPT_OWNER ...
+	res, err = apply(newOwner, sysaction.ActionLeaseTransferOwner, lease.TransferOwnerAction{ContractAddr: contractAddr, NewOwner: multisig})
+	if err != nil || res.Err != nil {
+		t.Fatalf("propose contract: err=%v vmerr=%v", err, res.Err)
+	}
+	if _, err := apply(multisig, sysaction.ActionLeaseAcceptOwner, lease.AcceptOwnerAction{ContractAddr: contractAddr}); !errors.Is(err, ErrSenderNoEOA) {
+		t.Fatalf("accept from contract: want %v, got %v", ErrSenderNoEOA, err)
+	}
+	// ... so it accepts from its own code when called.
+	msg := types.NewMessage(owner, &multisig, st.GetNonce(owner), big.NewInt(0), 1_000_000,
+		params.TxPrice(), params.TxPrice(), params.TxPrice(), nil, nil, false)
+	res, err = ApplyMessage(context.Background(), blockCtx, config, msg, new(GasPool).AddGas(msg.Gas()), st)
+	if err != nil || res.Err != nil {
+		t.Fatalf("contract accept: err=%v vmerr=%v", err, res.Err)
+	}
+	meta, _ = lease.ReadMeta(st, contractAddr)
+	if meta.LeaseOwner != multisig || meta.PendingOwner != (common.Address{}) {
+		t.Fatalf("after contract accept: owner=%v pending=%v", meta.LeaseOwner, meta.PendingOwner)
+	}
+}
@@ -45,6 +45,14 @@ func AnalyzeTx(msg types.Message, statedb StateReader) AccessSet {
 			as.WriteSlots[params.SponsorRegistryAddress] = make(map[common.Hash]struct{})
 		}
 		as.WriteSlots[params.SponsorRegistryAddress][nonceSlot] = struct{}{}
+		// A sponsor policy is read from SponsorRegistryAddress and can be
+		// registered or revoked by a system action in the same block, so
+		// order policy-bound txs against system actions. The per-sender
+		// budget slot is only written by txs of the same sender, which the
+		// sender write already serializes.
+		if msg.SponsorPolicyHash() != (common.Hash{}) {
+			as.ReadAddrs[params.LVMSerialAddress] = struct{}{}
+		}
 	}
 
 	// Privacy transactions (PrivTransfer, Shield, Unshield) modify encrypted
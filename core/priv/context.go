@@ -8,12 +8,13 @@ import (
 )
 
 const (
-	privContextVersion        byte = 1
-	privContextVersionAuditor byte = 2
-	privNativeAssetTag        byte = 0
-	privActionTransfer        byte = 0x10 // distinct from old action IDs
-	privActionShield          byte = 0x11
-	privActionUnshield        byte = 0x12
+	privContextVersion             byte = 1
+	privContextVersionAuditor      byte = 2
+	privContextVersionMultiAuditor byte = 3
+	privNativeAssetTag             byte = 0
+	privActionTransfer             byte = 0x10 // distinct from old action IDs
+	privActionShield               byte = 0x11
+	privActionUnshield             byte = 0x12
 )
 
 var zeroAuditorHandle [32]byte
@@ -73,6 +74,10 @@ func chainIDToU64(chainID *big.Int) uint64 {
 //
 // When auditorHandle is non-zero the version is set to 2 and the auditor
 // handle is appended after sourceCommitment (291 bytes total).
+//
+// When extraAuditorHandles are supplied (multi-auditor wallets) the version
+// is set to 3 and a one-byte extra-handle count followed by each extra handle
+// is appended after the primary auditor handle (292 + 32·n bytes total).
 func BuildPrivTransferTranscriptContext(
 	chainID *big.Int,
 	privNonce uint64,
@@ -82,6 +87,7 @@ func BuildPrivTransferTranscriptContext(
 	senderCt, receiverCt Ciphertext,
 	sourceCommitment [32]byte,
 	auditorHandle [32]byte,
+	extraAuditorHandles ...[32]byte,
 ) []byte {
 	hasAuditor := auditorHandle != zeroAuditorHandle
 	cap := 259
@@ -90,6 +96,11 @@ func BuildPrivTransferTranscriptContext(
 		cap = 291
 		version = privContextVersionAuditor
 	}
+	if len(extraAuditorHandles) > 0 {
+		hasAuditor = true
+		cap = 292 + 32*len(extraAuditorHandles)
+		version = privContextVersionMultiAuditor
+	}
 	ctx := make([]byte, 0, cap)
 	ctx = appendU8(ctx, version)
 	ctx = appendU64(ctx, chainIDToU64(chainID))
@@ -106,6 +117,12 @@ func BuildPrivTransferTranscriptContext(
 	if hasAuditor {
 		ctx = appendBytes32(ctx, auditorHandle)
 	}
+	if len(extraAuditorHandles) > 0 {
+		ctx = appendU8(ctx, byte(len(extraAuditorHandles)))
+		for _, h := range extraAuditorHandles {
+			ctx = appendBytes32(ctx, h)
+		}
+	}
 	return ctx
 }
 
@@ -1,6 +1,7 @@
 package priv
 
 import (
+	"bytes"
 	"encoding/binary"
 	"encoding/hex"
 	"math/big"
@@ -143,6 +144,34 @@ func TestBuildPrivTransferTranscriptContext_NilChainID(t *testing.T) {
 	}
 }
 
+func TestBuildPrivTransferTranscriptContext_MultiAuditor(t *testing.T) {
+	primary := [32]byte{0x01}
+	extras := [][32]byte{{0x02}, {0x03}}
+	ctx := BuildPrivTransferTranscriptContext(
+		big.NewInt(1), 0, 0, 0,
+		common.Address{}, common.Address{},
+		ZeroCiphertext(), ZeroCiphertext(),
+		[32]byte{},
+		primary,
+		extras...,
+	)
+	if want := 292 + 32*len(extras); len(ctx) != want {
+		t.Fatalf("context length: got %d want %d", len(ctx), want)
+	}
+	if ctx[0] != privContextVersionMultiAuditor {
+		t.Fatalf("contextVersion: got %d want %d", ctx[0], privContextVersionMultiAuditor)
+	}
+	if !bytes.Equal(ctx[259:291], primary[:]) {
+		t.Fatal("primary auditor handle mismatch")
+	}
+	if ctx[291] != byte(len(extras)) {
+		t.Fatalf("extra handle count: got %d want %d", ctx[291], len(extras))
+	}
+	if !bytes.Equal(ctx[324:356], extras[1][:]) {
+		t.Fatal("second extra auditor handle mismatch")
+	}
+}
+
 func TestBuildShieldTranscriptContext(t *testing.T) {
 	chainID := big.NewInt(1337)
 	_, aliceAddr := mustDecodePub(alicePubHex)
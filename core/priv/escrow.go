@@ -0,0 +1,48 @@
+package priv
+
+import (
+	"math/big"
+
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/core/vm"
+	"github.com/tos-network/gtos/crypto"
+)
+
+// BuildUnoEscrowLockEntry generates the proof bundle entry consumed by the
+// LVM tos.uno_escrow_lock primitive. This is a client-side operation: the
+// payer locks amount out of their encrypted balance into the confidential
+// escrow escrowID owned by contract, releasable to payeePub.
+//
+// payerBalance is the payer's decrypted balance and payerCiphertext the
+// matching on-chain ciphertext; the proof is bound to it, so it must be
+// rebuilt if the balance changes before the call is included.
+func BuildUnoEscrowLockEntry(
+	chainID *big.Int,
+	contract common.Address,
+	escrowID [32]byte,
+	payerPriv, payerPub, payeePub [32]byte,
+	amount, payerBalance uint64,
+	payerCiphertext Ciphertext,
+) (vm.ProofEntry, error) {
+	var balance [64]byte
+	copy(balance[:32], payerCiphertext.Commitment[:])
+	copy(balance[32:], payerCiphertext.Handle[:])
+	payer := common.BytesToAddress(crypto.Keccak256(payerPub[:]))
+	payee := common.BytesToAddress(crypto.Keccak256(payeePub[:]))
+	context := vm.BuildUnoEscrowLockContext(chainID, contract, escrowID, payer, payee, balance)
+
+	commitment, payerHandle, payeeHandle, sourceCommitment,
+		ctValidityProof, commitmentEqProof, rangeProof, err := BuildTransferProofs(
+		payerPriv, payerPub, payeePub,
+		amount, payerBalance, 0,
+		payerCiphertext, context,
+	)
+	if err != nil {
+		return vm.ProofEntry{}, err
+	}
+	return vm.NewUnoEscrowLockEntry(
+		escrowID, payerPub, payeePub,
+		commitment, payerHandle, payeeHandle, sourceCommitment,
+		ctValidityProof, commitmentEqProof, rangeProof,
+	), nil
+}
@@ -0,0 +1,74 @@
+package priv
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/tos-network/gtos/core/vm"
+	cryptopriv "github.com/tos-network/gtos/crypto/priv"
+)
+
+// BalanceAtLeastProofSize is the size of a balance-threshold proof:
+// commitment (32B) ‖ commitment equality proof (192B) ‖ range proof (672B).
+const BalanceAtLeastProofSize = vm.BalanceAtLeastProofSize
+
+// BalanceAtLeastClaim bundles a balance-threshold proof with the public
+// parameters it is bound to. Ciphertext and Nonce are the account's on-chain
+// encrypted balance and priv nonce at the time the proof was made.
+type BalanceAtLeastClaim struct {
+	Pubkey     [32]byte
+	Ciphertext Ciphertext
+	Nonce      uint64
+	Threshold  uint64
+	Challenge  [32]byte
+	Proof      []byte
+}
+
+// ProveBalanceAtLeast proves that the encrypted balance ct, which decrypts to
+// balance under pubkey, is at least threshold. challenge is chosen by the
+// verifier; the proof only verifies for the same chain, account state and
+// challenge.
+func ProveBalanceAtLeast(
+	privkey, pubkey [32]byte,
+	ct Ciphertext,
+	balance, nonce, threshold uint64,
+	challenge [32]byte,
+	chainID *big.Int,
+) ([]byte, error) {
+	if balance < threshold {
+		return nil, fmt.Errorf("priv: balance below threshold")
+	}
+	ct64 := ciphertextToCompressed(ct)
+	shifted, err := cryptopriv.SubAmountCompressed(ct64, threshold)
+	if err != nil {
+		return nil, err
+	}
+	excess := balance - threshold
+	commitment, opening, err := cryptopriv.CommitmentNew(excess)
+	if err != nil {
+		return nil, err
+	}
+	var balance64 [64]byte
+	copy(balance64[:], ct64)
+	ctx := vm.BuildBalanceAtLeastContext(chainID, pubkey, balance64, nonce, threshold, challenge)
+	eqProof, err := cryptopriv.ProveCommitmentEqProof(privkey[:], pubkey[:], shifted, commitment, opening, excess, ctx)
+	if err != nil {
+		return nil, err
+	}
+	rangeProof, err := cryptopriv.ProveRangeProof(commitment, excess, opening)
+	if err != nil {
+		return nil, err
+	}
+	proof := make([]byte, 0, BalanceAtLeastProofSize)
+	proof = append(proof, commitment...)
+	proof = append(proof, eqProof...)
+	proof = append(proof, rangeProof...)
+	return proof, nil
+}
+
+// VerifyBalanceAtLeast verifies a BalanceAtLeastClaim against the given chain ID.
+func VerifyBalanceAtLeast(claim BalanceAtLeastClaim, chainID *big.Int) error {
+	var balance [64]byte
+	copy(balance[:], ciphertextToCompressed(claim.Ciphertext))
+	return vm.VerifyBalanceAtLeastProof(chainID, claim.Pubkey, balance, claim.Nonce, claim.Threshold, claim.Challenge, claim.Proof)
+}
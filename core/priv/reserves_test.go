@@ -0,0 +1,104 @@
+package priv
+
+import (
+	"math/big"
+	"testing"
+
+	cryptopriv "github.com/tos-network/gtos/crypto/priv"
+)
+
+func TestBalanceAtLeast_RoundTrip(t *testing.T) {
+	if !cryptopriv.BackendEnabled() {
+		t.Skip("priv backend not enabled")
+	}
+	pub, privKey, err := cryptopriv.GenerateKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pubkey, privkey [32]byte
+	copy(pubkey[:], pub)
+	copy(privkey[:], privKey)
+
+	ct64, err := cryptopriv.Encrypt(pub, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, err := compressedToCiphertext(ct64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainID := big.NewInt(1)
+	challenge := [32]byte{0xc1}
+
+	proof, err := ProveBalanceAtLeast(privkey, pubkey, ct, 1000, 7, 600, challenge, chainID)
+	if err != nil {
+		t.Fatal("ProveBalanceAtLeast failed:", err)
+	}
+	if len(proof) != BalanceAtLeastProofSize {
+		t.Fatalf("proof size = %d, want %d", len(proof), BalanceAtLeastProofSize)
+	}
+	claim := BalanceAtLeastClaim{
+		Pubkey:     pubkey,
+		Ciphertext: ct,
+		Nonce:      7,
+		Threshold:  600,
+		Challenge:  challenge,
+		Proof:      proof,
+	}
+	if err := VerifyBalanceAtLeast(claim, chainID); err != nil {
+		t.Fatal("VerifyBalanceAtLeast failed:", err)
+	}
+
+	// Every bound parameter must invalidate the proof when changed.
+	mutations := map[string]func(c *BalanceAtLeastClaim){
+		"threshold": func(c *BalanceAtLeastClaim) { c.Threshold = 500 },
+		"nonce":     func(c *BalanceAtLeastClaim) { c.Nonce = 8 },
+		"challenge": func(c *BalanceAtLeastClaim) { c.Challenge[0] ^= 1 },
+	}
+	for name, mutate := range mutations {
+		bad := claim
+		mutate(&bad)
+		if err := VerifyBalanceAtLeast(bad, chainID); err == nil {
+			t.Errorf("expected verification to fail with modified %s", name)
+		}
+	}
+	if err := VerifyBalanceAtLeast(claim, big.NewInt(2)); err == nil {
+		t.Fatal("expected verification to fail on wrong chain ID")
+	}
+}
+
+func TestBalanceAtLeast_BelowThreshold(t *testing.T) {
+	if !cryptopriv.BackendEnabled() {
+		t.Skip("priv backend not enabled")
+	}
+	pub, privKey, err := cryptopriv.GenerateKeypair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pubkey, privkey [32]byte
+	copy(pubkey[:], pub)
+	copy(privkey[:], privKey)
+
+	ct64, err := cryptopriv.Encrypt(pub, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, err := compressedToCiphertext(ct64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainID := big.NewInt(1)
+
+	if _, err := ProveBalanceAtLeast(privkey, pubkey, ct, 100, 0, 101, [32]byte{}, chainID); err == nil {
+		t.Fatal("expected proving above the balance to fail")
+	}
+	// Lying about the balance yields a proof that does not verify.
+	proof, err := ProveBalanceAtLeast(privkey, pubkey, ct, 500, 0, 300, [32]byte{}, chainID)
+	if err != nil {
+		return // the backend may refuse to prove a false statement
+	}
+	claim := BalanceAtLeastClaim{Pubkey: pubkey, Ciphertext: ct, Threshold: 300, Proof: proof}
+	if err := VerifyBalanceAtLeast(claim, chainID); err == nil {
+		t.Fatal("expected proof over a false balance to fail")
+	}
+}
@@ -13,6 +13,8 @@ import (
 	cryptopriv "github.com/tos-network/gtos/crypto/priv"
 	"github.com/tos-network/gtos/crypto/ristretto255"
 	"github.com/tos-network/gtos/params"
+	"github.com/tos-network/gtos/policywallet"
+	"github.com/tos-network/gtos/sysaction"
 )
 
 // makePrivTransferMsg constructs a fake Message containing a PrivTransferTx.
@@ -446,6 +448,110 @@ func TestApplyShield_Success(t *testing.T) {
 	}
 }
 
+// TestApplyShield_ScheduledAuditorKey checks that a wallet which configured its
+// auditor through the rotation schedule (and therefore has no legacy auditor
+// key) can shield with a valid auditor handle.
+func TestApplyShield_ScheduledAuditorKey(t *testing.T) {
+	st := newTTLDeterminismState(t)
+	cfg := &params.ChainConfig{ChainID: big.NewInt(1337)}
+	coinbase := common.HexToAddress("0xC0FFEE")
+
+	senderPub, senderPriv := mustElgamalKeypair(t)
+	auditorPub, _ := mustElgamalKeypair(t)
+	addr := common.BytesToAddress(crypto.Keccak256(senderPub[:]))
+	fee := priv.EstimateShieldFee()
+	amount := uint64(500)
+	st.AddBalance(addr, priv.UnomiToTomiBig(amount+fee))
+	priv.SetAccountState(st, addr, priv.AccountState{Ciphertext: priv.ZeroCiphertext()})
+
+	policywallet.WriteOwner(st, addr, addr)
+	data, err := sysaction.MakeSysAction(sysaction.ActionPolicySetAuditorKey, policywallet.SetAuditorKeyPayload{
+		Account:    addr,
+		AuditorKey: auditorPub,
+	})
+	if err != nil {
+		t.Fatalf("MakeSysAction: %v", err)
+	}
+	if err := sysaction.ExecuteWithContext(&sysaction.Context{
+		From:        addr,
+		Value:       big.NewInt(0),
+		BlockNumber: big.NewInt(1),
+		StateDB:     st,
+		ChainConfig: cfg,
+	}, data); err != nil {
+		t.Fatalf("set auditor key: %v", err)
+	}
+	if legacy := policywallet.ReadAuditorKey(st, addr); legacy != ([32]byte{}) {
+		t.Fatalf("legacy auditor key should be unset, got %x", legacy)
+	}
+
+	opening, err := cryptopriv.GenerateOpening()
+	if err != nil {
+		t.Fatalf("GenerateOpening: %v", err)
+	}
+	commitmentBytes, err := cryptopriv.PedersenCommitmentWithOpening(opening, amount)
+	if err != nil {
+		t.Fatalf("PedersenCommitmentWithOpening: %v", err)
+	}
+	handleBytes, err := cryptopriv.DecryptHandleWithOpening(senderPub[:], opening)
+	if err != nil {
+		t.Fatalf("DecryptHandleWithOpening: %v", err)
+	}
+	auditorHandleBytes, err := cryptopriv.DecryptHandleWithOpening(auditorPub[:], opening)
+	if err != nil {
+		t.Fatalf("DecryptHandleWithOpening(auditor): %v", err)
+	}
+	commitment := bytesToArray32(commitmentBytes)
+	handle := bytesToArray32(handleBytes)
+	auditorHandle := bytesToArray32(auditorHandleBytes)
+	ctx := priv.BuildShieldTranscriptContext(cfg.ChainID, 0, fee, amount, addr, commitment, handle, auditorHandle)
+	shieldProof, _, _, err := cryptopriv.ProveShieldProofWithContext(senderPub[:], amount, opening, ctx)
+	if err != nil {
+		t.Fatalf("ProveShieldProofWithContext: %v", err)
+	}
+	rangeProof, err := cryptopriv.ProveRangeProof(commitmentBytes, amount, opening)
+	if err != nil {
+		t.Fatalf("ProveRangeProof: %v", err)
+	}
+	_, dleqProof, err := priv.BuildAuditorHandle(opening, auditorPub, senderPub, handle, ctx)
+	if err != nil {
+		t.Fatalf("BuildAuditorHandle: %v", err)
+	}
+
+	stx := &types.ShieldTx{
+		ChainID:          big.NewInt(1337),
+		PrivNonce:        0,
+		UnoFee:           fee,
+		Pubkey:           senderPub,
+		Recipient:        senderPub,
+		UnoAmount:        amount,
+		Commitment:       commitment,
+		Handle:           handle,
+		AuditorHandle:    auditorHandle,
+		AuditorDLEQProof: dleqProof,
+	}
+	copy(stx.ShieldProof[:], shieldProof)
+	copy(stx.RangeProof[:], rangeProof)
+	sigHash := stx.SigningHash()
+	stx.S, stx.E, err = priv.SignSchnorr(senderPriv, sigHash[:])
+	if err != nil {
+		t.Fatalf("SignSchnorr: %v", err)
+	}
+
+	msg := makeShieldMsg(senderPub, stx, 2_000_000)
+	gp := new(GasPool).AddGas(msg.Gas())
+	res, err := ApplyMessage(context.Background(), ttlBlockContext(1, coinbase), cfg, msg, gp, st)
+	if err != nil {
+		t.Fatalf("ApplyMessage precheck error: %v", err)
+	}
+	if res.Err != nil {
+		t.Fatalf("expected successful shield, got %v", res.Err)
+	}
+	if got := priv.GetAccountState(st, addr).Nonce; got != 1 {
+		t.Fatalf("priv nonce = %d, want 1", got)
+	}
+}
+
 func TestApplyUnshield_InsufficientPublicForFee(t *testing.T) {
 	st := newTTLDeterminismState(t)
 	cfg := &params.ChainConfig{ChainID: big.NewInt(1337)}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/core/rawdb"
+	"github.com/tos-network/gtos/core/types"
+	"github.com/tos-network/gtos/tosdb"
+)
+
+const (
+	// privTxIndexThrottling is the time to wait between processing two
+	// consecutive priv tx history sections.
+	privTxIndexThrottling = 100 * time.Millisecond
+)
+
+// PrivTxIndexer implements a core.ChainIndexer, building an index from ElGamal
+// public keys to the privacy transactions (PrivTransfer, Shield, Unshield)
+// that touched them, so wallets can list their history without scanning the
+// whole chain.
+type PrivTxIndexer struct {
+	size  uint64         // section size to index
+	db    tosdb.Database // database instance to read bodies from and write the index into
+	batch tosdb.Batch    // pending index writes of the section being processed
+}
+
+// NewPrivTxIndexer returns a chain indexer that maintains the priv transaction
+// history index for the canonical chain.
+func NewPrivTxIndexer(db tosdb.Database, size, confirms uint64) *ChainIndexer {
+	backend := &PrivTxIndexer{
+		db:   db,
+		size: size,
+	}
+	table := rawdb.NewTable(db, string(rawdb.PrivTxIndexTablePrefix))
+
+	return NewChainIndexer(db, table, backend, size, confirms, privTxIndexThrottling, "privtxindex")
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new section. Any
+// references left behind by a previous (possibly reorged) pass over the same
+// section are dropped before the section is indexed again.
+func (p *PrivTxIndexer) Reset(ctx context.Context, section uint64, lastSectionHead common.Hash) error {
+	p.batch = p.db.NewBatch()
+	for number := section * p.size; number < (section+1)*p.size; number++ {
+		rawdb.DeletePrivTxIndexBlock(p.db, p.batch, number)
+	}
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, indexing the privacy
+// transactions of a single block.
+func (p *PrivTxIndexer) Process(ctx context.Context, header *types.Header) error {
+	number := header.Number.Uint64()
+	body := rawdb.ReadBody(p.db, header.Hash(), number)
+	if body == nil {
+		return nil
+	}
+	var items []rawdb.PrivTxIndexBlockItem
+	index := func(pubkey [32]byte, txIndex uint32, tx *types.Transaction, role uint8) {
+		rawdb.WritePrivTxIndexEntry(p.batch, pubkey, rawdb.PrivTxIndexEntry{
+			BlockNumber: number,
+			TxIndex:     txIndex,
+			TxHash:      tx.Hash(),
+			TxType:      tx.Type(),
+			Role:        role,
+		})
+		items = append(items, rawdb.PrivTxIndexBlockItem{Pubkey: pubkey, TxIndex: txIndex})
+	}
+	for i, tx := range body.Transactions {
+		txIndex := uint32(i)
+		switch tx.Type() {
+		case types.PrivTransferTxType:
+			inner := tx.PrivTransferInner()
+			if inner.From == inner.To {
+				index(inner.From, txIndex, tx, rawdb.PrivTxRoleSender|rawdb.PrivTxRoleReceiver)
+				continue
+			}
+			index(inner.From, txIndex, tx, rawdb.PrivTxRoleSender)
+			index(inner.To, txIndex, tx, rawdb.PrivTxRoleReceiver)
+		case types.ShieldTxType:
+			inner := tx.ShieldInner()
+			if inner.Pubkey == inner.Recipient {
+				index(inner.Pubkey, txIndex, tx, rawdb.PrivTxRoleSender|rawdb.PrivTxRoleReceiver)
+				continue
+			}
+			index(inner.Pubkey, txIndex, tx, rawdb.PrivTxRoleSender)
+			index(inner.Recipient, txIndex, tx, rawdb.PrivTxRoleReceiver)
+		case types.UnshieldTxType:
+			index(tx.UnshieldInner().Pubkey, txIndex, tx, rawdb.PrivTxRoleSender)
+		}
+	}
+	if len(items) > 0 {
+		rawdb.WritePrivTxIndexBlock(p.batch, number, items)
+	}
+	return nil
+}
+
+// Commit implements core.ChainIndexerBackend, flushing the section's index
+// entries into the database.
+func (p *PrivTxIndexer) Commit() error {
+	return p.batch.Write()
+}
+
+// Prune returns an empty error since we don't support pruning here.
+func (p *PrivTxIndexer) Prune(threshold uint64) error {
+	return nil
+}
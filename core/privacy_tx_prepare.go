@@ -309,7 +309,7 @@ func verifyPreparedUnshieldProofs(utx *types.UnshieldTx, zeroedCt priv.Ciphertex
 	return priv.VerifySingleRangeProof(utx.SourceCommitment, utx.RangeProof[:])
 }
 
-func preparePrivacyTxState(chainID *big.Int, statedb vm.StateDB, tx *types.Transaction) (preparedPrivacyTx, error) {
+func preparePrivacyTxState(chainID *big.Int, blockNumber uint64, statedb vm.StateDB, tx *types.Transaction) (preparedPrivacyTx, error) {
 	// Privacy terminal access validation: if the sender has privacy terminal
 	// policies configured (policy wallet owner is set), enforce terminal rules.
 	// Accounts without a policy wallet are unaffected (backward-compatible).
@@ -323,19 +323,19 @@ func preparePrivacyTxState(chainID *big.Int, statedb vm.StateDB, tx *types.Trans
 		if ptx == nil {
 			return nil, errors.New("priv: message does not contain PrivTransferTx")
 		}
-		return preparePrivTransferState(chainID, statedb, tx, ptx)
+		return preparePrivTransferState(chainID, blockNumber, statedb, tx, ptx)
 	case types.ShieldTxType:
 		stx := tx.ShieldInner()
 		if stx == nil {
 			return nil, errors.New("priv: message does not contain ShieldTx")
 		}
-		return prepareShieldState(chainID, statedb, tx, stx)
+		return prepareShieldState(chainID, blockNumber, statedb, tx, stx)
 	case types.UnshieldTxType:
 		utx := tx.UnshieldInner()
 		if utx == nil {
 			return nil, errors.New("priv: message does not contain UnshieldTx")
 		}
-		return prepareUnshieldState(chainID, statedb, tx, utx)
+		return prepareUnshieldState(chainID, blockNumber, statedb, tx, utx)
 	default:
 		return nil, ErrTxTypeNotSupported
 	}
@@ -402,7 +402,7 @@ func validatePrivacyTerminalIfConfigured(statedb vm.StateDB, tx *types.Transacti
 	return policywallet.ValidatePrivacyTerminalAccess(statedb, senderAddr, terminalClass, trustTier, actionType, value)
 }
 
-func preparePrivTransferState(chainID *big.Int, statedb vm.StateDB, tx *types.Transaction, ptx *types.PrivTransferTx) (*preparedPrivTransferTx, error) {
+func preparePrivTransferState(chainID *big.Int, blockNumber uint64, statedb vm.StateDB, tx *types.Transaction, ptx *types.PrivTransferTx) (*preparedPrivTransferTx, error) {
 	fromAddr := ptx.FromAddress()
 	toAddr := ptx.ToAddress()
 
@@ -432,26 +432,6 @@ func preparePrivTransferState(chainID *big.Int, statedb vm.StateDB, tx *types.Tr
 		return nil, errInvalidPrivSchnorrSignature
 	}
 
-	// Validate auditor handle if auditor key is configured.
-	auditorKey := policywallet.ReadAuditorKey(statedb, fromAddr)
-	var zeroKey [32]byte
-	if auditorKey != zeroKey {
-		if ptx.AuditorHandle == zeroKey {
-			return nil, fmt.Errorf("priv: auditor key configured but AuditorHandle is zero")
-		}
-		if len(ptx.AuditorDLEQProof) != 96 {
-			return nil, fmt.Errorf("priv: auditor DLEQ proof must be 96 bytes")
-		}
-	} else {
-		// No auditor key: auditor fields must be empty
-		if ptx.AuditorHandle != zeroKey {
-			return nil, fmt.Errorf("priv: AuditorHandle set but no auditor key configured")
-		}
-		if len(ptx.AuditorDLEQProof) > 0 {
-			return nil, fmt.Errorf("priv: AuditorDLEQProof set but no auditor key configured")
-		}
-	}
-
 	senderState := priv.GetAccountState(statedb, fromAddr)
 	receiverState := priv.GetAccountState(statedb, toAddr)
 	if senderState.Version == math.MaxUint64 || receiverState.Version == math.MaxUint64 {
@@ -475,16 +455,14 @@ func preparePrivTransferState(chainID *big.Int, statedb vm.StateDB, tx *types.Tr
 		senderCt, receiverCt,
 		ptx.SourceCommitment,
 		ptx.AuditorHandle,
+		extraAuditorHandles(ptx)...,
 	)
-	// Verify auditor handle DLEQ if present.
-	if auditorKey != zeroKey {
-		if err := priv.VerifyAuditorHandleDLEQ(
-			ptx.AuditorHandle, ptx.ReceiverHandle,
-			auditorKey, ptx.To,
-			ptx.AuditorDLEQProof, transcriptCtx,
-		); err != nil {
-			return nil, fmt.Errorf("priv: auditor handle DLEQ verification failed: %w", err)
-		}
+	// Validate the auditor handles against the sender's auditor key sets.
+	if err := verifyPrivTransferAuditors(
+		policywallet.AcceptedAuditorKeySets(statedb, fromAddr, blockNumber),
+		ptx, transcriptCtx,
+	); err != nil {
+		return nil, err
 	}
 
 	outputCt, err := priv.AddScalarToCiphertext(senderCt, ptx.UnoFeeLimit)
@@ -508,7 +486,7 @@ func preparePrivTransferState(chainID *big.Int, statedb vm.StateDB, tx *types.Tr
 	}, nil
 }
 
-func prepareShieldState(chainID *big.Int, statedb vm.StateDB, tx *types.Transaction, stx *types.ShieldTx) (*preparedShieldTx, error) {
+func prepareShieldState(chainID *big.Int, blockNumber uint64, statedb vm.StateDB, tx *types.Transaction, stx *types.ShieldTx) (*preparedShieldTx, error) {
 	senderAddr := stx.DerivedAddress()
 	recipientAddr := stx.RecipientAddress()
 
@@ -533,25 +511,6 @@ func prepareShieldState(chainID *big.Int, statedb vm.StateDB, tx *types.Transact
 		return nil, errInvalidPrivSchnorrSignature
 	}
 
-	// Validate auditor handle if auditor key is configured.
-	shieldAuditorKey := policywallet.ReadAuditorKey(statedb, senderAddr)
-	var shieldZeroKey [32]byte
-	if shieldAuditorKey != shieldZeroKey {
-		if stx.AuditorHandle == shieldZeroKey {
-			return nil, fmt.Errorf("priv: auditor key configured but AuditorHandle is zero")
-		}
-		if len(stx.AuditorDLEQProof) != 96 {
-			return nil, fmt.Errorf("priv: auditor DLEQ proof must be 96 bytes")
-		}
-	} else {
-		if stx.AuditorHandle != shieldZeroKey {
-			return nil, fmt.Errorf("priv: AuditorHandle set but no auditor key configured")
-		}
-		if len(stx.AuditorDLEQProof) > 0 {
-			return nil, fmt.Errorf("priv: AuditorDLEQProof set but no auditor key configured")
-		}
-	}
-
 	recipientState := priv.GetAccountState(statedb, recipientAddr)
 	if recipientState.Version == math.MaxUint64 {
 		return nil, priv.ErrVersionOverflow
@@ -568,15 +527,14 @@ func prepareShieldState(chainID *big.Int, statedb vm.StateDB, tx *types.Transact
 		stx.AuditorHandle,
 	)
 
-	// Verify auditor handle DLEQ if present.
-	if shieldAuditorKey != shieldZeroKey {
-		if err := priv.VerifyAuditorHandleDLEQ(
-			stx.AuditorHandle, stx.Handle,
-			shieldAuditorKey, stx.Recipient,
-			stx.AuditorDLEQProof, shieldTranscriptCtx,
-		); err != nil {
-			return nil, fmt.Errorf("priv: auditor handle DLEQ verification failed: %w", err)
-		}
+	// Validate the auditor handle against the sender's primary auditor key.
+	if err := verifyPrimaryAuditorHandle(
+		primaryAuditorKeys(statedb, senderAddr, blockNumber),
+		stx.AuditorHandle, stx.AuditorDLEQProof,
+		stx.Handle, stx.Recipient,
+		shieldTranscriptCtx,
+	); err != nil {
+		return nil, err
 	}
 
 	return &preparedShieldTx{
@@ -589,7 +547,7 @@ func prepareShieldState(chainID *big.Int, statedb vm.StateDB, tx *types.Transact
 	}, nil
 }
 
-func prepareUnshieldState(chainID *big.Int, statedb vm.StateDB, tx *types.Transaction, utx *types.UnshieldTx) (*preparedUnshieldTx, error) {
+func prepareUnshieldState(chainID *big.Int, blockNumber uint64, statedb vm.StateDB, tx *types.Transaction, utx *types.UnshieldTx) (*preparedUnshieldTx, error) {
 	senderAddr := utx.DerivedAddress()
 	recipientAddr := utx.Recipient
 
@@ -616,25 +574,6 @@ func prepareUnshieldState(chainID *big.Int, statedb vm.StateDB, tx *types.Transa
 		return nil, errInvalidPrivSchnorrSignature
 	}
 
-	// Validate auditor handle if auditor key is configured.
-	unshieldAuditorKey := policywallet.ReadAuditorKey(statedb, senderAddr)
-	var unshieldZeroKey [32]byte
-	if unshieldAuditorKey != unshieldZeroKey {
-		if utx.AuditorHandle == unshieldZeroKey {
-			return nil, fmt.Errorf("priv: auditor key configured but AuditorHandle is zero")
-		}
-		if len(utx.AuditorDLEQProof) != 96 {
-			return nil, fmt.Errorf("priv: auditor DLEQ proof must be 96 bytes")
-		}
-	} else {
-		if utx.AuditorHandle != unshieldZeroKey {
-			return nil, fmt.Errorf("priv: AuditorHandle set but no auditor key configured")
-		}
-		if len(utx.AuditorDLEQProof) > 0 {
-			return nil, fmt.Errorf("priv: AuditorDLEQProof set but no auditor key configured")
-		}
-	}
-
 	accountState := priv.GetAccountState(statedb, senderAddr)
 	if accountState.Version == math.MaxUint64 {
 		return nil, priv.ErrVersionOverflow
@@ -660,15 +599,14 @@ func prepareUnshieldState(chainID *big.Int, statedb vm.StateDB, tx *types.Transa
 		utx.AuditorHandle,
 	)
 
-	// Verify auditor handle DLEQ if present.
-	if unshieldAuditorKey != unshieldZeroKey {
-		if err := priv.VerifyAuditorHandleDLEQ(
-			utx.AuditorHandle, accountState.Ciphertext.Handle,
-			unshieldAuditorKey, utx.Pubkey,
-			utx.AuditorDLEQProof, unshieldTranscriptCtx,
-		); err != nil {
-			return nil, fmt.Errorf("priv: auditor handle DLEQ verification failed: %w", err)
-		}
+	// Validate the auditor handle against the sender's primary auditor key.
+	if err := verifyPrimaryAuditorHandle(
+		primaryAuditorKeys(statedb, senderAddr, blockNumber),
+		utx.AuditorHandle, utx.AuditorDLEQProof,
+		accountState.Ciphertext.Handle, utx.Pubkey,
+		unshieldTranscriptCtx,
+	); err != nil {
+		return nil, err
 	}
 
 	return &preparedUnshieldTx{
@@ -683,6 +621,121 @@ func prepareUnshieldState(chainID *big.Int, statedb vm.StateDB, tx *types.Transa
 	}, nil
 }
 
+// extraAuditorHandles returns the handles of ptx.ExtraAuditors for binding
+// into the transfer transcript context.
+func extraAuditorHandles(ptx *types.PrivTransferTx) [][32]byte {
+	if len(ptx.ExtraAuditors) == 0 {
+		return nil
+	}
+	out := make([][32]byte, len(ptx.ExtraAuditors))
+	for i, a := range ptx.ExtraAuditors {
+		out[i] = a.Handle
+	}
+	return out
+}
+
+// primaryAuditorKeys returns the candidate primary (first-slot) auditor keys
+// for a shield/unshield sender at blockNumber: the key currently active and,
+// within the rotation grace window, the key it replaced. A zero key means no
+// auditor is configured for that candidate.
+func primaryAuditorKeys(statedb vm.StateDB, addr common.Address, blockNumber uint64) [][32]byte {
+	sets := policywallet.AcceptedAuditorKeySets(statedb, addr, blockNumber)
+	keys := make([][32]byte, 0, len(sets))
+	for _, set := range sets {
+		var key [32]byte
+		if len(set) > 0 {
+			key = set[0]
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// verifyPrimaryAuditorHandle accepts the auditor handle if it is valid for any
+// of the candidate auditor keys. The error for the first (currently active)
+// candidate is returned when none match.
+func verifyPrimaryAuditorHandle(candidates [][32]byte, handle [32]byte, proof []byte, receiverHandle, receiverPub [32]byte, transcriptCtx []byte) error {
+	var firstErr error
+	for _, key := range candidates {
+		err := verifyAuditorHandle(key, handle, proof, receiverHandle, receiverPub, transcriptCtx)
+		if err == nil {
+			return nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// verifyPrivTransferAuditors accepts the transfer's auditor handles if they
+// match any of the accepted auditor key sets, one handle per key in slot order.
+// The error for the first (currently active) set is returned when none match.
+func verifyPrivTransferAuditors(keySets [][][32]byte, ptx *types.PrivTransferTx, transcriptCtx []byte) error {
+	var firstErr error
+	for _, keys := range keySets {
+		err := verifyAuditorHandleSet(keys, ptx, transcriptCtx)
+		if err == nil {
+			return nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func verifyAuditorHandleSet(keys [][32]byte, ptx *types.PrivTransferTx, transcriptCtx []byte) error {
+	var primary [32]byte
+	if len(keys) > 0 {
+		primary = keys[0]
+	}
+	if err := verifyAuditorHandle(primary, ptx.AuditorHandle, ptx.AuditorDLEQProof, ptx.ReceiverHandle, ptx.To, transcriptCtx); err != nil {
+		return err
+	}
+	wantExtra := 0
+	if len(keys) > 1 {
+		wantExtra = len(keys) - 1
+	}
+	if len(ptx.ExtraAuditors) != wantExtra {
+		return fmt.Errorf("priv: %d auditor keys configured but %d auditor handles supplied", len(keys), 1+len(ptx.ExtraAuditors))
+	}
+	for i, extra := range ptx.ExtraAuditors {
+		if extra.Handle == ([32]byte{}) {
+			return fmt.Errorf("priv: extra auditor handle %d is zero", i)
+		}
+		if err := verifyAuditorHandle(keys[i+1], extra.Handle, extra.DLEQProof, ptx.ReceiverHandle, ptx.To, transcriptCtx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyAuditorHandle checks a single auditor handle against auditorKey. When
+// auditorKey is zero the handle and proof must both be empty.
+func verifyAuditorHandle(auditorKey, handle [32]byte, proof []byte, receiverHandle, receiverPub [32]byte, transcriptCtx []byte) error {
+	var zeroKey [32]byte
+	if auditorKey == zeroKey {
+		if handle != zeroKey {
+			return fmt.Errorf("priv: AuditorHandle set but no auditor key configured")
+		}
+		if len(proof) > 0 {
+			return fmt.Errorf("priv: AuditorDLEQProof set but no auditor key configured")
+		}
+		return nil
+	}
+	if handle == zeroKey {
+		return fmt.Errorf("priv: auditor key configured but AuditorHandle is zero")
+	}
+	if len(proof) != 96 {
+		return fmt.Errorf("priv: auditor DLEQ proof must be 96 bytes")
+	}
+	if err := priv.VerifyAuditorHandleDLEQ(handle, receiverHandle, auditorKey, receiverPub, proof, transcriptCtx); err != nil {
+		return fmt.Errorf("priv: auditor handle DLEQ verification failed: %w", err)
+	}
+	return nil
+}
+
 func accountStateEqual(a, b priv.AccountState) bool {
 	return a.Ciphertext == b.Ciphertext && a.Version == b.Version && a.Nonce == b.Nonce
 }
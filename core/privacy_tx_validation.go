@@ -11,33 +11,42 @@ import (
 
 var errInvalidPrivSchnorrSignature = errors.New("priv: invalid Schnorr signature")
 
-func applyPrivacyTxState(chainID *big.Int, statedb vm.StateDB, tx *types.Transaction) (*big.Int, error) {
+// privacyBlockNumber converts the block number privacy transactions are
+// validated against to uint64. A nil number (pre-genesis callers) maps to 0.
+func privacyBlockNumber(number *big.Int) uint64 {
+	if number == nil {
+		return 0
+	}
+	return number.Uint64()
+}
+
+func applyPrivacyTxState(chainID *big.Int, blockNumber uint64, statedb vm.StateDB, tx *types.Transaction) (*big.Int, error) {
 	switch tx.Type() {
 	case types.PrivTransferTxType:
 		ptx := tx.PrivTransferInner()
 		if ptx == nil {
 			return common.Big0, errors.New("priv: message does not contain PrivTransferTx")
 		}
-		return applyPrivTransferState(chainID, statedb, ptx)
+		return applyPrivTransferState(chainID, blockNumber, statedb, ptx)
 	case types.ShieldTxType:
 		stx := tx.ShieldInner()
 		if stx == nil {
 			return common.Big0, errors.New("priv: message does not contain ShieldTx")
 		}
-		return applyShieldState(chainID, statedb, stx)
+		return applyShieldState(chainID, blockNumber, statedb, stx)
 	case types.UnshieldTxType:
 		utx := tx.UnshieldInner()
 		if utx == nil {
 			return common.Big0, errors.New("priv: message does not contain UnshieldTx")
 		}
-		return applyUnshieldState(chainID, statedb, utx)
+		return applyUnshieldState(chainID, blockNumber, statedb, utx)
 	default:
 		return common.Big0, ErrTxTypeNotSupported
 	}
 }
 
-func applyPrivTransferState(chainID *big.Int, statedb vm.StateDB, ptx *types.PrivTransferTx) (*big.Int, error) {
-	prepared, err := preparePrivTransferState(chainID, statedb, types.NewTx(ptx), ptx)
+func applyPrivTransferState(chainID *big.Int, blockNumber uint64, statedb vm.StateDB, ptx *types.PrivTransferTx) (*big.Int, error) {
+	prepared, err := preparePrivTransferState(chainID, blockNumber, statedb, types.NewTx(ptx), ptx)
 	if err != nil {
 		return common.Big0, err
 	}
@@ -47,8 +56,8 @@ func applyPrivTransferState(chainID *big.Int, statedb vm.StateDB, ptx *types.Pri
 	return prepared.ApplyState(statedb)
 }
 
-func applyShieldState(chainID *big.Int, statedb vm.StateDB, stx *types.ShieldTx) (*big.Int, error) {
-	prepared, err := prepareShieldState(chainID, statedb, types.NewTx(stx), stx)
+func applyShieldState(chainID *big.Int, blockNumber uint64, statedb vm.StateDB, stx *types.ShieldTx) (*big.Int, error) {
+	prepared, err := prepareShieldState(chainID, blockNumber, statedb, types.NewTx(stx), stx)
 	if err != nil {
 		return common.Big0, err
 	}
@@ -58,8 +67,8 @@ func applyShieldState(chainID *big.Int, statedb vm.StateDB, stx *types.ShieldTx)
 	return prepared.ApplyState(statedb)
 }
 
-func applyUnshieldState(chainID *big.Int, statedb vm.StateDB, utx *types.UnshieldTx) (*big.Int, error) {
-	prepared, err := prepareUnshieldState(chainID, statedb, types.NewTx(utx), utx)
+func applyUnshieldState(chainID *big.Int, blockNumber uint64, statedb vm.StateDB, utx *types.UnshieldTx) (*big.Int, error) {
+	prepared, err := prepareUnshieldState(chainID, blockNumber, statedb, types.NewTx(utx), utx)
 	if err != nil {
 		return common.Big0, err
 	}
@@ -0,0 +1,131 @@
+package rawdb
+
+import (
+	"encoding/binary"
+
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/log"
+	"github.com/tos-network/gtos/tosdb"
+)
+
+// Roles an ElGamal public key can play in an indexed priv transaction.
+const (
+	PrivTxRoleSender   uint8 = 1 << 0
+	PrivTxRoleReceiver uint8 = 1 << 1
+)
+
+// PrivTxIndexEntry references a privacy transaction (PrivTransfer, Shield or
+// Unshield) that touched an ElGamal public key. Only public positional data
+// is stored; amounts stay encrypted in the transaction itself.
+type PrivTxIndexEntry struct {
+	BlockNumber uint64
+	TxIndex     uint32
+	TxHash      common.Hash
+	TxType      uint8
+	Role        uint8
+}
+
+// privTxIndexValueLength is the encoded size of an entry value:
+// tx hash (32) + tx type (1) + role (1).
+const privTxIndexValueLength = common.HashLength + 2
+
+// privTxIndexBlockItemLength is the size of one reverse-index item in a block
+// record: pubkey (32) + tx index (uint32 big endian).
+const privTxIndexBlockItemLength = 32 + 4
+
+// WritePrivTxIndexEntry stores a history reference for pubkey.
+func WritePrivTxIndexEntry(db tosdb.KeyValueWriter, pubkey [32]byte, entry PrivTxIndexEntry) {
+	value := make([]byte, 0, privTxIndexValueLength)
+	value = append(value, entry.TxHash.Bytes()...)
+	value = append(value, entry.TxType, entry.Role)
+	if err := db.Put(privTxIndexKey(pubkey, entry.BlockNumber, entry.TxIndex), value); err != nil {
+		log.Crit("Failed to store priv tx index entry", "err", err)
+	}
+}
+
+// DeletePrivTxIndexEntry removes the history reference for pubkey at the
+// given transaction position.
+func DeletePrivTxIndexEntry(db tosdb.KeyValueWriter, pubkey [32]byte, number uint64, txIndex uint32) {
+	if err := db.Delete(privTxIndexKey(pubkey, number, txIndex)); err != nil {
+		log.Crit("Failed to delete priv tx index entry", "err", err)
+	}
+}
+
+// ReadPrivTxIndexEntries returns up to limit history references for pubkey in
+// ascending chain order, starting at (fromBlock, fromTxIndex) inclusive. The
+// second return value reports whether more entries follow the last one.
+func ReadPrivTxIndexEntries(db tosdb.Iteratee, pubkey [32]byte, fromBlock uint64, fromTxIndex uint32, limit int) ([]PrivTxIndexEntry, bool) {
+	prefix := privTxIndexKeyPrefix(pubkey)
+	start := binary.BigEndian.AppendUint32(encodeBlockNumber(fromBlock), fromTxIndex)
+	it := db.NewIterator(prefix, start)
+	defer it.Release()
+
+	var entries []PrivTxIndexEntry
+	for it.Next() {
+		key, value := it.Key(), it.Value()
+		if len(key) != len(prefix)+12 || len(value) != privTxIndexValueLength {
+			continue
+		}
+		if len(entries) == limit {
+			return entries, true
+		}
+		entries = append(entries, PrivTxIndexEntry{
+			BlockNumber: binary.BigEndian.Uint64(key[len(prefix):]),
+			TxIndex:     binary.BigEndian.Uint32(key[len(prefix)+8:]),
+			TxHash:      common.BytesToHash(value[:common.HashLength]),
+			TxType:      value[common.HashLength],
+			Role:        value[common.HashLength+1],
+		})
+	}
+	return entries, false
+}
+
+// PrivTxIndexBlockItem is one (pubkey, tx index) pair recorded for a block so
+// that its history references can be removed again on reorg.
+type PrivTxIndexBlockItem struct {
+	Pubkey  [32]byte
+	TxIndex uint32
+}
+
+// WritePrivTxIndexBlock stores the reverse index of the references written
+// for block number.
+func WritePrivTxIndexBlock(db tosdb.KeyValueWriter, number uint64, items []PrivTxIndexBlockItem) {
+	value := make([]byte, 0, len(items)*privTxIndexBlockItemLength)
+	for _, item := range items {
+		value = append(value, item.Pubkey[:]...)
+		value = binary.BigEndian.AppendUint32(value, item.TxIndex)
+	}
+	if err := db.Put(privTxIndexBlockKey(number), value); err != nil {
+		log.Crit("Failed to store priv tx index block record", "err", err)
+	}
+}
+
+// ReadPrivTxIndexBlock returns the reverse index recorded for block number.
+func ReadPrivTxIndexBlock(db tosdb.KeyValueReader, number uint64) []PrivTxIndexBlockItem {
+	data, _ := db.Get(privTxIndexBlockKey(number))
+	if len(data)%privTxIndexBlockItemLength != 0 {
+		log.Error("Invalid priv tx index block record", "number", number, "len", len(data))
+		return nil
+	}
+	items := make([]PrivTxIndexBlockItem, 0, len(data)/privTxIndexBlockItemLength)
+	for len(data) > 0 {
+		var item PrivTxIndexBlockItem
+		copy(item.Pubkey[:], data[:32])
+		item.TxIndex = binary.BigEndian.Uint32(data[32:privTxIndexBlockItemLength])
+		items = append(items, item)
+		data = data[privTxIndexBlockItemLength:]
+	}
+	return items
+}
+
+// DeletePrivTxIndexBlock removes every history reference recorded in db for
+// block number, together with its reverse index, by queueing the deletions
+// into batch.
+func DeletePrivTxIndexBlock(db tosdb.KeyValueReader, batch tosdb.KeyValueWriter, number uint64) {
+	for _, item := range ReadPrivTxIndexBlock(db, number) {
+		DeletePrivTxIndexEntry(batch, item.Pubkey, number, item.TxIndex)
+	}
+	if err := batch.Delete(privTxIndexBlockKey(number)); err != nil {
+		log.Crit("Failed to delete priv tx index block record", "err", err)
+	}
+}
@@ -0,0 +1,80 @@
+package rawdb
+
+import (
+	"testing"
+
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/core/types"
+)
+
+func TestPrivTxIndexStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	var alice, bob [32]byte
+	alice[0], bob[0] = 0xa1, 0xb0
+
+	write := func(number uint64, txIndex uint32, role uint8, keys ...[32]byte) {
+		var items []PrivTxIndexBlockItem
+		for _, key := range keys {
+			WritePrivTxIndexEntry(db, key, PrivTxIndexEntry{
+				BlockNumber: number,
+				TxIndex:     txIndex,
+				TxHash:      common.BytesToHash([]byte{byte(number), byte(txIndex)}),
+				TxType:      types.PrivTransferTxType,
+				Role:        role,
+			})
+			items = append(items, PrivTxIndexBlockItem{Pubkey: key, TxIndex: txIndex})
+		}
+		WritePrivTxIndexBlock(db, number, append(ReadPrivTxIndexBlock(db, number), items...))
+	}
+	write(1, 0, PrivTxRoleSender, alice)
+	write(1, 3, PrivTxRoleReceiver, alice, bob)
+	write(7, 2, PrivTxRoleSender|PrivTxRoleReceiver, alice)
+	write(300, 0, PrivTxRoleReceiver, bob)
+
+	entries, more := ReadPrivTxIndexEntries(db, alice, 0, 0, 10)
+	if more || len(entries) != 3 {
+		t.Fatalf("alice history: have %d entries (more=%v), want 3", len(entries), more)
+	}
+	if entries[0].BlockNumber != 1 || entries[0].TxIndex != 0 || entries[2].BlockNumber != 7 {
+		t.Fatalf("alice history out of order: %+v", entries)
+	}
+	if entries[2].Role != PrivTxRoleSender|PrivTxRoleReceiver {
+		t.Fatalf("role mismatch: have %d", entries[2].Role)
+	}
+	if entries[1].TxHash != common.BytesToHash([]byte{1, 3}) || entries[1].TxType != types.PrivTransferTxType {
+		t.Fatalf("entry payload mismatch: %+v", entries[1])
+	}
+
+	// Pagination resumes from an inclusive cursor.
+	page, more := ReadPrivTxIndexEntries(db, alice, 0, 0, 2)
+	if !more || len(page) != 2 {
+		t.Fatalf("first page: have %d entries (more=%v), want 2 with more", len(page), more)
+	}
+	page, more = ReadPrivTxIndexEntries(db, alice, 1, 4, 2)
+	if more || len(page) != 1 || page[0].BlockNumber != 7 {
+		t.Fatalf("second page: have %+v (more=%v)", page, more)
+	}
+
+	// Keys do not leak into each other's history.
+	entries, _ = ReadPrivTxIndexEntries(db, bob, 0, 0, 10)
+	if len(entries) != 2 || entries[1].BlockNumber != 300 {
+		t.Fatalf("bob history: have %+v", entries)
+	}
+
+	// Dropping a block removes every reference recorded for it.
+	batch := db.NewBatch()
+	DeletePrivTxIndexBlock(db, batch, 1)
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write batch: %v", err)
+	}
+	if entries, _ = ReadPrivTxIndexEntries(db, alice, 0, 0, 10); len(entries) != 1 || entries[0].BlockNumber != 7 {
+		t.Fatalf("alice history after delete: have %+v", entries)
+	}
+	if entries, _ = ReadPrivTxIndexEntries(db, bob, 0, 0, 10); len(entries) != 1 || entries[0].BlockNumber != 300 {
+		t.Fatalf("bob history after delete: have %+v", entries)
+	}
+	if items := ReadPrivTxIndexBlock(db, 1); len(items) != 0 {
+		t.Fatalf("block record not deleted: %+v", items)
+	}
+}
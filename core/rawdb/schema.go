@@ -104,12 +104,16 @@ var (
 	CodePrefix            = []byte("c") // CodePrefix + code hash -> account code
 	skeletonHeaderPrefix  = []byte("S") // skeletonHeaderPrefix + num (uint64 big endian) -> header
 
+	privTxIndexPrefix      = []byte("pk") // privTxIndexPrefix + pubkey (32 bytes) + num (uint64 big endian) + tx index (uint32 big endian) -> priv tx reference
+	privTxIndexBlockPrefix = []byte("pb") // privTxIndexBlockPrefix + num (uint64 big endian) -> pubkeys indexed in the block
+
 	PreimagePrefix = []byte("secure-key-")  // PreimagePrefix + hash -> preimage
 	configPrefix   = []byte("tos-config-")  // config prefix for the db
 	genesisPrefix  = []byte("tos-genesis-") // genesis state prefix for the db
 
 	// Chain index prefixes (use `i` + single byte to avoid mixing data types).
-	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
+	BloomBitsIndexPrefix   = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
+	PrivTxIndexTablePrefix = []byte("iP") // PrivTxIndexTablePrefix is the data table of the priv tx history indexer to track its progress
 
 	preimageCounter    = metrics.NewRegisteredCounter("db/preimage/total", nil)
 	preimageHitCounter = metrics.NewRegisteredCounter("db/preimage/hits", nil)
@@ -130,6 +134,22 @@ func encodeBlockNumber(number uint64) []byte {
 	return enc
 }
 
+// privTxIndexKeyPrefix = privTxIndexPrefix + pubkey
+func privTxIndexKeyPrefix(pubkey [32]byte) []byte {
+	return append(append([]byte{}, privTxIndexPrefix...), pubkey[:]...)
+}
+
+// privTxIndexKey = privTxIndexPrefix + pubkey + num (uint64 big endian) + tx index (uint32 big endian)
+func privTxIndexKey(pubkey [32]byte, number uint64, txIndex uint32) []byte {
+	key := append(privTxIndexKeyPrefix(pubkey), encodeBlockNumber(number)...)
+	return binary.BigEndian.AppendUint32(key, txIndex)
+}
+
+// privTxIndexBlockKey = privTxIndexBlockPrefix + num (uint64 big endian)
+func privTxIndexBlockKey(number uint64) []byte {
+	return append(append([]byte{}, privTxIndexBlockPrefix...), encodeBlockNumber(number)...)
+}
+
 // headerKeyPrefix = headerPrefix + num (uint64 big endian)
 func headerKeyPrefix(number uint64) []byte {
 	return append(headerPrefix, encodeBlockNumber(number)...)
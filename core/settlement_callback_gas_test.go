@@ -0,0 +1,100 @@
+package core
+
+import (
+	"context"
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/core/rawdb"
+	"github.com/tos-network/gtos/core/state"
+	"github.com/tos-network/gtos/core/types"
+	"github.com/tos-network/gtos/core/vm"
+	"github.com/tos-network/gtos/crypto"
+	"github.com/tos-network/gtos/params"
+	"github.com/tos-network/gtos/settlement"
+	"github.com/tos-network/gtos/sysaction"
+)
+
+// TestSettlementCallbackGasChargedToTx runs SETTLEMENT_EXECUTE_CALLBACK
+// through ApplyMessage and checks that the gas the callback target uses is
+// part of the transaction's gas, not only the flat system action cost.
+func TestSettlementCallbackGasChargedToTx(t *testing.T) {
+	config := &params.ChainConfig{ChainID: big.NewInt(1)}
+	creator := common.HexToAddress("0xAB40")
+	target := common.HexToAddress("0xCC40")
+	txHash := common.HexToHash("0xdeadbeef")
+
+	st, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatalf("state.New: %v", err)
+	}
+	st.AddBalance(creator, new(big.Int).Mul(big.NewInt(1000), new(big.Int).SetUint64(params.TOS)))
+	st.SetCode(target, []byte(`local s = 0 for i = 1, 2000 do s = s + i end`))
+	st.Finalise(false)
+
+	blockCtx := vm.BlockContext{
+		CanTransfer: CanTransfer,
+		Transfer:    Transfer,
+		Coinbase:    common.HexToAddress("0xCAFE"),
+		BlockNumber: big.NewInt(20),
+		Time:        big.NewInt(60),
+		GasLimit:    30_000_000,
+	}
+	apply := func(gas uint64, kind sysaction.ActionKind, payload interface{}) (*ExecutionResult, []byte) {
+		t.Helper()
+		data, err := sysaction.MakeSysAction(kind, payload)
+		if err != nil {
+			t.Fatalf("MakeSysAction(%s): %v", kind, err)
+		}
+		to := params.SystemActionAddress
+		msg := types.NewMessage(creator, &to, st.GetNonce(creator), big.NewInt(0), gas,
+			params.TxPrice(), params.TxPrice(), params.TxPrice(), data, nil, false)
+		res, err := ApplyMessage(context.Background(), blockCtx, config, msg, new(GasPool).AddGas(gas), st)
+		if err != nil {
+			t.Fatalf("apply %s: %v", kind, err)
+		}
+		return res, data
+	}
+
+	res, _ := apply(1_000_000, sysaction.ActionSettlementRegisterCallback, settlement.RegisterCallbackPayload{
+		TxHash:       txHash.Hex(),
+		CallbackType: "on_settle",
+		Target:       target.Hex(),
+		MaxGas:       300_000,
+		TTLBlocks:    100,
+	})
+	if res.Err != nil {
+		t.Fatalf("register: %v", res.Err)
+	}
+	var nonce [8]byte
+	binary.BigEndian.PutUint64(nonce[:], settlement.ReadCallbackCount(st)-1)
+	id := crypto.Keccak256Hash(creator.Bytes(), txHash[:], []byte("on_settle"), nonce[:])
+	execute := settlement.ExecuteCallbackPayload{CallbackID: id.Hex()}
+
+	// Without room for max_gas the execution is rejected.
+	res, _ = apply(params.SysActionGas+200_000, sysaction.ActionSettlementExecuteCallback, execute)
+	if res.Err != settlement.ErrCallbackGasTooLow {
+		t.Fatalf("low gas: want %v, got %v", settlement.ErrCallbackGasTooLow, res.Err)
+	}
+
+	res, data := apply(1_000_000, sysaction.ActionSettlementExecuteCallback, execute)
+	if res.Err != nil {
+		t.Fatalf("execute: %v", res.Err)
+	}
+	if status := settlement.ReadCallbackStatus(st, id); status != settlement.StatusExecuted {
+		t.Fatalf("callback status: %q", status)
+	}
+	callbackGas := settlement.ReadCallbackGasUsed(st, id)
+	if callbackGas == 0 {
+		t.Fatal("callback target used no gas")
+	}
+	intrinsic, err := IntrinsicGas(data, nil, false, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := intrinsic + params.SysActionGas + callbackGas; res.UsedGas != want {
+		t.Fatalf("receipt gas used: want %d (incl. %d callback gas), got %d", want, callbackGas, res.UsedGas)
+	}
+}
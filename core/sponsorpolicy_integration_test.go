@@ -0,0 +1,89 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/core/state"
+	"github.com/tos-network/gtos/core/types"
+	"github.com/tos-network/gtos/params"
+	"github.com/tos-network/gtos/sponsorpolicy"
+	"github.com/tos-network/gtos/sysaction"
+)
+
+// registerSponsorPolicy registers p for sponsor through the system action
+// handler and returns its hash.
+func registerSponsorPolicy(t *testing.T, st *state.StateDB, sponsor common.Address, p sponsorpolicy.RegisterPolicyPayload) common.Hash {
+	t.Helper()
+	data, err := sysaction.MakeSysAction(sysaction.ActionSponsorPolicyRegister, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := &sysaction.Context{From: sponsor, Value: new(big.Int), BlockNumber: big.NewInt(1), StateDB: st}
+	if err := sysaction.ExecuteWithContext(ctx, data); err != nil {
+		t.Fatalf("register policy: %v", err)
+	}
+	policy, err := sponsorpolicy.PolicyFromPayload(sponsor, &p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return policy.Hash()
+}
+
+func TestSponsoredTxPolicyEnforced(t *testing.T) {
+	from := common.HexToAddress("0xA200")
+	sponsor := common.HexToAddress("0xB200")
+	to := common.HexToAddress("0xC200")
+	other := common.HexToAddress("0xC201")
+	cfg := &params.ChainConfig{ChainID: big.NewInt(1337), SponsorPolicyBlock: big.NewInt(0)}
+
+	txPrice := big.NewInt(1e9)
+	gasLimit := uint64(21_000)
+	gasCost := new(big.Int).Mul(new(big.Int).SetUint64(gasLimit), txPrice)
+	st := newPWState(t, map[common.Address]*big.Int{
+		sponsor: new(big.Int).Mul(gasCost, big.NewInt(10)),
+	})
+
+	// The budget covers one transaction a day.
+	budget := new(big.Int).Add(gasCost, big.NewInt(1))
+	policyHash := registerSponsorPolicy(t, st, sponsor, sponsorpolicy.RegisterPolicyPayload{
+		Targets:     []common.Address{to},
+		DailyBudget: budget.String(),
+	})
+
+	apply := func(nonce, sponsorNonce uint64, target common.Address, hash common.Hash) error {
+		msg := types.NewMessage(from, &target, nonce, big.NewInt(0), gasLimit, txPrice, txPrice, big.NewInt(0), nil, nil, false).
+			WithSponsor(sponsor, sponsorNonce, 0, hash)
+		_, err := ApplyMessage(context.Background(), pwBlockCtx(), cfg, msg, new(GasPool).AddGas(gasLimit), st)
+		return err
+	}
+
+	if err := apply(0, 0, other, policyHash); !errors.Is(err, sponsorpolicy.ErrTargetNotAllowed) {
+		t.Fatalf("expected ErrTargetNotAllowed, got %v", err)
+	}
+	if err := apply(0, 0, to, common.HexToHash("0x01")); !errors.Is(err, sponsorpolicy.ErrPolicyNotFound) {
+		t.Fatalf("expected ErrPolicyNotFound, got %v", err)
+	}
+	if err := apply(0, 0, to, policyHash); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	day := pwBlockCtx().Time.Uint64() / sponsorpolicy.DayMs
+	if spent := sponsorpolicy.ReadSpent(st, policyHash, from, day); spent.Cmp(gasCost) != 0 {
+		t.Fatalf("spent = %s, want %s", spent, gasCost)
+	}
+	if err := apply(1, 1, to, policyHash); !errors.Is(err, sponsorpolicy.ErrBudgetExceeded) {
+		t.Fatalf("expected ErrBudgetExceeded, got %v", err)
+	}
+	// Transactions without a policy hash are unaffected.
+	if err := apply(1, 1, other, common.Hash{}); err != nil {
+		t.Fatalf("unbound sponsored tx: %v", err)
+	}
+	// Before SponsorPolicyBlock the policy hash is ignored.
+	cfg.SponsorPolicyBlock = big.NewInt(2)
+	if err := apply(2, 2, other, common.HexToHash("0x01")); err != nil {
+		t.Fatalf("pre-activation bound tx: %v", err)
+	}
+}
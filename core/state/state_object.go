@@ -285,6 +285,31 @@ func (s *stateObject) setState(key, value common.Hash) {
 	s.dirtyStorage[key] = value
 }
 
+// slotDelta returns the net number of storage slots created by the pending and
+// dirty writes, relative to the committed values cached in originStorage.
+// Every written key has its committed value cached, since SetState reads it.
+func (s *stateObject) slotDelta() int64 {
+	var delta int64
+	count := func(key, value common.Hash) {
+		origin := s.originStorage[key]
+		switch {
+		case origin == (common.Hash{}) && value != (common.Hash{}):
+			delta++
+		case origin != (common.Hash{}) && value == (common.Hash{}):
+			delta--
+		}
+	}
+	for key, value := range s.pendingStorage {
+		if _, dirty := s.dirtyStorage[key]; !dirty {
+			count(key, value)
+		}
+	}
+	for key, value := range s.dirtyStorage {
+		count(key, value)
+	}
+	return delta
+}
+
 // finalise moves all dirty storage slots into the pending area to be hashed or
 // committed later. It is invoked at the end of every transaction.
 func (s *stateObject) finalise(prefetch bool) {
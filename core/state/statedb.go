@@ -348,6 +348,23 @@ func (s *StateDB) GetCommittedState(addr common.Address, hash common.Hash) commo
 	return common.Hash{}
 }
 
+// StorageSlotDeltas returns, for every account whose storage changed since the
+// last IntermediateRoot (normally the start of the block), the net number of
+// slots it created: slots set from zero minus slots cleared to zero. Accounts
+// without a net change are omitted.
+func (s *StateDB) StorageSlotDeltas() map[common.Address]int64 {
+	deltas := make(map[common.Address]int64)
+	for addr, obj := range s.stateObjects {
+		if obj.deleted {
+			continue
+		}
+		if delta := obj.slotDelta(); delta != 0 {
+			deltas[addr] = delta
+		}
+	}
+	return deltas
+}
+
 // Database retrieves the low level database supporting the lower level trie ops.
 func (s *StateDB) Database() Database {
 	return s.db
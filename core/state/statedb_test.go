@@ -955,3 +955,32 @@ func TestFlushOrderDataLoss(t *testing.T) {
 		}
 	}
 }
+
+func TestStorageSlotDeltas(t *testing.T) {
+	state, _ := New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	addr := common.BytesToAddress([]byte("lease"))
+	one, two, three := common.Hash{1}, common.Hash{2}, common.Hash{3}
+	state.SetNonce(addr, 1)
+	state.SetState(addr, one, common.Hash{0xaa})
+	state.SetState(addr, two, common.Hash{0xbb})
+	root, _ := state.Commit(false)
+	state, _ = New(root, state.Database(), nil)
+
+	// A new slot in one transaction, an overwrite and a clear in the next.
+	state.SetState(addr, three, common.Hash{0xcc})
+	state.Finalise(true)
+	state.SetState(addr, one, common.Hash{0xdd})
+	state.SetState(addr, two, common.Hash{})
+	if got := state.StorageSlotDeltas()[addr]; got != 0 {
+		t.Fatalf("delta = %d, want 0", got)
+	}
+	state.SetState(addr, common.Hash{4}, common.Hash{0xee})
+	state.Finalise(true)
+	if got := state.StorageSlotDeltas()[addr]; got != 1 {
+		t.Fatalf("delta = %d, want 1", got)
+	}
+	state.IntermediateRoot(true)
+	if deltas := state.StorageSlotDeltas(); len(deltas) != 0 {
+		t.Fatalf("deltas after IntermediateRoot = %v, want none", deltas)
+	}
+}
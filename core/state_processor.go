@@ -28,8 +28,11 @@ import (
 	"github.com/tos-network/gtos/core/state"
 	"github.com/tos-network/gtos/core/types"
 	"github.com/tos-network/gtos/core/vm"
+	"github.com/tos-network/gtos/gateway"
 	"github.com/tos-network/gtos/params"
+	"github.com/tos-network/gtos/settlement"
 	"github.com/tos-network/gtos/task"
+	"github.com/tos-network/gtos/tns"
 )
 
 // StateProcessor is a basic Processor, which takes care of transitioning
@@ -152,32 +155,62 @@ func ExecuteTransactions(
 	return parallel.ExecuteParallel(config, blockCtx, statedb, txs, blockHash, blockNumber, gp, msgs, applyMsgFn)
 }
 
-// RunScheduledTasks executes all tasks due at blockNum against statedb.
-// Called by both Process() (block validation) and the miner (block building)
-// before user transactions are applied, so the resulting state root is identical
-// in both paths.
+// RunScheduledTasks executes all tasks and settlement callbacks due at
+// blockNum against statedb, deactivates gateways that missed their
+// heartbeats and, at TNSExpiryBlock, sets the expiry of earlier TNS names. Called by both Process() (block validation) and
+// the miner (block building) before user transactions are applied, so the
+// resulting state root is identical in both paths.
 func RunScheduledTasks(statedb *state.StateDB, blockCtx vm.BlockContext, chainCfg *params.ChainConfig, blockNum uint64, gp *GasPool) (uint64, error) {
-	_, gasUsed, err := task.ProcessDueTasks(statedb, blockCtx, chainCfg, blockNum,
-		func(db vm.StateDB, bCtx vm.BlockContext, cfg *params.ChainConfig,
-			caller, target common.Address, calldata []byte, gasLimit uint64,
-		) task.ExecResult {
-			if gp != nil && gasLimit > gp.Gas() {
-				return task.ExecResult{Err: ErrGasLimitReached, Fatal: true}
+	exec := func(db vm.StateDB, bCtx vm.BlockContext, cfg *params.ChainConfig,
+		caller, target common.Address, calldata []byte, gasLimit uint64,
+	) task.ExecResult {
+		if gp != nil && gasLimit > gp.Gas() {
+			return task.ExecResult{Err: ErrGasLimitReached, Fatal: true}
+		}
+		ctx := vm.CallCtx{From: caller, To: target, Data: calldata}
+		code := db.GetCode(target)
+		gasUsed, _, _, err := vm.Execute(db, bCtx, cfg, ctx, code, gasLimit)
+		if gp != nil {
+			if gasUsed > gasLimit {
+				gasUsed = gasLimit
+			}
+			if err := gp.SubGas(gasUsed); err != nil {
+				return task.ExecResult{GasUsed: gasUsed, Err: ErrGasLimitReached, Fatal: true}
+			}
+		}
+		return task.ExecResult{GasUsed: gasUsed, Err: err}
+	}
+	tns.ActivateExpiry(statedb, blockNum, chainCfg)
+	gateway.SeedLegacyGateways(statedb, blockNum, chainCfg)
+	gateway.ProcessGatewayLiveness(statedb, blockNum)
+
+	_, gasUsed, err := task.ProcessDueTasks(statedb, blockCtx, chainCfg, blockNum, exec)
+	if err != nil {
+		return gasUsed, err
+	}
+
+	// Settlement callbacks run through the same executor. Callbacks that do
+	// not fit the remaining block gas move to the next block; a fatal result
+	// fails only the callback inside settlement, so it is captured here and
+	// invalidates the block.
+	callbackGas := uint64(cmath.MaxUint64)
+	if gp != nil {
+		callbackGas = gp.Gas()
+	}
+	var fatal error
+	settlement.ProcessDueCallbacks(statedb, blockNum, blockCtx.Coinbase, callbackGas,
+		func(caller, target common.Address, input []byte, gasLimit uint64) (uint64, error) {
+			if fatal != nil {
+				return 0, fatal
 			}
-			ctx := vm.CallCtx{From: caller, To: target, Data: calldata}
-			code := db.GetCode(target)
-			gasUsed, _, _, err := vm.Execute(db, bCtx, cfg, ctx, code, gasLimit)
-			if gp != nil {
-				if gasUsed > gasLimit {
-					gasUsed = gasLimit
-				}
-				if err := gp.SubGas(gasUsed); err != nil {
-					return task.ExecResult{GasUsed: gasUsed, Err: ErrGasLimitReached, Fatal: true}
-				}
+			res := exec(statedb, blockCtx, chainCfg, caller, target, input, gasLimit)
+			gasUsed += res.GasUsed
+			if res.Fatal {
+				fatal = res.Err
 			}
-			return task.ExecResult{GasUsed: gasUsed, Err: err}
+			return res.GasUsed, res.Err
 		})
-	return gasUsed, err
+	return gasUsed, fatal
 }
 
 // TxAsMessageWithAccountSigner converts a transaction to a Message using the
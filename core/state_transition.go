@@ -32,6 +32,7 @@ import (
 	"github.com/tos-network/gtos/lease"
 	"github.com/tos-network/gtos/params"
 	"github.com/tos-network/gtos/policywallet"
+	"github.com/tos-network/gtos/sponsorpolicy"
 	"github.com/tos-network/gtos/sysaction"
 )
 
@@ -228,6 +229,14 @@ func (st *StateTransition) to() common.Address {
 	return *st.msg.To()
 }
 
+// blockTimeMs returns the block time in milliseconds, or 0 if unset.
+func (st *StateTransition) blockTimeMs() uint64 {
+	if st.blockCtx.Time == nil {
+		return 0
+	}
+	return st.blockCtx.Time.Uint64()
+}
+
 func (st *StateTransition) gasPayer() common.Address {
 	if st.msg != nil && st.msg.IsSponsored() {
 		return st.msg.Sponsor()
@@ -287,6 +296,16 @@ func (st *StateTransition) preCheck() error {
 			if expiry := st.msg.SponsorExpiry(); expiry != 0 && st.blockCtx.Time != nil && st.blockCtx.Time.Sign() > 0 && st.blockCtx.Time.Uint64() > expiry {
 				return fmt.Errorf("sponsor authorization expired: sponsor %v expiry %d block_time %d", st.msg.Sponsor().Hex(), expiry, st.blockCtx.Time.Uint64())
 			}
+			// Once sponsor policies are active, a non-zero SponsorPolicyHash
+			// must name an active policy of the sponsor that covers this
+			// transaction.
+			if st.chainConfig.IsSponsorPolicy(st.blockCtx.BlockNumber) {
+				maxCost := new(big.Int).Mul(new(big.Int).SetUint64(st.msg.Gas()), st.txPrice)
+				if err := sponsorpolicy.Check(st.state, st.msg.SponsorPolicyHash(), st.msg.Sponsor(), st.msg.From(),
+					st.msg.To(), st.msg.Data(), st.msg.Gas(), maxCost, st.blockTimeMs()); err != nil {
+					return fmt.Errorf("%w: sponsor %v policy %v", err, st.msg.Sponsor().Hex(), st.msg.SponsorPolicyHash().Hex())
+				}
+			}
 		}
 		// Sender must always be an EOA — contract addresses have no private key.
 		if codeHash := st.state.GetCodeHash(st.msg.From()); codeHash != emptyCodeHash && codeHash != (common.Hash{}) {
@@ -477,7 +496,7 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 					st.gas = 0
 					vmerr = vm.ErrOutOfGas
 				} else {
-					gasUsed, execErr := sysaction.Execute(msg, st.state, st.blockCtx.BlockNumber, st.chainConfig)
+					gasUsed, execErr := sysaction.Execute(msg, st.state, st.blockCtx, st.chainConfig, st.gas, st.callContract)
 					st.gas -= gasUsed
 					vmerr = execErr
 				}
@@ -518,6 +537,12 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	// Refund gas — apply strict cap (gasUsed/5).
 	st.refundGas(params.RefundQuotientStrict)
 
+	// Charge the gas the sponsor paid to the sender's policy budget.
+	if msg.IsSponsored() && !msg.IsFake() && st.chainConfig.IsSponsorPolicy(st.blockCtx.BlockNumber) {
+		cost := new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.txPrice)
+		sponsorpolicy.RecordSpend(st.state, msg.SponsorPolicyHash(), msg.From(), cost, st.blockTimeMs())
+	}
+
 	// Pay miner fee by fixed txPrice — skip for simulated calls (DoCall/DoEstimateGas).
 	// IsFake() is true for all simulated messages; avoiding the credit prevents
 	// spurious coinbase balance changes that break trace/diff outputs.
@@ -550,6 +575,13 @@ func (st *StateTransition) ensureLeaseCallable(addr common.Address) error {
 	return lease.CheckCallable(st.state, addr, st.blockCtx.BlockNumber.Uint64(), st.chainConfig)
 }
 
+// callContract is the sysaction.ContractCaller handed to system action
+// handlers; it runs target through the transaction's LVM.
+func (st *StateTransition) callContract(caller, target common.Address, input []byte, gasLimit uint64) (uint64, error) {
+	_, leftOverGas, err := st.lvm.Call(vm.ContractAccount(caller), target, input, gasLimit, nil)
+	return gasLimit - leftOverGas, err
+}
+
 func (st *StateTransition) rejectTombstonedAddress(addr common.Address) error {
 	return lease.RejectTombstoned(st.state, addr)
 }
@@ -771,7 +803,7 @@ func (st *StateTransition) applyPrivTransfer() error {
 	if ptx == nil {
 		return errors.New("priv: message does not contain PrivTransferTx")
 	}
-	feeWei, err := applyPrivTransferState(st.chainConfig.ChainID, st.state, ptx)
+	feeWei, err := applyPrivTransferState(st.chainConfig.ChainID, privacyBlockNumber(st.blockCtx.BlockNumber), st.state, ptx)
 	if err != nil {
 		return err
 	}
@@ -830,7 +862,7 @@ func (st *StateTransition) applyShield() error {
 	if stx == nil {
 		return errors.New("priv: message does not contain ShieldTx")
 	}
-	feeWei, err := applyShieldState(st.chainConfig.ChainID, st.state, stx)
+	feeWei, err := applyShieldState(st.chainConfig.ChainID, privacyBlockNumber(st.blockCtx.BlockNumber), st.state, stx)
 	if err != nil {
 		return err
 	}
@@ -852,7 +884,7 @@ func (st *StateTransition) applyUnshield() error {
 	if utx == nil {
 		return errors.New("priv: message does not contain UnshieldTx")
 	}
-	feeWei, err := applyUnshieldState(st.chainConfig.ChainID, st.state, utx)
+	feeWei, err := applyUnshieldState(st.chainConfig.ChainID, privacyBlockNumber(st.blockCtx.BlockNumber), st.state, utx)
 	if err != nil {
 		return err
 	}
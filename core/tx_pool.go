@@ -20,6 +20,7 @@ import (
 	"github.com/tos-network/gtos/log"
 	"github.com/tos-network/gtos/metrics"
 	"github.com/tos-network/gtos/params"
+	"github.com/tos-network/gtos/sponsorpolicy"
 )
 
 const (
@@ -233,6 +234,7 @@ type TxPool struct {
 	pendingNonces        *txNoncer      // Pending state tracking virtual nonces
 	sponsorPendingNonces *sponsorNoncer // Pending state tracking virtual sponsor nonces
 	currentMaxGas        uint64         // Current gas limit for transaction caps
+	currentNumber        uint64         // Number of the current head block
 
 	locals  *accountSet // Set of local transaction to exempt from eviction rules
 	journal *txJournal  // Journal of local transaction to back up to disk
@@ -651,11 +653,15 @@ func (pool *TxPool) validateTxWithState(tx *types.Transaction, from common.Addre
 		if err := pool.validateSponsoredNonce(tx, from, sponsor); err != nil {
 			return err
 		}
+		now := uint64(time.Now().UnixMilli())
 		sponsorExpiry, _ := tx.SponsorExpiry()
-		if sponsorExpiry != 0 {
-			now := uint64(time.Now().UnixMilli())
-			if now > sponsorExpiry {
-				return ErrInvalidSponsor
+		if sponsorExpiry != 0 && now > sponsorExpiry {
+			return ErrInvalidSponsor
+		}
+		if pool.chainconfig.IsSponsorPolicy(new(big.Int).SetUint64(pool.pendingBlockNumber())) {
+			policyHash, _ := tx.SponsorPolicyHash()
+			if err := sponsorpolicy.Check(pool.currentState, policyHash, sponsor, from, tx.To(), tx.Data(), tx.Gas(), gasCost, now); err != nil {
+				return err
 			}
 		}
 	} else if pool.currentState.GetBalance(from).Cmp(tx.Cost()) < 0 {
@@ -1484,6 +1490,7 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	pool.pendingNonces = newTxNoncer(statedb)
 	pool.sponsorPendingNonces = newSponsorNoncer(statedb)
 	pool.currentMaxGas = newHead.GasLimit
+	pool.currentNumber = newHead.Number.Uint64()
 
 	// Inject any transactions discarded due to reorgs
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
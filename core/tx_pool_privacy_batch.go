@@ -78,7 +78,7 @@ func (b *txPoolPrivBatch) buildState(tx *types.Transaction) *state.StateDB {
 		candidates = append(candidates, acceptedTx)
 	}
 	sortPrivacyReplayTxs(candidates)
-	replayPrivacyTxs(b.pool.chainconfig.ChainID, statedb, candidates)
+	replayPrivacyTxs(b.pool.chainconfig.ChainID, b.pool.pendingBlockNumber(), statedb, candidates)
 	return statedb
 }
 
@@ -118,7 +118,7 @@ func sortPrivacyReplayTxs(txs []*types.Transaction) {
 	})
 }
 
-func replayPrivacyTxs(chainID *big.Int, statedb *state.StateDB, txs []*types.Transaction) {
+func replayPrivacyTxs(chainID *big.Int, blockNumber uint64, statedb *state.StateDB, txs []*types.Transaction) {
 	remaining := make([]*types.Transaction, len(txs))
 	copy(remaining, txs)
 	for {
@@ -128,7 +128,7 @@ func replayPrivacyTxs(chainID *big.Int, statedb *state.StateDB, txs []*types.Tra
 				continue
 			}
 			snap := statedb.Snapshot()
-			if _, err := applyPrivacyTxState(chainID, statedb, tx); err != nil {
+			if _, err := applyPrivacyTxState(chainID, blockNumber, statedb, tx); err != nil {
 				statedb.RevertToSnapshot(snap)
 				continue
 			}
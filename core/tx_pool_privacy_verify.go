@@ -7,6 +7,7 @@ import (
 	"github.com/tos-network/gtos/core/priv"
 	"github.com/tos-network/gtos/core/types"
 	"github.com/tos-network/gtos/core/vm"
+	"github.com/tos-network/gtos/policywallet"
 )
 
 func (pool *TxPool) addPreparedPrivacyTx(prepared preparedPrivacyTx, local bool) (bool, error) {
@@ -40,6 +41,13 @@ func (pool *TxPool) preparePrivacyTx(tx *types.Transaction, from common.Address,
 	}
 }
 
+// pendingBlockNumber returns the number of the block pooled transactions
+// would be included in, used to resolve scheduled auditor keys and fork
+// activations.
+func (pool *TxPool) pendingBlockNumber() uint64 {
+	return pool.currentNumber + 1
+}
+
 func (pool *TxPool) preparePrivTransferTx(tx *types.Transaction, from common.Address, local bool, statedb vm.StateDB) (*preparedPrivTransferTx, error) {
 	if uint64(tx.Size()) > txMaxSize {
 		return nil, ErrOversizedData
@@ -66,6 +74,14 @@ func (pool *TxPool) preparePrivTransferTx(tx *types.Transaction, from common.Add
 	if len(ptx.AuditorDLEQProof) > 0 && len(ptx.AuditorDLEQProof) != 96 {
 		return nil, priv.ErrInvalidPayload
 	}
+	if len(ptx.ExtraAuditors) >= policywallet.MaxAuditors {
+		return nil, priv.ErrInvalidPayload
+	}
+	for _, extra := range ptx.ExtraAuditors {
+		if len(extra.DLEQProof) != 96 {
+			return nil, priv.ErrInvalidPayload
+		}
+	}
 	if ptx.UnoFee > ptx.UnoFeeLimit {
 		return nil, priv.ErrFeeLimitExceeded
 	}
@@ -89,7 +105,7 @@ func (pool *TxPool) preparePrivTransferTx(tx *types.Transaction, from common.Add
 	if !local && tx.TxPrice().Cmp(pool.txPrice) < 0 {
 		return nil, ErrUnderpriced
 	}
-	prepared, err := preparePrivacyTxState(pool.chainconfig.ChainID, statedb, tx)
+	prepared, err := preparePrivacyTxState(pool.chainconfig.ChainID, pool.pendingBlockNumber(), statedb, tx)
 	if err != nil {
 		return nil, mapPreparedPrivacyError(err)
 	}
@@ -140,7 +156,7 @@ func (pool *TxPool) prepareShieldTx(tx *types.Transaction, from common.Address,
 	if !local && tx.TxPrice().Cmp(pool.txPrice) < 0 {
 		return nil, ErrUnderpriced
 	}
-	prepared, err := preparePrivacyTxState(pool.chainconfig.ChainID, statedb, tx)
+	prepared, err := preparePrivacyTxState(pool.chainconfig.ChainID, pool.pendingBlockNumber(), statedb, tx)
 	if err != nil {
 		return nil, mapPreparedPrivacyError(err)
 	}
@@ -191,7 +207,7 @@ func (pool *TxPool) prepareUnshieldTx(tx *types.Transaction, from common.Address
 	if !local && tx.TxPrice().Cmp(pool.txPrice) < 0 {
 		return nil, ErrUnderpriced
 	}
-	prepared, err := preparePrivacyTxState(pool.chainconfig.ChainID, statedb, tx)
+	prepared, err := preparePrivacyTxState(pool.chainconfig.ChainID, pool.pendingBlockNumber(), statedb, tx)
 	if err != nil {
 		return nil, mapPreparedPrivacyError(err)
 	}
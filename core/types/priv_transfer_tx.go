@@ -45,6 +45,18 @@ type PrivTransferTx struct {
 	// ElGamal Schnorr signature
 	S [32]byte
 	E [32]byte
+
+	// Additional auditor handles for wallets with more than one auditor slot
+	// configured. AuditorHandle/AuditorDLEQProof above carry the first active
+	// auditor; ExtraAuditors carry the remaining ones in slot order.
+	ExtraAuditors []PrivAuditorHandle `rlp:"optional"`
+}
+
+// PrivAuditorHandle is an auditor decrypt handle r·PK_audit together with the
+// DLEQ proof that it shares randomness with the receiver handle.
+type PrivAuditorHandle struct {
+	Handle    [32]byte
+	DLEQProof []byte
 }
 
 // copy creates a deep copy of the transaction data and initializes all fields.
@@ -74,6 +86,15 @@ func (tx *PrivTransferTx) copy() TxData {
 	cpy.RangeProof = common.CopyBytes(tx.RangeProof)
 	cpy.AuditorDLEQProof = common.CopyBytes(tx.AuditorDLEQProof)
 	cpy.EncryptedMemo = common.CopyBytes(tx.EncryptedMemo)
+	if tx.ExtraAuditors != nil {
+		cpy.ExtraAuditors = make([]PrivAuditorHandle, len(tx.ExtraAuditors))
+		for i, a := range tx.ExtraAuditors {
+			cpy.ExtraAuditors[i] = PrivAuditorHandle{
+				Handle:    a.Handle,
+				DLEQProof: common.CopyBytes(a.DLEQProof),
+			}
+		}
+	}
 	return cpy
 }
 
@@ -121,12 +142,25 @@ func (tx *PrivTransferTx) ToAddress() common.Address {
 	return common.BytesToAddress(crypto.Keccak256(tx.To[:]))
 }
 
+// AuditorHandles returns every auditor handle carried by the transaction in
+// slot order, starting with the primary AuditorHandle. Returns nil when the
+// transaction carries no auditor handle.
+func (tx *PrivTransferTx) AuditorHandles() []PrivAuditorHandle {
+	if tx.AuditorHandle == ([32]byte{}) && len(tx.ExtraAuditors) == 0 {
+		return nil
+	}
+	out := make([]PrivAuditorHandle, 0, 1+len(tx.ExtraAuditors))
+	out = append(out, PrivAuditorHandle{Handle: tx.AuditorHandle, DLEQProof: tx.AuditorDLEQProof})
+	return append(out, tx.ExtraAuditors...)
+}
+
 // SigningHash returns the hash that the ElGamal Schnorr signature (S, E) signs.
-// It covers all transaction fields except S and E themselves.
+// It covers all transaction fields except S and E themselves. ExtraAuditors
+// is only appended when non-empty so single-auditor hashes are unchanged.
 func (tx *PrivTransferTx) SigningHash() common.Hash {
 	sha := crypto.NewKeccakState()
 	sha.Write([]byte{PrivTransferTxType})
-	rlp.Encode(sha, []interface{}{
+	fields := []interface{}{
 		tx.ChainID,
 		tx.PrivNonce,
 		tx.UnoFee,
@@ -145,7 +179,11 @@ func (tx *PrivTransferTx) SigningHash() common.Hash {
 		tx.EncryptedMemo,
 		tx.MemoSenderHandle,
 		tx.MemoReceiverHandle,
-	})
+	}
+	if len(tx.ExtraAuditors) > 0 {
+		fields = append(fields, tx.ExtraAuditors)
+	}
+	rlp.Encode(sha, fields)
 	var h common.Hash
 	sha.Read(h[:])
 	return h
@@ -388,3 +388,50 @@ func TestMessageTypeAndPrivTransferInner(t *testing.T) {
 		t.Fatalf("SignerTx message PrivTransferInner() should be nil")
 	}
 }
+
+func TestPrivTransferTxExtraAuditorsRoundTrip(t *testing.T) {
+	inner := samplePrivTransferTx()
+	legacyHash := inner.SigningHash()
+	legacyEnc, err := NewTx(inner).MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+
+	inner.AuditorHandle = [32]byte{0x01}
+	inner.AuditorDLEQProof = bytes.Repeat([]byte{0x55}, 96)
+	inner.ExtraAuditors = []PrivAuditorHandle{
+		{Handle: [32]byte{0x02}, DLEQProof: bytes.Repeat([]byte{0x66}, 96)},
+		{Handle: [32]byte{0x03}, DLEQProof: bytes.Repeat([]byte{0x77}, 96)},
+	}
+	data, err := NewTx(inner).MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+	var decoded Transaction
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary failed: %v", err)
+	}
+	ptx := decoded.inner.(*PrivTransferTx)
+	handles := ptx.AuditorHandles()
+	if len(handles) != 3 || handles[0].Handle != inner.AuditorHandle || handles[2].Handle != [32]byte{0x03} {
+		t.Fatalf("AuditorHandles() = %+v", handles)
+	}
+	if !bytes.Equal(handles[1].DLEQProof, inner.ExtraAuditors[0].DLEQProof) {
+		t.Fatalf("extra auditor DLEQ proof mismatch")
+	}
+	if ptx.SigningHash() == legacyHash {
+		t.Fatalf("signing hash must cover extra auditors")
+	}
+
+	// Transactions without extra auditors keep their legacy encoding.
+	var legacy Transaction
+	if err := legacy.UnmarshalBinary(legacyEnc); err != nil {
+		t.Fatalf("legacy UnmarshalBinary failed: %v", err)
+	}
+	if got := legacy.inner.(*PrivTransferTx).SigningHash(); got != legacyHash {
+		t.Fatalf("legacy signing hash changed: %s != %s", got.Hex(), legacyHash.Hex())
+	}
+	if legacy.inner.(*PrivTransferTx).AuditorHandles() != nil {
+		t.Fatalf("legacy tx must report no auditor handles")
+	}
+}
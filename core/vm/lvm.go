@@ -913,23 +913,23 @@ func executeRuntimeSettlement(
 		}
 		blockCtx.Transfer(stateDB, contractAddr, recipient, amount)
 		amountRef = publicAmountRef(amount)
-	case settlement.ModeUnoTransfer, settlement.ModeRefundUno:
+	case settlement.ModeUnoTransfer, settlement.ModeRefundUno, settlement.ModeEscrowReleaseUno:
 		ctStr, ok := amountArg.(lua.LString)
 		if !ok {
 			return common.Hash{}, fmt.Errorf("ciphertext mode requires hex string payload")
 		}
-		ct, err := parseCiphertextHex(string(ctStr))
-		if err != nil {
-			return common.Hash{}, err
-		}
-		if err := applyUnoTransfer(stateDB, recipient, ct); err != nil {
-			return common.Hash{}, err
-		}
-		amountRef = unoAmountRef(ct)
-	case settlement.ModeEscrowReleaseUno:
-		ctStr, ok := amountArg.(lua.LString)
-		if !ok {
-			return common.Hash{}, fmt.Errorf("ciphertext mode requires hex string payload")
+		// Escrow modes accept a confidential escrow id (bytes32) in place of a
+		// ciphertext: the locked ciphertext is released to the payee or
+		// refunded to the payer without revealing the amount.
+		if mode != settlement.ModeUnoTransfer {
+			if escrowID, err := parseBytes32Hex(string(ctStr)); err == nil {
+				ct, err := settleUnoEscrowTo(stateDB, contractAddr, escrowID, mode == settlement.ModeRefundUno, recipient)
+				if err != nil {
+					return common.Hash{}, err
+				}
+				amountRef = unoAmountRef(ct)
+				break
+			}
 		}
 		ct, err := parseCiphertextHex(string(ctStr))
 		if err != nil {
@@ -1035,6 +1035,11 @@ func finalizeRuntimeReceiptFailure(stateDB StateDB, blockCtx BlockContext, recei
 	settlement.WriteRuntimeReceiptProofRef(stateDB, receiptRef, proofRef)
 	settlement.WriteRuntimeReceiptStatus(stateDB, receiptRef, settlement.ReceiptStatusFailure)
 	settlement.WriteRuntimeReceiptFinalizedAt(stateDB, receiptRef, currentBlockMillis(blockCtx))
+	var blockNum uint64
+	if blockCtx.BlockNumber != nil {
+		blockNum = blockCtx.BlockNumber.Uint64()
+	}
+	settlement.TriggerReceiptFailure(stateDB, receiptRef, blockNum)
 	return nil
 }
 
@@ -1446,7 +1451,9 @@ func Execute(stateDB StateDB, blockCtx BlockContext, chainConfig *params.ChainCo
 			}
 			proofRef = bytes32ToHash(proofRaw)
 		}
-		chargePrimGas(gasSStore * 2)
+		// Each linked on_fail callback is queued with two more writes.
+		linked := settlement.ReadLinkedCallbackCount(stateDB, bytes32ToHash(receiptRefRaw))
+		chargePrimGas(gasSStore * (2 + 2*linked))
 		if err := finalizeRuntimeReceiptFailure(stateDB, blockCtx, bytes32ToHash(receiptRefRaw), bytes32ToHash(failureRefRaw), proofRef); err != nil {
 			L.RaiseError("tos.receipt_failure: %v", err)
 			return 0
@@ -3092,28 +3099,47 @@ func Execute(stateDB StateDB, blockCtx BlockContext, chainConfig *params.ChainCo
 
 	// ── KYC primitives ────────────────────────────────────────────────────────
 
+	// kycBlock is the block at which KYC expiry is evaluated.
+	kycBlock := func() uint64 {
+		if blockCtx.BlockNumber == nil {
+			return 0
+		}
+		return blockCtx.BlockNumber.Uint64()
+	}
+
 	// tos.kyc(addr, field) → value | nil
-	//   Reads a KYC field for addr. Gas cost: params.KYCLoadGas (100, one SLOAD).
+	//   Reads a KYC field for addr. Gas cost: params.KYCLoadGas (100) per SLOAD:
+	//   one for most fields, two for "active", which also reads the expiry.
 	//   Fields:
 	//     "level"  → uint256 (u16 bitmask of verified tiers)
 	//     "tier"   → uint256 (tier number 0–8)
-	//     "active" → bool (true iff status == KycActive)
+	//     "active" → bool (true iff status == KycActive and not expired)
+	//     "expiry" → uint256 (expiry block, 0 if the record never expires)
+	//     "issuer" → addrHex (committee member that set the record)
 	L.SetField(tosTable, "kyc", L.NewFunction(func(L *lua.LState) int {
-		chargePrimGas(params.KYCLoadGas)
 		addrStr := L.CheckString(1)
 		field := L.CheckString(2)
 		addr := common.HexToAddress(addrStr)
+		if field == "active" {
+			chargePrimGas(2 * params.KYCLoadGas)
+		} else {
+			chargePrimGas(params.KYCLoadGas)
+		}
 		switch field {
 		case "level":
 			L.Push(luBig(new(big.Int).SetUint64(uint64(kyc.ReadLevel(stateDB, addr)))))
 		case "tier":
 			L.Push(luBig(new(big.Int).SetUint64(uint64(kyc.TierOf(kyc.ReadLevel(stateDB, addr))))))
 		case "active":
-			if kyc.ReadStatus(stateDB, addr) == kyc.KycActive {
+			if kyc.ReadStatus(stateDB, addr, kycBlock()) == kyc.KycActive {
 				L.Push(lua.LTrue)
 			} else {
 				L.Push(lua.LFalse)
 			}
+		case "expiry":
+			L.Push(luBig(new(big.Int).SetUint64(kyc.ReadExpiry(stateDB, addr))))
+		case "issuer":
+			L.Push(lua.LString(kyc.ReadIssuer(stateDB, addr).Hex()))
 		default:
 			L.Push(lua.LNil)
 		}
@@ -3122,9 +3148,9 @@ func Execute(stateDB StateDB, blockCtx BlockContext, chainConfig *params.ChainCo
 
 	// tos.meetskyclevel(addr, required) → bool
 	//   Returns true if addr has Active KYC and level bitmask includes all bits in required.
-	//   Gas cost: params.KYCLoadGas.
+	//   Gas cost: 2 × params.KYCLoadGas (the record and its expiry).
 	L.SetField(tosTable, "meetskyclevel", L.NewFunction(func(L *lua.LState) int {
-		chargePrimGas(params.KYCLoadGas)
+		chargePrimGas(2 * params.KYCLoadGas)
 		addrStr := L.CheckString(1)
 		requiredBig := L.CheckUserData(2)
 		addr := common.HexToAddress(addrStr)
@@ -3132,7 +3158,7 @@ func Execute(stateDB StateDB, blockCtx BlockContext, chainConfig *params.ChainCo
 		if b, ok := requiredBig.Value.(*big.Int); ok {
 			required = uint16(b.Uint64())
 		}
-		if kyc.MeetsLevel(stateDB, addr, required) {
+		if kyc.MeetsLevel(stateDB, addr, required, kycBlock()) {
 			L.Push(lua.LTrue)
 		} else {
 			L.Push(lua.LFalse)
@@ -3140,16 +3166,87 @@ func Execute(stateDB StateDB, blockCtx BlockContext, chainConfig *params.ChainCo
 		return 1
 	}))
 
+	// tos.kyc_check(addr, minLevel, jurisdictions) → bool
+	//   Returns true if addr has active, unexpired KYC whose level includes all
+	//   bits of minLevel and whose jurisdiction set contains at least one of
+	//   jurisdictions (a table of ISO 3166-1 alpha-2 codes such as {"SG", "HK"}).
+	//   A nil or empty table accepts any jurisdiction.
+	//   Gas cost: 4 × params.KYCLoadGas.
+	L.SetField(tosTable, "kyc_check", L.NewFunction(func(L *lua.LState) int {
+		chargePrimGas(4 * params.KYCLoadGas)
+		addr := common.HexToAddress(L.CheckString(1))
+		var minLevel uint16
+		if b, ok := L.CheckUserData(2).Value.(*big.Int); ok {
+			minLevel = uint16(b.Uint64())
+		}
+		var codes []string
+		if tbl := L.OptTable(3, nil); tbl != nil {
+			for i := 1; i <= tbl.Len(); i++ {
+				codes = append(codes, strings.ToUpper(lua.LVAsString(tbl.RawGetInt(i))))
+			}
+		}
+		if kyc.Check(stateDB, addr, minLevel, codes, kycBlock()) {
+			L.Push(lua.LTrue)
+		} else {
+			L.Push(lua.LFalse)
+		}
+		return 1
+	}))
+
+	// tos.kyc_verify_tier(holderHex, minTier, proofHex) → bool
+	//   Verifies a private KYC tier proof (kyc.TierProof.Encode) showing that
+	//   holder has a committee-issued credential of at least minTier, without
+	//   revealing the tier. Nothing is written to state.
+	//   Gas cost: params.KYCTierProofGas.
+	L.SetField(tosTable, "kyc_verify_tier", L.NewFunction(func(L *lua.LState) int {
+		chargePrimGas(params.KYCTierProofGas)
+		holder := common.HexToAddress(L.CheckString(1))
+		var minTier uint8
+		if b, ok := L.CheckUserData(2).Value.(*big.Int); ok && b.IsUint64() && b.Uint64() <= uint64(kyc.MaxTier) {
+			minTier = uint8(b.Uint64())
+		}
+		proof, err := kyc.DecodeTierProof(common.FromHex(L.CheckString(3)))
+		if err == nil {
+			_, err = kyc.VerifyTierProof(stateDB, holder, minTier, proof)
+		}
+		if err == nil {
+			L.Push(lua.LTrue)
+		} else {
+			L.Push(lua.LFalse)
+		}
+		return 1
+	}))
+
+	// tos.kyc_proven_tier(holderHex) → uint256
+	//   Returns the minimum tier holder last proved with KYC_PROVE_TIER, or 0
+	//   if that attestation has expired or been invalidated.
+	//   Gas cost: 6 × params.KYCLoadGas (one per slot read).
+	L.SetField(tosTable, "kyc_proven_tier", L.NewFunction(func(L *lua.LState) int {
+		chargePrimGas(6 * params.KYCLoadGas)
+		holder := common.HexToAddress(L.CheckString(1))
+		L.Push(luBig(new(big.Int).SetUint64(uint64(kyc.ProvenTier(stateDB, holder, kycBlock())))))
+		return 1
+	}))
+
 	// ── TNS primitives ────────────────────────────────────────────────────────
 
+	// tnsBlock is the block at which name expiry is evaluated.
+	tnsBlock := func() uint64 {
+		if blockCtx.BlockNumber == nil {
+			return 0
+		}
+		return blockCtx.BlockNumber.Uint64()
+	}
+
 	// tos.tnsresolve(nameHashHex) → addrHex | nil
-	//   Resolves a name hash (hex string) to the registered address.
+	//   Resolves a name or subname hash (hex string) to the registered
+	//   address; expired names resolve to nil.
 	//   Gas cost: params.TNSLoadGas (200).
 	L.SetField(tosTable, "tnsresolve", L.NewFunction(func(L *lua.LState) int {
 		chargePrimGas(params.TNSLoadGas)
 		hashHex := L.CheckString(1)
 		nameHash := common.HexToHash(hashHex)
-		addr := tns.Resolve(stateDB, nameHash)
+		addr := tns.Resolve(stateDB, nameHash, tnsBlock())
 		if addr == (common.Address{}) {
 			L.Push(lua.LNil)
 		} else {
@@ -3165,7 +3262,7 @@ func Execute(stateDB StateDB, blockCtx BlockContext, chainConfig *params.ChainCo
 		chargePrimGas(params.TNSLoadGas)
 		addrStr := L.CheckString(1)
 		addr := common.HexToAddress(addrStr)
-		h := tns.Reverse(stateDB, addr)
+		h := tns.Reverse(stateDB, addr, tnsBlock())
 		if h == (common.Hash{}) {
 			L.Push(lua.LNil)
 		} else {
@@ -3175,13 +3272,13 @@ func Execute(stateDB StateDB, blockCtx BlockContext, chainConfig *params.ChainCo
 	}))
 
 	// tos.tnshasname(addrHex) → bool
-	//   Returns true if addr has a registered TNS name.
+	//   Returns true if addr has a registered, unexpired TNS name.
 	//   Gas cost: params.TNSLoadGas.
 	L.SetField(tosTable, "tnshasname", L.NewFunction(func(L *lua.LState) int {
 		chargePrimGas(params.TNSLoadGas)
 		addrStr := L.CheckString(1)
 		addr := common.HexToAddress(addrStr)
-		if tns.HasName(stateDB, addr) {
+		if tns.HasName(stateDB, addr, tnsBlock()) {
 			L.Push(lua.LTrue)
 		} else {
 			L.Push(lua.LFalse)
@@ -3189,6 +3286,25 @@ func Execute(stateDB StateDB, blockCtx BlockContext, chainConfig *params.ChainCo
 		return 1
 	}))
 
+	// tos.tns_record(nameHashHex, key) → string | nil
+	//   Returns resolver record key of a name or subname: 0x-hex for the
+	//   elgamal, agent_card and contenthash records, the raw string for
+	//   gateway and text records. nil if unset or the name does not resolve.
+	//   Gas cost: params.TNSLoadGas + params.TNSRecordWordGas per 32-byte word.
+	L.SetField(tosTable, "tns_record", L.NewFunction(func(L *lua.LState) int {
+		chargePrimGas(params.TNSLoadGas)
+		nameHash := common.HexToHash(L.CheckString(1))
+		key := L.CheckString(2)
+		raw := tns.ReadRecord(stateDB, nameHash, key, tnsBlock())
+		if raw == nil {
+			L.Push(lua.LNil)
+			return 1
+		}
+		chargePrimGas(params.TNSRecordWordGas * uint64((len(raw)+31)/32))
+		L.Push(lua.LString(tns.FormatRecord(key, raw)))
+		return 1
+	}))
+
 	// ── Referral primitives ───────────────────────────────────────────────────
 
 	// tos.hasreferrer(addrHex) → bool
@@ -3327,14 +3443,55 @@ func Execute(stateDB StateDB, blockCtx BlockContext, chainConfig *params.ChainCo
 		return 1
 	}))
 
+	// tos.referral_payout(programIdHex, userHex, amount) → uint256 (amount paid)
+	//   Splits amount from THIS contract's balance across the uplines of user
+	//   using the rate table of the given referral program. Each share is
+	//   credited to the upline's claimable balance in the referral registry;
+	//   shares for missing uplines stay with the contract.
+	//   Gas cost: ReferralLoadGas + ReferralPayoutLevelGas per program level.
+	//   Write primitive — fails in staticcall.
+	L.SetField(tosTable, "referral_payout", L.NewFunction(func(L *lua.LState) int {
+		if ctx.Readonly {
+			L.RaiseError("tos.referral_payout: state modification not allowed in staticcall")
+			return 0
+		}
+		id := common.HexToHash(L.CheckString(1))
+		user := common.HexToAddress(L.CheckString(2))
+		amountUD := L.CheckUserData(3)
+		amount, ok := amountUD.Value.(*big.Int)
+		if !ok || amount.Sign() <= 0 {
+			L.RaiseError("tos.referral_payout: amount must be a positive uint256")
+			return 0
+		}
+		levels := uint64(len(referral.ReadProgramRates(stateDB, id)))
+		chargePrimGas(params.ReferralLoadGas + params.ReferralPayoutLevelGas*levels)
+		paid, err := referral.Payout(stateDB, id, contractAddr, user, amount)
+		if err != nil {
+			L.RaiseError("tos.referral_payout: %v", err)
+			return 0
+		}
+		L.Push(luBig(paid))
+		return 1
+	}))
+
+	// tos.referral_claimable(addrHex) → uint256
+	//   Returns the referral rewards addr has earned but not yet claimed.
+	L.SetField(tosTable, "referral_claimable", L.NewFunction(func(L *lua.LState) int {
+		chargePrimGas(params.ReferralLoadGas)
+		addr := common.HexToAddress(L.CheckString(1))
+		L.Push(luBig(referral.ReadClaimable(stateDB, addr)))
+		return 1
+	}))
+
 	// ── Scheduled tasks ───────────────────────────────────────────────────────
 
 	// tos.TASK_SCHEDULER — the canonical address of the on-chain task scheduler.
 	L.SetField(tosTable, "TASK_SCHEDULER", lua.LString(params.TaskSchedulerAddress.Hex()))
 
-	// tos.schedule(target, selector, taskData, gasLimit, delayBlocks, intervalBlocks, maxRuns)
+	// tos.schedule(target, selector, taskData, gasLimit, delayBlocks, intervalBlocks, maxRuns [, bid])
 	//   Schedules a future call to target:selector(taskData) on behalf of the
 	//   calling contract. A gas deposit is deducted from the contract's balance.
+	//   bid is an optional priority fee in tomi per gas (default 0).
 	//   Returns the task ID as a hex string, or nil on any validation failure.
 	//   Write primitive — fails in staticcall.
 	L.SetField(tosTable, "schedule", L.NewFunction(func(L *lua.LState) int {
@@ -3351,6 +3508,7 @@ func Execute(stateDB StateDB, blockCtx BlockContext, chainConfig *params.ChainCo
 		delayBlocks := uint64(L.CheckInt64(5))
 		intervalBlocks := uint64(L.CheckInt64(6))
 		maxRuns := uint64(L.CheckInt64(7))
+		bid := uint64(L.OptInt64(8, 0))
 
 		if gasLimit < params.TaskMinGasLimit || gasLimit > params.TaskMaxGasLimit {
 			L.Push(lua.LNil)
@@ -3369,10 +3527,7 @@ func Execute(stateDB StateDB, blockCtx BlockContext, chainConfig *params.ChainCo
 			return 1
 		}
 
-		deposit := new(big.Int).Mul(
-			new(big.Int).SetUint64(gasLimit),
-			big.NewInt(params.TxPriceTomi),
-		)
+		deposit := (&task.TaskRecord{GasLimit: gasLimit, Bid: bid}).Deposit()
 		if stateDB.GetBalance(contractAddr).Cmp(deposit) < 0 {
 			L.Push(lua.LNil)
 			return 1
@@ -3398,6 +3553,7 @@ func Execute(stateDB StateDB, blockCtx BlockContext, chainConfig *params.ChainCo
 			Selector:       selector,
 			TaskData:       taskData,
 			GasLimit:       gasLimit,
+			Bid:            bid,
 			TargetBlock:    targetBlock,
 			IntervalBlocks: intervalBlocks,
 			MaxRuns:        maxRuns,
@@ -3445,10 +3601,7 @@ func Execute(stateDB StateDB, blockCtx BlockContext, chainConfig *params.ChainCo
 			return 1
 		}
 
-		deposit := new(big.Int).Mul(
-			new(big.Int).SetUint64(rec.GasLimit),
-			big.NewInt(params.TxPriceTomi),
-		)
+		deposit := rec.Deposit()
 		stateDB.SubBalance(params.TaskSchedulerAddress, deposit)
 		stateDB.AddBalance(rec.Scheduler, deposit)
 
@@ -3463,7 +3616,7 @@ func Execute(stateDB StateDB, blockCtx BlockContext, chainConfig *params.ChainCo
 	// tos.taskinfo(taskIdHex, field) → value | nil
 	//   Returns a single field of a task record, or nil if the task does not exist.
 	//   field is one of: "scheduler", "target", "status", "runs", "nextblock",
-	//   "gaslimit", "interval", "maxruns".
+	//   "gaslimit", "interval", "maxruns", "bid".
 	L.SetField(tosTable, "taskinfo", L.NewFunction(func(L *lua.LState) int {
 		chargePrimGas(params.TaskInfoGas)
 
@@ -3492,6 +3645,8 @@ func Execute(stateDB StateDB, blockCtx BlockContext, chainConfig *params.ChainCo
 			L.Push(luBig(new(big.Int).SetUint64(rec.IntervalBlocks)))
 		case "maxruns":
 			L.Push(luBig(new(big.Int).SetUint64(rec.MaxRuns)))
+		case "bid":
+			L.Push(luBig(new(big.Int).SetUint64(rec.Bid)))
 		default:
 			L.Push(lua.LNil)
 		}
@@ -5100,6 +5255,54 @@ func Execute(stateDB StateDB, blockCtx BlockContext, chainConfig *params.ChainCo
 		return 1
 	}))
 
+	// tos.lease_transfer_owner(contractAddr, newOwner) → bool
+	//   Proposes newOwner as owner of a lease owned by the calling contract,
+	//   like LEASE_TRANSFER_OWNER; a zero newOwner withdraws the proposal.
+	//   Returns false if the lease is missing, not live, or not owned by the
+	//   calling contract.
+	//   Gas cost: gasSLoad + gasSStore.
+	//   Write primitive — fails in staticcall.
+	L.SetField(tosTable, "lease_transfer_owner", L.NewFunction(func(L *lua.LState) int {
+		if ctx.Readonly {
+			L.RaiseError("tos.lease_transfer_owner: state modification not allowed in staticcall")
+			return 0
+		}
+		chargePrimGas(gasSLoad + gasSStore)
+		leaseAddr := common.HexToAddress(L.CheckString(1))
+		newOwner := common.HexToAddress(L.CheckString(2))
+		currentBlock := uint64(0)
+		if blockCtx.BlockNumber != nil {
+			currentBlock = blockCtx.BlockNumber.Uint64()
+		}
+		err := lease.ProposeOwner(stateDB, leaseAddr, contractAddr, newOwner, currentBlock, chainConfig)
+		L.Push(lua.LBool(err == nil))
+		return 1
+	}))
+
+	// tos.lease_accept_owner(contractAddr) → bool
+	//   Accepts ownership of a lease for which the calling contract is the
+	//   pending owner, like LEASE_ACCEPT_OWNER. This is how a multisig or
+	//   other contract takes over a lease, since contracts cannot send
+	//   system actions. Returns false if the calling contract is not the
+	//   pending owner or the lease is not live.
+	//   Gas cost: gasSLoad + gasSStore.
+	//   Write primitive — fails in staticcall.
+	L.SetField(tosTable, "lease_accept_owner", L.NewFunction(func(L *lua.LState) int {
+		if ctx.Readonly {
+			L.RaiseError("tos.lease_accept_owner: state modification not allowed in staticcall")
+			return 0
+		}
+		chargePrimGas(gasSLoad + gasSStore)
+		leaseAddr := common.HexToAddress(L.CheckString(1))
+		currentBlock := uint64(0)
+		if blockCtx.BlockNumber != nil {
+			currentBlock = blockCtx.BlockNumber.Uint64()
+		}
+		err := lease.AcceptOwner(stateDB, leaseAddr, contractAddr, currentBlock, chainConfig)
+		L.Push(lua.LBool(err == nil))
+		return 1
+	}))
+
 	// tos.create2addr(deployer, salt, code) → string
 	//   Pure address-prediction function: returns the CREATE2 address that
 	//   tos.create2(code, salt) would produce when called from `deployer`,
@@ -6111,6 +6314,12 @@ func Execute(stateDB StateDB, blockCtx BlockContext, chainConfig *params.ChainCo
 	// ── Encrypted ciphertext operations (tos.ciphertext.*) ───────────────────
 	registerCiphertextTable(L, tosTable, chargePrimGas, ctx.Readonly, proofBundle, stateDB, contractAddr)
 
+	// ── Confidential UNO escrow (tos.uno_escrow_*) ───────────────────────────
+	registerUnoEscrowFunctions(L, tosTable, chargePrimGas, ctx.Readonly, proofBundle, stateDB, chainConfig.ChainID, contractAddr, ctx.From)
+
+	// ── Balance-threshold proofs (tos.priv_balance_at_least) ─────────────────
+	registerPrivReservesFunctions(L, tosTable, chargePrimGas, proofBundle, stateDB, chainConfig.ChainID)
+
 	// ── Inject globals ────────────────────────────────────────────────────────
 
 	L.SetGlobal("tos", tosTable)
@@ -69,6 +69,7 @@ var (
 	privCommitmentSlot = crypto.Keccak256Hash([]byte("gtos.priv.commitment"))
 	privHandleSlot     = crypto.Keccak256Hash([]byte("gtos.priv.handle"))
 	privVersionSlot    = crypto.Keccak256Hash([]byte("gtos.priv.version"))
+	privNonceSlot      = crypto.Keccak256Hash([]byte("gtos.priv.nonce"))
 )
 
 // zeroCiphertextHex is the canonical encrypted-zero value as a "0x..." hex string.
@@ -0,0 +1,188 @@
+package vm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/crypto"
+	cryptopriv "github.com/tos-network/gtos/crypto/priv"
+	lua "github.com/tos-network/tolang"
+)
+
+// Balance-threshold (proof-of-reserves) proofs.
+//
+// The owner of an encrypted balance proves "balance ≥ threshold" without
+// revealing the balance. Given the on-chain ciphertext ct = (C, D) under
+// pubkey P, the verifier computes ct' = ct − threshold·G, which encrypts
+// balance − threshold. The prover supplies:
+//
+//   - a fresh Pedersen commitment C' to balance − threshold;
+//   - a commitment equality proof that ct' and C' hold the same value;
+//   - a 64-bit range proof over C'.
+//
+// Since the range proof rules out a wrapped (negative) difference, the proof
+// establishes balance − threshold ∈ [0, 2^64). The transcript is bound to the
+// chain, the account, its current ciphertext and priv nonce, the threshold and
+// a verifier-chosen challenge, so a proof cannot be replayed to another
+// verifier or after the balance has changed.
+
+const (
+	balanceAtLeastContextVersion byte = 1
+	balanceAtLeastActionTag      byte = 0x21
+)
+
+// BalanceAtLeastProofSize is commitment (32B) ‖ commitment equality (192B) ‖
+// single 64-bit range proof (672B).
+const BalanceAtLeastProofSize = 32 + commitmentEqProofSize + rangeProofSingle64Size
+
+// gasPrivBalanceAtLeast covers the equality and range proof verification plus
+// the account state reads (commitment, handle, nonce).
+const gasPrivBalanceAtLeast uint64 = gasCtVerifyEq + 100000 + 3*2100
+
+var errNoEncryptedBalance = errors.New("account has no encrypted balance")
+
+// BuildBalanceAtLeastContext constructs the transcript context of a
+// balance-threshold proof.
+//
+// Layout (version 1, 154 bytes):
+//
+//	[0:1]     contextVersion (1)
+//	[1:9]     chainId, big-endian uint64
+//	[9:10]    actionTag (0x21 = balance at least)
+//	[10:42]   account pubkey
+//	[42:106]  account balance ciphertext (commitment 32 + handle 32)
+//	[106:114] account privNonce (big-endian uint64)
+//	[114:122] threshold (big-endian uint64)
+//	[122:154] verifier challenge
+func BuildBalanceAtLeastContext(chainID *big.Int, pubkey [32]byte, balance [64]byte, nonce, threshold uint64, challenge [32]byte) []byte {
+	ctx := make([]byte, 0, 154)
+	ctx = append(ctx, balanceAtLeastContextVersion)
+	chainU64 := ^uint64(0)
+	if chainID == nil {
+		chainU64 = 0
+	} else if chainID.IsUint64() {
+		chainU64 = chainID.Uint64()
+	}
+	ctx = binary.BigEndian.AppendUint64(ctx, chainU64)
+	ctx = append(ctx, balanceAtLeastActionTag)
+	ctx = append(ctx, pubkey[:]...)
+	ctx = append(ctx, balance[:]...)
+	ctx = binary.BigEndian.AppendUint64(ctx, nonce)
+	ctx = binary.BigEndian.AppendUint64(ctx, threshold)
+	return append(ctx, challenge[:]...)
+}
+
+// VerifyBalanceAtLeastProof checks that the account with the given pubkey,
+// balance ciphertext and priv nonce holds at least threshold.
+func VerifyBalanceAtLeastProof(chainID *big.Int, pubkey [32]byte, balance [64]byte, nonce, threshold uint64, challenge [32]byte, proof []byte) error {
+	if len(proof) != BalanceAtLeastProofSize {
+		return fmt.Errorf("proof must be %d bytes, got %d", BalanceAtLeastProofSize, len(proof))
+	}
+	commitment := proof[:32]
+	eqProof := proof[32 : 32+commitmentEqProofSize]
+	rangeProof := proof[32+commitmentEqProofSize:]
+
+	shifted, err := cryptopriv.SubAmountCompressed(balance[:], threshold)
+	if err != nil {
+		return fmt.Errorf("compute balance - threshold: %v", err)
+	}
+	ctx := BuildBalanceAtLeastContext(chainID, pubkey, balance, nonce, threshold, challenge)
+	if err := cryptopriv.VerifyCommitmentEqProofWithContext(eqProof, pubkey[:], shifted, commitment, ctx); err != nil {
+		return fmt.Errorf("commitment equality proof: %v", err)
+	}
+	if err := verifyRangeProof64(commitment, rangeProof); err != nil {
+		return fmt.Errorf("range proof: %v", err)
+	}
+	return nil
+}
+
+// NewBalanceAtLeastEntry wraps a balance-threshold proof into the proof bundle
+// entry consumed by tos.priv_balance_at_least.
+func NewBalanceAtLeastEntry(pubkey [32]byte, threshold uint64, challenge [32]byte, proof []byte) ProofEntry {
+	input := make([]byte, 0, 1+32+8+32)
+	input = append(input, opTagByName["balance_at_least"])
+	input = append(input, pubkey[:]...)
+	input = binary.BigEndian.AppendUint64(input, threshold)
+	input = append(input, challenge[:]...)
+	return ProofEntry{
+		Op:        "balance_at_least",
+		InputHash: crypto.Keccak256Hash(input),
+		Proof:     common.CopyBytes(proof),
+	}
+}
+
+// readPrivBalance returns the encrypted balance and priv nonce of account.
+func readPrivBalance(stateDB StateDB, account common.Address) ([64]byte, uint64, error) {
+	var balance [64]byte
+	commitment := stateDB.GetState(account, privCommitmentSlot)
+	handle := stateDB.GetState(account, privHandleSlot)
+	if commitment == (common.Hash{}) && handle == (common.Hash{}) {
+		return balance, 0, errNoEncryptedBalance
+	}
+	copy(balance[:32], commitment[:])
+	copy(balance[32:], handle[:])
+	nonce := stateDB.GetState(account, privNonceSlot)
+	return balance, binary.BigEndian.Uint64(nonce[24:]), nil
+}
+
+// registerPrivReservesFunctions installs the balance-threshold check on the
+// tos table:
+//
+//	tos.priv_balance_at_least(pubkey, threshold, challenge) → bool
+func registerPrivReservesFunctions(L *lua.LState, tosTable *lua.LTable,
+	chargePrimGas func(uint64), proofBundle *ProofBundle,
+	stateDB StateDB, chainID *big.Int) {
+
+	// priv_balance_at_least(pubkey, threshold, challenge) → bool
+	//   Verifies that the account owning pubkey currently holds an encrypted
+	//   balance of at least threshold. challenge is a 32-byte value chosen by
+	//   the contract (e.g. derived from a session or order id) so proofs made
+	//   for one check cannot be reused for another. The proof bundle entry
+	//   (op "balance_at_least") carries the 896-byte proof in Proof.
+	//   Returns false when the proof does not verify against the account's
+	//   current balance and nonce.
+	L.SetField(tosTable, "priv_balance_at_least", L.NewFunction(func(L *lua.LState) int {
+		chargePrimGas(gasPrivBalanceAtLeast)
+		pubkey, err := parseBytes32Hex(L.CheckString(1))
+		if err != nil {
+			L.RaiseError("priv_balance_at_least: pubkey: %v", err)
+			return 0
+		}
+		thresholdBig, err := parseUint256Value(L.CheckAny(2))
+		if err != nil || !thresholdBig.IsUint64() {
+			L.RaiseError("priv_balance_at_least: threshold must be a uint64 amount")
+			return 0
+		}
+		threshold := thresholdBig.Uint64()
+		challenge, err := parseBytes32Hex(L.CheckString(3))
+		if err != nil {
+			L.RaiseError("priv_balance_at_least: challenge: %v", err)
+			return 0
+		}
+		if proofBundle == nil {
+			L.RaiseError("priv_balance_at_least: proof bundle required")
+			return 0
+		}
+		var thresholdWord [8]byte
+		binary.BigEndian.PutUint64(thresholdWord[:], threshold)
+		entry, err := proofBundle.Next("balance_at_least", pubkey[:], thresholdWord[:], challenge[:])
+		if err != nil {
+			L.RaiseError("priv_balance_at_least: %v", err)
+			return 0
+		}
+		balance, nonce, err := readPrivBalance(stateDB, privAddressFromPubkey(pubkey))
+		if err != nil {
+			L.Push(lua.LFalse)
+			return 1
+		}
+		if VerifyBalanceAtLeastProof(chainID, pubkey, balance, nonce, threshold, challenge, entry.Proof) != nil {
+			L.Push(lua.LFalse)
+			return 1
+		}
+		L.Push(lua.LTrue)
+		return 1
+	}))
+}
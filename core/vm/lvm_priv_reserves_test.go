@@ -0,0 +1,79 @@
+package vm
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+	"testing"
+
+	"github.com/tos-network/gtos/common"
+	cryptopriv "github.com/tos-network/gtos/crypto/priv"
+)
+
+// balanceAtLeastProof builds a balance-threshold proof the way a wallet would.
+func balanceAtLeastProof(t *testing.T, priv, pub [32]byte, balance [64]byte, value, nonce, threshold uint64, challenge [32]byte) []byte {
+	t.Helper()
+	shifted, err := cryptopriv.SubAmountCompressed(balance[:], threshold)
+	if err != nil {
+		t.Fatalf("SubAmountCompressed: %v", err)
+	}
+	commitment, opening, err := cryptopriv.CommitmentNew(value - threshold)
+	if err != nil {
+		t.Fatalf("CommitmentNew: %v", err)
+	}
+	ctx := BuildBalanceAtLeastContext(testChainConfig.ChainID, pub, balance, nonce, threshold, challenge)
+	eqProof, err := cryptopriv.ProveCommitmentEqProof(priv[:], pub[:], shifted, commitment, opening, value-threshold, ctx)
+	if err != nil {
+		t.Fatalf("ProveCommitmentEqProof: %v", err)
+	}
+	rangeProof, err := cryptopriv.ProveRangeProof(commitment, value-threshold, opening)
+	if err != nil {
+		t.Fatalf("ProveRangeProof: %v", err)
+	}
+	return append(append(commitment, eqProof...), rangeProof...)
+}
+
+func TestPrivBalanceAtLeast(t *testing.T) {
+	f := newUnoEscrowFixture(t, 250)
+	var nonce common.Hash
+	binary.BigEndian.PutUint64(nonce[24:], 3)
+	f.st.SetState(f.payer, privNonceSlot, nonce)
+	challenge := [32]byte{0x42}
+
+	check := func(threshold uint64, want bool, proof []byte) {
+		t.Helper()
+		src := `if tos.priv_balance_at_least("0x` + hex.EncodeToString(f.payerPub[:]) + `", "` +
+			strconv.FormatUint(threshold, 10) + `", "` + bytes32ToHex(challenge) + `") ~= ` +
+			strconv.FormatBool(want) + ` then error("unexpected result") end`
+		if err := f.run(common.Address{0xAA}, src, NewBalanceAtLeastEntry(f.payerPub, threshold, challenge, proof)); err != nil {
+			t.Fatalf("threshold %d: %v", threshold, err)
+		}
+	}
+	check(200, true, balanceAtLeastProof(t, f.payerPriv, f.payerPub, f.balance, 250, 3, 200, challenge))
+	check(250, true, balanceAtLeastProof(t, f.payerPriv, f.payerPub, f.balance, 250, 3, 250, challenge))
+
+	// A proof made for a stale nonce no longer verifies.
+	check(100, false, balanceAtLeastProof(t, f.payerPriv, f.payerPub, f.balance, 250, 2, 100, challenge))
+
+	// A proof for another challenge is rejected by the bundle input hash.
+	proof := balanceAtLeastProof(t, f.payerPriv, f.payerPub, f.balance, 250, 3, 100, challenge)
+	src := `tos.priv_balance_at_least("0x` + hex.EncodeToString(f.payerPub[:]) + `", "100", "` + bytes32ToHex([32]byte{0x43}) + `")`
+	if err := f.run(common.Address{0xAA}, src, NewBalanceAtLeastEntry(f.payerPub, 100, challenge, proof)); err == nil {
+		t.Fatal("expected challenge mismatch to fail")
+	}
+}
+
+func TestVerifyBalanceAtLeastProofRejectsShortfall(t *testing.T) {
+	pub, priv := testKeypair(t)
+	ct, err := cryptopriv.Encrypt(pub[:], 80)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	var balance [64]byte
+	copy(balance[:], ct)
+	// Claim a balance of 500 to prove ≥ 300 while only 80 is held.
+	proof := balanceAtLeastProof(t, priv, pub, balance, 500, 0, 300, [32]byte{})
+	if err := VerifyBalanceAtLeastProof(testChainConfig.ChainID, pub, balance, 0, 300, [32]byte{}, proof); err == nil {
+		t.Fatal("expected proof for an insufficient balance to fail")
+	}
+}
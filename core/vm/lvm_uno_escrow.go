@@ -0,0 +1,444 @@
+package vm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/crypto"
+	cryptopriv "github.com/tos-network/gtos/crypto/priv"
+	lua "github.com/tos-network/tolang"
+)
+
+// Confidential UNO escrow.
+//
+// A payer locks an encrypted amount out of their native encrypted balance into
+// an escrow record owned by the calling contract. The lock carries a transfer
+// ciphertext (commitment C, payer handle D_payer, payee handle D_payee) with:
+//
+//   - a CT validity proof that D_payer and D_payee open C under the payer and
+//     payee keys with the same randomness, i.e. both handles decrypt to the
+//     same locked amount;
+//   - a commitment equality proof that the payer's new source commitment
+//     matches balance − C;
+//   - an aggregated range proof over the source and transfer commitments.
+//
+// Release credits (C, D_payee) to the payee and refund credits (C, D_payer)
+// back to the payer. Both reuse the locked commitment, so the released
+// ciphertext is provably equal to the locked one and no plaintext amount is
+// ever revealed on chain.
+
+// Escrow statuses.
+const (
+	unoEscrowNone     uint8 = 0
+	unoEscrowLocked   uint8 = 1
+	unoEscrowReleased uint8 = 2
+	unoEscrowRefunded uint8 = 3
+)
+
+// unoEscrowContextVersion tags the escrow-lock transcript context layout.
+const (
+	unoEscrowContextVersion byte = 1
+	unoEscrowActionLock     byte = 0x20
+)
+
+// unoEscrowRangeProofSize is the aggregated two-commitment range proof size
+// (mirrors core/priv.RangeProofTransfer).
+const unoEscrowRangeProofSize = 736
+
+// unoEscrowLockProofSize is CT validity + commitment equality + range proof.
+const unoEscrowLockProofSize = ctValidityProofSize + commitmentEqProofSize + unoEscrowRangeProofSize
+
+// Gas costs for confidential escrow operations.
+const (
+	gasUnoEscrowLock   uint64 = gasCtVerifyXfer + gasCtVerifyEq + 200000 + gasCtTransfer
+	gasUnoEscrowSettle uint64 = gasCtTransfer + 5200 // credit + escrow record read/update
+	gasUnoEscrowInfo   uint64 = 7800                 // 6× SLOAD
+)
+
+var (
+	errUnoEscrowExists    = errors.New("escrow id already used")
+	errUnoEscrowNotFound  = errors.New("escrow not found")
+	errUnoEscrowNotLocked = errors.New("escrow is not locked")
+)
+
+// Escrow record field tags.
+const (
+	unoEscrowFieldStatus byte = iota
+	unoEscrowFieldPayer
+	unoEscrowFieldPayee
+	unoEscrowFieldCommitment
+	unoEscrowFieldPayerHandle
+	unoEscrowFieldPayeeHandle
+)
+
+// unoEscrowSlot returns the storage slot of one escrow field. Escrows are
+// namespaced under the owning contract, like the public tos.escrow pool:
+//
+//	slot = keccak256("tol.uno_escrow." || contract || escrowID || field)
+func unoEscrowSlot(contractAddr common.Address, escrowID [32]byte, field byte) common.Hash {
+	key := append([]byte("tol.uno_escrow."), contractAddr.Bytes()...)
+	key = append(key, escrowID[:]...)
+	key = append(key, field)
+	return common.BytesToHash(crypto.Keccak256(key))
+}
+
+// unoEscrow is the decoded state of a confidential escrow record.
+type unoEscrow struct {
+	Status      uint8
+	Payer       common.Address
+	Payee       common.Address
+	Commitment  [32]byte
+	PayerHandle [32]byte
+	PayeeHandle [32]byte
+}
+
+func (e *unoEscrow) payerCiphertext() [64]byte {
+	var ct [64]byte
+	copy(ct[:32], e.Commitment[:])
+	copy(ct[32:], e.PayerHandle[:])
+	return ct
+}
+
+func (e *unoEscrow) payeeCiphertext() [64]byte {
+	var ct [64]byte
+	copy(ct[:32], e.Commitment[:])
+	copy(ct[32:], e.PayeeHandle[:])
+	return ct
+}
+
+func readUnoEscrow(stateDB StateDB, contractAddr common.Address, escrowID [32]byte) *unoEscrow {
+	status := stateDB.GetState(contractAddr, unoEscrowSlot(contractAddr, escrowID, unoEscrowFieldStatus))
+	if status[31] == unoEscrowNone {
+		return nil
+	}
+	return &unoEscrow{
+		Status:      status[31],
+		Payer:       common.BytesToAddress(stateDB.GetState(contractAddr, unoEscrowSlot(contractAddr, escrowID, unoEscrowFieldPayer)).Bytes()),
+		Payee:       common.BytesToAddress(stateDB.GetState(contractAddr, unoEscrowSlot(contractAddr, escrowID, unoEscrowFieldPayee)).Bytes()),
+		Commitment:  stateDB.GetState(contractAddr, unoEscrowSlot(contractAddr, escrowID, unoEscrowFieldCommitment)),
+		PayerHandle: stateDB.GetState(contractAddr, unoEscrowSlot(contractAddr, escrowID, unoEscrowFieldPayerHandle)),
+		PayeeHandle: stateDB.GetState(contractAddr, unoEscrowSlot(contractAddr, escrowID, unoEscrowFieldPayeeHandle)),
+	}
+}
+
+func writeUnoEscrowStatus(stateDB StateDB, contractAddr common.Address, escrowID [32]byte, status uint8) {
+	var word common.Hash
+	word[31] = status
+	stateDB.SetState(contractAddr, unoEscrowSlot(contractAddr, escrowID, unoEscrowFieldStatus), word)
+}
+
+func writeUnoEscrow(stateDB StateDB, contractAddr common.Address, escrowID [32]byte, e *unoEscrow) {
+	writeUnoEscrowStatus(stateDB, contractAddr, escrowID, e.Status)
+	stateDB.SetState(contractAddr, unoEscrowSlot(contractAddr, escrowID, unoEscrowFieldPayer), common.BytesToHash(e.Payer.Bytes()))
+	stateDB.SetState(contractAddr, unoEscrowSlot(contractAddr, escrowID, unoEscrowFieldPayee), common.BytesToHash(e.Payee.Bytes()))
+	stateDB.SetState(contractAddr, unoEscrowSlot(contractAddr, escrowID, unoEscrowFieldCommitment), common.Hash(e.Commitment))
+	stateDB.SetState(contractAddr, unoEscrowSlot(contractAddr, escrowID, unoEscrowFieldPayerHandle), common.Hash(e.PayerHandle))
+	stateDB.SetState(contractAddr, unoEscrowSlot(contractAddr, escrowID, unoEscrowFieldPayeeHandle), common.Hash(e.PayeeHandle))
+}
+
+func unoEscrowStatusName(status uint8) string {
+	switch status {
+	case unoEscrowLocked:
+		return "locked"
+	case unoEscrowReleased:
+		return "released"
+	case unoEscrowRefunded:
+		return "refunded"
+	default:
+		return ""
+	}
+}
+
+// privAddressFromPubkey derives the account address of an ElGamal public key.
+func privAddressFromPubkey(pubkey [32]byte) common.Address {
+	return common.BytesToAddress(crypto.Keccak256(pubkey[:]))
+}
+
+// BuildUnoEscrowLockContext constructs the transcript context that binds an
+// escrow-lock proof to the chain, the owning contract, the escrow id, both
+// parties and the payer's current encrypted balance (so a lock proof cannot be
+// replayed once the balance has changed).
+//
+// Layout (version 1, 202 bytes):
+//
+//	[0:1]     contextVersion (1)
+//	[1:9]     chainId, big-endian uint64
+//	[9:10]    actionTag (0x20 = escrow lock)
+//	[10:42]   contract address
+//	[42:74]   escrow id
+//	[74:106]  payer address
+//	[106:138] payee address
+//	[138:202] payer's current balance ciphertext (commitment 32 + handle 32)
+func BuildUnoEscrowLockContext(chainID *big.Int, contractAddr common.Address, escrowID [32]byte, payer, payee common.Address, payerBalance [64]byte) []byte {
+	ctx := make([]byte, 0, 202)
+	ctx = append(ctx, unoEscrowContextVersion)
+	var word [8]byte
+	chainU64 := ^uint64(0)
+	if chainID == nil {
+		chainU64 = 0
+	} else if chainID.IsUint64() {
+		chainU64 = chainID.Uint64()
+	}
+	binary.BigEndian.PutUint64(word[:], chainU64)
+	ctx = append(ctx, word[:]...)
+	ctx = append(ctx, unoEscrowActionLock)
+	ctx = append(ctx, contractAddr[:]...)
+	ctx = append(ctx, escrowID[:]...)
+	ctx = append(ctx, payer[:]...)
+	ctx = append(ctx, payee[:]...)
+	return append(ctx, payerBalance[:]...)
+}
+
+// NewUnoEscrowLockEntry packs the client-side outputs of an escrow-lock proof
+// into the proof bundle entry consumed by tos.uno_escrow_lock.
+//
+// ResultData = commitment ‖ payerHandle ‖ payeeHandle ‖ sourceCommitment (128B)
+// Proof      = CT validity (160B) ‖ commitment equality (192B) ‖ range (736B)
+func NewUnoEscrowLockEntry(escrowID, payerPub, payeePub [32]byte, commitment, payerHandle, payeeHandle, sourceCommitment [32]byte, ctValidityProof, commitmentEqProof, rangeProof []byte) ProofEntry {
+	tag := opTagByName["escrow_lock"]
+	input := make([]byte, 0, 1+3*32)
+	input = append(input, tag)
+	input = append(input, escrowID[:]...)
+	input = append(input, payerPub[:]...)
+	input = append(input, payeePub[:]...)
+
+	result := make([]byte, 0, 128)
+	result = append(result, commitment[:]...)
+	result = append(result, payerHandle[:]...)
+	result = append(result, payeeHandle[:]...)
+	result = append(result, sourceCommitment[:]...)
+
+	proof := make([]byte, 0, unoEscrowLockProofSize)
+	proof = append(proof, ctValidityProof...)
+	proof = append(proof, commitmentEqProof...)
+	proof = append(proof, rangeProof...)
+
+	return ProofEntry{
+		Op:         "escrow_lock",
+		InputHash:  crypto.Keccak256Hash(input),
+		ResultData: result,
+		Proof:      proof,
+	}
+}
+
+// lockUnoEscrow verifies an escrow-lock proof entry, debits the payer's
+// encrypted balance and records the escrow under contractAddr.
+func lockUnoEscrow(stateDB StateDB, chainID *big.Int, contractAddr, caller common.Address, escrowID, payerPub, payeePub [32]byte, entry *ProofEntry) error {
+	if readUnoEscrow(stateDB, contractAddr, escrowID) != nil {
+		return errUnoEscrowExists
+	}
+	payer := privAddressFromPubkey(payerPub)
+	if payer != caller {
+		return fmt.Errorf("payer pubkey does not belong to caller")
+	}
+	payee := privAddressFromPubkey(payeePub)
+	if len(entry.ResultData) != 128 {
+		return fmt.Errorf("result must be 128 bytes (commitment, payer handle, payee handle, source commitment)")
+	}
+	if len(entry.Proof) != unoEscrowLockProofSize {
+		return fmt.Errorf("proof must be %d bytes, got %d", unoEscrowLockProofSize, len(entry.Proof))
+	}
+	var commitment, payerHandle, payeeHandle, sourceCommitment [32]byte
+	copy(commitment[:], entry.ResultData[0:32])
+	copy(payerHandle[:], entry.ResultData[32:64])
+	copy(payeeHandle[:], entry.ResultData[64:96])
+	copy(sourceCommitment[:], entry.ResultData[96:128])
+	ctProof := entry.Proof[:ctValidityProofSize]
+	eqProof := entry.Proof[ctValidityProofSize : ctValidityProofSize+commitmentEqProofSize]
+	rangeProof := entry.Proof[ctValidityProofSize+commitmentEqProofSize:]
+
+	// Payer's current encrypted balance.
+	curCommit := stateDB.GetState(payer, privCommitmentSlot)
+	curHandle := stateDB.GetState(payer, privHandleSlot)
+	if curCommit == (common.Hash{}) && curHandle == (common.Hash{}) {
+		return fmt.Errorf("payer has no encrypted balance")
+	}
+	var balance [64]byte
+	copy(balance[:32], curCommit[:])
+	copy(balance[32:], curHandle[:])
+	transcriptCtx := BuildUnoEscrowLockContext(chainID, contractAddr, escrowID, payer, payee, balance)
+
+	// Both handles open the same commitment: the payee can later claim exactly
+	// what the payer locked.
+	if err := cryptopriv.VerifyCTValidityProofWithContext(ctProof, commitment[:], payerHandle[:], payeeHandle[:], payerPub[:], payeePub[:], true, transcriptCtx); err != nil {
+		return fmt.Errorf("invalid ciphertext validity proof: %w", err)
+	}
+	// The new source commitment matches balance − locked amount.
+	var locked [64]byte
+	copy(locked[:32], commitment[:])
+	copy(locked[32:], payerHandle[:])
+	remaining, err := cryptopriv.SubCompressedCiphertexts(balance[:], locked[:])
+	if err != nil {
+		return fmt.Errorf("homomorphic sub failed: %w", err)
+	}
+	if err := cryptopriv.VerifyCommitmentEqProofWithContext(eqProof, payerPub[:], remaining, sourceCommitment[:], transcriptCtx); err != nil {
+		return fmt.Errorf("invalid commitment equality proof: %w", err)
+	}
+	// Neither the remaining balance nor the locked amount is negative.
+	commitments := make([]byte, 0, 64)
+	commitments = append(commitments, sourceCommitment[:]...)
+	commitments = append(commitments, commitment[:]...)
+	if err := cryptopriv.VerifyRangeProof(rangeProof, commitments, []byte{64, 64}, 2); err != nil {
+		return fmt.Errorf("invalid range proof: %w", err)
+	}
+
+	// Debit the payer: new balance is (sourceCommitment, remaining handle).
+	versionWord := stateDB.GetState(payer, privVersionSlot)
+	version := binary.BigEndian.Uint64(versionWord[24:])
+	if version == math.MaxUint64 {
+		return fmt.Errorf("payer version overflow")
+	}
+	stateDB.SetState(payer, privCommitmentSlot, common.Hash(sourceCommitment))
+	stateDB.SetState(payer, privHandleSlot, common.BytesToHash(remaining[32:64]))
+	var newVersionWord common.Hash
+	binary.BigEndian.PutUint64(newVersionWord[24:], version+1)
+	stateDB.SetState(payer, privVersionSlot, newVersionWord)
+
+	writeUnoEscrow(stateDB, contractAddr, escrowID, &unoEscrow{
+		Status:      unoEscrowLocked,
+		Payer:       payer,
+		Payee:       payee,
+		Commitment:  commitment,
+		PayerHandle: payerHandle,
+		PayeeHandle: payeeHandle,
+	})
+	return nil
+}
+
+// settleUnoEscrow closes a locked escrow owned by contractAddr, crediting the
+// locked ciphertext to the payee (release) or back to the payer (refund). It
+// returns the credited ciphertext.
+func settleUnoEscrow(stateDB StateDB, contractAddr common.Address, escrowID [32]byte, refund bool) ([64]byte, error) {
+	return settleUnoEscrowTo(stateDB, contractAddr, escrowID, refund, common.Address{})
+}
+
+// settleUnoEscrowTo is settleUnoEscrow with an expected beneficiary; a
+// non-zero expect must match the credited party.
+func settleUnoEscrowTo(stateDB StateDB, contractAddr common.Address, escrowID [32]byte, refund bool, expect common.Address) ([64]byte, error) {
+	e := readUnoEscrow(stateDB, contractAddr, escrowID)
+	if e == nil {
+		return [64]byte{}, errUnoEscrowNotFound
+	}
+	if e.Status != unoEscrowLocked {
+		return [64]byte{}, errUnoEscrowNotLocked
+	}
+	to, ct, status := e.Payee, e.payeeCiphertext(), unoEscrowReleased
+	if refund {
+		to, ct, status = e.Payer, e.payerCiphertext(), unoEscrowRefunded
+	}
+	if expect != (common.Address{}) && expect != to {
+		return [64]byte{}, fmt.Errorf("recipient does not match escrow party")
+	}
+	if err := applyUnoTransfer(stateDB, to, ct); err != nil {
+		return [64]byte{}, err
+	}
+	writeUnoEscrowStatus(stateDB, contractAddr, escrowID, status)
+	return ct, nil
+}
+
+// registerUnoEscrowFunctions installs the confidential escrow primitives on
+// the tos table:
+//
+//	tos.uno_escrow_lock(escrowId, payerPub, payeePub)
+//	tos.uno_escrow_release(escrowId)
+//	tos.uno_escrow_refund(escrowId)
+//	tos.uno_escrow_info(escrowId) → table | nil
+func registerUnoEscrowFunctions(L *lua.LState, tosTable *lua.LTable,
+	chargePrimGas func(uint64), readonly bool, proofBundle *ProofBundle,
+	stateDB StateDB, chainID *big.Int, contractAddr, caller common.Address) {
+
+	// uno_escrow_lock(escrowId, payerPub, payeePub)
+	//   Locks an encrypted amount from the caller's encrypted balance into an
+	//   escrow owned by this contract. The payer must be msg.sender.
+	//   The proof bundle entry (op "escrow_lock") carries:
+	//     ResultData = commitment ‖ payerHandle ‖ payeeHandle ‖ sourceCommitment
+	//     Proof      = CT validity ‖ commitment equality ‖ aggregated range proof
+	L.SetField(tosTable, "uno_escrow_lock", L.NewFunction(func(L *lua.LState) int {
+		if readonly {
+			L.RaiseError("uno_escrow_lock: state modification not allowed in staticcall")
+			return 0
+		}
+		chargePrimGas(gasUnoEscrowLock)
+		escrowID, err := parseBytes32Hex(L.CheckString(1))
+		if err != nil {
+			L.RaiseError("uno_escrow_lock: escrow id: %v", err)
+			return 0
+		}
+		payerPub, err := parseBytes32Hex(L.CheckString(2))
+		if err != nil {
+			L.RaiseError("uno_escrow_lock: payer pubkey: %v", err)
+			return 0
+		}
+		payeePub, err := parseBytes32Hex(L.CheckString(3))
+		if err != nil {
+			L.RaiseError("uno_escrow_lock: payee pubkey: %v", err)
+			return 0
+		}
+		if proofBundle == nil {
+			L.RaiseError("uno_escrow_lock: proof bundle required")
+			return 0
+		}
+		entry, err := proofBundle.Next("escrow_lock", escrowID[:], payerPub[:], payeePub[:])
+		if err != nil {
+			L.RaiseError("uno_escrow_lock: %v", err)
+			return 0
+		}
+		if err := lockUnoEscrow(stateDB, chainID, contractAddr, caller, escrowID, payerPub, payeePub, entry); err != nil {
+			L.RaiseError("uno_escrow_lock: %v", err)
+			return 0
+		}
+		return 0
+	}))
+
+	settleFn := func(name string, refund bool) *lua.LFunction {
+		return L.NewFunction(func(L *lua.LState) int {
+			if readonly {
+				L.RaiseError("%s: state modification not allowed in staticcall", name)
+				return 0
+			}
+			chargePrimGas(gasUnoEscrowSettle)
+			escrowID, err := parseBytes32Hex(L.CheckString(1))
+			if err != nil {
+				L.RaiseError("%s: escrow id: %v", name, err)
+				return 0
+			}
+			if _, err := settleUnoEscrow(stateDB, contractAddr, escrowID, refund); err != nil {
+				L.RaiseError("%s: %v", name, err)
+				return 0
+			}
+			return 0
+		})
+	}
+	// uno_escrow_release(escrowId): credits the locked amount to the payee.
+	L.SetField(tosTable, "uno_escrow_release", settleFn("uno_escrow_release", false))
+	// uno_escrow_refund(escrowId): returns the locked amount to the payer.
+	L.SetField(tosTable, "uno_escrow_refund", settleFn("uno_escrow_refund", true))
+
+	// uno_escrow_info(escrowId) → {status, payer, payee, payer_ct, payee_ct} | nil
+	//   Ciphertexts stay encrypted; only the parties can decrypt the amount.
+	L.SetField(tosTable, "uno_escrow_info", L.NewFunction(func(L *lua.LState) int {
+		chargePrimGas(gasUnoEscrowInfo)
+		escrowID, err := parseBytes32Hex(L.CheckString(1))
+		if err != nil {
+			L.RaiseError("uno_escrow_info: escrow id: %v", err)
+			return 0
+		}
+		e := readUnoEscrow(stateDB, contractAddr, escrowID)
+		if e == nil {
+			L.Push(lua.LNil)
+			return 1
+		}
+		tbl := L.NewTable()
+		tbl.RawSetString("status", lua.LString(unoEscrowStatusName(e.Status)))
+		tbl.RawSetString("payer", lua.LString(e.Payer.Hex()))
+		tbl.RawSetString("payee", lua.LString(e.Payee.Hex()))
+		tbl.RawSetString("payer_ct", lua.LString(ciphertextToHex(e.payerCiphertext())))
+		tbl.RawSetString("payee_ct", lua.LString(ciphertextToHex(e.payeeCiphertext())))
+		L.Push(tbl)
+		return 1
+	}))
+}
@@ -0,0 +1,221 @@
+package vm
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/tos-network/gtos/common"
+	cryptopriv "github.com/tos-network/gtos/crypto/priv"
+)
+
+// unoEscrowFixture is a payer with an encrypted balance and a payee key.
+type unoEscrowFixture struct {
+	st                  StateDB
+	contract            common.Address
+	payerPub, payerPriv [32]byte
+	payeePub, payeePriv [32]byte
+	payer, payee        common.Address
+	balance             [64]byte
+	balanceValue        uint64
+}
+
+func newUnoEscrowFixture(t *testing.T, balanceValue uint64) *unoEscrowFixture {
+	t.Helper()
+	f := &unoEscrowFixture{
+		st:           newAgentTestState(),
+		contract:     common.Address{0xE5},
+		balanceValue: balanceValue,
+	}
+	f.payerPub, f.payerPriv = testKeypair(t)
+	f.payeePub, f.payeePriv = testKeypair(t)
+	f.payer = privAddressFromPubkey(f.payerPub)
+	f.payee = privAddressFromPubkey(f.payeePub)
+	ct, err := cryptopriv.Encrypt(f.payerPub[:], balanceValue)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	copy(f.balance[:], ct)
+	f.st.SetState(f.payer, privCommitmentSlot, common.BytesToHash(ct[:32]))
+	f.st.SetState(f.payer, privHandleSlot, common.BytesToHash(ct[32:]))
+	return f
+}
+
+// lockEntry builds an escrow_lock proof entry the way a wallet would.
+func (f *unoEscrowFixture) lockEntry(t *testing.T, escrowID [32]byte, amount uint64) ProofEntry {
+	t.Helper()
+	ctx := BuildUnoEscrowLockContext(testChainConfig.ChainID, f.contract, escrowID, f.payer, f.payee, f.balance)
+
+	commitment, opening, err := cryptopriv.CommitmentNew(amount)
+	if err != nil {
+		t.Fatalf("CommitmentNew: %v", err)
+	}
+	ctProof, _, payerHandle, payeeHandle, err := cryptopriv.ProveCTValidityProofWithContext(f.payerPub[:], f.payeePub[:], amount, opening, true, ctx)
+	if err != nil {
+		t.Fatalf("ProveCTValidityProof: %v", err)
+	}
+	var locked [64]byte
+	copy(locked[:32], commitment)
+	copy(locked[32:], payerHandle)
+	remaining, err := cryptopriv.SubCompressedCiphertexts(f.balance[:], locked[:])
+	if err != nil {
+		t.Fatalf("SubCompressedCiphertexts: %v", err)
+	}
+	newBalance := f.balanceValue - amount
+	sourceCommitment, sourceOpening, err := cryptopriv.CommitmentNew(newBalance)
+	if err != nil {
+		t.Fatalf("CommitmentNew: %v", err)
+	}
+	eqProof, err := cryptopriv.ProveCommitmentEqProof(f.payerPriv[:], f.payerPub[:], remaining, sourceCommitment, sourceOpening, newBalance, ctx)
+	if err != nil {
+		t.Fatalf("ProveCommitmentEqProof: %v", err)
+	}
+	rangeProof, err := cryptopriv.ProveAggregatedRangeProof(
+		[][]byte{sourceCommitment, commitment},
+		[]uint64{newBalance, amount},
+		[][]byte{sourceOpening, opening},
+	)
+	if err != nil {
+		t.Fatalf("ProveAggregatedRangeProof: %v", err)
+	}
+	var c, ph, qh, sc [32]byte
+	copy(c[:], commitment)
+	copy(ph[:], payerHandle)
+	copy(qh[:], payeeHandle)
+	copy(sc[:], sourceCommitment)
+	return NewUnoEscrowLockEntry(escrowID, f.payerPub, f.payeePub, c, ph, qh, sc, ctProof, eqProof, rangeProof)
+}
+
+func (f *unoEscrowFixture) run(from common.Address, src string, entries ...ProofEntry) error {
+	var data []byte
+	if len(entries) > 0 {
+		data = EncodeProofBundle(entries)
+	}
+	ctx := CallCtx{
+		From: from, To: f.contract,
+		Value: big.NewInt(0), Data: data,
+		TxOrigin: from, TxPrice: big.NewInt(1),
+	}
+	_, _, _, err := Execute(f.st, newBlockCtx(), testChainConfig, ctx, []byte(src), 5_000_000)
+	return err
+}
+
+func (f *unoEscrowFixture) decrypt(t *testing.T, addr common.Address, priv [32]byte) uint64 {
+	t.Helper()
+	var ct [64]byte
+	commit := f.st.GetState(addr, privCommitmentSlot)
+	handle := f.st.GetState(addr, privHandleSlot)
+	copy(ct[:32], commit[:])
+	copy(ct[32:], handle[:])
+	point, err := cryptopriv.DecryptToPoint(priv[:], ct[:])
+	if err != nil {
+		t.Fatalf("DecryptToPoint: %v", err)
+	}
+	value, ok, err := cryptopriv.SolveDiscreteLog(point, 1<<20)
+	if err != nil || !ok {
+		t.Fatalf("SolveDiscreteLog: ok=%v err=%v", ok, err)
+	}
+	return value
+}
+
+func lockSrc(escrowID [32]byte, payerPub, payeePub [32]byte) string {
+	return `tos.uno_escrow_lock("` + bytes32ToHex(escrowID) + `", "0x` + hex.EncodeToString(payerPub[:]) + `", "0x` + hex.EncodeToString(payeePub[:]) + `")
+local info = tos.uno_escrow_info("` + bytes32ToHex(escrowID) + `")
+if info == nil or info.status ~= "locked" then error("expected locked escrow") end
+`
+}
+
+func TestUnoEscrowLockRelease(t *testing.T) {
+	f := newUnoEscrowFixture(t, 100)
+	escrowID := [32]byte{0x01}
+
+	if err := f.run(f.payer, lockSrc(escrowID, f.payerPub, f.payeePub), f.lockEntry(t, escrowID, 30)); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	if got := f.decrypt(t, f.payer, f.payerPriv); got != 70 {
+		t.Fatalf("payer balance after lock = %d, want 70", got)
+	}
+
+	src := `tos.uno_escrow_release("` + bytes32ToHex(escrowID) + `")
+if tos.uno_escrow_info("` + bytes32ToHex(escrowID) + `").status ~= "released" then error("expected released") end`
+	if err := f.run(common.Address{0xAA}, src); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if got := f.decrypt(t, f.payee, f.payeePriv); got != 30 {
+		t.Fatalf("payee balance after release = %d, want 30", got)
+	}
+	// A settled escrow cannot be settled again.
+	if err := f.run(common.Address{0xAA}, `tos.uno_escrow_refund("`+bytes32ToHex(escrowID)+`")`); err == nil {
+		t.Fatal("expected refund of released escrow to fail")
+	}
+}
+
+func TestUnoEscrowLockRefund(t *testing.T) {
+	f := newUnoEscrowFixture(t, 100)
+	escrowID := [32]byte{0x02}
+
+	if err := f.run(f.payer, lockSrc(escrowID, f.payerPub, f.payeePub), f.lockEntry(t, escrowID, 45)); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	if err := f.run(common.Address{0xAA}, `tos.uno_escrow_refund("`+bytes32ToHex(escrowID)+`")`); err != nil {
+		t.Fatalf("refund: %v", err)
+	}
+	if got := f.decrypt(t, f.payer, f.payerPriv); got != 100 {
+		t.Fatalf("payer balance after refund = %d, want 100", got)
+	}
+	if commit := f.st.GetState(f.payee, privCommitmentSlot); commit != (common.Hash{}) {
+		t.Fatal("payee must not be credited on refund")
+	}
+}
+
+func TestUnoEscrowLockRejectsForeignCaller(t *testing.T) {
+	f := newUnoEscrowFixture(t, 100)
+	escrowID := [32]byte{0x03}
+
+	if err := f.run(common.Address{0xBB}, lockSrc(escrowID, f.payerPub, f.payeePub), f.lockEntry(t, escrowID, 10)); err == nil {
+		t.Fatal("expected lock by non-payer to fail")
+	}
+	if readUnoEscrow(f.st, f.contract, escrowID) != nil {
+		t.Fatal("escrow must not be recorded")
+	}
+}
+
+func TestUnoEscrowLockRejectsOverdraw(t *testing.T) {
+	f := newUnoEscrowFixture(t, 100)
+	escrowID := [32]byte{0x04}
+
+	// Claim a larger balance than the payer holds: the commitment equality
+	// proof no longer matches the on-chain balance.
+	f.balanceValue = 500
+	if err := f.run(f.payer, lockSrc(escrowID, f.payerPub, f.payeePub), f.lockEntry(t, escrowID, 200)); err == nil {
+		t.Fatal("expected overdrawing lock to fail")
+	}
+}
+
+func TestUnoEscrowSettlementBusRelease(t *testing.T) {
+	f := newUnoEscrowFixture(t, 100)
+	escrowID := [32]byte{0x05}
+	receiptRef := common.HexToHash("0x7200000000000000000000000000000000000000000000000000000000000005")
+
+	if err := f.run(f.payer, lockSrc(escrowID, f.payerPub, f.payeePub), f.lockEntry(t, escrowID, 12)); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	// The beneficiary must be the escrow payee.
+	wrong := `
+tos.receipt_open("` + receiptRef.Hex() + `", 6)
+tos.settle_escrow("ESCROW_RELEASE_UNO", "` + f.payer.Hex() + `", "` + bytes32ToHex(escrowID) + `", "` + receiptRef.Hex() + `")`
+	if err := f.run(common.Address{0xAA}, wrong); err == nil {
+		t.Fatal("expected release to non-payee to fail")
+	}
+	src := `
+tos.receipt_open("` + receiptRef.Hex() + `", 6)
+local s = tos.settle_escrow("ESCROW_RELEASE_UNO", "` + f.payee.Hex() + `", "` + bytes32ToHex(escrowID) + `", "` + receiptRef.Hex() + `")
+if tos.receipt_info("` + receiptRef.Hex() + `").status ~= "success" then error("expected receipt success") end
+if tos.uno_escrow_info("` + bytes32ToHex(escrowID) + `").status ~= "released" then error("expected released") end`
+	if err := f.run(common.Address{0xAA}, src); err != nil {
+		t.Fatalf("settle_escrow: %v", err)
+	}
+	if got := f.decrypt(t, f.payee, f.payeePriv); got != 12 {
+		t.Fatalf("payee balance = %d, want 12", got)
+	}
+}
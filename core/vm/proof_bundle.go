@@ -21,20 +21,24 @@ const (
 	opTagSelect         uint8 = 9
 	opTagVerifyTransfer uint8 = 10
 	opTagVerifyEq       uint8 = 11
+	opTagEscrowLock     uint8 = 12
+	opTagBalanceAtLeast uint8 = 13
 )
 
 var opTagByName = map[string]uint8{
-	"mul":             opTagMul,
-	"div":             opTagDiv,
-	"rem":             opTagRem,
-	"lt":              opTagLt,
-	"gt":              opTagGt,
-	"eq":              opTagEq,
-	"min":             opTagMin,
-	"max":             opTagMax,
-	"select":          opTagSelect,
-	"verify_transfer": opTagVerifyTransfer,
-	"verify_eq":       opTagVerifyEq,
+	"mul":              opTagMul,
+	"div":              opTagDiv,
+	"rem":              opTagRem,
+	"lt":               opTagLt,
+	"gt":               opTagGt,
+	"eq":               opTagEq,
+	"min":              opTagMin,
+	"max":              opTagMax,
+	"select":           opTagSelect,
+	"verify_transfer":  opTagVerifyTransfer,
+	"verify_eq":        opTagVerifyEq,
+	"escrow_lock":      opTagEscrowLock,
+	"balance_at_least": opTagBalanceAtLeast,
 }
 
 var opNameByTag = map[uint8]string{
@@ -49,6 +53,8 @@ var opNameByTag = map[uint8]string{
 	opTagSelect:         "select",
 	opTagVerifyTransfer: "verify_transfer",
 	opTagVerifyEq:       "verify_eq",
+	opTagEscrowLock:     "escrow_lock",
+	opTagBalanceAtLeast: "balance_at_least",
 }
 
 // proofBundleMagic is the 4-byte marker "PBND" separating ABI calldata from
@@ -57,7 +63,7 @@ var proofBundleMagic = []byte{0x50, 0x42, 0x4E, 0x44}
 
 // ProofEntry holds one pre-computed ZK proof for a ciphertext operation.
 type ProofEntry struct {
-	Op         string // "mul","div","rem","lt","gt","eq","min","max","select","verify_transfer","verify_eq"
+	Op         string // "mul","div","rem","lt","gt","eq","min","max","select","verify_transfer","verify_eq","escrow_lock","balance_at_least"
 	InputHash  [32]byte
 	ResultData []byte // 64B ciphertext or 1B bool
 	Proof      []byte // ZK proof bytes
@@ -75,3 +75,11 @@ func SolveDiscreteLogWithTable(table *ecdlptable.Table, msgPoint []byte, maxAmou
 	}
 	return table.Decode(msgPoint, maxAmount)
 }
+
+// SolveDiscreteLogKangaroo finds m ∈ [0, maxAmount] with Pollard's kangaroo
+// method. Unlike the BSGS solvers it uses constant memory for any range, so it
+// can recover arbitrary 64-bit balances; the cost is ~6·sqrt(maxAmount) point
+// additions. See ecdlptable.SolveKangaroo.
+func SolveDiscreteLogKangaroo(msgPoint []byte, maxAmount uint64) (uint64, bool, error) {
+	return ecdlptable.SolveKangaroo(msgPoint, maxAmount)
+}
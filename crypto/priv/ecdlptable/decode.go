@@ -2,6 +2,8 @@ package ecdlptable
 
 import (
 	"errors"
+	"fmt"
+	"math"
 
 	"github.com/tos-network/gtos/crypto/ristretto255"
 )
@@ -11,55 +13,156 @@ import (
 // DecryptToPoint applied to a Twisted ElGamal ciphertext).
 //
 // Returns (m, true, nil) on success, (0, false, nil) when m > maxAmount,
-// or (0, false, err) on invalid input.
+// or (0, false, err) on invalid input. Use NewSearch to spread a long search
+// over several calls.
 func (t *Table) Decode(msgPoint []byte, maxAmount uint64) (uint64, bool, error) {
-	if len(msgPoint) != 32 {
-		return 0, false, errors.New("ecdlptable: msgPoint must be 32 bytes")
+	s, err := t.NewSearch(msgPoint, maxAmount)
+	if err != nil {
+		return 0, false, err
 	}
+	m, found, _ := s.Run(math.MaxUint64)
+	return m, found, nil
+}
 
-	identity := ristretto255.NewIdentityElement()
+// Search is an in-progress giant-step search over a table. It can be run a
+// bounded number of steps at a time and checkpointed, so decrypting a large
+// balance does not need to happen in one call or even one process.
+type Search struct {
+	table     *Table
+	target    *ristretto255.Element // P = m*G
+	current   *ristretto255.Element // P - next*2^L1*G
+	next      uint64                // next giant step to examine
+	maxJ      uint64
+	maxAmount uint64
+	result    uint64
+	found     bool
+}
 
+// Checkpoint is the serialisable state of a Search.
+type Checkpoint struct {
+	L1        uint     `json:"l1"`
+	MsgPoint  [32]byte `json:"msgPoint"`
+	MaxAmount uint64   `json:"maxAmount"`
+	NextStep  uint64   `json:"nextStep"`
+	Current   [32]byte `json:"current"`
+}
+
+// NewSearch starts a search for m ∈ [0, maxAmount] with msgPoint = m*G.
+func (t *Table) NewSearch(msgPoint []byte, maxAmount uint64) (*Search, error) {
+	if len(msgPoint) != 32 {
+		return nil, errors.New("ecdlptable: msgPoint must be 32 bytes")
+	}
 	P, err := ristretto255.NewIdentityElement().SetCanonicalBytes(msgPoint)
 	if err != nil {
-		return 0, false, errors.New("ecdlptable: invalid msgPoint encoding")
+		return nil, errors.New("ecdlptable: invalid msgPoint encoding")
+	}
+	s := &Search{
+		table:     t,
+		target:    P,
+		current:   ristretto255.NewIdentityElement().Set(P),
+		maxJ:      maxAmount/(uint64(1)<<t.l1) + 1,
+		maxAmount: maxAmount,
 	}
-
 	// m == 0 check.
-	if P.Equal(identity) == 1 {
-		return 0, true, nil
+	if P.Equal(ristretto255.NewIdentityElement()) == 1 {
+		s.found = true
 	}
+	return s, nil
+}
 
-	step := uint64(1) << t.l1
-	maxJ := maxAmount/step + 1
+// ResumeSearch continues a search from a checkpoint taken with the same table
+// parameters.
+func (t *Table) ResumeSearch(cp Checkpoint) (*Search, error) {
+	if cp.L1 != t.l1 {
+		return nil, fmt.Errorf("ecdlptable: checkpoint L1=%d does not match table L1=%d", cp.L1, t.l1)
+	}
+	s, err := t.NewSearch(cp.MsgPoint[:], cp.MaxAmount)
+	if err != nil {
+		return nil, err
+	}
+	if s.found || cp.NextStep == 0 {
+		return s, nil
+	}
+	if cp.NextStep > s.maxJ+1 {
+		return nil, errors.New("ecdlptable: checkpoint step out of range")
+	}
+	current, err := ristretto255.NewIdentityElement().SetCanonicalBytes(cp.Current[:])
+	if err != nil {
+		return nil, errors.New("ecdlptable: invalid checkpoint point")
+	}
+	// The cursor must be consistent with the target: current = P - next*2^L1*G.
+	offset := ristretto255.NewIdentityElement().ScalarMult(u64ToScalar(cp.NextStep), t.stepPoint)
+	if ristretto255.NewIdentityElement().Add(current, offset).Equal(s.target) != 1 {
+		return nil, errors.New("ecdlptable: checkpoint does not match msgPoint")
+	}
+	s.current, s.next = current, cp.NextStep
+	return s, nil
+}
 
+// Run performs up to steps giant steps. It returns (m, true, true) once the
+// value is found, (0, false, true) when the range is exhausted without a
+// match and (0, false, false) when the search should be continued.
+func (s *Search) Run(steps uint64) (m uint64, found bool, done bool) {
+	if s.found {
+		return s.result, true, true
+	}
+	t := s.table
+	step := uint64(1) << t.l1
+	identity := ristretto255.NewIdentityElement()
 	G := ristretto255.NewGeneratorElement()
-	current := ristretto255.NewIdentityElement().Set(P)
 
-	for j := uint64(0); j <= maxJ; j++ {
+	for n := uint64(0); n < steps && s.next <= s.maxJ; n++ {
+		j := s.next
 		// Check identity: current == 0 means m = j*step.
-		if current.Equal(identity) == 1 {
-			candidate := j * step
-			if candidate <= maxAmount {
-				return candidate, true, nil
+		if s.current.Equal(identity) == 1 {
+			if candidate := j * step; candidate <= s.maxAmount {
+				return s.finish(candidate)
 			}
 		}
 
 		// Positive lookup: candidate = j*step + i.
-		if m, ok := t.tryLookup(current, G, P, j, step, maxAmount, false); ok {
-			return m, true, nil
+		if m, ok := t.tryLookup(s.current, G, s.target, j, step, s.maxAmount, false); ok {
+			return s.finish(m)
 		}
 
 		// Negative lookup (negation trick): candidate = j*step - i.
-		neg := ristretto255.NewIdentityElement().Negate(current)
-		if m, ok := t.tryLookup(neg, G, P, j, step, maxAmount, true); ok {
-			return m, true, nil
+		neg := ristretto255.NewIdentityElement().Negate(s.current)
+		if m, ok := t.tryLookup(neg, G, s.target, j, step, s.maxAmount, true); ok {
+			return s.finish(m)
 		}
 
 		// Advance: current = current - stepPoint.
-		current = ristretto255.NewIdentityElement().Subtract(current, t.stepPoint)
+		s.current = ristretto255.NewIdentityElement().Subtract(s.current, t.stepPoint)
+		s.next++
 	}
+	return 0, false, s.Done()
+}
+
+func (s *Search) finish(m uint64) (uint64, bool, bool) {
+	s.result, s.found = m, true
+	return m, true, true
+}
+
+// Done reports whether the search has finished, with or without a match.
+func (s *Search) Done() bool {
+	return s.found || s.next > s.maxJ
+}
+
+// Progress returns the number of giant steps examined and the total.
+func (s *Search) Progress() (uint64, uint64) {
+	return s.next, s.maxJ + 1
+}
 
-	return 0, false, nil
+// Checkpoint captures the search state for ResumeSearch.
+func (s *Search) Checkpoint() Checkpoint {
+	cp := Checkpoint{
+		L1:        s.table.l1,
+		MaxAmount: s.maxAmount,
+		NextStep:  s.next,
+	}
+	copy(cp.MsgPoint[:], s.target.Bytes())
+	copy(cp.Current[:], s.current.Bytes())
+	return cp
 }
 
 // tryLookup searches the hash table for the fingerprint of point, then
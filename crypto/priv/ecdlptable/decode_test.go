@@ -259,3 +259,74 @@ func TestGenerateBadL1(t *testing.T) {
 		t.Fatal("expected error for l1=33")
 	}
 }
+
+func TestSearchResume(t *testing.T) {
+	const l1 = 10
+	tbl, err := Generate(l1, nil)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	const amount = uint64(123_456)
+	const maxAmount = uint64(1 << 20)
+
+	s, err := tbl.NewSearch(amountToPoint(amount), maxAmount)
+	if err != nil {
+		t.Fatalf("NewSearch: %v", err)
+	}
+	// Advance a few steps at a time, checkpointing and resuming in between
+	// as a wallet would across separate calls.
+	for rounds := 0; ; rounds++ {
+		if rounds > 1000 {
+			t.Fatal("search did not terminate")
+		}
+		m, found, done := s.Run(7)
+		if found {
+			if m != amount {
+				t.Fatalf("found %d, want %d", m, amount)
+			}
+			if rounds == 0 {
+				t.Fatal("expected the search to need several rounds")
+			}
+			break
+		}
+		if done {
+			t.Fatal("search exhausted without a match")
+		}
+		if s, err = tbl.ResumeSearch(s.Checkpoint()); err != nil {
+			t.Fatalf("ResumeSearch: %v", err)
+		}
+	}
+
+	// A checkpoint that does not match its target point is rejected.
+	s, _ = tbl.NewSearch(amountToPoint(amount), maxAmount)
+	s.Run(3)
+	cp := s.Checkpoint()
+	cp.MsgPoint = [32]byte(amountToPoint(amount + 1))
+	if _, err := tbl.ResumeSearch(cp); err == nil {
+		t.Fatal("expected mismatched checkpoint to be rejected")
+	}
+	cp = s.Checkpoint()
+	cp.L1 = l1 + 1
+	if _, err := tbl.ResumeSearch(cp); err == nil {
+		t.Fatal("expected checkpoint for another table to be rejected")
+	}
+}
+
+func TestSolveKangaroo(t *testing.T) {
+	const maxAmount = uint64(1 << 20)
+	for _, amount := range []uint64{0, 1, 63, 64, 1000, 524_287, 999_999, maxAmount} {
+		got, found, err := SolveKangaroo(amountToPoint(amount), maxAmount)
+		if err != nil {
+			t.Fatalf("SolveKangaroo(%d): %v", amount, err)
+		}
+		if !found || got != amount {
+			t.Fatalf("SolveKangaroo(%d) = %d, %v", amount, got, found)
+		}
+	}
+	if _, found, _ := SolveKangaroo(amountToPoint(maxAmount+5), maxAmount); found {
+		t.Fatal("expected value above maxAmount not to be found")
+	}
+	if got, found, _ := SolveKangaroo(amountToPoint(17), 40); !found || got != 17 {
+		t.Fatalf("small-range SolveKangaroo = %d, %v", got, found)
+	}
+}
@@ -0,0 +1,460 @@
+package ecdlptable
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// On-disk table format.
+//
+// Version 2 (written by Save):
+//
+//	[0:4]   magic "BSGS"
+//	[4:8]   version (2)
+//	[8:12]  L1 (uint32)
+//	[12:16] table_len (uint32)
+//	[16:20] chunk_size in bytes (uint32)
+//	[20:24] chunk_count (uint32)
+//	[24:56] root = sha256(chunk hashes)
+//	[56:64] reserved
+//	[64 .. 64 + 32*chunk_count]                chunk hashes (sha256 of each body chunk)
+//	[.. + 4*table_len]                         keys   ([]uint32 LE)
+//	[.. + 8*table_len]                         values ([]uint32 LE)
+//
+// The body (keys followed by values) is split into chunk_size-byte chunks,
+// the last one possibly shorter. Chunks can be verified and transferred
+// independently, which lets a node serve a table to wallets piecewise.
+//
+// Version 1 tables (32-byte header, no checksums) are still accepted by Load.
+const (
+	fileMagic     = "BSGS"
+	fileVersionV1 = uint32(1)
+	fileVersion   = uint32(2)
+	headerSizeV1  = 32
+	headerSize    = 64
+
+	// DefaultChunkSize is the chunk size used by Save.
+	DefaultChunkSize = 1 << 20
+	// MaxChunkSize bounds the chunk size accepted from a manifest.
+	MaxChunkSize = 16 << 20
+)
+
+var (
+	errBadMagic      = errors.New("ecdlptable: bad magic")
+	errChunkChecksum = errors.New("ecdlptable: chunk checksum mismatch")
+)
+
+// Manifest describes a version 2 table file: its parameters and the checksum
+// of every body chunk. The root commits to the whole table.
+type Manifest struct {
+	L1        uint
+	TableLen  uint32
+	ChunkSize uint32
+	Chunks    [][32]byte
+}
+
+// Root returns sha256 over the concatenated chunk hashes.
+func (m *Manifest) Root() [32]byte {
+	h := sha256.New()
+	for _, c := range m.Chunks {
+		h.Write(c[:])
+	}
+	var root [32]byte
+	copy(root[:], h.Sum(nil))
+	return root
+}
+
+// BodySize returns the byte length of the table body.
+func (m *Manifest) BodySize() uint64 {
+	return uint64(m.TableLen) * 8
+}
+
+// ChunkRange returns the body byte range [start, end) of chunk i.
+func (m *Manifest) ChunkRange(i int) (uint64, uint64) {
+	start := uint64(i) * uint64(m.ChunkSize)
+	end := start + uint64(m.ChunkSize)
+	if size := m.BodySize(); end > size {
+		end = size
+	}
+	return start, end
+}
+
+// Validate checks the internal consistency of the manifest.
+func (m *Manifest) Validate() error {
+	if m.L1 == 0 || m.L1 > 32 {
+		return fmt.Errorf("ecdlptable: invalid L1=%d", m.L1)
+	}
+	if m.TableLen != tableLen(uint32(1)<<(m.L1-1)) {
+		return fmt.Errorf("ecdlptable: table_len %d does not match L1=%d", m.TableLen, m.L1)
+	}
+	if m.ChunkSize == 0 || m.ChunkSize%8 != 0 || m.ChunkSize > MaxChunkSize {
+		return fmt.Errorf("ecdlptable: invalid chunk size %d", m.ChunkSize)
+	}
+	want := (m.BodySize() + uint64(m.ChunkSize) - 1) / uint64(m.ChunkSize)
+	if uint64(len(m.Chunks)) != want {
+		return fmt.Errorf("ecdlptable: have %d chunks, want %d", len(m.Chunks), want)
+	}
+	return nil
+}
+
+// dataOffset returns the file offset of the table body.
+func (m *Manifest) dataOffset() int64 {
+	return int64(headerSize) + int64(len(m.Chunks))*32
+}
+
+// encodeHeader serialises the header and chunk hash list.
+func (m *Manifest) encodeHeader() []byte {
+	out := make([]byte, headerSize, m.dataOffset())
+	copy(out[0:4], fileMagic)
+	binary.LittleEndian.PutUint32(out[4:8], fileVersion)
+	binary.LittleEndian.PutUint32(out[8:12], uint32(m.L1))
+	binary.LittleEndian.PutUint32(out[12:16], m.TableLen)
+	binary.LittleEndian.PutUint32(out[16:20], m.ChunkSize)
+	binary.LittleEndian.PutUint32(out[20:24], uint32(len(m.Chunks)))
+	root := m.Root()
+	copy(out[24:56], root[:])
+	for _, c := range m.Chunks {
+		out = append(out, c[:]...)
+	}
+	return out
+}
+
+// readManifest decodes a version 2 header; readHashes supplies the n bytes of
+// the chunk hash list that follows it.
+func readManifest(hdr []byte, readHashes func(n int) ([]byte, error)) (*Manifest, error) {
+	if len(hdr) < headerSize {
+		return nil, errors.New("ecdlptable: file too short for header")
+	}
+	if string(hdr[0:4]) != fileMagic {
+		return nil, errBadMagic
+	}
+	if ver := binary.LittleEndian.Uint32(hdr[4:8]); ver != fileVersion {
+		return nil, fmt.Errorf("ecdlptable: unsupported version %d", ver)
+	}
+	m := &Manifest{
+		L1:        uint(binary.LittleEndian.Uint32(hdr[8:12])),
+		TableLen:  binary.LittleEndian.Uint32(hdr[12:16]),
+		ChunkSize: binary.LittleEndian.Uint32(hdr[16:20]),
+	}
+	count := binary.LittleEndian.Uint32(hdr[20:24])
+	if m.ChunkSize == 0 || uint64(count) != (m.BodySize()+uint64(m.ChunkSize)-1)/uint64(m.ChunkSize) {
+		return nil, errors.New("ecdlptable: inconsistent chunk count")
+	}
+	hashes, err := readHashes(int(count) * 32)
+	if err != nil {
+		return nil, err
+	}
+	m.Chunks = make([][32]byte, count)
+	for i := range m.Chunks {
+		copy(m.Chunks[i][:], hashes[i*32:])
+	}
+	if root := m.Root(); !bytes.Equal(root[:], hdr[24:56]) {
+		return nil, errors.New("ecdlptable: manifest root mismatch")
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// readBody fills dst with the table body starting at byte offset off.
+// The body is the key slice followed by the value slice, little-endian.
+// off and len(dst) must be multiples of 4, which chunk boundaries are.
+func (t *Table) readBody(dst []byte, off uint64) {
+	keysLen := uint64(t.ht.len)
+	for i := 0; i < len(dst); i += 4 {
+		word := (off + uint64(i)) / 4
+		if word < keysLen {
+			binary.LittleEndian.PutUint32(dst[i:], t.ht.keys[word])
+		} else {
+			binary.LittleEndian.PutUint32(dst[i:], t.ht.values[word-keysLen])
+		}
+	}
+}
+
+// Manifest computes the version 2 manifest of the table for the given chunk
+// size.
+func (t *Table) Manifest(chunkSize uint32) (*Manifest, error) {
+	m := &Manifest{L1: t.l1, TableLen: t.ht.len, ChunkSize: chunkSize}
+	if chunkSize == 0 || chunkSize%8 != 0 || chunkSize > MaxChunkSize {
+		return nil, fmt.Errorf("ecdlptable: invalid chunk size %d", chunkSize)
+	}
+	count := int((m.BodySize() + uint64(chunkSize) - 1) / uint64(chunkSize))
+	m.Chunks = make([][32]byte, count)
+	buf := make([]byte, chunkSize)
+	for i := range m.Chunks {
+		start, end := m.ChunkRange(i)
+		chunk := buf[:end-start]
+		t.readBody(chunk, start)
+		m.Chunks[i] = sha256.Sum256(chunk)
+	}
+	return m, nil
+}
+
+// Save writes the table to a version 2 binary file with DefaultChunkSize
+// chunks. See the format description at the top of this file.
+func (t *Table) Save(path string) error {
+	return t.SaveWithChunkSize(path, DefaultChunkSize)
+}
+
+// SaveWithChunkSize writes the table to a version 2 binary file split into
+// chunkSize-byte chunks. chunkSize must be a multiple of 8.
+func (t *Table) SaveWithChunkSize(path string, chunkSize uint32) error {
+	m, err := t.Manifest(chunkSize)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("ecdlptable: create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	if _, err := w.Write(m.encodeHeader()); err != nil {
+		return fmt.Errorf("ecdlptable: write header: %w", err)
+	}
+	buf := make([]byte, m.ChunkSize)
+	for i := range m.Chunks {
+		start, end := m.ChunkRange(i)
+		chunk := buf[:end-start]
+		t.readBody(chunk, start)
+		if _, err := w.Write(chunk); err != nil {
+			return fmt.Errorf("ecdlptable: write body: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("ecdlptable: write body: %w", err)
+	}
+	return f.Sync()
+}
+
+// Load reads a precomputed table from a binary file. Version 2 files are
+// verified chunk by chunk against their manifest.
+func Load(path string) (*Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ecdlptable: read %s: %w", path, err)
+	}
+	if len(data) < headerSizeV1 {
+		return nil, errors.New("ecdlptable: file too short for header")
+	}
+	if string(data[0:4]) != fileMagic {
+		return nil, errBadMagic
+	}
+	if binary.LittleEndian.Uint32(data[4:8]) == fileVersionV1 {
+		return loadV1(data)
+	}
+	m, err := readManifest(data, func(n int) ([]byte, error) {
+		if len(data) < headerSize+n {
+			return nil, errors.New("ecdlptable: file truncated in chunk list")
+		}
+		return data[headerSize : headerSize+n], nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	body := data[m.dataOffset():]
+	if uint64(len(body)) < m.BodySize() {
+		return nil, fmt.Errorf("ecdlptable: file truncated: have %d body bytes, want %d", len(body), m.BodySize())
+	}
+	for i, want := range m.Chunks {
+		start, end := m.ChunkRange(i)
+		if sha256.Sum256(body[start:end]) != want {
+			return nil, fmt.Errorf("%w: chunk %d", errChunkChecksum, i)
+		}
+	}
+	return tableFromBody(m.L1, m.TableLen, body), nil
+}
+
+// loadV1 decodes a legacy version 1 table (no checksums).
+func loadV1(data []byte) (*Table, error) {
+	l1 := binary.LittleEndian.Uint32(data[8:12])
+	tl := binary.LittleEndian.Uint32(data[12:16])
+
+	if l1 == 0 || l1 > 32 {
+		return nil, fmt.Errorf("ecdlptable: invalid L1=%d", l1)
+	}
+	expectedSize := int64(headerSizeV1) + int64(tl)*8
+	if int64(len(data)) < expectedSize {
+		return nil, fmt.Errorf("ecdlptable: file truncated: have %d, want %d", len(data), expectedSize)
+	}
+	return tableFromBody(uint(l1), tl, data[headerSizeV1:]), nil
+}
+
+// tableFromBody decodes the key and value slices of a table body.
+func tableFromBody(l1 uint, tl uint32, body []byte) *Table {
+	keys := make([]uint32, tl)
+	values := make([]uint32, tl)
+	off := 0
+	for i := uint32(0); i < tl; i++ {
+		keys[i] = binary.LittleEndian.Uint32(body[off : off+4])
+		off += 4
+	}
+	for i := uint32(0); i < tl; i++ {
+		values[i] = binary.LittleEndian.Uint32(body[off : off+4])
+		off += 4
+	}
+	return &Table{
+		l1:        l1,
+		babyCount: 1 << (l1 - 1),
+		ht:        hashTableFromSlices(keys, values),
+		stepPoint: computeStepPoint(l1),
+	}
+}
+
+// File is a version 2 table file opened for serving chunks, without loading
+// the table into memory.
+type File struct {
+	f        *os.File
+	manifest *Manifest
+}
+
+// OpenFile opens a version 2 table file and reads its manifest. Chunk
+// contents are verified lazily as they are read.
+func OpenFile(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ecdlptable: open %s: %w", path, err)
+	}
+	m, err := readFileManifest(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if st.Size() < m.dataOffset()+int64(m.BodySize()) {
+		f.Close()
+		return nil, errors.New("ecdlptable: file truncated")
+	}
+	return &File{f: f, manifest: m}, nil
+}
+
+func readFileManifest(f *os.File) (*Manifest, error) {
+	hdr := make([]byte, headerSize)
+	if _, err := f.ReadAt(hdr, 0); err != nil {
+		return nil, fmt.Errorf("ecdlptable: read header: %w", err)
+	}
+	if string(hdr[0:4]) == fileMagic && binary.LittleEndian.Uint32(hdr[4:8]) == fileVersionV1 {
+		return nil, errors.New("ecdlptable: version 1 table has no checksums, regenerate it with priv-generate-table")
+	}
+	return readManifest(hdr, func(n int) ([]byte, error) {
+		hashes := make([]byte, n)
+		if _, err := f.ReadAt(hashes, headerSize); err != nil {
+			return nil, fmt.Errorf("ecdlptable: read chunk list: %w", err)
+		}
+		return hashes, nil
+	})
+}
+
+// Manifest returns the table manifest.
+func (f *File) Manifest() *Manifest { return f.manifest }
+
+// Chunk reads and verifies body chunk i.
+func (f *File) Chunk(i int) ([]byte, error) {
+	if i < 0 || i >= len(f.manifest.Chunks) {
+		return nil, fmt.Errorf("ecdlptable: chunk %d out of range", i)
+	}
+	start, end := f.manifest.ChunkRange(i)
+	chunk := make([]byte, end-start)
+	if _, err := f.f.ReadAt(chunk, f.manifest.dataOffset()+int64(start)); err != nil {
+		return nil, fmt.Errorf("ecdlptable: read chunk %d: %w", i, err)
+	}
+	if sha256.Sum256(chunk) != f.manifest.Chunks[i] {
+		return nil, fmt.Errorf("%w: chunk %d", errChunkChecksum, i)
+	}
+	return chunk, nil
+}
+
+// Close closes the underlying file.
+func (f *File) Close() error { return f.f.Close() }
+
+// Assembly is a version 2 table file being reconstructed from chunks fetched
+// elsewhere (e.g. from a node). Chunks may arrive in any order and across
+// several sessions; once every chunk is present the file can be loaded with
+// Load.
+type Assembly struct {
+	f        *os.File
+	manifest *Manifest
+}
+
+// CreateAssembly opens path for assembling the table described by m. An
+// existing partial assembly of the same table is resumed; any other file at
+// path is overwritten.
+func CreateAssembly(path string, m *Manifest) (*Assembly, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("ecdlptable: open %s: %w", path, err)
+	}
+	if existing, err := readFileManifest(f); err == nil && existing.Root() == m.Root() {
+		return &Assembly{f: f, manifest: existing}, nil
+	}
+	if err := f.Truncate(0); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.WriteAt(m.encodeHeader(), 0); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("ecdlptable: write header: %w", err)
+	}
+	if err := f.Truncate(m.dataOffset() + int64(m.BodySize())); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Assembly{f: f, manifest: m}, nil
+}
+
+// WriteChunk verifies chunk i against the manifest and stores it.
+func (a *Assembly) WriteChunk(i int, data []byte) error {
+	if i < 0 || i >= len(a.manifest.Chunks) {
+		return fmt.Errorf("ecdlptable: chunk %d out of range", i)
+	}
+	if sha256.Sum256(data) != a.manifest.Chunks[i] {
+		return fmt.Errorf("%w: chunk %d", errChunkChecksum, i)
+	}
+	start, _ := a.manifest.ChunkRange(i)
+	if _, err := a.f.WriteAt(data, a.manifest.dataOffset()+int64(start)); err != nil {
+		return fmt.Errorf("ecdlptable: write chunk %d: %w", i, err)
+	}
+	return nil
+}
+
+// Missing returns the indices of chunks not yet stored (or corrupted).
+func (a *Assembly) Missing() ([]int, error) {
+	var (
+		missing []int
+		buf     = make([]byte, a.manifest.ChunkSize)
+	)
+	for i, want := range a.manifest.Chunks {
+		start, end := a.manifest.ChunkRange(i)
+		chunk := buf[:end-start]
+		if _, err := a.f.ReadAt(chunk, a.manifest.dataOffset()+int64(start)); err != nil {
+			return nil, fmt.Errorf("ecdlptable: read chunk %d: %w", i, err)
+		}
+		if sha256.Sum256(chunk) != want {
+			missing = append(missing, i)
+		}
+	}
+	return missing, nil
+}
+
+// Close flushes and closes the assembly file.
+func (a *Assembly) Close() error {
+	if err := a.f.Sync(); err != nil {
+		a.f.Close()
+		return err
+	}
+	return a.f.Close()
+}
@@ -0,0 +1,116 @@
+package ecdlptable
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveRejectsCorruption(t *testing.T) {
+	tbl, err := Generate(13, nil)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "table.bin")
+	if err := tbl.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-3] ^= 0xff
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Fatal("expected corrupted table to be rejected")
+	}
+}
+
+func TestChunkedTransfer(t *testing.T) {
+	const l1 = 13
+	tbl, err := Generate(l1, nil)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	// Serve the table in small chunks so the transfer spans many of them.
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.bin")
+	if err := tbl.SaveWithChunkSize(src, 4096); err != nil {
+		t.Fatalf("SaveWithChunkSize: %v", err)
+	}
+	want, err := tbl.Manifest(4096)
+	if err != nil {
+		t.Fatalf("Manifest: %v", err)
+	}
+	server, err := OpenFile(src)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	defer server.Close()
+	m := server.Manifest()
+	if m.L1 != l1 || m.Root() != want.Root() || len(m.Chunks) < 4 {
+		t.Fatalf("manifest mismatch: %+v", m)
+	}
+
+	// First session: fetch half of the chunks, then stop.
+	dst := filepath.Join(dir, "dst.bin")
+	asm, err := CreateAssembly(dst, m)
+	if err != nil {
+		t.Fatalf("CreateAssembly: %v", err)
+	}
+	for i := 0; i < len(m.Chunks); i += 2 {
+		chunk, err := server.Chunk(i)
+		if err != nil {
+			t.Fatalf("Chunk(%d): %v", i, err)
+		}
+		if err := asm.WriteChunk(i, chunk); err != nil {
+			t.Fatalf("WriteChunk(%d): %v", i, err)
+		}
+	}
+	if err := asm.WriteChunk(1, make([]byte, 8)); err == nil {
+		t.Fatal("expected a chunk with a bad checksum to be rejected")
+	}
+	if err := asm.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := Load(dst); err == nil {
+		t.Fatal("expected a partial table not to load")
+	}
+
+	// Second session resumes and fetches only what is missing.
+	asm, err = CreateAssembly(dst, m)
+	if err != nil {
+		t.Fatalf("CreateAssembly (resume): %v", err)
+	}
+	missing, err := asm.Missing()
+	if err != nil {
+		t.Fatalf("Missing: %v", err)
+	}
+	if len(missing) != len(m.Chunks)/2 {
+		t.Fatalf("have %d missing chunks, want %d", len(missing), len(m.Chunks)/2)
+	}
+	for _, i := range missing {
+		chunk, err := server.Chunk(i)
+		if err != nil {
+			t.Fatalf("Chunk(%d): %v", i, err)
+		}
+		if err := asm.WriteChunk(i, chunk); err != nil {
+			t.Fatalf("WriteChunk(%d): %v", i, err)
+		}
+	}
+	if missing, _ := asm.Missing(); len(missing) != 0 {
+		t.Fatalf("still missing %v", missing)
+	}
+	if err := asm.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	loaded, err := Load(dst)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got, found, err := loaded.Decode(amountToPoint(77_777), 100_000); err != nil || !found || got != 77_777 {
+		t.Fatalf("Decode = %d, %v, %v", got, found, err)
+	}
+}
@@ -0,0 +1,135 @@
+package ecdlptable
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/bits"
+
+	"github.com/tos-network/gtos/crypto/ristretto255"
+)
+
+// kangarooAttempts bounds how many jump functions SolveKangaroo tries before
+// giving up. Each attempt fails with probability ≈ e^-4.
+const kangarooAttempts = 8
+
+// SolveKangaroo finds m ∈ [0, maxAmount] such that msgPoint = m*G using
+// Pollard's lambda (kangaroo) method. It needs no precomputed table and only
+// O(log maxAmount) memory, at the cost of ~6·sqrt(maxAmount) point additions
+// per attempt. It is the fallback for balances beyond the range a table can
+// cover: a 32-bit range takes seconds, the full 64-bit range days of a
+// single core, but never more memory than a few points.
+//
+// Returns (m, true, nil) on success and (0, false, nil) when no m in range was
+// found.
+func SolveKangaroo(msgPoint []byte, maxAmount uint64) (uint64, bool, error) {
+	if len(msgPoint) != 32 {
+		return 0, false, errors.New("ecdlptable: msgPoint must be 32 bytes")
+	}
+	P, err := ristretto255.NewIdentityElement().SetCanonicalBytes(msgPoint)
+	if err != nil {
+		return 0, false, errors.New("ecdlptable: invalid msgPoint encoding")
+	}
+	G := ristretto255.NewGeneratorElement()
+	if P.Equal(ristretto255.NewIdentityElement()) == 1 {
+		return 0, true, nil
+	}
+	if maxAmount < 64 {
+		// Too small an interval for random walks to pay off.
+		current := ristretto255.NewIdentityElement()
+		for m := uint64(1); m <= maxAmount; m++ {
+			current = ristretto255.NewIdentityElement().Add(current, G)
+			if current.Equal(P) == 1 {
+				return m, true, nil
+			}
+		}
+		return 0, false, nil
+	}
+
+	// Jump sizes 2^0 .. 2^(k-1) with mean ≈ sqrt(maxAmount)/2.
+	root := sqrtU64(maxAmount)
+	k := 1
+	for k < 63 && (uint64(1)<<k-1)/uint64(k) < root/2 {
+		k++
+	}
+	jumps := make([]*ristretto255.Element, k)
+	jumps[0] = ristretto255.NewGeneratorElement()
+	for i := 1; i < k; i++ {
+		jumps[i] = ristretto255.NewIdentityElement().Add(jumps[i-1], jumps[i-1])
+	}
+	tameSteps := 2*root + 1
+
+	for attempt := uint64(0); attempt < kangarooAttempts; attempt++ {
+		seed := attempt * 0x9E3779B97F4A7C15
+		index := func(p *ristretto255.Element) int {
+			x := binary.LittleEndian.Uint64(p.Bytes()[8:16]) ^ seed
+			return int((x * 0xBF58476D1CE4E5B9 >> 32) % uint64(k))
+		}
+		// Tame kangaroo: start at maxAmount*G and lay a trap at the end of
+		// its walk, remembering only the trap and the distance travelled.
+		tame := ristretto255.NewIdentityElement().ScalarMult(u64ToScalar(maxAmount), G)
+		var tameDist u128
+		for n := uint64(0); n < tameSteps; n++ {
+			i := index(tame)
+			tame = ristretto255.NewIdentityElement().Add(tame, jumps[i])
+			tameDist.add(uint64(1) << i)
+		}
+		// Wild kangaroo: start at P = m*G. Once it lands on any point of the
+		// tame walk it follows the same path into the trap. Give up once it
+		// has passed the trap.
+		limit := tameDist
+		limit.add(maxAmount)
+		wild := ristretto255.NewIdentityElement().Set(P)
+		var wildDist u128
+		for !limit.less(wildDist) {
+			if wild.Equal(tame) == 1 {
+				// m + wildDist = maxAmount + tameDist.
+				if m, ok := limit.sub(wildDist); ok && m <= maxAmount {
+					if verify(G, P, m) {
+						return m, true, nil
+					}
+				}
+				break
+			}
+			i := index(wild)
+			wild = ristretto255.NewIdentityElement().Add(wild, jumps[i])
+			wildDist.add(uint64(1) << i)
+		}
+	}
+	return 0, false, nil
+}
+
+// u128 is an unsigned 128-bit kangaroo distance.
+type u128 struct{ hi, lo uint64 }
+
+func (a *u128) add(v uint64) {
+	var carry uint64
+	a.lo, carry = bits.Add64(a.lo, v, 0)
+	a.hi += carry
+}
+
+func (a u128) less(b u128) bool {
+	return a.hi < b.hi || (a.hi == b.hi && a.lo < b.lo)
+}
+
+// sub returns a-b when it is non-negative and fits in 64 bits.
+func (a u128) sub(b u128) (uint64, bool) {
+	lo, borrow := bits.Sub64(a.lo, b.lo, 0)
+	hi, borrow := bits.Sub64(a.hi, b.hi, borrow)
+	return lo, borrow == 0 && hi == 0
+}
+
+// sqrtU64 returns floor(sqrt(v)).
+func sqrtU64(v uint64) uint64 {
+	r := uint64(1) << ((bits.Len64(v) + 1) / 2)
+	for {
+		next := (r + v/r) / 2
+		if next >= r {
+			break
+		}
+		r = next
+	}
+	for r*r > v {
+		r--
+	}
+	return r
+}
@@ -9,10 +9,8 @@
 package ecdlptable
 
 import (
-	"encoding/binary"
 	"errors"
 	"fmt"
-	"os"
 
 	"github.com/tos-network/gtos/crypto/ristretto255"
 )
@@ -22,10 +20,6 @@ const (
 	L1Medium uint = 22
 	// L1High gives ~33 M baby entries (~350 MB table).
 	L1High uint = 26
-
-	fileMagic   = "BSGS"
-	fileVersion = uint32(1)
-	headerSize  = 32
 )
 
 // Table is a precomputed BSGS baby-step lookup table.
@@ -97,98 +91,3 @@ func computeStepPoint(l1 uint) *ristretto255.Element {
 	}
 	return p
 }
-
-// Save writes the table to a binary file.
-//
-// Format:
-//
-//	[0:4]   magic "BSGS"
-//	[4:8]   version (1)
-//	[8:12]  L1 (uint32)
-//	[12:16] table_len (uint32)
-//	[16:32] reserved
-//	[32 .. 32 + 4*table_len]                   keys   ([]uint32 LE)
-//	[32 + 4*table_len .. 32 + 8*table_len]     values ([]uint32 LE)
-func (t *Table) Save(path string) error {
-	tl := t.ht.len
-
-	hdr := make([]byte, headerSize)
-	copy(hdr[0:4], fileMagic)
-	binary.LittleEndian.PutUint32(hdr[4:8], fileVersion)
-	binary.LittleEndian.PutUint32(hdr[8:12], uint32(t.l1))
-	binary.LittleEndian.PutUint32(hdr[12:16], tl)
-
-	f, err := os.Create(path)
-	if err != nil {
-		return fmt.Errorf("ecdlptable: create %s: %w", path, err)
-	}
-	defer f.Close()
-
-	if _, err := f.Write(hdr); err != nil {
-		return fmt.Errorf("ecdlptable: write header: %w", err)
-	}
-
-	buf := make([]byte, 4)
-	for _, v := range t.ht.keys {
-		binary.LittleEndian.PutUint32(buf, v)
-		if _, err := f.Write(buf); err != nil {
-			return fmt.Errorf("ecdlptable: write keys: %w", err)
-		}
-	}
-	for _, v := range t.ht.values {
-		binary.LittleEndian.PutUint32(buf, v)
-		if _, err := f.Write(buf); err != nil {
-			return fmt.Errorf("ecdlptable: write values: %w", err)
-		}
-	}
-
-	return f.Sync()
-}
-
-// Load reads a precomputed table from a binary file.
-func Load(path string) (*Table, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("ecdlptable: read %s: %w", path, err)
-	}
-
-	if len(data) < headerSize {
-		return nil, errors.New("ecdlptable: file too short for header")
-	}
-	if string(data[0:4]) != fileMagic {
-		return nil, errors.New("ecdlptable: bad magic")
-	}
-	ver := binary.LittleEndian.Uint32(data[4:8])
-	if ver != fileVersion {
-		return nil, fmt.Errorf("ecdlptable: unsupported version %d", ver)
-	}
-	l1 := binary.LittleEndian.Uint32(data[8:12])
-	tl := binary.LittleEndian.Uint32(data[12:16])
-
-	if l1 == 0 || l1 > 32 {
-		return nil, fmt.Errorf("ecdlptable: invalid L1=%d", l1)
-	}
-	expectedSize := int64(headerSize) + int64(tl)*8
-	if int64(len(data)) < expectedSize {
-		return nil, fmt.Errorf("ecdlptable: file truncated: have %d, want %d", len(data), expectedSize)
-	}
-
-	keys := make([]uint32, tl)
-	values := make([]uint32, tl)
-	off := headerSize
-	for i := uint32(0); i < tl; i++ {
-		keys[i] = binary.LittleEndian.Uint32(data[off : off+4])
-		off += 4
-	}
-	for i := uint32(0); i < tl; i++ {
-		values[i] = binary.LittleEndian.Uint32(data[off : off+4])
-		off += 4
-	}
-
-	return &Table{
-		l1:        uint(l1),
-		babyCount: 1 << (l1 - 1),
-		ht:        hashTableFromSlices(keys, values),
-		stepPoint: computeStepPoint(uint(l1)),
-	}, nil
-}
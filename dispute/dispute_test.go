@@ -0,0 +1,423 @@
+package dispute
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/tos-network/gtos/agent"
+	"github.com/tos-network/gtos/capability"
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/core/rawdb"
+	"github.com/tos-network/gtos/core/state"
+	"github.com/tos-network/gtos/crypto"
+	"github.com/tos-network/gtos/job"
+	"github.com/tos-network/gtos/params"
+	"github.com/tos-network/gtos/reputation"
+	"github.com/tos-network/gtos/settlement"
+	"github.com/tos-network/gtos/sysaction"
+)
+
+func newTestState() *state.StateDB {
+	db := state.NewDatabase(rawdb.NewMemoryDatabase())
+	s, _ := state.New(common.Hash{}, db, nil)
+	return s
+}
+
+func tAddr(b byte) common.Address { return common.Address{b} }
+
+func tos(n int64) *big.Int { return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e18)) }
+
+var (
+	client   = tAddr(0x01)
+	worker   = tAddr(0x02)
+	arbiters = []common.Address{tAddr(0xA1), tAddr(0xA2), tAddr(0xA3), tAddr(0xA4)}
+)
+
+// testBlockHash stands in for the chain's block hashes.
+func testBlockHash(n uint64) common.Hash {
+	return crypto.Keccak256Hash(new(big.Int).SetUint64(n).Bytes())
+}
+
+func do(t *testing.T, st *state.StateDB, from common.Address, value *big.Int, block uint64, action sysaction.ActionKind, payload interface{}) error {
+	t.Helper()
+	data, err := sysaction.MakeSysAction(action, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sysaction.ExecuteWithContext(&sysaction.Context{
+		From:        from,
+		Value:       value,
+		BlockNumber: new(big.Int).SetUint64(block),
+		StateDB:     st,
+		ChainConfig: &params.ChainConfig{},
+		GetHash:     testBlockHash,
+	}, data)
+}
+
+// setup registers client, worker and arbiters as agents, grants the Arbiter
+// capability and runs a 100 TOS job with a 10 TOS bond up to submission.
+// Arbiters stake DisputeArbiterStakeCap, so every draw that samples one
+// seats it.
+func setup(t *testing.T) (*state.StateDB, common.Hash) {
+	t.Helper()
+	st := newTestState()
+	bit, err := capability.RegisterCapabilityName(st, CapabilityName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range append([]common.Address{client, worker}, arbiters...) {
+		stake := params.AgentMinStake
+		if a != client && a != worker {
+			stake = params.DisputeArbiterStakeCap
+		}
+		st.AddBalance(a, new(big.Int).Add(tos(1000), stake))
+		if err := do(t, st, a, stake, 1, sysaction.ActionAgentRegister, nil); err != nil {
+			t.Fatalf("agent register: %v", err)
+		}
+	}
+	for _, a := range arbiters {
+		capability.GrantCapability(st, a, bit)
+	}
+
+	if err := do(t, st, client, tos(100), 10, sysaction.ActionJobPost, map[string]interface{}{
+		"spec_hash": common.Hash{0x5e}.Hex(), "bond": tos(10).String(),
+		"deadline_blocks": 50, "review_blocks": params.JobMinReviewBlocks,
+	}); err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	jobId := job.NewJobID(client, 0)
+	if err := do(t, st, worker, tos(10), 20, sysaction.ActionJobAccept, map[string]string{"job_id": jobId.Hex()}); err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	if err := do(t, st, worker, nil, 30, sysaction.ActionJobSubmit, map[string]string{
+		"job_id": jobId.Hex(), "deliverable": common.Hash{0xde}.Hex(),
+	}); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	return st, jobId
+}
+
+// draw draws the panel of a dispute opened at openedAt and checks that it
+// seats every arbiter.
+func draw(t *testing.T, st *state.StateDB, disputeId common.Hash, openedAt uint64) {
+	t.Helper()
+	if err := do(t, st, client, nil, openedAt+params.DisputeSeedDelayBlocks+1, sysaction.ActionDisputeDraw, drawPayload{DisputeID: disputeId.Hex()}); err != nil {
+		t.Fatalf("draw: %v", err)
+	}
+	d, _ := ReadDispute(st, disputeId)
+	if !d.Drawn || d.Panel != uint64(len(arbiters)) {
+		t.Fatalf("panel: drawn %v size %d", d.Drawn, d.Panel)
+	}
+	for _, a := range arbiters {
+		if !IsPanelMember(st, disputeId, a) {
+			t.Fatalf("arbiter %x not drawn", a)
+		}
+	}
+}
+
+// vote commits at block commitAt and reveals just after the commit window.
+func vote(t *testing.T, st *state.StateDB, disputeId common.Hash, arbiter common.Address, v Vote, commitAt, revealAt uint64) {
+	t.Helper()
+	salt := common.Hash{0x5a, arbiter[0]}
+	if err := do(t, st, arbiter, nil, commitAt, sysaction.ActionDisputeCommit, commitPayload{
+		DisputeID: disputeId.Hex(), Commitment: Commitment(disputeId, arbiter, v, salt).Hex(),
+	}); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if revealAt == 0 {
+		return
+	}
+	if err := do(t, st, arbiter, nil, revealAt, sysaction.ActionDisputeReveal, revealPayload{
+		DisputeID: disputeId.Hex(), Vote: uint8(v), Salt: salt.Hex(),
+	}); err != nil {
+		t.Fatalf("reveal: %v", err)
+	}
+}
+
+func TestDisputeRejectionWorkerWins(t *testing.T) {
+	st, jobId := setup(t)
+	if err := do(t, st, client, nil, 40, sysaction.ActionJobReject, map[string]string{"job_id": jobId.Hex()}); err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+	receiptRef := job.ReceiptRef(jobId)
+	if err := do(t, st, worker, params.DisputeFee, 50, sysaction.ActionDisputeOpen, openPayload{ReceiptRef: receiptRef.Hex()}); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	disputeId := DisputeID(receiptRef)
+
+	// Frozen jobs cannot time out.
+	if err := do(t, st, client, nil, 40+params.JobContestBlocks+1, sysaction.ActionJobTimeout, map[string]string{"job_id": jobId.Hex()}); err == nil {
+		t.Fatal("expected timeout of a disputed job to fail")
+	}
+
+	draw(t, st, disputeId, 50)
+	revealAt := 50 + params.DisputeCommitBlocks + 1
+	vote(t, st, disputeId, arbiters[0], VoteWorker, 60, revealAt)
+	vote(t, st, disputeId, arbiters[1], VoteWorker, 60, revealAt)
+	vote(t, st, disputeId, arbiters[2], VoteClient, 60, revealAt)
+	vote(t, st, disputeId, arbiters[3], VoteClient, 60, 0) // never reveals
+
+	revealEnd := 50 + params.DisputeCommitBlocks + params.DisputeRevealBlocks
+	if err := do(t, st, arbiters[0], nil, revealEnd, sysaction.ActionDisputeResolve, resolvePayload{DisputeID: disputeId.Hex()}); err != ErrRevealNotOver {
+		t.Fatalf("early resolve: want ErrRevealNotOver, got %v", err)
+	}
+	if err := do(t, st, arbiters[0], nil, revealEnd+1, sysaction.ActionDisputeResolve, resolvePayload{DisputeID: disputeId.Hex()}); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+
+	d, _ := ReadDispute(st, disputeId)
+	if d.Outcome != OutcomeWorker || d.Status != StatusResolved {
+		t.Fatalf("unexpected dispute: %+v", d)
+	}
+	if j, _ := job.ReadJob(st, jobId); j.Status != job.JobCompleted {
+		t.Errorf("job status: want Completed, got %d", j.Status)
+	}
+	receipt, _ := settlement.ReadRuntimeReceipt(st, receiptRef)
+	if receipt.Status != settlement.ReceiptStatusSuccess || receipt.ProofRef != disputeId {
+		t.Errorf("unexpected receipt: %+v", receipt)
+	}
+
+	// The client's stake is slashed to the worker.
+	slash := new(big.Int).Div(new(big.Int).Mul(params.AgentMinStake, new(big.Int).SetUint64(params.DisputeSlashBps)), big.NewInt(10_000))
+	if got := agent.ReadStake(st, client); got.Cmp(new(big.Int).Sub(params.AgentMinStake, slash)) != 0 {
+		t.Errorf("client stake: got %v", got)
+	}
+	// The opener receives the indivisible remainder of the fee split and
+	// the stake slashed from the arbiter who did not reveal.
+	share := new(big.Int).Div(params.DisputeFee, big.NewInt(3))
+	silentSlash := new(big.Int).Div(new(big.Int).Mul(params.DisputeArbiterStakeCap, new(big.Int).SetUint64(params.DisputeNoRevealSlashBps)), big.NewInt(10_000))
+	wantWorker := new(big.Int).Add(tos(1100), slash)
+	wantWorker.Add(wantWorker, silentSlash)
+	wantWorker.Sub(wantWorker, new(big.Int).Mul(share, big.NewInt(3)))
+	if got := st.GetBalance(worker); got.Cmp(wantWorker) != 0 {
+		t.Errorf("worker balance: want %v, got %v", wantWorker, got)
+	}
+	if reputation.TotalScoreOf(st, worker).Cmp(ReputationDeltaWon) != 0 ||
+		reputation.TotalScoreOf(st, client).Cmp(ReputationDeltaLost) != 0 {
+		t.Errorf("scores: worker %v client %v", reputation.TotalScoreOf(st, worker), reputation.TotalScoreOf(st, client))
+	}
+
+	// The fee is split among the three arbiters who revealed.
+	if got := st.GetBalance(arbiters[0]); got.Cmp(new(big.Int).Add(tos(1000), share)) != 0 {
+		t.Errorf("arbiter balance: got %v", got)
+	}
+	if got := st.GetBalance(arbiters[3]); got.Cmp(tos(1000)) != 0 {
+		t.Errorf("silent arbiter must not be paid, got %v", got)
+	}
+	if got := agent.ReadStake(st, arbiters[3]); got.Cmp(new(big.Int).Sub(params.DisputeArbiterStakeCap, silentSlash)) != 0 {
+		t.Errorf("silent arbiter stake: got %v", got)
+	}
+	if got := agent.ReadStake(st, arbiters[0]); got.Cmp(params.DisputeArbiterStakeCap) != 0 {
+		t.Errorf("revealing arbiter stake: got %v", got)
+	}
+	if st.GetBalance(params.DisputeRegistryAddress).Sign() != 0 || st.GetBalance(params.JobMarketAddress).Sign() != 0 {
+		t.Error("escrow or fee balance left behind")
+	}
+}
+
+func TestDisputeClientWins(t *testing.T) {
+	st, jobId := setup(t)
+	receiptRef := job.ReceiptRef(jobId)
+	if err := do(t, st, client, params.DisputeFee, 40, sysaction.ActionDisputeOpen, openPayload{ReceiptRef: receiptRef.Hex()}); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	disputeId := DisputeID(receiptRef)
+	draw(t, st, disputeId, 40)
+	vote(t, st, disputeId, arbiters[0], VoteClient, 45, 40+params.DisputeCommitBlocks+1)
+	if err := do(t, st, client, nil, 40+params.DisputeCommitBlocks+params.DisputeRevealBlocks+1, sysaction.ActionDisputeResolve, resolvePayload{DisputeID: disputeId.Hex()}); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if j, _ := job.ReadJob(st, jobId); j.Status != job.JobDefaulted {
+		t.Errorf("job status: want Defaulted, got %d", j.Status)
+	}
+	slash := new(big.Int).Div(new(big.Int).Mul(params.AgentMinStake, new(big.Int).SetUint64(params.DisputeSlashBps)), big.NewInt(10_000))
+	wantClient := new(big.Int).Add(tos(910), slash) // 900 after posting, + budget + bond
+	wantClient.Add(wantClient, tos(100))
+	wantClient.Sub(wantClient, params.DisputeFee)
+	// The three drawn arbiters who never voted are slashed to the opener.
+	silentSlash := new(big.Int).Div(new(big.Int).Mul(params.DisputeArbiterStakeCap, new(big.Int).SetUint64(params.DisputeNoRevealSlashBps)), big.NewInt(10_000))
+	wantClient.Add(wantClient, new(big.Int).Mul(silentSlash, big.NewInt(3)))
+	if got := st.GetBalance(client); got.Cmp(wantClient) != 0 {
+		t.Errorf("client balance: want %v, got %v", wantClient, got)
+	}
+	if reputation.TotalScoreOf(st, worker).Cmp(ReputationDeltaLost) != 0 {
+		t.Errorf("worker score: got %v", reputation.TotalScoreOf(st, worker))
+	}
+}
+
+func TestDisputeUndecided(t *testing.T) {
+	st, jobId := setup(t)
+	receiptRef := job.ReceiptRef(jobId)
+	if err := do(t, st, client, params.DisputeFee, 40, sysaction.ActionDisputeOpen, openPayload{ReceiptRef: receiptRef.Hex()}); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	disputeId := DisputeID(receiptRef)
+	if err := do(t, st, worker, nil, 40+params.DisputeCommitBlocks+params.DisputeRevealBlocks+1, sysaction.ActionDisputeResolve, resolvePayload{DisputeID: disputeId.Hex()}); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if j, _ := job.ReadJob(st, jobId); j.Status != job.JobRefunded {
+		t.Errorf("job status: want Refunded, got %d", j.Status)
+	}
+	// Without a verdict nobody is slashed and the opener gets the fee back.
+	if st.GetBalance(client).Cmp(tos(1000)) != 0 || st.GetBalance(worker).Cmp(tos(1000)) != 0 {
+		t.Errorf("balances: client %v worker %v", st.GetBalance(client), st.GetBalance(worker))
+	}
+	if agent.ReadStake(st, worker).Cmp(params.AgentMinStake) != 0 {
+		t.Error("worker stake must be untouched")
+	}
+	if err := do(t, st, worker, nil, 40+params.DisputeCommitBlocks+params.DisputeRevealBlocks+2, sysaction.ActionDisputeResolve, resolvePayload{DisputeID: disputeId.Hex()}); err != ErrDisputeNotOpen {
+		t.Errorf("double resolve: want ErrDisputeNotOpen, got %v", err)
+	}
+}
+
+func TestDisputeChecks(t *testing.T) {
+	st, jobId := setup(t)
+	receiptRef := job.ReceiptRef(jobId)
+	open := func(from common.Address, value *big.Int, ref common.Hash) error {
+		return do(t, st, from, value, 40, sysaction.ActionDisputeOpen, openPayload{ReceiptRef: ref.Hex()})
+	}
+	if err := open(arbiters[0], params.DisputeFee, receiptRef); err != job.ErrJobNotParty {
+		t.Errorf("outsider open: want ErrJobNotParty, got %v", err)
+	}
+	if err := open(client, tos(2), receiptRef); err != ErrDisputeFeeMismatch {
+		t.Errorf("wrong fee: want ErrDisputeFeeMismatch, got %v", err)
+	}
+	if err := open(client, params.DisputeFee, common.Hash{0x01}); err != ErrNotJobReceipt {
+		t.Errorf("unknown receipt: want ErrNotJobReceipt, got %v", err)
+	}
+	if err := open(client, params.DisputeFee, receiptRef); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := open(worker, params.DisputeFee, receiptRef); err != ErrDisputeExists {
+		t.Errorf("second open: want ErrDisputeExists, got %v", err)
+	}
+
+	disputeId := DisputeID(receiptRef)
+	commit := func(from common.Address, block uint64) error {
+		return do(t, st, from, nil, block, sysaction.ActionDisputeCommit, commitPayload{
+			DisputeID: disputeId.Hex(), Commitment: Commitment(disputeId, from, VoteClient, common.Hash{}).Hex(),
+		})
+	}
+	if err := commit(arbiters[0], 41); err != ErrPanelNotDrawn {
+		t.Errorf("commit before draw: want ErrPanelNotDrawn, got %v", err)
+	}
+
+	// Parties and agents registered after the dispute opened are never drawn.
+	bit, _ := capability.CapabilityBit(st, CapabilityName)
+	capability.GrantCapability(st, worker, bit)
+	late := tAddr(0xA5)
+	st.AddBalance(late, params.DisputeArbiterStakeCap)
+	if err := do(t, st, late, params.DisputeArbiterStakeCap, 41, sysaction.ActionAgentRegister, nil); err != nil {
+		t.Fatalf("agent register: %v", err)
+	}
+	capability.GrantCapability(st, late, bit)
+
+	drawAt := func(block uint64, getHash func(uint64) common.Hash) error {
+		data, _ := sysaction.MakeSysAction(sysaction.ActionDisputeDraw, drawPayload{DisputeID: disputeId.Hex()})
+		return sysaction.ExecuteWithContext(&sysaction.Context{
+			From: client, BlockNumber: new(big.Int).SetUint64(block), StateDB: st,
+			ChainConfig: &params.ChainConfig{}, GetHash: getHash,
+		}, data)
+	}
+	if err := drawAt(40+params.DisputeSeedDelayBlocks, testBlockHash); err != ErrSeedNotReady {
+		t.Errorf("draw at seed block: want ErrSeedNotReady, got %v", err)
+	}
+	// Without the seed block's hash the seed moves to the current block.
+	if err := drawAt(500, nil); err != nil {
+		t.Fatalf("re-seed: %v", err)
+	}
+	if d, _ := ReadDispute(st, disputeId); d.Drawn || d.SeedBlock != 500 {
+		t.Fatalf("re-seed: drawn %v seed block %d", d.Drawn, d.SeedBlock)
+	}
+	if err := drawAt(501, testBlockHash); err != nil {
+		t.Fatalf("draw: %v", err)
+	}
+	if err := drawAt(502, testBlockHash); err != ErrAlreadyDrawn {
+		t.Errorf("second draw: want ErrAlreadyDrawn, got %v", err)
+	}
+	for _, a := range arbiters {
+		if !IsPanelMember(st, disputeId, a) {
+			t.Errorf("arbiter %x not drawn", a)
+		}
+	}
+	if err := commit(worker, 505); err != ErrNotOnPanel {
+		t.Errorf("party commit: want ErrNotOnPanel, got %v", err)
+	}
+	if err := commit(late, 505); err != ErrNotOnPanel {
+		t.Errorf("late agent commit: want ErrNotOnPanel, got %v", err)
+	}
+	if err := commit(arbiters[0], 40+params.DisputeCommitBlocks+1); err != ErrCommitClosed {
+		t.Errorf("late commit: want ErrCommitClosed, got %v", err)
+	}
+	if err := commit(arbiters[0], 505); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if err := commit(arbiters[0], 506); err != ErrAlreadyCommitted {
+		t.Errorf("double commit: want ErrAlreadyCommitted, got %v", err)
+	}
+
+	reveal := func(v Vote, block uint64) error {
+		return do(t, st, arbiters[0], nil, block, sysaction.ActionDisputeReveal, revealPayload{
+			DisputeID: disputeId.Hex(), Vote: uint8(v), Salt: common.Hash{}.Hex(),
+		})
+	}
+	if err := reveal(VoteClient, 510); err != ErrRevealNotOpen {
+		t.Errorf("reveal during commit window: want ErrRevealNotOpen, got %v", err)
+	}
+	revealAt := 40 + params.DisputeCommitBlocks + 1
+	if err := reveal(VoteWorker, revealAt); err != ErrCommitmentMismatch {
+		t.Errorf("changed vote: want ErrCommitmentMismatch, got %v", err)
+	}
+	if err := reveal(VoteClient, revealAt); err != nil {
+		t.Fatalf("reveal: %v", err)
+	}
+	if err := reveal(VoteClient, revealAt); err != ErrAlreadyRevealed {
+		t.Errorf("double reveal: want ErrAlreadyRevealed, got %v", err)
+	}
+}
+
+func TestDisputeDrawStakeWeighted(t *testing.T) {
+	st, jobId := setup(t)
+	// Twenty arbiters at the minimum stake alongside the four at the cap.
+	bit, _ := capability.CapabilityBit(st, CapabilityName)
+	var sybils []common.Address
+	for i := 0; i < 20; i++ {
+		a := tAddr(0xC0 + byte(i))
+		st.AddBalance(a, params.AgentMinStake)
+		if err := do(t, st, a, params.AgentMinStake, 1, sysaction.ActionAgentRegister, nil); err != nil {
+			t.Fatalf("agent register: %v", err)
+		}
+		capability.GrantCapability(st, a, bit)
+		sybils = append(sybils, a)
+	}
+	j, _ := job.ReadJob(st, jobId)
+	ctx := &sysaction.Context{StateDB: st}
+	seats := make(map[common.Address]int)
+	const draws = 100
+	for i := 0; i < draws; i++ {
+		disputeId := common.Hash{0xd1, byte(i)}
+		d := &Dispute{Candidates: agent.AgentCount(st)}
+		drawPanel(ctx, disputeId, d, j, crypto.Keccak256Hash(disputeId.Bytes()))
+		if d.Panel != params.DisputePanelSize {
+			t.Fatalf("draw %d: panel size %d", i, d.Panel)
+		}
+		for _, a := range ReadPanel(st, disputeId, d.Panel) {
+			seats[a]++
+		}
+	}
+	if seats[client] != 0 || seats[worker] != 0 {
+		t.Fatalf("parties drawn: client %d worker %d", seats[client], seats[worker])
+	}
+	var capped, small int
+	for _, a := range arbiters {
+		capped += seats[a]
+	}
+	for _, a := range sybils {
+		small += seats[a]
+	}
+	// Per agent, a capped arbiter must win several times the seats of a
+	// minimum-stake one.
+	if capped*len(sybils) < 3*small*len(arbiters) {
+		t.Errorf("seats: %d for %d capped arbiters, %d for %d sybils", capped, len(arbiters), small, len(sybils))
+	}
+}
@@ -0,0 +1,382 @@
+package dispute
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+
+	"github.com/tos-network/gtos/agent"
+	"github.com/tos-network/gtos/capability"
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/crypto"
+	"github.com/tos-network/gtos/job"
+	"github.com/tos-network/gtos/params"
+	"github.com/tos-network/gtos/reputation"
+	"github.com/tos-network/gtos/sysaction"
+)
+
+func init() {
+	sysaction.DefaultRegistry.Register(&disputeHandler{})
+}
+
+type disputeHandler struct{}
+
+func (h *disputeHandler) Actions() []sysaction.ActionKind {
+	return []sysaction.ActionKind{
+		sysaction.ActionDisputeOpen,
+		sysaction.ActionDisputeDraw,
+		sysaction.ActionDisputeCommit,
+		sysaction.ActionDisputeReveal,
+		sysaction.ActionDisputeResolve,
+	}
+}
+
+func (h *disputeHandler) Handle(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	switch sa.Action {
+	case sysaction.ActionDisputeOpen:
+		return h.handleOpen(ctx, sa)
+	case sysaction.ActionDisputeDraw:
+		return h.handleDraw(ctx, sa)
+	case sysaction.ActionDisputeCommit:
+		return h.handleCommit(ctx, sa)
+	case sysaction.ActionDisputeReveal:
+		return h.handleReveal(ctx, sa)
+	case sysaction.ActionDisputeResolve:
+		return h.handleResolve(ctx, sa)
+	}
+	return nil
+}
+
+type openPayload struct {
+	ReceiptRef string `json:"receipt_ref"`        // hex hash of the job's runtime receipt
+	Evidence   string `json:"evidence,omitempty"` // optional hex 32-byte evidence reference
+}
+
+func (h *disputeHandler) handleOpen(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p openPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	receiptRef := common.HexToHash(p.ReceiptRef)
+	jobId, ok := job.JobByReceipt(ctx.StateDB, receiptRef)
+	if !ok {
+		return ErrNotJobReceipt
+	}
+	disputeId := DisputeID(receiptRef)
+	if _, exists := ReadDispute(ctx.StateDB, disputeId); exists {
+		return ErrDisputeExists
+	}
+
+	// 1. The tx value is the dispute fee.
+	if ctx.Value == nil || ctx.Value.Cmp(params.DisputeFee) != 0 {
+		return ErrDisputeFeeMismatch
+	}
+	if ctx.StateDB.GetBalance(ctx.From).Cmp(ctx.Value) < 0 {
+		return ErrInsufficientBalance
+	}
+
+	// 2. Freeze the job; only its parties may dispute it.
+	now := ctx.BlockNumber.Uint64()
+	if _, err := job.Freeze(ctx.StateDB, now, jobId, ctx.From); err != nil {
+		return err
+	}
+
+	// 3. Lock the fee and store the dispute. Only agents registered before
+	// the dispute was opened are candidates for its panel, and the seed is
+	// the hash of a block produced after it was opened.
+	ctx.StateDB.SubBalance(ctx.From, ctx.Value)
+	ctx.StateDB.AddBalance(params.DisputeRegistryAddress, ctx.Value)
+	WriteDispute(ctx.StateDB, disputeId, &Dispute{
+		JobID:      jobId,
+		ReceiptRef: receiptRef,
+		Opener:     ctx.From,
+		Evidence:   common.HexToHash(p.Evidence),
+		Fee:        new(big.Int).Set(ctx.Value),
+		SeedBlock:  now + params.DisputeSeedDelayBlocks,
+		Candidates: agent.AgentCount(ctx.StateDB),
+		CommitEnd:  now + params.DisputeCommitBlocks,
+		RevealEnd:  now + params.DisputeCommitBlocks + params.DisputeRevealBlocks,
+		Status:     StatusOpen,
+	})
+	return nil
+}
+
+type drawPayload struct {
+	DisputeID string `json:"dispute_id"` // hex hash
+}
+
+// handleDraw draws the dispute's panel once the seed block has been produced.
+// It may be sent by anyone during the commit window. If the seed block has
+// left the block hash window, the seed moves to the current block and the
+// draw must be sent again.
+func (h *disputeHandler) handleDraw(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p drawPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	disputeId := common.HexToHash(p.DisputeID)
+	d, err := readOpen(ctx, disputeId)
+	if err != nil {
+		return err
+	}
+	if d.Drawn {
+		return ErrAlreadyDrawn
+	}
+	now := ctx.BlockNumber.Uint64()
+	if now > d.CommitEnd {
+		return ErrCommitClosed
+	}
+	if now <= d.SeedBlock {
+		return ErrSeedNotReady
+	}
+	var hash common.Hash
+	if ctx.GetHash != nil {
+		hash = ctx.GetHash(d.SeedBlock)
+	}
+	if hash == (common.Hash{}) {
+		d.SeedBlock = now
+		WriteDispute(ctx.StateDB, disputeId, d)
+		return nil
+	}
+	j, ok := job.ReadJob(ctx.StateDB, d.JobID)
+	if !ok {
+		return job.ErrJobNotFound
+	}
+	drawPanel(ctx, disputeId, d, j, common.BytesToHash(crypto.Keccak256(disputeId.Bytes(), hash.Bytes())))
+	d.Drawn = true
+	WriteDispute(ctx.StateDB, disputeId, d)
+	return nil
+}
+
+// drawPanel fills the panel by sampling up to DisputeDrawAttempts candidates
+// uniformly and accepting an eligible one with probability
+// min(stake, DisputeArbiterStakeCap) / DisputeArbiterStakeCap, so that the
+// chance of a seat grows with stake rather than with the number of agents an
+// operator registers.
+func drawPanel(ctx *sysaction.Context, disputeId common.Hash, d *Dispute, j *job.Job, seed common.Hash) {
+	if d.Candidates == 0 {
+		return
+	}
+	stakeCap := params.DisputeArbiterStakeCap
+	var buf [8]byte
+	for k := uint64(0); k < params.DisputeDrawAttempts && d.Panel < params.DisputePanelSize; k++ {
+		binary.BigEndian.PutUint64(buf[:], k)
+		r := crypto.Keccak256(seed.Bytes(), buf[:])
+		cand := agent.AgentAt(ctx.StateDB, binary.BigEndian.Uint64(r[:8])%d.Candidates)
+		if IsPanelMember(ctx.StateDB, disputeId, cand) || !isArbiter(ctx, cand, j) {
+			continue
+		}
+		weight := agent.ReadStake(ctx.StateDB, cand)
+		if weight.Cmp(stakeCap) > 0 {
+			weight = stakeCap
+		}
+		roll := new(big.Int).SetBytes(r[8:])
+		if roll.Mod(roll, stakeCap).Cmp(weight) >= 0 {
+			continue
+		}
+		writePanelMember(ctx.StateDB, disputeId, d.Panel, cand)
+		d.Panel++
+	}
+}
+
+type commitPayload struct {
+	DisputeID  string `json:"dispute_id"` // hex hash
+	Commitment string `json:"commitment"` // hex hash, see Commitment
+}
+
+func (h *disputeHandler) handleCommit(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p commitPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	disputeId := common.HexToHash(p.DisputeID)
+	d, err := readOpen(ctx, disputeId)
+	if err != nil {
+		return err
+	}
+	if ctx.BlockNumber.Uint64() > d.CommitEnd {
+		return ErrCommitClosed
+	}
+	if !d.Drawn {
+		return ErrPanelNotDrawn
+	}
+	if !IsPanelMember(ctx.StateDB, disputeId, ctx.From) {
+		return ErrNotOnPanel
+	}
+	if ReadCommitment(ctx.StateDB, disputeId, ctx.From) != (common.Hash{}) {
+		return ErrAlreadyCommitted
+	}
+	commitment := common.HexToHash(p.Commitment)
+	if commitment == (common.Hash{}) {
+		return ErrInvalidCommitment
+	}
+	writeCommitment(ctx.StateDB, disputeId, ctx.From, commitment)
+	return nil
+}
+
+type revealPayload struct {
+	DisputeID string `json:"dispute_id"` // hex hash
+	Vote      uint8  `json:"vote"`       // 1 = client, 2 = worker
+	Salt      string `json:"salt"`       // hex 32 bytes
+}
+
+func (h *disputeHandler) handleReveal(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p revealPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	disputeId := common.HexToHash(p.DisputeID)
+	d, err := readOpen(ctx, disputeId)
+	if err != nil {
+		return err
+	}
+	now := ctx.BlockNumber.Uint64()
+	if now <= d.CommitEnd || now > d.RevealEnd {
+		return ErrRevealNotOpen
+	}
+	commitment := ReadCommitment(ctx.StateDB, disputeId, ctx.From)
+	if commitment == (common.Hash{}) {
+		return ErrNotCommitted
+	}
+	if ReadRevealedVote(ctx.StateDB, disputeId, ctx.From) != 0 {
+		return ErrAlreadyRevealed
+	}
+	vote := Vote(p.Vote)
+	if vote != VoteClient && vote != VoteWorker {
+		return ErrInvalidVote
+	}
+	if Commitment(disputeId, ctx.From, vote, common.HexToHash(p.Salt)) != commitment {
+		return ErrCommitmentMismatch
+	}
+	writeRevealedVote(ctx.StateDB, disputeId, ctx.From, vote)
+	if vote == VoteClient {
+		d.VotesClient++
+	} else {
+		d.VotesWorker++
+	}
+	WriteDispute(ctx.StateDB, disputeId, d)
+	return nil
+}
+
+type resolvePayload struct {
+	DisputeID string `json:"dispute_id"` // hex hash
+}
+
+// handleResolve applies the panel's verdict once the reveal window is over.
+// It may be sent by anyone.
+func (h *disputeHandler) handleResolve(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p resolvePayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	disputeId := common.HexToHash(p.DisputeID)
+	d, err := readOpen(ctx, disputeId)
+	if err != nil {
+		return err
+	}
+	now := ctx.BlockNumber.Uint64()
+	if now <= d.RevealEnd {
+		return ErrRevealNotOver
+	}
+	j, ok := job.ReadJob(ctx.StateDB, d.JobID)
+	if !ok {
+		return job.ErrJobNotFound
+	}
+
+	// 1. Release the job escrow according to the majority verdict.
+	switch {
+	case d.VotesClient > d.VotesWorker:
+		d.Outcome = OutcomeClient
+		err = job.ResolveForClient(ctx.StateDB, now, d.JobID, disputeId)
+	case d.VotesWorker > d.VotesClient:
+		d.Outcome = OutcomeWorker
+		err = job.ResolveForWorker(ctx.StateDB, now, d.JobID, disputeId)
+	default:
+		d.Outcome = OutcomeUndecided
+		err = job.ResolveUndecided(ctx.StateDB, now, d.JobID, disputeId)
+	}
+	if err != nil {
+		return err
+	}
+
+	// 2. Slash the loser's agent stake to the winner and score both parties.
+	if d.Outcome != OutcomeUndecided {
+		winner, loser := j.Client, j.Worker
+		if d.Outcome == OutcomeWorker {
+			winner, loser = j.Worker, j.Client
+		}
+		slash := new(big.Int).Mul(agent.ReadStake(ctx.StateDB, loser), new(big.Int).SetUint64(params.DisputeSlashBps))
+		slash.Div(slash, big.NewInt(10_000))
+		agent.SlashStake(ctx.StateDB, loser, slash, winner)
+		reputation.RecordScore(ctx.StateDB, winner, ReputationDeltaWon)
+		reputation.RecordScore(ctx.StateDB, loser, ReputationDeltaLost)
+	}
+
+	// 3. Share the fee among the arbiters who revealed; any remainder
+	// returns to the opener, together with the stake slashed from panel
+	// members who did not reveal.
+	if err := payFee(ctx, disputeId, d); err != nil {
+		return err
+	}
+	for _, arbiter := range ReadPanel(ctx.StateDB, disputeId, d.Panel) {
+		if ReadRevealedVote(ctx.StateDB, disputeId, arbiter) != 0 {
+			continue
+		}
+		slash := new(big.Int).Mul(agent.ReadStake(ctx.StateDB, arbiter), new(big.Int).SetUint64(params.DisputeNoRevealSlashBps))
+		slash.Div(slash, big.NewInt(10_000))
+		agent.SlashStake(ctx.StateDB, arbiter, slash, d.Opener)
+	}
+	d.Status = StatusResolved
+	WriteDispute(ctx.StateDB, disputeId, d)
+	return nil
+}
+
+// readOpen reads disputeId and checks that it is still open.
+func readOpen(ctx *sysaction.Context, disputeId common.Hash) (*Dispute, error) {
+	d, ok := ReadDispute(ctx.StateDB, disputeId)
+	if !ok {
+		return nil, ErrDisputeNotFound
+	}
+	if d.Status != StatusOpen {
+		return nil, ErrDisputeNotOpen
+	}
+	return d, nil
+}
+
+// isArbiter reports whether addr is an active, unsuspended agent holding the
+// Arbiter capability and not a party to job j.
+func isArbiter(ctx *sysaction.Context, addr common.Address, j *job.Job) bool {
+	if addr == j.Client || addr == j.Worker {
+		return false
+	}
+	if !agent.IsRegistered(ctx.StateDB, addr) || agent.IsSuspended(ctx.StateDB, addr) ||
+		agent.ReadStatus(ctx.StateDB, addr) != agent.AgentActive {
+		return false
+	}
+	bit, found := capability.CapabilityBit(ctx.StateDB, CapabilityName)
+	return found && capability.HasCapability(ctx.StateDB, addr, bit)
+}
+
+// payFee distributes the dispute fee held at the registry.
+func payFee(ctx *sysaction.Context, disputeId common.Hash, d *Dispute) error {
+	if ctx.StateDB.GetBalance(params.DisputeRegistryAddress).Cmp(d.Fee) < 0 {
+		return ErrFeeBalanceBroken
+	}
+	remaining := new(big.Int).Set(d.Fee)
+	if revealed := d.VotesClient + d.VotesWorker; revealed > 0 {
+		share := new(big.Int).Div(d.Fee, new(big.Int).SetUint64(revealed))
+		for _, arbiter := range ReadPanel(ctx.StateDB, disputeId, d.Panel) {
+			if ReadRevealedVote(ctx.StateDB, disputeId, arbiter) == 0 {
+				continue
+			}
+			ctx.StateDB.SubBalance(params.DisputeRegistryAddress, share)
+			ctx.StateDB.AddBalance(arbiter, share)
+			remaining.Sub(remaining, share)
+		}
+	}
+	if remaining.Sign() > 0 {
+		ctx.StateDB.SubBalance(params.DisputeRegistryAddress, remaining)
+		ctx.StateDB.AddBalance(d.Opener, remaining)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package dispute
+
+import (
+	"encoding/binary"
+
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/crypto"
+	"github.com/tos-network/gtos/params"
+)
+
+// stateDB is the minimal storage interface required by this package.
+type stateDB interface {
+	GetState(common.Address, common.Hash) common.Hash
+	SetState(common.Address, common.Hash, common.Hash)
+}
+
+// ── Slot helpers ──────────────────────────────────────────────────────────────
+
+// disputeFieldSlot returns the storage slot for a single field of a Dispute.
+// key = keccak256("dispute\x00" || disputeId[32] || field)
+func disputeFieldSlot(disputeId common.Hash, field string) common.Hash {
+	key := append([]byte("dispute\x00"), disputeId.Bytes()...)
+	key = append(key, []byte(field)...)
+	return common.BytesToHash(crypto.Keccak256(key))
+}
+
+// commitmentSlot returns the slot holding arbiter's vote commitment.
+func commitmentSlot(disputeId common.Hash, arbiter common.Address) common.Hash {
+	return common.BytesToHash(crypto.Keccak256([]byte("dispute\x00commit\x00"), disputeId.Bytes(), arbiter.Bytes()))
+}
+
+// revealedSlot returns the slot holding arbiter's revealed vote (0 = none).
+func revealedSlot(disputeId common.Hash, arbiter common.Address) common.Hash {
+	return common.BytesToHash(crypto.Keccak256([]byte("dispute\x00reveal\x00"), disputeId.Bytes(), arbiter.Bytes()))
+}
+
+// panelSlot returns the slot holding the i-th arbiter of a dispute's panel.
+func panelSlot(disputeId common.Hash, i uint64) common.Hash {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], i)
+	return common.BytesToHash(crypto.Keccak256([]byte("dispute\x00panel\x00"), disputeId.Bytes(), buf[:]))
+}
+
+// panelMemberSlot returns the slot flagging arbiter as drawn to the panel.
+func panelMemberSlot(disputeId common.Hash, arbiter common.Address) common.Hash {
+	return common.BytesToHash(crypto.Keccak256([]byte("dispute\x00member\x00"), disputeId.Bytes(), arbiter.Bytes()))
+}
+
+// ── IDs ───────────────────────────────────────────────────────────────────────
+
+// DisputeID returns the ID of the dispute over receiptRef. A receipt can be
+// disputed at most once.
+func DisputeID(receiptRef common.Hash) common.Hash {
+	return common.BytesToHash(crypto.Keccak256([]byte("dispute\x00id\x00"), receiptRef.Bytes()))
+}
+
+// Commitment returns the hidden vote an arbiter submits with DISPUTE_COMMIT.
+// Formula: keccak256(disputeId[32] || arbiter[32] || vote[1] || salt[32])
+func Commitment(disputeId common.Hash, arbiter common.Address, vote Vote, salt common.Hash) common.Hash {
+	return common.BytesToHash(crypto.Keccak256(disputeId.Bytes(), arbiter.Bytes(), []byte{byte(vote)}, salt.Bytes()))
+}
+
+// ── Read / Write ──────────────────────────────────────────────────────────────
+
+// ReadDispute reads a Dispute from state. Returns (dispute, true) if found,
+// (nil, false) if the dispute ID has never been written.
+func ReadDispute(db stateDB, disputeId common.Hash) (*Dispute, bool) {
+	load := func(field string) common.Hash {
+		return db.GetState(params.DisputeRegistryAddress, disputeFieldSlot(disputeId, field))
+	}
+	status := Status(load("status")[31])
+	if status == StatusNone {
+		return nil, false
+	}
+	return &Dispute{
+		JobID:       load("job"),
+		ReceiptRef:  load("receipt"),
+		Opener:      common.BytesToAddress(load("opener").Bytes()),
+		Evidence:    load("evidence"),
+		Fee:         load("fee").Big(),
+		CommitEnd:   load("commitend").Big().Uint64(),
+		RevealEnd:   load("revealend").Big().Uint64(),
+		SeedBlock:   load("seedblock").Big().Uint64(),
+		Candidates:  load("candidates").Big().Uint64(),
+		Drawn:       load("drawn")[31] != 0,
+		Panel:       load("panel").Big().Uint64(),
+		VotesClient: load("votesclient").Big().Uint64(),
+		VotesWorker: load("votesworker").Big().Uint64(),
+		Outcome:     Outcome(load("outcome")[31]),
+		Status:      status,
+	}, true
+}
+
+// WriteDispute persists a Dispute to state, one field per slot.
+func WriteDispute(db stateDB, disputeId common.Hash, d *Dispute) {
+	store := func(field string, val common.Hash) {
+		db.SetState(params.DisputeRegistryAddress, disputeFieldSlot(disputeId, field), val)
+	}
+	u64 := func(v uint64) common.Hash {
+		var h common.Hash
+		binary.BigEndian.PutUint64(h[24:], v)
+		return h
+	}
+	store("job", d.JobID)
+	store("receipt", d.ReceiptRef)
+	store("opener", common.BytesToHash(d.Opener.Bytes()))
+	store("evidence", d.Evidence)
+	store("fee", common.BigToHash(d.Fee))
+	store("commitend", u64(d.CommitEnd))
+	store("revealend", u64(d.RevealEnd))
+	var drawn uint64
+	if d.Drawn {
+		drawn = 1
+	}
+	store("seedblock", u64(d.SeedBlock))
+	store("candidates", u64(d.Candidates))
+	store("drawn", u64(drawn))
+	store("panel", u64(d.Panel))
+	store("votesclient", u64(d.VotesClient))
+	store("votesworker", u64(d.VotesWorker))
+	store("outcome", u64(uint64(d.Outcome)))
+	store("status", u64(uint64(d.Status)))
+}
+
+// ReadCommitment returns arbiter's vote commitment (zero if none).
+func ReadCommitment(db stateDB, disputeId common.Hash, arbiter common.Address) common.Hash {
+	return db.GetState(params.DisputeRegistryAddress, commitmentSlot(disputeId, arbiter))
+}
+
+func writeCommitment(db stateDB, disputeId common.Hash, arbiter common.Address, commitment common.Hash) {
+	db.SetState(params.DisputeRegistryAddress, commitmentSlot(disputeId, arbiter), commitment)
+}
+
+// ReadRevealedVote returns arbiter's revealed vote (0 if not revealed).
+func ReadRevealedVote(db stateDB, disputeId common.Hash, arbiter common.Address) Vote {
+	return Vote(db.GetState(params.DisputeRegistryAddress, revealedSlot(disputeId, arbiter))[31])
+}
+
+func writeRevealedVote(db stateDB, disputeId common.Hash, arbiter common.Address, vote Vote) {
+	var val common.Hash
+	val[31] = byte(vote)
+	db.SetState(params.DisputeRegistryAddress, revealedSlot(disputeId, arbiter), val)
+}
+
+// ReadPanel returns the arbiters drawn to the dispute's panel, in order.
+func ReadPanel(db stateDB, disputeId common.Hash, n uint64) []common.Address {
+	panel := make([]common.Address, n)
+	for i := uint64(0); i < n; i++ {
+		panel[i] = common.BytesToAddress(db.GetState(params.DisputeRegistryAddress, panelSlot(disputeId, i)).Bytes())
+	}
+	return panel
+}
+
+// IsPanelMember reports whether arbiter was drawn to the dispute's panel.
+func IsPanelMember(db stateDB, disputeId common.Hash, arbiter common.Address) bool {
+	return db.GetState(params.DisputeRegistryAddress, panelMemberSlot(disputeId, arbiter))[31] != 0
+}
+
+func writePanelMember(db stateDB, disputeId common.Hash, i uint64, arbiter common.Address) {
+	db.SetState(params.DisputeRegistryAddress, panelSlot(disputeId, i), common.BytesToHash(arbiter.Bytes()))
+	var flag common.Hash
+	flag[31] = 1
+	db.SetState(params.DisputeRegistryAddress, panelMemberSlot(disputeId, arbiter), flag)
+}
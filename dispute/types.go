@@ -0,0 +1,96 @@
+// Package dispute implements arbitration of agent jobs.
+//
+// Either party to a job opens a dispute against the job's runtime receipt,
+// which freezes the job's escrow. DISPUTE_DRAW then draws the panel from the
+// agents holding the Arbiter capability, weighted by stake, using the hash of
+// a block produced after the dispute was opened as the seed. Panel members
+// commit a hidden vote and reveal it once the commit window closes.
+// DISPUTE_RESOLVE applies the majority verdict: it releases the escrow,
+// slashes the losing agent's stake to the winner, records reputation for both
+// parties and slashes panel members who did not reveal.
+package dispute
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/tos-network/gtos/common"
+)
+
+// CapabilityName is the registered capability name arbiters must hold.
+const CapabilityName = "Arbiter"
+
+// Status is the lifecycle state of a dispute.
+type Status uint8
+
+const (
+	StatusNone     Status = 0
+	StatusOpen     Status = 1
+	StatusResolved Status = 2
+)
+
+// Vote is an arbiter's verdict.
+type Vote uint8
+
+const (
+	VoteClient Vote = 1 // the client is right; the worker forfeits
+	VoteWorker Vote = 2 // the worker is right; the worker is paid
+)
+
+// Outcome is the verdict applied by DISPUTE_RESOLVE.
+type Outcome uint8
+
+const (
+	OutcomeUndecided Outcome = 0 // tie or no revealed votes; escrow unwound
+	OutcomeClient    Outcome = 1
+	OutcomeWorker    Outcome = 2
+)
+
+// Reputation deltas recorded when a dispute is resolved with a verdict.
+var (
+	ReputationDeltaWon  = big.NewInt(1)
+	ReputationDeltaLost = big.NewInt(-3)
+)
+
+// Dispute holds all on-chain state for a single dispute.
+type Dispute struct {
+	JobID       common.Hash
+	ReceiptRef  common.Hash
+	Opener      common.Address
+	Evidence    common.Hash // opener's evidence reference
+	Fee         *big.Int
+	CommitEnd   uint64 // last block accepting commitments
+	RevealEnd   uint64 // last block accepting reveals
+	SeedBlock   uint64 // block whose hash seeds the panel draw
+	Candidates  uint64 // agents registered when the dispute was opened
+	Drawn       bool   // panel has been drawn
+	Panel       uint64 // number of arbiters drawn to the panel
+	VotesClient uint64
+	VotesWorker uint64
+	Outcome     Outcome
+	Status      Status
+}
+
+// Sentinel errors returned by dispute system action handlers.
+var (
+	ErrNotJobReceipt       = errors.New("dispute: receipt does not belong to a job")
+	ErrDisputeExists       = errors.New("dispute: receipt already disputed")
+	ErrDisputeFeeMismatch  = errors.New("dispute: value must equal the dispute fee")
+	ErrInsufficientBalance = errors.New("dispute: sender balance below dispute fee")
+	ErrDisputeNotFound     = errors.New("dispute: dispute not found")
+	ErrDisputeNotOpen      = errors.New("dispute: dispute is not open")
+	ErrSeedNotReady        = errors.New("dispute: panel seed block not produced yet")
+	ErrAlreadyDrawn        = errors.New("dispute: panel already drawn")
+	ErrPanelNotDrawn       = errors.New("dispute: panel not drawn yet")
+	ErrNotOnPanel          = errors.New("dispute: caller was not drawn to the panel")
+	ErrCommitClosed        = errors.New("dispute: commit window closed")
+	ErrAlreadyCommitted    = errors.New("dispute: arbiter already committed")
+	ErrInvalidCommitment   = errors.New("dispute: commitment must not be zero")
+	ErrRevealNotOpen       = errors.New("dispute: reveal window not open")
+	ErrNotCommitted        = errors.New("dispute: arbiter has no commitment")
+	ErrAlreadyRevealed     = errors.New("dispute: vote already revealed")
+	ErrInvalidVote         = errors.New("dispute: invalid vote")
+	ErrCommitmentMismatch  = errors.New("dispute: reveal does not match commitment")
+	ErrRevealNotOver       = errors.New("dispute: reveal window not over")
+	ErrFeeBalanceBroken    = errors.New("dispute: fee balance invariant violated")
+)
@@ -15,6 +15,11 @@ type GatewayConfigResult struct {
 	FeeAmount      string         `json:"fee_amount"`
 	Active         bool           `json:"active"`
 	RegisteredAt   uint64         `json:"registered_at"`
+	LastHeartbeat  uint64         `json:"last_heartbeat"`
+	HeartbeatCount uint64         `json:"heartbeat_count"`
+	AvgLatencyMs   uint64         `json:"avg_latency_ms"`
+	UptimeBps      uint64         `json:"uptime_bps"`
+	DeactivatedAt  uint64         `json:"deactivated_at,omitempty"` // set when deactivated for missed heartbeats
 }
 
 // PublicGatewayAPI provides RPC methods for querying gateway state.
@@ -45,6 +50,11 @@ func (api *PublicGatewayAPI) GetGatewayConfig(agent common.Address) (*GatewayCon
 		FeeAmount:      ReadFeeAmount(db, agent).String(),
 		Active:         ReadActive(db, agent),
 		RegisteredAt:   ReadRegisteredAt(db, agent),
+		LastHeartbeat:  ReadLastHeartbeat(db, agent),
+		HeartbeatCount: ReadHeartbeatCount(db, agent),
+		AvgLatencyMs:   ReadAvgLatencyMs(db, agent),
+		UptimeBps:      ReadUptimeBps(db, agent),
+		DeactivatedAt:  ReadDeactivatedAt(db, agent),
 	}, nil
 }
 
@@ -20,6 +20,7 @@ func (h *gatewayHandler) Actions() []sysaction.ActionKind {
 		sysaction.ActionGatewayRegister,
 		sysaction.ActionGatewayUpdate,
 		sysaction.ActionGatewayDeregister,
+		sysaction.ActionGatewayHeartbeat,
 	}
 }
 
@@ -31,6 +32,8 @@ func (h *gatewayHandler) Handle(ctx *sysaction.Context, sa *sysaction.SysAction)
 		return h.handleUpdate(ctx, sa)
 	case sysaction.ActionGatewayDeregister:
 		return h.handleDeregister(ctx, sa)
+	case sysaction.ActionGatewayHeartbeat:
+		return h.handleHeartbeat(ctx, sa)
 	}
 	return nil
 }
@@ -107,6 +110,7 @@ func (h *gatewayHandler) handleRegister(ctx *sysaction.Context, sa *sysaction.Sy
 	WriteActive(ctx.StateDB, ctx.From, true)
 	WriteRegisteredAt(ctx.StateDB, ctx.From, ctx.BlockNumber.Uint64())
 	IncrementGatewayCount(ctx.StateDB)
+	resetLiveness(ctx.StateDB, ctx.From, ctx.BlockNumber.Uint64(), ctx.ChainConfig)
 
 	return nil
 }
@@ -122,6 +126,7 @@ func (h *gatewayHandler) handleUpdate(ctx *sysaction.Context, sa *sysaction.SysA
 	if err := json.Unmarshal(sa.Payload, &p); err != nil {
 		return err
 	}
+	seedLiveness(ctx.StateDB, ctx.From, ctx.BlockNumber.Uint64(), ctx.ChainConfig)
 
 	// 3. Apply updates selectively.
 	if p.Endpoint != "" {
@@ -172,3 +177,26 @@ func (h *gatewayHandler) handleDeregister(ctx *sysaction.Context, sa *sysaction.
 
 	return nil
 }
+
+func (h *gatewayHandler) handleHeartbeat(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	// 1. Only an active gateway can post heartbeats; a deactivated one
+	//    must register again.
+	if !ReadActive(ctx.StateDB, ctx.From) {
+		return ErrGatewayNotActive
+	}
+
+	// 2. Parse payload.
+	var p HeartbeatGatewayPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	if p.LatencyMs > MaxHeartbeatLatencyMs {
+		return ErrInvalidLatency
+	}
+
+	// 3. Record stats and extend the liveness deadline.
+	seedLiveness(ctx.StateDB, ctx.From, ctx.BlockNumber.Uint64(), ctx.ChainConfig)
+	recordHeartbeat(ctx.StateDB, ctx.From, ctx.BlockNumber.Uint64(), p.LatencyMs, ctx.ChainConfig)
+
+	return nil
+}
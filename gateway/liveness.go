@@ -0,0 +1,216 @@
+package gateway
+
+import (
+	"encoding/binary"
+
+	"github.com/tos-network/gtos/agent"
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/crypto"
+	"github.com/tos-network/gtos/params"
+)
+
+// ---------- Liveness ----------
+//
+// An active gateway must post a GATEWAY_HEARTBEAT at least once every
+// LivenessWindow blocks. Registration and every heartbeat move the gateway's
+// liveness deadline forward and queue it under the new deadline block;
+// ProcessGatewayLiveness deactivates gateways whose deadline is reached
+// without a newer heartbeat. Gateways registered before liveness tracking
+// get their first deadline from SeedLegacyGateways, or earlier from their
+// next GATEWAY_UPDATE or heartbeat.
+
+// LivenessWindow returns the number of blocks a gateway may go without a
+// heartbeat: GatewayHeartbeatMissEpochs DPoS epochs.
+func LivenessWindow(cfg *params.ChainConfig) uint64 {
+	return params.GatewayHeartbeatMissEpochs * epochLength(cfg)
+}
+
+func epochLength(cfg *params.ChainConfig) uint64 {
+	if cfg != nil && cfg.DPoS != nil && cfg.DPoS.Epoch != 0 {
+		return cfg.DPoS.Epoch
+	}
+	return params.DPoSEpochLength
+}
+
+func readUint64Slot(db stateDB, slot common.Hash) uint64 {
+	raw := db.GetState(registry, slot)
+	return binary.BigEndian.Uint64(raw[24:])
+}
+
+func writeUint64Slot(db stateDB, slot common.Hash, v uint64) {
+	var val common.Hash
+	binary.BigEndian.PutUint64(val[24:], v)
+	db.SetState(registry, slot, val)
+}
+
+func readGwUint64(db stateDB, addr common.Address, field string) uint64 {
+	return readUint64Slot(db, gwSlot(addr, field))
+}
+
+func writeGwUint64(db stateDB, addr common.Address, field string, v uint64) {
+	writeUint64Slot(db, gwSlot(addr, field), v)
+}
+
+// ReadLastHeartbeat returns the block of the gateway's latest heartbeat, or 0.
+func ReadLastHeartbeat(db stateDB, addr common.Address) uint64 {
+	return readGwUint64(db, addr, "lastHeartbeat")
+}
+
+// ReadHeartbeatCount returns the heartbeats posted since registration.
+func ReadHeartbeatCount(db stateDB, addr common.Address) uint64 {
+	return readGwUint64(db, addr, "heartbeatCount")
+}
+
+// ReadAvgLatencyMs returns the mean reported latency since registration.
+func ReadAvgLatencyMs(db stateDB, addr common.Address) uint64 {
+	n := ReadHeartbeatCount(db, addr)
+	if n == 0 {
+		return 0
+	}
+	return readGwUint64(db, addr, "latencySum") / n
+}
+
+// ReadUptimeBps returns, in basis points, the share of epochs from
+// registration through the latest heartbeat in which the gateway posted at
+// least one heartbeat. Combine with ReadLastHeartbeat to judge freshness.
+func ReadUptimeBps(db stateDB, addr common.Address) uint64 {
+	beats := readGwUint64(db, addr, "heartbeatEpochs")
+	if beats == 0 {
+		return 0
+	}
+	span := readGwUint64(db, addr, "lastHeartbeatEpoch") - readGwUint64(db, addr, "registeredEpoch") + 1
+	return beats * 10_000 / span
+}
+
+// ReadLivenessDeadline returns the block at which the gateway is
+// deactivated unless it posts another heartbeat.
+func ReadLivenessDeadline(db stateDB, addr common.Address) uint64 {
+	return readGwUint64(db, addr, "livenessDeadline")
+}
+
+// ReadDeactivatedAt returns the block at which the gateway was last
+// deactivated for missing heartbeats, or 0.
+func ReadDeactivatedAt(db stateDB, addr common.Address) uint64 {
+	return readGwUint64(db, addr, "deactivatedAt")
+}
+
+// resetLiveness clears the liveness stats of a newly registered gateway and
+// starts its first window at blockNum.
+func resetLiveness(db stateDB, addr common.Address, blockNum uint64, cfg *params.ChainConfig) {
+	writeGwUint64(db, addr, "lastHeartbeat", 0)
+	writeGwUint64(db, addr, "heartbeatCount", 0)
+	writeGwUint64(db, addr, "latencySum", 0)
+	writeGwUint64(db, addr, "heartbeatEpochs", 0)
+	writeGwUint64(db, addr, "lastHeartbeatEpoch", 0)
+	writeGwUint64(db, addr, "registeredEpoch", blockNum/epochLength(cfg))
+	scheduleLivenessCheck(db, addr, blockNum+LivenessWindow(cfg))
+}
+
+// seedLiveness starts the first liveness window of a gateway registered
+// before liveness tracking existed, which has no deadline. Such a gateway
+// has no heartbeats either, so its uptime is counted from blockNum.
+func seedLiveness(db stateDB, addr common.Address, blockNum uint64, cfg *params.ChainConfig) {
+	if ReadLivenessDeadline(db, addr) != 0 {
+		return
+	}
+	if ReadHeartbeatCount(db, addr) == 0 {
+		writeGwUint64(db, addr, "registeredEpoch", blockNum/epochLength(cfg))
+	}
+	scheduleLivenessCheck(db, addr, blockNum+LivenessWindow(cfg))
+}
+
+// gwSeedCursorSlot holds the position in the agent list up to which
+// SeedLegacyGateways has visited agents.
+var gwSeedCursorSlot = common.BytesToHash(crypto.Keccak256([]byte("gw\x00seedCursor")))
+
+// SeedLegacyGateways continues the sweep that gives gateways registered
+// before liveness tracking their first deadline, so that one which never
+// heartbeats or updates is still deactivated. Every gateway is a registered
+// agent, so the sweep walks the append-only agent list, visiting at most
+// GatewayLivenessSeedPerBlock agents per block, and seeds active gateways
+// without a deadline at blockNum. Once it has caught up it only visits newly
+// registered agents, whose gateways already carry deadlines. Returns the
+// number of gateways seeded.
+func SeedLegacyGateways(db stateDB, blockNum uint64, cfg *params.ChainConfig) int {
+	cursor := readUint64Slot(db, gwSeedCursorSlot)
+	end := agent.AgentCount(db)
+	if cursor >= end {
+		return 0
+	}
+	if end-cursor > params.GatewayLivenessSeedPerBlock {
+		end = cursor + params.GatewayLivenessSeedPerBlock
+	}
+	seeded := 0
+	for i := cursor; i < end; i++ {
+		addr := agent.AgentAt(db, i)
+		if ReadActive(db, addr) && ReadLivenessDeadline(db, addr) == 0 {
+			seedLiveness(db, addr, blockNum, cfg)
+			seeded++
+		}
+	}
+	writeUint64Slot(db, gwSeedCursorSlot, end)
+	return seeded
+}
+
+// recordHeartbeat updates the gateway's stats and extends its deadline.
+func recordHeartbeat(db stateDB, addr common.Address, blockNum, latencyMs uint64, cfg *params.ChainConfig) {
+	epoch := blockNum / epochLength(cfg)
+	if n := ReadHeartbeatCount(db, addr); n == 0 || readGwUint64(db, addr, "lastHeartbeatEpoch") != epoch {
+		writeGwUint64(db, addr, "heartbeatEpochs", readGwUint64(db, addr, "heartbeatEpochs")+1)
+		writeGwUint64(db, addr, "lastHeartbeatEpoch", epoch)
+	}
+	writeGwUint64(db, addr, "lastHeartbeat", blockNum)
+	writeGwUint64(db, addr, "heartbeatCount", ReadHeartbeatCount(db, addr)+1)
+	writeGwUint64(db, addr, "latencySum", readGwUint64(db, addr, "latencySum")+latencyMs)
+	scheduleLivenessCheck(db, addr, blockNum+LivenessWindow(cfg))
+}
+
+// scheduleLivenessCheck sets the gateway's deadline and queues it there.
+func scheduleLivenessCheck(db stateDB, addr common.Address, deadline uint64) {
+	if ReadLivenessDeadline(db, addr) == deadline {
+		return
+	}
+	writeGwUint64(db, addr, "livenessDeadline", deadline)
+	n := readUint64Slot(db, gwQlenSlot(deadline))
+	db.SetState(registry, gwQEntrySlot(deadline, n), common.BytesToHash(addr.Bytes()))
+	writeUint64Slot(db, gwQlenSlot(deadline), n+1)
+}
+
+// gwQlenSlot returns the slot for the liveness queue length at blockNum.
+func gwQlenSlot(blockNum uint64) common.Hash {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], blockNum)
+	return common.BytesToHash(crypto.Keccak256(append([]byte("gw\x00qlen\x00"), buf[:]...)))
+}
+
+// gwQEntrySlot returns the slot for the i-th gateway queued at blockNum.
+func gwQEntrySlot(blockNum, i uint64) common.Hash {
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], blockNum)
+	binary.BigEndian.PutUint64(buf[8:], i)
+	return common.BytesToHash(crypto.Keccak256(append([]byte("gw\x00q\x00"), buf[:]...)))
+}
+
+// ProcessGatewayLiveness deactivates the gateways whose liveness deadline is
+// blockNum. Entries superseded by a later heartbeat or re-registration, and
+// gateways that already deregistered, are skipped. It is called with the
+// other scheduled work by both Process() and the miner before user
+// transactions. Returns the number of gateways deactivated.
+func ProcessGatewayLiveness(db stateDB, blockNum uint64) int {
+	n := readUint64Slot(db, gwQlenSlot(blockNum))
+	if n == 0 {
+		return 0
+	}
+	deactivated := 0
+	for i := uint64(0); i < n; i++ {
+		addr := common.BytesToAddress(db.GetState(registry, gwQEntrySlot(blockNum, i)).Bytes())
+		if !ReadActive(db, addr) || ReadLivenessDeadline(db, addr) != blockNum {
+			continue
+		}
+		WriteActive(db, addr, false)
+		writeGwUint64(db, addr, "deactivatedAt", blockNum)
+		deactivated++
+	}
+	db.SetState(registry, gwQlenSlot(blockNum), common.Hash{})
+	return deactivated
+}
@@ -0,0 +1,204 @@
+package gateway
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/crypto"
+	"github.com/tos-network/gtos/params"
+	"github.com/tos-network/gtos/sysaction"
+)
+
+// livenessChainConfig uses 10-block epochs, so the liveness window is
+// GatewayHeartbeatMissEpochs*10 blocks.
+var livenessChainConfig = &params.ChainConfig{DPoS: &params.DPoSConfig{Epoch: 10}}
+
+func registerLiveGateway(t *testing.T, db *handlerMockStateDB, addr common.Address, blockNum uint64) {
+	t.Helper()
+	setupAgentWithGatewayCapability(db, addr)
+	ctx := makeCtx(db, addr, blockNum)
+	ctx.ChainConfig = livenessChainConfig
+	err := (&gatewayHandler{}).Handle(ctx, makeSysAction(sysaction.ActionGatewayRegister, RegisterGatewayPayload{
+		Endpoint:       "https://relay.example.com",
+		SupportedKinds: []string{"paymaster"},
+		MaxRelayGas:    500_000,
+		FeePolicy:      "free",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func heartbeat(db *handlerMockStateDB, addr common.Address, blockNum, latencyMs uint64) error {
+	ctx := makeCtx(db, addr, blockNum)
+	ctx.ChainConfig = livenessChainConfig
+	return (&gatewayHandler{}).Handle(ctx, makeSysAction(sysaction.ActionGatewayHeartbeat, HeartbeatGatewayPayload{LatencyMs: latencyMs}))
+}
+
+func TestGatewayDeactivatedAfterMissedHeartbeats(t *testing.T) {
+	db := newHandlerMockStateDB()
+	registerLiveGateway(t, db, agentAddr, 100)
+	window := LivenessWindow(livenessChainConfig)
+	if window != params.GatewayHeartbeatMissEpochs*10 {
+		t.Fatalf("window = %d", window)
+	}
+
+	if n := ProcessGatewayLiveness(db, 100+window-1); n != 0 || !ReadActive(db, agentAddr) {
+		t.Fatal("gateway should stay active inside the window")
+	}
+	if n := ProcessGatewayLiveness(db, 100+window); n != 1 || ReadActive(db, agentAddr) {
+		t.Fatal("gateway should be deactivated at the deadline")
+	}
+	if got := ReadDeactivatedAt(db, agentAddr); got != 100+window {
+		t.Fatalf("deactivated_at = %d, want %d", got, 100+window)
+	}
+	if err := heartbeat(db, agentAddr, 100+window+1, 10); !errors.Is(err, ErrGatewayNotActive) {
+		t.Fatalf("expected ErrGatewayNotActive, got %v", err)
+	}
+
+	// Registering again restores the gateway and starts a new window.
+	registerLiveGateway(t, db, agentAddr, 200)
+	if !ReadActive(db, agentAddr) || ReadLivenessDeadline(db, agentAddr) != 200+window {
+		t.Fatal("re-registration should reactivate the gateway")
+	}
+}
+
+func TestGatewayHeartbeatExtendsDeadline(t *testing.T) {
+	db := newHandlerMockStateDB()
+	registerLiveGateway(t, db, agentAddr, 100)
+	window := LivenessWindow(livenessChainConfig)
+
+	if err := heartbeat(db, agentAddr, 120, 40); err != nil {
+		t.Fatal(err)
+	}
+	if err := heartbeat(db, agentAddr, 125, 60); err != nil {
+		t.Fatal(err)
+	}
+	// The original deadline and the superseded one are skipped.
+	for _, b := range []uint64{100 + window, 120 + window} {
+		if n := ProcessGatewayLiveness(db, b); n != 0 || !ReadActive(db, agentAddr) {
+			t.Fatalf("gateway deactivated at stale deadline %d", b)
+		}
+	}
+	if n := ProcessGatewayLiveness(db, 125+window); n != 1 || ReadActive(db, agentAddr) {
+		t.Fatal("gateway should be deactivated at the latest deadline")
+	}
+}
+
+func TestGatewayDeregisterSkipsLivenessCheck(t *testing.T) {
+	db := newHandlerMockStateDB()
+	registerLiveGateway(t, db, agentAddr, 100)
+	ctx := makeCtx(db, agentAddr, 110)
+	if err := (&gatewayHandler{}).Handle(ctx, makeSysAction(sysaction.ActionGatewayDeregister, DeregisterGatewayPayload{})); err != nil {
+		t.Fatal(err)
+	}
+	if n := ProcessGatewayLiveness(db, 100+LivenessWindow(livenessChainConfig)); n != 0 {
+		t.Fatalf("deregistered gateway counted as deactivated: %d", n)
+	}
+	if ReadDeactivatedAt(db, agentAddr) != 0 {
+		t.Fatal("deactivated_at should be unset after a voluntary deregister")
+	}
+}
+
+func TestGatewayHeartbeatStats(t *testing.T) {
+	db := newHandlerMockStateDB()
+	registerLiveGateway(t, db, agentAddr, 100) // epoch 10
+
+	if err := heartbeat(db, agentAddr, 101, MaxHeartbeatLatencyMs+1); !errors.Is(err, ErrInvalidLatency) {
+		t.Fatalf("expected ErrInvalidLatency, got %v", err)
+	}
+	if err := heartbeat(db, agentAddr, 102, 10); err != nil {
+		t.Fatal(err)
+	}
+	// Heartbeats in epochs 10 and 12, none in 11.
+	for _, hb := range []struct{ block, latency uint64 }{{105, 20}, {125, 60}} {
+		if err := heartbeat(db, agentAddr, hb.block, hb.latency); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := heartbeat(db, strangerAddr, 125, 10); !errors.Is(err, ErrGatewayNotActive) {
+		t.Fatalf("expected ErrGatewayNotActive, got %v", err)
+	}
+
+	api := NewPublicGatewayAPI(func() stateDB { return db })
+	res, err := api.GetGatewayConfig(agentAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.LastHeartbeat != 125 || res.HeartbeatCount != 3 || res.AvgLatencyMs != 30 {
+		t.Fatalf("unexpected stats: %+v", res)
+	}
+	if res.UptimeBps != 6666 {
+		t.Fatalf("uptime = %d bps, want 6666", res.UptimeBps)
+	}
+}
+
+func TestLegacyGatewaySeededOnUpdate(t *testing.T) {
+	db := newHandlerMockStateDB()
+	window := LivenessWindow(livenessChainConfig)
+
+	// Registered before liveness tracking: active, but no deadline.
+	WriteActive(db, agentAddr, true)
+	WriteRegisteredAt(db, agentAddr, 5)
+	ctx := makeCtx(db, agentAddr, 300)
+	ctx.ChainConfig = livenessChainConfig
+	if err := (&gatewayHandler{}).Handle(ctx, makeSysAction(sysaction.ActionGatewayUpdate, UpdateGatewayPayload{MaxRelayGas: 400_000})); err != nil {
+		t.Fatal(err)
+	}
+	if got := ReadLivenessDeadline(db, agentAddr); got != 300+window {
+		t.Fatalf("deadline = %d, want %d", got, 300+window)
+	}
+	if n := ProcessGatewayLiveness(db, 300+window); n != 1 || ReadActive(db, agentAddr) {
+		t.Fatal("legacy gateway should be deactivated at its seeded deadline")
+	}
+}
+
+func TestLegacyGatewaySeededOnHeartbeat(t *testing.T) {
+	db := newHandlerMockStateDB()
+	WriteActive(db, agentAddr, true)
+	if err := heartbeat(db, agentAddr, 305, 10); err != nil {
+		t.Fatal(err)
+	}
+	if got := ReadLivenessDeadline(db, agentAddr); got != 305+LivenessWindow(livenessChainConfig) {
+		t.Fatalf("deadline = %d", got)
+	}
+	// Uptime counts from the first tracked epoch, not from epoch 0.
+	if got := ReadUptimeBps(db, agentAddr); got != 10_000 {
+		t.Fatalf("uptime = %d bps, want 10000", got)
+	}
+}
+
+func TestLegacyGatewaySeededBySweep(t *testing.T) {
+	db := newHandlerMockStateDB()
+	window := LivenessWindow(livenessChainConfig)
+
+	// A legacy gateway that never heartbeats or updates, listed after a full
+	// sweep batch of other agents.
+	WriteActive(db, agentAddr, true)
+	legacyIndex := params.GatewayLivenessSeedPerBlock
+	var entry, count common.Hash
+	copy(entry[:], agentAddr.Bytes())
+	var idx [8]byte
+	binary.BigEndian.PutUint64(idx[:], legacyIndex)
+	db.SetState(params.AgentRegistryAddress, common.BytesToHash(crypto.Keccak256(append([]byte("agent\x00list\x00"), idx[:]...))), entry)
+	binary.BigEndian.PutUint64(count[24:], legacyIndex+1)
+	db.SetState(params.AgentRegistryAddress, common.BytesToHash(crypto.Keccak256([]byte("agent\x00count"))), count)
+
+	if n := SeedLegacyGateways(db, 300, livenessChainConfig); n != 0 || ReadLivenessDeadline(db, agentAddr) != 0 {
+		t.Fatalf("first batch seeded %d gateways", n)
+	}
+	if n := SeedLegacyGateways(db, 301, livenessChainConfig); n != 1 {
+		t.Fatalf("second batch seeded %d gateways, want 1", n)
+	}
+	if got := ReadLivenessDeadline(db, agentAddr); got != 301+window {
+		t.Fatalf("deadline = %d, want %d", got, 301+window)
+	}
+	if n := SeedLegacyGateways(db, 302, livenessChainConfig); n != 0 || ReadLivenessDeadline(db, agentAddr) != 301+window {
+		t.Fatal("a caught-up sweep must not reseed")
+	}
+	if n := ProcessGatewayLiveness(db, 301+window); n != 1 || ReadActive(db, agentAddr) {
+		t.Fatal("legacy gateway should be deactivated at its seeded deadline")
+	}
+}
@@ -0,0 +1,370 @@
+package gateway
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/tos-network/gtos/accountsigner"
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/common/hexutil"
+	"github.com/tos-network/gtos/core/types"
+	"github.com/tos-network/gtos/crypto"
+	"github.com/tos-network/gtos/params"
+)
+
+// KindPaymaster is the supported_kinds entry a gateway must advertise to
+// sponsor transactions through the relay.
+const KindPaymaster = "paymaster"
+
+// Relay defaults.
+const (
+	DefaultRelayMaxExpiry = 10 * time.Minute
+	DefaultRelayConfigTTL = 30 * time.Second
+)
+
+// feeBasisPoints is the denominator of the "percent" fee policy: FeeAmount
+// is a rate in basis points of the relayed transaction's value. The fee is
+// never less than the gas the gateway sponsors, so zero-value calls are not
+// relayed for free.
+const feeBasisPoints = 10_000
+
+// Sentinel errors returned by the relay.
+var (
+	ErrRelayNoSponsorKey     = errors.New("gateway: relay sponsor key is required")
+	ErrRelayKindUnsupported  = errors.New("gateway: gateway does not advertise the paymaster kind")
+	ErrRelayInvalidTx        = errors.New("gateway: invalid relay transaction")
+	ErrRelayWrongChain       = errors.New("gateway: transaction chain id mismatch")
+	ErrRelayWrongSponsor     = errors.New("gateway: transaction does not name this gateway as sponsor")
+	ErrRelayPolicyMismatch   = errors.New("gateway: sponsor policy hash mismatch")
+	ErrRelayExpiry           = errors.New("gateway: sponsor expiry outside the accepted window")
+	ErrRelayGasTooHigh       = errors.New("gateway: transaction gas exceeds max_relay_gas")
+	ErrRelayFeeRequired      = errors.New("gateway: fee transaction required by fee policy")
+	ErrRelayFeeInvalid       = errors.New("gateway: fee transaction does not pay the gateway fee")
+	ErrRelayStaleNonce       = errors.New("gateway: sponsor nonce is not the next nonce, request a new quote")
+	ErrRelaySubmit           = errors.New("gateway: transaction submission failed")
+	ErrRelayBackendUnhealthy = errors.New("gateway: relay backend unavailable")
+)
+
+// RelayBackend is the node the relay reads gateway state from and submits
+// transactions to. *tosclient.Client implements it.
+type RelayBackend interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+	GetSponsorNonce(ctx context.Context, address common.Address, blockNumber *big.Int) (uint64, error)
+	GetGatewayConfig(ctx context.Context, agent common.Address) (*GatewayConfigResult, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+}
+
+// RelayConfig configures a Relay.
+type RelayConfig struct {
+	// SponsorKey is the secp256k1 key of the registered gateway agent. The
+	// relay co-signs as this account and it pays for relayed gas.
+	SponsorKey *ecdsa.PrivateKey
+	// PolicyHash is the sponsor policy hash relayed transactions must carry.
+	PolicyHash common.Hash
+	// MaxExpiry bounds how far in the future a sponsor expiry may be.
+	MaxExpiry time.Duration
+	// ConfigTTL is how long the on-chain gateway config is cached.
+	ConfigTTL time.Duration
+}
+
+// RelayQuote tells a client how to build a transaction this relay will
+// sponsor. If a fee is due, the client signs two sponsored transactions:
+// a fee transfer to Sponsor using SponsorNonce, then the relayed
+// transaction using SponsorNonce+1 and the next account nonce.
+type RelayQuote struct {
+	ChainID           *hexutil.Big   `json:"chain_id"`
+	Sponsor           common.Address `json:"sponsor"`
+	SponsorSignerType string         `json:"sponsor_signer_type"`
+	SponsorNonce      uint64         `json:"sponsor_nonce"`
+	SponsorExpiry     uint64         `json:"sponsor_expiry"` // milliseconds, like header.Time
+	SponsorPolicyHash common.Hash    `json:"sponsor_policy_hash"`
+	MaxRelayGas       uint64         `json:"max_relay_gas"`
+	FeePolicy         string         `json:"fee_policy"`
+	FeeAmount         string         `json:"fee_amount"`
+}
+
+// RelayRequest carries user-signed sponsored transactions, hex encoded.
+type RelayRequest struct {
+	RawTransaction string `json:"raw_transaction"`
+	FeeTransaction string `json:"fee_transaction,omitempty"`
+}
+
+// RelayResult is returned for an accepted RelayRequest.
+type RelayResult struct {
+	TxHash    common.Hash  `json:"tx_hash"`
+	FeeTxHash *common.Hash `json:"fee_tx_hash,omitempty"`
+}
+
+// Relay co-signs user SignerTx payloads as sponsor on behalf of a registered
+// gateway, enforcing the gateway's on-chain MaxRelayGas and fee policy, and
+// submits them to a node.
+type Relay struct {
+	backend RelayBackend
+	cfg     RelayConfig
+	sponsor common.Address
+	chainID *big.Int
+	signer  types.Signer
+	now     func() time.Time
+
+	mu       sync.Mutex
+	nonce    uint64 // next sponsor nonce, valid while nonceOK
+	nonceOK  bool
+	config   *GatewayConfigResult
+	configAt time.Time
+}
+
+// NewRelay creates a relay for the gateway owning cfg.SponsorKey.
+func NewRelay(ctx context.Context, backend RelayBackend, cfg RelayConfig) (*Relay, error) {
+	if cfg.SponsorKey == nil {
+		return nil, ErrRelayNoSponsorKey
+	}
+	if cfg.MaxExpiry <= 0 {
+		cfg.MaxExpiry = DefaultRelayMaxExpiry
+	}
+	if cfg.ConfigTTL <= 0 {
+		cfg.ConfigTTL = DefaultRelayConfigTTL
+	}
+	chainID, err := backend.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRelayBackendUnhealthy, err)
+	}
+	return &Relay{
+		backend: backend,
+		cfg:     cfg,
+		sponsor: crypto.PubkeyToAddress(cfg.SponsorKey.PublicKey),
+		chainID: chainID,
+		signer:  types.LatestSignerForChainID(chainID),
+		now:     time.Now,
+	}, nil
+}
+
+// Sponsor returns the gateway account the relay sponsors as.
+func (r *Relay) Sponsor() common.Address { return r.sponsor }
+
+// RelayFee returns the fee a gateway with the given fee policy charges to
+// relay a transaction transferring value with the given gas limit.
+func RelayFee(policy string, amount, value *big.Int, gas uint64) *big.Int {
+	if amount == nil {
+		return new(big.Int)
+	}
+	switch policy {
+	case "fixed":
+		return new(big.Int).Set(amount)
+	case "percent":
+		minFee := new(big.Int).Mul(new(big.Int).SetUint64(gas), big.NewInt(params.TxPriceTomi))
+		if value == nil {
+			return minFee
+		}
+		fee := new(big.Int).Mul(value, amount)
+		fee.Quo(fee, big.NewInt(feeBasisPoints))
+		if fee.Cmp(minFee) < 0 {
+			return minFee
+		}
+		return fee
+	default:
+		return new(big.Int)
+	}
+}
+
+// gatewayConfig returns the gateway's on-chain config, cached for ConfigTTL.
+// The caller must hold r.mu.
+func (r *Relay) gatewayConfig(ctx context.Context) (*GatewayConfigResult, error) {
+	if r.config != nil && r.now().Sub(r.configAt) < r.cfg.ConfigTTL {
+		return r.config, nil
+	}
+	conf, err := r.backend.GetGatewayConfig(ctx, r.sponsor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRelayBackendUnhealthy, err)
+	}
+	if !conf.Active {
+		return nil, ErrGatewayNotActive
+	}
+	supported := false
+	for _, k := range conf.SupportedKinds {
+		if k == KindPaymaster {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return nil, ErrRelayKindUnsupported
+	}
+	r.config, r.configAt = conf, r.now()
+	return conf, nil
+}
+
+// nextNonce returns the next sponsor nonce, reading it from the node after
+// startup or a failed submission. The caller must hold r.mu.
+func (r *Relay) nextNonce(ctx context.Context) (uint64, error) {
+	if r.nonceOK {
+		return r.nonce, nil
+	}
+	nonce, err := r.backend.GetSponsorNonce(ctx, r.sponsor, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrRelayBackendUnhealthy, err)
+	}
+	r.nonce, r.nonceOK = nonce, true
+	return nonce, nil
+}
+
+// Quote returns the sponsor fields and fee terms for the next relayed
+// transaction.
+func (r *Relay) Quote(ctx context.Context) (*RelayQuote, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	conf, err := r.gatewayConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := r.nextNonce(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &RelayQuote{
+		ChainID:           (*hexutil.Big)(new(big.Int).Set(r.chainID)),
+		Sponsor:           r.sponsor,
+		SponsorSignerType: accountsigner.SignerTypeSecp256k1,
+		SponsorNonce:      nonce,
+		SponsorExpiry:     uint64(r.now().Add(r.cfg.MaxExpiry).UnixMilli()),
+		SponsorPolicyHash: r.cfg.PolicyHash,
+		MaxRelayGas:       conf.MaxRelayGas,
+		FeePolicy:         conf.FeePolicy,
+		FeeAmount:         conf.FeeAmount,
+	}, nil
+}
+
+// decodeSponsored decodes a hex-encoded transaction and checks that it is a
+// user-signed SignerTx this relay may sponsor. It returns the transaction
+// and its sender.
+func (r *Relay) decodeSponsored(raw string, conf *GatewayConfigResult) (*types.Transaction, common.Address, error) {
+	enc, err := hexutil.Decode(raw)
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf("%w: %v", ErrRelayInvalidTx, err)
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(enc); err != nil {
+		return nil, common.Address{}, fmt.Errorf("%w: %v", ErrRelayInvalidTx, err)
+	}
+	if tx.Type() != types.SignerTxType {
+		return nil, common.Address{}, fmt.Errorf("%w: unsupported type %d", ErrRelayInvalidTx, tx.Type())
+	}
+	if tx.ChainId() == nil || tx.ChainId().Cmp(r.chainID) != 0 {
+		return nil, common.Address{}, ErrRelayWrongChain
+	}
+	if sponsor, ok := tx.SponsorFrom(); !ok || sponsor != r.sponsor {
+		return nil, common.Address{}, ErrRelayWrongSponsor
+	}
+	if st, _ := tx.SponsorSignerType(); st != accountsigner.SignerTypeSecp256k1 {
+		return nil, common.Address{}, ErrRelayWrongSponsor
+	}
+	if ph, _ := tx.SponsorPolicyHash(); ph != r.cfg.PolicyHash {
+		return nil, common.Address{}, ErrRelayPolicyMismatch
+	}
+	now := uint64(r.now().UnixMilli())
+	if expiry, _ := tx.SponsorExpiry(); expiry <= now || expiry > now+uint64(r.cfg.MaxExpiry.Milliseconds()) {
+		return nil, common.Address{}, ErrRelayExpiry
+	}
+	if tx.Gas() > conf.MaxRelayGas {
+		return nil, common.Address{}, ErrRelayGasTooHigh
+	}
+	from, err := types.Sender(r.signer, tx)
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf("%w: %v", ErrRelayInvalidTx, err)
+	}
+	return tx, from, nil
+}
+
+// checkFee verifies that feeTx pays the gateway at least fee from the
+// sender of tx immediately before it.
+func checkFee(feeTx, tx *types.Transaction, feeFrom, from, sponsor common.Address, fee *big.Int) error {
+	if feeFrom != from || feeTx.To() == nil || *feeTx.To() != sponsor || len(feeTx.Data()) != 0 {
+		return ErrRelayFeeInvalid
+	}
+	if feeTx.Value().Cmp(fee) < 0 {
+		return ErrRelayFeeInvalid
+	}
+	feeNonce, _ := feeTx.SponsorNonce()
+	txNonce, _ := tx.SponsorNonce()
+	if feeTx.Nonce()+1 != tx.Nonce() || feeNonce+1 != txNonce {
+		return ErrRelayFeeInvalid
+	}
+	return nil
+}
+
+// cosign adds the relay's sponsor signature to tx.
+func (r *Relay) cosign(tx *types.Transaction) (*types.Transaction, error) {
+	hash := r.signer.Hash(tx)
+	sig, err := crypto.Sign(hash[:], r.cfg.SponsorKey)
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSponsorSignature(sig)
+}
+
+// Relay validates req against the gateway's on-chain config, co-signs its
+// transactions as sponsor and submits them, fee transaction first.
+func (r *Relay) Relay(ctx context.Context, req *RelayRequest) (*RelayResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	conf, err := r.gatewayConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tx, from, err := r.decodeSponsored(req.RawTransaction, conf)
+	if err != nil {
+		return nil, err
+	}
+	feeAmount, ok := new(big.Int).SetString(conf.FeeAmount, 10)
+	if !ok {
+		feeAmount = new(big.Int)
+	}
+	txs := []*types.Transaction{tx}
+	if fee := RelayFee(conf.FeePolicy, feeAmount, tx.Value(), tx.Gas()); fee.Sign() > 0 {
+		if req.FeeTransaction == "" {
+			return nil, ErrRelayFeeRequired
+		}
+		feeTx, feeFrom, err := r.decodeSponsored(req.FeeTransaction, conf)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkFee(feeTx, tx, feeFrom, from, r.sponsor, fee); err != nil {
+			return nil, err
+		}
+		txs = []*types.Transaction{feeTx, tx}
+	}
+
+	nonce, err := r.nextNonce(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if first, _ := txs[0].SponsorNonce(); first != nonce {
+		return nil, fmt.Errorf("%w: have %d, want %d", ErrRelayStaleNonce, first, nonce)
+	}
+	hashes := make([]common.Hash, len(txs))
+	for i, t := range txs {
+		signed, err := r.cosign(t)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.backend.SendTransaction(ctx, signed); err != nil {
+			// Resync from the node; an earlier transaction of this
+			// request may already have been accepted.
+			r.nonceOK = false
+			return nil, fmt.Errorf("%w: %v", ErrRelaySubmit, err)
+		}
+		hashes[i] = signed.Hash()
+		r.nonce++
+	}
+	res := &RelayResult{TxHash: hashes[len(hashes)-1]}
+	if len(hashes) > 1 {
+		res.FeeTxHash = &hashes[0]
+	}
+	return res, nil
+}
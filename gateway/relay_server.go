@@ -0,0 +1,208 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tos-network/gtos/metrics"
+	"github.com/tos-network/gtos/metrics/prometheus"
+	"golang.org/x/time/rate"
+)
+
+// Relay server defaults.
+const (
+	DefaultRelayRateLimit    = 5 // requests per second per client
+	DefaultRelayRateBurst    = 10
+	DefaultRelayMaxBodyBytes = 128 * 1024
+
+	// maxRelayClients bounds the per-client limiter table; it is reset when
+	// full so that a flood of source addresses cannot exhaust memory.
+	maxRelayClients = 10_000
+)
+
+// RelayServerConfig configures the relay HTTP handler.
+type RelayServerConfig struct {
+	RateLimit    rate.Limit // requests per second per client IP
+	RateBurst    int
+	MaxBodyBytes int64
+}
+
+// relayMetrics are registered in a registry owned by the handler, so they
+// are collected whether or not node metrics are enabled.
+type relayMetrics struct {
+	registry    metrics.Registry
+	quotes      metrics.Meter
+	requests    metrics.Meter
+	accepted    metrics.Meter
+	rejected    metrics.Meter
+	rateLimited metrics.Meter
+	submitFail  metrics.Meter
+	latency     metrics.Timer
+}
+
+func newRelayMetrics() *relayMetrics {
+	r := metrics.NewRegistry()
+	return &relayMetrics{
+		registry:    r,
+		quotes:      metrics.NewRegisteredMeterForced("gateway/relay/quotes", r),
+		requests:    metrics.NewRegisteredMeterForced("gateway/relay/requests", r),
+		accepted:    metrics.NewRegisteredMeterForced("gateway/relay/accepted", r),
+		rejected:    metrics.NewRegisteredMeterForced("gateway/relay/rejected", r),
+		rateLimited: metrics.NewRegisteredMeterForced("gateway/relay/ratelimited", r),
+		submitFail:  metrics.NewRegisteredMeterForced("gateway/relay/submitfail", r),
+		latency:     metrics.NewCustomTimer(metrics.NewHistogram(metrics.NewExpDecaySample(1028, 0.015)), metrics.NewMeterForced()),
+	}
+}
+
+// relayServer serves the relay HTTP API.
+type relayServer struct {
+	relay   *Relay
+	cfg     RelayServerConfig
+	metrics *relayMetrics
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// NewRelayHandler returns the HTTP API of relay:
+//
+//	GET  /v1/quote   sponsor fields and fee terms for the next transaction
+//	POST /v1/relay   co-sign and submit a RelayRequest
+//	GET  /healthz    liveness
+//	GET  /metrics    Prometheus metrics
+func NewRelayHandler(relay *Relay, cfg RelayServerConfig) http.Handler {
+	if cfg.RateLimit <= 0 {
+		cfg.RateLimit = DefaultRelayRateLimit
+	}
+	if cfg.RateBurst <= 0 {
+		cfg.RateBurst = DefaultRelayRateBurst
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = DefaultRelayMaxBodyBytes
+	}
+	s := &relayServer{
+		relay:    relay,
+		cfg:      cfg,
+		metrics:  newRelayMetrics(),
+		limiters: make(map[string]*rate.Limiter),
+	}
+	s.metrics.registry.Register("gateway/relay/latency", s.metrics.latency)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		writeRelayJSON(w, http.StatusOK, map[string]any{"ok": true, "sponsor": relay.Sponsor()})
+	})
+	mux.Handle("/metrics", prometheus.Handler(s.metrics.registry))
+	mux.Handle("/v1/quote", s.limit(http.HandlerFunc(s.serveQuote)))
+	mux.Handle("/v1/relay", s.limit(http.HandlerFunc(s.serveRelay)))
+	return mux
+}
+
+// limit applies the per-client rate limit to next.
+func (s *relayServer) limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.limiter(clientIP(r)).Allow() {
+			s.metrics.rateLimited.Mark(1)
+			writeRelayError(w, http.StatusTooManyRequests, errors.New("gateway: rate limit exceeded"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *relayServer) limiter(client string) *rate.Limiter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.limiters[client]
+	if !ok {
+		if len(s.limiters) >= maxRelayClients {
+			s.limiters = make(map[string]*rate.Limiter)
+		}
+		l = rate.NewLimiter(s.cfg.RateLimit, s.cfg.RateBurst)
+		s.limiters[client] = l
+	}
+	return l
+}
+
+func (s *relayServer) serveQuote(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeRelayError(w, http.StatusMethodNotAllowed, errors.New("gateway: method not allowed"))
+		return
+	}
+	s.metrics.quotes.Mark(1)
+	quote, err := s.relay.Quote(r.Context())
+	if err != nil {
+		writeRelayError(w, relayErrorStatus(err), err)
+		return
+	}
+	writeRelayJSON(w, http.StatusOK, quote)
+}
+
+func (s *relayServer) serveRelay(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeRelayError(w, http.StatusMethodNotAllowed, errors.New("gateway: method not allowed"))
+		return
+	}
+	start := time.Now()
+	defer s.metrics.latency.UpdateSince(start)
+	s.metrics.requests.Mark(1)
+
+	var req RelayRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.cfg.MaxBodyBytes)).Decode(&req); err != nil {
+		s.metrics.rejected.Mark(1)
+		writeRelayError(w, http.StatusBadRequest, ErrRelayInvalidTx)
+		return
+	}
+	res, err := s.relay.Relay(r.Context(), &req)
+	if err != nil {
+		if errors.Is(err, ErrRelaySubmit) {
+			s.metrics.submitFail.Mark(1)
+		} else {
+			s.metrics.rejected.Mark(1)
+		}
+		writeRelayError(w, relayErrorStatus(err), err)
+		return
+	}
+	s.metrics.accepted.Mark(1)
+	writeRelayJSON(w, http.StatusOK, res)
+}
+
+// relayErrorStatus maps a relay error to its HTTP status.
+func relayErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrRelayStaleNonce):
+		return http.StatusConflict
+	case errors.Is(err, ErrRelayFeeRequired), errors.Is(err, ErrRelayFeeInvalid):
+		return http.StatusPaymentRequired
+	case errors.Is(err, ErrRelaySubmit):
+		return http.StatusBadGateway
+	case errors.Is(err, ErrRelayBackendUnhealthy), errors.Is(err, ErrGatewayNotActive),
+		errors.Is(err, ErrRelayKindUnsupported):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func writeRelayJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeRelayError(w http.ResponseWriter, status int, err error) {
+	writeRelayJSON(w, status, map[string]string{"error": err.Error()})
+}
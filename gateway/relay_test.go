@@ -0,0 +1,271 @@
+package gateway
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tos-network/gtos/accountsigner"
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/common/hexutil"
+	"github.com/tos-network/gtos/core/types"
+	"github.com/tos-network/gtos/crypto"
+	"github.com/tos-network/gtos/params"
+)
+
+type mockRelayBackend struct {
+	chainID *big.Int
+	nonce   uint64
+	config  *GatewayConfigResult
+	sent    []*types.Transaction
+	sendErr error
+}
+
+func (b *mockRelayBackend) ChainID(context.Context) (*big.Int, error) { return b.chainID, nil }
+
+func (b *mockRelayBackend) GetSponsorNonce(context.Context, common.Address, *big.Int) (uint64, error) {
+	return b.nonce, nil
+}
+
+func (b *mockRelayBackend) GetGatewayConfig(context.Context, common.Address) (*GatewayConfigResult, error) {
+	return b.config, nil
+}
+
+func (b *mockRelayBackend) SendTransaction(_ context.Context, tx *types.Transaction) error {
+	if b.sendErr != nil {
+		return b.sendErr
+	}
+	b.sent = append(b.sent, tx)
+	return nil
+}
+
+func newTestRelay(t *testing.T, feePolicy, feeAmount string) (*Relay, *mockRelayBackend) {
+	t.Helper()
+	sponsorKey, _ := crypto.GenerateKey()
+	backend := &mockRelayBackend{
+		chainID: big.NewInt(1666),
+		nonce:   7,
+		config: &GatewayConfigResult{
+			SupportedKinds: []string{KindPaymaster},
+			MaxRelayGas:    100_000,
+			FeePolicy:      feePolicy,
+			FeeAmount:      feeAmount,
+			Active:         true,
+		},
+	}
+	relay, err := NewRelay(context.Background(), backend, RelayConfig{SponsorKey: sponsorKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return relay, backend
+}
+
+// signRelayTx builds and signs a sponsored transaction from the quote.
+func signRelayTx(t *testing.T, key *ecdsa.PrivateKey, q *RelayQuote, nonce, sponsorNonce, gas uint64, to common.Address, value *big.Int) string {
+	t.Helper()
+	tx := types.NewTx(&types.SignerTx{
+		ChainID:           q.ChainID.ToInt(),
+		Nonce:             nonce,
+		Gas:               gas,
+		To:                &to,
+		Value:             value,
+		From:              crypto.PubkeyToAddress(key.PublicKey),
+		SignerType:        accountsigner.SignerTypeSecp256k1,
+		Sponsor:           q.Sponsor,
+		SponsorSignerType: q.SponsorSignerType,
+		SponsorNonce:      sponsorNonce,
+		SponsorExpiry:     q.SponsorExpiry,
+		SponsorPolicyHash: q.SponsorPolicyHash,
+	})
+	signed, err := types.SignTx(tx, types.LatestSignerForChainID(q.ChainID.ToInt()), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hexutil.Encode(raw)
+}
+
+func TestRelayFee(t *testing.T) {
+	if fee := RelayFee("free", big.NewInt(5), big.NewInt(1000), 21000); fee.Sign() != 0 {
+		t.Fatalf("free fee = %s, want 0", fee)
+	}
+	if fee := RelayFee("fixed", big.NewInt(5), big.NewInt(1000), 21000); fee.Int64() != 5 {
+		t.Fatalf("fixed fee = %s, want 5", fee)
+	}
+	value := new(big.Int).Mul(big.NewInt(1e6), big.NewInt(params.TxPriceTomi))
+	if fee := RelayFee("percent", big.NewInt(250), value, 21000); fee.Cmp(new(big.Int).Div(value, big.NewInt(40))) != 0 {
+		t.Fatalf("percent fee = %s, want %s", fee, new(big.Int).Div(value, big.NewInt(40)))
+	}
+	// A zero-value call still pays for the gas the gateway sponsors.
+	gasCost := big.NewInt(21000 * params.TxPriceTomi)
+	if fee := RelayFee("percent", big.NewInt(250), new(big.Int), 21000); fee.Cmp(gasCost) != 0 {
+		t.Fatalf("zero-value percent fee = %s, want %s", fee, gasCost)
+	}
+}
+
+func TestRelayCosignsAndSubmits(t *testing.T) {
+	relay, backend := newTestRelay(t, "free", "0")
+	userKey, _ := crypto.GenerateKey()
+	to := common.HexToAddress("0x01")
+
+	q, err := relay.Quote(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Sponsor != relay.Sponsor() || q.SponsorNonce != 7 || q.MaxRelayGas != 100_000 {
+		t.Fatalf("unexpected quote: %+v", q)
+	}
+	res, err := relay.Relay(context.Background(), &RelayRequest{
+		RawTransaction: signRelayTx(t, userKey, q, 0, q.SponsorNonce, 50_000, to, big.NewInt(1)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backend.sent) != 1 || res.TxHash != backend.sent[0].Hash() || res.FeeTxHash != nil {
+		t.Fatalf("unexpected submission: %+v", res)
+	}
+
+	// The sponsor signature covers the same hash as the sender's.
+	sent := backend.sent[0]
+	v, r, s, _ := sent.SponsorRawSignatureValues()
+	sig := make([]byte, crypto.SignatureLength)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:64])
+	sig[64] = byte(v.Uint64())
+	hash := types.LatestSignerForChainID(backend.chainID).Hash(sent)
+	pub, err := crypto.SigToPub(hash[:], sig)
+	if err != nil || crypto.PubkeyToAddress(*pub) != relay.Sponsor() {
+		t.Fatalf("sponsor signature does not recover to the gateway: %v", err)
+	}
+
+	// The next quote uses the next sponsor nonce; the old one is stale.
+	if q2, _ := relay.Quote(context.Background()); q2.SponsorNonce != 8 {
+		t.Fatalf("next sponsor nonce = %d, want 8", q2.SponsorNonce)
+	}
+	_, err = relay.Relay(context.Background(), &RelayRequest{
+		RawTransaction: signRelayTx(t, userKey, q, 1, q.SponsorNonce, 50_000, to, big.NewInt(1)),
+	})
+	if !errors.Is(err, ErrRelayStaleNonce) {
+		t.Fatalf("expected ErrRelayStaleNonce, got %v", err)
+	}
+}
+
+func TestRelayRejectsOutsidePolicy(t *testing.T) {
+	relay, backend := newTestRelay(t, "free", "0")
+	userKey, _ := crypto.GenerateKey()
+	to := common.HexToAddress("0x01")
+	q, _ := relay.Quote(context.Background())
+
+	_, err := relay.Relay(context.Background(), &RelayRequest{
+		RawTransaction: signRelayTx(t, userKey, q, 0, q.SponsorNonce, 100_001, to, nil),
+	})
+	if !errors.Is(err, ErrRelayGasTooHigh) {
+		t.Fatalf("expected ErrRelayGasTooHigh, got %v", err)
+	}
+
+	other := *q
+	other.Sponsor = common.HexToAddress("0x02")
+	_, err = relay.Relay(context.Background(), &RelayRequest{
+		RawTransaction: signRelayTx(t, userKey, &other, 0, q.SponsorNonce, 50_000, to, nil),
+	})
+	if !errors.Is(err, ErrRelayWrongSponsor) {
+		t.Fatalf("expected ErrRelayWrongSponsor, got %v", err)
+	}
+
+	late := *q
+	late.SponsorExpiry = uint64(time.Now().Add(time.Hour).UnixMilli())
+	_, err = relay.Relay(context.Background(), &RelayRequest{
+		RawTransaction: signRelayTx(t, userKey, &late, 0, q.SponsorNonce, 50_000, to, nil),
+	})
+	if !errors.Is(err, ErrRelayExpiry) {
+		t.Fatalf("expected ErrRelayExpiry, got %v", err)
+	}
+
+	backend.config.Active = false
+	relay.config = nil
+	if _, err := relay.Quote(context.Background()); !errors.Is(err, ErrGatewayNotActive) {
+		t.Fatalf("expected ErrGatewayNotActive, got %v", err)
+	}
+	if len(backend.sent) != 0 {
+		t.Fatalf("nothing should be submitted, got %d", len(backend.sent))
+	}
+}
+
+func TestRelayFixedFee(t *testing.T) {
+	relay, backend := newTestRelay(t, "fixed", "1000")
+	userKey, _ := crypto.GenerateKey()
+	to := common.HexToAddress("0x01")
+	q, _ := relay.Quote(context.Background())
+
+	relayed := signRelayTx(t, userKey, q, 1, q.SponsorNonce+1, 50_000, to, big.NewInt(1))
+	if _, err := relay.Relay(context.Background(), &RelayRequest{RawTransaction: relayed}); !errors.Is(err, ErrRelayFeeRequired) {
+		t.Fatalf("expected ErrRelayFeeRequired, got %v", err)
+	}
+	short := signRelayTx(t, userKey, q, 0, q.SponsorNonce, 30_000, q.Sponsor, big.NewInt(999))
+	if _, err := relay.Relay(context.Background(), &RelayRequest{RawTransaction: relayed, FeeTransaction: short}); !errors.Is(err, ErrRelayFeeInvalid) {
+		t.Fatalf("expected ErrRelayFeeInvalid, got %v", err)
+	}
+
+	fee := signRelayTx(t, userKey, q, 0, q.SponsorNonce, 30_000, q.Sponsor, big.NewInt(1000))
+	res, err := relay.Relay(context.Background(), &RelayRequest{RawTransaction: relayed, FeeTransaction: fee})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backend.sent) != 2 || res.FeeTxHash == nil || *res.FeeTxHash != backend.sent[0].Hash() || res.TxHash != backend.sent[1].Hash() {
+		t.Fatalf("fee transaction should be submitted first: %+v", res)
+	}
+	if q2, _ := relay.Quote(context.Background()); q2.SponsorNonce != q.SponsorNonce+2 {
+		t.Fatalf("next sponsor nonce = %d, want %d", q2.SponsorNonce, q.SponsorNonce+2)
+	}
+}
+
+func TestRelaySubmitFailureResyncsNonce(t *testing.T) {
+	relay, backend := newTestRelay(t, "free", "0")
+	userKey, _ := crypto.GenerateKey()
+	q, _ := relay.Quote(context.Background())
+
+	backend.sendErr = errors.New("txpool full")
+	_, err := relay.Relay(context.Background(), &RelayRequest{
+		RawTransaction: signRelayTx(t, userKey, q, 0, q.SponsorNonce, 50_000, common.HexToAddress("0x01"), nil),
+	})
+	if !errors.Is(err, ErrRelaySubmit) {
+		t.Fatalf("expected ErrRelaySubmit, got %v", err)
+	}
+	backend.nonce = 9
+	if q2, _ := relay.Quote(context.Background()); q2.SponsorNonce != 9 {
+		t.Fatalf("nonce should be re-read from the node, got %d", q2.SponsorNonce)
+	}
+}
+
+func TestRelayHandler(t *testing.T) {
+	relay, _ := newTestRelay(t, "free", "0")
+	h := NewRelayHandler(relay, RelayServerConfig{RateLimit: 1, RateBurst: 1})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/quote", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"sponsor_nonce":7`) {
+		t.Fatalf("quote: %d %s", rec.Code, rec.Body.String())
+	}
+
+	// The burst of one is used up.
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/relay", strings.NewReader(`{}`)))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "gateway_relay_ratelimited") {
+		t.Fatalf("metrics: %d %s", rec.Code, rec.Body.String())
+	}
+}
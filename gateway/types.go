@@ -21,6 +21,7 @@ const (
 	ActionRegisterGateway   = "GATEWAY_REGISTER"
 	ActionUpdateGateway     = "GATEWAY_UPDATE"
 	ActionDeregisterGateway = "GATEWAY_DEREGISTER"
+	ActionHeartbeatGateway  = "GATEWAY_HEARTBEAT"
 )
 
 // GatewayConfig holds the on-chain configuration for a registered gateway relay.
@@ -56,6 +57,12 @@ type UpdateGatewayPayload struct {
 // DeregisterGatewayPayload is the JSON payload for ActionDeregisterGateway (GATEWAY_DEREGISTER).
 type DeregisterGatewayPayload struct{}
 
+// HeartbeatGatewayPayload is the JSON payload for ActionHeartbeatGateway (GATEWAY_HEARTBEAT).
+// LatencyMs is the relay's self-measured request latency.
+type HeartbeatGatewayPayload struct {
+	LatencyMs uint64 `json:"latency_ms"`
+}
+
 // Sentinel errors returned by gateway handlers.
 var (
 	ErrNotRegisteredAgent   = errors.New("gateway: agent is not registered")
@@ -68,6 +75,7 @@ var (
 	ErrInvalidFeeAmount     = errors.New("gateway: invalid fee_amount")
 	ErrNoSupportedKinds     = errors.New("gateway: supported_kinds must not be empty")
 	ErrMaxRelayGasZero      = errors.New("gateway: max_relay_gas must be > 0")
+	ErrInvalidLatency       = errors.New("gateway: latency_ms exceeds MaxHeartbeatLatencyMs")
 )
 
 // MaxEndpointLength is the maximum stored endpoint string length.
@@ -79,6 +87,9 @@ const MaxSupportedKinds = 16
 // MaxKindLength is the maximum length of a single kind string.
 const MaxKindLength = 32
 
+// MaxHeartbeatLatencyMs is the largest latency a heartbeat may report.
+const MaxHeartbeatLatencyMs = 60_000
+
 // Valid fee policies.
 var validFeePolicies = map[string]bool{
 	"free":    true,
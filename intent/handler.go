@@ -0,0 +1,438 @@
+package intent
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+
+	"github.com/tos-network/gtos/boundary"
+	"github.com/tos-network/gtos/common"
+	vmtypes "github.com/tos-network/gtos/core/vmtypes"
+	"github.com/tos-network/gtos/crypto"
+	"github.com/tos-network/gtos/params"
+	"github.com/tos-network/gtos/reputation"
+	"github.com/tos-network/gtos/sysaction"
+)
+
+func init() {
+	sysaction.DefaultRegistry.Register(&intentHandler{})
+}
+
+type intentHandler struct{}
+
+func (h *intentHandler) Actions() []sysaction.ActionKind {
+	return []sysaction.ActionKind{
+		sysaction.ActionIntentSubmit,
+		sysaction.ActionIntentCancel,
+		sysaction.ActionIntentBid,
+		sysaction.ActionIntentReveal,
+		sysaction.ActionIntentExecute,
+		sysaction.ActionIntentExpire,
+	}
+}
+
+func (h *intentHandler) Handle(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	switch sa.Action {
+	case sysaction.ActionIntentSubmit:
+		return h.handleSubmit(ctx, sa)
+	case sysaction.ActionIntentCancel:
+		return h.handleCancel(ctx, sa)
+	case sysaction.ActionIntentBid:
+		return h.handleBid(ctx, sa)
+	case sysaction.ActionIntentReveal:
+		return h.handleReveal(ctx, sa)
+	case sysaction.ActionIntentExecute:
+		return h.handleExecute(ctx, sa)
+	case sysaction.ActionIntentExpire:
+		return h.handleExpire(ctx, sa)
+	}
+	return nil
+}
+
+type submitPayload struct {
+	Intent    boundary.IntentEnvelope `json:"intent"`
+	BidBlocks uint64                  `json:"bid_blocks"` // sealed-bid window length
+}
+
+// handleSubmit escrows the tx value and opens the sealed-bid window. The
+// envelope's ExpiresAt and the constraints' Deadline are unix seconds and
+// are checked against the block time; the earlier of the two ends execution.
+func (h *intentHandler) handleSubmit(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p submitPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	env := &p.Intent
+	if err := env.Validate(); err != nil || env.Status != boundary.IntentPending {
+		return ErrIntentInvalid
+	}
+	if !boundary.IsCompatible(boundary.SchemaVersion, env.SchemaVersion) {
+		return ErrIntentSchema
+	}
+	if env.Requester != ctx.From {
+		return ErrIntentNotRequester
+	}
+	intentKey := IntentKey(ctx.From, env.IntentID)
+	if _, exists := ReadIntent(ctx.StateDB, intentKey); exists {
+		return ErrIntentExists
+	}
+	c := env.Constraints
+	if c == nil {
+		c = &boundary.IntentConstraints{}
+	}
+
+	// 1. The tx value is the escrow and caps what any plan may spend.
+	if ctx.Value == nil || ctx.Value.Sign() <= 0 {
+		return ErrIntentNoEscrow
+	}
+	if c.MaxValue != nil && c.MaxValue.Cmp(ctx.Value) != 0 {
+		return ErrIntentEscrowMismatch
+	}
+	if ctx.StateDB.GetBalance(ctx.From).Cmp(ctx.Value) < 0 {
+		return ErrInsufficientBalance
+	}
+
+	// 2. Auction windows.
+	if p.BidBlocks < params.IntentMinBidBlocks || p.BidBlocks > params.IntentMaxBidBlocks {
+		return ErrIntentBidWindow
+	}
+	now := ctx.BlockNumber.Uint64()
+	bidEnd := now + p.BidBlocks
+	revealEnd := bidEnd + params.IntentRevealBlocks
+	expiresAt := env.ExpiresAt
+	if c.Deadline != 0 && c.Deadline < expiresAt {
+		expiresAt = c.Deadline
+	}
+	nowSecs := blockSecs(ctx)
+	if expiresAt <= nowSecs || expiresAt > nowSecs+params.IntentMaxHorizonSecs {
+		return ErrIntentDeadline
+	}
+	if len(c.AllowedRecipients) == 0 || len(c.AllowedRecipients) > params.IntentMaxRecipients {
+		return ErrIntentRecipients
+	}
+
+	// 3. Escrow and store.
+	ctx.StateDB.SubBalance(ctx.From, ctx.Value)
+	ctx.StateDB.AddBalance(params.IntentPoolAddress, ctx.Value)
+	WriteIntent(ctx.StateDB, intentKey, &Intent{
+		Requester:         ctx.From,
+		ActionHash:        crypto.Keccak256Hash([]byte(env.Action)),
+		Escrow:            new(big.Int).Set(ctx.Value),
+		Recipients:        c.AllowedRecipients,
+		RequiredTrustTier: c.RequiredTrustTier,
+		MaxGas:            c.MaxGas,
+		BidEnd:            bidEnd,
+		RevealEnd:         revealEnd,
+		Deadline:          revealEnd + params.IntentExecBlocks,
+		ExpiresAt:         expiresAt,
+		Status:            StatusOpen,
+	})
+	return nil
+}
+
+type intentIDPayload struct {
+	IntentID string `json:"intent_id"` // hex IntentKey
+}
+
+// handleCancel refunds an intent while its bid window is still open.
+func (h *intentHandler) handleCancel(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p intentIDPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	intentKey := common.HexToHash(p.IntentID)
+	in, err := readOpen(ctx, intentKey)
+	if err != nil {
+		return err
+	}
+	if ctx.From != in.Requester {
+		return ErrIntentNotRequester
+	}
+	if ctx.BlockNumber.Uint64() > in.BidEnd {
+		return ErrNotCancellable
+	}
+	return closeIntent(ctx.StateDB, intentKey, in, StatusCancelled)
+}
+
+type bidPayload struct {
+	IntentID   string `json:"intent_id"`  // hex IntentKey
+	Commitment string `json:"commitment"` // hex hash, see BidCommitment
+}
+
+func (h *intentHandler) handleBid(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p bidPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	intentKey := common.HexToHash(p.IntentID)
+	in, err := readOpen(ctx, intentKey)
+	if err != nil {
+		return err
+	}
+	if ctx.BlockNumber.Uint64() > in.BidEnd {
+		return ErrIntentBidClosed
+	}
+	if ctx.From == in.Requester {
+		return ErrSolverIsRequester
+	}
+	if SolverTrustTier(ctx.StateDB, ctx.From, ctx.BlockNumber.Uint64()) < in.RequiredTrustTier {
+		return ErrSolverTrustTier
+	}
+	if ReadBid(ctx.StateDB, intentKey, ctx.From) != (common.Hash{}) {
+		return ErrAlreadyBid
+	}
+	if in.Bids >= params.IntentMaxBids {
+		return ErrIntentTooManyBids
+	}
+	commitment := common.HexToHash(p.Commitment)
+	if commitment == (common.Hash{}) {
+		return ErrInvalidCommitment
+	}
+	writeBid(ctx.StateDB, intentKey, ctx.From, commitment)
+	in.Bids++
+	WriteIntent(ctx.StateDB, intentKey, in)
+	return nil
+}
+
+type revealPayload struct {
+	IntentID string              `json:"intent_id"` // hex IntentKey
+	Plan     boundary.PlanRecord `json:"plan"`
+	Salt     string              `json:"salt"` // hex 32 bytes
+}
+
+// handleReveal opens a sealed bid and keeps it if it beats the current best
+// plan. Plans are ranked by lowest EstimatedValue, then lowest EstimatedGas,
+// then lowest PlanHash, so the winner does not depend on reveal order.
+func (h *intentHandler) handleReveal(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p revealPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	intentKey := common.HexToHash(p.IntentID)
+	in, err := readOpen(ctx, intentKey)
+	if err != nil {
+		return err
+	}
+	now := ctx.BlockNumber.Uint64()
+	if now <= in.BidEnd || now > in.RevealEnd {
+		return ErrRevealNotOpen
+	}
+	commitment := ReadBid(ctx.StateDB, intentKey, ctx.From)
+	if commitment == (common.Hash{}) {
+		return ErrNotBidder
+	}
+	if HasRevealed(ctx.StateDB, intentKey, ctx.From) {
+		return ErrAlreadyRevealed
+	}
+	plan := &p.Plan
+	planHash := PlanHash(plan)
+	if BidCommitment(intentKey, ctx.From, planHash, common.HexToHash(p.Salt)) != commitment {
+		return ErrCommitmentMismatch
+	}
+	if err := checkPlan(ctx.StateDB, intentKey, in, plan, ctx.From, ctx.BlockNumber.Uint64()); err != nil {
+		return err
+	}
+	markRevealed(ctx.StateDB, intentKey, ctx.From)
+
+	if in.Winner == (common.Address{}) || beats(plan, planHash, in) {
+		in.Winner = ctx.From
+		in.WinnerPlan = planHash
+		in.WinnerValue = new(big.Int).Set(plan.EstimatedValue)
+		in.WinnerGas = plan.EstimatedGas
+		WriteIntent(ctx.StateDB, intentKey, in)
+	}
+	return nil
+}
+
+type executePayload struct {
+	IntentID string                    `json:"intent_id"` // hex IntentKey
+	Plan     boundary.PlanRecord       `json:"plan"`
+	Receipt  boundary.ExecutionReceipt `json:"receipt"`
+}
+
+// handleExecute lets the winning solver execute its plan. The receipt must
+// carry the EffectsHash of the Settlement the pool computes itself, which is
+// then paid out of escrow.
+func (h *intentHandler) handleExecute(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p executePayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	intentKey := common.HexToHash(p.IntentID)
+	in, err := readOpen(ctx, intentKey)
+	if err != nil {
+		return err
+	}
+	if in.Winner == (common.Address{}) || ctx.From != in.Winner {
+		return ErrNotWinner
+	}
+	now := ctx.BlockNumber.Uint64()
+	if now <= in.RevealEnd || now > in.Deadline || blockSecs(ctx) > in.ExpiresAt {
+		return ErrExecNotOpen
+	}
+	plan, receipt := &p.Plan, &p.Receipt
+	if PlanHash(plan) != in.WinnerPlan {
+		return ErrPlanNotWinning
+	}
+	if err := receipt.Validate(); err != nil {
+		return ErrReceiptInvalid
+	}
+	if receipt.IntentID != plan.IntentID || receipt.PlanID != plan.PlanID ||
+		receipt.From != in.Requester || receipt.ActorAgentID != ctx.From {
+		return ErrReceiptMismatch
+	}
+	transfers := Settlement(in, ctx.From, plan)
+	if receipt.EffectsHash != EffectsHash(intentKey, in, transfers) {
+		return ErrEffectsMismatch
+	}
+	if ctx.StateDB.GetBalance(params.IntentPoolAddress).Cmp(in.Escrow) < 0 {
+		return ErrEscrowBroken
+	}
+
+	// checkPlan bounded the route by EstimatedValue and EstimatedValue by
+	// the escrow, so the payouts sum to exactly the escrow.
+	for _, t := range transfers {
+		release(ctx.StateDB, t.To, t.Amount)
+	}
+
+	in.ReceiptHash = ReceiptHash(receipt)
+	in.Status = StatusSettled
+	WriteIntent(ctx.StateDB, intentKey, in)
+	reputation.RecordScore(ctx.StateDB, ctx.From, ReputationDeltaExecuted)
+	return nil
+}
+
+// handleExpire refunds an intent nobody executed. Without a winner it can
+// expire once the reveal window is over; with one, only after the deadline
+// block. Either way it can expire once the block time passes ExpiresAt. A
+// winning solver that let the execution window pass is penalised. It may be
+// sent by anyone.
+func (h *intentHandler) handleExpire(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p intentIDPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	intentKey := common.HexToHash(p.IntentID)
+	in, err := readOpen(ctx, intentKey)
+	if err != nil {
+		return err
+	}
+	now := ctx.BlockNumber.Uint64()
+	hasWinner := in.Winner != (common.Address{})
+	timedOut := blockSecs(ctx) > in.ExpiresAt
+	if !timedOut && ((!hasWinner && now <= in.RevealEnd) || (hasWinner && now <= in.Deadline)) {
+		return ErrNotExpirable
+	}
+	if err := closeIntent(ctx.StateDB, intentKey, in, StatusExpired); err != nil {
+		return err
+	}
+	if hasWinner && now > in.RevealEnd {
+		reputation.RecordScore(ctx.StateDB, in.Winner, ReputationDeltaMissed)
+	}
+	return nil
+}
+
+// blockSecs returns the block time in unix seconds, or 0 if unset.
+func blockSecs(ctx *sysaction.Context) uint64 {
+	if ctx.Time == nil {
+		return 0
+	}
+	return ctx.Time.Uint64() / 1000
+}
+
+// readOpen reads intentKey and checks that it is still open.
+func readOpen(ctx *sysaction.Context, intentKey common.Hash) (*Intent, error) {
+	in, ok := ReadIntent(ctx.StateDB, intentKey)
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+	if in.Status != StatusOpen {
+		return nil, ErrIntentNotOpen
+	}
+	return in, nil
+}
+
+// checkPlan verifies that plan, revealed by solver, satisfies the intent's
+// constraints.
+func checkPlan(db stateDB, intentKey common.Hash, in *Intent, plan *boundary.PlanRecord, solver common.Address, block uint64) error {
+	if err := plan.Validate(); err != nil || plan.EstimatedValue == nil || plan.EstimatedValue.Sign() < 0 {
+		return ErrPlanInvalid
+	}
+	if IntentKey(in.Requester, plan.IntentID) != intentKey {
+		return ErrPlanWrongIntent
+	}
+	if plan.Provider != solver {
+		return ErrPlanNotProvider
+	}
+	if SolverTrustTier(db, solver, block) < in.RequiredTrustTier {
+		return ErrSolverTrustTier
+	}
+	if plan.EstimatedValue.Cmp(in.Escrow) > 0 {
+		return ErrPlanOverMaxValue
+	}
+	if in.MaxGas > 0 && plan.EstimatedGas > in.MaxGas {
+		return ErrPlanOverMaxGas
+	}
+	if len(plan.Route) == 0 || len(plan.Route) > params.IntentMaxRouteSteps {
+		return ErrPlanRoute
+	}
+	total := new(big.Int)
+	for _, step := range plan.Route {
+		if step.Target == (common.Address{}) || (step.Value != nil && step.Value.Sign() < 0) {
+			return ErrPlanRoute
+		}
+		if step.Value != nil {
+			total.Add(total, step.Value)
+		}
+		if !contains(in.Recipients, step.Target) {
+			return ErrPlanRecipient
+		}
+	}
+	if total.Cmp(plan.EstimatedValue) > 0 {
+		return ErrPlanRoute
+	}
+	if EffectsHash(intentKey, in, Settlement(in, solver, plan)) != plan.EffectsHash {
+		return ErrPlanEffects
+	}
+	return nil
+}
+
+// beats reports whether plan ranks ahead of the intent's current winner.
+func beats(plan *boundary.PlanRecord, planHash common.Hash, in *Intent) bool {
+	if c := plan.EstimatedValue.Cmp(in.WinnerValue); c != 0 {
+		return c < 0
+	}
+	if plan.EstimatedGas != in.WinnerGas {
+		return plan.EstimatedGas < in.WinnerGas
+	}
+	return bytes.Compare(planHash.Bytes(), in.WinnerPlan.Bytes()) < 0
+}
+
+// closeIntent refunds the whole escrow to the requester and sets status.
+func closeIntent(db vmtypes.StateDB, intentKey common.Hash, in *Intent, status Status) error {
+	if db.GetBalance(params.IntentPoolAddress).Cmp(in.Escrow) < 0 {
+		return ErrEscrowBroken
+	}
+	release(db, in.Requester, in.Escrow)
+	in.Status = status
+	WriteIntent(db, intentKey, in)
+	return nil
+}
+
+// release moves amount from the intent pool to addr.
+func release(db vmtypes.StateDB, addr common.Address, amount *big.Int) {
+	if amount.Sign() <= 0 {
+		return
+	}
+	db.SubBalance(params.IntentPoolAddress, amount)
+	db.AddBalance(addr, amount)
+}
+
+func contains(list []common.Address, addr common.Address) bool {
+	for _, a := range list {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
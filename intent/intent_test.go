@@ -0,0 +1,388 @@
+package intent
+
+import (
+	"math/big"
+	"testing"
+
+	_ "github.com/tos-network/gtos/agent" // registers AGENT_* handlers via init()
+	"github.com/tos-network/gtos/boundary"
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/core/rawdb"
+	"github.com/tos-network/gtos/core/state"
+	"github.com/tos-network/gtos/crypto"
+	"github.com/tos-network/gtos/kyc"
+	"github.com/tos-network/gtos/params"
+	"github.com/tos-network/gtos/reputation"
+	"github.com/tos-network/gtos/sysaction"
+)
+
+func newTestState() *state.StateDB {
+	db := state.NewDatabase(rawdb.NewMemoryDatabase())
+	s, _ := state.New(common.Hash{}, db, nil)
+	return s
+}
+
+func tAddr(b byte) common.Address { return common.Address{b} }
+
+func tos(n int64) *big.Int { return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e18)) }
+
+var (
+	requester = tAddr(0x01)
+	solverA   = tAddr(0x0A)
+	solverB   = tAddr(0x0B)
+	solverC   = tAddr(0x0C)
+	payee1    = tAddr(0x21)
+	payee2    = tAddr(0x22)
+)
+
+// testTimeMs is the block time of block n: one second per block from the
+// envelopes' CreatedAt.
+func testTimeMs(n uint64) *big.Int {
+	return new(big.Int).SetUint64((1700000000 + n) * 1000)
+}
+
+func do(t *testing.T, st *state.StateDB, from common.Address, value *big.Int, block uint64, action sysaction.ActionKind, payload interface{}) error {
+	t.Helper()
+	data, err := sysaction.MakeSysAction(action, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sysaction.ExecuteWithContext(&sysaction.Context{
+		From:        from,
+		Value:       value,
+		BlockNumber: new(big.Int).SetUint64(block),
+		Time:        testTimeMs(block),
+		StateDB:     st,
+		ChainConfig: &params.ChainConfig{},
+	}, data)
+}
+
+func setup(t *testing.T) *state.StateDB {
+	t.Helper()
+	st := newTestState()
+	st.AddBalance(requester, tos(1000))
+	for _, s := range []common.Address{solverA, solverB, solverC} {
+		st.AddBalance(s, params.AgentMinStake)
+		if err := do(t, st, s, params.AgentMinStake, 1, sysaction.ActionAgentRegister, nil); err != nil {
+			t.Fatalf("agent register: %v", err)
+		}
+	}
+	return st
+}
+
+func testEnvelope(tier boundary.TrustTier) boundary.IntentEnvelope {
+	return boundary.IntentEnvelope{
+		IntentID:      "intent-001",
+		SchemaVersion: boundary.SchemaVersion,
+		Action:        "pay",
+		Requester:     requester,
+		TerminalClass: boundary.TerminalApp,
+		TrustTier:     boundary.TrustTierHigh,
+		Constraints: &boundary.IntentConstraints{
+			MaxValue:          tos(100),
+			AllowedRecipients: []common.Address{payee1, payee2},
+			RequiredTrustTier: tier,
+		},
+		CreatedAt: 1700000000,
+		ExpiresAt: 1700003600,
+		Status:    boundary.IntentPending,
+	}
+}
+
+func testPlan(st *state.StateDB, key common.Hash, planID string, solver common.Address, value int64, route ...boundary.RouteStep) boundary.PlanRecord {
+	plan := boundary.PlanRecord{
+		PlanID:         planID,
+		IntentID:       "intent-001",
+		SchemaVersion:  boundary.SchemaVersion,
+		Provider:       solver,
+		EstimatedGas:   21000,
+		EstimatedValue: tos(value),
+		Route:          route,
+		CreatedAt:      1700000000,
+		ExpiresAt:      1700003600,
+		Status:         boundary.PlanReady,
+	}
+	if in, ok := ReadIntent(st, key); ok {
+		plan.EffectsHash = EffectsHash(key, in, Settlement(in, solver, &plan))
+	}
+	return plan
+}
+
+func submit(t *testing.T, st *state.StateDB, tier boundary.TrustTier) common.Hash {
+	t.Helper()
+	if err := do(t, st, requester, tos(100), 10, sysaction.ActionIntentSubmit, submitPayload{
+		Intent: testEnvelope(tier), BidBlocks: params.IntentMinBidBlocks,
+	}); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	return IntentKey(requester, "intent-001")
+}
+
+func bidAndReveal(t *testing.T, st *state.StateDB, key common.Hash, solver common.Address, plan boundary.PlanRecord) error {
+	t.Helper()
+	salt := common.Hash{0x5a, solver[0]}
+	if err := do(t, st, solver, nil, 15, sysaction.ActionIntentBid, bidPayload{
+		IntentID: key.Hex(), Commitment: BidCommitment(key, solver, PlanHash(&plan), salt).Hex(),
+	}); err != nil {
+		return err
+	}
+	return do(t, st, solver, nil, 10+params.IntentMinBidBlocks+1, sysaction.ActionIntentReveal, revealPayload{
+		IntentID: key.Hex(), Plan: plan, Salt: salt.Hex(),
+	})
+}
+
+func testReceipt(plan *boundary.PlanRecord, solver common.Address) boundary.ExecutionReceipt {
+	return boundary.ExecutionReceipt{
+		ReceiptID:     "receipt-001",
+		IntentID:      plan.IntentID,
+		PlanID:        plan.PlanID,
+		SchemaVersion: boundary.SchemaVersion,
+		From:          requester,
+		To:            plan.Route[0].Target,
+		ActorAgentID:  solver,
+		EffectsHash:   plan.EffectsHash,
+		Status:        boundary.ReceiptSuccess,
+		SettledAt:     1700001000,
+	}
+}
+
+func TestIntentAuctionAndExecute(t *testing.T) {
+	st := setup(t)
+	key := submit(t, st, boundary.TrustTierLow)
+	if got := st.GetBalance(params.IntentPoolAddress); got.Cmp(tos(100)) != 0 {
+		t.Fatalf("escrow: got %v", got)
+	}
+
+	planA := testPlan(st, key, "plan-a", solverA, 90,
+		boundary.RouteStep{Target: payee1, Action: "transfer", Value: tos(60)},
+		boundary.RouteStep{Target: payee2, Action: "transfer", Value: tos(25)})
+	planB := testPlan(st, key, "plan-b", solverB, 95,
+		boundary.RouteStep{Target: payee1, Action: "transfer", Value: tos(85)})
+	if err := bidAndReveal(t, st, key, solverB, planB); err != nil {
+		t.Fatalf("bid B: %v", err)
+	}
+	if err := bidAndReveal(t, st, key, solverA, planA); err != nil {
+		t.Fatalf("bid A: %v", err)
+	}
+	in, _ := ReadIntent(st, key)
+	if in.Winner != solverA || in.WinnerPlan != PlanHash(&planA) || in.Bids != 2 {
+		t.Fatalf("unexpected winner: %+v", in)
+	}
+
+	execBlock := in.RevealEnd + 1
+	execute := func(from common.Address, plan boundary.PlanRecord, receipt boundary.ExecutionReceipt) error {
+		return do(t, st, from, nil, execBlock, sysaction.ActionIntentExecute, executePayload{
+			IntentID: key.Hex(), Plan: plan, Receipt: receipt,
+		})
+	}
+	if err := execute(solverB, planB, testReceipt(&planB, solverB)); err != ErrNotWinner {
+		t.Errorf("loser execute: want ErrNotWinner, got %v", err)
+	}
+	bad := testReceipt(&planA, solverA)
+	bad.EffectsHash = common.Hash{0xee}
+	if err := execute(solverA, planA, bad); err != ErrEffectsMismatch {
+		t.Errorf("wrong effects: want ErrEffectsMismatch, got %v", err)
+	}
+	if err := execute(solverA, planA, testReceipt(&planA, solverA)); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	// Route paid, solver keeps 90-85, requester refunded 100-90.
+	for addr, want := range map[common.Address]*big.Int{
+		payee1:                   tos(60),
+		payee2:                   tos(25),
+		solverA:                  tos(5),
+		requester:                tos(910),
+		params.IntentPoolAddress: new(big.Int),
+	} {
+		if got := st.GetBalance(addr); got.Cmp(want) != 0 {
+			t.Errorf("balance of %x: want %v, got %v", addr[:1], want, got)
+		}
+	}
+	in, _ = ReadIntent(st, key)
+	receipt := testReceipt(&planA, solverA)
+	if in.Status != StatusSettled || in.ReceiptHash != ReceiptHash(&receipt) {
+		t.Errorf("unexpected intent: %+v", in)
+	}
+	if reputation.TotalScoreOf(st, solverA).Cmp(ReputationDeltaExecuted) != 0 {
+		t.Errorf("solver score: got %v", reputation.TotalScoreOf(st, solverA))
+	}
+}
+
+func TestIntentConstraints(t *testing.T) {
+	st := setup(t)
+	key := submit(t, st, boundary.TrustTierLow)
+
+	outsider := testPlan(st, key, "plan-x", solverA, 50,
+		boundary.RouteStep{Target: tAddr(0x99), Action: "transfer", Value: tos(50)})
+	if err := bidAndReveal(t, st, key, solverA, outsider); err != ErrPlanRecipient {
+		t.Errorf("disallowed recipient: want ErrPlanRecipient, got %v", err)
+	}
+	forged := testPlan(st, key, "plan-z", solverC, 50,
+		boundary.RouteStep{Target: payee1, Action: "transfer", Value: tos(50)})
+	forged.EffectsHash = crypto.Keccak256Hash(payee1.Bytes(), crypto.Keccak256([]byte("transfer")), common.BigToHash(tos(50)).Bytes())
+	if err := bidAndReveal(t, st, key, solverC, forged); err != ErrPlanEffects {
+		t.Errorf("route-only effects: want ErrPlanEffects, got %v", err)
+	}
+	tooMuch := testPlan(st, key, "plan-y", solverB, 101,
+		boundary.RouteStep{Target: payee1, Action: "transfer", Value: tos(101)})
+	if err := bidAndReveal(t, st, key, solverB, tooMuch); err != ErrPlanOverMaxValue {
+		t.Errorf("over max value: want ErrPlanOverMaxValue, got %v", err)
+	}
+
+	// A rejected reveal leaves no winner, so the intent expires after the
+	// reveal window and the escrow is refunded.
+	in, _ := ReadIntent(st, key)
+	if err := do(t, st, requester, nil, in.RevealEnd, sysaction.ActionIntentExpire, intentIDPayload{IntentID: key.Hex()}); err != ErrNotExpirable {
+		t.Errorf("early expire: want ErrNotExpirable, got %v", err)
+	}
+	if err := do(t, st, requester, nil, in.RevealEnd+1, sysaction.ActionIntentExpire, intentIDPayload{IntentID: key.Hex()}); err != nil {
+		t.Fatalf("expire: %v", err)
+	}
+	if st.GetBalance(requester).Cmp(tos(1000)) != 0 {
+		t.Errorf("requester refund: got %v", st.GetBalance(requester))
+	}
+}
+
+func TestIntentTrustTier(t *testing.T) {
+	st := setup(t)
+	if tier := SolverTrustTier(st, requester, 1); tier != boundary.TrustTierUntrusted {
+		t.Errorf("unregistered: want Untrusted, got %d", tier)
+	}
+	if tier := SolverTrustTier(st, solverA, 1); tier != boundary.TrustTierLow {
+		t.Errorf("fresh agent: want Low, got %d", tier)
+	}
+	key := submit(t, st, boundary.TrustTierHigh)
+	err := do(t, st, solverA, nil, 15, sysaction.ActionIntentBid, bidPayload{IntentID: key.Hex(), Commitment: common.Hash{1}.Hex()})
+	if err != ErrSolverTrustTier {
+		t.Errorf("low-tier bid: want ErrSolverTrustTier, got %v", err)
+	}
+
+	reputation.RecordScore(st, solverA, big.NewInt(1))
+	if tier := SolverTrustTier(st, solverA, 1); tier != boundary.TrustTierMedium {
+		t.Errorf("scored agent: want Medium, got %d", tier)
+	}
+	kyc.WriteKYC(st, solverA, kyc.KycLevelBasic, kyc.KycActive)
+	if tier := SolverTrustTier(st, solverA, 1); tier != boundary.TrustTierHigh {
+		t.Errorf("basic KYC: want High, got %d", tier)
+	}
+	if err := do(t, st, solverA, nil, 15, sysaction.ActionIntentBid, bidPayload{IntentID: key.Hex(), Commitment: common.Hash{1}.Hex()}); err != nil {
+		t.Errorf("high-tier bid: %v", err)
+	}
+}
+
+func TestIntentSubmitChecks(t *testing.T) {
+	st := setup(t)
+	env := testEnvelope(boundary.TrustTierLow)
+	sub := func(from common.Address, value *big.Int, env boundary.IntentEnvelope) error {
+		return do(t, st, from, value, 10, sysaction.ActionIntentSubmit, submitPayload{Intent: env, BidBlocks: params.IntentMinBidBlocks})
+	}
+	if err := sub(solverA, tos(100), env); err != ErrIntentNotRequester {
+		t.Errorf("foreign requester: want ErrIntentNotRequester, got %v", err)
+	}
+	if err := sub(requester, tos(50), env); err != ErrIntentEscrowMismatch {
+		t.Errorf("escrow mismatch: want ErrIntentEscrowMismatch, got %v", err)
+	}
+	open := env
+	open.Constraints = &boundary.IntentConstraints{MaxValue: tos(100)}
+	if err := sub(requester, tos(100), open); err != ErrIntentRecipients {
+		t.Errorf("no recipients: want ErrIntentRecipients, got %v", err)
+	}
+	past := env
+	past.Constraints = &boundary.IntentConstraints{MaxValue: tos(100), AllowedRecipients: env.Constraints.AllowedRecipients, Deadline: 1700000005}
+	if err := sub(requester, tos(100), past); err != ErrIntentDeadline {
+		t.Errorf("past deadline: want ErrIntentDeadline, got %v", err)
+	}
+	far := env
+	far.ExpiresAt = 1700000010 + params.IntentMaxHorizonSecs + 1
+	if err := sub(requester, tos(100), far); err != ErrIntentDeadline {
+		t.Errorf("expiry past horizon: want ErrIntentDeadline, got %v", err)
+	}
+	stale := env
+	stale.SchemaVersion = "9.0.0"
+	if err := sub(requester, tos(100), stale); err != ErrIntentSchema {
+		t.Errorf("schema: want ErrIntentSchema, got %v", err)
+	}
+	if err := sub(requester, tos(100), env); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if err := sub(requester, tos(100), env); err != ErrIntentExists {
+		t.Errorf("resubmit: want ErrIntentExists, got %v", err)
+	}
+
+	key := IntentKey(requester, env.IntentID)
+	cancel := func(block uint64) error {
+		return do(t, st, requester, nil, block, sysaction.ActionIntentCancel, intentIDPayload{IntentID: key.Hex()})
+	}
+	if err := cancel(10 + params.IntentMinBidBlocks + 1); err != ErrNotCancellable {
+		t.Errorf("late cancel: want ErrNotCancellable, got %v", err)
+	}
+	if err := cancel(12); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if in, _ := ReadIntent(st, key); in.Status != StatusCancelled || st.GetBalance(requester).Cmp(tos(1000)) != 0 {
+		t.Errorf("cancel did not refund: status %d balance %v", in.Status, st.GetBalance(requester))
+	}
+}
+
+func TestIntentMissedExecution(t *testing.T) {
+	st := setup(t)
+	key := submit(t, st, boundary.TrustTierLow)
+	plan := testPlan(st, key, "plan-a", solverA, 10, boundary.RouteStep{Target: payee1, Action: "transfer", Value: tos(10)})
+	if err := bidAndReveal(t, st, key, solverA, plan); err != nil {
+		t.Fatalf("bid: %v", err)
+	}
+	in, _ := ReadIntent(st, key)
+	if err := do(t, st, payee1, nil, in.Deadline, sysaction.ActionIntentExpire, intentIDPayload{IntentID: key.Hex()}); err != ErrNotExpirable {
+		t.Errorf("expire before deadline: want ErrNotExpirable, got %v", err)
+	}
+	if err := do(t, st, payee1, nil, in.Deadline+1, sysaction.ActionIntentExpire, intentIDPayload{IntentID: key.Hex()}); err != nil {
+		t.Fatalf("expire: %v", err)
+	}
+	if st.GetBalance(requester).Cmp(tos(1000)) != 0 {
+		t.Errorf("requester refund: got %v", st.GetBalance(requester))
+	}
+	if reputation.TotalScoreOf(st, solverA).Cmp(ReputationDeltaMissed) != 0 {
+		t.Errorf("solver score: got %v", reputation.TotalScoreOf(st, solverA))
+	}
+}
+
+func TestIntentTimeExpiry(t *testing.T) {
+	st := setup(t)
+	env := testEnvelope(boundary.TrustTierLow)
+	env.Constraints.Deadline = 1700000200 // unix seconds, before ExpiresAt
+	if err := do(t, st, requester, tos(100), 10, sysaction.ActionIntentSubmit, submitPayload{
+		Intent: env, BidBlocks: params.IntentMinBidBlocks,
+	}); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	key := IntentKey(requester, env.IntentID)
+	plan := testPlan(st, key, "plan-a", solverA, 10, boundary.RouteStep{Target: payee1, Action: "transfer", Value: tos(10)})
+	if err := bidAndReveal(t, st, key, solverA, plan); err != nil {
+		t.Fatalf("bid: %v", err)
+	}
+	in, _ := ReadIntent(st, key)
+	if in.ExpiresAt != 1700000200 || in.Deadline <= 200 {
+		t.Fatalf("unexpected windows: expires %d, deadline block %d", in.ExpiresAt, in.Deadline)
+	}
+
+	// Block 250 is inside the execution window but past the deadline time.
+	err := do(t, st, solverA, nil, 250, sysaction.ActionIntentExecute, executePayload{
+		IntentID: key.Hex(), Plan: plan, Receipt: testReceipt(&plan, solverA),
+	})
+	if err != ErrExecNotOpen {
+		t.Errorf("execute after deadline: want ErrExecNotOpen, got %v", err)
+	}
+	if err := do(t, st, payee1, nil, 200, sysaction.ActionIntentExpire, intentIDPayload{IntentID: key.Hex()}); err != ErrNotExpirable {
+		t.Errorf("expire at deadline: want ErrNotExpirable, got %v", err)
+	}
+	if err := do(t, st, payee1, nil, 250, sysaction.ActionIntentExpire, intentIDPayload{IntentID: key.Hex()}); err != nil {
+		t.Fatalf("expire: %v", err)
+	}
+	if st.GetBalance(requester).Cmp(tos(1000)) != 0 {
+		t.Errorf("requester refund: got %v", st.GetBalance(requester))
+	}
+	if reputation.TotalScoreOf(st, solverA).Cmp(ReputationDeltaMissed) != 0 {
+		t.Errorf("solver score: got %v", reputation.TotalScoreOf(st, solverA))
+	}
+}
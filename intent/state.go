@@ -0,0 +1,230 @@
+package intent
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+
+	"github.com/tos-network/gtos/agent"
+	"github.com/tos-network/gtos/boundary"
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/crypto"
+	"github.com/tos-network/gtos/kyc"
+	"github.com/tos-network/gtos/params"
+	"github.com/tos-network/gtos/reputation"
+)
+
+// stateDB is the minimal storage interface required by this package.
+type stateDB interface {
+	GetState(common.Address, common.Hash) common.Hash
+	SetState(common.Address, common.Hash, common.Hash)
+}
+
+// ── Slot helpers ──────────────────────────────────────────────────────────────
+
+// intentFieldSlot returns the storage slot for a single field of an Intent.
+// key = keccak256("intent\x00" || intentKey[32] || field)
+func intentFieldSlot(intentKey common.Hash, field string) common.Hash {
+	key := append([]byte("intent\x00"), intentKey.Bytes()...)
+	key = append(key, []byte(field)...)
+	return common.BytesToHash(crypto.Keccak256(key))
+}
+
+// recipientSlot returns the slot holding the i-th allowed recipient.
+func recipientSlot(intentKey common.Hash, i uint64) common.Hash {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], i)
+	return common.BytesToHash(crypto.Keccak256([]byte("intent\x00recipient\x00"), intentKey.Bytes(), buf[:]))
+}
+
+// bidSlot returns the slot holding solver's sealed bid.
+func bidSlot(intentKey common.Hash, solver common.Address) common.Hash {
+	return common.BytesToHash(crypto.Keccak256([]byte("intent\x00bid\x00"), intentKey.Bytes(), solver.Bytes()))
+}
+
+// revealedSlot returns the slot flagging that solver revealed its bid.
+func revealedSlot(intentKey common.Hash, solver common.Address) common.Hash {
+	return common.BytesToHash(crypto.Keccak256([]byte("intent\x00revealed\x00"), intentKey.Bytes(), solver.Bytes()))
+}
+
+// ── Hashes ────────────────────────────────────────────────────────────────────
+
+// IntentKey returns the on-chain key of the intent requester submitted under
+// the envelope's IntentID.
+func IntentKey(requester common.Address, intentID string) common.Hash {
+	return common.BytesToHash(crypto.Keccak256([]byte("intent\x00id\x00"), requester.Bytes(), []byte(intentID)))
+}
+
+// PlanHash returns keccak256 of the plan's JSON encoding.
+func PlanHash(plan *boundary.PlanRecord) common.Hash {
+	data, _ := json.Marshal(plan)
+	return crypto.Keccak256Hash(data)
+}
+
+// ReceiptHash returns keccak256 of the execution receipt's JSON encoding.
+func ReceiptHash(receipt *boundary.ExecutionReceipt) common.Hash {
+	data, _ := json.Marshal(receipt)
+	return crypto.Keccak256Hash(data)
+}
+
+// Settlement returns the payouts that settle in with plan, won by solver:
+// each route step's value to its target, the rest of EstimatedValue to the
+// solver and the unspent escrow to the requester. Zero amounts are omitted.
+func Settlement(in *Intent, solver common.Address, plan *boundary.PlanRecord) []Transfer {
+	var transfers []Transfer
+	spent := new(big.Int)
+	for _, step := range plan.Route {
+		if step.Value != nil && step.Value.Sign() > 0 {
+			transfers = append(transfers, Transfer{To: step.Target, Amount: step.Value})
+			spent.Add(spent, step.Value)
+		}
+	}
+	if fee := new(big.Int).Sub(plan.EstimatedValue, spent); fee.Sign() > 0 {
+		transfers = append(transfers, Transfer{To: solver, Amount: fee})
+	}
+	if refund := new(big.Int).Sub(in.Escrow, plan.EstimatedValue); refund.Sign() > 0 {
+		transfers = append(transfers, Transfer{To: in.Requester, Amount: refund})
+	}
+	return transfers
+}
+
+// EffectsHash returns the hash of the payouts settling the intent at
+// intentKey. It binds the intent's own terms, so it cannot be derived from a
+// plan alone.
+// Formula: keccak256(intentKey[32] || requester[32] || actionHash[32] || escrow[32] || to[32] || amount[32] || ...)
+func EffectsHash(intentKey common.Hash, in *Intent, transfers []Transfer) common.Hash {
+	buf := append([]byte{}, intentKey.Bytes()...)
+	buf = append(buf, in.Requester.Bytes()...)
+	buf = append(buf, in.ActionHash.Bytes()...)
+	buf = append(buf, common.BigToHash(in.Escrow).Bytes()...)
+	for _, t := range transfers {
+		buf = append(buf, t.To.Bytes()...)
+		buf = append(buf, common.BigToHash(t.Amount).Bytes()...)
+	}
+	return crypto.Keccak256Hash(buf)
+}
+
+// BidCommitment returns the sealed bid a solver submits with INTENT_BID.
+// Formula: keccak256(intentKey[32] || solver[32] || planHash[32] || salt[32])
+func BidCommitment(intentKey common.Hash, solver common.Address, planHash, salt common.Hash) common.Hash {
+	return crypto.Keccak256Hash(intentKey.Bytes(), solver.Bytes(), planHash.Bytes(), salt.Bytes())
+}
+
+// ── Trust ─────────────────────────────────────────────────────────────────────
+
+// SolverTrustTier derives a solver's trust tier from on-chain state at block:
+//
+//	Untrusted  not an active, unsuspended registered agent
+//	Low        active agent
+//	Medium     active agent with a positive reputation score
+//	High       Medium with active, unexpired basic KYC
+//	Full       Medium with active, unexpired identity KYC
+func SolverTrustTier(db stateDB, solver common.Address, block uint64) boundary.TrustTier {
+	if !agent.IsRegistered(db, solver) || agent.IsSuspended(db, solver) ||
+		agent.ReadStatus(db, solver) != agent.AgentActive {
+		return boundary.TrustTierUntrusted
+	}
+	if reputation.TotalScoreOf(db, solver).Sign() <= 0 {
+		return boundary.TrustTierLow
+	}
+	switch {
+	case kyc.MeetsLevel(db, solver, kyc.KycLevelIdentity, block):
+		return boundary.TrustTierFull
+	case kyc.MeetsLevel(db, solver, kyc.KycLevelBasic, block):
+		return boundary.TrustTierHigh
+	}
+	return boundary.TrustTierMedium
+}
+
+// ── Read / Write ──────────────────────────────────────────────────────────────
+
+// ReadIntent reads an Intent from state. Returns (intent, true) if found,
+// (nil, false) if the key has never been written.
+func ReadIntent(db stateDB, intentKey common.Hash) (*Intent, bool) {
+	load := func(field string) common.Hash {
+		return db.GetState(params.IntentPoolAddress, intentFieldSlot(intentKey, field))
+	}
+	status := Status(load("status")[31])
+	if status == StatusNone {
+		return nil, false
+	}
+	n := load("recipients").Big().Uint64()
+	recipients := make([]common.Address, n)
+	for i := uint64(0); i < n; i++ {
+		recipients[i] = common.BytesToAddress(db.GetState(params.IntentPoolAddress, recipientSlot(intentKey, i)).Bytes())
+	}
+	return &Intent{
+		Requester:         common.BytesToAddress(load("requester").Bytes()),
+		ActionHash:        load("action"),
+		Escrow:            load("escrow").Big(),
+		Recipients:        recipients,
+		RequiredTrustTier: boundary.TrustTier(load("tier")[31]),
+		MaxGas:            load("maxgas").Big().Uint64(),
+		BidEnd:            load("bidend").Big().Uint64(),
+		RevealEnd:         load("revealend").Big().Uint64(),
+		Deadline:          load("deadline").Big().Uint64(),
+		ExpiresAt:         load("expiresat").Big().Uint64(),
+		Bids:              load("bids").Big().Uint64(),
+		Winner:            common.BytesToAddress(load("winner").Bytes()),
+		WinnerPlan:        load("winnerplan"),
+		WinnerValue:       load("winnervalue").Big(),
+		WinnerGas:         load("winnergas").Big().Uint64(),
+		ReceiptHash:       load("receipt"),
+		Status:            status,
+	}, true
+}
+
+// WriteIntent persists an Intent to state, one field per slot. Recipients
+// are only written on first submission; they never change afterwards.
+func WriteIntent(db stateDB, intentKey common.Hash, in *Intent) {
+	store := func(field string, val common.Hash) {
+		db.SetState(params.IntentPoolAddress, intentFieldSlot(intentKey, field), val)
+	}
+	u64 := func(v uint64) common.Hash {
+		var h common.Hash
+		binary.BigEndian.PutUint64(h[24:], v)
+		return h
+	}
+	for i, r := range in.Recipients {
+		db.SetState(params.IntentPoolAddress, recipientSlot(intentKey, uint64(i)), common.BytesToHash(r.Bytes()))
+	}
+	winnerValue := new(big.Int)
+	if in.WinnerValue != nil {
+		winnerValue = in.WinnerValue
+	}
+	store("requester", common.BytesToHash(in.Requester.Bytes()))
+	store("action", in.ActionHash)
+	store("escrow", common.BigToHash(in.Escrow))
+	store("recipients", u64(uint64(len(in.Recipients))))
+	store("tier", u64(uint64(in.RequiredTrustTier)))
+	store("maxgas", u64(in.MaxGas))
+	store("bidend", u64(in.BidEnd))
+	store("revealend", u64(in.RevealEnd))
+	store("deadline", u64(in.Deadline))
+	store("expiresat", u64(in.ExpiresAt))
+	store("bids", u64(in.Bids))
+	store("winner", common.BytesToHash(in.Winner.Bytes()))
+	store("winnerplan", in.WinnerPlan)
+	store("winnervalue", common.BigToHash(winnerValue))
+	store("winnergas", u64(in.WinnerGas))
+	store("receipt", in.ReceiptHash)
+	store("status", u64(uint64(in.Status)))
+}
+
+// ReadBid returns solver's sealed bid (zero if none).
+func ReadBid(db stateDB, intentKey common.Hash, solver common.Address) common.Hash {
+	return db.GetState(params.IntentPoolAddress, bidSlot(intentKey, solver))
+}
+
+func writeBid(db stateDB, intentKey common.Hash, solver common.Address, commitment common.Hash) {
+	db.SetState(params.IntentPoolAddress, bidSlot(intentKey, solver), commitment)
+}
+
+// HasRevealed reports whether solver revealed its bid.
+func HasRevealed(db stateDB, intentKey common.Hash, solver common.Address) bool {
+	return db.GetState(params.IntentPoolAddress, revealedSlot(intentKey, solver)) != (common.Hash{})
+}
+
+func markRevealed(db stateDB, intentKey common.Hash, solver common.Address) {
+	db.SetState(params.IntentPoolAddress, revealedSlot(intentKey, solver), common.BytesToHash([]byte{1}))
+}
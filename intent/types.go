@@ -0,0 +1,105 @@
+// Package intent implements the on-chain intent pool and solver auction.
+//
+// A requester submits a boundary.IntentEnvelope together with the escrow the
+// intent may spend. During the sealed-bid window solver agents commit to a
+// hidden boundary.PlanRecord; once the window closes they reveal it and the
+// pool keeps the best plan that satisfies the intent's IntentConstraints.
+// The winning solver then executes the plan: the protocol computes the
+// payouts from the intent's escrow and the plan's route, checks the solver's
+// boundary.ExecutionReceipt against their EffectsHash and applies them.
+package intent
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/tos-network/gtos/boundary"
+	"github.com/tos-network/gtos/common"
+)
+
+// Status is the on-chain lifecycle state of an intent.
+type Status uint8
+
+const (
+	StatusNone      Status = 0
+	StatusOpen      Status = 1 // accepting bids, reveals or execution
+	StatusSettled   Status = 2
+	StatusCancelled Status = 3
+	StatusExpired   Status = 4
+)
+
+// Reputation deltas recorded for the winning solver.
+var (
+	ReputationDeltaExecuted = big.NewInt(1)
+	ReputationDeltaMissed   = big.NewInt(-1)
+)
+
+// Intent holds all on-chain state for a single intent.
+type Intent struct {
+	Requester         common.Address
+	ActionHash        common.Hash // keccak256 of the envelope's action
+	Escrow            *big.Int    // max value the intent may spend
+	Recipients        []common.Address
+	RequiredTrustTier boundary.TrustTier
+	MaxGas            uint64
+	BidEnd            uint64 // last block accepting sealed bids
+	RevealEnd         uint64 // last block accepting reveals
+	Deadline          uint64 // last block accepting execution
+	ExpiresAt         uint64 // unix seconds after which the intent cannot execute
+	Bids              uint64 // number of sealed bids
+	Winner            common.Address
+	WinnerPlan        common.Hash // PlanHash of the best revealed plan
+	WinnerValue       *big.Int
+	WinnerGas         uint64
+	ReceiptHash       common.Hash // ReceiptHash of the execution receipt
+	Status            Status
+}
+
+// Transfer is one payout from escrow when an intent settles.
+type Transfer struct {
+	To     common.Address
+	Amount *big.Int
+}
+
+// Sentinel errors returned by intent system action handlers.
+var (
+	ErrIntentInvalid        = errors.New("intent: invalid intent envelope")
+	ErrIntentSchema         = errors.New("intent: incompatible schema version")
+	ErrIntentNotRequester   = errors.New("intent: requester must be the sender")
+	ErrIntentExists         = errors.New("intent: intent already submitted")
+	ErrIntentNoEscrow       = errors.New("intent: value must be positive")
+	ErrIntentEscrowMismatch = errors.New("intent: value must equal max_value")
+	ErrInsufficientBalance  = errors.New("intent: sender balance below value")
+	ErrIntentBidWindow      = errors.New("intent: bid window out of range")
+	ErrIntentDeadline       = errors.New("intent: deadline or expiry out of range")
+	ErrIntentRecipients     = errors.New("intent: allowed recipients must number 1 to IntentMaxRecipients")
+	ErrIntentNotFound       = errors.New("intent: intent not found")
+	ErrIntentNotOpen        = errors.New("intent: intent is not open")
+	ErrIntentBidClosed      = errors.New("intent: bid window closed")
+	ErrIntentTooManyBids    = errors.New("intent: bid limit reached")
+	ErrSolverIsRequester    = errors.New("intent: requester cannot bid on its own intent")
+	ErrSolverTrustTier      = errors.New("intent: solver trust tier below requirement")
+	ErrAlreadyBid           = errors.New("intent: solver already bid")
+	ErrInvalidCommitment    = errors.New("intent: commitment must not be zero")
+	ErrRevealNotOpen        = errors.New("intent: reveal window not open")
+	ErrNotBidder            = errors.New("intent: solver has no sealed bid")
+	ErrAlreadyRevealed      = errors.New("intent: bid already revealed")
+	ErrCommitmentMismatch   = errors.New("intent: plan does not match commitment")
+	ErrPlanInvalid          = errors.New("intent: invalid plan")
+	ErrPlanWrongIntent      = errors.New("intent: plan is for another intent")
+	ErrPlanNotProvider      = errors.New("intent: plan provider must be the solver")
+	ErrPlanOverMaxValue     = errors.New("intent: plan value exceeds max value")
+	ErrPlanOverMaxGas       = errors.New("intent: plan gas exceeds max gas")
+	ErrPlanRoute            = errors.New("intent: plan route is empty, too long or over its value")
+	ErrPlanRecipient        = errors.New("intent: route target not an allowed recipient")
+	ErrPlanEffects          = errors.New("intent: plan effects hash does not match its settlement")
+	ErrNotCancellable       = errors.New("intent: intent can only be cancelled during the bid window")
+	ErrNotWinner            = errors.New("intent: caller is not the winning solver")
+	ErrExecNotOpen          = errors.New("intent: execution window not open")
+	ErrPlanNotWinning       = errors.New("intent: plan is not the winning plan")
+	ErrReceiptInvalid       = errors.New("intent: invalid execution receipt")
+	ErrReceiptMismatch      = errors.New("intent: receipt does not match the plan")
+	ErrEffectsMismatch      = errors.New("intent: receipt effects hash does not match the settlement")
+	ErrNotExpirable         = errors.New("intent: intent cannot expire yet")
+	ErrEscrowBroken         = errors.New("intent: escrow balance invariant violated")
+)
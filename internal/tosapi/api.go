@@ -30,8 +30,10 @@ import (
 	"github.com/tos-network/gtos/log"
 	"github.com/tos-network/gtos/p2p"
 	"github.com/tos-network/gtos/params"
+	"github.com/tos-network/gtos/policywallet"
 	"github.com/tos-network/gtos/rlp"
 	"github.com/tos-network/gtos/rpc"
+	"github.com/tos-network/gtos/settlement"
 	"github.com/tos-network/gtos/sysaction"
 )
 
@@ -2122,7 +2124,14 @@ type RPCLeaseRecord struct {
 	DepositWei          *hexutil.Big   `json:"depositWei"`
 	ScheduledPruneEpoch hexutil.Uint64 `json:"scheduledPruneEpoch"`
 	ScheduledPruneSeq   hexutil.Uint64 `json:"scheduledPruneSeq"`
+	AutoRenewBlocks     hexutil.Uint64 `json:"autoRenewBlocks"`
+	RenewalBudgetWei    *hexutil.Big   `json:"renewalBudgetWei"`
+	PendingOwner        common.Address `json:"pendingOwner"`
+	PermanentTopUpWei   *hexutil.Big   `json:"permanentTopUpWei,omitempty"`
+	StorageSlots        hexutil.Uint64 `json:"storageSlots"`
+	RentDueWei          *hexutil.Big   `json:"rentDueWei"`
 	Status              string         `json:"status"`
+	Warnings            []string       `json:"warnings,omitempty"`
 	Tombstoned          bool           `json:"tombstoned"`
 	TombstoneCodeHash   common.Hash    `json:"tombstoneCodeHash"`
 	TombstoneExpiredAt  hexutil.Uint64 `json:"tombstoneExpiredAt"`
@@ -2276,12 +2285,14 @@ type RPCPrivVerifyDecryptionTokenArgs struct {
 type RPCPrivDecryptAuditorArgs struct {
 	AuditorPrivkey hexutil.Bytes `json:"auditorPrivkey"` // 32B auditor ElGamal private key
 	TxHash         common.Hash   `json:"txHash"`
+	AuditorIndex   *hexutil.Uint `json:"auditorIndex"` // optional handle index; resolved from the key schedule if omitted
 }
 
 // RPCPrivDecryptAuditorResult holds the result for tos_privDecryptWithAuditorKey RPC.
 type RPCPrivDecryptAuditorResult struct {
-	Amount hexutil.Uint64 `json:"amount"`
-	TxType string         `json:"txType"`
+	Amount       hexutil.Uint64 `json:"amount"`
+	TxType       string         `json:"txType"`
+	AuditorIndex hexutil.Uint   `json:"auditorIndex"`
 }
 
 // RPCPrivDecryptWithTokenArgs holds arguments for tos_privDecryptWithToken RPC.
@@ -2554,7 +2565,18 @@ func (s *TOSAPI) GetLease(ctx context.Context, address common.Address, blockNrOr
 		record.DepositWei = (*hexutil.Big)(deposit)
 		record.ScheduledPruneEpoch = hexutil.Uint64(meta.ScheduledPruneEpoch)
 		record.ScheduledPruneSeq = hexutil.Uint64(meta.ScheduledPruneSeq)
+		record.AutoRenewBlocks = hexutil.Uint64(meta.AutoRenewBlocks)
+		record.RenewalBudgetWei = (*hexutil.Big)(new(big.Int).Set(meta.RenewalBudgetWei))
+		record.PendingOwner = meta.PendingOwner
+		charged := meta
+		lease.ChargeRent(state, address, &charged, header.Number.Uint64())
+		record.StorageSlots = hexutil.Uint64(charged.StorageSlots)
+		record.RentDueWei = (*hexutil.Big)(new(big.Int).Sub(deposit, charged.DepositWei))
+		if topUp, err := lease.PermanentTopUp(charged); err == nil {
+			record.PermanentTopUpWei = (*hexutil.Big)(topUp)
+		}
 		record.Status = rpcLeaseStatus(header.Number.Uint64(), meta, tombstoned, s.b.ChainConfig())
+		record.Warnings = lease.Warnings(meta, header.Number.Uint64(), s.b.ChainConfig())
 	} else {
 		record.Status = rpcLeaseStatus(header.Number.Uint64(), lease.Meta{}, tombstoned, s.b.ChainConfig())
 	}
@@ -2667,9 +2689,14 @@ func estimateSystemActionGas(payload []byte) (uint64, error) {
 		}
 		return intrinsic + extra, nil
 	}
-	if intrinsic > stdmath.MaxUint64-params.SysActionGas {
+	if intrinsic > stdmath.MaxUint64-params.SysActionGas-settlement.MaxCallbackGas {
 		return 0, fmt.Errorf("system action gas overflows")
 	}
+	// A callback runs its target on the transaction's gas; cover the
+	// largest max_gas, the unused part is refunded.
+	if sa, err := sysaction.Decode(payload); err == nil && sa.Action == sysaction.ActionSettlementExecuteCallback {
+		return intrinsic + params.SysActionGas + settlement.MaxCallbackGas, nil
+	}
 	return intrinsic + params.SysActionGas, nil
 }
 
@@ -3705,7 +3732,7 @@ func (s *TOSAPI) PrivDecryptWithAuditorKey(ctx context.Context, args RPCPrivDecr
 	}
 
 	// Look up the transaction
-	tx, _, _, _, err := s.b.GetTransaction(ctx, args.TxHash)
+	tx, _, blockNumber, _, err := s.b.GetTransaction(ctx, args.TxHash)
 	if err != nil {
 		return nil, err
 	}
@@ -3715,6 +3742,7 @@ func (s *TOSAPI) PrivDecryptWithAuditorKey(ctx context.Context, args RPCPrivDecr
 
 	var commitment, auditorHandle [32]byte
 	var txType string
+	var auditorIndex uint
 
 	switch tx.Type() {
 	case types.PrivTransferTxType:
@@ -3722,12 +3750,23 @@ func (s *TOSAPI) PrivDecryptWithAuditorKey(ctx context.Context, args RPCPrivDecr
 		if ptx == nil {
 			return nil, fmt.Errorf("invalid priv transfer tx")
 		}
-		var zero [32]byte
-		if ptx.AuditorHandle == zero {
+		handles := ptx.AuditorHandles()
+		if len(handles) == 0 {
 			return nil, fmt.Errorf("transaction has no auditor handle")
 		}
+		if args.AuditorIndex != nil {
+			auditorIndex = uint(*args.AuditorIndex)
+		} else {
+			auditorIndex, err = s.resolvePrivAuditorIndex(ctx, args.AuditorPrivkey, ptx.FromAddress(), blockNumber)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if auditorIndex >= uint(len(handles)) {
+			return nil, fmt.Errorf("auditorIndex %d out of range (%d handles)", auditorIndex, len(handles))
+		}
 		commitment = ptx.Commitment
-		auditorHandle = ptx.AuditorHandle
+		auditorHandle = handles[auditorIndex].Handle
 		txType = "privTransfer"
 
 	case types.ShieldTxType:
@@ -3787,7 +3826,39 @@ func (s *TOSAPI) PrivDecryptWithAuditorKey(ctx context.Context, args RPCPrivDecr
 	}
 
 	return &RPCPrivDecryptAuditorResult{
-		Amount: hexutil.Uint64(amount),
-		TxType: txType,
+		Amount:       hexutil.Uint64(amount),
+		TxType:       txType,
+		AuditorIndex: hexutil.Uint(auditorIndex),
 	}, nil
 }
+
+// resolvePrivAuditorIndex finds the auditor handle index that the auditor
+// owning privkey was assigned in a transfer sent by from at blockNumber.
+// Retired epochs stay in the sender's schedule, so the latest state is enough
+// when the state at the transfer's block has been pruned by the node. Returns
+// an error when the key matches none of the auditor keys accepted for that
+// transfer.
+func (s *TOSAPI) resolvePrivAuditorIndex(ctx context.Context, privkey []byte, from common.Address, blockNumber uint64) (uint, error) {
+	pub, err := cryptopriv.PublicKeyFromPrivate(privkey)
+	if err != nil || len(pub) != 32 {
+		return 0, fmt.Errorf("invalid auditor private key")
+	}
+	var auditorPub [32]byte
+	copy(auditorPub[:], pub)
+
+	statedb, _, err := s.b.StateAndHeaderByNumber(ctx, rpc.BlockNumber(blockNumber))
+	if err != nil || statedb == nil {
+		statedb, _, err = s.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+		if err != nil || statedb == nil {
+			return 0, fmt.Errorf("state unavailable to resolve auditor index")
+		}
+	}
+	for _, keys := range policywallet.AcceptedAuditorKeySets(statedb, from, blockNumber) {
+		for i, key := range keys {
+			if key == auditorPub {
+				return uint(i), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("auditor key not among handles")
+}
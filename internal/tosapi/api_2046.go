@@ -6,11 +6,13 @@ import (
 	"github.com/tos-network/gtos/policywallet"
 	"github.com/tos-network/gtos/rpc"
 	"github.com/tos-network/gtos/settlement"
-	"github.com/tos-network/gtos/tns"
+	"github.com/tos-network/gtos/sponsorpolicy"
+	"github.com/tos-network/gtos/task"
 )
 
 // Register2046APIs returns the RPC API descriptors for the 2046 architecture
-// modules (policy wallet, gateway, audit receipt, settlement).
+// modules (policy wallet, gateway, audit receipt, settlement, sponsor policy,
+// scheduled tasks).
 //
 // stateReader must return the state at the current head block.  The concrete
 // return value must implement GetState/SetState — typically *state.StateDB.
@@ -39,8 +41,14 @@ func Register2046APIs(stateReader func() policywallet.StateDB) []rpc.API {
 			}),
 		},
 		{
-			Namespace: "tns",
-			Service: tns.NewPublicTNSAPI(func() tns.StateDB {
+			Namespace: "sponsorPolicy",
+			Service: sponsorpolicy.NewPublicSponsorPolicyAPI(func() sponsorpolicy.StateDB {
+				return stateReader()
+			}),
+		},
+		{
+			Namespace: "task",
+			Service: task.NewPublicTaskAPI(func() task.StateDB {
 				return stateReader()
 			}),
 		},
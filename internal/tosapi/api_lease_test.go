@@ -127,6 +127,12 @@ func TestGetLeaseReadsActiveLeaseMetadata(t *testing.T) {
 	if got.DepositWei == nil || (*big.Int)(got.DepositWei).Cmp(deposit) != 0 {
 		t.Fatalf("unexpected deposit: %v", got.DepositWei)
 	}
+	if got.RenewalBudgetWei == nil || (*big.Int)(got.RenewalBudgetWei).Sign() != 0 {
+		t.Fatalf("unexpected renewal budget: %v", got.RenewalBudgetWei)
+	}
+	if len(got.Warnings) != 1 {
+		t.Fatalf("expected an expiry warning, got %q", got.Warnings)
+	}
 }
 
 func TestGetLeaseReadsTombstone(t *testing.T) {
@@ -0,0 +1,124 @@
+package tosapi
+
+import (
+	"context"
+
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/common/hexutil"
+	"github.com/tos-network/gtos/core"
+	"github.com/tos-network/gtos/core/rawdb"
+)
+
+const (
+	// privIndexDefaultLimit is the page size used when the caller omits one.
+	privIndexDefaultLimit = 100
+	// privIndexMaxLimit caps the page size of priv_getTransactionsByKey.
+	privIndexMaxLimit = 1000
+)
+
+// PrivIndexAPI exposes the priv transaction history index maintained by the
+// opt-in PrivTxIndexer under the "priv" namespace.
+type PrivIndexAPI struct {
+	b       Backend
+	indexer *core.ChainIndexer
+}
+
+// NewPrivIndexAPI creates a new priv history index API.
+func NewPrivIndexAPI(b Backend, indexer *core.ChainIndexer) *PrivIndexAPI {
+	return &PrivIndexAPI{b: b, indexer: indexer}
+}
+
+// RPCPrivTxHistoryArgs selects a page of a priv account's history. The cursor
+// (FromBlock, FromTxIndex) is inclusive; pass back the NextCursor of a previous
+// page to continue.
+type RPCPrivTxHistoryArgs struct {
+	Pubkey      hexutil.Bytes   `json:"pubkey"`      // 32B ElGamal public key
+	FromBlock   *hexutil.Uint64 `json:"fromBlock"`   // default 0
+	FromTxIndex *hexutil.Uint   `json:"fromTxIndex"` // default 0
+	Limit       *hexutil.Uint   `json:"limit"`       // default 100, max 1000
+}
+
+// RPCPrivTxHistoryEntry is one reference in a priv account's history. The
+// sender/receiver flags describe the role of the queried key in the tx.
+type RPCPrivTxHistoryEntry struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	TxIndex     hexutil.Uint   `json:"txIndex"`
+	TxHash      common.Hash    `json:"txHash"`
+	Type        hexutil.Uint64 `json:"type"`
+	Sender      bool           `json:"sender"`
+	Receiver    bool           `json:"receiver"`
+}
+
+// RPCPrivTxHistoryCursor is the position to resume a history listing from.
+type RPCPrivTxHistoryCursor struct {
+	FromBlock   hexutil.Uint64 `json:"fromBlock"`
+	FromTxIndex hexutil.Uint   `json:"fromTxIndex"`
+}
+
+// RPCPrivTxHistoryResult is a page of a priv account's history.
+type RPCPrivTxHistoryResult struct {
+	Transactions []RPCPrivTxHistoryEntry `json:"transactions"`
+	NextCursor   *RPCPrivTxHistoryCursor `json:"nextCursor"`   // nil when the listing is complete
+	IndexedBlock *hexutil.Uint64         `json:"indexedBlock"` // last block covered by the index, nil if none yet
+}
+
+// GetTransactionsByKey returns the PrivTransfer, Shield and Unshield
+// transactions touching the given ElGamal public key in ascending chain order.
+// Only blocks already processed by the indexer are covered; see IndexedBlock.
+func (api *PrivIndexAPI) GetTransactionsByKey(ctx context.Context, args RPCPrivTxHistoryArgs) (*RPCPrivTxHistoryResult, error) {
+	if len(args.Pubkey) != 32 {
+		return nil, newRPCInvalidParamsError("pubkey", "must be exactly 32 bytes")
+	}
+	limit := privIndexDefaultLimit
+	if args.Limit != nil {
+		if *args.Limit == 0 || *args.Limit > privIndexMaxLimit {
+			return nil, newRPCInvalidParamsError("limit", "must be between 1 and 1000")
+		}
+		limit = int(*args.Limit)
+	}
+	var (
+		pubkey      [32]byte
+		fromBlock   uint64
+		fromTxIndex uint32
+	)
+	copy(pubkey[:], args.Pubkey)
+	if args.FromBlock != nil {
+		fromBlock = uint64(*args.FromBlock)
+	}
+	if args.FromTxIndex != nil {
+		fromTxIndex = uint32(*args.FromTxIndex)
+	}
+
+	entries, more := rawdb.ReadPrivTxIndexEntries(api.b.ChainDb(), pubkey, fromBlock, fromTxIndex, limit)
+	result := &RPCPrivTxHistoryResult{
+		Transactions: make([]RPCPrivTxHistoryEntry, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		result.Transactions = append(result.Transactions, RPCPrivTxHistoryEntry{
+			BlockNumber: hexutil.Uint64(entry.BlockNumber),
+			TxIndex:     hexutil.Uint(entry.TxIndex),
+			TxHash:      entry.TxHash,
+			Type:        hexutil.Uint64(entry.TxType),
+			Sender:      entry.Role&rawdb.PrivTxRoleSender != 0,
+			Receiver:    entry.Role&rawdb.PrivTxRoleReceiver != 0,
+		})
+	}
+	if more && len(entries) > 0 {
+		last := entries[len(entries)-1]
+		next := &RPCPrivTxHistoryCursor{
+			FromBlock:   hexutil.Uint64(last.BlockNumber),
+			FromTxIndex: hexutil.Uint(last.TxIndex + 1),
+		}
+		if last.TxIndex == ^uint32(0) {
+			next.FromBlock, next.FromTxIndex = hexutil.Uint64(last.BlockNumber+1), 0
+		}
+		result.NextCursor = next
+	}
+	if api.indexer != nil {
+		if sections, head, _ := api.indexer.Sections(); sections > 0 {
+			indexed := hexutil.Uint64(head)
+			result.IndexedBlock = &indexed
+		}
+	}
+	return result, nil
+}
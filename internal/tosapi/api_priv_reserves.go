@@ -0,0 +1,138 @@
+package tosapi
+
+import (
+	"context"
+
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/common/hexutil"
+	corepriv "github.com/tos-network/gtos/core/priv"
+	"github.com/tos-network/gtos/crypto"
+	"github.com/tos-network/gtos/rpc"
+)
+
+// PrivReservesAPI provides balance-threshold (proof-of-reserves) proofs for
+// priv accounts under the "priv" namespace.
+type PrivReservesAPI struct {
+	b Backend
+}
+
+// NewPrivReservesAPI creates a new balance-threshold proof API.
+func NewPrivReservesAPI(b Backend) *PrivReservesAPI {
+	return &PrivReservesAPI{b: b}
+}
+
+// RPCPrivBalanceAtLeastArgs holds arguments for priv_proveBalanceAtLeast.
+type RPCPrivBalanceAtLeastArgs struct {
+	Privkey   hexutil.Bytes          `json:"privkey"`   // 32B ElGamal private key
+	Pubkey    hexutil.Bytes          `json:"pubkey"`    // 32B ElGamal public key
+	Balance   *hexutil.Uint64        `json:"balance"`   // decrypted balance of the account
+	Threshold *hexutil.Uint64        `json:"threshold"` // amount the balance is proven to reach
+	Challenge common.Hash            `json:"challenge"` // verifier-chosen challenge
+	Block     *rpc.BlockNumberOrHash `json:"block"`     // account state to prove against, default latest
+}
+
+// RPCPrivBalanceAtLeastResult is a balance-threshold proof together with the
+// account state it is bound to.
+type RPCPrivBalanceAtLeastResult struct {
+	Proof       hexutil.Bytes  `json:"proof"`      // 896B: commitment ‖ equality proof ‖ range proof
+	Commitment  hexutil.Bytes  `json:"commitment"` // 32B balance commitment at BlockNumber
+	Handle      hexutil.Bytes  `json:"handle"`     // 32B balance handle at BlockNumber
+	PrivNonce   hexutil.Uint64 `json:"privNonce"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+}
+
+// RPCPrivVerifyBalanceAtLeastArgs holds arguments for priv_verifyBalanceAtLeast.
+type RPCPrivVerifyBalanceAtLeastArgs struct {
+	Pubkey    hexutil.Bytes          `json:"pubkey"` // 32B ElGamal public key
+	Threshold *hexutil.Uint64        `json:"threshold"`
+	Challenge common.Hash            `json:"challenge"`
+	Proof     hexutil.Bytes          `json:"proof"` // 896B
+	Block     *rpc.BlockNumberOrHash `json:"block"` // account state to verify against, default latest
+}
+
+// ProveBalanceAtLeast proves that the account's encrypted balance at the
+// given block is at least threshold, without revealing the balance. The
+// proof is bound to the chain, the account's ciphertext and priv nonce at
+// that block, and the verifier's challenge.
+func (api *PrivReservesAPI) ProveBalanceAtLeast(ctx context.Context, args RPCPrivBalanceAtLeastArgs) (*RPCPrivBalanceAtLeastResult, error) {
+	if len(args.Privkey) != 32 {
+		return nil, newRPCInvalidParamsError("privkey", "must be exactly 32 bytes")
+	}
+	if len(args.Pubkey) != 32 {
+		return nil, newRPCInvalidParamsError("pubkey", "must be exactly 32 bytes")
+	}
+	if args.Balance == nil {
+		return nil, newRPCInvalidParamsError("balance", "is required")
+	}
+	if args.Threshold == nil {
+		return nil, newRPCInvalidParamsError("threshold", "is required")
+	}
+	if *args.Balance < *args.Threshold {
+		return nil, newRPCInvalidParamsError("threshold", "exceeds balance")
+	}
+	state, number, err := api.accountState(ctx, args.Pubkey, args.Block)
+	if err != nil {
+		return nil, err
+	}
+	var privkey, pubkey [32]byte
+	copy(privkey[:], args.Privkey)
+	copy(pubkey[:], args.Pubkey)
+	proof, err := corepriv.ProveBalanceAtLeast(privkey, pubkey, state.Ciphertext,
+		uint64(*args.Balance), state.Nonce, uint64(*args.Threshold), args.Challenge, api.b.ChainConfig().ChainID)
+	if err != nil {
+		return nil, err
+	}
+	return &RPCPrivBalanceAtLeastResult{
+		Proof:       proof,
+		Commitment:  hexutil.Bytes(state.Ciphertext.Commitment[:]),
+		Handle:      hexutil.Bytes(state.Ciphertext.Handle[:]),
+		PrivNonce:   hexutil.Uint64(state.Nonce),
+		BlockNumber: hexutil.Uint64(number),
+	}, nil
+}
+
+// VerifyBalanceAtLeast checks a balance-threshold proof against the account's
+// on-chain state at the given block. Proofs made at an earlier block stop
+// verifying once the account's balance or priv nonce changes; pass the
+// prover's blockNumber to check them against the state they were made for.
+func (api *PrivReservesAPI) VerifyBalanceAtLeast(ctx context.Context, args RPCPrivVerifyBalanceAtLeastArgs) (bool, error) {
+	if len(args.Pubkey) != 32 {
+		return false, newRPCInvalidParamsError("pubkey", "must be exactly 32 bytes")
+	}
+	if args.Threshold == nil {
+		return false, newRPCInvalidParamsError("threshold", "is required")
+	}
+	if len(args.Proof) != corepriv.BalanceAtLeastProofSize {
+		return false, newRPCInvalidParamsError("proof", "must be exactly 896 bytes")
+	}
+	state, _, err := api.accountState(ctx, args.Pubkey, args.Block)
+	if err != nil {
+		return false, err
+	}
+	claim := corepriv.BalanceAtLeastClaim{
+		Ciphertext: state.Ciphertext,
+		Nonce:      state.Nonce,
+		Threshold:  uint64(*args.Threshold),
+		Challenge:  args.Challenge,
+		Proof:      args.Proof,
+	}
+	copy(claim.Pubkey[:], args.Pubkey)
+	return corepriv.VerifyBalanceAtLeast(claim, api.b.ChainConfig().ChainID) == nil, nil
+}
+
+// accountState loads the priv account state of pubkey at the given block.
+func (api *PrivReservesAPI) accountState(ctx context.Context, pubkey []byte, block *rpc.BlockNumberOrHash) (corepriv.AccountState, uint64, error) {
+	resolved := resolveBlockArg(block)
+	if err := enforceHistoryRetentionByBlockArg(api.b, resolved); err != nil {
+		return corepriv.AccountState{}, 0, err
+	}
+	st, header, err := api.b.StateAndHeaderByNumberOrHash(ctx, resolved)
+	if err != nil {
+		return corepriv.AccountState{}, 0, err
+	}
+	if st == nil || header == nil {
+		return corepriv.AccountState{}, 0, &rpcAPIError{code: rpcErrNotFound, message: "priv state not found"}
+	}
+	address := common.BytesToAddress(crypto.Keccak256(pubkey))
+	return corepriv.GetAccountState(st, address), header.Number.Uint64(), nil
+}
@@ -0,0 +1,53 @@
+package tosapi
+
+import (
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/common/hexutil"
+	"github.com/tos-network/gtos/crypto/priv/ecdlptable"
+)
+
+// PrivTableAPI serves a precomputed BSGS discrete-log table to wallets under
+// the "priv" namespace, so they can download it once from a node instead of
+// generating it locally.
+type PrivTableAPI struct {
+	table *ecdlptable.File
+}
+
+// NewPrivTableAPI creates a new ECDLP table distribution API.
+func NewPrivTableAPI(table *ecdlptable.File) *PrivTableAPI {
+	return &PrivTableAPI{table: table}
+}
+
+// RPCECDLPTableManifest describes the served table. Every chunk can be
+// checked against its sha256 hash; Root is sha256 over all chunk hashes.
+type RPCECDLPTableManifest struct {
+	L1        hexutil.Uint   `json:"l1"`
+	TableLen  hexutil.Uint64 `json:"tableLen"`
+	ChunkSize hexutil.Uint64 `json:"chunkSize"`
+	Root      common.Hash    `json:"root"`
+	Chunks    []common.Hash  `json:"chunks"`
+}
+
+// GetECDLPTableManifest returns the manifest of the served table.
+func (api *PrivTableAPI) GetECDLPTableManifest() *RPCECDLPTableManifest {
+	m := api.table.Manifest()
+	out := &RPCECDLPTableManifest{
+		L1:        hexutil.Uint(m.L1),
+		TableLen:  hexutil.Uint64(m.TableLen),
+		ChunkSize: hexutil.Uint64(m.ChunkSize),
+		Root:      m.Root(),
+		Chunks:    make([]common.Hash, len(m.Chunks)),
+	}
+	for i, c := range m.Chunks {
+		out.Chunks[i] = c
+	}
+	return out
+}
+
+// GetECDLPTableChunk returns body chunk index of the served table.
+func (api *PrivTableAPI) GetECDLPTableChunk(index hexutil.Uint) (hexutil.Bytes, error) {
+	if int(index) >= len(api.table.Manifest().Chunks) {
+		return nil, newRPCInvalidParamsError("index", "out of range")
+	}
+	return api.table.Chunk(int(index))
+}
@@ -99,6 +99,9 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 		}, {
 			Namespace: "personal",
 			Service:   NewPersonalAccountAPI(apiBackend, nonceLock),
+		}, {
+			Namespace: "priv",
+			Service:   NewPrivReservesAPI(apiBackend),
 		},
 	}
 }
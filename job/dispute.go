@@ -0,0 +1,77 @@
+package job
+
+import (
+	"github.com/tos-network/gtos/common"
+	vmtypes "github.com/tos-network/gtos/core/vmtypes"
+	"github.com/tos-network/gtos/params"
+)
+
+// The functions below let the dispute module freeze and settle a job. They
+// do not record reputation; the dispute module scores both parties.
+
+// Freeze marks jobId as disputed by party, stopping approval, rejection and
+// timeouts until the dispute is resolved. Accepted and submitted jobs can be
+// disputed by either party, rejected jobs only within the contest window.
+func Freeze(db stateDB, now uint64, jobId common.Hash, party common.Address) (*Job, error) {
+	j, ok := ReadJob(db, jobId)
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	if party != j.Client && party != j.Worker {
+		return nil, ErrJobNotParty
+	}
+	switch j.Status {
+	case JobAccepted, JobSubmitted:
+	case JobRejected:
+		if now > j.RejectedAt+params.JobContestBlocks {
+			return nil, ErrJobDeadlinePassed
+		}
+	default:
+		return nil, ErrJobNotDisputable
+	}
+	j.Status = JobDisputed
+	WriteJob(db, jobId, j)
+	return j, nil
+}
+
+// ResolveForWorker settles a disputed job in the worker's favour: the worker
+// is paid and the receipt succeeds with disputeRef as its proof reference.
+func ResolveForWorker(db vmtypes.StateDB, now uint64, jobId, disputeRef common.Hash) error {
+	j, err := readDisputed(db, jobId)
+	if err != nil {
+		return err
+	}
+	return complete(db, now, jobId, j, disputeRef)
+}
+
+// ResolveForClient settles a disputed job in the client's favour: the budget
+// and the worker's bond go to the client and the receipt fails with
+// disputeRef as its failure reference.
+func ResolveForClient(db vmtypes.StateDB, now uint64, jobId, disputeRef common.Hash) error {
+	j, err := readDisputed(db, jobId)
+	if err != nil {
+		return err
+	}
+	return forfeit(db, now, jobId, j, disputeRef)
+}
+
+// ResolveUndecided unwinds a disputed job without a verdict: the budget
+// returns to the client, the bond to the worker, and the receipt fails.
+func ResolveUndecided(db vmtypes.StateDB, now uint64, jobId, disputeRef common.Hash) error {
+	j, err := readDisputed(db, jobId)
+	if err != nil {
+		return err
+	}
+	return refund(db, now, jobId, j, disputeRef)
+}
+
+func readDisputed(db stateDB, jobId common.Hash) (*Job, error) {
+	j, ok := ReadJob(db, jobId)
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	if j.Status != JobDisputed {
+		return nil, ErrJobNotDisputed
+	}
+	return j, nil
+}
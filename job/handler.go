@@ -0,0 +1,456 @@
+package job
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/tos-network/gtos/agent"
+	"github.com/tos-network/gtos/common"
+	vmtypes "github.com/tos-network/gtos/core/vmtypes"
+	"github.com/tos-network/gtos/crypto"
+	"github.com/tos-network/gtos/params"
+	"github.com/tos-network/gtos/reputation"
+	"github.com/tos-network/gtos/settlement"
+	"github.com/tos-network/gtos/sysaction"
+)
+
+func init() {
+	sysaction.DefaultRegistry.Register(&jobHandler{})
+}
+
+type jobHandler struct{}
+
+func (h *jobHandler) Actions() []sysaction.ActionKind {
+	return []sysaction.ActionKind{
+		sysaction.ActionJobPost,
+		sysaction.ActionJobAccept,
+		sysaction.ActionJobSubmit,
+		sysaction.ActionJobApprove,
+		sysaction.ActionJobReject,
+		sysaction.ActionJobCancel,
+		sysaction.ActionJobTimeout,
+	}
+}
+
+func (h *jobHandler) Handle(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	switch sa.Action {
+	case sysaction.ActionJobPost:
+		return h.handlePost(ctx, sa)
+	case sysaction.ActionJobAccept:
+		return h.handleAccept(ctx, sa)
+	case sysaction.ActionJobSubmit:
+		return h.handleSubmit(ctx, sa)
+	case sysaction.ActionJobApprove:
+		return h.handleApprove(ctx, sa)
+	case sysaction.ActionJobReject:
+		return h.handleReject(ctx, sa)
+	case sysaction.ActionJobCancel:
+		return h.handleCancel(ctx, sa)
+	case sysaction.ActionJobTimeout:
+		return h.handleTimeout(ctx, sa)
+	}
+	return nil
+}
+
+type postPayload struct {
+	SpecHash       string `json:"spec_hash"`        // hex 32 bytes
+	Worker         string `json:"worker,omitempty"` // optional designated worker
+	Bond           string `json:"bond"`             // decimal string (wei); 0 = no bond
+	DeadlineBlocks uint64 `json:"deadline_blocks"`
+	ReviewBlocks   uint64 `json:"review_blocks"`
+}
+
+func (h *jobHandler) handlePost(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p postPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+
+	// 1. The tx value is the escrowed budget.
+	if ctx.Value == nil || ctx.Value.Sign() <= 0 {
+		return ErrJobBudgetZero
+	}
+	if ctx.StateDB.GetBalance(ctx.From).Cmp(ctx.Value) < 0 {
+		return ErrJobInsufficientFunds
+	}
+
+	// 2. Validate terms.
+	spec := common.HexToHash(p.SpecHash)
+	if spec == (common.Hash{}) {
+		return ErrJobInvalidSpec
+	}
+	bond := new(big.Int)
+	if p.Bond != "" {
+		var ok bool
+		if bond, ok = new(big.Int).SetString(p.Bond, 10); !ok || bond.Sign() < 0 {
+			return ErrJobInvalidBond
+		}
+	}
+	if p.DeadlineBlocks < 1 {
+		return ErrJobDeadlineZero
+	}
+	if p.DeadlineBlocks > params.JobMaxHorizonBlocks {
+		return ErrJobDeadlineTooFar
+	}
+	if p.ReviewBlocks < params.JobMinReviewBlocks {
+		return ErrJobReviewTooShort
+	}
+	if p.ReviewBlocks > params.JobMaxHorizonBlocks {
+		return ErrJobReviewTooLong
+	}
+	var worker common.Address
+	if p.Worker != "" {
+		worker = common.HexToAddress(p.Worker)
+	}
+
+	// 3. Escrow the budget.
+	ctx.StateDB.SubBalance(ctx.From, ctx.Value)
+	ctx.StateDB.AddBalance(params.JobMarketAddress, ctx.Value)
+
+	// 4. Mint the job ID and store the job.
+	jobId := NewJobID(ctx.From, IncrementClientNonce(ctx.StateDB, ctx.From))
+	WriteJob(ctx.StateDB, jobId, &Job{
+		Client:       ctx.From,
+		Worker:       worker,
+		Budget:       new(big.Int).Set(ctx.Value),
+		Bond:         bond,
+		SpecHash:     spec,
+		Deadline:     ctx.BlockNumber.Uint64() + p.DeadlineBlocks,
+		ReviewBlocks: p.ReviewBlocks,
+		Status:       JobOpen,
+	})
+	return nil
+}
+
+type jobPayload struct {
+	JobID string `json:"job_id"` // hex hash
+}
+
+func (h *jobHandler) handleAccept(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	jobId, j, err := loadJob(ctx, sa)
+	if err != nil {
+		return err
+	}
+	if j.Status != JobOpen {
+		return ErrJobNotOpen
+	}
+	if ctx.BlockNumber.Uint64() > j.Deadline {
+		return ErrJobDeadlinePassed
+	}
+	if ctx.From == j.Client {
+		return ErrJobSelfAccept
+	}
+	if j.Worker != (common.Address{}) && j.Worker != ctx.From {
+		return ErrJobNotWorker
+	}
+	// Only active, unsuspended agents can be hired.
+	if !agent.IsRegistered(ctx.StateDB, ctx.From) || agent.IsSuspended(ctx.StateDB, ctx.From) ||
+		agent.ReadStatus(ctx.StateDB, ctx.From) != agent.AgentActive {
+		return ErrJobWorkerNotAgent
+	}
+
+	// The tx value is the bond and must match the posted terms exactly.
+	value := ctx.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	if value.Cmp(j.Bond) != 0 {
+		return ErrJobBondMismatch
+	}
+	if ctx.StateDB.GetBalance(ctx.From).Cmp(value) < 0 {
+		return ErrJobInsufficientFunds
+	}
+	ctx.StateDB.SubBalance(ctx.From, value)
+	ctx.StateDB.AddBalance(params.JobMarketAddress, value)
+
+	j.Worker = ctx.From
+	j.Status = JobAccepted
+	WriteJob(ctx.StateDB, jobId, j)
+	openReceipt(ctx.StateDB, ctx.BlockNumber.Uint64(), jobId, j)
+	return nil
+}
+
+type submitPayload struct {
+	JobID       string `json:"job_id"`      // hex hash
+	Deliverable string `json:"deliverable"` // hex 32 bytes
+}
+
+func (h *jobHandler) handleSubmit(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p submitPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	jobId := common.HexToHash(p.JobID)
+	j, ok := ReadJob(ctx.StateDB, jobId)
+	if !ok {
+		return ErrJobNotFound
+	}
+	if j.Status != JobAccepted {
+		return ErrJobNotAccepted
+	}
+	if ctx.From != j.Worker {
+		return ErrJobNotWorker
+	}
+	if ctx.BlockNumber.Uint64() > j.Deadline {
+		return ErrJobDeadlinePassed
+	}
+	deliverable := common.HexToHash(p.Deliverable)
+	if deliverable == (common.Hash{}) {
+		return ErrJobInvalidDeliverable
+	}
+	j.Deliverable = deliverable
+	j.SubmittedAt = ctx.BlockNumber.Uint64()
+	j.Status = JobSubmitted
+	WriteJob(ctx.StateDB, jobId, j)
+	return nil
+}
+
+func (h *jobHandler) handleApprove(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	jobId, j, err := loadJob(ctx, sa)
+	if err != nil {
+		return err
+	}
+	if j.Status != JobSubmitted {
+		return ErrJobNotSubmitted
+	}
+	if ctx.From != j.Client {
+		return ErrJobNotClient
+	}
+	if err := complete(ctx.StateDB, ctx.BlockNumber.Uint64(), jobId, j, common.Hash{}); err != nil {
+		return err
+	}
+	reputation.RecordScore(ctx.StateDB, j.Worker, ReputationDeltaCompleted)
+	return nil
+}
+
+type rejectPayload struct {
+	JobID  string `json:"job_id"`           // hex hash
+	Reason string `json:"reason,omitempty"` // optional hex 32-byte reason reference
+}
+
+func (h *jobHandler) handleReject(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p rejectPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	jobId := common.HexToHash(p.JobID)
+	j, ok := ReadJob(ctx.StateDB, jobId)
+	if !ok {
+		return ErrJobNotFound
+	}
+	if j.Status != JobSubmitted {
+		return ErrJobNotSubmitted
+	}
+	if ctx.From != j.Client {
+		return ErrJobNotClient
+	}
+	if ctx.BlockNumber.Uint64() > j.SubmittedAt+j.ReviewBlocks {
+		return ErrJobDeadlinePassed
+	}
+	// Escrow stays locked for JobContestBlocks so the worker can dispute the
+	// rejection; JOB_TIMEOUT returns the funds afterwards.
+	j.RejectedAt = ctx.BlockNumber.Uint64()
+	j.Reason = common.HexToHash(p.Reason)
+	j.Status = JobRejected
+	WriteJob(ctx.StateDB, jobId, j)
+	return nil
+}
+
+func (h *jobHandler) handleCancel(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	jobId, j, err := loadJob(ctx, sa)
+	if err != nil {
+		return err
+	}
+	if j.Status != JobOpen {
+		return ErrJobNotOpen
+	}
+	if ctx.From != j.Client {
+		return ErrJobNotClient
+	}
+	if err := release(ctx.StateDB, j.Client, j.Budget); err != nil {
+		return err
+	}
+	j.Status = JobCancelled
+	WriteJob(ctx.StateDB, jobId, j)
+	return nil
+}
+
+// handleTimeout settles a job whose counterparty missed a deadline. It may be
+// sent by anyone:
+//   - Open past the deadline: the budget is refunded to the client.
+//   - Accepted past the deadline: the budget and the slashed bond go to the
+//     client and the worker is penalized.
+//   - Submitted past the review window: the client's silence counts as
+//     approval and the worker is paid.
+//   - Rejected past the contest window: the rejection stands; the budget
+//     returns to the client and the bond to the worker.
+func (h *jobHandler) handleTimeout(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	jobId, j, err := loadJob(ctx, sa)
+	if err != nil {
+		return err
+	}
+	db, now := ctx.StateDB, ctx.BlockNumber.Uint64()
+	switch j.Status {
+	case JobOpen:
+		if now <= j.Deadline {
+			return ErrJobNotTimedOut
+		}
+		if err := release(db, j.Client, j.Budget); err != nil {
+			return err
+		}
+		j.Status = JobExpired
+		WriteJob(db, jobId, j)
+		return nil
+
+	case JobAccepted:
+		if now <= j.Deadline {
+			return ErrJobNotTimedOut
+		}
+		if err := forfeit(db, now, jobId, j, common.Hash{}); err != nil {
+			return err
+		}
+		reputation.RecordScore(db, j.Worker, ReputationDeltaDefaulted)
+		return nil
+
+	case JobSubmitted:
+		if now <= j.SubmittedAt+j.ReviewBlocks {
+			return ErrJobNotTimedOut
+		}
+		if err := complete(db, now, jobId, j, common.Hash{}); err != nil {
+			return err
+		}
+		reputation.RecordScore(db, j.Worker, ReputationDeltaCompleted)
+		return nil
+
+	case JobRejected:
+		if now <= j.RejectedAt+params.JobContestBlocks {
+			return ErrJobNotTimedOut
+		}
+		if err := refund(db, now, jobId, j, j.Reason); err != nil {
+			return err
+		}
+		reputation.RecordScore(db, j.Worker, ReputationDeltaRejected)
+		return nil
+	}
+	return ErrJobNotTimedOut
+}
+
+// loadJob decodes a {job_id} payload and reads the referenced job.
+func loadJob(ctx *sysaction.Context, sa *sysaction.SysAction) (common.Hash, *Job, error) {
+	var p jobPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return common.Hash{}, nil, err
+	}
+	jobId := common.HexToHash(p.JobID)
+	j, ok := ReadJob(ctx.StateDB, jobId)
+	if !ok {
+		return common.Hash{}, nil, ErrJobNotFound
+	}
+	return jobId, j, nil
+}
+
+// complete pays the budget and returns the bond to the worker and settles the
+// job's runtime receipt.
+func complete(db vmtypes.StateDB, now uint64, jobId common.Hash, j *Job, proofRef common.Hash) error {
+	if err := release(db, j.Worker, new(big.Int).Add(j.Budget, j.Bond)); err != nil {
+		return err
+	}
+	j.Status = JobCompleted
+	WriteJob(db, jobId, j)
+	settleReceipt(db, now, jobId, j, proofRef)
+	return nil
+}
+
+// forfeit returns the budget and pays the worker's bond to the client and
+// fails the job's runtime receipt.
+func forfeit(db vmtypes.StateDB, now uint64, jobId common.Hash, j *Job, failureRef common.Hash) error {
+	if err := release(db, j.Client, new(big.Int).Add(j.Budget, j.Bond)); err != nil {
+		return err
+	}
+	j.Status = JobDefaulted
+	WriteJob(db, jobId, j)
+	failReceipt(db, now, jobId, failureRef)
+	return nil
+}
+
+// refund returns the budget to the client and the bond to the worker and
+// fails the job's runtime receipt.
+func refund(db vmtypes.StateDB, now uint64, jobId common.Hash, j *Job, failureRef common.Hash) error {
+	if err := release(db, j.Client, j.Budget); err != nil {
+		return err
+	}
+	if err := release(db, j.Worker, j.Bond); err != nil {
+		return err
+	}
+	j.Status = JobRefunded
+	WriteJob(db, jobId, j)
+	failReceipt(db, now, jobId, failureRef)
+	return nil
+}
+
+// release moves amount out of the job escrow to to.
+func release(db vmtypes.StateDB, to common.Address, amount *big.Int) error {
+	if amount.Sign() == 0 {
+		return nil
+	}
+	if db.GetBalance(params.JobMarketAddress).Cmp(amount) < 0 {
+		return ErrJobEscrowBroken
+	}
+	db.SubBalance(params.JobMarketAddress, amount)
+	db.AddBalance(to, amount)
+	return nil
+}
+
+// ── Runtime receipts ──────────────────────────────────────────────────────────
+//
+// System actions do not see the block timestamp, so job receipts stamp
+// OpenedAt / FinalizedAt and the settlement CreatedAt with block numbers.
+
+// openReceipt opens the job's runtime receipt once a worker is bound.
+func openReceipt(db vmtypes.StateDB, now uint64, jobId common.Hash, j *Job) {
+	ref := ReceiptRef(jobId)
+	settlement.WriteRuntimeReceiptExists(db, ref)
+	settlement.WriteRuntimeReceiptKind(db, ref, ReceiptKindJob)
+	settlement.WriteRuntimeReceiptStatus(db, ref, settlement.ReceiptStatusOpen)
+	settlement.WriteRuntimeReceiptSender(db, ref, j.Client)
+	settlement.WriteRuntimeReceiptRecipient(db, ref, j.Worker)
+	settlement.WriteRuntimeReceiptPolicyRef(db, ref, j.SpecHash)
+	settlement.WriteRuntimeReceiptOpenedAt(db, ref, now)
+	writeReceiptJob(db, ref, jobId)
+}
+
+// settleReceipt records the worker payout as a settlement effect and
+// finalizes the job's receipt as successful.
+func settleReceipt(db vmtypes.StateDB, now uint64, jobId common.Hash, j *Job, proofRef common.Hash) {
+	ref := ReceiptRef(jobId)
+	settlementRef := SettlementRef(jobId)
+	amountRef := crypto.Keccak256Hash([]byte(j.Budget.String()))
+
+	settlement.WriteSettlementEffectExists(db, settlementRef)
+	settlement.WriteSettlementEffectReceiptRef(db, settlementRef, ref)
+	settlement.WriteSettlementEffectMode(db, settlementRef, settlement.ModeEscrowReleasePublic)
+	settlement.WriteSettlementEffectSender(db, settlementRef, j.Client)
+	settlement.WriteSettlementEffectRecipient(db, settlementRef, j.Worker)
+	settlement.WriteSettlementEffectAmountRef(db, settlementRef, amountRef)
+	settlement.WriteSettlementEffectPolicyRef(db, settlementRef, j.SpecHash)
+	settlement.WriteSettlementEffectArtifactRef(db, settlementRef, j.Deliverable)
+	settlement.WriteSettlementEffectCreatedAt(db, settlementRef, now)
+
+	settlement.WriteRuntimeReceiptMode(db, ref, settlement.ModeEscrowReleasePublic)
+	settlement.WriteRuntimeReceiptSettlementRef(db, ref, settlementRef)
+	settlement.WriteRuntimeReceiptAmountRef(db, ref, amountRef)
+	settlement.WriteRuntimeReceiptArtifactRef(db, ref, j.Deliverable)
+	settlement.WriteRuntimeReceiptProofRef(db, ref, proofRef)
+	settlement.WriteRuntimeReceiptStatus(db, ref, settlement.ReceiptStatusSuccess)
+	settlement.WriteRuntimeReceiptFinalizedAt(db, ref, now)
+}
+
+// failReceipt finalizes the job's receipt as failed and queues the on_fail
+// callbacks linked to it.
+func failReceipt(db vmtypes.StateDB, now uint64, jobId common.Hash, failureRef common.Hash) {
+	ref := ReceiptRef(jobId)
+	settlement.WriteRuntimeReceiptFailureRef(db, ref, failureRef)
+	settlement.WriteRuntimeReceiptStatus(db, ref, settlement.ReceiptStatusFailure)
+	settlement.WriteRuntimeReceiptFinalizedAt(db, ref, now)
+	settlement.TriggerReceiptFailure(db, ref, now)
+}
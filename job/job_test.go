@@ -0,0 +1,298 @@
+package job
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	_ "github.com/tos-network/gtos/agent" // registers AGENT_REGISTER for setup
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/core/rawdb"
+	"github.com/tos-network/gtos/core/state"
+	"github.com/tos-network/gtos/params"
+	"github.com/tos-network/gtos/reputation"
+	"github.com/tos-network/gtos/settlement"
+	"github.com/tos-network/gtos/sysaction"
+)
+
+func newTestState() *state.StateDB {
+	db := state.NewDatabase(rawdb.NewMemoryDatabase())
+	s, _ := state.New(common.Hash{}, db, nil)
+	return s
+}
+
+func newCtx(st *state.StateDB, from common.Address, value *big.Int, block uint64) *sysaction.Context {
+	return &sysaction.Context{
+		From:        from,
+		Value:       value,
+		BlockNumber: new(big.Int).SetUint64(block),
+		StateDB:     st,
+		ChainConfig: &params.ChainConfig{},
+	}
+}
+
+func tAddr(b byte) common.Address { return common.Address{b} }
+
+func tos(n int64) *big.Int { return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e18)) }
+
+var h = &jobHandler{}
+
+var (
+	client = tAddr(0x01)
+	worker = tAddr(0x02)
+	spec   = common.Hash{0x5e}
+)
+
+func do(t *testing.T, st *state.StateDB, from common.Address, value *big.Int, block uint64, action sysaction.ActionKind, payload interface{}) error {
+	t.Helper()
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h.Handle(newCtx(st, from, value, block), &sysaction.SysAction{Action: action, Payload: raw})
+}
+
+// setup funds both parties, registers the worker as an active agent and
+// posts a job with a 100 TOS budget and a 10 TOS bond.
+func setup(t *testing.T) (*state.StateDB, common.Hash) {
+	t.Helper()
+	st := newTestState()
+	st.AddBalance(client, tos(1000))
+	st.AddBalance(worker, new(big.Int).Add(tos(1000), params.AgentMinStake))
+	register, _ := sysaction.MakeSysAction(sysaction.ActionAgentRegister, nil)
+	if err := sysaction.ExecuteWithContext(newCtx(st, worker, params.AgentMinStake, 1), register); err != nil {
+		t.Fatalf("agent register: %v", err)
+	}
+
+	err := do(t, st, client, tos(100), 10, sysaction.ActionJobPost, postPayload{
+		SpecHash:       spec.Hex(),
+		Bond:           tos(10).String(),
+		DeadlineBlocks: 50,
+		ReviewBlocks:   params.JobMinReviewBlocks,
+	})
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	return st, NewJobID(client, 0)
+}
+
+func accept(t *testing.T, st *state.StateDB, id common.Hash) {
+	t.Helper()
+	if err := do(t, st, worker, tos(10), 20, sysaction.ActionJobAccept, jobPayload{JobID: id.Hex()}); err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+}
+
+func submit(t *testing.T, st *state.StateDB, id common.Hash) {
+	t.Helper()
+	if err := do(t, st, worker, nil, 30, sysaction.ActionJobSubmit, submitPayload{JobID: id.Hex(), Deliverable: common.Hash{0xde}.Hex()}); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+}
+
+func TestJobApprove(t *testing.T) {
+	st, id := setup(t)
+	if st.GetBalance(params.JobMarketAddress).Cmp(tos(100)) != 0 {
+		t.Fatalf("escrow after post: got %v", st.GetBalance(params.JobMarketAddress))
+	}
+	accept(t, st, id)
+	receipt, err := settlement.ReadRuntimeReceipt(st, ReceiptRef(id))
+	if err != nil {
+		t.Fatalf("receipt not opened: %v", err)
+	}
+	if receipt.Status != settlement.ReceiptStatusOpen || receipt.ReceiptKind != ReceiptKindJob ||
+		receipt.Sender != client || receipt.Recipient != worker {
+		t.Fatalf("unexpected open receipt: %+v", receipt)
+	}
+	submit(t, st, id)
+	if err := do(t, st, client, nil, 40, sysaction.ActionJobApprove, jobPayload{JobID: id.Hex()}); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+
+	j, _ := ReadJob(st, id)
+	if j.Status != JobCompleted {
+		t.Fatalf("status: want Completed, got %d", j.Status)
+	}
+	if st.GetBalance(worker).Cmp(tos(1100)) != 0 {
+		t.Errorf("worker balance: want 1100 TOS, got %v", st.GetBalance(worker))
+	}
+	if st.GetBalance(params.JobMarketAddress).Sign() != 0 {
+		t.Errorf("escrow not drained: %v", st.GetBalance(params.JobMarketAddress))
+	}
+	receipt, _ = settlement.ReadRuntimeReceipt(st, ReceiptRef(id))
+	if receipt.Status != settlement.ReceiptStatusSuccess || receipt.ArtifactRef != (common.Hash{0xde}) {
+		t.Errorf("unexpected final receipt: %+v", receipt)
+	}
+	effect, err := settlement.ReadSettlementEffect(st, receipt.SettlementRef)
+	if err != nil || effect.Recipient != worker || effect.ReceiptRef != ReceiptRef(id) {
+		t.Errorf("unexpected settlement effect: %+v, %v", effect, err)
+	}
+	if reputation.TotalScoreOf(st, worker).Cmp(ReputationDeltaCompleted) != 0 {
+		t.Errorf("worker score: got %v", reputation.TotalScoreOf(st, worker))
+	}
+}
+
+func TestJobReject(t *testing.T) {
+	st, id := setup(t)
+	accept(t, st, id)
+	submit(t, st, id)
+	if err := do(t, st, worker, nil, 40, sysaction.ActionJobReject, rejectPayload{JobID: id.Hex()}); err != ErrJobNotClient {
+		t.Fatalf("worker reject: want ErrJobNotClient, got %v", err)
+	}
+	reason := common.Hash{0xba, 0xd0}
+	if err := do(t, st, client, nil, 40, sysaction.ActionJobReject, rejectPayload{JobID: id.Hex(), Reason: reason.Hex()}); err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+	// Funds stay escrowed while the rejection can still be contested.
+	if st.GetBalance(params.JobMarketAddress).Cmp(tos(110)) != 0 {
+		t.Fatalf("escrow during contest window: got %v", st.GetBalance(params.JobMarketAddress))
+	}
+	contestEnd := 40 + params.JobContestBlocks
+	if err := do(t, st, client, nil, contestEnd, sysaction.ActionJobTimeout, jobPayload{JobID: id.Hex()}); err != ErrJobNotTimedOut {
+		t.Fatalf("early timeout: want ErrJobNotTimedOut, got %v", err)
+	}
+	if err := do(t, st, client, nil, contestEnd+1, sysaction.ActionJobTimeout, jobPayload{JobID: id.Hex()}); err != nil {
+		t.Fatalf("timeout: %v", err)
+	}
+	if j, _ := ReadJob(st, id); j.Status != JobRefunded {
+		t.Errorf("status: want Refunded, got %d", j.Status)
+	}
+	if st.GetBalance(client).Cmp(tos(1000)) != 0 || st.GetBalance(worker).Cmp(tos(1000)) != 0 {
+		t.Errorf("funds not returned: client %v worker %v", st.GetBalance(client), st.GetBalance(worker))
+	}
+	receipt, _ := settlement.ReadRuntimeReceipt(st, ReceiptRef(id))
+	if receipt.Status != settlement.ReceiptStatusFailure || receipt.FailureRef != reason {
+		t.Errorf("unexpected final receipt: %+v", receipt)
+	}
+	if reputation.TotalScoreOf(st, worker).Cmp(ReputationDeltaRejected) != 0 {
+		t.Errorf("worker score: got %v", reputation.TotalScoreOf(st, worker))
+	}
+}
+
+func TestJobAcceptChecks(t *testing.T) {
+	st, id := setup(t)
+	if err := do(t, st, client, tos(10), 20, sysaction.ActionJobAccept, jobPayload{JobID: id.Hex()}); err != ErrJobSelfAccept {
+		t.Errorf("self accept: want ErrJobSelfAccept, got %v", err)
+	}
+	stranger := tAddr(0x03)
+	st.AddBalance(stranger, tos(100))
+	if err := do(t, st, stranger, tos(10), 20, sysaction.ActionJobAccept, jobPayload{JobID: id.Hex()}); err != ErrJobWorkerNotAgent {
+		t.Errorf("unregistered worker: want ErrJobWorkerNotAgent, got %v", err)
+	}
+	if err := do(t, st, worker, tos(5), 20, sysaction.ActionJobAccept, jobPayload{JobID: id.Hex()}); err != ErrJobBondMismatch {
+		t.Errorf("short bond: want ErrJobBondMismatch, got %v", err)
+	}
+	if err := do(t, st, worker, tos(10), 61, sysaction.ActionJobAccept, jobPayload{JobID: id.Hex()}); err != ErrJobDeadlinePassed {
+		t.Errorf("late accept: want ErrJobDeadlinePassed, got %v", err)
+	}
+	accept(t, st, id)
+	if err := do(t, st, worker, tos(10), 20, sysaction.ActionJobAccept, jobPayload{JobID: id.Hex()}); err != ErrJobNotOpen {
+		t.Errorf("double accept: want ErrJobNotOpen, got %v", err)
+	}
+	if err := do(t, st, client, nil, 20, sysaction.ActionJobCancel, jobPayload{JobID: id.Hex()}); err != ErrJobNotOpen {
+		t.Errorf("cancel accepted job: want ErrJobNotOpen, got %v", err)
+	}
+}
+
+func TestJobTimeoutDefault(t *testing.T) {
+	st, id := setup(t)
+	accept(t, st, id)
+	// Deadline is block 60; anyone may trigger the timeout afterwards.
+	if err := do(t, st, tAddr(0x09), nil, 60, sysaction.ActionJobTimeout, jobPayload{JobID: id.Hex()}); err != ErrJobNotTimedOut {
+		t.Fatalf("early timeout: want ErrJobNotTimedOut, got %v", err)
+	}
+	if err := do(t, st, tAddr(0x09), nil, 61, sysaction.ActionJobTimeout, jobPayload{JobID: id.Hex()}); err != nil {
+		t.Fatalf("timeout: %v", err)
+	}
+	j, _ := ReadJob(st, id)
+	if j.Status != JobDefaulted {
+		t.Fatalf("status: want Defaulted, got %d", j.Status)
+	}
+	// The client recovers the budget and the worker's bond.
+	if st.GetBalance(client).Cmp(tos(1010)) != 0 {
+		t.Errorf("client balance: want 1010 TOS, got %v", st.GetBalance(client))
+	}
+	receipt, _ := settlement.ReadRuntimeReceipt(st, ReceiptRef(id))
+	if receipt.Status != settlement.ReceiptStatusFailure {
+		t.Errorf("receipt status: want failure, got %d", receipt.Status)
+	}
+	if reputation.TotalScoreOf(st, worker).Cmp(ReputationDeltaDefaulted) != 0 {
+		t.Errorf("worker score: got %v", reputation.TotalScoreOf(st, worker))
+	}
+}
+
+func TestJobTimeoutReviewAutoApproves(t *testing.T) {
+	st, id := setup(t)
+	accept(t, st, id)
+	submit(t, st, id)
+	reviewEnd := 30 + params.JobMinReviewBlocks
+	if err := do(t, st, worker, nil, reviewEnd, sysaction.ActionJobTimeout, jobPayload{JobID: id.Hex()}); err != ErrJobNotTimedOut {
+		t.Fatalf("early timeout: want ErrJobNotTimedOut, got %v", err)
+	}
+	if err := do(t, st, client, nil, reviewEnd+1, sysaction.ActionJobReject, rejectPayload{JobID: id.Hex()}); err != ErrJobDeadlinePassed {
+		t.Fatalf("late reject: want ErrJobDeadlinePassed, got %v", err)
+	}
+	if err := do(t, st, worker, nil, reviewEnd+1, sysaction.ActionJobTimeout, jobPayload{JobID: id.Hex()}); err != nil {
+		t.Fatalf("timeout: %v", err)
+	}
+	j, _ := ReadJob(st, id)
+	if j.Status != JobCompleted || st.GetBalance(worker).Cmp(tos(1100)) != 0 {
+		t.Errorf("want completed and paid, got status %d balance %v", j.Status, st.GetBalance(worker))
+	}
+}
+
+func TestJobCancelAndExpire(t *testing.T) {
+	st, id := setup(t)
+	if err := do(t, st, worker, nil, 20, sysaction.ActionJobCancel, jobPayload{JobID: id.Hex()}); err != ErrJobNotClient {
+		t.Fatalf("foreign cancel: want ErrJobNotClient, got %v", err)
+	}
+	if err := do(t, st, client, nil, 20, sysaction.ActionJobCancel, jobPayload{JobID: id.Hex()}); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if st.GetBalance(client).Cmp(tos(1000)) != 0 {
+		t.Errorf("budget not refunded: %v", st.GetBalance(client))
+	}
+
+	// A second job nobody accepts expires after its deadline.
+	if err := do(t, st, client, tos(50), 100, sysaction.ActionJobPost, postPayload{
+		SpecHash: spec.Hex(), DeadlineBlocks: 5, ReviewBlocks: params.JobMinReviewBlocks,
+	}); err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	id2 := NewJobID(client, 1)
+	if err := do(t, st, worker, nil, 106, sysaction.ActionJobTimeout, jobPayload{JobID: id2.Hex()}); err != nil {
+		t.Fatalf("timeout: %v", err)
+	}
+	if j, _ := ReadJob(st, id2); j.Status != JobExpired {
+		t.Errorf("status: want Expired, got %d", j.Status)
+	}
+	if st.GetBalance(client).Cmp(tos(1000)) != 0 {
+		t.Errorf("budget not refunded: %v", st.GetBalance(client))
+	}
+	if _, err := settlement.ReadRuntimeReceipt(st, ReceiptRef(id2)); err != settlement.ErrReceiptNotFound {
+		t.Errorf("unaccepted job must not open a receipt, got %v", err)
+	}
+}
+
+func TestJobPostValidation(t *testing.T) {
+	st := newTestState()
+	st.AddBalance(client, tos(10))
+	cases := []struct {
+		value *big.Int
+		p     postPayload
+		want  error
+	}{
+		{nil, postPayload{SpecHash: spec.Hex(), DeadlineBlocks: 1, ReviewBlocks: params.JobMinReviewBlocks}, ErrJobBudgetZero},
+		{tos(20), postPayload{SpecHash: spec.Hex(), DeadlineBlocks: 1, ReviewBlocks: params.JobMinReviewBlocks}, ErrJobInsufficientFunds},
+		{tos(1), postPayload{DeadlineBlocks: 1, ReviewBlocks: params.JobMinReviewBlocks}, ErrJobInvalidSpec},
+		{tos(1), postPayload{SpecHash: spec.Hex(), Bond: "-1", DeadlineBlocks: 1, ReviewBlocks: params.JobMinReviewBlocks}, ErrJobInvalidBond},
+		{tos(1), postPayload{SpecHash: spec.Hex(), ReviewBlocks: params.JobMinReviewBlocks}, ErrJobDeadlineZero},
+		{tos(1), postPayload{SpecHash: spec.Hex(), DeadlineBlocks: params.JobMaxHorizonBlocks + 1, ReviewBlocks: params.JobMinReviewBlocks}, ErrJobDeadlineTooFar},
+		{tos(1), postPayload{SpecHash: spec.Hex(), DeadlineBlocks: 1, ReviewBlocks: 1}, ErrJobReviewTooShort},
+	}
+	for i, c := range cases {
+		if err := do(t, st, client, c.value, 1, sysaction.ActionJobPost, c.p); err != c.want {
+			t.Errorf("case %d: want %v, got %v", i, c.want, err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package job
+
+import (
+	"encoding/binary"
+
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/crypto"
+	"github.com/tos-network/gtos/params"
+)
+
+// stateDB is the minimal storage interface required by this package.
+type stateDB interface {
+	GetState(common.Address, common.Hash) common.Hash
+	SetState(common.Address, common.Hash, common.Hash)
+}
+
+// ── Slot helpers ──────────────────────────────────────────────────────────────
+
+// jobFieldSlot returns the storage slot for a single field of a Job.
+// key = keccak256("job\x00" || jobId[32] || field)
+func jobFieldSlot(jobId common.Hash, field string) common.Hash {
+	key := append([]byte("job\x00"), jobId.Bytes()...)
+	key = append(key, []byte(field)...)
+	return common.BytesToHash(crypto.Keccak256(key))
+}
+
+// receiptJobSlot returns the slot mapping a job receipt back to its job.
+func receiptJobSlot(receiptRef common.Hash) common.Hash {
+	return common.BytesToHash(crypto.Keccak256(
+		append([]byte("job\x00byreceipt\x00"), receiptRef.Bytes()...)))
+}
+
+// clientNonceSlot returns the slot for the per-client post nonce.
+func clientNonceSlot(addr common.Address) common.Hash {
+	return common.BytesToHash(crypto.Keccak256(
+		append([]byte("job\x00nonce\x00"), addr.Bytes()...)))
+}
+
+// ── IDs ───────────────────────────────────────────────────────────────────────
+
+// NewJobID derives a deterministic job ID from the client address and a
+// per-client monotonic nonce.
+// Formula: keccak256(client[32] || nonce[8])
+func NewJobID(client common.Address, nonce uint64) common.Hash {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], nonce)
+	return common.BytesToHash(crypto.Keccak256(client.Bytes(), buf[:]))
+}
+
+// ReceiptRef returns the runtime receipt reference opened for jobId.
+func ReceiptRef(jobId common.Hash) common.Hash {
+	return common.BytesToHash(crypto.Keccak256([]byte("job\x00receipt\x00"), jobId.Bytes()))
+}
+
+// SettlementRef returns the settlement effect reference recorded when jobId
+// is paid out to its worker.
+func SettlementRef(jobId common.Hash) common.Hash {
+	return common.BytesToHash(crypto.Keccak256([]byte("job\x00settlement\x00"), jobId.Bytes()))
+}
+
+// ── Read / Write ──────────────────────────────────────────────────────────────
+
+// ReadJob reads a Job from state. Returns (job, true) if found, (nil, false)
+// if the job ID has never been written.
+func ReadJob(db stateDB, jobId common.Hash) (*Job, bool) {
+	load := func(field string) common.Hash {
+		return db.GetState(params.JobMarketAddress, jobFieldSlot(jobId, field))
+	}
+	status := JobStatus(load("status")[31])
+	if status == JobNone {
+		return nil, false
+	}
+	return &Job{
+		Client:       common.BytesToAddress(load("client").Bytes()),
+		Worker:       common.BytesToAddress(load("worker").Bytes()),
+		Budget:       load("budget").Big(),
+		Bond:         load("bond").Big(),
+		SpecHash:     load("spec"),
+		Deliverable:  load("deliverable"),
+		Deadline:     load("deadline").Big().Uint64(),
+		ReviewBlocks: load("review").Big().Uint64(),
+		SubmittedAt:  load("submitted").Big().Uint64(),
+		RejectedAt:   load("rejected").Big().Uint64(),
+		Reason:       load("reason"),
+		Status:       status,
+	}, true
+}
+
+// WriteJob persists a Job to state, one field per slot.
+func WriteJob(db stateDB, jobId common.Hash, j *Job) {
+	store := func(field string, val common.Hash) {
+		db.SetState(params.JobMarketAddress, jobFieldSlot(jobId, field), val)
+	}
+	u64 := func(v uint64) common.Hash {
+		var h common.Hash
+		binary.BigEndian.PutUint64(h[24:], v)
+		return h
+	}
+	store("client", common.BytesToHash(j.Client.Bytes()))
+	store("worker", common.BytesToHash(j.Worker.Bytes()))
+	store("budget", common.BigToHash(j.Budget))
+	store("bond", common.BigToHash(j.Bond))
+	store("spec", j.SpecHash)
+	store("deliverable", j.Deliverable)
+	store("deadline", u64(j.Deadline))
+	store("review", u64(j.ReviewBlocks))
+	store("submitted", u64(j.SubmittedAt))
+	store("rejected", u64(j.RejectedAt))
+	store("reason", j.Reason)
+	store("status", u64(uint64(j.Status)))
+}
+
+// IncrementClientNonce bumps the per-client nonce and returns the value
+// BEFORE the increment (used as the nonce component of NewJobID).
+func IncrementClientNonce(db stateDB, addr common.Address) uint64 {
+	slot := clientNonceSlot(addr)
+	n := db.GetState(params.JobMarketAddress, slot).Big().Uint64()
+	var h common.Hash
+	binary.BigEndian.PutUint64(h[24:], n+1)
+	db.SetState(params.JobMarketAddress, slot, h)
+	return n
+}
+
+// JobByReceipt returns the job whose runtime receipt is receiptRef.
+func JobByReceipt(db stateDB, receiptRef common.Hash) (common.Hash, bool) {
+	jobId := db.GetState(params.JobMarketAddress, receiptJobSlot(receiptRef))
+	return jobId, jobId != (common.Hash{})
+}
+
+func writeReceiptJob(db stateDB, receiptRef, jobId common.Hash) {
+	db.SetState(params.JobMarketAddress, receiptJobSlot(receiptRef), jobId)
+}
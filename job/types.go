@@ -0,0 +1,86 @@
+// Package job implements the native agent-to-agent job marketplace.
+//
+// A client posts a job with an escrowed budget, a registered agent accepts it
+// by bonding stake, submits a deliverable hash, and the client approves or
+// rejects the work. Missed deadlines are settled by JOB_TIMEOUT, which anyone
+// may send. Accepted jobs open a runtime receipt on the settlement bus and
+// every outcome is recorded in the reputation hub.
+package job
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/tos-network/gtos/common"
+)
+
+// JobStatus is the lifecycle state of a job.
+type JobStatus uint8
+
+const (
+	JobNone      JobStatus = 0  // never posted
+	JobOpen      JobStatus = 1  // posted, waiting for a worker
+	JobAccepted  JobStatus = 2  // worker bonded, deliverable pending
+	JobSubmitted JobStatus = 3  // deliverable submitted, client review pending
+	JobCompleted JobStatus = 4  // approved (explicitly or by review timeout); worker paid
+	JobRejected  JobStatus = 5  // client rejected the deliverable; escrow held during the contest window
+	JobCancelled JobStatus = 6  // client withdrew the job before acceptance
+	JobExpired   JobStatus = 7  // nobody accepted before the deadline
+	JobDefaulted JobStatus = 8  // worker missed the deadline or lost a dispute; bond slashed to client
+	JobDisputed  JobStatus = 9  // frozen pending a dispute
+	JobRefunded  JobStatus = 10 // rejection stood or dispute undecided; budget and bond returned
+)
+
+// ReceiptKindJob is the runtime receipt kind of receipts opened by JOB_ACCEPT.
+const ReceiptKindJob uint16 = 0x0111
+
+// Reputation deltas recorded for the worker when a job is finalized.
+var (
+	ReputationDeltaCompleted = big.NewInt(1)
+	ReputationDeltaRejected  = big.NewInt(-1)
+	ReputationDeltaDefaulted = big.NewInt(-2)
+)
+
+// Job holds all on-chain state for a single job.
+type Job struct {
+	Client       common.Address // address that posted and funded the job
+	Worker       common.Address // designated or accepting worker; zero while open to anyone
+	Budget       *big.Int       // escrowed payment, released to the worker on approval
+	Bond         *big.Int       // stake the worker must lock to accept
+	SpecHash     common.Hash    // hash of the off-chain job specification
+	Deliverable  common.Hash    // hash of the submitted deliverable
+	Deadline     uint64         // last block at which the deliverable may be submitted
+	ReviewBlocks uint64         // client review window after submission
+	SubmittedAt  uint64         // block of submission
+	RejectedAt   uint64         // block of rejection
+	Reason       common.Hash    // client's rejection reason reference
+	Status       JobStatus
+}
+
+// Sentinel errors returned by job system action handlers.
+var (
+	ErrJobBudgetZero         = errors.New("job: budget must be > 0")
+	ErrJobInvalidBond        = errors.New("job: invalid bond")
+	ErrJobInvalidSpec        = errors.New("job: spec_hash must not be zero")
+	ErrJobDeadlineZero       = errors.New("job: deadline_blocks must be >= 1")
+	ErrJobDeadlineTooFar     = errors.New("job: deadline_blocks exceeds horizon")
+	ErrJobReviewTooShort     = errors.New("job: review_blocks below minimum")
+	ErrJobReviewTooLong      = errors.New("job: review_blocks exceeds horizon")
+	ErrJobInsufficientFunds  = errors.New("job: sender balance below value")
+	ErrJobNotFound           = errors.New("job: job not found")
+	ErrJobNotOpen            = errors.New("job: job is not open")
+	ErrJobNotAccepted        = errors.New("job: job is not accepted")
+	ErrJobNotSubmitted       = errors.New("job: job is not submitted")
+	ErrJobNotClient          = errors.New("job: caller is not the job client")
+	ErrJobNotWorker          = errors.New("job: caller is not the job worker")
+	ErrJobNotParty           = errors.New("job: caller is neither client nor worker")
+	ErrJobSelfAccept         = errors.New("job: client cannot accept its own job")
+	ErrJobWorkerNotAgent     = errors.New("job: worker is not an active registered agent")
+	ErrJobBondMismatch       = errors.New("job: value must equal the required bond")
+	ErrJobDeadlinePassed     = errors.New("job: deadline has passed")
+	ErrJobInvalidDeliverable = errors.New("job: deliverable must not be zero")
+	ErrJobNotTimedOut        = errors.New("job: job has not timed out")
+	ErrJobEscrowBroken       = errors.New("job: escrow balance invariant violated")
+	ErrJobNotDisputable      = errors.New("job: job cannot be disputed in its current state")
+	ErrJobNotDisputed        = errors.New("job: job is not disputed")
+)
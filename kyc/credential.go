@@ -0,0 +1,169 @@
+package kyc
+
+import (
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/crypto"
+	cryptopriv "github.com/tos-network/gtos/crypto/priv"
+)
+
+// MaxTier is the highest KYC tier (KycLevelRegulated).
+const MaxTier uint8 = 8
+
+// rangeBits is the bit length of the tier range proof.
+const rangeBits = 64
+
+// TierProofSize is the encoded size of a TierProof:
+// issuer[32] || commitment[32] || s[32] || e[32] || rangeProof[672].
+const TierProofSize = 32 + 32 + 32 + 32 + 672
+
+// Credential is a committee-issued KYC credential. It is held off-chain by
+// the holder; only the commitment to the tier ever appears on chain.
+type Credential struct {
+	Holder     common.Address
+	Issuer     common.Address // committee member that signed the credential
+	Tier       uint8
+	Commitment [32]byte // Pedersen commitment tier·G + opening·H
+	Opening    [32]byte // secret blinding factor; never published
+	S, E       [32]byte // issuer's Schnorr signature over CredentialMessage
+}
+
+// TierProof shows that the holder of a credential has at least some tier,
+// without revealing the tier. A proof is valid only for the minimum tier it
+// was built for.
+type TierProof struct {
+	Issuer     common.Address
+	Commitment [32]byte
+	S, E       [32]byte
+	RangeProof []byte // Bulletproof that the commitment minus minTier·G is in [0, 2^64)
+}
+
+// CredentialMessage returns the message an issuer signs to bind commitment
+// to holder.
+func CredentialMessage(holder common.Address, commitment [32]byte) []byte {
+	buf := append([]byte("kyc\x00credential\x00"), holder.Bytes()...)
+	buf = append(buf, commitment[:]...)
+	return crypto.Keccak256(buf)
+}
+
+// IssuerMessage returns the message a committee member signs with its issuer
+// key to prove possession when registering it.
+func IssuerMessage(member common.Address) []byte {
+	return crypto.Keccak256(append([]byte("kyc\x00issuer\x00"), member.Bytes()...))
+}
+
+// CredentialID returns the identifier under which a credential is revoked.
+func CredentialID(holder, issuer common.Address, commitment [32]byte) common.Hash {
+	buf := append([]byte("kyc\x00credid\x00"), holder.Bytes()...)
+	buf = append(buf, issuer.Bytes()...)
+	buf = append(buf, commitment[:]...)
+	return crypto.Keccak256Hash(buf)
+}
+
+// IssueCredential creates a credential for holder at tier, signed with the
+// committee member's issuer private key. It runs off-chain at the issuer;
+// the credential, including the opening, is then handed to the holder.
+func IssueCredential(issuerPriv [32]byte, issuer, holder common.Address, tier uint8) (*Credential, error) {
+	if tier > MaxTier {
+		return nil, ErrKYCInvalidTier
+	}
+	commitment, opening, err := cryptopriv.CommitmentNew(uint64(tier))
+	if err != nil {
+		return nil, err
+	}
+	c := &Credential{Holder: holder, Issuer: issuer, Tier: tier}
+	copy(c.Commitment[:], commitment)
+	copy(c.Opening[:], opening)
+	c.S, c.E, err = cryptopriv.SignSchnorr(issuerPriv, CredentialMessage(holder, c.Commitment))
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// shiftCommitment returns commitment − minTier·G, which commits to
+// tier − minTier under the same opening.
+func shiftCommitment(commitment [32]byte, minTier uint8) ([]byte, error) {
+	ct := make([]byte, 64) // zero handle: the identity point
+	copy(ct, commitment[:])
+	shifted, err := cryptopriv.SubAmountCompressed(ct, uint64(minTier))
+	if err != nil {
+		return nil, err
+	}
+	return shifted[:32], nil
+}
+
+// ProveTier builds a proof that the credential's tier is at least minTier.
+func (c *Credential) ProveTier(minTier uint8) (*TierProof, error) {
+	if minTier == 0 || minTier > MaxTier || c.Tier < minTier {
+		return nil, ErrKYCInvalidTier
+	}
+	shifted, err := shiftCommitment(c.Commitment, minTier)
+	if err != nil {
+		return nil, err
+	}
+	rp, err := cryptopriv.ProveRangeProof(shifted, uint64(c.Tier-minTier), c.Opening[:])
+	if err != nil {
+		return nil, err
+	}
+	return &TierProof{
+		Issuer:     c.Issuer,
+		Commitment: c.Commitment,
+		S:          c.S,
+		E:          c.E,
+		RangeProof: rp,
+	}, nil
+}
+
+// Encode returns the fixed-size binary form of p.
+func (p *TierProof) Encode() []byte {
+	out := make([]byte, 0, TierProofSize)
+	out = append(out, p.Issuer.Bytes()...)
+	out = append(out, p.Commitment[:]...)
+	out = append(out, p.S[:]...)
+	out = append(out, p.E[:]...)
+	return append(out, p.RangeProof...)
+}
+
+// DecodeTierProof parses the output of TierProof.Encode.
+func DecodeTierProof(b []byte) (*TierProof, error) {
+	if len(b) != TierProofSize {
+		return nil, ErrKYCInvalidProof
+	}
+	p := &TierProof{Issuer: common.BytesToAddress(b[:32])}
+	copy(p.Commitment[:], b[32:64])
+	copy(p.S[:], b[64:96])
+	copy(p.E[:], b[96:128])
+	p.RangeProof = common.CopyBytes(b[128:])
+	return p, nil
+}
+
+// VerifyTierProof checks that proof shows holder has a credential of at
+// least minTier, issued by a committee member that is still active, that has
+// not been revoked, and that holder is not suspended. It returns the
+// credential ID.
+func VerifyTierProof(db stateDB, holder common.Address, minTier uint8, proof *TierProof) (common.Hash, error) {
+	if minTier == 0 || minTier > MaxTier {
+		return common.Hash{}, ErrKYCInvalidTier
+	}
+	if isSuspended(db, holder) {
+		return common.Hash{}, ErrKYCSuspended
+	}
+	if !isActiveIssuer(db, proof.Issuer) {
+		return common.Hash{}, ErrKYCUnknownIssuer
+	}
+	id := CredentialID(holder, proof.Issuer, proof.Commitment)
+	if IsCredentialRevoked(db, id) {
+		return common.Hash{}, ErrKYCCredentialRevoked
+	}
+	if !cryptopriv.VerifySchnorr(ReadIssuerKey(db, proof.Issuer), CredentialMessage(holder, proof.Commitment), proof.S, proof.E) {
+		return common.Hash{}, ErrKYCInvalidProof
+	}
+	shifted, err := shiftCommitment(proof.Commitment, minTier)
+	if err != nil {
+		return common.Hash{}, ErrKYCInvalidProof
+	}
+	if err := cryptopriv.VerifyRangeProof(proof.RangeProof, shifted, []byte{rangeBits}, 1); err != nil {
+		return common.Hash{}, ErrKYCInvalidProof
+	}
+	return id, nil
+}
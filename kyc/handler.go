@@ -2,9 +2,13 @@ package kyc
 
 import (
 	"encoding/json"
+	"strings"
 
 	"github.com/tos-network/gtos/capability"
 	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/common/hexutil"
+	cryptopriv "github.com/tos-network/gtos/crypto/priv"
+	"github.com/tos-network/gtos/crypto/ristretto255"
 	"github.com/tos-network/gtos/params"
 	"github.com/tos-network/gtos/sysaction"
 )
@@ -16,7 +20,13 @@ func init() {
 type kycHandler struct{}
 
 func (h *kycHandler) Actions() []sysaction.ActionKind {
-	return []sysaction.ActionKind{sysaction.ActionKYCSet, sysaction.ActionKYCSuspend}
+	return []sysaction.ActionKind{
+		sysaction.ActionKYCSet,
+		sysaction.ActionKYCSuspend,
+		sysaction.ActionKYCIssuerRegister,
+		sysaction.ActionKYCCredentialRevoke,
+		sysaction.ActionKYCProveTier,
+	}
 }
 
 func (h *kycHandler) Handle(ctx *sysaction.Context, sa *sysaction.SysAction) error {
@@ -25,13 +35,40 @@ func (h *kycHandler) Handle(ctx *sysaction.Context, sa *sysaction.SysAction) err
 		return h.handleSet(ctx, sa)
 	case sysaction.ActionKYCSuspend:
 		return h.handleSuspend(ctx, sa)
+	case sysaction.ActionKYCIssuerRegister:
+		return h.handleIssuerRegister(ctx, sa)
+	case sysaction.ActionKYCCredentialRevoke:
+		return h.handleCredentialRevoke(ctx, sa)
+	case sysaction.ActionKYCProveTier:
+		return h.handleProveTier(ctx, sa)
 	}
 	return nil
 }
 
 type setPayload struct {
-	Target string `json:"target"` // hex address
-	Level  uint16 `json:"level"`
+	Target        string   `json:"target"` // hex address
+	Level         uint16   `json:"level"`
+	ValidBlocks   uint64   `json:"valid_blocks,omitempty"`  // defaults to params.KYCDefaultValidityBlocks
+	Jurisdictions []string `json:"jurisdictions,omitempty"` // ISO 3166-1 alpha-2 codes
+}
+
+// normalizeJurisdictions upper-cases and validates a jurisdiction code set:
+// at most params.KYCMaxJurisdictions distinct two-letter codes.
+func normalizeJurisdictions(codes []string) ([]string, error) {
+	if len(codes) > params.KYCMaxJurisdictions {
+		return nil, ErrKYCInvalidJurisdiction
+	}
+	out := make([]string, 0, len(codes))
+	seen := make(map[string]bool, len(codes))
+	for _, c := range codes {
+		c = strings.ToUpper(c)
+		if len(c) != 2 || c[0] < 'A' || c[0] > 'Z' || c[1] < 'A' || c[1] > 'Z' || seen[c] {
+			return nil, ErrKYCInvalidJurisdiction
+		}
+		seen[c] = true
+		out = append(out, c)
+	}
+	return out, nil
 }
 
 func (h *kycHandler) handleSet(ctx *sysaction.Context, sa *sysaction.SysAction) error {
@@ -45,8 +82,22 @@ func (h *kycHandler) handleSet(ctx *sysaction.Context, sa *sysaction.SysAction)
 	if !IsValidLevel(p.Level) {
 		return ErrKYCInvalidLevel
 	}
+	valid := p.ValidBlocks
+	if valid == 0 {
+		valid = params.KYCDefaultValidityBlocks
+	}
+	if valid > params.KYCMaxValidityBlocks {
+		return ErrKYCInvalidValidity
+	}
+	codes, err := normalizeJurisdictions(p.Jurisdictions)
+	if err != nil {
+		return err
+	}
+	// Setting a record again is how the committee re-verifies an account:
+	// the level, expiry, issuer and jurisdictions are all replaced.
 	target := common.HexToAddress(p.Target)
 	WriteKYC(ctx.StateDB, target, p.Level, KycActive)
+	writeRecordMeta(ctx.StateDB, target, ctx.BlockNumber.Uint64()+valid, ctx.From, codes)
 	return nil
 }
 
@@ -70,3 +121,77 @@ func (h *kycHandler) handleSuspend(ctx *sysaction.Context, sa *sysaction.SysActi
 	writePacked(ctx.StateDB, target, level, KycSuspended)
 	return nil
 }
+
+type issuerRegisterPayload struct {
+	Pubkey common.Hash `json:"pubkey"` // ElGamal public key
+	S      common.Hash `json:"s"`      // Schnorr signature over IssuerMessage(sender)
+	E      common.Hash `json:"e"`
+}
+
+// handleIssuerRegister lets a committee member register or rotate the key
+// it signs private credentials with. Rotating invalidates every credential
+// signed with the previous key.
+func (h *kycHandler) handleIssuerRegister(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	if !capability.HasCapability(ctx.StateDB, ctx.From, params.KYCCommitteeBit) {
+		return ErrKYCNotCommittee
+	}
+	var p issuerRegisterPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	if p.Pubkey == (common.Hash{}) {
+		return ErrKYCInvalidIssuerKey
+	}
+	if _, err := new(ristretto255.Element).SetCanonicalBytes(p.Pubkey[:]); err != nil {
+		return ErrKYCInvalidIssuerKey
+	}
+	if !cryptopriv.VerifySchnorr(p.Pubkey, IssuerMessage(ctx.From), p.S, p.E) {
+		return ErrKYCInvalidIssuerKey
+	}
+	writeIssuerKey(ctx.StateDB, ctx.From, p.Pubkey)
+	return nil
+}
+
+type credentialRevokePayload struct {
+	CredentialID common.Hash `json:"credential_id"`
+}
+
+// handleCredentialRevoke revokes a private credential. Like KYC_SUSPEND it
+// may be sent by any committee member.
+func (h *kycHandler) handleCredentialRevoke(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	if !capability.HasCapability(ctx.StateDB, ctx.From, params.KYCCommitteeBit) {
+		return ErrKYCNotCommittee
+	}
+	var p credentialRevokePayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	revokeCredential(ctx.StateDB, p.CredentialID)
+	return nil
+}
+
+type proveTierPayload struct {
+	MinTier uint8         `json:"min_tier"`
+	Proof   hexutil.Bytes `json:"proof"` // TierProof.Encode()
+}
+
+// handleProveTier verifies a tier proof for the sender and records that it
+// holds at least min_tier for params.KYCTierProofValidityBlocks. Only the
+// proven lower bound is stored, never the tier itself.
+func (h *kycHandler) handleProveTier(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p proveTierPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	proof, err := DecodeTierProof(p.Proof)
+	if err != nil {
+		return err
+	}
+	id, err := VerifyTierProof(ctx.StateDB, ctx.From, p.MinTier, proof)
+	if err != nil {
+		return err
+	}
+	now := ctx.BlockNumber.Uint64()
+	writeProvenTier(ctx.StateDB, ctx.From, proof.Issuer, id, p.MinTier, now+params.KYCTierProofValidityBlocks)
+	return nil
+}
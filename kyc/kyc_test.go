@@ -7,8 +7,10 @@ import (
 
 	"github.com/tos-network/gtos/capability"
 	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/common/hexutil"
 	"github.com/tos-network/gtos/core/rawdb"
 	"github.com/tos-network/gtos/core/state"
+	cryptopriv "github.com/tos-network/gtos/crypto/priv"
 	"github.com/tos-network/gtos/params"
 	"github.com/tos-network/gtos/sysaction"
 )
@@ -109,13 +111,13 @@ func TestKYCSetValid(t *testing.T) {
 	if got := ReadLevel(st, target); got != KycLevelIdentity {
 		t.Errorf("ReadLevel: want %d, got %d", KycLevelIdentity, got)
 	}
-	if got := ReadStatus(st, target); got != KycActive {
+	if got := ReadStatus(st, target, 1); got != KycActive {
 		t.Errorf("ReadStatus: want KycActive, got %v", got)
 	}
-	if !MeetsLevel(st, target, KycLevelIdentity) {
+	if !MeetsLevel(st, target, KycLevelIdentity, 1) {
 		t.Error("MeetsLevel(31): want true, got false")
 	}
-	if MeetsLevel(st, target, KycLevelAddress) {
+	if MeetsLevel(st, target, KycLevelAddress, 1) {
 		t.Error("MeetsLevel(63): want false, got true")
 	}
 }
@@ -152,10 +154,10 @@ func TestKYCSuspend(t *testing.T) {
 		t.Fatalf("KYC_SUSPEND: %v", err)
 	}
 
-	if got := ReadStatus(st, target); got != KycSuspended {
+	if got := ReadStatus(st, target, 1); got != KycSuspended {
 		t.Errorf("ReadStatus: want KycSuspended, got %v", got)
 	}
-	if MeetsLevel(st, target, KycLevelBasic) {
+	if MeetsLevel(st, target, KycLevelBasic, 1) {
 		t.Error("MeetsLevel after suspend: want false, got true")
 	}
 	// Level bits must be preserved after suspension.
@@ -183,3 +185,266 @@ func TestKYCSuspendNonActive(t *testing.T) {
 		t.Errorf("want ErrKYCNotActive, got %v", err)
 	}
 }
+
+// setupIssuer grants member the committee capability and registers a fresh
+// issuer key for it, returning the private key.
+func setupIssuer(t *testing.T, st *state.StateDB, member common.Address) [32]byte {
+	t.Helper()
+	capability.GrantCapability(st, member, params.KYCCommitteeBit)
+	pub, priv, err := cryptopriv.GenerateKeypair()
+	if err != nil {
+		t.Fatalf("GenerateKeypair: %v", err)
+	}
+	var privKey [32]byte
+	copy(privKey[:], priv)
+	sig, e, err := cryptopriv.SignSchnorr(privKey, IssuerMessage(member))
+	if err != nil {
+		t.Fatalf("SignSchnorr: %v", err)
+	}
+	sa := &sysaction.SysAction{
+		Action:  sysaction.ActionKYCIssuerRegister,
+		Payload: mustMarshal(issuerRegisterPayload{Pubkey: common.BytesToHash(pub), S: sig, E: e}),
+	}
+	if err := h.Handle(newCtx(st, member, big.NewInt(0)), sa); err != nil {
+		t.Fatalf("issuer register: %v", err)
+	}
+	return privKey
+}
+
+// proveTierSA builds a KYC_PROVE_TIER SysAction.
+func proveTierSA(minTier uint8, proof *TierProof) *sysaction.SysAction {
+	return &sysaction.SysAction{
+		Action:  sysaction.ActionKYCProveTier,
+		Payload: mustMarshal(proveTierPayload{MinTier: minTier, Proof: hexutil.Bytes(proof.Encode())}),
+	}
+}
+
+// TestKYCIssuerRegisterRequiresPossession verifies that an issuer key is only
+// accepted with a signature by that key over the member's address.
+func TestKYCIssuerRegisterRequiresPossession(t *testing.T) {
+	st := newTestState()
+	member, other := tAddr(0x40), tAddr(0x41)
+	capability.GrantCapability(st, member, params.KYCCommitteeBit)
+	pub, priv, _ := cryptopriv.GenerateKeypair()
+	var privKey [32]byte
+	copy(privKey[:], priv)
+	// Signed for a different member.
+	sig, e, _ := cryptopriv.SignSchnorr(privKey, IssuerMessage(other))
+	sa := &sysaction.SysAction{
+		Action:  sysaction.ActionKYCIssuerRegister,
+		Payload: mustMarshal(issuerRegisterPayload{Pubkey: common.BytesToHash(pub), S: sig, E: e}),
+	}
+	if err := h.Handle(newCtx(st, member, big.NewInt(0)), sa); err != ErrKYCInvalidIssuerKey {
+		t.Fatalf("want ErrKYCInvalidIssuerKey, got %v", err)
+	}
+	if ReadIssuerKey(st, member) != [32]byte{} {
+		t.Errorf("issuer key stored despite invalid signature")
+	}
+}
+
+// TestKYCTierProof walks a private credential through issuance, proofs at
+// and above its tier, encoding, and the recorded attestation.
+func TestKYCTierProof(t *testing.T) {
+	st := newTestState()
+	member, holder, other := tAddr(0x50), tAddr(0x51), tAddr(0x52)
+	issuerPriv := setupIssuer(t, st, member)
+
+	cred, err := IssueCredential(issuerPriv, member, holder, TierOf(KycLevelIdentity))
+	if err != nil {
+		t.Fatalf("IssueCredential: %v", err)
+	}
+	if _, err := cred.ProveTier(3); err != ErrKYCInvalidTier {
+		t.Fatalf("prove above tier: want ErrKYCInvalidTier, got %v", err)
+	}
+	proof, err := cred.ProveTier(2)
+	if err != nil {
+		t.Fatalf("ProveTier: %v", err)
+	}
+	decoded, err := DecodeTierProof(proof.Encode())
+	if err != nil {
+		t.Fatalf("DecodeTierProof: %v", err)
+	}
+	if _, err := VerifyTierProof(st, holder, 2, decoded); err != nil {
+		t.Errorf("verify: %v", err)
+	}
+	// The range proof only covers the minimum tier it was built for.
+	for _, minTier := range []uint8{1, 3} {
+		if _, err := VerifyTierProof(st, holder, minTier, decoded); err != ErrKYCInvalidProof {
+			t.Errorf("verify min tier %d: want ErrKYCInvalidProof, got %v", minTier, err)
+		}
+	}
+	// The credential is bound to its holder.
+	if _, err := VerifyTierProof(st, other, 2, decoded); err != ErrKYCInvalidProof {
+		t.Errorf("verify for other holder: want ErrKYCInvalidProof, got %v", err)
+	}
+
+	if err := h.Handle(newCtx(st, holder, big.NewInt(0)), proveTierSA(2, proof)); err != nil {
+		t.Fatalf("KYC_PROVE_TIER: %v", err)
+	}
+	if got := ProvenTier(st, holder, 1); got != 2 {
+		t.Errorf("ProvenTier: want 2, got %d", got)
+	}
+	if got := ProvenTier(st, holder, 1+params.KYCTierProofValidityBlocks); got != 0 {
+		t.Errorf("ProvenTier after validity: want 0, got %d", got)
+	}
+	// No level is written to the public KYC record.
+	if ReadLevel(st, holder) != 0 || ReadStatus(st, holder, 1) != KycNone {
+		t.Errorf("public KYC record written by KYC_PROVE_TIER")
+	}
+}
+
+// TestKYCTierProofRevocation verifies that revoking the credential, removing
+// the issuer from the committee, or suspending the holder invalidates both
+// new proofs and existing attestations, and that an issuer key rotation
+// invalidates existing attestations.
+func TestKYCTierProofRevocation(t *testing.T) {
+	st := newTestState()
+	member, holder := tAddr(0x60), tAddr(0x61)
+	issuerPriv := setupIssuer(t, st, member)
+	cred, _ := IssueCredential(issuerPriv, member, holder, 4)
+	proof, err := cred.ProveTier(4)
+	if err != nil {
+		t.Fatalf("ProveTier: %v", err)
+	}
+	if err := h.Handle(newCtx(st, holder, big.NewInt(0)), proveTierSA(4, proof)); err != nil {
+		t.Fatalf("KYC_PROVE_TIER: %v", err)
+	}
+
+	// Issuer leaves the committee.
+	capability.RevokeCapability(st, member, params.KYCCommitteeBit)
+	if _, err := VerifyTierProof(st, holder, 4, proof); err != ErrKYCUnknownIssuer {
+		t.Errorf("inactive issuer: want ErrKYCUnknownIssuer, got %v", err)
+	}
+	if got := ProvenTier(st, holder, 1); got != 0 {
+		t.Errorf("ProvenTier with inactive issuer: want 0, got %d", got)
+	}
+	capability.GrantCapability(st, member, params.KYCCommitteeBit)
+
+	// Issuer rotates its key: the attestation was made under the old one.
+	if got := ProvenTier(st, holder, 1); got != 4 {
+		t.Fatalf("ProvenTier before rotation: want 4, got %d", got)
+	}
+	setupIssuer(t, st, member)
+	if got := ProvenTier(st, holder, 1); got != 0 {
+		t.Errorf("ProvenTier after issuer key rotation: want 0, got %d", got)
+	}
+
+	// Credential revoked.
+	revoke := &sysaction.SysAction{
+		Action:  sysaction.ActionKYCCredentialRevoke,
+		Payload: mustMarshal(credentialRevokePayload{CredentialID: CredentialID(holder, member, cred.Commitment)}),
+	}
+	if err := h.Handle(newCtx(st, holder, big.NewInt(0)), revoke); err != ErrKYCNotCommittee {
+		t.Fatalf("revoke by holder: want ErrKYCNotCommittee, got %v", err)
+	}
+	if err := h.Handle(newCtx(st, member, big.NewInt(0)), revoke); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if err := h.Handle(newCtx(st, holder, big.NewInt(0)), proveTierSA(4, proof)); err != ErrKYCCredentialRevoked {
+		t.Errorf("prove revoked: want ErrKYCCredentialRevoked, got %v", err)
+	}
+	if got := ProvenTier(st, holder, 1); got != 0 {
+		t.Errorf("ProvenTier after revoke: want 0, got %d", got)
+	}
+
+	// Suspended holders cannot prove with a fresh credential either.
+	fresh, _ := IssueCredential(issuerPriv, member, holder, 4)
+	freshProof, _ := fresh.ProveTier(1)
+	WriteKYC(st, holder, KycLevelBasic, KycSuspended)
+	if _, err := VerifyTierProof(st, holder, 1, freshProof); err != ErrKYCSuspended {
+		t.Errorf("suspended holder: want ErrKYCSuspended, got %v", err)
+	}
+}
+
+// kycSetAt sends KYC_SET from caller at block.
+func kycSetAt(st *state.StateDB, caller common.Address, p setPayload, block uint64) error {
+	ctx := newCtx(st, caller, big.NewInt(0))
+	ctx.BlockNumber = new(big.Int).SetUint64(block)
+	return h.Handle(ctx, &sysaction.SysAction{Action: sysaction.ActionKYCSet, Payload: mustMarshal(p)})
+}
+
+// TestKYCExpiryAndReverification verifies that a record reads as expired
+// after valid_blocks and that setting it again re-verifies the account.
+func TestKYCExpiryAndReverification(t *testing.T) {
+	st := newTestState()
+	caller, other, target := tAddr(0x70), tAddr(0x71), tAddr(0x72)
+	capability.GrantCapability(st, caller, params.KYCCommitteeBit)
+	capability.GrantCapability(st, other, params.KYCCommitteeBit)
+
+	p := setPayload{Target: target.Hex(), Level: KycLevelIdentity, ValidBlocks: 100}
+	if err := kycSetAt(st, caller, p, 10); err != nil {
+		t.Fatalf("KYC_SET: %v", err)
+	}
+	if got := ReadExpiry(st, target); got != 110 {
+		t.Errorf("ReadExpiry: want 110, got %d", got)
+	}
+	if got := ReadStatus(st, target, 109); got != KycActive {
+		t.Errorf("status before expiry: want KycActive, got %d", got)
+	}
+	if got := ReadStatus(st, target, 110); got != KycExpired {
+		t.Errorf("status at expiry: want KycExpired, got %d", got)
+	}
+	if MeetsLevel(st, target, KycLevelBasic, 110) {
+		t.Error("MeetsLevel after expiry: want false")
+	}
+
+	// Re-verification by another member resets expiry and issuer.
+	p.ValidBlocks = 0
+	if err := kycSetAt(st, other, p, 200); err != nil {
+		t.Fatalf("re-verify: %v", err)
+	}
+	rec := ReadRecord(st, target, 200)
+	if rec.Status != KycActive || rec.Issuer != other || rec.ExpiresAt != 200+params.KYCDefaultValidityBlocks {
+		t.Errorf("re-verified record: got %+v", rec)
+	}
+
+	p.ValidBlocks = params.KYCMaxValidityBlocks + 1
+	if err := kycSetAt(st, caller, p, 200); err != ErrKYCInvalidValidity {
+		t.Errorf("too long validity: want ErrKYCInvalidValidity, got %v", err)
+	}
+
+	// Records set before expiry existed never expire.
+	legacy := tAddr(0x73)
+	WriteKYC(st, legacy, KycLevelBasic, KycActive)
+	if got := ReadStatus(st, legacy, 1<<40); got != KycActive {
+		t.Errorf("legacy status: want KycActive, got %d", got)
+	}
+}
+
+// TestKYCJurisdictions verifies jurisdiction validation and Check.
+func TestKYCJurisdictions(t *testing.T) {
+	st := newTestState()
+	caller, target := tAddr(0x80), tAddr(0x81)
+	capability.GrantCapability(st, caller, params.KYCCommitteeBit)
+
+	for _, codes := range [][]string{{"USA"}, {"S1"}, {"SG", "sg"}, make([]string, params.KYCMaxJurisdictions+1)} {
+		p := setPayload{Target: target.Hex(), Level: KycLevelBasic, Jurisdictions: codes}
+		if err := kycSetAt(st, caller, p, 1); err != ErrKYCInvalidJurisdiction {
+			t.Errorf("codes %q: want ErrKYCInvalidJurisdiction, got %v", codes, err)
+		}
+	}
+	p := setPayload{Target: target.Hex(), Level: KycLevelIdentity, Jurisdictions: []string{"sg", "HK"}}
+	if err := kycSetAt(st, caller, p, 1); err != nil {
+		t.Fatalf("KYC_SET: %v", err)
+	}
+	if got := ReadJurisdictions(st, target); len(got) != 2 || got[0] != "SG" || got[1] != "HK" {
+		t.Errorf("ReadJurisdictions: want [SG HK], got %v", got)
+	}
+	cases := []struct {
+		minLevel uint16
+		codes    []string
+		block    uint64
+		want     bool
+	}{
+		{KycLevelBasic, nil, 1, true},
+		{KycLevelIdentity, []string{"US", "HK"}, 1, true},
+		{KycLevelIdentity, []string{"US"}, 1, false},
+		{KycLevelAddress, []string{"SG"}, 1, false},
+		{KycLevelBasic, []string{"SG"}, 1 + params.KYCDefaultValidityBlocks, false},
+	}
+	for i, tc := range cases {
+		if got := Check(st, target, tc.minLevel, tc.codes, tc.block); got != tc.want {
+			t.Errorf("case %d: Check = %v, want %v", i, got, tc.want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package kyc
 import (
 	"encoding/binary"
 
+	"github.com/tos-network/gtos/capability"
 	"github.com/tos-network/gtos/common"
 	"github.com/tos-network/gtos/crypto"
 	"github.com/tos-network/gtos/params"
@@ -34,25 +35,193 @@ func writePacked(db stateDB, addr common.Address, level uint16, status KycStatus
 	db.SetState(params.KYCRegistryAddress, kycSlot(addr), val)
 }
 
+// recordSlot returns the slot for one extended field of addr's KYC record.
+// key = keccak256("kyc\x00rec\x00" || addr[32] || field)
+func recordSlot(addr common.Address, field string) common.Hash {
+	key := append([]byte("kyc\x00rec\x00"), addr.Bytes()...)
+	return common.BytesToHash(crypto.Keccak256(append(key, []byte(field)...)))
+}
+
 // ReadLevel returns the KYC level bitmask for addr.
 func ReadLevel(db stateDB, addr common.Address) uint16 {
 	level, _ := readPacked(db, addr)
 	return level
 }
 
-// ReadStatus returns the KYC status for addr.
-func ReadStatus(db stateDB, addr common.Address) KycStatus {
+// ReadExpiry returns the block at which addr's KYC record expires, or zero
+// if it never does.
+func ReadExpiry(db stateDB, addr common.Address) uint64 {
+	raw := db.GetState(params.KYCRegistryAddress, recordSlot(addr, "expiry"))
+	return binary.BigEndian.Uint64(raw[24:])
+}
+
+// ReadStatus returns the KYC status for addr at block. An active record past
+// its expiry block reads as KycExpired.
+func ReadStatus(db stateDB, addr common.Address, block uint64) KycStatus {
 	_, status := readPacked(db, addr)
+	if status == KycActive {
+		if expiry := ReadExpiry(db, addr); expiry != 0 && block >= expiry {
+			return KycExpired
+		}
+	}
 	return status
 }
 
+// ReadJurisdictions returns the jurisdiction codes of addr's KYC record.
+func ReadJurisdictions(db stateDB, addr common.Address) []string {
+	raw := db.GetState(params.KYCRegistryAddress, recordSlot(addr, "juris"))
+	var codes []string
+	for i := 0; i+1 < len(raw) && raw[i] != 0; i += 2 {
+		codes = append(codes, string(raw[i:i+2]))
+	}
+	return codes
+}
+
+// ReadIssuer returns the committee member that last set addr's KYC record.
+func ReadIssuer(db stateDB, addr common.Address) common.Address {
+	raw := db.GetState(params.KYCRegistryAddress, recordSlot(addr, "issuer"))
+	return common.BytesToAddress(raw.Bytes())
+}
+
+// ReadRecord returns addr's full KYC record at block.
+func ReadRecord(db stateDB, addr common.Address, block uint64) *KycRecord {
+	level, _ := readPacked(db, addr)
+	return &KycRecord{
+		Level:         level,
+		Status:        ReadStatus(db, addr, block),
+		ExpiresAt:     ReadExpiry(db, addr),
+		Issuer:        ReadIssuer(db, addr),
+		Jurisdictions: ReadJurisdictions(db, addr),
+	}
+}
+
 // WriteKYC writes level and status into the packed slot.
 func WriteKYC(db stateDB, addr common.Address, level uint16, status KycStatus) {
 	writePacked(db, addr, level, status)
 }
 
-// MeetsLevel returns true if addr has active KYC and (level & required) == required.
-func MeetsLevel(db stateDB, addr common.Address, required uint16) bool {
-	level, status := readPacked(db, addr)
-	return status == KycActive && (level&required) == required
+// writeRecordMeta stores the expiry, issuer and jurisdiction codes of addr's
+// record. Codes must already be validated.
+func writeRecordMeta(db stateDB, addr common.Address, expiry uint64, issuer common.Address, codes []string) {
+	var exp, juris common.Hash
+	binary.BigEndian.PutUint64(exp[24:], expiry)
+	for i, c := range codes {
+		copy(juris[2*i:], c)
+	}
+	db.SetState(params.KYCRegistryAddress, recordSlot(addr, "expiry"), exp)
+	db.SetState(params.KYCRegistryAddress, recordSlot(addr, "issuer"), common.BytesToHash(issuer.Bytes()))
+	db.SetState(params.KYCRegistryAddress, recordSlot(addr, "juris"), juris)
+}
+
+// MeetsLevel returns true if addr has KYC that is active and unexpired at
+// block and (level & required) == required.
+func MeetsLevel(db stateDB, addr common.Address, required uint16, block uint64) bool {
+	level, _ := readPacked(db, addr)
+	return ReadStatus(db, addr, block) == KycActive && (level&required) == required
+}
+
+// InJurisdiction reports whether addr's record lists any of codes. An empty
+// codes list matches every record.
+func InJurisdiction(db stateDB, addr common.Address, codes []string) bool {
+	if len(codes) == 0 {
+		return true
+	}
+	for _, have := range ReadJurisdictions(db, addr) {
+		for _, want := range codes {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Check reports whether addr has active, unexpired KYC at block meeting
+// minLevel in one of jurisdictions (any jurisdiction if empty).
+func Check(db stateDB, addr common.Address, minLevel uint16, jurisdictions []string, block uint64) bool {
+	return MeetsLevel(db, addr, minLevel, block) && InJurisdiction(db, addr, jurisdictions)
+}
+
+// isSuspended reports whether addr's record is suspended, regardless of
+// expiry.
+func isSuspended(db stateDB, addr common.Address) bool {
+	_, status := readPacked(db, addr)
+	return status == KycSuspended
+}
+
+// ── Private credentials ───────────────────────────────────────────────────────
+
+func issuerKeySlot(member common.Address) common.Hash {
+	return common.BytesToHash(crypto.Keccak256(
+		append([]byte("kyc\x00issuer\x00"), member.Bytes()...)))
+}
+
+func revokedSlot(id common.Hash) common.Hash {
+	return common.BytesToHash(crypto.Keccak256(
+		append([]byte("kyc\x00revoked\x00"), id.Bytes()...)))
+}
+
+func provenSlot(holder common.Address, field string) common.Hash {
+	key := append([]byte("kyc\x00proven\x00"), holder.Bytes()...)
+	return common.BytesToHash(crypto.Keccak256(append(key, []byte(field)...)))
+}
+
+// ReadIssuerKey returns the ElGamal issuer public key registered by the
+// committee member, or zero if none.
+func ReadIssuerKey(db stateDB, member common.Address) [32]byte {
+	return db.GetState(params.KYCRegistryAddress, issuerKeySlot(member))
+}
+
+func writeIssuerKey(db stateDB, member common.Address, pub [32]byte) {
+	db.SetState(params.KYCRegistryAddress, issuerKeySlot(member), pub)
+}
+
+// isActiveIssuer reports whether member has an issuer key and still holds
+// the KYC committee capability.
+func isActiveIssuer(db stateDB, member common.Address) bool {
+	return ReadIssuerKey(db, member) != [32]byte{} &&
+		capability.HasCapability(db, member, params.KYCCommitteeBit)
+}
+
+// IsCredentialRevoked reports whether the credential id has been revoked.
+func IsCredentialRevoked(db stateDB, id common.Hash) bool {
+	return db.GetState(params.KYCRegistryAddress, revokedSlot(id))[31] != 0
+}
+
+func revokeCredential(db stateDB, id common.Hash) {
+	var val common.Hash
+	val[31] = 1
+	db.SetState(params.KYCRegistryAddress, revokedSlot(id), val)
+}
+
+// writeProvenTier records that holder proved at least tier with credential
+// id from issuer, valid until expiry, along with the issuer key the proof was
+// checked against. It replaces any earlier record.
+func writeProvenTier(db stateDB, holder, issuer common.Address, id common.Hash, tier uint8, expiry uint64) {
+	var packed common.Hash
+	binary.BigEndian.PutUint64(packed[23:31], expiry)
+	packed[31] = tier
+	db.SetState(params.KYCRegistryAddress, provenSlot(holder, "tier"), packed)
+	db.SetState(params.KYCRegistryAddress, provenSlot(holder, "cred"), id)
+	db.SetState(params.KYCRegistryAddress, provenSlot(holder, "issuer"), common.BytesToHash(issuer.Bytes()))
+	db.SetState(params.KYCRegistryAddress, provenSlot(holder, "issuerkey"), ReadIssuerKey(db, issuer))
+}
+
+// ProvenTier returns the tier holder last proved with KYC_PROVE_TIER, or 0
+// if that proof has expired at block, its credential or issuer is no longer
+// valid, the issuer has registered a new key since, or holder is suspended.
+func ProvenTier(db stateDB, holder common.Address, block uint64) uint8 {
+	packed := db.GetState(params.KYCRegistryAddress, provenSlot(holder, "tier"))
+	if block >= binary.BigEndian.Uint64(packed[23:31]) {
+		return 0
+	}
+	issuer := common.BytesToAddress(db.GetState(params.KYCRegistryAddress, provenSlot(holder, "issuer")).Bytes())
+	id := db.GetState(params.KYCRegistryAddress, provenSlot(holder, "cred"))
+	if !isActiveIssuer(db, issuer) || IsCredentialRevoked(db, id) || isSuspended(db, holder) {
+		return 0
+	}
+	if ReadIssuerKey(db, issuer) != db.GetState(params.KYCRegistryAddress, provenSlot(holder, "issuerkey")) {
+		return 0
+	}
+	return packed[31]
 }
@@ -1,6 +1,10 @@
 package kyc
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/tos-network/gtos/common"
+)
 
 // KycStatus is the on-chain status byte for a KYC record.
 type KycStatus uint8
@@ -9,8 +13,18 @@ const (
 	KycNone      KycStatus = 0 // never set
 	KycActive    KycStatus = 1 // valid and active
 	KycSuspended KycStatus = 2 // suspended by committee
+	KycExpired   KycStatus = 3 // past its expiry block; derived on read, never stored
 )
 
+// KycRecord is the full KYC record of an account as of a given block.
+type KycRecord struct {
+	Level         uint16
+	Status        KycStatus
+	ExpiresAt     uint64         // zero for records set before expiry existed
+	Issuer        common.Address // committee member that last set the record
+	Jurisdictions []string       // ISO 3166-1 alpha-2 codes, e.g. "SG"
+}
+
 // Valid cumulative KYC levels (2^n − 1 pattern).
 const (
 	KycLevelAnonymous uint16 = 0
@@ -25,10 +39,18 @@ const (
 )
 
 var (
-	ErrKYCNotCommittee     = errors.New("kyc: caller is not an authorized committee member")
-	ErrKYCInvalidLevel     = errors.New("kyc: level is not a valid cumulative value")
-	ErrKYCNotActive        = errors.New("kyc: account has no active KYC record")
-	ErrKYCAlreadySuspended = errors.New("kyc: account is already suspended")
+	ErrKYCNotCommittee        = errors.New("kyc: caller is not an authorized committee member")
+	ErrKYCInvalidLevel        = errors.New("kyc: level is not a valid cumulative value")
+	ErrKYCNotActive           = errors.New("kyc: account has no active KYC record")
+	ErrKYCAlreadySuspended    = errors.New("kyc: account is already suspended")
+	ErrKYCSuspended           = errors.New("kyc: account is suspended")
+	ErrKYCInvalidValidity     = errors.New("kyc: invalid validity period")
+	ErrKYCInvalidJurisdiction = errors.New("kyc: invalid jurisdiction code set")
+	ErrKYCInvalidTier         = errors.New("kyc: invalid tier")
+	ErrKYCInvalidProof        = errors.New("kyc: invalid tier proof")
+	ErrKYCInvalidIssuerKey    = errors.New("kyc: invalid issuer key")
+	ErrKYCUnknownIssuer       = errors.New("kyc: issuer is not an active committee member")
+	ErrKYCCredentialRevoked   = errors.New("kyc: credential has been revoked")
 )
 
 // IsValidLevel returns true if level is one of the nine defined cumulative values.
@@ -134,6 +134,29 @@ func DepositFor(codeBytes uint64, leaseBlocks uint64) (*big.Int, error) {
 	return depositGas.Mul(depositGas, big.NewInt(params.TxPriceTomi)), nil
 }
 
+// PermanentCostFor returns the total price of a permanent contract of
+// codeBytes: the deposit of a lease for LeaseReferenceBlocks, i.e. the full
+// LeaseDepositReferenceByteGas per byte.
+func PermanentCostFor(codeBytes uint64) (*big.Int, error) {
+	return DepositFor(codeBytes, params.LeaseReferenceBlocks)
+}
+
+// PermanentTopUp returns what converting meta's lease to a permanent contract
+// costs on top of the deposit it already locks.
+func PermanentTopUp(meta Meta) (*big.Int, error) {
+	cost, err := PermanentCostFor(meta.CodeBytes)
+	if err != nil {
+		return nil, err
+	}
+	if meta.DepositWei != nil {
+		cost.Sub(cost, meta.DepositWei)
+	}
+	if cost.Sign() < 0 {
+		return new(big.Int), nil
+	}
+	return cost, nil
+}
+
 // RefundFor returns the refundable portion of the remaining deposit.
 func RefundFor(deposit *big.Int) *big.Int {
 	if deposit == nil || deposit.Sign() == 0 {
@@ -159,12 +182,15 @@ func NewMeta(owner common.Address, createdAt uint64, leaseBlocks uint64, codeByt
 		return Meta{}, fmt.Errorf("lease: grace window overflows")
 	}
 	meta := Meta{
-		LeaseOwner:      owner,
-		CreatedAtBlock:  createdAt,
-		ExpireAtBlock:   expireAt,
-		GraceUntilBlock: expireAt + graceBlocks,
-		CodeBytes:       codeBytes,
-		DepositWei:      new(big.Int),
+		LeaseOwner:       owner,
+		CreatedAtBlock:   createdAt,
+		ExpireAtBlock:    expireAt,
+		GraceUntilBlock:  expireAt + graceBlocks,
+		CodeBytes:        codeBytes,
+		DepositWei:       new(big.Int),
+		RenewalBudgetWei: new(big.Int),
+		RentPaidBlock:    createdAt,
+		StorageSeeded:    true,
 	}
 	if deposit != nil {
 		meta.DepositWei = new(big.Int).Set(deposit)
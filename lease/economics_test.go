@@ -1,6 +1,7 @@
 package lease
 
 import (
+	"math/big"
 	"testing"
 
 	"github.com/tos-network/gtos/params"
@@ -57,3 +58,28 @@ func TestCreate2XGasScalesBaseWithLeaseBlocks(t *testing.T) {
 		t.Fatalf("Create2XGas should increase with leaseBlocks: short=%d long=%d", shortGas, longGas)
 	}
 }
+
+func TestPermanentTopUpCreditsLockedDeposit(t *testing.T) {
+	cost, err := PermanentCostFor(1000)
+	if err != nil {
+		t.Fatalf("PermanentCostFor: %v", err)
+	}
+	want := new(big.Int).SetUint64(1000 * params.LeaseDepositReferenceByteGas)
+	want.Mul(want, big.NewInt(params.TxPriceTomi))
+	if cost.Cmp(want) != 0 {
+		t.Fatalf("PermanentCostFor: want %v, got %v", want, cost)
+	}
+
+	meta := Meta{CodeBytes: 1000, DepositWei: big.NewInt(1)}
+	topUp, err := PermanentTopUp(meta)
+	if err != nil {
+		t.Fatalf("PermanentTopUp: %v", err)
+	}
+	if topUp.Cmp(new(big.Int).Sub(cost, big.NewInt(1))) != 0 {
+		t.Fatalf("PermanentTopUp: want %v, got %v", new(big.Int).Sub(cost, big.NewInt(1)), topUp)
+	}
+	meta.DepositWei = new(big.Int).Add(cost, big.NewInt(1))
+	if topUp, _ = PermanentTopUp(meta); topUp.Sign() != 0 {
+		t.Fatalf("PermanentTopUp with surplus deposit: want 0, got %v", topUp)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"math/big"
 
+	"github.com/tos-network/gtos/common"
+	vmtypes "github.com/tos-network/gtos/core/vmtypes"
 	"github.com/tos-network/gtos/params"
 	"github.com/tos-network/gtos/sysaction"
 )
@@ -15,7 +17,15 @@ func init() {
 type handler struct{}
 
 func (h *handler) Actions() []sysaction.ActionKind {
-	return []sysaction.ActionKind{sysaction.ActionLeaseRenew, sysaction.ActionLeaseClose}
+	return []sysaction.ActionKind{
+		sysaction.ActionLeaseRenew,
+		sysaction.ActionLeaseClose,
+		sysaction.ActionLeaseFundRenewal,
+		sysaction.ActionLeaseWithdrawRenewal,
+		sysaction.ActionLeaseTransferOwner,
+		sysaction.ActionLeaseAcceptOwner,
+		sysaction.ActionLeaseMakePermanent,
+	}
 }
 
 func (h *handler) Handle(ctx *sysaction.Context, sa *sysaction.SysAction) error {
@@ -24,11 +34,24 @@ func (h *handler) Handle(ctx *sysaction.Context, sa *sysaction.SysAction) error
 		return h.handleRenew(ctx, sa)
 	case sysaction.ActionLeaseClose:
 		return h.handleClose(ctx, sa)
+	case sysaction.ActionLeaseFundRenewal:
+		return h.handleFundRenewal(ctx, sa)
+	case sysaction.ActionLeaseWithdrawRenewal:
+		return h.handleWithdrawRenewal(ctx, sa)
+	case sysaction.ActionLeaseTransferOwner:
+		return h.handleTransferOwner(ctx, sa)
+	case sysaction.ActionLeaseAcceptOwner:
+		return h.handleAcceptOwner(ctx, sa)
+	case sysaction.ActionLeaseMakePermanent:
+		return h.handleMakePermanent(ctx, sa)
 	default:
 		return nil
 	}
 }
 
+// handleRenew extends a lease. Anyone may renew, paying the deposit from
+// their own balance; the deposit joins the lease's and is refunded to the
+// owner on close or prune.
 func (h *handler) handleRenew(ctx *sysaction.Context, sa *sysaction.SysAction) error {
 	if ctx.Value != nil && ctx.Value.Sign() != 0 {
 		return ErrLeaseValueNotAllowed
@@ -44,9 +67,6 @@ func (h *handler) handleRenew(ctx *sysaction.Context, sa *sysaction.SysAction) e
 	if err := RejectTombstoned(ctx.StateDB, p.ContractAddr); err != nil {
 		return err
 	}
-	if ctx.From != meta.LeaseOwner {
-		return ErrLeaseOwnerOnly
-	}
 	currentBlock := uint64(0)
 	if ctx.BlockNumber != nil {
 		currentBlock = ctx.BlockNumber.Uint64()
@@ -56,7 +76,8 @@ func (h *handler) handleRenew(ctx *sysaction.Context, sa *sysaction.SysAction) e
 	default:
 		return ErrLeaseExpired
 	}
-	deposit, err := DepositFor(meta.CodeBytes, p.DeltaBlocks)
+	ChargeRent(ctx.StateDB, p.ContractAddr, &meta, currentBlock)
+	deposit, err := RenewalCost(meta, p.DeltaBlocks)
 	if err != nil {
 		return err
 	}
@@ -71,11 +92,248 @@ func (h *handler) handleRenew(ctx *sysaction.Context, sa *sysaction.SysAction) e
 		return err
 	}
 	meta.DepositWei = new(big.Int).Add(meta.DepositWei, deposit)
+	CapExpiry(&meta, currentBlock, ctx.ChainConfig)
 	ScheduleMeta(ctx.StateDB, p.ContractAddr, &meta, ctx.ChainConfig)
+	ScheduleRenewal(ctx.StateDB, p.ContractAddr, meta, currentBlock/EpochLength(ctx.ChainConfig), ctx.ChainConfig)
+	WriteMeta(ctx.StateDB, p.ContractAddr, meta)
+	return nil
+}
+
+func (h *handler) handleFundRenewal(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	if ctx.Value != nil && ctx.Value.Sign() != 0 {
+		return ErrLeaseValueNotAllowed
+	}
+	var p FundRenewalAction
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	if p.AmountWei == nil || p.AmountWei.Sign() < 0 {
+		return ErrLeaseInvalidAmount
+	}
+	meta, ok := ReadMeta(ctx.StateDB, p.ContractAddr)
+	if !ok {
+		return ErrLeaseNotFound
+	}
+	if err := RejectTombstoned(ctx.StateDB, p.ContractAddr); err != nil {
+		return err
+	}
+	if ctx.From != meta.LeaseOwner {
+		return ErrLeaseOwnerOnly
+	}
+	// A call must add to the budget or change the renewal period.
+	if p.AmountWei.Sign() == 0 && (p.RenewBlocks == 0 || p.RenewBlocks == meta.AutoRenewBlocks) {
+		return ErrLeaseInvalidAmount
+	}
+	currentBlock := uint64(0)
+	if ctx.BlockNumber != nil {
+		currentBlock = ctx.BlockNumber.Uint64()
+	}
+	switch EffectiveStatus(meta, currentBlock, ctx.ChainConfig) {
+	case StatusActive, StatusFrozen:
+	default:
+		return ErrLeaseExpired
+	}
+	if p.RenewBlocks != 0 {
+		if err := ValidateLeaseBlocks(p.RenewBlocks); err != nil {
+			return err
+		}
+		meta.AutoRenewBlocks = p.RenewBlocks
+	}
+	if meta.AutoRenewBlocks == 0 {
+		return ErrLeaseAutoRenewDisabled
+	}
+	if ctx.StateDB.GetBalance(ctx.From).Cmp(p.AmountWei) < 0 {
+		return ErrLeaseInsufficientDeposit
+	}
+	ctx.StateDB.SubBalance(ctx.From, p.AmountWei)
+	ctx.StateDB.AddBalance(params.LeaseRegistryAddress, p.AmountWei)
+
+	meta.RenewalBudgetWei = new(big.Int).Add(meta.RenewalBudgetWei, p.AmountWei)
+	ScheduleRenewal(ctx.StateDB, p.ContractAddr, meta, currentBlock/EpochLength(ctx.ChainConfig), ctx.ChainConfig)
 	WriteMeta(ctx.StateDB, p.ContractAddr, meta)
 	return nil
 }
 
+func (h *handler) handleWithdrawRenewal(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	if ctx.Value != nil && ctx.Value.Sign() != 0 {
+		return ErrLeaseValueNotAllowed
+	}
+	var p WithdrawRenewalAction
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	meta, ok := ReadMeta(ctx.StateDB, p.ContractAddr)
+	if !ok {
+		return ErrLeaseNotFound
+	}
+	if err := RejectTombstoned(ctx.StateDB, p.ContractAddr); err != nil {
+		return err
+	}
+	if ctx.From != meta.LeaseOwner {
+		return ErrLeaseOwnerOnly
+	}
+	if err := refundRenewalBudget(ctx.StateDB, &meta); err != nil {
+		return err
+	}
+	meta.AutoRenewBlocks = 0
+	WriteMeta(ctx.StateDB, p.ContractAddr, meta)
+	return nil
+}
+
+// refundRenewalBudget returns the whole renewal budget to the lease owner.
+func refundRenewalBudget(db vmtypes.StateDB, meta *Meta) error {
+	budget := meta.RenewalBudgetWei
+	if budget == nil || budget.Sign() == 0 {
+		return nil
+	}
+	if db.GetBalance(params.LeaseRegistryAddress).Cmp(budget) < 0 {
+		return ErrLeaseRegistryInvariant
+	}
+	db.SubBalance(params.LeaseRegistryAddress, budget)
+	db.AddBalance(meta.LeaseOwner, budget)
+	meta.RenewalBudgetWei = new(big.Int)
+	return nil
+}
+
+func blockNumberOf(ctx *sysaction.Context) uint64 {
+	if ctx.BlockNumber == nil {
+		return 0
+	}
+	return ctx.BlockNumber.Uint64()
+}
+
+// requireLive rejects lease management once the lease has expired.
+func requireLive(ctx *sysaction.Context, meta Meta) error {
+	return requireLiveAt(meta, blockNumberOf(ctx), ctx.ChainConfig)
+}
+
+func requireLiveAt(meta Meta, block uint64, chainConfig *params.ChainConfig) error {
+	switch EffectiveStatus(meta, block, chainConfig) {
+	case StatusActive, StatusFrozen:
+		return nil
+	default:
+		return ErrLeaseExpired
+	}
+}
+
+// handleTransferOwner proposes a new lease owner; see ProposeOwner.
+func (h *handler) handleTransferOwner(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	if ctx.Value != nil && ctx.Value.Sign() != 0 {
+		return ErrLeaseValueNotAllowed
+	}
+	var p TransferOwnerAction
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	return ProposeOwner(ctx.StateDB, p.ContractAddr, ctx.From, p.NewOwner, blockNumberOf(ctx), ctx.ChainConfig)
+}
+
+// handleAcceptOwner completes a transfer; see AcceptOwner.
+func (h *handler) handleAcceptOwner(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	if ctx.Value != nil && ctx.Value.Sign() != 0 {
+		return ErrLeaseValueNotAllowed
+	}
+	var p AcceptOwnerAction
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	return AcceptOwner(ctx.StateDB, p.ContractAddr, ctx.From, blockNumberOf(ctx), ctx.ChainConfig)
+}
+
+// ProposeOwner records newOwner as the pending owner of the lease on
+// contractAddr, on behalf of from, which must be the current owner. The new
+// owner may be an EOA, accepting with LEASE_ACCEPT_OWNER, or a contract,
+// accepting with tos.lease_accept_owner. A zero newOwner withdraws the
+// proposal.
+func ProposeOwner(db vmtypes.StateDB, contractAddr, from, newOwner common.Address, block uint64, chainConfig *params.ChainConfig) error {
+	meta, ok := ReadMeta(db, contractAddr)
+	if !ok {
+		return ErrLeaseNotFound
+	}
+	if err := RejectTombstoned(db, contractAddr); err != nil {
+		return err
+	}
+	if from != meta.LeaseOwner {
+		return ErrLeaseOwnerOnly
+	}
+	if err := requireLiveAt(meta, block, chainConfig); err != nil {
+		return err
+	}
+	if newOwner == meta.LeaseOwner {
+		return ErrLeaseSameOwner
+	}
+	meta.PendingOwner = newOwner
+	WriteMeta(db, contractAddr, meta)
+	return nil
+}
+
+// AcceptOwner makes from, the pending owner of the lease on contractAddr,
+// its owner. The deposit and renewal budget stay with the lease, so later
+// refunds go to the new owner.
+func AcceptOwner(db vmtypes.StateDB, contractAddr, from common.Address, block uint64, chainConfig *params.ChainConfig) error {
+	meta, ok := ReadMeta(db, contractAddr)
+	if !ok {
+		return ErrLeaseNotFound
+	}
+	if err := RejectTombstoned(db, contractAddr); err != nil {
+		return err
+	}
+	if meta.PendingOwner == (common.Address{}) || from != meta.PendingOwner {
+		return ErrLeaseNotPendingOwner
+	}
+	if err := requireLiveAt(meta, block, chainConfig); err != nil {
+		return err
+	}
+	meta.LeaseOwner = meta.PendingOwner
+	meta.PendingOwner = common.Address{}
+	WriteMeta(db, contractAddr, meta)
+	return nil
+}
+
+// handleMakePermanent converts a lease contract into a permanent one. The
+// owner pays PermanentTopUp; the locked deposit and the top-up stay in the
+// registry for good, and the renewal budget is refunded. Clearing the lease
+// metadata leaves stale prune and renewal queue entries that the sweeps skip.
+func (h *handler) handleMakePermanent(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	if ctx.Value != nil && ctx.Value.Sign() != 0 {
+		return ErrLeaseValueNotAllowed
+	}
+	var p MakePermanentAction
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	meta, ok := ReadMeta(ctx.StateDB, p.ContractAddr)
+	if !ok {
+		return ErrLeaseNotFound
+	}
+	if err := RejectTombstoned(ctx.StateDB, p.ContractAddr); err != nil {
+		return err
+	}
+	if ctx.From != meta.LeaseOwner {
+		return ErrLeaseOwnerOnly
+	}
+	if err := requireLive(ctx, meta); err != nil {
+		return err
+	}
+	ChargeRent(ctx.StateDB, p.ContractAddr, &meta, blockNumberOf(ctx))
+	topUp, err := PermanentTopUp(meta)
+	if err != nil {
+		return err
+	}
+	if ctx.StateDB.GetBalance(ctx.From).Cmp(topUp) < 0 {
+		return ErrLeaseInsufficientDeposit
+	}
+	if err := refundRenewalBudget(ctx.StateDB, &meta); err != nil {
+		return err
+	}
+	if topUp.Sign() > 0 {
+		ctx.StateDB.SubBalance(ctx.From, topUp)
+		ctx.StateDB.AddBalance(params.LeaseRegistryAddress, topUp)
+	}
+	ClearMeta(ctx.StateDB, p.ContractAddr)
+	return nil
+}
+
 func (h *handler) handleClose(ctx *sysaction.Context, sa *sysaction.SysAction) error {
 	if ctx.Value != nil && ctx.Value.Sign() != 0 {
 		return ErrLeaseValueNotAllowed
@@ -94,6 +352,12 @@ func (h *handler) handleClose(ctx *sysaction.Context, sa *sysaction.SysAction) e
 	if ctx.From != meta.LeaseOwner {
 		return ErrLeaseOwnerOnly
 	}
+	if err := refundRenewalBudget(ctx.StateDB, &meta); err != nil {
+		return err
+	}
+	meta.AutoRenewBlocks = 0
+	currentBlock := blockNumberOf(ctx)
+	ChargeRent(ctx.StateDB, p.ContractAddr, &meta, currentBlock)
 	refund := RefundFor(meta.DepositWei)
 	if ctx.StateDB.GetBalance(params.LeaseRegistryAddress).Cmp(refund) < 0 {
 		return ErrLeaseRegistryInvariant
@@ -102,10 +366,6 @@ func (h *handler) handleClose(ctx *sysaction.Context, sa *sysaction.SysAction) e
 		ctx.StateDB.SubBalance(params.LeaseRegistryAddress, refund)
 		ctx.StateDB.AddBalance(ctx.From, refund)
 	}
-	currentBlock := uint64(0)
-	if ctx.BlockNumber != nil {
-		currentBlock = ctx.BlockNumber.Uint64()
-	}
 	meta = CloseMeta(meta, currentBlock)
 	ScheduleMeta(ctx.StateDB, p.ContractAddr, &meta, ctx.ChainConfig)
 	WriteMeta(ctx.StateDB, p.ContractAddr, meta)
@@ -1,6 +1,7 @@
 package lease
 
 import (
+	"errors"
 	"math/big"
 	"testing"
 
@@ -117,3 +118,192 @@ func TestHandleCloseRefundsOwner(t *testing.T) {
 		t.Fatalf("close should expire immediately, got expire=%d grace=%d", meta.ExpireAtBlock, meta.GraceUntilBlock)
 	}
 }
+
+func TestHandleRenewByThirdParty(t *testing.T) {
+	st := newTestState()
+	owner := testAddr(0x55)
+	payer := testAddr(0x66)
+	contractAddr := testAddr(0x77)
+
+	initialDeposit, err := DepositFor(64, 100)
+	if err != nil {
+		t.Fatalf("DepositFor initial: %v", err)
+	}
+	if _, err := Activate(st, contractAddr, owner, 10, 100, 64, initialDeposit, &params.ChainConfig{}); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	st.AddBalance(params.LeaseRegistryAddress, initialDeposit)
+
+	renewDeposit, err := DepositFor(64, 40)
+	if err != nil {
+		t.Fatalf("DepositFor renew: %v", err)
+	}
+	st.AddBalance(payer, renewDeposit)
+
+	wire, err := sysaction.MakeSysAction(sysaction.ActionLeaseRenew, RenewAction{
+		ContractAddr: contractAddr,
+		DeltaBlocks:  40,
+	})
+	if err != nil {
+		t.Fatalf("MakeSysAction: %v", err)
+	}
+	if err := sysaction.ExecuteWithContext(newCtx(st, payer, 20), wire); err != nil {
+		t.Fatalf("ExecuteWithContext renew: %v", err)
+	}
+
+	meta, _ := ReadMeta(st, contractAddr)
+	if meta.ExpireAtBlock != 150 || meta.LeaseOwner != owner {
+		t.Fatalf("unexpected lease after third-party renew: expire=%d owner=%v", meta.ExpireAtBlock, meta.LeaseOwner)
+	}
+	if st.GetBalance(payer).Sign() != 0 {
+		t.Fatalf("payer balance: want 0, got %v", st.GetBalance(payer))
+	}
+}
+
+func TestTransferOwnerToContract(t *testing.T) {
+	st := newTestState()
+	cfg := leaseTestConfig(10)
+	owner := testAddr(0xd1)
+	multisig := testAddr(0xd2)
+	contractAddr := testAddr(0xd4)
+	activateLeaseForPruneTest(t, st, contractAddr, owner, cfg)
+	st.SetCode(multisig, []byte{0x01})
+
+	// A contract may be proposed; it accepts through tos.lease_accept_owner,
+	// which calls AcceptOwner with the contract as sender.
+	if err := executeLeaseAction(t, st, owner, 11, cfg, sysaction.ActionLeaseTransferOwner, TransferOwnerAction{ContractAddr: contractAddr, NewOwner: multisig}); err != nil {
+		t.Fatalf("propose contract owner: %v", err)
+	}
+	if err := AcceptOwner(st, contractAddr, owner, 12, cfg); !errors.Is(err, ErrLeaseNotPendingOwner) {
+		t.Fatalf("accept by old owner: want %v, got %v", ErrLeaseNotPendingOwner, err)
+	}
+	if err := AcceptOwner(st, contractAddr, multisig, 12, cfg); err != nil {
+		t.Fatalf("accept by contract: %v", err)
+	}
+	meta, _ := ReadMeta(st, contractAddr)
+	if meta.LeaseOwner != multisig || meta.PendingOwner != (common.Address{}) {
+		t.Fatalf("after accept: owner=%v pending=%v", meta.LeaseOwner, meta.PendingOwner)
+	}
+	// The contract owner can hand the lease on in the same way.
+	if err := ProposeOwner(st, contractAddr, multisig, owner, 13, cfg); err != nil {
+		t.Fatalf("propose by contract owner: %v", err)
+	}
+	if meta, _ = ReadMeta(st, contractAddr); meta.PendingOwner != owner {
+		t.Fatalf("pending owner = %v, want %v", meta.PendingOwner, owner)
+	}
+}
+
+func TestTransferOwnerTwoStep(t *testing.T) {
+	st := newTestState()
+	cfg := leaseTestConfig(10)
+	owner := testAddr(0xd1)
+	newOwner := testAddr(0xd2)
+	stranger := testAddr(0xd3)
+	contractAddr := testAddr(0xd4)
+	activateLeaseForPruneTest(t, st, contractAddr, owner, cfg)
+
+	propose := TransferOwnerAction{ContractAddr: contractAddr, NewOwner: newOwner}
+	if err := executeLeaseAction(t, st, stranger, 11, cfg, sysaction.ActionLeaseTransferOwner, propose); !errors.Is(err, ErrLeaseOwnerOnly) {
+		t.Fatalf("propose by non-owner: want %v, got %v", ErrLeaseOwnerOnly, err)
+	}
+	if err := executeLeaseAction(t, st, owner, 11, cfg, sysaction.ActionLeaseTransferOwner, TransferOwnerAction{ContractAddr: contractAddr, NewOwner: owner}); !errors.Is(err, ErrLeaseSameOwner) {
+		t.Fatalf("propose current owner: want %v, got %v", ErrLeaseSameOwner, err)
+	}
+	if err := executeLeaseAction(t, st, owner, 11, cfg, sysaction.ActionLeaseTransferOwner, propose); err != nil {
+		t.Fatalf("propose: %v", err)
+	}
+	meta, _ := ReadMeta(st, contractAddr)
+	if meta.LeaseOwner != owner || meta.PendingOwner != newOwner {
+		t.Fatalf("after propose: owner=%v pending=%v", meta.LeaseOwner, meta.PendingOwner)
+	}
+
+	accept := AcceptOwnerAction{ContractAddr: contractAddr}
+	if err := executeLeaseAction(t, st, stranger, 12, cfg, sysaction.ActionLeaseAcceptOwner, accept); !errors.Is(err, ErrLeaseNotPendingOwner) {
+		t.Fatalf("accept by stranger: want %v, got %v", ErrLeaseNotPendingOwner, err)
+	}
+	if err := executeLeaseAction(t, st, newOwner, 12, cfg, sysaction.ActionLeaseAcceptOwner, accept); err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	meta, _ = ReadMeta(st, contractAddr)
+	if meta.LeaseOwner != newOwner || meta.PendingOwner != (common.Address{}) {
+		t.Fatalf("after accept: owner=%v pending=%v", meta.LeaseOwner, meta.PendingOwner)
+	}
+	if err := executeLeaseAction(t, st, newOwner, 12, cfg, sysaction.ActionLeaseAcceptOwner, accept); !errors.Is(err, ErrLeaseNotPendingOwner) {
+		t.Fatalf("second accept: want %v, got %v", ErrLeaseNotPendingOwner, err)
+	}
+
+	// The new owner can cancel its own proposal with a zero new owner.
+	if err := executeLeaseAction(t, st, newOwner, 13, cfg, sysaction.ActionLeaseTransferOwner, TransferOwnerAction{ContractAddr: contractAddr, NewOwner: stranger}); err != nil {
+		t.Fatalf("second propose: %v", err)
+	}
+	if err := executeLeaseAction(t, st, newOwner, 13, cfg, sysaction.ActionLeaseTransferOwner, TransferOwnerAction{ContractAddr: contractAddr}); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if err := executeLeaseAction(t, st, stranger, 13, cfg, sysaction.ActionLeaseAcceptOwner, accept); !errors.Is(err, ErrLeaseNotPendingOwner) {
+		t.Fatalf("accept after cancel: want %v, got %v", ErrLeaseNotPendingOwner, err)
+	}
+
+	if err := executeLeaseAction(t, st, owner, 14, cfg, sysaction.ActionLeaseClose, CloseAction{ContractAddr: contractAddr}); !errors.Is(err, ErrLeaseOwnerOnly) {
+		t.Fatalf("close by previous owner: want %v, got %v", ErrLeaseOwnerOnly, err)
+	}
+	if err := executeLeaseAction(t, st, newOwner, 14, cfg, sysaction.ActionLeaseClose, CloseAction{ContractAddr: contractAddr}); err != nil {
+		t.Fatalf("close by new owner: %v", err)
+	}
+}
+
+func TestMakePermanent(t *testing.T) {
+	st := newTestState()
+	cfg := leaseTestConfig(10)
+	owner := testAddr(0xe1)
+	contractAddr := testAddr(0xe2)
+	meta := activateLeaseForPruneTest(t, st, contractAddr, owner, cfg)
+
+	budget := big.NewInt(500)
+	st.AddBalance(owner, budget)
+	fund := FundRenewalAction{ContractAddr: contractAddr, AmountWei: budget, RenewBlocks: 5}
+	if err := executeLeaseAction(t, st, owner, 11, cfg, sysaction.ActionLeaseFundRenewal, fund); err != nil {
+		t.Fatalf("fund: %v", err)
+	}
+
+	topUp, err := PermanentTopUp(meta)
+	if err != nil {
+		t.Fatalf("PermanentTopUp: %v", err)
+	}
+	cost, _ := PermanentCostFor(meta.CodeBytes)
+	if want := new(big.Int).Sub(cost, meta.DepositWei); topUp.Cmp(want) != 0 {
+		t.Fatalf("top-up: want %v, got %v", want, topUp)
+	}
+	convert := MakePermanentAction{ContractAddr: contractAddr}
+	if err := executeLeaseAction(t, st, testAddr(0xe3), 12, cfg, sysaction.ActionLeaseMakePermanent, convert); !errors.Is(err, ErrLeaseOwnerOnly) {
+		t.Fatalf("convert by non-owner: want %v, got %v", ErrLeaseOwnerOnly, err)
+	}
+	if err := executeLeaseAction(t, st, owner, 12, cfg, sysaction.ActionLeaseMakePermanent, convert); !errors.Is(err, ErrLeaseInsufficientDeposit) {
+		t.Fatalf("convert without funds: want %v, got %v", ErrLeaseInsufficientDeposit, err)
+	}
+
+	st.AddBalance(owner, topUp)
+	registryBefore := new(big.Int).Set(st.GetBalance(params.LeaseRegistryAddress))
+	if err := executeLeaseAction(t, st, owner, 12, cfg, sysaction.ActionLeaseMakePermanent, convert); err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	if st.GetBalance(owner).Cmp(budget) != 0 {
+		t.Fatalf("owner balance: want refunded budget %v, got %v", budget, st.GetBalance(owner))
+	}
+	if want := new(big.Int).Sub(new(big.Int).Add(registryBefore, topUp), budget); st.GetBalance(params.LeaseRegistryAddress).Cmp(want) != 0 {
+		t.Fatalf("registry balance: want %v, got %v", want, st.GetBalance(params.LeaseRegistryAddress))
+	}
+	if _, ok := ReadMeta(st, contractAddr); ok {
+		t.Fatal("permanent contract should have no lease metadata")
+	}
+
+	// The stale queue entries neither renew nor prune the contract.
+	for block := uint64(10); block <= 60; block += 10 {
+		RunPruneSweep(st, block, cfg)
+	}
+	if HasTombstone(st, contractAddr) || len(st.GetCode(contractAddr)) == 0 {
+		t.Fatal("permanent contract must not be pruned")
+	}
+	if err := CheckCallable(st, contractAddr, 1_000_000, cfg); err != nil {
+		t.Fatalf("permanent contract should stay callable: %v", err)
+	}
+}
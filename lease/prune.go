@@ -8,12 +8,15 @@ import (
 	"github.com/tos-network/gtos/params"
 )
 
-// RunPruneSweep processes the lease-prune queue deterministically at epoch boundaries.
+// RunPruneSweep processes the lease auto-renewal and prune queues
+// deterministically at epoch boundaries. Renewals run first so a lease
+// renewed from its budget is never pruned in the same sweep.
 func RunPruneSweep(db vmtypes.StateDB, currentBlock uint64, chainConfig *params.ChainConfig) {
 	epochLength := EpochLength(chainConfig)
 	if epochLength == 0 || currentBlock == 0 || currentBlock%epochLength != 0 {
 		return
 	}
+	runRenewalSweep(db, currentBlock, chainConfig, params.LeaseAutoRenewBudgetPerSweep)
 	runPruneSweep(db, currentBlock, chainConfig, params.LeasePruneBudgetPerSweep)
 }
 
@@ -62,7 +65,12 @@ func runPruneSweep(db vmtypes.StateDB, currentBlock uint64, chainConfig *params.
 				continue
 			}
 
+			// The unused renewal budget is returned in full.
+			ChargeRent(db, addr, &meta, currentBlock)
 			refund := RefundFor(meta.DepositWei)
+			if meta.RenewalBudgetWei != nil {
+				refund.Add(refund, meta.RenewalBudgetWei)
+			}
 			if refund.Sign() > 0 {
 				payout := refund
 				if registryBalance := db.GetBalance(params.LeaseRegistryAddress); registryBalance.Cmp(payout) < 0 {
@@ -0,0 +1,170 @@
+package lease
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/tos-network/gtos/common"
+	vmtypes "github.com/tos-network/gtos/core/vmtypes"
+	"github.com/tos-network/gtos/crypto"
+	"github.com/tos-network/gtos/params"
+)
+
+func renewCountSlot(epoch uint64) common.Hash {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], epoch)
+	return common.BytesToHash(crypto.Keccak256(append([]byte("lease\x00renew_count\x00"), buf[:]...)))
+}
+
+func renewEntrySlot(epoch uint64, seq uint64) common.Hash {
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], epoch)
+	binary.BigEndian.PutUint64(buf[8:], seq)
+	return common.BytesToHash(crypto.Keccak256(append([]byte("lease\x00renew_entry\x00"), buf[:]...)))
+}
+
+func renewCursorSlot(epoch uint64) common.Hash {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], epoch)
+	return common.BytesToHash(crypto.Keccak256(append([]byte("lease\x00renew_cursor\x00"), buf[:]...)))
+}
+
+// renewQueuedSlot holds one plus the epoch at which addr has an entry the
+// sweep has not yet reached, or zero if it has none.
+func renewQueuedSlot(addr common.Address) common.Hash {
+	return common.BytesToHash(crypto.Keccak256(append([]byte("lease\x00renew_queued\x00"), addr.Bytes()...)))
+}
+
+func renewHeadEpochSlot() common.Hash {
+	return common.BytesToHash(crypto.Keccak256([]byte("lease\x00renew_head_epoch")))
+}
+
+// RenewalEpoch returns the epoch on whose boundary an auto-renewing lease
+// expiring at expireAt is renewed: the last boundary before expiry.
+func RenewalEpoch(expireAt uint64, epochLength uint64) uint64 {
+	if epochLength == 0 {
+		epochLength = params.DPoSEpochLength
+	}
+	if expireAt <= epochLength {
+		return 1
+	}
+	return (expireAt - 1) / epochLength
+}
+
+// ScheduleRenewal queues an auto-renewing lease for the renewal sweep at
+// RenewalEpoch, or at notBefore if that is later. Leases without
+// auto-renewal are not queued, nor are leases already queued for that
+// epoch; stale entries are skipped by the sweep.
+func ScheduleRenewal(db vmtypes.StateDB, addr common.Address, meta Meta, notBefore uint64, chainConfig *params.ChainConfig) {
+	if meta.AutoRenewBlocks == 0 {
+		return
+	}
+	epoch := RenewalEpoch(meta.ExpireAtBlock, EpochLength(chainConfig))
+	if epoch < notBefore {
+		epoch = notBefore
+	}
+	if readUint64(db, renewQueuedSlot(addr)) == epoch+1 {
+		return
+	}
+	count := readUint64(db, renewCountSlot(epoch))
+	writeAddress(db, renewEntrySlot(epoch, count), addr)
+	writeUint64(db, renewCountSlot(epoch), count+1)
+	writeUint64(db, renewQueuedSlot(addr), epoch+1)
+	if head := readUint64(db, renewHeadEpochSlot()); head == 0 || epoch < head {
+		writeUint64(db, renewHeadEpochSlot(), epoch)
+	}
+}
+
+// RenewalDeposit returns the deposit one auto-renewal of meta costs.
+func RenewalDeposit(meta Meta) (*big.Int, error) {
+	return RenewalCost(meta, meta.AutoRenewBlocks)
+}
+
+// runRenewalSweep renews, from their budgets, the queued leases that would
+// otherwise expire before the next epoch boundary. Up to budget entries are
+// processed; the rest resume at the next sweep.
+func runRenewalSweep(db vmtypes.StateDB, currentBlock uint64, chainConfig *params.ChainConfig, budget uint64) {
+	epochLength := EpochLength(chainConfig)
+	if epochLength == 0 {
+		return
+	}
+	currentEpoch := currentBlock / epochLength
+	headEpoch := readUint64(db, renewHeadEpochSlot())
+	if headEpoch == 0 || headEpoch > currentEpoch {
+		return
+	}
+
+	processed := uint64(0)
+	for epoch := headEpoch; epoch <= currentEpoch; epoch++ {
+		count := readUint64(db, renewCountSlot(epoch))
+		for cursor := readUint64(db, renewCursorSlot(epoch)); cursor < count; cursor++ {
+			if budget != 0 && processed >= budget {
+				writeUint64(db, renewCursorSlot(epoch), cursor)
+				writeUint64(db, renewHeadEpochSlot(), epoch)
+				return
+			}
+			processed++
+			addr := readAddress(db, renewEntrySlot(epoch, cursor))
+			if readUint64(db, renewQueuedSlot(addr)) == epoch+1 {
+				db.SetState(params.LeaseRegistryAddress, renewQueuedSlot(addr), common.Hash{})
+			}
+			meta, ok := ReadMeta(db, addr)
+			if !ok || meta.AutoRenewBlocks == 0 || meta.ExpireAtBlock > currentBlock+epochLength {
+				continue
+			}
+			switch EffectiveStatus(meta, currentBlock, chainConfig) {
+			case StatusActive, StatusFrozen:
+				autoRenew(db, addr, meta, currentBlock, chainConfig)
+			}
+		}
+		db.SetState(params.LeaseRegistryAddress, renewCountSlot(epoch), common.Hash{})
+		db.SetState(params.LeaseRegistryAddress, renewCursorSlot(epoch), common.Hash{})
+	}
+	writeUint64(db, renewHeadEpochSlot(), currentEpoch+1)
+}
+
+// autoRenew extends the lease by AutoRenewBlocks, paying the deposit from its
+// renewal budget, which already sits in the lease registry. A budget that
+// cannot cover the deposit leaves the lease to expire.
+func autoRenew(db vmtypes.StateDB, addr common.Address, meta Meta, currentBlock uint64, chainConfig *params.ChainConfig) {
+	ChargeRent(db, addr, &meta, currentBlock)
+	deposit, err := RenewalDeposit(meta)
+	if err != nil || meta.RenewalBudgetWei.Cmp(deposit) < 0 {
+		return
+	}
+	renewed, err := RenewMeta(meta, currentBlock, meta.AutoRenewBlocks, chainConfig)
+	if err != nil {
+		return
+	}
+	renewed.RenewalBudgetWei = new(big.Int).Sub(meta.RenewalBudgetWei, deposit)
+	renewed.DepositWei = new(big.Int).Add(meta.DepositWei, deposit)
+	CapExpiry(&renewed, currentBlock, chainConfig)
+	ScheduleMeta(db, addr, &renewed, chainConfig)
+	ScheduleRenewal(db, addr, renewed, currentBlock/EpochLength(chainConfig)+1, chainConfig)
+	WriteMeta(db, addr, renewed)
+}
+
+// Warnings describes what a lease owner should act on at blockNumber: an
+// approaching expiry, a frozen contract, or a renewal budget too small for
+// the next auto-renewal.
+func Warnings(meta Meta, blockNumber uint64, chainConfig *params.ChainConfig) []string {
+	var warnings []string
+	switch EffectiveStatus(meta, blockNumber, chainConfig) {
+	case StatusActive:
+		window := params.LeaseExpiryWarningEpochs * EpochLength(chainConfig)
+		if left := meta.ExpireAtBlock - blockNumber; left <= window {
+			warnings = append(warnings, fmt.Sprintf("lease expires in %d blocks", left))
+		}
+	case StatusFrozen:
+		warnings = append(warnings, fmt.Sprintf("lease is frozen; renew before block %d to avoid pruning", meta.GraceUntilBlock))
+	default:
+		return nil
+	}
+	if meta.AutoRenewBlocks != 0 {
+		if deposit, err := RenewalDeposit(meta); err == nil && meta.RenewalBudgetWei.Cmp(deposit) < 0 {
+			warnings = append(warnings, fmt.Sprintf("renewal budget %v is below the next auto-renewal deposit %v", meta.RenewalBudgetWei, deposit))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,211 @@
+package lease
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/core/state"
+	"github.com/tos-network/gtos/params"
+	"github.com/tos-network/gtos/sysaction"
+)
+
+func executeLeaseAction(t *testing.T, st *state.StateDB, from common.Address, block uint64, cfg *params.ChainConfig, kind sysaction.ActionKind, payload interface{}) error {
+	t.Helper()
+	wire, err := sysaction.MakeSysAction(kind, payload)
+	if err != nil {
+		t.Fatalf("MakeSysAction: %v", err)
+	}
+	ctx := newCtx(st, from, block)
+	ctx.ChainConfig = cfg
+	return sysaction.ExecuteWithContext(ctx, wire)
+}
+
+func TestFundAndWithdrawRenewal(t *testing.T) {
+	st := newTestState()
+	cfg := leaseTestConfig(10)
+	owner := testAddr(0x91)
+	other := testAddr(0x92)
+	contractAddr := testAddr(0x93)
+	activateLeaseForPruneTest(t, st, contractAddr, owner, cfg)
+	registryBefore := new(big.Int).Set(st.GetBalance(params.LeaseRegistryAddress))
+
+	amount := big.NewInt(1_000_000)
+	st.AddBalance(owner, amount)
+	st.AddBalance(other, amount)
+
+	fund := FundRenewalAction{ContractAddr: contractAddr, AmountWei: amount, RenewBlocks: 5}
+	if err := executeLeaseAction(t, st, other, 12, cfg, sysaction.ActionLeaseFundRenewal, fund); !errors.Is(err, ErrLeaseOwnerOnly) {
+		t.Fatalf("fund by non-owner: want %v, got %v", ErrLeaseOwnerOnly, err)
+	}
+	if err := executeLeaseAction(t, st, owner, 12, cfg, sysaction.ActionLeaseFundRenewal, FundRenewalAction{ContractAddr: contractAddr, AmountWei: amount}); !errors.Is(err, ErrLeaseAutoRenewDisabled) {
+		t.Fatalf("fund without renew blocks: want %v, got %v", ErrLeaseAutoRenewDisabled, err)
+	}
+	if err := executeLeaseAction(t, st, owner, 12, cfg, sysaction.ActionLeaseFundRenewal, fund); err != nil {
+		t.Fatalf("fund: %v", err)
+	}
+	meta, _ := ReadMeta(st, contractAddr)
+	if meta.AutoRenewBlocks != 5 || meta.RenewalBudgetWei.Cmp(amount) != 0 {
+		t.Fatalf("unexpected renewal settings: blocks=%d budget=%v", meta.AutoRenewBlocks, meta.RenewalBudgetWei)
+	}
+	if st.GetBalance(owner).Sign() != 0 {
+		t.Fatalf("owner balance after fund: want 0, got %v", st.GetBalance(owner))
+	}
+	if want := new(big.Int).Add(registryBefore, amount); st.GetBalance(params.LeaseRegistryAddress).Cmp(want) != 0 {
+		t.Fatalf("registry balance: want %v, got %v", want, st.GetBalance(params.LeaseRegistryAddress))
+	}
+
+	withdraw := WithdrawRenewalAction{ContractAddr: contractAddr}
+	if err := executeLeaseAction(t, st, other, 13, cfg, sysaction.ActionLeaseWithdrawRenewal, withdraw); !errors.Is(err, ErrLeaseOwnerOnly) {
+		t.Fatalf("withdraw by non-owner: want %v, got %v", ErrLeaseOwnerOnly, err)
+	}
+	if err := executeLeaseAction(t, st, owner, 13, cfg, sysaction.ActionLeaseWithdrawRenewal, withdraw); err != nil {
+		t.Fatalf("withdraw: %v", err)
+	}
+	meta, _ = ReadMeta(st, contractAddr)
+	if meta.AutoRenewBlocks != 0 || meta.RenewalBudgetWei.Sign() != 0 {
+		t.Fatalf("withdraw should disable auto-renewal, got blocks=%d budget=%v", meta.AutoRenewBlocks, meta.RenewalBudgetWei)
+	}
+	if st.GetBalance(owner).Cmp(amount) != 0 {
+		t.Fatalf("owner balance after withdraw: want %v, got %v", amount, st.GetBalance(owner))
+	}
+	if st.GetBalance(params.LeaseRegistryAddress).Cmp(registryBefore) != 0 {
+		t.Fatalf("registry balance after withdraw: want %v, got %v", registryBefore, st.GetBalance(params.LeaseRegistryAddress))
+	}
+}
+
+func TestFundRenewalQueuesOnce(t *testing.T) {
+	st := newTestState()
+	cfg := leaseTestConfig(10)
+	owner := testAddr(0x94)
+	contractAddr := testAddr(0x95)
+	activateLeaseForPruneTest(t, st, contractAddr, owner, cfg)
+	st.AddBalance(owner, big.NewInt(3))
+
+	fund := FundRenewalAction{ContractAddr: contractAddr, AmountWei: big.NewInt(1), RenewBlocks: 5}
+	for i := 0; i < 3; i++ {
+		if err := executeLeaseAction(t, st, owner, 12, cfg, sysaction.ActionLeaseFundRenewal, fund); err != nil {
+			t.Fatalf("fund %d: %v", i, err)
+		}
+	}
+	// A zero amount that leaves the renewal period as it is does nothing.
+	fund.AmountWei = new(big.Int)
+	if err := executeLeaseAction(t, st, owner, 12, cfg, sysaction.ActionLeaseFundRenewal, fund); !errors.Is(err, ErrLeaseInvalidAmount) {
+		t.Fatalf("zero fund: want %v, got %v", ErrLeaseInvalidAmount, err)
+	}
+	fund.RenewBlocks = 6
+	if err := executeLeaseAction(t, st, owner, 12, cfg, sysaction.ActionLeaseFundRenewal, fund); err != nil {
+		t.Fatalf("zero fund changing period: %v", err)
+	}
+	meta, _ := ReadMeta(st, contractAddr)
+	epoch := RenewalEpoch(meta.ExpireAtBlock, EpochLength(cfg))
+	if got := readUint64(st, renewCountSlot(epoch)); got != 1 {
+		t.Fatalf("renewal queue length: want 1, got %d", got)
+	}
+
+	// Once the sweep has visited the entry the lease may be queued again.
+	RunPruneSweep(st, epoch*EpochLength(cfg), cfg)
+	if got := readUint64(st, renewQueuedSlot(contractAddr)); got != 0 {
+		t.Fatalf("queued marker after sweep: want 0, got %d", got)
+	}
+}
+
+func TestRenewalSweepRenewsFromBudget(t *testing.T) {
+	st := newTestState()
+	cfg := leaseTestConfig(10)
+	owner := testAddr(0xa1)
+	contractAddr := testAddr(0xa2)
+	activateLeaseForPruneTest(t, st, contractAddr, owner, cfg)
+
+	deposit, err := DepositFor(32, 5)
+	if err != nil {
+		t.Fatalf("DepositFor: %v", err)
+	}
+	budget := new(big.Int).Mul(deposit, big.NewInt(2))
+	st.AddBalance(owner, budget)
+	fund := FundRenewalAction{ContractAddr: contractAddr, AmountWei: budget, RenewBlocks: 5}
+	if err := executeLeaseAction(t, st, owner, 10, cfg, sysaction.ActionLeaseFundRenewal, fund); err != nil {
+		t.Fatalf("fund: %v", err)
+	}
+
+	// Each boundary before expiry renews the lease from the budget.
+	RunPruneSweep(st, 10, cfg)
+	meta, _ := ReadMeta(st, contractAddr)
+	if meta.ExpireAtBlock != 20 {
+		t.Fatalf("ExpireAtBlock after first sweep: want 20, got %d", meta.ExpireAtBlock)
+	}
+	if meta.RenewalBudgetWei.Cmp(deposit) != 0 {
+		t.Fatalf("budget after first sweep: want %v, got %v", deposit, meta.RenewalBudgetWei)
+	}
+	if want := new(big.Int).Mul(deposit, big.NewInt(2)); meta.DepositWei.Cmp(want) != 0 {
+		t.Fatalf("DepositWei after first sweep: want %v, got %v", want, meta.DepositWei)
+	}
+
+	RunPruneSweep(st, 20, cfg)
+	meta, _ = ReadMeta(st, contractAddr)
+	if meta.ExpireAtBlock != 25 || meta.RenewalBudgetWei.Sign() != 0 {
+		t.Fatalf("after second sweep: expire=%d budget=%v", meta.ExpireAtBlock, meta.RenewalBudgetWei)
+	}
+
+	// An exhausted budget leaves the lease to expire.
+	RunPruneSweep(st, 30, cfg)
+	meta, _ = ReadMeta(st, contractAddr)
+	if meta.ExpireAtBlock != 25 {
+		t.Fatalf("exhausted budget should not renew, got expire=%d", meta.ExpireAtBlock)
+	}
+	if got := EffectiveStatus(meta, 30, cfg); got != StatusFrozen {
+		t.Fatalf("status at 30: want %v, got %v", StatusFrozen, got)
+	}
+	if warnings := Warnings(meta, 30, cfg); len(warnings) != 2 {
+		t.Fatalf("want frozen and budget warnings, got %q", warnings)
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	cfg := leaseTestConfig(10)
+	meta, err := NewMeta(testAddr(0xb1), 0, 100, 32, big.NewInt(1), cfg)
+	if err != nil {
+		t.Fatalf("NewMeta: %v", err)
+	}
+
+	if warnings := Warnings(meta, 10, cfg); warnings != nil {
+		t.Fatalf("no warning expected far from expiry, got %q", warnings)
+	}
+	if warnings := Warnings(meta, 85, cfg); len(warnings) != 1 || warnings[0] != "lease expires in 15 blocks" {
+		t.Fatalf("unexpected expiry warnings: %q", warnings)
+	}
+	meta.AutoRenewBlocks = 50
+	if warnings := Warnings(meta, 85, cfg); len(warnings) != 2 {
+		t.Fatalf("want expiry and budget warnings, got %q", warnings)
+	}
+	if warnings := Warnings(meta, meta.GraceUntilBlock, cfg); warnings != nil {
+		t.Fatalf("no warning expected once expired, got %q", warnings)
+	}
+}
+
+func TestHandleCloseRefundsRenewalBudget(t *testing.T) {
+	st := newTestState()
+	cfg := leaseTestConfig(10)
+	owner := testAddr(0xc1)
+	contractAddr := testAddr(0xc2)
+	meta := activateLeaseForPruneTest(t, st, contractAddr, owner, cfg)
+
+	amount := big.NewInt(777)
+	st.AddBalance(owner, amount)
+	fund := FundRenewalAction{ContractAddr: contractAddr, AmountWei: amount, RenewBlocks: 5}
+	if err := executeLeaseAction(t, st, owner, 11, cfg, sysaction.ActionLeaseFundRenewal, fund); err != nil {
+		t.Fatalf("fund: %v", err)
+	}
+	if err := executeLeaseAction(t, st, owner, 12, cfg, sysaction.ActionLeaseClose, CloseAction{ContractAddr: contractAddr}); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	want := new(big.Int).Add(amount, RefundFor(meta.DepositWei))
+	if st.GetBalance(owner).Cmp(want) != 0 {
+		t.Fatalf("owner balance after close: want %v, got %v", want, st.GetBalance(owner))
+	}
+	closed, _ := ReadMeta(st, contractAddr)
+	if closed.AutoRenewBlocks != 0 || closed.RenewalBudgetWei.Sign() != 0 {
+		t.Fatalf("close should clear auto-renewal, got blocks=%d budget=%v", closed.AutoRenewBlocks, closed.RenewalBudgetWei)
+	}
+}
@@ -0,0 +1,219 @@
+package lease
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/big"
+	"sort"
+
+	"github.com/tos-network/gtos/common"
+	vmtypes "github.com/tos-network/gtos/core/vmtypes"
+	"github.com/tos-network/gtos/crypto"
+	"github.com/tos-network/gtos/params"
+)
+
+// slotRentPerBlock returns the rent, in wei, of one storage slot for one
+// block, rounded up. Rent is always a whole multiple of it, so RentFor and
+// RentRunway round the same way and invert each other.
+func slotRentPerBlock() *big.Int {
+	rate := new(big.Int).SetUint64(params.LeaseStorageSlotBytes * params.LeaseDepositReferenceByteGas)
+	rate.Mul(rate, big.NewInt(params.TxPriceTomi))
+	referenceBlocks := new(big.Int).SetUint64(params.LeaseReferenceBlocks)
+	rate.Add(rate, new(big.Int).Sub(referenceBlocks, big.NewInt(1)))
+	return rate.Quo(rate, referenceBlocks)
+}
+
+// RentFor returns the storage rent, in wei, of holding slots storage slots
+// for blocks blocks.
+func RentFor(slots uint64, blocks uint64) *big.Int {
+	rent := slotRentPerBlock()
+	rent.Mul(rent, new(big.Int).SetUint64(slots))
+	return rent.Mul(rent, new(big.Int).SetUint64(blocks))
+}
+
+// RentRunway returns how many whole blocks deposit pays the rent of slots
+// for. It returns math.MaxUint64 when no rent is due.
+func RentRunway(deposit *big.Int, slots uint64) uint64 {
+	if slots == 0 {
+		return math.MaxUint64
+	}
+	if deposit == nil || deposit.Sign() <= 0 {
+		return 0
+	}
+	rate := slotRentPerBlock()
+	rate.Mul(rate, new(big.Int).SetUint64(slots))
+	blocks := new(big.Int).Quo(deposit, rate)
+	if !blocks.IsUint64() {
+		return math.MaxUint64
+	}
+	return blocks.Uint64()
+}
+
+// RenewalCost returns the deposit extending meta's lease by blocks costs: the
+// code deposit plus the rent of its current storage for that period.
+func RenewalCost(meta Meta, blocks uint64) (*big.Int, error) {
+	deposit, err := DepositFor(meta.CodeBytes, blocks)
+	if err != nil {
+		return nil, err
+	}
+	return deposit.Add(deposit, RentFor(meta.StorageSlots, blocks)), nil
+}
+
+func seedCountSlot() common.Hash {
+	return common.BytesToHash(crypto.Keccak256([]byte("lease\x00seed_count")))
+}
+
+func seedCursorSlot() common.Hash {
+	return common.BytesToHash(crypto.Keccak256([]byte("lease\x00seed_cursor")))
+}
+
+func seedEntrySlot(seq uint64) common.Hash {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], seq)
+	return common.BytesToHash(crypto.Keccak256(append([]byte("lease\x00seed_entry\x00"), buf[:]...)))
+}
+
+func seedQueuedSlot(addr common.Address) common.Hash {
+	return common.BytesToHash(crypto.Keccak256(append([]byte("lease\x00seed_queued\x00"), addr.Bytes()...)))
+}
+
+// queueSeed queues a lease created before storage rent for RunSeedSweep,
+// once.
+func queueSeed(db vmtypes.StateDB, addr common.Address) {
+	if readUint64(db, seedQueuedSlot(addr)) != 0 {
+		return
+	}
+	count := readUint64(db, seedCountSlot())
+	writeAddress(db, seedEntrySlot(count), addr)
+	writeUint64(db, seedCountSlot(), count+1)
+	writeUint64(db, seedQueuedSlot(addr), 1)
+}
+
+// RunSeedSweep counts, at each epoch boundary, the storage of up to
+// LeaseStorageSeedPerSweep queued leases created before storage rent, so that
+// from then on they pay rent on all of it. It must run before ChargeStorage
+// applies the block's slot deltas: the count is taken from the contract's
+// storage trie as of the last state root, the base those deltas are taken
+// against.
+func RunSeedSweep(db vmtypes.StateDB, currentBlock uint64, chainConfig *params.ChainConfig) {
+	epochLength := EpochLength(chainConfig)
+	if epochLength == 0 || currentBlock == 0 || currentBlock%epochLength != 0 {
+		return
+	}
+	runSeedSweep(db, currentBlock, chainConfig, params.LeaseStorageSeedPerSweep)
+}
+
+func runSeedSweep(db vmtypes.StateDB, currentBlock uint64, chainConfig *params.ChainConfig, budget uint64) {
+	count := readUint64(db, seedCountSlot())
+	cursor := readUint64(db, seedCursorSlot())
+	for end := cursor + budget; cursor < count && cursor < end; cursor++ {
+		addr := readAddress(db, seedEntrySlot(cursor))
+		if meta, ok := ReadMeta(db, addr); ok && !meta.StorageSeeded {
+			seedStorage(db, addr, &meta, currentBlock, chainConfig)
+		}
+		db.SetState(params.LeaseRegistryAddress, seedQueuedSlot(addr), common.Hash{})
+	}
+	if cursor >= count {
+		db.SetState(params.LeaseRegistryAddress, seedCountSlot(), common.Hash{})
+		db.SetState(params.LeaseRegistryAddress, seedCursorSlot(), common.Hash{})
+		return
+	}
+	writeUint64(db, seedCursorSlot(), cursor)
+}
+
+// seedStorage charges the rent a lease created before storage rent has
+// accrued on the slots it changed since, then replaces that count with its
+// whole storage and caps the expiry at the new footprint.
+func seedStorage(db vmtypes.StateDB, addr common.Address, meta *Meta, currentBlock uint64, chainConfig *params.ChainConfig) {
+	ChargeRent(db, addr, meta, currentBlock)
+	var slots uint64
+	db.ForEachStorage(addr, func(common.Hash, common.Hash) bool {
+		slots++
+		return true
+	})
+	meta.StorageSlots = slots
+	meta.StorageSeeded = true
+	if CapExpiry(meta, currentBlock, chainConfig) {
+		ScheduleMeta(db, addr, meta, chainConfig)
+		ScheduleRenewal(db, addr, *meta, currentBlock/EpochLength(chainConfig), chainConfig)
+	}
+	WriteMeta(db, addr, *meta)
+}
+
+// ChargeRent deducts from meta's deposit the storage rent accrued since
+// RentPaidBlock by the lease contract at addr. A lease created before storage
+// rent is charged only for the slots it changed since, and is queued for
+// RunSeedSweep to count the rest. Rent accrues only until the lease expires;
+// the deducted amount stays in the lease registry and is never refunded.
+func ChargeRent(db vmtypes.StateDB, addr common.Address, meta *Meta, currentBlock uint64) {
+	if !meta.StorageSeeded {
+		queueSeed(db, addr)
+	}
+	end := currentBlock
+	if meta.ExpireAtBlock < end {
+		end = meta.ExpireAtBlock
+	}
+	if end > meta.RentPaidBlock {
+		rent := RentFor(meta.StorageSlots, end-meta.RentPaidBlock)
+		if meta.DepositWei == nil || rent.Cmp(meta.DepositWei) >= 0 {
+			meta.DepositWei = new(big.Int)
+		} else {
+			meta.DepositWei = new(big.Int).Sub(meta.DepositWei, rent)
+		}
+	}
+	if currentBlock > meta.RentPaidBlock {
+		meta.RentPaidBlock = currentBlock
+	}
+}
+
+// CapExpiry brings an active lease's expiry forward to the block its deposit
+// stops covering storage rent, freezing the contract once the deposit runs
+// dry. Rent must be charged up to currentBlock first. It reports whether the
+// expiry changed, in which case the lease must be rescheduled.
+func CapExpiry(meta *Meta, currentBlock uint64, chainConfig *params.ChainConfig) bool {
+	if meta.ExpireAtBlock <= currentBlock {
+		return false
+	}
+	runway := RentRunway(meta.DepositWei, meta.StorageSlots)
+	if runway >= meta.ExpireAtBlock-currentBlock {
+		return false
+	}
+	meta.ExpireAtBlock = currentBlock + runway
+	meta.GraceUntilBlock = meta.ExpireAtBlock + GraceBlocks(chainConfig)
+	return true
+}
+
+// ChargeStorage records the storage slots each lease contract in deltas
+// created or freed during the block, charging the rent accrued at the old
+// footprint first and capping the expiry at the new one. Called once per
+// block, before the prune sweep, with the block's net slot changes.
+func ChargeStorage(db vmtypes.StateDB, deltas map[common.Address]int64, currentBlock uint64, chainConfig *params.ChainConfig) {
+	addrs := make([]common.Address, 0, len(deltas))
+	for addr, delta := range deltas {
+		if delta != 0 && addr != params.LeaseRegistryAddress {
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Slice(addrs, func(i, j int) bool { return bytes.Compare(addrs[i][:], addrs[j][:]) < 0 })
+
+	for _, addr := range addrs {
+		meta, ok := ReadMeta(db, addr)
+		if !ok {
+			continue
+		}
+		ChargeRent(db, addr, &meta, currentBlock)
+		if delta := deltas[addr]; delta > 0 {
+			meta.StorageSlots += uint64(delta)
+		} else if freed := uint64(-delta); freed < meta.StorageSlots {
+			meta.StorageSlots -= freed
+		} else {
+			meta.StorageSlots = 0
+		}
+		if CapExpiry(&meta, currentBlock, chainConfig) {
+			ScheduleMeta(db, addr, &meta, chainConfig)
+			ScheduleRenewal(db, addr, meta, currentBlock/EpochLength(chainConfig), chainConfig)
+		}
+		WriteMeta(db, addr, meta)
+	}
+}
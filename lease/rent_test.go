@@ -0,0 +1,194 @@
+package lease
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/params"
+	"github.com/tos-network/gtos/sysaction"
+)
+
+func TestRentForAndRunway(t *testing.T) {
+	// The per-block rate is the reference price rounded up to a whole wei.
+	reference := new(big.Int).SetUint64(params.LeaseStorageSlotBytes * params.LeaseDepositReferenceByteGas)
+	reference.Mul(reference, big.NewInt(params.TxPriceTomi))
+	rate := RentFor(1, 1)
+	if floor := new(big.Int).Quo(reference, new(big.Int).SetUint64(params.LeaseReferenceBlocks)); new(big.Int).Sub(rate, floor).Cmp(big.NewInt(1)) != 0 {
+		t.Fatalf("RentFor(1, 1): want %v + 1, got %v", floor, rate)
+	}
+	if got := RentFor(3, 7); got.Cmp(new(big.Int).Mul(rate, big.NewInt(21))) != 0 {
+		t.Fatalf("RentFor(3, 7): want 21 * %v, got %v", rate, got)
+	}
+	if got := RentFor(0, 100); got.Sign() != 0 {
+		t.Fatalf("RentFor without slots: want 0, got %v", got)
+	}
+	if got := RentRunway(RentFor(3, 1000), 3); got != 1000 {
+		t.Fatalf("RentRunway: want 1000, got %d", got)
+	}
+	almost := new(big.Int).Sub(RentFor(3, 1000), big.NewInt(1))
+	if got := RentRunway(almost, 3); got != 999 {
+		t.Fatalf("RentRunway short of 1000 blocks: want 999, got %d", got)
+	}
+	if got := RentRunway(big.NewInt(1), 0); got != math.MaxUint64 {
+		t.Fatalf("RentRunway without slots: want unlimited, got %d", got)
+	}
+	if got := RentRunway(new(big.Int), 1); got != 0 {
+		t.Fatalf("RentRunway without deposit: want 0, got %d", got)
+	}
+}
+
+func TestChargeStorageFreezesWhenDepositRunsDry(t *testing.T) {
+	st := newTestState()
+	cfg := leaseTestConfig(10)
+	owner := testAddr(0xf1)
+	contractAddr := testAddr(0xf2)
+
+	deposit := RentFor(2, 100)
+	if _, err := Activate(st, contractAddr, owner, 10, 1000, 32, deposit, cfg); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	st.AddBalance(params.LeaseRegistryAddress, deposit)
+
+	// Two slots written at block 20 leave the deposit 100 blocks of rent.
+	ChargeStorage(st, map[common.Address]int64{contractAddr: 2, testAddr(0xf3): 5}, 20, cfg)
+	meta, _ := ReadMeta(st, contractAddr)
+	if meta.StorageSlots != 2 || meta.RentPaidBlock != 20 {
+		t.Fatalf("after charge: slots=%d paid=%d", meta.StorageSlots, meta.RentPaidBlock)
+	}
+	if meta.ExpireAtBlock != 120 || meta.GraceUntilBlock != 130 {
+		t.Fatalf("expiry should be capped at the rent runway, got expire=%d grace=%d", meta.ExpireAtBlock, meta.GraceUntilBlock)
+	}
+	if meta.ScheduledPruneEpoch != PruneEpoch(meta.GraceUntilBlock, 10) {
+		t.Fatalf("lease should be rescheduled, got epoch %d", meta.ScheduledPruneEpoch)
+	}
+	if err := CheckCallable(st, contractAddr, 121, cfg); !errors.Is(err, ErrLeaseFrozen) {
+		t.Fatalf("contract with a dry deposit: want %v, got %v", ErrLeaseFrozen, err)
+	}
+
+	// Freeing a slot charges the rent so far and halves the rate.
+	ChargeStorage(st, map[common.Address]int64{contractAddr: -1}, 70, cfg)
+	meta, _ = ReadMeta(st, contractAddr)
+	if want := new(big.Int).Sub(deposit, RentFor(2, 50)); meta.DepositWei.Cmp(want) != 0 {
+		t.Fatalf("deposit after 50 blocks of rent: want %v, got %v", want, meta.DepositWei)
+	}
+	if meta.StorageSlots != 1 || meta.ExpireAtBlock != 120 {
+		t.Fatalf("after freeing a slot: slots=%d expire=%d", meta.StorageSlots, meta.ExpireAtBlock)
+	}
+
+	// Slots freed beyond the tracked footprint are ignored.
+	ChargeStorage(st, map[common.Address]int64{contractAddr: -3}, 80, cfg)
+	meta, _ = ReadMeta(st, contractAddr)
+	if meta.StorageSlots != 0 {
+		t.Fatalf("slots should not underflow, got %d", meta.StorageSlots)
+	}
+}
+
+func TestRenewChargesStorageRent(t *testing.T) {
+	st := newTestState()
+	cfg := leaseTestConfig(10)
+	owner := testAddr(0xf4)
+	contractAddr := testAddr(0xf5)
+
+	deposit := RentFor(4, 30)
+	if _, err := Activate(st, contractAddr, owner, 10, 1000, 32, deposit, cfg); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	st.AddBalance(params.LeaseRegistryAddress, deposit)
+	ChargeStorage(st, map[common.Address]int64{contractAddr: 4}, 10, cfg)
+	meta, _ := ReadMeta(st, contractAddr)
+	if meta.ExpireAtBlock != 40 {
+		t.Fatalf("capped expiry: want 40, got %d", meta.ExpireAtBlock)
+	}
+
+	// Renewing the frozen lease pays for the code and the storage it holds.
+	cost, err := RenewalCost(meta, 50)
+	if err != nil {
+		t.Fatalf("RenewalCost: %v", err)
+	}
+	codeDeposit, _ := DepositFor(32, 50)
+	if want := new(big.Int).Add(codeDeposit, RentFor(4, 50)); cost.Cmp(want) != 0 {
+		t.Fatalf("RenewalCost: want %v, got %v", want, cost)
+	}
+	st.AddBalance(owner, cost)
+	renew := RenewAction{ContractAddr: contractAddr, DeltaBlocks: 50}
+	if err := executeLeaseAction(t, st, owner, 45, cfg, sysaction.ActionLeaseRenew, renew); err != nil {
+		t.Fatalf("renew: %v", err)
+	}
+	meta, _ = ReadMeta(st, contractAddr)
+	if meta.ExpireAtBlock != 95 || meta.RentPaidBlock != 45 {
+		t.Fatalf("after renew: expire=%d paid=%d", meta.ExpireAtBlock, meta.RentPaidBlock)
+	}
+	if got := EffectiveStatus(meta, 45, cfg); got != StatusActive {
+		t.Fatalf("status after renew: want %v, got %v", StatusActive, got)
+	}
+
+	// Closing charges the rent accrued since the renewal before refunding.
+	if err := executeLeaseAction(t, st, owner, 55, cfg, sysaction.ActionLeaseClose, CloseAction{ContractAddr: contractAddr}); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	remaining := new(big.Int).Sub(meta.DepositWei, RentFor(4, 10))
+	if want := RefundFor(remaining); st.GetBalance(owner).Cmp(want) != 0 {
+		t.Fatalf("close refund: want %v, got %v", want, st.GetBalance(owner))
+	}
+}
+
+func TestSeedSweepCountsLeasesCreatedBeforeRent(t *testing.T) {
+	st := newTestState()
+	cfg := leaseTestConfig(10)
+	owner := testAddr(0xf6)
+	contractAddr := testAddr(0xf7)
+	otherAddr := testAddr(0xf8)
+
+	deposit := RentFor(10, 1000)
+	for _, addr := range []common.Address{contractAddr, otherAddr} {
+		if _, err := Activate(st, addr, owner, 10, 5000, 32, deposit, cfg); err != nil {
+			t.Fatalf("Activate: %v", err)
+		}
+		st.AddBalance(params.LeaseRegistryAddress, deposit)
+		// A lease from before storage rent: three slots held, none tracked.
+		meta, _ := ReadMeta(st, addr)
+		meta.StorageSlots, meta.RentPaidBlock, meta.StorageSeeded = 0, 0, false
+		WriteMeta(st, addr, meta)
+		for i := byte(1); i <= 3; i++ {
+			st.SetState(addr, common.Hash{i}, common.Hash{0xff})
+		}
+	}
+	st.IntermediateRoot(false)
+
+	// Until it is seeded, rent is charged on changed slots only.
+	ChargeStorage(st, map[common.Address]int64{contractAddr: 1, otherAddr: 1}, 500, cfg)
+	st.SetState(contractAddr, common.Hash{4}, common.Hash{0xff})
+	st.IntermediateRoot(false)
+	meta, _ := ReadMeta(st, contractAddr)
+	if meta.StorageSlots != 1 || meta.RentPaidBlock != 500 || meta.StorageSeeded || meta.DepositWei.Cmp(deposit) != 0 {
+		t.Fatalf("before seeding: slots=%d paid=%d seeded=%v deposit=%v", meta.StorageSlots, meta.RentPaidBlock, meta.StorageSeeded, meta.DepositWei)
+	}
+
+	// The sweep only runs on epoch boundaries and seeds within its budget.
+	RunSeedSweep(st, 695, cfg)
+	runSeedSweep(st, 700, cfg, 1)
+	meta, _ = ReadMeta(st, contractAddr)
+	charged := new(big.Int).Sub(deposit, RentFor(1, 200))
+	if meta.StorageSlots != 4 || meta.RentPaidBlock != 700 || !meta.StorageSeeded || meta.DepositWei.Cmp(charged) != 0 {
+		t.Fatalf("after seeding: slots=%d paid=%d seeded=%v deposit=%v", meta.StorageSlots, meta.RentPaidBlock, meta.StorageSeeded, meta.DepositWei)
+	}
+	if other, _ := ReadMeta(st, otherAddr); other.StorageSeeded {
+		t.Fatalf("second lease seeded past the sweep budget")
+	}
+	RunSeedSweep(st, 710, cfg)
+	if other, _ := ReadMeta(st, otherAddr); !other.StorageSeeded || other.StorageSlots != 3 {
+		t.Fatalf("second lease after next sweep: seeded=%v slots=%d", other.StorageSeeded, other.StorageSlots)
+	}
+	if got := readUint64(st, seedCountSlot()); got != 0 {
+		t.Fatalf("seed queue length after draining: want 0, got %d", got)
+	}
+
+	ChargeStorage(st, map[common.Address]int64{contractAddr: -1}, 800, cfg)
+	meta, _ = ReadMeta(st, contractAddr)
+	if want := new(big.Int).Sub(charged, RentFor(4, 100)); meta.StorageSlots != 3 || meta.DepositWei.Cmp(want) != 0 {
+		t.Fatalf("after charge: slots=%d deposit=%v, want 3 and %v", meta.StorageSlots, meta.DepositWei, want)
+	}
+}
@@ -98,6 +98,12 @@ func ReadMeta(db vmtypes.StateDB, addr common.Address) (Meta, bool) {
 		DepositWei:          readBig(db, leaseSlot(addr, "deposit_wei")),
 		ScheduledPruneEpoch: readUint64(db, leaseSlot(addr, "scheduled_prune_epoch")),
 		ScheduledPruneSeq:   readUint64(db, leaseSlot(addr, "scheduled_prune_seq")),
+		AutoRenewBlocks:     readUint64(db, leaseSlot(addr, "auto_renew_blocks")),
+		RenewalBudgetWei:    readBig(db, leaseSlot(addr, "renewal_budget_wei")),
+		PendingOwner:        readAddress(db, leaseSlot(addr, "pending_owner")),
+		StorageSlots:        readUint64(db, leaseSlot(addr, "storage_slots")),
+		RentPaidBlock:       readUint64(db, leaseSlot(addr, "rent_paid_block")),
+		StorageSeeded:       readUint64(db, leaseSlot(addr, "storage_seeded")) != 0,
 	}
 	return meta, true
 }
@@ -115,9 +121,19 @@ func WriteMeta(db vmtypes.StateDB, addr common.Address, meta Meta) {
 	writeBig(db, leaseSlot(addr, "deposit_wei"), meta.DepositWei)
 	writeUint64(db, leaseSlot(addr, "scheduled_prune_epoch"), meta.ScheduledPruneEpoch)
 	writeUint64(db, leaseSlot(addr, "scheduled_prune_seq"), meta.ScheduledPruneSeq)
+	writeUint64(db, leaseSlot(addr, "auto_renew_blocks"), meta.AutoRenewBlocks)
+	writeBig(db, leaseSlot(addr, "renewal_budget_wei"), meta.RenewalBudgetWei)
+	writeAddress(db, leaseSlot(addr, "pending_owner"), meta.PendingOwner)
+	writeUint64(db, leaseSlot(addr, "storage_slots"), meta.StorageSlots)
+	writeUint64(db, leaseSlot(addr, "rent_paid_block"), meta.RentPaidBlock)
+	seeded := uint64(0)
+	if meta.StorageSeeded {
+		seeded = 1
+	}
+	writeUint64(db, leaseSlot(addr, "storage_seeded"), seeded)
 }
 
-// ClearMeta removes lease metadata for a pruned contract.
+// ClearMeta removes lease metadata for a pruned or permanent contract.
 func ClearMeta(db vmtypes.StateDB, addr common.Address) {
 	for _, field := range []string{
 		"mode",
@@ -129,6 +145,12 @@ func ClearMeta(db vmtypes.StateDB, addr common.Address) {
 		"deposit_wei",
 		"scheduled_prune_epoch",
 		"scheduled_prune_seq",
+		"auto_renew_blocks",
+		"renewal_budget_wei",
+		"pending_owner",
+		"storage_slots",
+		"rent_paid_block",
+		"storage_seeded",
 	} {
 		db.SetState(params.LeaseRegistryAddress, leaseSlot(addr, field), common.Hash{})
 	}
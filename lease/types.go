@@ -27,6 +27,12 @@ type Meta struct {
 	DepositWei          *big.Int
 	ScheduledPruneEpoch uint64
 	ScheduledPruneSeq   uint64
+	AutoRenewBlocks     uint64         // blocks added per auto-renewal; 0 = disabled
+	RenewalBudgetWei    *big.Int       // prepaid budget auto-renewals draw from
+	PendingOwner        common.Address // proposed owner awaiting acceptance
+	StorageSlots        uint64         // storage slots the contract holds
+	RentPaidBlock       uint64         // block storage rent is charged up to
+	StorageSeeded       bool           // StorageSlots counts all storage, not only changes
 }
 
 // Tombstone permanently marks a previously-pruned lease address.
@@ -48,6 +54,40 @@ type RenewAction struct {
 	DeltaBlocks  uint64         `json:"delta_blocks"`
 }
 
+// FundRenewalAction is the system-action payload for LEASE_FUND_RENEWAL. It
+// moves AmountWei from the owner into the lease's renewal budget and, when
+// RenewBlocks is non-zero, sets the blocks each auto-renewal adds.
+type FundRenewalAction struct {
+	ContractAddr common.Address `json:"contract_addr"`
+	AmountWei    *big.Int       `json:"amount_wei"`
+	RenewBlocks  uint64         `json:"renew_blocks"`
+}
+
+// WithdrawRenewalAction is the system-action payload for
+// LEASE_WITHDRAW_RENEWAL. It refunds the renewal budget and disables
+// auto-renewal.
+type WithdrawRenewalAction struct {
+	ContractAddr common.Address `json:"contract_addr"`
+}
+
+// TransferOwnerAction is the system-action payload for LEASE_TRANSFER_OWNER.
+// It proposes NewOwner, who takes over once it sends LEASE_ACCEPT_OWNER; a
+// zero NewOwner cancels a pending proposal.
+type TransferOwnerAction struct {
+	ContractAddr common.Address `json:"contract_addr"`
+	NewOwner     common.Address `json:"new_owner"`
+}
+
+// AcceptOwnerAction is the system-action payload for LEASE_ACCEPT_OWNER.
+type AcceptOwnerAction struct {
+	ContractAddr common.Address `json:"contract_addr"`
+}
+
+// MakePermanentAction is the system-action payload for LEASE_MAKE_PERMANENT.
+type MakePermanentAction struct {
+	ContractAddr common.Address `json:"contract_addr"`
+}
+
 // CloseAction is the system-action payload for LEASE_CLOSE.
 type CloseAction struct {
 	ContractAddr common.Address `json:"contract_addr"`
@@ -66,4 +106,8 @@ var (
 	ErrLeaseValueNotAllowed     = errors.New("lease: non-zero tx value is not allowed for this action")
 	ErrLeaseInsufficientDeposit = errors.New("lease: insufficient balance for deposit")
 	ErrLeaseRegistryInvariant   = errors.New("lease: registry balance invariant violated")
+	ErrLeaseInvalidAmount       = errors.New("lease: invalid renewal budget amount")
+	ErrLeaseAutoRenewDisabled   = errors.New("lease: auto-renewal requires renew_blocks")
+	ErrLeaseSameOwner           = errors.New("lease: new owner is already the lease owner")
+	ErrLeaseNotPendingOwner     = errors.New("lease: sender is not the pending lease owner")
 )
@@ -72,7 +72,9 @@ var (
 	// AllDPoSProtocolChanges contains every protocol change proposal introduced
 	// and accepted by the TOS core developers into the DPoS consensus.
 	AllDPoSProtocolChanges = &ChainConfig{
-		ChainID: big.NewInt(1337),
+		ChainID:            big.NewInt(1337),
+		SponsorPolicyBlock: big.NewInt(0),
+		TNSExpiryBlock:     big.NewInt(0),
 		DPoS: &DPoSConfig{
 			PeriodMs:       DPoSBlockPeriodMs,
 			Epoch:          DPoSEpochLength,
@@ -180,6 +182,20 @@ type ChainConfig struct {
 	// the new binary before that block is reached.
 	ProtocolForks []uint64 `json:"protocolForks,omitempty"`
 
+	// SponsorPolicyBlock is the block from which a non-zero SponsorPolicyHash
+	// must name a registered sponsor policy that covers the transaction
+	// (nil => inactive, the hash is ignored as before). It changes which
+	// transactions are valid, so on a running network it must also be listed
+	// in ProtocolForks.
+	SponsorPolicyBlock *big.Int `json:"sponsorPolicyBlock,omitempty"`
+
+	// TNSExpiryBlock is the block from which TNS names are registered for a
+	// number of years at length-based prices, and names registered earlier
+	// expire TNSGracePeriodBlocks after it unless renewed (nil => inactive,
+	// every name is permanent at the flat fee). It must also be listed in
+	// ProtocolForks on a running network.
+	TNSExpiryBlock *big.Int `json:"tnsExpiryBlock,omitempty"`
+
 	// Various consensus engines
 	DPoS *DPoSConfig `json:"dpos,omitempty"`
 }
@@ -368,6 +384,18 @@ func (c *ChainConfig) String() string {
 	return banner
 }
 
+// IsSponsorPolicy reports whether sponsor policies are enforced at the given
+// block number.
+func (c *ChainConfig) IsSponsorPolicy(num *big.Int) bool {
+	return c.SponsorPolicyBlock != nil && num != nil && num.Cmp(c.SponsorPolicyBlock) >= 0
+}
+
+// IsTNSExpiry reports whether TNS name expiry and length-based pricing are
+// active at the given block number.
+func (c *ChainConfig) IsTNSExpiry(num *big.Int) bool {
+	return c.TNSExpiryBlock != nil && num != nil && num.Cmp(c.TNSExpiryBlock) >= 0
+}
+
 // IsTerminalPoWBlock returns whether the given block is the last block of PoW stage.
 func (c *ChainConfig) IsTerminalPoWBlock(parentTotalDiff *big.Int, totalDiff *big.Int) bool {
 	if c.TerminalTotalDifficulty == nil {
@@ -502,6 +530,14 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 			}
 		}
 	}
+	if !configNumEqual(c.SponsorPolicyBlock, newcfg.SponsorPolicyBlock) &&
+		(c.IsSponsorPolicy(head) || newcfg.IsSponsorPolicy(head)) {
+		return newCompatError("sponsorPolicyBlock", c.SponsorPolicyBlock, newcfg.SponsorPolicyBlock)
+	}
+	if !configNumEqual(c.TNSExpiryBlock, newcfg.TNSExpiryBlock) &&
+		(c.IsTNSExpiry(head) || newcfg.IsTNSExpiry(head)) {
+		return newCompatError("tnsExpiryBlock", c.TNSExpiryBlock, newcfg.TNSExpiryBlock)
+	}
 	storedForks := appliedProtocolForks(c.ProtocolForks, head.Uint64())
 	newForks := appliedProtocolForks(newcfg.ProtocolForks, head.Uint64())
 	if storedFork, newFork := firstProtocolForkMismatch(storedForks, newForks); storedFork != nil || newFork != nil {
@@ -347,6 +347,34 @@ func TestCheckCompatible(t *testing.T) {
 				RewindTo:     19,
 			},
 		},
+		{
+			stored:  &ChainConfig{ChainID: big.NewInt(1)},
+			new:     &ChainConfig{ChainID: big.NewInt(1), SponsorPolicyBlock: big.NewInt(30)},
+			head:    25,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{ChainID: big.NewInt(1), SponsorPolicyBlock: big.NewInt(20)},
+			new:    &ChainConfig{ChainID: big.NewInt(1), SponsorPolicyBlock: big.NewInt(30)},
+			head:   25,
+			wantErr: &ConfigCompatError{
+				What:         "sponsorPolicyBlock",
+				StoredConfig: big.NewInt(20),
+				NewConfig:    big.NewInt(30),
+				RewindTo:     19,
+			},
+		},
+		{
+			stored: &ChainConfig{ChainID: big.NewInt(1), TNSExpiryBlock: big.NewInt(20)},
+			new:    &ChainConfig{ChainID: big.NewInt(1)},
+			head:   25,
+			wantErr: &ConfigCompatError{
+				What:         "tnsExpiryBlock",
+				StoredConfig: big.NewInt(20),
+				NewConfig:    nil,
+				RewindTo:     19,
+			},
+		},
 	}
 
 	for _, test := range tests {
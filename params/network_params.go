@@ -28,6 +28,14 @@ const (
 	// considered probably final and its rotated bits are calculated.
 	BloomConfirms = 256
 
+	// PrivTxIndexBlocks is the number of blocks a single priv transaction
+	// history index section covers.
+	PrivTxIndexBlocks uint64 = 16
+
+	// PrivTxIndexConfirms is the number of confirmation blocks before a priv
+	// transaction history section is indexed.
+	PrivTxIndexConfirms = 12
+
 	// CHTFrequency is the block frequency for creating CHTs
 	CHTFrequency = 32768
 
@@ -44,8 +44,8 @@ var (
 	// that accepts malicious-vote evidence submissions for checkpoint finality.
 	CheckpointSlashIndicatorAddress = common.HexToAddress("0x0000000000000000000000000000000000000000000000000000000000000109")
 
-	// SponsorRegistryAddress stores protocol-level sponsor nonce state for
-	// native sponsored transactions.
+	// SponsorRegistryAddress stores protocol-level sponsor nonce and sponsor
+	// policy state for native sponsored transactions.
 	SponsorRegistryAddress = common.HexToAddress("0x0000000000000000000000000000000000000000000000000000000000000110")
 
 	// GroupRegistryAddress stores on-chain Group registration and state commitment data.
@@ -71,6 +71,18 @@ var (
 	// fulfillment records composable with account policy and receipts.
 	SettlementRegistryAddress = common.HexToAddress("0x000000000000000000000000000000000000000000000000000000000000010F")
 
+	// JobMarketAddress stores agent-to-agent job records and holds escrowed
+	// job budgets and worker bonds.
+	JobMarketAddress = common.HexToAddress("0x0000000000000000000000000000000000000000000000000000000000000111")
+
+	// DisputeRegistryAddress stores dispute records and arbiter votes and
+	// holds dispute fees until resolution.
+	DisputeRegistryAddress = common.HexToAddress("0x0000000000000000000000000000000000000000000000000000000000000112")
+
+	// IntentPoolAddress stores submitted intents and solver bids and holds
+	// each intent's escrow until execution.
+	IntentPoolAddress = common.HexToAddress("0x0000000000000000000000000000000000000000000000000000000000000113")
+
 	// PackageRegistryAddress stores on-chain package publishing registry state
 	// (publisher records, package records, hash lookups).
 	PackageRegistryAddress = common.HexToAddress("0x0000000000000000000000000000000000000000000000000000000000000200")
@@ -92,8 +104,20 @@ var (
 	// AgentMinStake is the minimum stake required for AGENT_REGISTER.
 	AgentMinStake = new(big.Int).Mul(big.NewInt(1_000), big.NewInt(1e18)) // 1,000 TOS
 
-	// TNSRegistrationFee is the fee required for TNS_REGISTER (0.1 TOS).
+	// TNSRegistrationFee is the yearly TNS_REGISTER / TNS_RENEW fee for names
+	// of five or more characters (0.1 TOS).
 	TNSRegistrationFee = new(big.Int).Mul(big.NewInt(1e17), big.NewInt(1)) // 0.1 TOS (1e17 tomi)
+	// TNSFourCharFee and TNSThreeCharFee are the yearly fees for short names.
+	TNSFourCharFee  = new(big.Int).Mul(big.NewInt(1), big.NewInt(1e18))  // 1 TOS
+	TNSThreeCharFee = new(big.Int).Mul(big.NewInt(10), big.NewInt(1e18)) // 10 TOS
+
+	// DisputeFee is the fee required for DISPUTE_OPEN; it is shared by the
+	// arbiters who reveal their votes.
+	DisputeFee = new(big.Int).Mul(big.NewInt(1), big.NewInt(1e18)) // 1 TOS
+
+	// DisputeArbiterStakeCap is the stake at which an arbiter's chance of
+	// being drawn to a dispute panel stops growing.
+	DisputeArbiterStakeCap = new(big.Int).Mul(big.NewInt(10_000), big.NewInt(1e18)) // 10,000 TOS
 )
 
 // Account Abstraction constants.
@@ -115,6 +139,24 @@ const (
 	TaskMaxPerContract    uint64 = 100
 	TaskMinIntervalBlocks uint64 = 10
 	TaskMaxHorizonBlocks  uint64 = 1_000_000
+	// TaskMaxConditionChecks bounds how many conditional tasks the processor
+	// evaluates per block; the highest bids are checked first.
+	TaskMaxConditionChecks uint64 = 64
+	// TaskMaxWatched caps the global watch list of conditional tasks. When it
+	// is full a new conditional task must outbid the lowest watched bid,
+	// which is evicted and refunded.
+	TaskMaxWatched uint64 = 1024
+	// TaskConditionCheckGas is taken from a conditional task's deposit, at
+	// the task's gas price, every time the processor evaluates its condition.
+	TaskConditionCheckGas uint64 = 2_000
+	// TaskWatchFeeGas is the non-refundable fee, at the task's gas price, a
+	// conditional task pays to join the watch list.
+	TaskWatchFeeGas uint64 = 10_000
+	// TaskMaxCalldataBytes caps the calldata stored with a task.
+	TaskMaxCalldataBytes uint64 = 1024
+	// TaskAgeBoostTomi is the priority, in tomi per gas, a due task gains
+	// for every block it is deferred under TaskMaxPerBlock congestion.
+	TaskAgeBoostTomi uint64 = 1_000_000_000
 )
 
 // TNS / KYC / Referral constants.
@@ -129,6 +171,102 @@ const (
 	KYCCommitteeBit  uint8  = 1
 )
 
+// Referral reward constants.
+const (
+	// ReferralMaxProgramNameLen bounds the name a program is registered under.
+	ReferralMaxProgramNameLen = 64
+	// ReferralPayoutLevelGas is charged by tos.referral_payout per rate level.
+	ReferralPayoutLevelGas uint64 = 5_000
+)
+
+// KYC record lifetime constants.
+const (
+	// KYCDefaultValidityBlocks is how long a KYC_SET record stays active
+	// unless valid_blocks is given (one year at 360ms blocks).
+	KYCDefaultValidityBlocks uint64 = 87_600_000
+	// KYCMaxValidityBlocks bounds valid_blocks (three years).
+	KYCMaxValidityBlocks uint64 = 3 * KYCDefaultValidityBlocks
+	// KYCMaxJurisdictions is the most jurisdiction codes a record may carry;
+	// they are packed two bytes each into one slot.
+	KYCMaxJurisdictions = 16
+)
+
+// Private KYC credential constants.
+const (
+	// KYCTierProofGas is charged by tos.kyc_verify_tier, which verifies a
+	// Bulletproof range proof and a Schnorr signature.
+	KYCTierProofGas uint64 = 100_000
+	// KYCTierProofValidityBlocks is how long a KYC_PROVE_TIER attestation
+	// lasts (~24h at the default 360ms block interval).
+	KYCTierProofValidityBlocks uint64 = 240_000
+)
+
+// TNS name lifetime constants.
+const (
+	// TNSRegistrationPeriodBlocks is one registration year at the default
+	// 360ms block interval.
+	TNSRegistrationPeriodBlocks uint64 = 87_600_000
+	// TNSGracePeriodBlocks is how long an expired name stays reserved for
+	// renewal by its owner (~30 days).
+	TNSGracePeriodBlocks uint64 = 7_200_000
+	// TNSMaxRegistrationYears bounds how far ahead a name may be paid for.
+	TNSMaxRegistrationYears uint64 = 10
+	// TNSMaxRecordKeyLen and TNSMaxRecordLen bound resolver record keys and
+	// values in bytes.
+	TNSMaxRecordKeyLen = 32
+	TNSMaxRecordLen    = 256
+	// TNSRecordWordGas is charged by tos.tns_record per 32-byte word read,
+	// on top of TNSLoadGas.
+	TNSRecordWordGas uint64 = 100
+)
+
+// Job marketplace constants.
+const (
+	JobMaxHorizonBlocks uint64 = 1_000_000 // upper bound for deadline and review windows
+	JobMinReviewBlocks  uint64 = 100       // minimum client review window after submission
+	JobContestBlocks    uint64 = 1_000     // window in which a rejection can be disputed
+)
+
+// Dispute resolution constants.
+const (
+	DisputeCommitBlocks uint64 = 1_000 // arbiter commit window after DISPUTE_OPEN
+	DisputeRevealBlocks uint64 = 1_000 // arbiter reveal window after the commit window
+	DisputePanelSize    uint64 = 5     // maximum number of arbiters per dispute
+	DisputeSlashBps     uint64 = 1_000 // share of the losing agent's stake slashed to the winner
+
+	DisputeSeedDelayBlocks  uint64 = 1   // the panel seed is the hash of the block this far after DISPUTE_OPEN
+	DisputeDrawAttempts     uint64 = 256 // candidates sampled by DISPUTE_DRAW
+	DisputeNoRevealSlashBps uint64 = 500 // share of a silent panel arbiter's stake slashed to the opener
+)
+
+// Reputation v2 constants. Weights are in basis points (10_000 = 1.0).
+const (
+	ReputationHalfLifeBlocks    uint64 = 2_400_000 // capability scores halve every ~10 days
+	ReputationHistorySize       uint64 = 16        // recent ratings kept per (agent, capability)
+	ReputationBaseWeightBps     uint64 = 5_000     // weight of any authorized scorer
+	ReputationStakeWeightBps    uint64 = 2_500     // extra weight per AgentMinStake staked by the scorer
+	ReputationMaxStakeUnits     uint64 = 4         // cap on AgentMinStake units counted
+	ReputationScoreWeightBps    uint64 = 50        // extra weight per point of the scorer's own reputation
+	ReputationMaxScoreWeightBps uint64 = 5_000     // cap on the reputation-derived weight
+	ReputationConfidenceWeight  uint64 = 50_000    // decayed weight at which confidence reaches 50%
+
+	ReputationFeedbackWeightBps    uint64 = 100    // feedback weight per TOS settled by the receipt
+	ReputationFeedbackMaxWeightBps uint64 = 20_000 // cap on feedback weight
+	ReputationFeedbackMaxRating    int64  = 5      // feedback ratings lie in [-5, 5], excluding 0
+)
+
+// Intent pool constants.
+const (
+	IntentMinBidBlocks   uint64 = 10        // minimum sealed-bid window after INTENT_SUBMIT
+	IntentMaxBidBlocks   uint64 = 10_000    // maximum sealed-bid window
+	IntentRevealBlocks   uint64 = 100       // bid reveal window after the bid window
+	IntentExecBlocks     uint64 = 1_000     // execution window after the reveal window
+	IntentMaxHorizonSecs uint64 = 2_592_000 // upper bound for an intent's expiry, 30 days past the block time
+	IntentMaxBids        uint64 = 32        // maximum sealed bids per intent
+	IntentMaxRecipients         = 16        // maximum allowed recipients per intent
+	IntentMaxRouteSteps         = 16        // maximum route steps per plan
+)
+
 // SysActionGas is the fixed gas cost charged for any system action transaction,
 // on top of the intrinsic gas.
 const SysActionGas uint64 = 100_000
@@ -155,6 +293,19 @@ const (
 	LeaseRefundNumerator         uint64 = 80
 	LeaseRefundDenominator       uint64 = 100
 	LeasePruneBudgetPerSweep     uint64 = 4096
+
+	// Auto-renewal: leases renewed from their prepaid budget per epoch
+	// boundary, and how many epochs before expiry tos_getLease warns.
+	LeaseAutoRenewBudgetPerSweep uint64 = 1024
+	LeaseExpiryWarningEpochs     uint64 = 2
+
+	// Storage rent: each storage slot a lease contract holds is charged from
+	// its deposit as LeaseStorageSlotBytes code bytes would be, at
+	// LeaseDepositReferenceByteGas per LeaseReferenceBlocks.
+	LeaseStorageSlotBytes uint64 = 64
+	// Leases from before storage rent whose storage is counted per epoch
+	// boundary.
+	LeaseStorageSeedPerSweep uint64 = 64
 )
 
 // UNO (Untraceable Native cOin) unit system.
@@ -190,4 +341,9 @@ const (
 	DPoSMaintenanceMaxBlocks uint64 = 240000
 	// Lease contracts freeze for one epoch by default before becoming expired.
 	LeaseGraceBlocks uint64 = DPoSEpochLength
+	// Registered gateways are deactivated after this many epochs without a heartbeat.
+	GatewayHeartbeatMissEpochs uint64 = 3
+	// Agents per block visited by the sweep that starts liveness tracking for
+	// gateways registered before it existed.
+	GatewayLivenessSeedPerBlock uint64 = 256
 )
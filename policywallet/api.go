@@ -38,6 +38,13 @@ type RecoveryStateResult struct {
 	Timelock    uint64         `json:"timelock"`
 }
 
+// AuditorEpochResult is the JSON-friendly result for one auditor rotation epoch.
+type AuditorEpochResult struct {
+	Slot            uint8  `json:"slot"`
+	AuditorKey      string `json:"auditor_key"`
+	ActivationBlock uint64 `json:"activation_block"`
+}
+
 // PublicPolicyWalletAPI provides RPC methods for querying policy wallet state.
 type PublicPolicyWalletAPI struct {
 	stateReader func() stateDB
@@ -114,6 +121,29 @@ func (api *PublicPolicyWalletAPI) GetGuardian(account common.Address) (common.Ad
 	return ReadGuardian(db, account), nil
 }
 
+// GetAuditorSchedule returns the auditor rotation schedule of every slot
+// configured on an account, including epochs that have not yet activated.
+func (api *PublicPolicyWalletAPI) GetAuditorSchedule(account common.Address) ([]AuditorEpochResult, error) {
+	db := api.stateReader()
+	out := make([]AuditorEpochResult, 0)
+	for slot := uint8(0); slot < MaxAuditors; slot++ {
+		history := ReadAuditorHistory(db, account, slot)
+		if len(history) == 0 && slot == 0 {
+			if legacy := ReadAuditorKey(db, account); legacy != ([32]byte{}) {
+				history = []AuditorEpoch{{Key: legacy}}
+			}
+		}
+		for _, epoch := range history {
+			out = append(out, AuditorEpochResult{
+				Slot:            slot,
+				AuditorKey:      common.Bytes2Hex(epoch.Key[:]),
+				ActivationBlock: epoch.ActivationBlock,
+			})
+		}
+	}
+	return out, nil
+}
+
 // GetBoundaryVersion returns the boundary schema version used by this node.
 func (api *PublicPolicyWalletAPI) GetBoundaryVersion() string {
 	return boundary.SchemaVersion
@@ -28,6 +28,8 @@ func (h *policyWalletHandler) Actions() []sysaction.ActionKind {
 		sysaction.ActionPolicySuspend,
 		sysaction.ActionPolicyUnsuspend,
 		sysaction.ActionPolicySetAuditorKey,
+		sysaction.ActionPolicyScheduleAuditorRotation,
+		sysaction.ActionPolicyCancelAuditorRotation,
 	}
 }
 
@@ -57,6 +59,10 @@ func (h *policyWalletHandler) Handle(ctx *sysaction.Context, sa *sysaction.SysAc
 		return h.handleUnsuspend(ctx, sa)
 	case sysaction.ActionPolicySetAuditorKey:
 		return h.handleSetAuditorKey(ctx, sa)
+	case sysaction.ActionPolicyScheduleAuditorRotation:
+		return h.handleScheduleAuditorRotation(ctx, sa)
+	case sysaction.ActionPolicyCancelAuditorRotation:
+		return h.handleCancelAuditorRotation(ctx, sa)
 	}
 	return nil
 }
@@ -365,6 +371,83 @@ func (h *policyWalletHandler) handleSetAuditorKey(ctx *sysaction.Context, sa *sy
 	if err := requireNotSuspended(ctx.StateDB, p.Account); err != nil {
 		return err
 	}
-	WriteAuditorKey(ctx.StateDB, p.Account, p.AuditorKey)
+	// Setting the key directly is an immediate rotation of slot 0.
+	return scheduleAuditorEpoch(ctx.StateDB, p.Account, 0, ctx.BlockNumber.Uint64(), AuditorEpoch{
+		Key:             p.AuditorKey,
+		ActivationBlock: ctx.BlockNumber.Uint64(),
+	})
+}
+
+func (h *policyWalletHandler) handleScheduleAuditorRotation(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p ScheduleAuditorRotationPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	if err := requireOwner(ctx, p.Account); err != nil {
+		return err
+	}
+	if err := requireNotSuspended(ctx.StateDB, p.Account); err != nil {
+		return err
+	}
+	if p.Slot >= MaxAuditors {
+		return ErrInvalidAuditorSlot
+	}
+	if p.ActivationBlock < ctx.BlockNumber.Uint64() {
+		return ErrActivationInPast
+	}
+	return scheduleAuditorEpoch(ctx.StateDB, p.Account, p.Slot, ctx.BlockNumber.Uint64(), AuditorEpoch{
+		Key:             p.AuditorKey,
+		ActivationBlock: p.ActivationBlock,
+	})
+}
+
+// handleCancelAuditorRotation drops the latest rotation scheduled for a slot,
+// provided it has not activated yet. Repeat to cancel earlier pending ones.
+func (h *policyWalletHandler) handleCancelAuditorRotation(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p CancelAuditorRotationPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	if err := requireOwner(ctx, p.Account); err != nil {
+		return err
+	}
+	if err := requireNotSuspended(ctx.StateDB, p.Account); err != nil {
+		return err
+	}
+	if p.Slot >= MaxAuditors {
+		return ErrInvalidAuditorSlot
+	}
+	db := ctx.StateDB
+	if LiveAuditorEpochs(db, p.Account, p.Slot) == 0 {
+		return ErrNoPendingRotation
+	}
+	n := ReadAuditorEpochCount(db, p.Account, p.Slot)
+	if ReadAuditorEpoch(db, p.Account, p.Slot, n-1).ActivationBlock <= ctx.BlockNumber.Uint64() {
+		return ErrNoPendingRotation
+	}
+	PopAuditorEpoch(db, p.Account, p.Slot)
+	return nil
+}
+
+// scheduleAuditorEpoch appends epoch to slot's rotation schedule. A legacy
+// single auditor key is migrated into slot 0 as a genesis epoch first so
+// that it stays active until the new epoch activates. Epochs superseded
+// before block are retired before the schedule size is checked.
+func scheduleAuditorEpoch(db stateDB, wallet common.Address, slot uint8, block uint64, epoch AuditorEpoch) error {
+	RetireAuditorEpochs(db, wallet, slot, block)
+	n := ReadAuditorEpochCount(db, wallet, slot)
+	if n == 0 && slot == 0 {
+		if legacy := ReadAuditorKey(db, wallet); legacy != ([32]byte{}) {
+			AppendAuditorEpoch(db, wallet, 0, AuditorEpoch{Key: legacy})
+			n = 1
+		}
+	}
+	if n-ReadAuditorEpochBase(db, wallet, slot) >= MaxAuditorEpochs {
+		return ErrAuditorScheduleFull
+	}
+	if n > 0 && ReadAuditorEpoch(db, wallet, slot, n-1).ActivationBlock > epoch.ActivationBlock {
+		return ErrAuditorRotationOrder
+	}
+	AppendAuditorEpoch(db, wallet, slot, epoch)
 	return nil
 }
@@ -1,6 +1,7 @@
 package policywallet
 
 import (
+	"encoding/binary"
 	"encoding/json"
 	"math/big"
 	"testing"
@@ -629,3 +630,248 @@ func TestHandleUnsuspend_NotOwner(t *testing.T) {
 		t.Fatalf("expected ErrNotOwner, got %v", err)
 	}
 }
+
+// ---------- Auditor rotation ----------
+
+func TestHandleSetAuditorKey_RecordsEpoch(t *testing.T) {
+	db := newHandlerMockStateDB()
+	h := &policyWalletHandler{}
+	WriteOwner(db, walletAddr, ownerAddr)
+
+	key := [32]byte{0xA1}
+	sa := makeSysAction(sysaction.ActionPolicySetAuditorKey, SetAuditorKeyPayload{
+		Account:    walletAddr,
+		AuditorKey: key,
+	})
+	if err := h.Handle(makeCtx(db, ownerAddr, 100), sa); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := AuditorKeyAt(db, walletAddr, 0, 100); got != key {
+		t.Fatalf("slot 0 key at 100: got %x", got)
+	}
+	if got := AuditorKeyAt(db, walletAddr, 0, 99); got != ([32]byte{}) {
+		t.Fatalf("slot 0 key before activation should be zero, got %x", got)
+	}
+}
+
+func TestHandleScheduleAuditorRotation_MultiSlot(t *testing.T) {
+	db := newHandlerMockStateDB()
+	h := &policyWalletHandler{}
+	WriteOwner(db, walletAddr, ownerAddr)
+
+	internal, regulator, rotated := [32]byte{0x01}, [32]byte{0x02}, [32]byte{0x03}
+	schedule := []ScheduleAuditorRotationPayload{
+		{Account: walletAddr, Slot: 0, AuditorKey: internal, ActivationBlock: 100},
+		{Account: walletAddr, Slot: 1, AuditorKey: regulator, ActivationBlock: 100},
+		{Account: walletAddr, Slot: 1, AuditorKey: rotated, ActivationBlock: 500},
+	}
+	for i, p := range schedule {
+		sa := makeSysAction(sysaction.ActionPolicyScheduleAuditorRotation, p)
+		if err := h.Handle(makeCtx(db, ownerAddr, 100), sa); err != nil {
+			t.Fatalf("schedule %d: %v", i, err)
+		}
+	}
+
+	keys := ReadActiveAuditorKeys(db, walletAddr, 499)
+	if len(keys) != 2 || keys[0] != internal || keys[1] != regulator {
+		t.Fatalf("keys at 499: %x", keys)
+	}
+	keys = ReadActiveAuditorKeys(db, walletAddr, 500)
+	if len(keys) != 2 || keys[0] != internal || keys[1] != rotated {
+		t.Fatalf("keys at 500: %x", keys)
+	}
+	if n := len(ReadAuditorHistory(db, walletAddr, 1)); n != 2 {
+		t.Fatalf("slot 1 history length: got %d, want 2", n)
+	}
+
+	// Inside the grace window the pre-rotation set is still accepted.
+	sets := AcceptedAuditorKeySets(db, walletAddr, 500+AuditorRotationGraceBlocks-1)
+	if len(sets) != 2 || sets[1][1] != regulator {
+		t.Fatalf("grace window sets: %x", sets)
+	}
+	sets = AcceptedAuditorKeySets(db, walletAddr, 500+AuditorRotationGraceBlocks)
+	if len(sets) != 1 {
+		t.Fatalf("after grace window: got %d sets, want 1", len(sets))
+	}
+}
+
+func TestHandleScheduleAuditorRotation_MigratesLegacyKey(t *testing.T) {
+	db := newHandlerMockStateDB()
+	h := &policyWalletHandler{}
+	WriteOwner(db, walletAddr, ownerAddr)
+
+	legacy, next := [32]byte{0x0A}, [32]byte{0x0B}
+	WriteAuditorKey(db, walletAddr, legacy)
+
+	sa := makeSysAction(sysaction.ActionPolicyScheduleAuditorRotation, ScheduleAuditorRotationPayload{
+		Account: walletAddr, Slot: 0, AuditorKey: next, ActivationBlock: 300,
+	})
+	if err := h.Handle(makeCtx(db, ownerAddr, 200), sa); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := AuditorKeyAt(db, walletAddr, 0, 299); got != legacy {
+		t.Fatalf("legacy key must stay active until rotation, got %x", got)
+	}
+	if got := AuditorKeyAt(db, walletAddr, 0, 300); got != next {
+		t.Fatalf("rotated key at 300: got %x", got)
+	}
+}
+
+func TestHandleScheduleAuditorRotation_Rejects(t *testing.T) {
+	db := newHandlerMockStateDB()
+	h := &policyWalletHandler{}
+	WriteOwner(db, walletAddr, ownerAddr)
+
+	cases := []struct {
+		name    string
+		payload ScheduleAuditorRotationPayload
+		want    error
+	}{
+		{"bad slot", ScheduleAuditorRotationPayload{Account: walletAddr, Slot: MaxAuditors, ActivationBlock: 200}, ErrInvalidAuditorSlot},
+		{"past activation", ScheduleAuditorRotationPayload{Account: walletAddr, Slot: 0, ActivationBlock: 99}, ErrActivationInPast},
+	}
+	for _, tc := range cases {
+		sa := makeSysAction(sysaction.ActionPolicyScheduleAuditorRotation, tc.payload)
+		if err := h.Handle(makeCtx(db, ownerAddr, 100), sa); err != tc.want {
+			t.Fatalf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	// A rotation may not be scheduled before one already pending.
+	sa := makeSysAction(sysaction.ActionPolicyScheduleAuditorRotation, ScheduleAuditorRotationPayload{
+		Account: walletAddr, Slot: 2, AuditorKey: [32]byte{1}, ActivationBlock: 400,
+	})
+	if err := h.Handle(makeCtx(db, ownerAddr, 100), sa); err != nil {
+		t.Fatalf("schedule: %v", err)
+	}
+	sa = makeSysAction(sysaction.ActionPolicyScheduleAuditorRotation, ScheduleAuditorRotationPayload{
+		Account: walletAddr, Slot: 2, AuditorKey: [32]byte{2}, ActivationBlock: 300,
+	})
+	if err := h.Handle(makeCtx(db, ownerAddr, 100), sa); err != ErrAuditorRotationOrder {
+		t.Fatalf("expected ErrAuditorRotationOrder, got %v", err)
+	}
+
+	// Non-owners cannot rotate.
+	stranger := common.HexToAddress("0x1234")
+	sa = makeSysAction(sysaction.ActionPolicyScheduleAuditorRotation, ScheduleAuditorRotationPayload{
+		Account: walletAddr, Slot: 3, AuditorKey: [32]byte{3}, ActivationBlock: 400,
+	})
+	if err := h.Handle(makeCtx(db, stranger, 100), sa); err != ErrNotOwner {
+		t.Fatalf("expected ErrNotOwner, got %v", err)
+	}
+}
+
+func TestHandleCancelAuditorRotation(t *testing.T) {
+	db := newHandlerMockStateDB()
+	h := &policyWalletHandler{}
+	WriteOwner(db, walletAddr, ownerAddr)
+
+	active, pending, replacement := [32]byte{0x01}, [32]byte{0x02}, [32]byte{0x03}
+	for _, p := range []ScheduleAuditorRotationPayload{
+		{Account: walletAddr, Slot: 0, AuditorKey: active, ActivationBlock: 100},
+		{Account: walletAddr, Slot: 0, AuditorKey: pending, ActivationBlock: 400},
+	} {
+		if err := h.Handle(makeCtx(db, ownerAddr, 100), makeSysAction(sysaction.ActionPolicyScheduleAuditorRotation, p)); err != nil {
+			t.Fatalf("schedule: %v", err)
+		}
+	}
+
+	// An earlier rotation is rejected until the pending one is cancelled.
+	early := ScheduleAuditorRotationPayload{Account: walletAddr, Slot: 0, AuditorKey: replacement, ActivationBlock: 300}
+	if err := h.Handle(makeCtx(db, ownerAddr, 200), makeSysAction(sysaction.ActionPolicyScheduleAuditorRotation, early)); err != ErrAuditorRotationOrder {
+		t.Fatalf("expected ErrAuditorRotationOrder, got %v", err)
+	}
+	cancel := makeSysAction(sysaction.ActionPolicyCancelAuditorRotation, CancelAuditorRotationPayload{Account: walletAddr, Slot: 0})
+	if err := h.Handle(makeCtx(db, ownerAddr, 200), cancel); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if got := AuditorKeyAt(db, walletAddr, 0, 400); got != active {
+		t.Fatalf("cancelled rotation still scheduled, key at 400: %x", got)
+	}
+	if err := h.Handle(makeCtx(db, ownerAddr, 200), makeSysAction(sysaction.ActionPolicyScheduleAuditorRotation, early)); err != nil {
+		t.Fatalf("reschedule: %v", err)
+	}
+
+	// Active epochs cannot be cancelled.
+	if err := h.Handle(makeCtx(db, ownerAddr, 300), cancel); err != ErrNoPendingRotation {
+		t.Fatalf("expected ErrNoPendingRotation, got %v", err)
+	}
+	if err := h.Handle(makeCtx(db, ownerAddr, 300), makeSysAction(sysaction.ActionPolicyCancelAuditorRotation, CancelAuditorRotationPayload{Account: walletAddr, Slot: 1})); err != ErrNoPendingRotation {
+		t.Fatalf("empty slot: expected ErrNoPendingRotation, got %v", err)
+	}
+}
+
+func TestAuditorEpochsRetired(t *testing.T) {
+	db := newHandlerMockStateDB()
+	h := &policyWalletHandler{}
+	WriteOwner(db, walletAddr, ownerAddr)
+
+	// Rotate far more often than MaxAuditorEpochs allows without retiring;
+	// superseded epochs leave the grace window and drop out of the live count.
+	for i := uint64(0); i < 2*MaxAuditorEpochs; i++ {
+		var key [32]byte
+		binary.BigEndian.PutUint64(key[:8], i+1)
+		sa := makeSysAction(sysaction.ActionPolicySetAuditorKey, SetAuditorKeyPayload{Account: walletAddr, AuditorKey: key})
+		if err := h.Handle(makeCtx(db, ownerAddr, 1+i*10), sa); err != nil {
+			t.Fatalf("rotation %d: %v", i, err)
+		}
+	}
+	if live := LiveAuditorEpochs(db, walletAddr, 0); live > MaxAuditorEpochs {
+		t.Fatalf("live epochs %d exceed cap %d", live, MaxAuditorEpochs)
+	}
+	base := ReadAuditorEpochBase(db, walletAddr, 0)
+	if base == 0 {
+		t.Fatal("expected superseded epochs to be retired")
+	}
+
+	// Retired epochs are kept, so an old transfer still resolves to its key.
+	var first [32]byte
+	binary.BigEndian.PutUint64(first[:8], 1)
+	if got := ReadAuditorEpoch(db, walletAddr, 0, 0); got.Key != first || got.ActivationBlock != 1 {
+		t.Fatalf("retired epoch lost: %+v", got)
+	}
+	if got := AuditorKeyAt(db, walletAddr, 0, 5); got != first {
+		t.Fatalf("key at block 5: %x, want %x", got, first)
+	}
+	sets := AcceptedAuditorKeySets(db, walletAddr, 5)
+	if len(sets[0]) != 1 || sets[0][0] != first {
+		t.Fatalf("key sets at block 5: %x", sets)
+	}
+	if n := uint64(len(ReadAuditorHistory(db, walletAddr, 0))); n != 2*MaxAuditorEpochs {
+		t.Fatalf("history length %d, want %d", n, 2*MaxAuditorEpochs)
+	}
+}
+
+func TestAcceptedAuditorKeySets_AllRotationsInWindow(t *testing.T) {
+	db := newHandlerMockStateDB()
+	h := &policyWalletHandler{}
+	WriteOwner(db, walletAddr, ownerAddr)
+
+	k1, k2, k3, r1 := [32]byte{0x01}, [32]byte{0x02}, [32]byte{0x03}, [32]byte{0x11}
+	for _, p := range []ScheduleAuditorRotationPayload{
+		{Account: walletAddr, Slot: 0, AuditorKey: k1, ActivationBlock: 100},
+		{Account: walletAddr, Slot: 1, AuditorKey: r1, ActivationBlock: 100},
+		{Account: walletAddr, Slot: 0, AuditorKey: k2, ActivationBlock: 1000},
+		{Account: walletAddr, Slot: 0, AuditorKey: k3, ActivationBlock: 1200},
+	} {
+		if err := h.Handle(makeCtx(db, ownerAddr, 100), makeSysAction(sysaction.ActionPolicyScheduleAuditorRotation, p)); err != nil {
+			t.Fatalf("schedule: %v", err)
+		}
+	}
+
+	// Both rotations of slot 0 fall inside the window at 1300.
+	sets := AcceptedAuditorKeySets(db, walletAddr, 1300)
+	if len(sets) != 3 {
+		t.Fatalf("got %d sets, want 3: %x", len(sets), sets)
+	}
+	for i, want := range [][32]byte{k3, k2, k1} {
+		if sets[i][0] != want || sets[i][1] != r1 {
+			t.Fatalf("set %d: %x", i, sets[i])
+		}
+	}
+	// Once the older rotation leaves the window only its successor remains.
+	sets = AcceptedAuditorKeySets(db, walletAddr, 1000+AuditorRotationGraceBlocks)
+	if len(sets) != 2 || sets[1][0] != k2 {
+		t.Fatalf("sets after first window: %x", sets)
+	}
+}
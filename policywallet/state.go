@@ -3,6 +3,7 @@ package policywallet
 import (
 	"encoding/binary"
 	"math/big"
+	"sort"
 
 	"github.com/tos-network/gtos/common"
 	"github.com/tos-network/gtos/crypto"
@@ -160,6 +161,21 @@ func WriteAllowlisted(db stateDB, wallet common.Address, target common.Address,
 	db.SetState(registry, walletMapSlot(wallet, "allowlist", target.Bytes()), val)
 }
 
+// ---------- Policy digest ----------
+
+// PolicyHash returns a digest of wallet's owner, guardian and spend caps:
+// keccak256(owner[32] || guardian[32] || dailyLimit[32] || singleTxLimit[32]).
+// Commitments made under a policy (e.g. settlement callbacks) record it and
+// compare it later to detect that the policy has since changed.
+func PolicyHash(db stateDB, wallet common.Address) common.Hash {
+	return crypto.Keccak256Hash(
+		ReadOwner(db, wallet).Bytes(),
+		ReadGuardian(db, wallet).Bytes(),
+		common.BigToHash(ReadDailyLimit(db, wallet)).Bytes(),
+		common.BigToHash(ReadSingleTxLimit(db, wallet)).Bytes(),
+	)
+}
+
 // ---------- Terminal policies ----------
 
 // terminalSlot returns a sub-slot for a terminal-class field.
@@ -268,8 +284,9 @@ func WriteRecoveryState(db stateDB, wallet common.Address, rs RecoveryState) {
 
 // ---------- Auditor key ----------
 
-// ReadAuditorKey returns the 32-byte auditor ElGamal public key for wallet.
-// Returns zero if not set.
+// ReadAuditorKey returns the legacy single 32-byte auditor ElGamal public key
+// for wallet. Returns zero if not set. Wallets that have scheduled rotations
+// should be read through AuditorKeyAt / ReadActiveAuditorKeys instead.
 func ReadAuditorKey(db stateDB, wallet common.Address) [32]byte {
 	raw := db.GetState(registry, walletSlot(wallet, "auditorKey"))
 	return raw
@@ -279,3 +296,212 @@ func ReadAuditorKey(db stateDB, wallet common.Address) [32]byte {
 func WriteAuditorKey(db stateDB, wallet common.Address, key [32]byte) {
 	db.SetState(registry, walletSlot(wallet, "auditorKey"), common.Hash(key))
 }
+
+// ---------- Auditor rotation schedule ----------
+
+// auditorEpochSlot returns a sub-slot for one field of an auditor epoch entry.
+func auditorEpochSlot(wallet common.Address, slot uint8, idx uint64, field string) common.Hash {
+	mapKey := make([]byte, 0, 1+8+len(field))
+	mapKey = append(mapKey, slot)
+	mapKey = binary.BigEndian.AppendUint64(mapKey, idx)
+	mapKey = append(mapKey, field...)
+	return walletMapSlot(wallet, "auditorEpoch", mapKey)
+}
+
+// ReadAuditorEpochCount returns the number of rotation epochs ever recorded
+// for slot, including retired ones. Live epochs are indexed
+// [ReadAuditorEpochBase, ReadAuditorEpochCount).
+func ReadAuditorEpochCount(db stateDB, wallet common.Address, slot uint8) uint64 {
+	raw := db.GetState(registry, walletMapSlot(wallet, "auditorEpochCount", []byte{slot}))
+	return binary.BigEndian.Uint64(raw[24:])
+}
+
+func writeAuditorEpochCount(db stateDB, wallet common.Address, slot uint8, n uint64) {
+	var count common.Hash
+	binary.BigEndian.PutUint64(count[24:], n)
+	db.SetState(registry, walletMapSlot(wallet, "auditorEpochCount", []byte{slot}), count)
+}
+
+// ReadAuditorEpochBase returns the index of the oldest epoch of slot that has
+// not been retired.
+func ReadAuditorEpochBase(db stateDB, wallet common.Address, slot uint8) uint64 {
+	raw := db.GetState(registry, walletMapSlot(wallet, "auditorEpochBase", []byte{slot}))
+	return binary.BigEndian.Uint64(raw[24:])
+}
+
+func writeAuditorEpochBase(db stateDB, wallet common.Address, slot uint8, base uint64) {
+	var v common.Hash
+	binary.BigEndian.PutUint64(v[24:], base)
+	db.SetState(registry, walletMapSlot(wallet, "auditorEpochBase", []byte{slot}), v)
+}
+
+// LiveAuditorEpochs returns the number of unretired epochs recorded for slot.
+func LiveAuditorEpochs(db stateDB, wallet common.Address, slot uint8) uint64 {
+	return ReadAuditorEpochCount(db, wallet, slot) - ReadAuditorEpochBase(db, wallet, slot)
+}
+
+// ReadAuditorEpoch returns the idx-th rotation epoch recorded for slot.
+func ReadAuditorEpoch(db stateDB, wallet common.Address, slot uint8, idx uint64) AuditorEpoch {
+	key := db.GetState(registry, auditorEpochSlot(wallet, slot, idx, "key"))
+	at := db.GetState(registry, auditorEpochSlot(wallet, slot, idx, "at"))
+	return AuditorEpoch{
+		Key:             key,
+		ActivationBlock: binary.BigEndian.Uint64(at[24:]),
+	}
+}
+
+// AppendAuditorEpoch appends a rotation epoch to slot's schedule. Callers are
+// responsible for keeping activation blocks non-decreasing within a slot.
+func AppendAuditorEpoch(db stateDB, wallet common.Address, slot uint8, epoch AuditorEpoch) {
+	n := ReadAuditorEpochCount(db, wallet, slot)
+	db.SetState(registry, auditorEpochSlot(wallet, slot, n, "key"), common.Hash(epoch.Key))
+	var at common.Hash
+	binary.BigEndian.PutUint64(at[24:], epoch.ActivationBlock)
+	db.SetState(registry, auditorEpochSlot(wallet, slot, n, "at"), at)
+	writeAuditorEpochCount(db, wallet, slot, n+1)
+}
+
+// PopAuditorEpoch removes the most recently appended epoch of slot. It is a
+// no-op when slot has no live epochs.
+func PopAuditorEpoch(db stateDB, wallet common.Address, slot uint8) {
+	n := ReadAuditorEpochCount(db, wallet, slot)
+	if n == ReadAuditorEpochBase(db, wallet, slot) {
+		return
+	}
+	clearAuditorEpoch(db, wallet, slot, n-1)
+	writeAuditorEpochCount(db, wallet, slot, n-1)
+}
+
+// RetireAuditorEpochs moves the live window of slot past the epochs that no
+// longer have any effect at block: an epoch is retired once its successor has
+// been active for at least AuditorRotationGraceBlocks, since transactions
+// built against it are no longer accepted. Retired epochs stay in state so
+// that transfers from their activation range can still be attributed to a
+// key; they only stop counting towards MaxAuditorEpochs. The newest live
+// epoch is never retired.
+func RetireAuditorEpochs(db stateDB, wallet common.Address, slot uint8, block uint64) {
+	n := ReadAuditorEpochCount(db, wallet, slot)
+	base := ReadAuditorEpochBase(db, wallet, slot)
+	start := base
+	for base+1 < n {
+		next := ReadAuditorEpoch(db, wallet, slot, base+1).ActivationBlock
+		if next > block || block-next < AuditorRotationGraceBlocks {
+			break
+		}
+		base++
+	}
+	if base != start {
+		writeAuditorEpochBase(db, wallet, slot, base)
+	}
+}
+
+func clearAuditorEpoch(db stateDB, wallet common.Address, slot uint8, idx uint64) {
+	db.SetState(registry, auditorEpochSlot(wallet, slot, idx, "key"), common.Hash{})
+	db.SetState(registry, auditorEpochSlot(wallet, slot, idx, "at"), common.Hash{})
+}
+
+// ReadAuditorHistory returns the full rotation schedule of slot in activation
+// order, retired epochs included.
+func ReadAuditorHistory(db stateDB, wallet common.Address, slot uint8) []AuditorEpoch {
+	n := ReadAuditorEpochCount(db, wallet, slot)
+	out := make([]AuditorEpoch, 0, n)
+	for i := uint64(0); i < n; i++ {
+		out = append(out, ReadAuditorEpoch(db, wallet, slot, i))
+	}
+	return out
+}
+
+// AuditorKeyAt returns the auditor key active in slot at block. Slot 0 falls
+// back to the legacy single auditor key for wallets that never scheduled a
+// rotation. Returns zero if the slot has no active key. Retired epochs are
+// consulted too, so past blocks resolve to the key that was active then; for
+// recent blocks the scan stops inside the live window.
+func AuditorKeyAt(db stateDB, wallet common.Address, slot uint8, block uint64) [32]byte {
+	n := ReadAuditorEpochCount(db, wallet, slot)
+	if n == 0 {
+		if slot == 0 {
+			return ReadAuditorKey(db, wallet)
+		}
+		return [32]byte{}
+	}
+	for i := n; i > 0; i-- {
+		epoch := ReadAuditorEpoch(db, wallet, slot, i-1)
+		if epoch.ActivationBlock <= block {
+			return epoch.Key
+		}
+	}
+	return [32]byte{}
+}
+
+// ReadActiveAuditorKeys returns the auditor keys active for wallet at block,
+// ordered by slot with retired slots omitted. The i-th key corresponds to the
+// i-th auditor handle carried by a confidential transfer.
+func ReadActiveAuditorKeys(db stateDB, wallet common.Address, block uint64) [][32]byte {
+	var (
+		zero [32]byte
+		keys [][32]byte
+	)
+	for slot := uint8(0); slot < MaxAuditors; slot++ {
+		if key := AuditorKeyAt(db, wallet, slot, block); key != zero {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// AcceptedAuditorKeySets returns the auditor key sets a transaction included
+// at block may be built against. The first set is always the one active at
+// block; it is followed by the set active just before each rotation (in any
+// slot) that happened within the last AuditorRotationGraceBlocks, most recent
+// rotation first. Duplicate sets are omitted.
+func AcceptedAuditorKeySets(db stateDB, wallet common.Address, block uint64) [][][32]byte {
+	sets := [][][32]byte{ReadActiveAuditorKeys(db, wallet, block)}
+
+	// Collect every rotation inside the grace window across all slots.
+	var rotations []uint64
+	for slot := uint8(0); slot < MaxAuditors; slot++ {
+		n := ReadAuditorEpochCount(db, wallet, slot)
+		for i := n; i > 0; i-- {
+			at := ReadAuditorEpoch(db, wallet, slot, i-1).ActivationBlock
+			if at > block {
+				continue
+			}
+			if at == 0 || block-at >= AuditorRotationGraceBlocks {
+				break
+			}
+			rotations = append(rotations, at)
+		}
+	}
+	sort.Slice(rotations, func(i, j int) bool { return rotations[i] > rotations[j] })
+
+	for i, at := range rotations {
+		if i > 0 && rotations[i-1] == at {
+			continue
+		}
+		if previous := ReadActiveAuditorKeys(db, wallet, at-1); !containsAuditorKeySet(sets, previous) {
+			sets = append(sets, previous)
+		}
+	}
+	return sets
+}
+
+func containsAuditorKeySet(sets [][][32]byte, keys [][32]byte) bool {
+	for _, set := range sets {
+		if auditorKeySetsEqual(set, keys) {
+			return true
+		}
+	}
+	return false
+}
+
+func auditorKeySetsEqual(a, b [][32]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
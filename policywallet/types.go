@@ -61,6 +61,14 @@ type RecoveryState struct {
 	Timelock    uint64
 }
 
+// AuditorEpoch is one entry in an auditor slot's rotation schedule. The key
+// becomes the slot's active auditor key at ActivationBlock and stays active
+// until the next epoch in the same slot activates. A zero Key retires the slot.
+type AuditorEpoch struct {
+	Key             [32]byte
+	ActivationBlock uint64
+}
+
 // ---------- System action payloads (JSON) ----------
 
 // SetSpendCapsPayload is the payload for ActionPolicySetSpendCaps.
@@ -133,6 +141,22 @@ type SetAuditorKeyPayload struct {
 	AuditorKey [32]byte       `json:"auditor_key"`
 }
 
+// ScheduleAuditorRotationPayload is the payload for
+// ActionPolicyScheduleAuditorRotation.
+type ScheduleAuditorRotationPayload struct {
+	Account         common.Address `json:"account"`
+	Slot            uint8          `json:"slot"`
+	AuditorKey      [32]byte       `json:"auditor_key"`
+	ActivationBlock uint64         `json:"activation_block"`
+}
+
+// CancelAuditorRotationPayload is the payload for
+// ActionPolicyCancelAuditorRotation.
+type CancelAuditorRotationPayload struct {
+	Account common.Address `json:"account"`
+	Slot    uint8          `json:"slot"`
+}
+
 // Sentinel errors returned by policy wallet handlers.
 var (
 	ErrNotOwner              = errors.New("policywallet: caller is not wallet owner")
@@ -150,8 +174,28 @@ var (
 	ErrNegativeAmount        = errors.New("policywallet: negative amount not allowed")
 	ErrTimelockOverflow      = errors.New("policywallet: timelock arithmetic overflow")
 	ErrZeroAllowance         = errors.New("policywallet: zero allowance not allowed for delegate")
+	ErrInvalidAuditorSlot    = errors.New("policywallet: invalid auditor slot")
+	ErrActivationInPast      = errors.New("policywallet: auditor activation block is in the past")
+	ErrAuditorRotationOrder  = errors.New("policywallet: auditor activation must not precede a scheduled rotation")
+	ErrAuditorScheduleFull   = errors.New("policywallet: auditor rotation schedule is full")
+	ErrNoPendingRotation     = errors.New("policywallet: no pending auditor rotation")
 )
 
 // RecoveryTimelockBlocks is the number of blocks that must pass between
 // initiating and completing a recovery (approximately 24 hours at 360ms blocks).
 const RecoveryTimelockBlocks uint64 = 240_000
+
+// MaxAuditors is the maximum number of auditor slots per wallet. Each slot
+// contributes one auditor handle (with its DLEQ proof) to every confidential
+// transfer sent from the wallet.
+const MaxAuditors = 4
+
+// MaxAuditorEpochs bounds the number of live rotation epochs kept per auditor
+// slot. Superseded epochs are retired from the live window (but kept in state)
+// once they leave the rotation grace window.
+const MaxAuditorEpochs uint64 = 256
+
+// AuditorRotationGraceBlocks is the number of blocks after a rotation during
+// which transactions built against the previous auditor key set are still
+// accepted, so that transactions in flight at activation remain valid.
+const AuditorRotationGraceBlocks uint64 = 600
@@ -15,11 +15,26 @@ func init() {
 type referralHandler struct{}
 
 func (h *referralHandler) Actions() []sysaction.ActionKind {
-	return []sysaction.ActionKind{sysaction.ActionReferralBind}
+	return []sysaction.ActionKind{
+		sysaction.ActionReferralBind,
+		sysaction.ActionReferralProgramSet,
+		sysaction.ActionReferralPayout,
+		sysaction.ActionReferralClaim,
+	}
 }
 
 func (h *referralHandler) Handle(ctx *sysaction.Context, sa *sysaction.SysAction) error {
-	return h.handleBind(ctx, sa)
+	switch sa.Action {
+	case sysaction.ActionReferralBind:
+		return h.handleBind(ctx, sa)
+	case sysaction.ActionReferralProgramSet:
+		return h.handleProgramSet(ctx, sa)
+	case sysaction.ActionReferralPayout:
+		return h.handlePayout(ctx, sa)
+	case sysaction.ActionReferralClaim:
+		return h.handleClaim(ctx, sa)
+	}
+	return nil
 }
 
 type bindPayload struct {
@@ -66,3 +81,51 @@ func (h *referralHandler) handleBind(ctx *sysaction.Context, sa *sysaction.SysAc
 
 	return nil
 }
+
+type programSetPayload struct {
+	Name  string   `json:"name"`
+	Rates []uint16 `json:"rates"` // basis points per upline level, direct referrer first
+}
+
+// handleProgramSet registers a reward program owned by the sender, or
+// replaces the rate table of one it already owns.
+func (h *referralHandler) handleProgramSet(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p programSetPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	if p.Name == "" || len(p.Name) > params.ReferralMaxProgramNameLen {
+		return ErrReferralInvalidName
+	}
+	if err := ValidateRates(p.Rates); err != nil {
+		return err
+	}
+	id := ProgramID(ctx.From, p.Name)
+	if owner := ReadProgramOwner(ctx.StateDB, id); owner != (common.Address{}) && owner != ctx.From {
+		return ErrReferralNotOwner
+	}
+	writeProgram(ctx.StateDB, id, ctx.From, p.Rates)
+	return nil
+}
+
+type payoutPayload struct {
+	Program string `json:"program"` // hex program ID
+	User    string `json:"user"`    // hex address whose uplines are paid
+}
+
+// handlePayout splits ctx.Value across the uplines of the user. Anyone may
+// fund a payout; the part no upline is entitled to stays with the sender.
+func (h *referralHandler) handlePayout(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p payoutPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	_, err := Payout(ctx.StateDB, common.HexToHash(p.Program), ctx.From, common.HexToAddress(p.User), ctx.Value)
+	return err
+}
+
+// handleClaim pays the sender its accumulated referral rewards.
+func (h *referralHandler) handleClaim(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	_, err := Claim(ctx.StateDB, ctx.From)
+	return err
+}
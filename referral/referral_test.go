@@ -194,3 +194,116 @@ func TestAddTeamVolume(t *testing.T) {
 		t.Errorf("ReadDirectVolume(C): want 0, got %s", got)
 	}
 }
+
+// jsonSA creates a SysAction of the given kind with a JSON-encoded payload.
+func jsonSA(action sysaction.ActionKind, payload interface{}) *sysaction.SysAction {
+	raw, _ := json.Marshal(payload)
+	return &sysaction.SysAction{Action: action, Payload: raw}
+}
+
+// TestReferralProgramSet verifies rate table validation and program ownership.
+func TestReferralProgramSet(t *testing.T) {
+	st := newTestState()
+	owner := tAddr(0x10)
+	set := func(from common.Address, rates []uint16) error {
+		return h.Handle(newCtx(st, from, big.NewInt(0)), jsonSA(sysaction.ActionReferralProgramSet,
+			programSetPayload{Name: "shop", Rates: rates}))
+	}
+
+	if err := set(owner, nil); err != ErrReferralInvalidRates {
+		t.Errorf("empty rates: want ErrReferralInvalidRates, got %v", err)
+	}
+	if err := set(owner, []uint16{6000, 5000}); err != ErrReferralInvalidRates {
+		t.Errorf("rates over 100%%: want ErrReferralInvalidRates, got %v", err)
+	}
+	if err := set(owner, make([]uint16, params.MaxReferralDepth+1)); err != ErrReferralInvalidRates {
+		t.Errorf("too many levels: want ErrReferralInvalidRates, got %v", err)
+	}
+	if err := set(owner, []uint16{1000, 500, 250}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	id := ProgramID(owner, "shop")
+	if got := ReadProgramOwner(st, id); got != owner {
+		t.Errorf("owner: want %v, got %v", owner, got)
+	}
+
+	// Shrinking the table drops the old levels.
+	if err := set(owner, []uint16{2000}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if got := ReadProgramRates(st, id); len(got) != 1 || got[0] != 2000 {
+		t.Errorf("rates after update: got %v", got)
+	}
+	// The same name under another sender is a different program.
+	if ProgramID(tAddr(0x11), "shop") == id {
+		t.Error("program IDs of different owners collide")
+	}
+}
+
+// TestReferralPayoutAndClaim verifies that a payout credits each upline its
+// share, leaves unpaid levels with the payer, and that claims pay out once.
+// Chain: A's referrer = B, B's referrer = C.
+func TestReferralPayoutAndClaim(t *testing.T) {
+	st := newTestState()
+	a, b, c := tAddr(0x01), tAddr(0x02), tAddr(0x03)
+	owner, payer := tAddr(0x10), tAddr(0x20)
+
+	if err := h.Handle(newCtx(st, b, big.NewInt(0)), bindSA(c)); err != nil {
+		t.Fatalf("B->C bind: %v", err)
+	}
+	if err := h.Handle(newCtx(st, a, big.NewInt(0)), bindSA(b)); err != nil {
+		t.Fatalf("A->B bind: %v", err)
+	}
+	if err := h.Handle(newCtx(st, owner, big.NewInt(0)), jsonSA(sysaction.ActionReferralProgramSet,
+		programSetPayload{Name: "shop", Rates: []uint16{1000, 500, 250}})); err != nil {
+		t.Fatalf("program set: %v", err)
+	}
+	id := ProgramID(owner, "shop")
+	payout := func(user common.Address, value int64) error {
+		return h.Handle(newCtx(st, payer, big.NewInt(value)), jsonSA(sysaction.ActionReferralPayout,
+			payoutPayload{Program: id.Hex(), User: user.Hex()}))
+	}
+
+	if err := payout(a, 1000); err != ErrReferralInsufficientBal {
+		t.Errorf("unfunded payout: want ErrReferralInsufficientBal, got %v", err)
+	}
+	st.AddBalance(payer, big.NewInt(1000))
+	if err := payout(a, 0); err != ErrReferralInvalidAmount {
+		t.Errorf("zero payout: want ErrReferralInvalidAmount, got %v", err)
+	}
+	if err := h.Handle(newCtx(st, payer, big.NewInt(1000)), jsonSA(sysaction.ActionReferralPayout,
+		payoutPayload{Program: common.Hash{1}.Hex(), User: a.Hex()})); err != ErrReferralUnknownProgram {
+		t.Errorf("unknown program: want ErrReferralUnknownProgram, got %v", err)
+	}
+
+	// B earns 10%, C earns 5%; the 2.5% for the missing third level stays.
+	logs := len(st.Logs())
+	if err := payout(a, 1000); err != nil {
+		t.Fatalf("payout: %v", err)
+	}
+	if got := ReadClaimable(st, b); got.Cmp(big.NewInt(100)) != 0 {
+		t.Errorf("claimable(B): want 100, got %s", got)
+	}
+	if got := ReadClaimable(st, c); got.Cmp(big.NewInt(50)) != 0 {
+		t.Errorf("claimable(C): want 50, got %s", got)
+	}
+	if got := st.GetBalance(payer); got.Cmp(big.NewInt(850)) != 0 {
+		t.Errorf("payer balance: want 850, got %s", got)
+	}
+	if got := len(st.Logs()) - logs; got != 2 {
+		t.Errorf("reward events: want 2, got %d", got)
+	}
+
+	if err := h.Handle(newCtx(st, b, big.NewInt(0)), jsonSA(sysaction.ActionReferralClaim, struct{}{})); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if got := st.GetBalance(b); got.Cmp(big.NewInt(100)) != 0 {
+		t.Errorf("B balance after claim: want 100, got %s", got)
+	}
+	if got := st.GetBalance(params.ReferralRegistryAddress); got.Cmp(big.NewInt(50)) != 0 {
+		t.Errorf("registry balance: want 50, got %s", got)
+	}
+	if err := h.Handle(newCtx(st, b, big.NewInt(0)), jsonSA(sysaction.ActionReferralClaim, struct{}{})); err != ErrReferralNothingToClaim {
+		t.Errorf("second claim: want ErrReferralNothingToClaim, got %v", err)
+	}
+}
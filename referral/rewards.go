@@ -0,0 +1,172 @@
+package referral
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/core/types"
+	vmtypes "github.com/tos-network/gtos/core/vmtypes"
+	"github.com/tos-network/gtos/crypto"
+	"github.com/tos-network/gtos/params"
+)
+
+// Log topics emitted by the referral reward primitive.
+var (
+	// ReferralProgramSetTopic = keccak256("ReferralProgramSet(bytes32,address)").
+	ReferralProgramSetTopic = crypto.Keccak256Hash([]byte("ReferralProgramSet(bytes32,address)"))
+	// ReferralRewardTopic = keccak256("ReferralReward(bytes32,address,address,uint8,uint256)").
+	ReferralRewardTopic = crypto.Keccak256Hash([]byte("ReferralReward(bytes32,address,address,uint8,uint256)"))
+	// ReferralClaimedTopic = keccak256("ReferralClaimed(address,uint256)").
+	ReferralClaimedTopic = crypto.Keccak256Hash([]byte("ReferralClaimed(address,uint256)"))
+)
+
+// maxRateBps is the largest total commission a program may pay out.
+const maxRateBps = 10_000
+
+// ProgramID returns the ID of the reward program owner registers as name.
+// id = keccak256("ref\x00program\x00" || owner[32] || name)
+func ProgramID(owner common.Address, name string) common.Hash {
+	key := append([]byte("ref\x00program\x00"), owner.Bytes()...)
+	key = append(key, []byte(name)...)
+	return crypto.Keccak256Hash(key)
+}
+
+// programSlot returns the slot for one field of a reward program.
+// key = keccak256("ref\x00prog\x00" || id[32] || field)
+func programSlot(id common.Hash, field string) common.Hash {
+	key := append([]byte("ref\x00prog\x00"), id.Bytes()...)
+	key = append(key, []byte(field)...)
+	return crypto.Keccak256Hash(key)
+}
+
+// rateSlot returns the slot holding a program's rate for level (0 = direct referrer).
+func rateSlot(id common.Hash, level int) common.Hash {
+	return programSlot(id, string([]byte{'r', 'a', 't', 'e', byte(level)}))
+}
+
+// ReadProgramOwner returns the owner of program id, or the zero address if
+// no such program exists.
+func ReadProgramOwner(db stateDB, id common.Hash) common.Address {
+	raw := db.GetState(params.ReferralRegistryAddress, programSlot(id, "owner"))
+	return common.BytesToAddress(raw[:])
+}
+
+// ReadProgramRates returns the commission rates of program id in basis
+// points, indexed by upline level starting at the direct referrer.
+func ReadProgramRates(db stateDB, id common.Hash) []uint16 {
+	n := db.GetState(params.ReferralRegistryAddress, programSlot(id, "levels")).Big().Uint64()
+	rates := make([]uint16, n)
+	for i := range rates {
+		raw := db.GetState(params.ReferralRegistryAddress, rateSlot(id, i))
+		rates[i] = binary.BigEndian.Uint16(raw[30:])
+	}
+	return rates
+}
+
+// ValidateRates checks that rates covers 1–MaxReferralDepth levels and pays
+// out at most 100% in total.
+func ValidateRates(rates []uint16) error {
+	if len(rates) == 0 || len(rates) > int(params.MaxReferralDepth) {
+		return ErrReferralInvalidRates
+	}
+	var total uint64
+	for _, r := range rates {
+		total += uint64(r)
+	}
+	if total == 0 || total > maxRateBps {
+		return ErrReferralInvalidRates
+	}
+	return nil
+}
+
+func writeProgram(db vmtypes.StateDB, id common.Hash, owner common.Address, rates []uint16) {
+	var val common.Hash
+	copy(val[:], owner.Bytes())
+	db.SetState(params.ReferralRegistryAddress, programSlot(id, "owner"), val)
+	var n common.Hash
+	binary.BigEndian.PutUint64(n[24:], uint64(len(rates)))
+	db.SetState(params.ReferralRegistryAddress, programSlot(id, "levels"), n)
+	for i, r := range rates {
+		var rv common.Hash
+		binary.BigEndian.PutUint16(rv[30:], r)
+		db.SetState(params.ReferralRegistryAddress, rateSlot(id, i), rv)
+	}
+	db.AddLog(&types.Log{
+		Address: params.ReferralRegistryAddress,
+		Topics:  []common.Hash{ReferralProgramSetTopic, id},
+		Data:    common.BytesToHash(owner.Bytes()).Bytes(),
+	})
+}
+
+// ReadClaimable returns the referral rewards addr has earned but not yet claimed.
+func ReadClaimable(db stateDB, addr common.Address) *big.Int {
+	return db.GetState(params.ReferralRegistryAddress, refSlot("ref\x00claim\x00", addr)).Big()
+}
+
+func writeClaimable(db stateDB, addr common.Address, amount *big.Int) {
+	db.SetState(params.ReferralRegistryAddress, refSlot("ref\x00claim\x00", addr), common.BigToHash(amount))
+}
+
+// Payout splits amount across the uplines of user according to the rates of
+// program id and credits each share to the upline's claimable balance. Only
+// the credited total is taken from payer; shares for missing uplines and
+// rounding dust stay with payer. Returns the amount taken.
+func Payout(db vmtypes.StateDB, id common.Hash, payer, user common.Address, amount *big.Int) (*big.Int, error) {
+	if amount == nil || amount.Sign() <= 0 {
+		return nil, ErrReferralInvalidAmount
+	}
+	if ReadProgramOwner(db, id) == (common.Address{}) {
+		return nil, ErrReferralUnknownProgram
+	}
+	if db.GetBalance(payer).Cmp(amount) < 0 {
+		return nil, ErrReferralInsufficientBal
+	}
+	rates := ReadProgramRates(db, id)
+	uplines := GetUplines(db, user, uint8(len(rates)))
+	paid := new(big.Int)
+	for i, up := range uplines {
+		share := new(big.Int).Mul(amount, big.NewInt(int64(rates[i])))
+		share.Quo(share, big.NewInt(maxRateBps))
+		if share.Sign() == 0 {
+			continue
+		}
+		writeClaimable(db, up, new(big.Int).Add(ReadClaimable(db, up), share))
+		paid.Add(paid, share)
+
+		// ReferralReward(program, upline, user, level, amount); level 1 is
+		// the direct referrer.
+		data := make([]byte, 96)
+		copy(data[:32], user.Bytes())
+		data[63] = byte(i + 1)
+		share.FillBytes(data[64:])
+		db.AddLog(&types.Log{
+			Address: params.ReferralRegistryAddress,
+			Topics:  []common.Hash{ReferralRewardTopic, id, common.BytesToHash(up.Bytes())},
+			Data:    data,
+		})
+	}
+	if paid.Sign() > 0 {
+		db.SubBalance(payer, paid)
+		db.AddBalance(params.ReferralRegistryAddress, paid)
+	}
+	return paid, nil
+}
+
+// Claim pays addr its whole claimable balance from the registry and returns
+// the amount paid.
+func Claim(db vmtypes.StateDB, addr common.Address) (*big.Int, error) {
+	amount := ReadClaimable(db, addr)
+	if amount.Sign() == 0 {
+		return nil, ErrReferralNothingToClaim
+	}
+	writeClaimable(db, addr, new(big.Int))
+	db.SubBalance(params.ReferralRegistryAddress, amount)
+	db.AddBalance(addr, amount)
+	db.AddLog(&types.Log{
+		Address: params.ReferralRegistryAddress,
+		Topics:  []common.Hash{ReferralClaimedTopic, common.BytesToHash(addr.Bytes())},
+		Data:    common.BigToHash(amount).Bytes(),
+	})
+	return amount, nil
+}
@@ -8,4 +8,12 @@ var (
 	ErrReferralCircular      = errors.New("referral: would create a circular reference")
 	ErrReferralDepthExceeded = errors.New("referral: upline depth exceeds maximum")
 	ErrReferralInvalidLevels = errors.New("referral: levels must be 1–20")
+
+	ErrReferralInvalidName     = errors.New("referral: invalid program name")
+	ErrReferralInvalidRates    = errors.New("referral: rates must list 1–20 levels summing to at most 10000 bps")
+	ErrReferralNotOwner        = errors.New("referral: caller does not own the program")
+	ErrReferralUnknownProgram  = errors.New("referral: program is not registered")
+	ErrReferralInvalidAmount   = errors.New("referral: payout amount must be positive")
+	ErrReferralInsufficientBal = errors.New("referral: insufficient balance for payout")
+	ErrReferralNothingToClaim  = errors.New("referral: no claimable balance")
 )
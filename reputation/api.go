@@ -0,0 +1,85 @@
+package reputation
+
+import (
+	"github.com/tos-network/gtos/capability"
+	"github.com/tos-network/gtos/common"
+)
+
+// ReputationScoreResult is the JSON-friendly result for GetScore.
+type ReputationScoreResult struct {
+	Address     common.Address `json:"address"`
+	Block       uint64         `json:"block"`
+	TotalScore  string         `json:"total_score"`
+	RatingCount string         `json:"rating_count"`
+
+	// Capability fields are set only when a capability is requested.
+	Capability    string             `json:"capability,omitempty"`
+	CapabilityBit *uint8             `json:"capability_bit,omitempty"`
+	Score         string             `json:"score,omitempty"`  // decayed, scaled by ScoreScale
+	Weight        string             `json:"weight,omitempty"` // decayed, scaled by ScoreScale
+	Confidence    uint64             `json:"confidence"`       // basis points
+	Count         uint64             `json:"count"`
+	UpdatedAt     uint64             `json:"updated_at"`
+	History       []ScoreEntryResult `json:"history,omitempty"`
+}
+
+// ScoreEntryResult is one recent rating in a ReputationScoreResult.
+type ScoreEntryResult struct {
+	Block     uint64         `json:"block"`
+	Scorer    common.Address `json:"scorer"`
+	Delta     string         `json:"delta"`
+	WeightBps uint64         `json:"weight_bps"`
+}
+
+// PublicReputationAPI provides RPC methods for querying reputation state.
+type PublicReputationAPI struct {
+	headReader func() (StateDB, uint64)
+}
+
+// NewPublicReputationAPI creates a new reputation API instance. headReader
+// must return the state and number of the current head block; capability
+// scores are decayed to that block.
+func NewPublicReputationAPI(headReader func() (StateDB, uint64)) *PublicReputationAPI {
+	return &PublicReputationAPI{headReader: headReader}
+}
+
+// GetScore returns address's cumulative score and, if capabilityName is not
+// empty, its decayed score, confidence and recent history for that
+// capability.
+// RPC: reputation_getScore
+func (api *PublicReputationAPI) GetScore(address common.Address, capabilityName string) (*ReputationScoreResult, error) {
+	db, head := api.headReader()
+	if db == nil {
+		return nil, ErrStateUnavailable
+	}
+	res := &ReputationScoreResult{
+		Address:     address,
+		Block:       head,
+		TotalScore:  TotalScoreOf(db, address).String(),
+		RatingCount: RatingCountOf(db, address).String(),
+	}
+	if capabilityName == "" {
+		return res, nil
+	}
+	bit, ok := capability.CapabilityBit(db, capabilityName)
+	if !ok {
+		return nil, ErrUnknownCapability
+	}
+	cs := ReadCapabilityScore(db, address, bit, head)
+	res.Capability = capabilityName
+	res.CapabilityBit = &bit
+	res.Score = cs.Score.String()
+	res.Weight = cs.Weight.String()
+	res.Confidence = cs.Confidence
+	res.Count = cs.Count
+	res.UpdatedAt = cs.UpdatedAt
+	for _, e := range ReadScoreHistory(db, address, bit) {
+		res.History = append(res.History, ScoreEntryResult{
+			Block:     e.Block,
+			Scorer:    e.Scorer,
+			Delta:     e.Delta.String(),
+			WeightBps: e.WeightBps,
+		})
+	}
+	return res, nil
+}
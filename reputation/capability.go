@@ -0,0 +1,179 @@
+package reputation
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/tos-network/gtos/agent"
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/crypto"
+	"github.com/tos-network/gtos/params"
+)
+
+// ── Slot helpers ──────────────────────────────────────────────────────────────
+
+// capFieldSlot returns the slot for one field of who's score for capability bit.
+// key = keccak256("rep\x00cap\x00" || who[32] || bit[1] || field)
+func capFieldSlot(who common.Address, bit uint8, field string) common.Hash {
+	key := append([]byte("rep\x00cap\x00"), who.Bytes()...)
+	key = append(key, bit)
+	key = append(key, []byte(field)...)
+	return common.BytesToHash(crypto.Keccak256(key))
+}
+
+// historySlot returns the slot for one field of the i-th history ring entry.
+func historySlot(who common.Address, bit uint8, i uint64, field string) common.Hash {
+	var idx [8]byte
+	binary.BigEndian.PutUint64(idx[:], i)
+	key := append([]byte("rep\x00hist\x00"), who.Bytes()...)
+	key = append(key, bit)
+	key = append(key, idx[:]...)
+	key = append(key, []byte(field)...)
+	return common.BytesToHash(crypto.Keccak256(key))
+}
+
+// ── Encoding ──────────────────────────────────────────────────────────────────
+
+// signedToWord encodes v as a two's complement uint256.
+func signedToWord(v *big.Int) common.Hash {
+	n := new(big.Int).Set(v)
+	if n.Sign() < 0 {
+		n.Add(n, uint256Mod)
+	}
+	return common.BigToHash(n)
+}
+
+// wordToSigned decodes a two's complement uint256.
+func wordToSigned(w common.Hash) *big.Int {
+	n := w.Big()
+	if n.Bit(255) == 1 {
+		n.Sub(n, uint256Mod)
+	}
+	return n
+}
+
+func u64Word(v uint64) common.Hash {
+	var h common.Hash
+	binary.BigEndian.PutUint64(h[24:], v)
+	return h
+}
+
+// ── Decay and weights ─────────────────────────────────────────────────────────
+
+// Decay returns v aged by age blocks: it halves v once per full
+// ReputationHalfLifeBlocks and interpolates linearly within the remaining
+// half-life, rounding toward zero. Integer-only so every node agrees.
+func Decay(v *big.Int, age uint64) *big.Int {
+	halfLife := params.ReputationHalfLifeBlocks
+	halvings := age / halfLife
+	if halvings >= 256 {
+		return new(big.Int)
+	}
+	out := new(big.Int).Quo(v, new(big.Int).Lsh(big.NewInt(1), uint(halvings)))
+	// Within a half-life 2^(-r/H) is approximated by 1 - r/(2H).
+	if r := age % halfLife; r > 0 {
+		cut := new(big.Int).Mul(out, new(big.Int).SetUint64(r))
+		cut.Quo(cut, new(big.Int).SetUint64(2*halfLife))
+		out.Sub(out, cut)
+	}
+	return out
+}
+
+// ScorerWeight returns the weight, in basis points, of ratings recorded by
+// scorer. Every scorer gets ReputationBaseWeightBps; registered agents earn
+// more for each AgentMinStake they stake and for each point of their own
+// cumulative reputation, both capped.
+func ScorerWeight(db StateDB, scorer common.Address) uint64 {
+	weight := params.ReputationBaseWeightBps
+	if agent.IsRegistered(db, scorer) && !agent.IsSuspended(db, scorer) {
+		units := new(big.Int).Quo(agent.ReadStake(db, scorer), params.AgentMinStake)
+		if units.Cmp(new(big.Int).SetUint64(params.ReputationMaxStakeUnits)) > 0 {
+			units.SetUint64(params.ReputationMaxStakeUnits)
+		}
+		weight += units.Uint64() * params.ReputationStakeWeightBps
+	}
+	if own := TotalScoreOf(db, scorer); own.Sign() > 0 {
+		bonus := params.ReputationMaxScoreWeightBps
+		if own.IsUint64() && own.Uint64() < bonus/params.ReputationScoreWeightBps {
+			bonus = own.Uint64() * params.ReputationScoreWeightBps
+		}
+		weight += bonus
+	}
+	return weight
+}
+
+// ── Read / Write ──────────────────────────────────────────────────────────────
+
+// RecordCapabilityScore records a rating of delta for who's capability bit,
+// weighted by weightBps, at block. The stored score and weight are first
+// decayed to block, then the new rating is added and appended to the
+// history ring.
+func RecordCapabilityScore(db StateDB, who common.Address, bit uint8, scorer common.Address, delta *big.Int, weightBps uint64, block uint64) {
+	store := func(field string, val common.Hash) {
+		db.SetState(params.ReputationHubAddress, capFieldSlot(who, bit, field), val)
+	}
+	cur := ReadCapabilityScore(db, who, bit, block)
+	w := new(big.Int).SetUint64(weightBps)
+	cur.Score.Add(cur.Score, new(big.Int).Mul(delta, w))
+	cur.Weight.Add(cur.Weight, w)
+
+	// Append to the history ring; "count" doubles as the write cursor.
+	i := cur.Count % params.ReputationHistorySize
+	db.SetState(params.ReputationHubAddress, historySlot(who, bit, i, "block"), u64Word(block))
+	db.SetState(params.ReputationHubAddress, historySlot(who, bit, i, "scorer"), common.BytesToHash(scorer.Bytes()))
+	db.SetState(params.ReputationHubAddress, historySlot(who, bit, i, "delta"), signedToWord(delta))
+	db.SetState(params.ReputationHubAddress, historySlot(who, bit, i, "weight"), u64Word(weightBps))
+
+	store("score", signedToWord(cur.Score))
+	store("weight", common.BigToHash(cur.Weight))
+	store("updated", u64Word(block))
+	store("count", u64Word(cur.Count+1))
+}
+
+// ReadCapabilityScore returns who's score for capability bit decayed to block.
+func ReadCapabilityScore(db StateDB, who common.Address, bit uint8, block uint64) *CapabilityScore {
+	load := func(field string) common.Hash {
+		return db.GetState(params.ReputationHubAddress, capFieldSlot(who, bit, field))
+	}
+	updated := load("updated").Big().Uint64()
+	var age uint64
+	if block > updated {
+		age = block - updated
+	}
+	cs := &CapabilityScore{
+		Score:     Decay(wordToSigned(load("score")), age),
+		Weight:    Decay(load("weight").Big(), age),
+		Count:     load("count").Big().Uint64(),
+		UpdatedAt: updated,
+	}
+	if cs.Weight.Sign() > 0 {
+		conf := new(big.Int).Mul(cs.Weight, big.NewInt(10_000))
+		conf.Quo(conf, new(big.Int).Add(cs.Weight, new(big.Int).SetUint64(params.ReputationConfidenceWeight)))
+		cs.Confidence = conf.Uint64()
+	}
+	return cs
+}
+
+// ReadScoreHistory returns up to ReputationHistorySize of the most recent
+// ratings for who's capability bit, newest first.
+func ReadScoreHistory(db StateDB, who common.Address, bit uint8) []ScoreEntry {
+	count := db.GetState(params.ReputationHubAddress, capFieldSlot(who, bit, "count")).Big().Uint64()
+	n := count
+	if n > params.ReputationHistorySize {
+		n = params.ReputationHistorySize
+	}
+	entries := make([]ScoreEntry, 0, n)
+	for k := uint64(1); k <= n; k++ {
+		i := (count - k) % params.ReputationHistorySize
+		load := func(field string) common.Hash {
+			return db.GetState(params.ReputationHubAddress, historySlot(who, bit, i, field))
+		}
+		entries = append(entries, ScoreEntry{
+			Block:     load("block").Big().Uint64(),
+			Scorer:    common.BytesToAddress(load("scorer").Bytes()),
+			Delta:     wordToSigned(load("delta")),
+			WeightBps: load("weight").Big().Uint64(),
+		})
+	}
+	return entries
+}
@@ -0,0 +1,70 @@
+package reputation
+
+import (
+	"math/big"
+
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/crypto"
+	"github.com/tos-network/gtos/params"
+	"github.com/tos-network/gtos/settlement"
+)
+
+// feedbackSlot returns the slot flagging that a receipt has been rated.
+func feedbackSlot(receiptRef common.Hash) common.Hash {
+	key := append([]byte("rep\x00feedback\x00"), receiptRef.Bytes()...)
+	return common.BytesToHash(crypto.Keccak256(key))
+}
+
+// HasFeedback reports whether receiptRef has already been rated.
+func HasFeedback(db StateDB, receiptRef common.Hash) bool {
+	return db.GetState(params.ReputationHubAddress, feedbackSlot(receiptRef))[31] != 0
+}
+
+func markFeedback(db StateDB, receiptRef common.Hash) {
+	var val common.Hash
+	val[31] = 1
+	db.SetState(params.ReputationHubAddress, feedbackSlot(receiptRef), val)
+}
+
+// FeedbackWeight returns the weight, in basis points, of feedback backed by
+// a settlement of amount: ReputationFeedbackWeightBps per whole TOS, capped
+// at ReputationFeedbackMaxWeightBps.
+func FeedbackWeight(amount *big.Int) uint64 {
+	w := new(big.Int).Mul(amount, new(big.Int).SetUint64(params.ReputationFeedbackWeightBps))
+	w.Quo(w, big.NewInt(1e18))
+	if !w.IsUint64() || w.Uint64() > params.ReputationFeedbackMaxWeightBps {
+		return params.ReputationFeedbackMaxWeightBps
+	}
+	return w.Uint64()
+}
+
+// checkFeedbackReceipt verifies that rater may rate receiptRef and returns
+// the rated agent. The receipt must have settled successfully in a public
+// payment mode with rater as the payer, and amount must match its amount
+// reference.
+func checkFeedbackReceipt(db StateDB, rater common.Address, receiptRef common.Hash, amount *big.Int) (common.Address, error) {
+	receipt, err := settlement.ReadRuntimeReceipt(db, receiptRef)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if receipt.Status != settlement.ReceiptStatusSuccess {
+		return common.Address{}, ErrReceiptNotSettled
+	}
+	if receipt.Sender != rater || receipt.Recipient == rater || receipt.Recipient == (common.Address{}) {
+		return common.Address{}, ErrNotReceiptPayer
+	}
+	switch receipt.Mode {
+	case settlement.ModePublicTransfer, settlement.ModeEscrowReleasePublic:
+	default:
+		// Refunds do not pay the recipient and confidential amounts
+		// cannot be checked against the claimed amount.
+		return common.Address{}, ErrReceiptModeUnrated
+	}
+	if crypto.Keccak256Hash([]byte(amount.String())) != receipt.AmountRef {
+		return common.Address{}, ErrAmountMismatch
+	}
+	if HasFeedback(db, receiptRef) {
+		return common.Address{}, ErrFeedbackExists
+	}
+	return receipt.Recipient, nil
+}
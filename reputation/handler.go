@@ -6,6 +6,7 @@ import (
 
 	"github.com/tos-network/gtos/capability"
 	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/params"
 	"github.com/tos-network/gtos/sysaction"
 )
 
@@ -22,6 +23,7 @@ func (h *reputationHandler) Actions() []sysaction.ActionKind {
 	return []sysaction.ActionKind{
 		sysaction.ActionReputationAuthorizeScorer,
 		sysaction.ActionReputationRecordScore,
+		sysaction.ActionReputationFeedback,
 	}
 }
 
@@ -31,6 +33,8 @@ func (h *reputationHandler) Handle(ctx *sysaction.Context, sa *sysaction.SysActi
 		return h.handleAuthorizeScorer(ctx, sa)
 	case sysaction.ActionReputationRecordScore:
 		return h.handleRecordScore(ctx, sa)
+	case sysaction.ActionReputationFeedback:
+		return h.handleFeedback(ctx, sa)
 	}
 	return nil
 }
@@ -54,10 +58,11 @@ func (h *reputationHandler) handleAuthorizeScorer(ctx *sysaction.Context, sa *sy
 }
 
 type recordScorePayload struct {
-	Who    string `json:"who"`    // hex address of agent being scored
-	Delta  string `json:"delta"`  // signed decimal string (may be negative)
-	Reason string `json:"reason"` // human-readable reason (not stored on-chain)
-	RefID  string `json:"ref_id"` // external reference ID (not stored on-chain)
+	Who        string `json:"who"`                  // hex address of agent being scored
+	Delta      string `json:"delta"`                // signed decimal string (may be negative)
+	Capability string `json:"capability,omitempty"` // optional registered capability name
+	Reason     string `json:"reason"`               // human-readable reason (not stored on-chain)
+	RefID      string `json:"ref_id"`               // external reference ID (not stored on-chain)
 }
 
 func (h *reputationHandler) handleRecordScore(ctx *sysaction.Context, sa *sysaction.SysAction) error {
@@ -75,6 +80,54 @@ func (h *reputationHandler) handleRecordScore(ctx *sysaction.Context, sa *sysact
 	}
 
 	who := common.HexToAddress(p.Who)
+	if p.Capability != "" {
+		bit, ok := capability.CapabilityBit(ctx.StateDB, p.Capability)
+		if !ok {
+			return ErrUnknownCapability
+		}
+		weight := ScorerWeight(ctx.StateDB, ctx.From)
+		RecordCapabilityScore(ctx.StateDB, who, bit, ctx.From, delta, weight, ctx.BlockNumber.Uint64())
+	}
 	RecordScore(ctx.StateDB, who, delta)
 	return nil
 }
+
+type feedbackPayload struct {
+	ReceiptRef string `json:"receipt_ref"` // hex hash of a settled runtime receipt
+	Capability string `json:"capability"`  // registered capability name being rated
+	Rating     int64  `json:"rating"`      // in [-ReputationFeedbackMaxRating, ReputationFeedbackMaxRating], not 0
+	Amount     string `json:"amount"`      // decimal amount settled by the receipt
+}
+
+// handleFeedback lets the payer of a settled receipt rate its recipient
+// once. The rating feeds the recipient's capability score with a weight
+// proportional to the settled amount; no scorer authorization is needed.
+func (h *reputationHandler) handleFeedback(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p feedbackPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	if p.Rating == 0 || p.Rating > params.ReputationFeedbackMaxRating || p.Rating < -params.ReputationFeedbackMaxRating {
+		return ErrInvalidRating
+	}
+	amount, ok := new(big.Int).SetString(p.Amount, 10)
+	if !ok || amount.Sign() <= 0 {
+		return ErrAmountMismatch
+	}
+	bit, ok := capability.CapabilityBit(ctx.StateDB, p.Capability)
+	if !ok {
+		return ErrUnknownCapability
+	}
+	receiptRef := common.HexToHash(p.ReceiptRef)
+	who, err := checkFeedbackReceipt(ctx.StateDB, ctx.From, receiptRef, amount)
+	if err != nil {
+		return err
+	}
+	weight := FeedbackWeight(amount)
+	if weight == 0 {
+		return ErrFeedbackTooSmall
+	}
+	markFeedback(ctx.StateDB, receiptRef)
+	RecordCapabilityScore(ctx.StateDB, who, bit, ctx.From, big.NewInt(p.Rating), weight, ctx.BlockNumber.Uint64())
+	return nil
+}
@@ -9,7 +9,9 @@ import (
 	"github.com/tos-network/gtos/common"
 	"github.com/tos-network/gtos/core/rawdb"
 	"github.com/tos-network/gtos/core/state"
+	"github.com/tos-network/gtos/crypto"
 	"github.com/tos-network/gtos/params"
+	"github.com/tos-network/gtos/settlement"
 	"github.com/tos-network/gtos/sysaction"
 )
 
@@ -162,3 +164,235 @@ func TestRecordScoreCrossInvariant(t *testing.T) {
 		t.Errorf("rating count: want 110, got %v", RatingCountOf(st, who))
 	}
 }
+
+// TestDecay verifies halving per half-life and interpolation within one.
+func TestDecay(t *testing.T) {
+	h := params.ReputationHalfLifeBlocks
+	for _, tc := range []struct {
+		v    int64
+		age  uint64
+		want int64
+	}{
+		{1000, 0, 1000},
+		{1000, h, 500},
+		{1000, 2 * h, 250},
+		{1000, h / 2, 750},
+		{-1000, h, -500},
+		{-1000, h + h/2, -375},
+		{1000, 300 * h, 0},
+	} {
+		if got := Decay(big.NewInt(tc.v), tc.age); got.Cmp(big.NewInt(tc.want)) != 0 {
+			t.Errorf("Decay(%d, %d): want %d, got %v", tc.v, tc.age, tc.want, got)
+		}
+	}
+}
+
+// TestScorerWeight verifies stake- and reputation-derived scorer weights.
+func TestScorerWeight(t *testing.T) {
+	st := newTestState()
+	scorer := tAddr(0x20)
+	if w := ScorerWeight(st, scorer); w != params.ReputationBaseWeightBps {
+		t.Errorf("plain scorer: want %d, got %d", params.ReputationBaseWeightBps, w)
+	}
+	RecordScore(st, scorer, big.NewInt(10))
+	want := params.ReputationBaseWeightBps + 10*params.ReputationScoreWeightBps
+	if w := ScorerWeight(st, scorer); w != want {
+		t.Errorf("scored scorer: want %d, got %d", want, w)
+	}
+	RecordScore(st, scorer, big.NewInt(1_000_000))
+	want = params.ReputationBaseWeightBps + params.ReputationMaxScoreWeightBps
+	if w := ScorerWeight(st, scorer); w != want {
+		t.Errorf("capped scorer: want %d, got %d", want, w)
+	}
+}
+
+// TestRecordCapabilityScore verifies weighted, decayed per-capability scores
+// recorded through REPUTATION_RECORD_SCORE.
+func TestRecordCapabilityScore(t *testing.T) {
+	st := newTestState()
+	scorer := tAddr(0x21)
+	who := tAddr(0x22)
+	AuthorizeScorer(st, scorer, true)
+	bit, err := capability.RegisterCapabilityName(st, "Solver")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := func(delta string, capName string, block int64) error {
+		payload, _ := json.Marshal(recordScorePayload{Who: who.Hex(), Delta: delta, Capability: capName})
+		ctx := newCtx(st, scorer)
+		ctx.BlockNumber = big.NewInt(block)
+		return h.Handle(ctx, &sysaction.SysAction{Action: sysaction.ActionReputationRecordScore, Payload: payload})
+	}
+	if err := record("1", "Unknown", 1); err != ErrUnknownCapability {
+		t.Fatalf("unknown capability: want ErrUnknownCapability, got %v", err)
+	}
+	if err := record("4", "Solver", 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := record("-2", "Solver", 100); err != nil {
+		t.Fatal(err)
+	}
+
+	w := int64(params.ReputationBaseWeightBps)
+	cs := ReadCapabilityScore(st, who, bit, 100)
+	if cs.Score.Cmp(big.NewInt(2*w)) != 0 || cs.Weight.Cmp(big.NewInt(2*w)) != 0 || cs.Count != 2 {
+		t.Fatalf("unexpected score: %+v", cs)
+	}
+	wantConf := uint64(2*w) * 10_000 / (uint64(2*w) + params.ReputationConfidenceWeight)
+	if cs.Confidence != wantConf {
+		t.Errorf("confidence: want %d, got %d", wantConf, cs.Confidence)
+	}
+	// The cumulative score still counts every rating.
+	if TotalScoreOf(st, who).Cmp(big.NewInt(2)) != 0 {
+		t.Errorf("total score: want 2, got %v", TotalScoreOf(st, who))
+	}
+
+	later := 100 + params.ReputationHalfLifeBlocks
+	if got := ReadCapabilityScore(st, who, bit, later).Score; got.Cmp(big.NewInt(w)) != 0 {
+		t.Errorf("decayed score: want %d, got %v", w, got)
+	}
+
+	history := ReadScoreHistory(st, who, bit)
+	if len(history) != 2 || history[0].Delta.Cmp(big.NewInt(-2)) != 0 || history[1].Delta.Cmp(big.NewInt(4)) != 0 {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+	if history[0].Scorer != scorer || history[0].Block != 100 || history[0].WeightBps != uint64(w) {
+		t.Errorf("unexpected entry: %+v", history[0])
+	}
+}
+
+// TestScoreHistoryRing verifies only the most recent ratings are kept.
+func TestScoreHistoryRing(t *testing.T) {
+	st := newTestState()
+	who := tAddr(0x23)
+	n := params.ReputationHistorySize + 3
+	for i := uint64(0); i < n; i++ {
+		RecordCapabilityScore(st, who, 5, tAddr(0x24), big.NewInt(int64(i)), 10_000, i)
+	}
+	history := ReadScoreHistory(st, who, 5)
+	if uint64(len(history)) != params.ReputationHistorySize {
+		t.Fatalf("history length: want %d, got %d", params.ReputationHistorySize, len(history))
+	}
+	for k, e := range history {
+		if want := int64(n) - 1 - int64(k); e.Delta.Int64() != want {
+			t.Errorf("entry %d: want delta %d, got %v", k, want, e.Delta)
+		}
+	}
+}
+
+// TestGetScoreAPI verifies the reputation_getScore RPC result.
+func TestGetScoreAPI(t *testing.T) {
+	st := newTestState()
+	who := tAddr(0x25)
+	bit, _ := capability.RegisterCapabilityName(st, "Oracle")
+	RecordScore(st, who, big.NewInt(3))
+	RecordCapabilityScore(st, who, bit, tAddr(0x26), big.NewInt(3), 10_000, 10)
+
+	api := NewPublicReputationAPI(func() (StateDB, uint64) { return st, 10 })
+	res, err := api.GetScore(who, "Oracle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.TotalScore != "3" || res.Score != "30000" || res.Count != 1 || len(res.History) != 1 || *res.CapabilityBit != bit {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if _, err := api.GetScore(who, "Missing"); err != ErrUnknownCapability {
+		t.Errorf("missing capability: want ErrUnknownCapability, got %v", err)
+	}
+}
+
+// writeTestReceipt stores a settled public receipt from payer to payee.
+func writeTestReceipt(st *state.StateDB, ref common.Hash, payer, payee common.Address, mode uint16, amount *big.Int) {
+	settlement.WriteRuntimeReceiptExists(st, ref)
+	settlement.WriteRuntimeReceiptStatus(st, ref, settlement.ReceiptStatusSuccess)
+	settlement.WriteRuntimeReceiptMode(st, ref, mode)
+	settlement.WriteRuntimeReceiptSender(st, ref, payer)
+	settlement.WriteRuntimeReceiptRecipient(st, ref, payee)
+	settlement.WriteRuntimeReceiptAmountRef(st, ref, crypto.Keccak256Hash([]byte(amount.String())))
+}
+
+// TestFeedback verifies receipt-bound counterparty ratings.
+func TestFeedback(t *testing.T) {
+	st := newTestState()
+	payer := tAddr(0x30)
+	payee := tAddr(0x31)
+	bit, _ := capability.RegisterCapabilityName(st, "Translator")
+	amount := new(big.Int).Mul(big.NewInt(50), big.NewInt(1e18)) // 50 TOS
+	ref := common.Hash{0x40}
+	writeTestReceipt(st, ref, payer, payee, settlement.ModePublicTransfer, amount)
+
+	feedback := func(from common.Address, ref common.Hash, rating int64, amount string) error {
+		payload, _ := json.Marshal(feedbackPayload{ReceiptRef: ref.Hex(), Capability: "Translator", Rating: rating, Amount: amount})
+		ctx := newCtx(st, from)
+		ctx.BlockNumber = big.NewInt(50)
+		return h.Handle(ctx, &sysaction.SysAction{Action: sysaction.ActionReputationFeedback, Payload: payload})
+	}
+	if err := feedback(payer, ref, 6, amount.String()); err != ErrInvalidRating {
+		t.Errorf("rating 6: want ErrInvalidRating, got %v", err)
+	}
+	if err := feedback(payee, ref, 5, amount.String()); err != ErrNotReceiptPayer {
+		t.Errorf("payee rating: want ErrNotReceiptPayer, got %v", err)
+	}
+	if err := feedback(payer, ref, 5, "1"); err != ErrAmountMismatch {
+		t.Errorf("wrong amount: want ErrAmountMismatch, got %v", err)
+	}
+	if err := feedback(payer, common.Hash{0x41}, 5, amount.String()); err != settlement.ErrReceiptNotFound {
+		t.Errorf("unknown receipt: want ErrReceiptNotFound, got %v", err)
+	}
+	if err := feedback(payer, ref, 4, amount.String()); err != nil {
+		t.Fatalf("feedback: %v", err)
+	}
+	if err := feedback(payer, ref, 4, amount.String()); err != ErrFeedbackExists {
+		t.Errorf("second rating: want ErrFeedbackExists, got %v", err)
+	}
+
+	// 50 TOS at 100 bps per TOS gives weight 5_000.
+	cs := ReadCapabilityScore(st, payee, bit, 50)
+	if cs.Score.Cmp(big.NewInt(4*5_000)) != 0 || cs.Weight.Cmp(big.NewInt(5_000)) != 0 {
+		t.Errorf("unexpected score: %+v", cs)
+	}
+	if history := ReadScoreHistory(st, payee, bit); len(history) != 1 || history[0].Scorer != payer {
+		t.Errorf("unexpected history: %+v", history)
+	}
+	// Feedback does not touch the scorer-driven cumulative score.
+	if TotalScoreOf(st, payee).Sign() != 0 {
+		t.Errorf("total score: want 0, got %v", TotalScoreOf(st, payee))
+	}
+
+	// Open, refund and dust receipts cannot be rated.
+	open := common.Hash{0x42}
+	writeTestReceipt(st, open, payer, payee, settlement.ModePublicTransfer, amount)
+	settlement.WriteRuntimeReceiptStatus(st, open, settlement.ReceiptStatusOpen)
+	if err := feedback(payer, open, 1, amount.String()); err != ErrReceiptNotSettled {
+		t.Errorf("open receipt: want ErrReceiptNotSettled, got %v", err)
+	}
+	refund := common.Hash{0x43}
+	writeTestReceipt(st, refund, payer, payee, settlement.ModeRefundPublic, amount)
+	if err := feedback(payer, refund, 1, amount.String()); err != ErrReceiptModeUnrated {
+		t.Errorf("refund receipt: want ErrReceiptModeUnrated, got %v", err)
+	}
+	dust := common.Hash{0x44}
+	writeTestReceipt(st, dust, payer, payee, settlement.ModeEscrowReleasePublic, big.NewInt(1000))
+	if err := feedback(payer, dust, 1, "1000"); err != ErrFeedbackTooSmall {
+		t.Errorf("dust receipt: want ErrFeedbackTooSmall, got %v", err)
+	}
+}
+
+// TestFeedbackWeight verifies the amount-proportional, capped weight.
+func TestFeedbackWeight(t *testing.T) {
+	tos := func(n int64) *big.Int { return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e18)) }
+	for _, tc := range []struct {
+		amount *big.Int
+		want   uint64
+	}{
+		{big.NewInt(1), 0},
+		{tos(1), params.ReputationFeedbackWeightBps},
+		{tos(100), 100 * params.ReputationFeedbackWeightBps},
+		{tos(1_000_000), params.ReputationFeedbackMaxWeightBps},
+	} {
+		if got := FeedbackWeight(tc.amount); got != tc.want {
+			t.Errorf("FeedbackWeight(%v): want %d, got %d", tc.amount, tc.want, got)
+		}
+	}
+}
@@ -1,11 +1,52 @@
 // Package reputation implements the Agent-Native reputation hub.
+//
+// Every rating adds to a cumulative per-address score. Ratings recorded for
+// a capability additionally feed a per-(agent, capability bit) score that is
+// weighted by the scorer's own stake and reputation and decays
+// exponentially with block age.
 package reputation
 
-import "errors"
+import (
+	"errors"
+	"math/big"
+
+	"github.com/tos-network/gtos/common"
+)
+
+// ScoreScale is the fixed-point scale of capability scores and weights:
+// a rating of delta from a full-weight scorer adds delta*ScoreScale.
+const ScoreScale = 10_000
+
+// CapabilityScore is the decayed reputation of an agent for one capability,
+// evaluated at a given block.
+type CapabilityScore struct {
+	Score      *big.Int // decayed weighted sum of deltas, scaled by ScoreScale
+	Weight     *big.Int // decayed sum of scorer weights, scaled by ScoreScale
+	Confidence uint64   // Weight / (Weight + ReputationConfidenceWeight), in bps
+	Count      uint64   // number of ratings ever recorded
+	UpdatedAt  uint64   // block of the last rating
+}
+
+// ScoreEntry is one recorded capability rating.
+type ScoreEntry struct {
+	Block     uint64
+	Scorer    common.Address
+	Delta     *big.Int
+	WeightBps uint64
+}
 
 // Sentinel errors returned by reputation system action handlers.
 var (
-	ErrNotAuthorizedScorer  = errors.New("reputation: caller is not an authorized scorer")
-	ErrRegistrarRequired    = errors.New("reputation: Registrar capability required")
-	ErrInvalidDelta         = errors.New("reputation: invalid delta value")
+	ErrNotAuthorizedScorer = errors.New("reputation: caller is not an authorized scorer")
+	ErrRegistrarRequired   = errors.New("reputation: Registrar capability required")
+	ErrInvalidDelta        = errors.New("reputation: invalid delta value")
+	ErrUnknownCapability   = errors.New("reputation: capability is not registered")
+	ErrStateUnavailable    = errors.New("reputation: state unavailable")
+	ErrInvalidRating       = errors.New("reputation: rating out of range")
+	ErrReceiptNotSettled   = errors.New("reputation: receipt has not settled successfully")
+	ErrNotReceiptPayer     = errors.New("reputation: rater is not the payer of the receipt")
+	ErrReceiptModeUnrated  = errors.New("reputation: receipt settlement mode cannot be rated")
+	ErrAmountMismatch      = errors.New("reputation: amount does not match the receipt")
+	ErrFeedbackTooSmall    = errors.New("reputation: settled amount too small to rate")
+	ErrFeedbackExists      = errors.New("reputation: receipt already rated")
 )
@@ -19,6 +19,9 @@ type SettlementCallbackResult struct {
 	ExecutedAt    uint64         `json:"executed_at"`
 	Status        string         `json:"status"`
 	Creator       common.Address `json:"creator"`
+	Deposit       string         `json:"deposit"`
+	GasUsed       uint64         `json:"gas_used"`
+	ReceiptRef    common.Hash    `json:"receipt_ref,omitempty"` // set once executed
 }
 
 // AsyncFulfillmentResult is the JSON-friendly result for GetFulfillment.
@@ -83,7 +86,7 @@ func (api *PublicSettlementAPI) GetCallback(callbackID common.Hash) (*Settlement
 	if !ReadCallbackExists(db, callbackID) {
 		return nil, ErrCallbackNotFound
 	}
-	return &SettlementCallbackResult{
+	res := &SettlementCallbackResult{
 		CallbackID:    callbackID,
 		TxHash:        ReadCallbackTxHash(db, callbackID),
 		CallbackType:  string(ReadCallbackType(db, callbackID)),
@@ -96,7 +99,13 @@ func (api *PublicSettlementAPI) GetCallback(callbackID common.Hash) (*Settlement
 		ExecutedAt:    ReadCallbackExecutedAt(db, callbackID),
 		Status:        ReadCallbackStatus(db, callbackID),
 		Creator:       ReadCallbackCreator(db, callbackID),
-	}, nil
+		Deposit:       ReadCallbackDeposit(db, callbackID).String(),
+		GasUsed:       ReadCallbackGasUsed(db, callbackID),
+	}
+	if ReadRuntimeReceiptExists(db, CallbackReceiptRef(callbackID)) {
+		res.ReceiptRef = CallbackReceiptRef(callbackID)
+	}
+	return res, nil
 }
 
 // GetFulfillment returns an async fulfillment by ID.
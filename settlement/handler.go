@@ -2,10 +2,12 @@ package settlement
 
 import (
 	"encoding/json"
+	"math/big"
 
 	"github.com/tos-network/gtos/common"
 	"github.com/tos-network/gtos/crypto"
 	"github.com/tos-network/gtos/log"
+	"github.com/tos-network/gtos/params"
 	"github.com/tos-network/gtos/sysaction"
 )
 
@@ -95,14 +97,47 @@ func (h *settlementHandler) handleRegisterCallback(ctx *sysaction.Context, sa *s
 	if p.MaxGas == 0 {
 		return ErrMaxGasZero
 	}
+	if p.MaxGas > MaxCallbackGas {
+		return ErrMaxGasTooHigh
+	}
 	if p.TTLBlocks == 0 {
 		return ErrTTLZero
 	}
 	if p.TTLBlocks > MaxTTLBlocks {
 		return ErrTTLTooLong
 	}
+	var receiptRef common.Hash
+	if p.ReceiptRef != "" {
+		if cbType != CallbackOnFail {
+			return ErrReceiptLinkNotOnFail
+		}
+		receiptRef = common.HexToHash(p.ReceiptRef)
+		if !ReadRuntimeReceiptExists(ctx.StateDB, receiptRef) {
+			return ErrReceiptNotFound
+		}
+		if ReadRuntimeReceiptStatus(ctx.StateDB, receiptRef) != ReceiptStatusOpen {
+			return ErrReceiptNotOpen
+		}
+		if ReadLinkedCallbackCount(ctx.StateDB, receiptRef) >= MaxLinkedCallbacks {
+			return ErrTooManyLinkedCallbacks
+		}
+	}
+	// A policy_hash must be the creator's current policy.
+	if p.PolicyHash != "" {
+		if err := requirePolicy(ctx.StateDB, ctx.From, common.HexToHash(p.PolicyHash)); err != nil {
+			return err
+		}
+	}
+
+	// 2. Escrow the gas deposit; the target's gas is paid from it.
+	deposit := new(big.Int).Mul(new(big.Int).SetUint64(p.MaxGas), big.NewInt(params.TxPriceTomi))
+	if ctx.StateDB.GetBalance(ctx.From).Cmp(deposit) < 0 {
+		return ErrInsufficientDeposit
+	}
+	ctx.StateDB.SubBalance(ctx.From, deposit)
+	ctx.StateDB.AddBalance(registry, deposit)
 
-	// 2. Mint callback ID using counter as nonce.
+	// 3. Mint callback ID using counter as nonce.
 	nonce := ReadCallbackCount(ctx.StateDB)
 	blockNum := ctx.BlockNumber.Uint64()
 
@@ -113,7 +148,7 @@ func (h *settlementHandler) handleRegisterCallback(ctx *sysaction.Context, sa *s
 
 	callbackID := mintCallbackID(ctx.From, txHash, cbType, nonce)
 
-	// 3. Parse optional hex fields.
+	// 4. Parse optional hex fields.
 	var cbData common.Hash
 	if p.CallbackData != "" {
 		cbData = common.HexToHash(p.CallbackData)
@@ -123,7 +158,7 @@ func (h *settlementHandler) handleRegisterCallback(ctx *sysaction.Context, sa *s
 		policyHash = common.HexToHash(p.PolicyHash)
 	}
 
-	// 4. Write callback state.
+	// 5. Write callback state.
 	WriteCallbackExists(ctx.StateDB, callbackID)
 	WriteCallbackTxHash(ctx.StateDB, callbackID, txHash)
 	WriteCallbackType(ctx.StateDB, callbackID, cbType)
@@ -135,8 +170,20 @@ func (h *settlementHandler) handleRegisterCallback(ctx *sysaction.Context, sa *s
 	WriteCallbackExpiresAt(ctx.StateDB, callbackID, blockNum+p.TTLBlocks)
 	WriteCallbackStatus(ctx.StateDB, callbackID, StatusPending)
 	WriteCallbackCreator(ctx.StateDB, callbackID, ctx.From)
+	WriteCallbackDeposit(ctx.StateDB, callbackID, deposit)
 	IncrementCallbackCount(ctx.StateDB)
 
+	// 6. Queue the callback for block processing: on_timeout callbacks fire
+	// at their expiry block, all others are expired and refunded after it.
+	// An on_fail callback linked to a receipt is also queued when that
+	// receipt fails.
+	EnqueueCallback(ctx.StateDB, dueBlock(cbType, blockNum+p.TTLBlocks), callbackID)
+	if receiptRef != (common.Hash{}) {
+		if err := linkCallback(ctx.StateDB, receiptRef, callbackID); err != nil {
+			return err
+		}
+	}
+
 	// Monotonicity check: verify counter incremented to exactly nonce+1.
 	if post := ReadCallbackCount(ctx.StateDB); post != nonce+1 {
 		log.Warn("Settlement callback counter monotonicity violation", "expected", nonce+1, "got", post, "block", blockNum)
@@ -179,13 +226,55 @@ func (h *settlementHandler) handleExecuteCallback(ctx *sysaction.Context, sa *sy
 		return ErrNotCallbackCreator
 	}
 
-	// 5. Mark as executed.
-	WriteCallbackStatus(ctx.StateDB, callbackID, StatusExecuted)
-	WriteCallbackExecutedAt(ctx.StateDB, callbackID, blockNum)
+	// 5. The creator's policy wallet must still carry the policy the
+	// callback was registered under.
+	if err := checkCallbackPolicy(ctx.StateDB, callbackID, creator); err != nil {
+		return err
+	}
+
+	// 6. Callbacks registered before gas deposits existed are bookkeeping
+	// only: mark them executed without calling the target.
+	if ReadCallbackDeposit(ctx.StateDB, callbackID).Sign() == 0 {
+		WriteCallbackStatus(ctx.StateDB, callbackID, StatusExecuted)
+		WriteCallbackExecutedAt(ctx.StateDB, callbackID, blockNum)
+		return nil
+	}
+	if ctx.Call == nil {
+		return ErrCallerUnavailable
+	}
+	if ReadCallbackMaxGas(ctx.StateDB, callbackID) > ctx.Gas {
+		return ErrCallbackGasTooLow
+	}
 
+	// 7. Call the target with the callback data. The call's gas is charged
+	// to this transaction, so the whole deposit is refunded. The callback's
+	// own state, the refund and the receipt are committed even if the
+	// target fails.
+	runCallback(ctx.StateDB, ctx.Call, ctx.Coinbase, callbackID, blockNum, true)
 	return nil
 }
 
+// writeCallbackReceipt records the outcome of an executed callback as a
+// runtime receipt at CallbackReceiptRef. System actions do not see the
+// block timestamp, so OpenedAt / FinalizedAt are block numbers.
+func writeCallbackReceipt(db stateDB, callbackID common.Hash, creator, target common.Address, blockNum uint64, callErr error) {
+	ref := CallbackReceiptRef(callbackID)
+	WriteRuntimeReceiptExists(db, ref)
+	WriteRuntimeReceiptKind(db, ref, ReceiptKindCallback)
+	WriteRuntimeReceiptSender(db, ref, creator)
+	WriteRuntimeReceiptRecipient(db, ref, target)
+	WriteRuntimeReceiptPolicyRef(db, ref, ReadCallbackPolicyHash(db, callbackID))
+	WriteRuntimeReceiptArtifactRef(db, ref, ReadCallbackData(db, callbackID))
+	WriteRuntimeReceiptOpenedAt(db, ref, ReadCallbackCreatedAt(db, callbackID))
+	WriteRuntimeReceiptFinalizedAt(db, ref, blockNum)
+	if callErr != nil {
+		WriteRuntimeReceiptStatus(db, ref, ReceiptStatusFailure)
+		WriteRuntimeReceiptFailureRef(db, ref, crypto.Keccak256Hash([]byte(callErr.Error())))
+		return
+	}
+	WriteRuntimeReceiptStatus(db, ref, ReceiptStatusSuccess)
+}
+
 func (h *settlementHandler) handleFulfillAsync(ctx *sysaction.Context, sa *sysaction.SysAction) error {
 	var p FulfillAsyncPayload
 	if err := json.Unmarshal(sa.Payload, &p); err != nil {
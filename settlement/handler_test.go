@@ -1,24 +1,37 @@
 package settlement
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"math"
 	"math/big"
 	"testing"
 
 	"github.com/tos-network/gtos/common"
 	"github.com/tos-network/gtos/core/types"
+	"github.com/tos-network/gtos/params"
+	"github.com/tos-network/gtos/policywallet"
 	"github.com/tos-network/gtos/sysaction"
 )
 
 // ---------- full vmtypes.StateDB mock ----------
 
 type handlerMockStateDB struct {
-	storage map[common.Address]map[common.Hash]common.Hash
+	storage  map[common.Address]map[common.Hash]common.Hash
+	balances map[common.Address]*big.Int
+	code     map[common.Address][]byte
+	snaps    []map[common.Address]map[common.Hash]common.Hash
+
+	// call stands in for the LVM when a callback runs its target.
+	call func(db *handlerMockStateDB, target common.Address, input []byte, gasLimit uint64) (uint64, error)
 }
 
 func newHandlerMockStateDB() *handlerMockStateDB {
 	return &handlerMockStateDB{
-		storage: make(map[common.Address]map[common.Hash]common.Hash),
+		storage:  make(map[common.Address]map[common.Hash]common.Hash),
+		balances: make(map[common.Address]*big.Int),
+		code:     make(map[common.Address][]byte),
 	}
 }
 
@@ -36,43 +49,72 @@ func (m *handlerMockStateDB) SetState(addr common.Address, key common.Hash, val
 	m.storage[addr][key] = val
 }
 
-func (m *handlerMockStateDB) CreateAccount(common.Address)                           {}
-func (m *handlerMockStateDB) SubBalance(common.Address, *big.Int)                    {}
-func (m *handlerMockStateDB) AddBalance(common.Address, *big.Int)                    {}
-func (m *handlerMockStateDB) GetBalance(common.Address) *big.Int                     { return big.NewInt(0) }
-func (m *handlerMockStateDB) GetNonce(common.Address) uint64                         { return 0 }
-func (m *handlerMockStateDB) SetNonce(common.Address, uint64)                        {}
-func (m *handlerMockStateDB) GetCodeHash(common.Address) common.Hash                 { return common.Hash{} }
-func (m *handlerMockStateDB) GetCode(common.Address) []byte                          { return nil }
-func (m *handlerMockStateDB) SetCode(common.Address, []byte)                         {}
-func (m *handlerMockStateDB) GetCodeSize(common.Address) int                         { return 0 }
-func (m *handlerMockStateDB) AddRefund(uint64)                                       {}
-func (m *handlerMockStateDB) SubRefund(uint64)                                       {}
-func (m *handlerMockStateDB) GetRefund() uint64                                      { return 0 }
+func (m *handlerMockStateDB) CreateAccount(common.Address)           {}
+func (m *handlerMockStateDB) GetNonce(common.Address) uint64         { return 0 }
+func (m *handlerMockStateDB) SetNonce(common.Address, uint64)        {}
+func (m *handlerMockStateDB) GetCodeHash(common.Address) common.Hash { return common.Hash{} }
+func (m *handlerMockStateDB) SetCode(common.Address, []byte)         {}
+func (m *handlerMockStateDB) GetCodeSize(common.Address) int         { return 0 }
+func (m *handlerMockStateDB) AddRefund(uint64)                       {}
+func (m *handlerMockStateDB) SubRefund(uint64)                       {}
+func (m *handlerMockStateDB) GetRefund() uint64                      { return 0 }
 func (m *handlerMockStateDB) GetCommittedState(common.Address, common.Hash) common.Hash {
 	return common.Hash{}
 }
-func (m *handlerMockStateDB) Suicide(common.Address) bool    { return false }
+func (m *handlerMockStateDB) Suicide(common.Address) bool     { return false }
 func (m *handlerMockStateDB) HasSuicided(common.Address) bool { return false }
-func (m *handlerMockStateDB) Exist(common.Address) bool      { return false }
-func (m *handlerMockStateDB) Empty(common.Address) bool      { return true }
+func (m *handlerMockStateDB) Exist(common.Address) bool       { return false }
+func (m *handlerMockStateDB) Empty(common.Address) bool       { return true }
 func (m *handlerMockStateDB) PrepareAccessList(common.Address, *common.Address, []common.Address, types.AccessList) {
 }
 func (m *handlerMockStateDB) AddressInAccessList(common.Address) bool { return false }
 func (m *handlerMockStateDB) SlotInAccessList(common.Address, common.Hash) (bool, bool) {
 	return false, false
 }
-func (m *handlerMockStateDB) AddAddressToAccessList(common.Address)             {}
-func (m *handlerMockStateDB) AddSlotToAccessList(common.Address, common.Hash)   {}
-func (m *handlerMockStateDB) RevertToSnapshot(int)                              {}
-func (m *handlerMockStateDB) Snapshot() int                                     { return 0 }
-func (m *handlerMockStateDB) AddLog(*types.Log)                                 {}
-func (m *handlerMockStateDB) Logs() []*types.Log                                { return nil }
-func (m *handlerMockStateDB) AddPreimage(common.Hash, []byte)                   {}
+func (m *handlerMockStateDB) AddAddressToAccessList(common.Address)           {}
+func (m *handlerMockStateDB) AddSlotToAccessList(common.Address, common.Hash) {}
+func (m *handlerMockStateDB) AddLog(*types.Log)                               {}
+func (m *handlerMockStateDB) Logs() []*types.Log                              { return nil }
+func (m *handlerMockStateDB) AddPreimage(common.Hash, []byte)                 {}
 func (m *handlerMockStateDB) ForEachStorage(common.Address, func(common.Hash, common.Hash) bool) error {
 	return nil
 }
 
+func (m *handlerMockStateDB) GetBalance(addr common.Address) *big.Int {
+	if b, ok := m.balances[addr]; ok {
+		return new(big.Int).Set(b)
+	}
+	return new(big.Int)
+}
+
+func (m *handlerMockStateDB) AddBalance(addr common.Address, amount *big.Int) {
+	m.balances[addr] = new(big.Int).Add(m.GetBalance(addr), amount)
+}
+
+func (m *handlerMockStateDB) SubBalance(addr common.Address, amount *big.Int) {
+	m.balances[addr] = new(big.Int).Sub(m.GetBalance(addr), amount)
+}
+
+func (m *handlerMockStateDB) GetCode(addr common.Address) []byte { return m.code[addr] }
+
+// Snapshot copies storage only; tests revert contract writes, not balances.
+func (m *handlerMockStateDB) Snapshot() int {
+	cp := make(map[common.Address]map[common.Hash]common.Hash, len(m.storage))
+	for addr, slots := range m.storage {
+		cp[addr] = make(map[common.Hash]common.Hash, len(slots))
+		for k, v := range slots {
+			cp[addr][k] = v
+		}
+	}
+	m.snaps = append(m.snaps, cp)
+	return len(m.snaps) - 1
+}
+
+func (m *handlerMockStateDB) RevertToSnapshot(id int) {
+	m.storage = m.snaps[id]
+	m.snaps = m.snaps[:id]
+}
+
 // ---------- helpers ----------
 
 func makeCtx(db *handlerMockStateDB, from common.Address, blockNum uint64) *sysaction.Context {
@@ -81,6 +123,14 @@ func makeCtx(db *handlerMockStateDB, from common.Address, blockNum uint64) *sysa
 		Value:       big.NewInt(0),
 		BlockNumber: new(big.Int).SetUint64(blockNum),
 		StateDB:     db,
+		Coinbase:    testCoinbase,
+		Gas:         MaxCallbackGas,
+		Call: func(caller, target common.Address, input []byte, gasLimit uint64) (uint64, error) {
+			if db.call == nil {
+				return 21_000, nil
+			}
+			return db.call(db, target, input, gasLimit)
+		},
 	}
 }
 
@@ -97,12 +147,18 @@ var (
 	targetHex    = "0x473302ca547d5f9877e272cffe58d4def43198b66ba35cff4b2e584be19efa05"
 	txHashHex    = "0x00000000000000000000000000000000000000000000000000000000deadbeef"
 	strangerAddr = common.HexToAddress("0x3ccadfb801017cfb0f5dc61ef0e96fdaacbdb11c91ba5a230959e8d14020ea50")
+	testCoinbase = common.HexToAddress("0xc0ffee")
+
+	// testDeposit is the gas deposit escrowed for a 300_000 max_gas callback.
+	testDeposit = new(big.Int).Mul(big.NewInt(300_000), big.NewInt(params.TxPriceTomi))
 )
 
 // registerTestCallback is a helper that registers a callback and returns its ID.
 func registerTestCallback(t *testing.T, db *handlerMockStateDB, h *settlementHandler, from common.Address, blockNum uint64) common.Hash {
 	t.Helper()
 
+	db.AddBalance(from, testDeposit)
+	db.code[common.HexToAddress(targetHex)] = []byte("-- callback target")
 	ctx := makeCtx(db, from, blockNum)
 	sa := makeSysAction(sysaction.ActionSettlementRegisterCallback, RegisterCallbackPayload{
 		TxHash:       txHashHex,
@@ -128,6 +184,7 @@ func registerTestCallback(t *testing.T, db *handlerMockStateDB, h *settlementHan
 func TestHandleRegisterCallback_Success(t *testing.T) {
 	db := newHandlerMockStateDB()
 	h := &settlementHandler{}
+	db.AddBalance(creatorAddr, testDeposit)
 
 	ctx := makeCtx(db, creatorAddr, 100)
 	sa := makeSysAction(sysaction.ActionSettlementRegisterCallback, RegisterCallbackPayload{
@@ -172,6 +229,12 @@ func TestHandleRegisterCallback_Success(t *testing.T) {
 	if got := ReadCallbackCreator(db, cbID); got != creatorAddr {
 		t.Fatalf("creator mismatch: got %s", got.Hex())
 	}
+	if got := ReadCallbackDeposit(db, cbID); got.Cmp(testDeposit) != 0 {
+		t.Fatalf("deposit mismatch: got %s", got)
+	}
+	if got := db.GetBalance(registry); got.Cmp(testDeposit) != 0 {
+		t.Fatalf("registry should hold the deposit, got %s", got)
+	}
 }
 
 func TestHandleRegisterCallback_MissingTxHash(t *testing.T) {
@@ -350,6 +413,401 @@ func TestHandleExecuteCallback_NotCreator(t *testing.T) {
 	}
 }
 
+func TestHandleExecuteCallback_InvokesTarget(t *testing.T) {
+	db := newHandlerMockStateDB()
+	h := &settlementHandler{}
+	target := common.HexToAddress(targetHex)
+
+	var gotCaller, gotTarget common.Address
+	var gotInput []byte
+	cbID := registerTestCallback(t, db, h, creatorAddr, 100)
+	ctx := makeCtx(db, creatorAddr, 200)
+	ctx.Call = func(caller, to common.Address, input []byte, gasLimit uint64) (uint64, error) {
+		if gasLimit != 300_000 {
+			t.Fatalf("gas limit should be max_gas, got %d", gasLimit)
+		}
+		gotCaller, gotTarget, gotInput = caller, to, input
+		return 50_000, nil
+	}
+	if err := h.Handle(ctx, makeSysAction(sysaction.ActionSettlementExecuteCallback, ExecuteCallbackPayload{
+		CallbackID: cbID.Hex(),
+	})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if gotCaller != registry || gotTarget != target {
+		t.Fatalf("target should be called by the settlement registry, got %s -> %s", gotCaller.Hex(), gotTarget.Hex())
+	}
+	if data := ReadCallbackData(db, cbID); !bytes.Equal(gotInput, data[:]) {
+		t.Fatalf("calldata should be the callback data, got %x", gotInput)
+	}
+	if got := ReadCallbackGasUsed(db, cbID); got != 50_000 {
+		t.Fatalf("gas_used should be 50000, got %d", got)
+	}
+	// The transaction pays for the call's gas, so the whole deposit is refunded.
+	if got := db.GetBalance(creatorAddr); got.Cmp(testDeposit) != 0 {
+		t.Fatalf("creator refund: want %s, got %s", testDeposit, got)
+	}
+	if got := db.GetBalance(testCoinbase); got.Sign() != 0 {
+		t.Fatalf("coinbase is paid by the transaction fee, got %s from the deposit", got)
+	}
+	receipt, err := ReadRuntimeReceipt(db, CallbackReceiptRef(cbID))
+	if err != nil {
+		t.Fatalf("receipt: %v", err)
+	}
+	if receipt.Status != ReceiptStatusSuccess || receipt.ReceiptKind != ReceiptKindCallback ||
+		receipt.Sender != creatorAddr || receipt.Recipient != target || receipt.FinalizedAt != 200 {
+		t.Fatalf("unexpected receipt: %+v", receipt)
+	}
+}
+
+func TestHandleExecuteCallback_GasTooLow(t *testing.T) {
+	db := newHandlerMockStateDB()
+	h := &settlementHandler{}
+
+	cbID := registerTestCallback(t, db, h, creatorAddr, 100)
+	ctx := makeCtx(db, creatorAddr, 200)
+	ctx.Gas = 300_000 - 1
+	if err := h.Handle(ctx, makeSysAction(sysaction.ActionSettlementExecuteCallback, ExecuteCallbackPayload{
+		CallbackID: cbID.Hex(),
+	})); err != ErrCallbackGasTooLow {
+		t.Fatalf("expected ErrCallbackGasTooLow, got %v", err)
+	}
+	if got := ReadCallbackStatus(db, cbID); got != StatusPending {
+		t.Fatalf("status should stay pending, got %q", got)
+	}
+}
+
+func TestHandleExecuteCallback_FailureIsolated(t *testing.T) {
+	db := newHandlerMockStateDB()
+	h := &settlementHandler{}
+	target := common.HexToAddress(targetHex)
+	marker := common.Hash{0x01}
+
+	db.call = func(db *handlerMockStateDB, to common.Address, input []byte, gasLimit uint64) (uint64, error) {
+		db.SetState(to, marker, common.Hash{0xff})
+		return gasLimit, errors.New("lvm: reverted")
+	}
+	cbID := registerTestCallback(t, db, h, creatorAddr, 100)
+	if err := h.Handle(makeCtx(db, creatorAddr, 200), makeSysAction(sysaction.ActionSettlementExecuteCallback, ExecuteCallbackPayload{
+		CallbackID: cbID.Hex(),
+	})); err != nil {
+		t.Fatalf("a failing target must not fail the execution: %v", err)
+	}
+
+	if db.GetState(target, marker) != (common.Hash{}) {
+		t.Fatal("target writes should be reverted")
+	}
+	if got := ReadCallbackStatus(db, cbID); got != StatusFailed {
+		t.Fatalf("status should be failed, got %q", got)
+	}
+	if got := db.GetBalance(creatorAddr); got.Cmp(testDeposit) != 0 {
+		t.Fatalf("a prepaid callback refunds the whole deposit, got %s", got)
+	}
+	receipt, err := ReadRuntimeReceipt(db, CallbackReceiptRef(cbID))
+	if err != nil {
+		t.Fatalf("receipt: %v", err)
+	}
+	if receipt.Status != ReceiptStatusFailure || receipt.FailureRef == (common.Hash{}) {
+		t.Fatalf("unexpected receipt: %+v", receipt)
+	}
+}
+
+func TestHandleRegisterCallback_PolicyHash(t *testing.T) {
+	db := newHandlerMockStateDB()
+	h := &settlementHandler{}
+	db.AddBalance(creatorAddr, testDeposit)
+	register := func(policyHash common.Hash) error {
+		return h.Handle(makeCtx(db, creatorAddr, 100), makeSysAction(sysaction.ActionSettlementRegisterCallback, RegisterCallbackPayload{
+			TxHash:       txHashHex,
+			CallbackType: string(CallbackOnSettle),
+			Target:       targetHex,
+			PolicyHash:   policyHash.Hex(),
+			MaxGas:       300_000,
+			TTLBlocks:    1000,
+		}))
+	}
+
+	// A creator without a policy wallet cannot bind a callback to a policy.
+	if err := register(policywallet.PolicyHash(db, creatorAddr)); err != ErrPolicyMismatch {
+		t.Fatalf("no policy wallet: expected ErrPolicyMismatch, got %v", err)
+	}
+	policywallet.WriteOwner(db, creatorAddr, creatorAddr)
+	if err := register(common.HexToHash("0xcafe")); err != ErrPolicyMismatch {
+		t.Fatalf("unknown policy: expected ErrPolicyMismatch, got %v", err)
+	}
+	policywallet.WriteSuspended(db, creatorAddr, true)
+	if err := register(policywallet.PolicyHash(db, creatorAddr)); err != ErrPolicyMismatch {
+		t.Fatalf("suspended wallet: expected ErrPolicyMismatch, got %v", err)
+	}
+	policywallet.WriteSuspended(db, creatorAddr, false)
+	if err := register(policywallet.PolicyHash(db, creatorAddr)); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+}
+
+func TestHandleExecuteCallback_PolicyHash(t *testing.T) {
+	db := newHandlerMockStateDB()
+	h := &settlementHandler{}
+
+	policywallet.WriteOwner(db, creatorAddr, creatorAddr)
+	policywallet.WriteDailyLimit(db, creatorAddr, big.NewInt(1000))
+	db.AddBalance(creatorAddr, testDeposit)
+	db.code[common.HexToAddress(targetHex)] = []byte("-- callback target")
+	if err := h.Handle(makeCtx(db, creatorAddr, 100), makeSysAction(sysaction.ActionSettlementRegisterCallback, RegisterCallbackPayload{
+		TxHash:       txHashHex,
+		CallbackType: string(CallbackOnSettle),
+		Target:       targetHex,
+		PolicyHash:   policywallet.PolicyHash(db, creatorAddr).Hex(),
+		MaxGas:       300_000,
+		TTLBlocks:    1000,
+	})); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	cbID := mintCallbackID(creatorAddr, common.HexToHash(txHashHex), CallbackOnSettle, 0)
+	execute := makeSysAction(sysaction.ActionSettlementExecuteCallback, ExecuteCallbackPayload{CallbackID: cbID.Hex()})
+
+	// Raising the daily limit changes the policy the callback was bound to.
+	policywallet.WriteDailyLimit(db, creatorAddr, big.NewInt(2000))
+	if err := h.Handle(makeCtx(db, creatorAddr, 200), execute); err != ErrPolicyMismatch {
+		t.Fatalf("expected ErrPolicyMismatch, got %v", err)
+	}
+	policywallet.WriteDailyLimit(db, creatorAddr, big.NewInt(1000))
+	policywallet.WriteSuspended(db, creatorAddr, true)
+	if err := h.Handle(makeCtx(db, creatorAddr, 200), execute); err != ErrPolicyMismatch {
+		t.Fatalf("suspended wallet: expected ErrPolicyMismatch, got %v", err)
+	}
+	policywallet.WriteSuspended(db, creatorAddr, false)
+	if err := h.Handle(makeCtx(db, creatorAddr, 200), execute); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if got := ReadCallbackStatus(db, cbID); got != StatusExecuted {
+		t.Fatalf("status should be executed, got %q", got)
+	}
+}
+
+// ---------- Block processing ----------
+
+// registerTypedCallback registers a callback of cbType at blockNum with a
+// ttl of 10 blocks, optionally linked to receiptRef, and returns its ID.
+func registerTypedCallback(t *testing.T, db *handlerMockStateDB, cbType CallbackType, receiptRef string, blockNum uint64) common.Hash {
+	t.Helper()
+
+	db.AddBalance(creatorAddr, testDeposit)
+	db.code[common.HexToAddress(targetHex)] = []byte("-- callback target")
+	nonce := ReadCallbackCount(db)
+	if err := (&settlementHandler{}).Handle(makeCtx(db, creatorAddr, blockNum), makeSysAction(sysaction.ActionSettlementRegisterCallback, RegisterCallbackPayload{
+		TxHash:       txHashHex,
+		CallbackType: string(cbType),
+		Target:       targetHex,
+		MaxGas:       300_000,
+		TTLBlocks:    10,
+		ReceiptRef:   receiptRef,
+	})); err != nil {
+		t.Fatalf("register callback failed: %v", err)
+	}
+	return mintCallbackID(creatorAddr, common.HexToHash(txHashHex), cbType, nonce)
+}
+
+func testCaller(gasUsed uint64, calls *int) func(common.Address, common.Address, []byte, uint64) (uint64, error) {
+	return func(caller, target common.Address, input []byte, gasLimit uint64) (uint64, error) {
+		*calls++
+		return gasUsed, nil
+	}
+}
+
+func TestProcessDueCallbacks_Timeout(t *testing.T) {
+	db := newHandlerMockStateDB()
+	cbID := registerTypedCallback(t, db, CallbackOnTimeout, "", 100)
+
+	var calls int
+	if n := ProcessDueCallbacks(db, 109, testCoinbase, math.MaxUint64, testCaller(40_000, &calls)); n != 0 || calls != 0 {
+		t.Fatalf("nothing is due before expiry, processed %d, calls %d", n, calls)
+	}
+	if n := ProcessDueCallbacks(db, 110, testCoinbase, math.MaxUint64, testCaller(40_000, &calls)); n != 1 || calls != 1 {
+		t.Fatalf("on_timeout should fire at its expiry block, processed %d, calls %d", n, calls)
+	}
+	if got := ReadCallbackStatus(db, cbID); got != StatusExecuted {
+		t.Fatalf("status: want executed, got %s", got)
+	}
+	if got := ReadCallbackExecutedAt(db, cbID); got != 110 {
+		t.Fatalf("executed_at: want 110, got %d", got)
+	}
+	refund := new(big.Int).Mul(big.NewInt(260_000), big.NewInt(params.TxPriceTomi))
+	if got := db.GetBalance(creatorAddr); got.Cmp(refund) != 0 {
+		t.Fatalf("creator refund: want %s, got %s", refund, got)
+	}
+	spent := new(big.Int).Mul(big.NewInt(40_000), big.NewInt(params.TxPriceTomi))
+	if got := db.GetBalance(testCoinbase); got.Cmp(spent) != 0 {
+		t.Fatalf("coinbase: want %s, got %s", spent, got)
+	}
+	if got := db.GetBalance(registry); got.Sign() != 0 {
+		t.Fatalf("registry should hold nothing, got %s", got)
+	}
+	// The queue slot is consumed.
+	if n := ProcessDueCallbacks(db, 110, testCoinbase, math.MaxUint64, testCaller(40_000, &calls)); n != 0 {
+		t.Fatalf("queue should be drained, processed %d", n)
+	}
+}
+
+func TestProcessDueCallbacks_ExpiresAndRefunds(t *testing.T) {
+	db := newHandlerMockStateDB()
+	cbID := registerTypedCallback(t, db, CallbackOnSettle, "", 100)
+
+	var calls int
+	if n := ProcessDueCallbacks(db, 111, testCoinbase, math.MaxUint64, testCaller(40_000, &calls)); n != 1 || calls != 0 {
+		t.Fatalf("on_settle should expire without a call, processed %d, calls %d", n, calls)
+	}
+	if got := ReadCallbackStatus(db, cbID); got != StatusExpired {
+		t.Fatalf("status: want expired, got %s", got)
+	}
+	if got := db.GetBalance(creatorAddr); got.Cmp(testDeposit) != 0 {
+		t.Fatalf("the whole deposit should be refunded, got %s", got)
+	}
+	if got := db.GetBalance(registry); got.Sign() != 0 {
+		t.Fatalf("registry should hold nothing, got %s", got)
+	}
+}
+
+func TestProcessDueCallbacks_ReceiptFailure(t *testing.T) {
+	db := newHandlerMockStateDB()
+	receiptRef := common.HexToHash("0xabc1")
+	WriteRuntimeReceiptExists(db, receiptRef)
+	WriteRuntimeReceiptStatus(db, receiptRef, ReceiptStatusOpen)
+
+	// Only on_fail callbacks may be linked to a receipt.
+	db.AddBalance(creatorAddr, testDeposit)
+	err := (&settlementHandler{}).Handle(makeCtx(db, creatorAddr, 100), makeSysAction(sysaction.ActionSettlementRegisterCallback, RegisterCallbackPayload{
+		TxHash:       txHashHex,
+		CallbackType: string(CallbackOnSettle),
+		Target:       targetHex,
+		MaxGas:       300_000,
+		TTLBlocks:    10,
+		ReceiptRef:   receiptRef.Hex(),
+	}))
+	if !errors.Is(err, ErrReceiptLinkNotOnFail) {
+		t.Fatalf("expected ErrReceiptLinkNotOnFail, got %v", err)
+	}
+	db.SubBalance(creatorAddr, testDeposit)
+
+	cbID := registerTypedCallback(t, db, CallbackOnFail, receiptRef.Hex(), 100)
+	if got := ReadLinkedCallbackCount(db, receiptRef); got != 1 {
+		t.Fatalf("linked callbacks: want 1, got %d", got)
+	}
+
+	WriteRuntimeReceiptStatus(db, receiptRef, ReceiptStatusFailure)
+	TriggerReceiptFailure(db, receiptRef, 105)
+
+	var calls int
+	if n := ProcessDueCallbacks(db, 106, testCoinbase, math.MaxUint64, testCaller(40_000, &calls)); n != 1 || calls != 1 {
+		t.Fatalf("on_fail should fire after the receipt fails, processed %d, calls %d", n, calls)
+	}
+	if got := ReadCallbackStatus(db, cbID); got != StatusExecuted {
+		t.Fatalf("status: want executed, got %s", got)
+	}
+	// The expiry entry finds the callback settled and skips it.
+	if n := ProcessDueCallbacks(db, 111, testCoinbase, math.MaxUint64, testCaller(40_000, &calls)); n != 0 || calls != 1 {
+		t.Fatalf("executed callback should not be processed again, processed %d, calls %d", n, calls)
+	}
+}
+
+func TestProcessDueCallbacks_OnFailExpiresWithoutFailure(t *testing.T) {
+	db := newHandlerMockStateDB()
+	receiptRef := common.HexToHash("0xabc2")
+	WriteRuntimeReceiptExists(db, receiptRef)
+	WriteRuntimeReceiptStatus(db, receiptRef, ReceiptStatusOpen)
+	cbID := registerTypedCallback(t, db, CallbackOnFail, receiptRef.Hex(), 100)
+
+	var calls int
+	ProcessDueCallbacks(db, 111, testCoinbase, math.MaxUint64, testCaller(40_000, &calls))
+	if calls != 0 {
+		t.Fatal("on_fail should not fire while its receipt is open")
+	}
+	if got := ReadCallbackStatus(db, cbID); got != StatusExpired {
+		t.Fatalf("status: want expired, got %s", got)
+	}
+}
+
+func TestProcessDueCallbacks_PerBlockCap(t *testing.T) {
+	db := newHandlerMockStateDB()
+	for i := 0; i < MaxCallbacksPerBlock+3; i++ {
+		registerTypedCallback(t, db, CallbackOnTimeout, "", 100)
+	}
+
+	var calls int
+	if n := ProcessDueCallbacks(db, 110, testCoinbase, math.MaxUint64, testCaller(21_000, &calls)); n != MaxCallbacksPerBlock {
+		t.Fatalf("want %d processed, got %d", MaxCallbacksPerBlock, n)
+	}
+	if n := ProcessDueCallbacks(db, 111, testCoinbase, math.MaxUint64, testCaller(21_000, &calls)); n != 3 {
+		t.Fatalf("overflow should move to the next block, got %d", n)
+	}
+	if calls != MaxCallbacksPerBlock+3 {
+		t.Fatalf("want %d calls, got %d", MaxCallbacksPerBlock+3, calls)
+	}
+}
+
+func TestProcessDueCallbacks_GasBudget(t *testing.T) {
+	db := newHandlerMockStateDB()
+	var ids []common.Hash
+	for i := 0; i < 5; i++ {
+		ids = append(ids, registerTypedCallback(t, db, CallbackOnTimeout, "", 100))
+	}
+
+	// The pool holds two callbacks' max_gas; the rest move to the next block
+	// instead of failing the block.
+	var calls int
+	if n := ProcessDueCallbacks(db, 110, testCoinbase, 2*300_000+1, testCaller(300_000, &calls)); n != 2 || calls != 2 {
+		t.Fatalf("want 2 processed, got %d (calls %d)", n, calls)
+	}
+	for _, id := range ids[2:] {
+		if got := ReadCallbackStatus(db, id); got != StatusPending {
+			t.Fatalf("deferred callback status: want pending, got %s", got)
+		}
+	}
+	if n := ProcessDueCallbacks(db, 111, testCoinbase, math.MaxUint64, testCaller(300_000, &calls)); n != 3 {
+		t.Fatalf("deferred callbacks should run at the next block, got %d", n)
+	}
+}
+
+func TestProcessDueCallbacks_DeferredCountTowardCap(t *testing.T) {
+	db := newHandlerMockStateDB()
+	for i := 0; i < MaxCallbacksPerBlock; i++ {
+		registerTypedCallback(t, db, CallbackOnTimeout, "", 100)
+	}
+	nonce := ReadCallbackCount(db)
+	db.AddBalance(creatorAddr, testDeposit)
+	if err := (&settlementHandler{}).Handle(makeCtx(db, creatorAddr, 100), makeSysAction(sysaction.ActionSettlementRegisterCallback, RegisterCallbackPayload{
+		TxHash:       txHashHex,
+		CallbackType: string(CallbackOnTimeout),
+		Target:       targetHex,
+		MaxGas:       21_000,
+		TTLBlocks:    10,
+	})); err != nil {
+		t.Fatalf("register callback failed: %v", err)
+	}
+	small := mintCallbackID(creatorAddr, common.HexToHash(txHashHex), CallbackOnTimeout, nonce)
+
+	// Callbacks moved for lack of gas use up the block's slots, so the one
+	// that would fit waits for the next block as well.
+	var calls int
+	if n := ProcessDueCallbacks(db, 110, testCoinbase, 100_000, testCaller(21_000, &calls)); n != 0 || calls != 0 {
+		t.Fatalf("want 0 processed, got %d (calls %d)", n, calls)
+	}
+	if got := ReadCallbackStatus(db, small); got != StatusPending {
+		t.Fatalf("small callback status: want pending, got %s", got)
+	}
+	if n := ProcessDueCallbacks(db, 111, testCoinbase, math.MaxUint64, testCaller(21_000, &calls)); n != MaxCallbacksPerBlock {
+		t.Fatalf("want %d processed at the next block, got %d", MaxCallbacksPerBlock, n)
+	}
+}
+
+func TestScheduledWorkFitsBlockGasLimit(t *testing.T) {
+	total := params.TaskMaxPerBlock*params.TaskMaxGasLimit + uint64(MaxCallbacksPerBlock)*MaxCallbackGas
+	if total > params.GenesisGasLimit {
+		t.Fatalf("scheduled tasks and callbacks may use %d gas, above the %d block gas limit", total, params.GenesisGasLimit)
+	}
+}
+
 // ---------- FulfillAsync ----------
 
 func TestHandleFulfillAsync_Success(t *testing.T) {
@@ -0,0 +1,255 @@
+package settlement
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/tos-network/gtos/common"
+	vmtypes "github.com/tos-network/gtos/core/vmtypes"
+	"github.com/tos-network/gtos/crypto"
+	"github.com/tos-network/gtos/params"
+	"github.com/tos-network/gtos/policywallet"
+	"github.com/tos-network/gtos/sysaction"
+)
+
+// ---------- Expiry queue ----------
+//
+// Callbacks that need attention at a given block are indexed under that
+// block, like task.EnqueueTask: on_timeout callbacks at their expiry block,
+// other callbacks one block after it (to expire them and refund the
+// deposit), and on_fail callbacks in the block after their linked receipt
+// fails.
+
+// cbQlenSlot returns the slot for the callback queue length at blockNum.
+func cbQlenSlot(blockNum uint64) common.Hash {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], blockNum)
+	return common.BytesToHash(crypto.Keccak256(append([]byte("cb\x00qlen\x00"), buf[:]...)))
+}
+
+// cbQEntrySlot returns the slot for the i-th callback queued at blockNum.
+func cbQEntrySlot(blockNum, i uint64) common.Hash {
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], blockNum)
+	binary.BigEndian.PutUint64(buf[8:], i)
+	return common.BytesToHash(crypto.Keccak256(append([]byte("cb\x00q\x00"), buf[:]...)))
+}
+
+// EnqueueCallback queues callback id for processing at blockNum.
+func EnqueueCallback(db stateDB, blockNum uint64, id common.Hash) {
+	n := readUint64Slot(db, cbQlenSlot(blockNum))
+	db.SetState(registry, cbQEntrySlot(blockNum, n), id)
+	writeUint64Slot(db, cbQlenSlot(blockNum), n+1)
+}
+
+// DequeueCallbacksAt returns the callbacks queued at blockNum and resets the
+// queue length to 0.
+func DequeueCallbacksAt(db stateDB, blockNum uint64) []common.Hash {
+	n := readUint64Slot(db, cbQlenSlot(blockNum))
+	if n == 0 {
+		return nil
+	}
+	ids := make([]common.Hash, n)
+	for i := uint64(0); i < n; i++ {
+		ids[i] = db.GetState(registry, cbQEntrySlot(blockNum, i))
+	}
+	db.SetState(registry, cbQlenSlot(blockNum), common.Hash{})
+	return ids
+}
+
+// dueBlock returns the block at which a newly registered callback is queued.
+func dueBlock(cbType CallbackType, expiresAt uint64) uint64 {
+	if cbType == CallbackOnTimeout {
+		return expiresAt
+	}
+	return expiresAt + 1
+}
+
+// ---------- Receipt links ----------
+
+// receiptLinkSlot returns the slot for the i-th on_fail callback linked to
+// receiptRef; i = MaxLinkedCallbacks holds the count.
+func receiptLinkSlot(receiptRef common.Hash, i uint64) common.Hash {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], i)
+	key := append([]byte("cb\x00link\x00"), receiptRef[:]...)
+	return common.BytesToHash(crypto.Keccak256(append(key, buf[:]...)))
+}
+
+// ReadCallbackReceiptRef returns the runtime receipt an on_fail callback is
+// linked to, or the zero hash.
+func ReadCallbackReceiptRef(db stateDB, id common.Hash) common.Hash {
+	return db.GetState(registry, cbSlot(id, "receiptRef"))
+}
+
+// ReadLinkedCallbackCount returns how many on_fail callbacks are linked to receiptRef.
+func ReadLinkedCallbackCount(db stateDB, receiptRef common.Hash) uint64 {
+	return readUint64Slot(db, receiptLinkSlot(receiptRef, MaxLinkedCallbacks))
+}
+
+// linkCallback links on_fail callback id to receiptRef.
+func linkCallback(db stateDB, receiptRef, id common.Hash) error {
+	n := ReadLinkedCallbackCount(db, receiptRef)
+	if n >= MaxLinkedCallbacks {
+		return ErrTooManyLinkedCallbacks
+	}
+	db.SetState(registry, cbSlot(id, "receiptRef"), receiptRef)
+	db.SetState(registry, receiptLinkSlot(receiptRef, n), id)
+	writeUint64Slot(db, receiptLinkSlot(receiptRef, MaxLinkedCallbacks), n+1)
+	return nil
+}
+
+// TriggerReceiptFailure queues the on_fail callbacks linked to receiptRef
+// to fire in the block after blockNum. Every path that finalizes a runtime
+// receipt as failed calls it.
+func TriggerReceiptFailure(db stateDB, receiptRef common.Hash, blockNum uint64) {
+	n := ReadLinkedCallbackCount(db, receiptRef)
+	for i := uint64(0); i < n; i++ {
+		EnqueueCallback(db, blockNum+1, db.GetState(registry, receiptLinkSlot(receiptRef, i)))
+	}
+}
+
+// ---------- Firing ----------
+
+// checkCallbackPolicy verifies that creator's policy wallet still carries
+// the policy callback id was registered under, if it named one.
+func checkCallbackPolicy(db stateDB, id common.Hash, creator common.Address) error {
+	policyHash := ReadCallbackPolicyHash(db, id)
+	if policyHash == (common.Hash{}) {
+		return nil
+	}
+	return requirePolicy(db, creator, policyHash)
+}
+
+// requirePolicy verifies that creator has a policy wallet that is not
+// suspended and whose current policy digest is policyHash.
+func requirePolicy(db stateDB, creator common.Address, policyHash common.Hash) error {
+	if policywallet.ReadOwner(db, creator) == (common.Address{}) ||
+		policywallet.ReadSuspended(db, creator) || policywallet.PolicyHash(db, creator) != policyHash {
+		return ErrPolicyMismatch
+	}
+	return nil
+}
+
+// runCallback calls the target of pending callback id with its callback
+// data under MaxGas and finalizes it. Only the target's writes are
+// reverted on failure. prepaid is set when the executing transaction pays
+// for the call's gas itself.
+func runCallback(db vmtypes.StateDB, call sysaction.ContractCaller, coinbase common.Address, id common.Hash, blockNum uint64, prepaid bool) uint64 {
+	target := ReadCallbackTarget(db, id)
+	data := ReadCallbackData(db, id)
+	maxGas := ReadCallbackMaxGas(db, id)
+	var (
+		gasUsed uint64
+		callErr error
+	)
+	if len(db.GetCode(target)) == 0 {
+		callErr = ErrTargetNoCode
+	} else {
+		snap := db.Snapshot()
+		gasUsed, callErr = call(registry, target, data[:], maxGas)
+		if gasUsed > maxGas {
+			gasUsed = maxGas
+		}
+		if callErr != nil {
+			db.RevertToSnapshot(snap)
+		}
+	}
+	finishCallback(db, id, coinbase, gasUsed, callErr, blockNum, prepaid)
+	return gasUsed
+}
+
+// finishCallback pays the gas spent to the block producer, refunds the
+// unused part of the deposit to the creator, records the outcome on the
+// callback and writes its runtime receipt. If the gas was prepaid by the
+// executing transaction, the whole deposit is refunded.
+func finishCallback(db vmtypes.StateDB, id common.Hash, coinbase common.Address, gasUsed uint64, callErr error, blockNum uint64, prepaid bool) {
+	creator := ReadCallbackCreator(db, id)
+	spent := new(big.Int)
+	if !prepaid {
+		spent.Mul(new(big.Int).SetUint64(gasUsed), big.NewInt(params.TxPriceTomi))
+	}
+	if spent.Sign() > 0 {
+		db.SubBalance(registry, spent)
+		db.AddBalance(coinbase, spent)
+	}
+	if refund := new(big.Int).Sub(ReadCallbackDeposit(db, id), spent); refund.Sign() > 0 {
+		db.SubBalance(registry, refund)
+		db.AddBalance(creator, refund)
+	}
+
+	outcome := StatusExecuted
+	if callErr != nil {
+		outcome = StatusFailed
+	}
+	WriteCallbackStatus(db, id, outcome)
+	WriteCallbackExecutedAt(db, id, blockNum)
+	WriteCallbackGasUsed(db, id, gasUsed)
+	writeCallbackReceipt(db, id, creator, ReadCallbackTarget(db, id), blockNum, callErr)
+}
+
+// expireCallback marks pending callback id expired and refunds its deposit.
+func expireCallback(db vmtypes.StateDB, id common.Hash) {
+	if deposit := ReadCallbackDeposit(db, id); deposit.Sign() > 0 {
+		db.SubBalance(registry, deposit)
+		db.AddBalance(ReadCallbackCreator(db, id), deposit)
+	}
+	WriteCallbackStatus(db, id, StatusExpired)
+}
+
+// ProcessDueCallbacks handles the callbacks queued at blockNum. It is called
+// with task.ProcessDueTasks by both Process() and the miner before user
+// transactions. A pending callback fires if it is an on_timeout callback
+// that has reached its expiry block or an on_fail callback whose linked
+// receipt has failed; otherwise it expires once past ExpiresAt. Like
+// scheduled tasks, a target that emits logs fails, since the call has no
+// transaction receipt to carry them. A callback whose max_gas exceeds what
+// is left of gasBudget is not run but moved to the next block. At most
+// MaxCallbacksPerBlock callbacks are handled or moved that way; the rest also
+// move to the next block. The gas callbacks spend is paid to coinbase.
+// Returns the number handled.
+func ProcessDueCallbacks(db vmtypes.StateDB, blockNum uint64, coinbase common.Address, gasBudget uint64, call sysaction.ContractCaller) int {
+	ids := DequeueCallbacksAt(db, blockNum)
+	processed, deferred := 0, 0
+	noLogs := func(caller, target common.Address, input []byte, gasLimit uint64) (uint64, error) {
+		pre := len(db.Logs())
+		gasUsed, err := call(caller, target, input, gasLimit)
+		if err == nil && len(db.Logs()) != pre {
+			err = ErrCallbackEmittedLogs
+		}
+		return gasUsed, err
+	}
+	for i, id := range ids {
+		if processed+deferred >= MaxCallbacksPerBlock {
+			for _, rest := range ids[i:] {
+				EnqueueCallback(db, blockNum+1, rest)
+			}
+			break
+		}
+		if ReadCallbackStatus(db, id) != StatusPending {
+			continue
+		}
+		expiresAt := ReadCallbackExpiresAt(db, id)
+		cbType := ReadCallbackType(db, id)
+		fire := (cbType == CallbackOnTimeout && blockNum >= expiresAt) ||
+			(cbType == CallbackOnFail && ReadRuntimeReceiptStatus(db, ReadCallbackReceiptRef(db, id)) == ReceiptStatusFailure)
+		switch {
+		case fire:
+			if err := checkCallbackPolicy(db, id, ReadCallbackCreator(db, id)); err != nil {
+				finishCallback(db, id, coinbase, 0, err, blockNum, false)
+			} else if ReadCallbackMaxGas(db, id) > gasBudget {
+				EnqueueCallback(db, blockNum+1, id)
+				deferred++
+				continue
+			} else {
+				gasBudget -= runCallback(db, noLogs, coinbase, id, blockNum, false)
+			}
+		case blockNum > expiresAt:
+			expireCallback(db, id)
+		default:
+			continue
+		}
+		processed++
+	}
+	return processed
+}
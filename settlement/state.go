@@ -212,6 +212,32 @@ func WriteCallbackCreator(db stateDB, id common.Hash, creator common.Address) {
 	db.SetState(registry, cbSlot(id, "creator"), val)
 }
 
+// ReadCallbackDeposit returns the gas deposit escrowed for a callback.
+func ReadCallbackDeposit(db stateDB, id common.Hash) *big.Int {
+	return db.GetState(registry, cbSlot(id, "deposit")).Big()
+}
+
+// WriteCallbackDeposit writes the gas deposit.
+func WriteCallbackDeposit(db stateDB, id common.Hash, deposit *big.Int) {
+	db.SetState(registry, cbSlot(id, "deposit"), common.BigToHash(deposit))
+}
+
+// ReadCallbackGasUsed returns the gas the callback's target consumed.
+func ReadCallbackGasUsed(db stateDB, id common.Hash) uint64 {
+	return readUint64Slot(db, cbSlot(id, "gasUsed"))
+}
+
+// WriteCallbackGasUsed writes the gas used.
+func WriteCallbackGasUsed(db stateDB, id common.Hash, gasUsed uint64) {
+	writeUint64Slot(db, cbSlot(id, "gasUsed"), gasUsed)
+}
+
+// CallbackReceiptRef returns the ref of the runtime receipt recorded when
+// callback id is executed.
+func CallbackReceiptRef(id common.Hash) common.Hash {
+	return common.BytesToHash(crypto.Keccak256([]byte("cb\x00receipt\x00"), id[:]))
+}
+
 // ---------- Fulfillment CRUD ----------
 
 // ReadFulfillmentExists returns true if a fulfillment record exists.
@@ -40,6 +40,10 @@ const (
 	ModeEscrowReleaseUno    uint16 = 6
 )
 
+// ReceiptKindCallback tags the runtime receipts recorded for executed
+// settlement callbacks.
+const ReceiptKindCallback uint16 = 0x0112
+
 // CallbackStatus tracks the lifecycle of a callback.
 const (
 	StatusPending  = "pending"
@@ -95,7 +99,8 @@ type RegisterCallbackPayload struct {
 	CallbackData string `json:"callback_data,omitempty"` // hex 32 bytes
 	PolicyHash   string `json:"policy_hash,omitempty"`   // hex 32 bytes
 	MaxGas       uint64 `json:"max_gas"`
-	TTLBlocks    uint64 `json:"ttl_blocks"` // expires at current block + ttl
+	TTLBlocks    uint64 `json:"ttl_blocks"`            // expires at current block + ttl
+	ReceiptRef   string `json:"receipt_ref,omitempty"` // hex 32 bytes; on_fail only
 }
 
 // ExecuteCallbackPayload is the payload for SETTLEMENT_EXECUTE_CALLBACK.
@@ -114,28 +119,46 @@ type FulfillAsyncPayload struct {
 
 // Sentinel errors returned by settlement handlers.
 var (
-	ErrCallbackNotFound      = errors.New("settlement: callback not found")
-	ErrCallbackNotPending    = errors.New("settlement: callback is not pending")
-	ErrCallbackExpired       = errors.New("settlement: callback has expired")
-	ErrNotCallbackCreator    = errors.New("settlement: caller is not callback creator")
-	ErrInvalidCallbackType   = errors.New("settlement: invalid callback type")
-	ErrInvalidTarget         = errors.New("settlement: target address is zero")
-	ErrMaxGasZero            = errors.New("settlement: max_gas must be > 0")
-	ErrTTLZero               = errors.New("settlement: ttl_blocks must be > 0")
-	ErrTTLTooLong            = errors.New("settlement: ttl_blocks exceeds maximum")
-	ErrInvalidTxHash         = errors.New("settlement: tx_hash must not be zero")
-	ErrFulfillmentNotAllowed = errors.New("settlement: fulfillment not allowed")
-	ErrReceiptNotFound       = errors.New("settlement: receipt not found")
-	ErrReceiptAlreadyExists  = errors.New("settlement: receipt already exists")
-	ErrReceiptNotOpen        = errors.New("settlement: receipt is not open")
-	ErrSettlementNotFound    = errors.New("settlement: settlement not found")
-	ErrInvalidSettlementMode = errors.New("settlement: invalid settlement mode")
+	ErrCallbackNotFound       = errors.New("settlement: callback not found")
+	ErrCallbackNotPending     = errors.New("settlement: callback is not pending")
+	ErrCallbackExpired        = errors.New("settlement: callback has expired")
+	ErrNotCallbackCreator     = errors.New("settlement: caller is not callback creator")
+	ErrInvalidCallbackType    = errors.New("settlement: invalid callback type")
+	ErrInvalidTarget          = errors.New("settlement: target address is zero")
+	ErrMaxGasZero             = errors.New("settlement: max_gas must be > 0")
+	ErrTTLZero                = errors.New("settlement: ttl_blocks must be > 0")
+	ErrTTLTooLong             = errors.New("settlement: ttl_blocks exceeds maximum")
+	ErrInvalidTxHash          = errors.New("settlement: tx_hash must not be zero")
+	ErrFulfillmentNotAllowed  = errors.New("settlement: fulfillment not allowed")
+	ErrReceiptNotFound        = errors.New("settlement: receipt not found")
+	ErrReceiptAlreadyExists   = errors.New("settlement: receipt already exists")
+	ErrReceiptNotOpen         = errors.New("settlement: receipt is not open")
+	ErrSettlementNotFound     = errors.New("settlement: settlement not found")
+	ErrInvalidSettlementMode  = errors.New("settlement: invalid settlement mode")
+	ErrMaxGasTooHigh          = errors.New("settlement: max_gas exceeds maximum")
+	ErrInsufficientDeposit    = errors.New("settlement: insufficient balance for callback gas deposit")
+	ErrPolicyMismatch         = errors.New("settlement: creator policy wallet does not match policy_hash")
+	ErrCallerUnavailable      = errors.New("settlement: contract runtime unavailable")
+	ErrTargetNoCode           = errors.New("settlement: callback target has no contract code")
+	ErrReceiptLinkNotOnFail   = errors.New("settlement: receipt_ref is only valid for on_fail callbacks")
+	ErrTooManyLinkedCallbacks = errors.New("settlement: too many callbacks linked to receipt")
+	ErrCallbackEmittedLogs    = errors.New("settlement: callback target emitted logs")
+	ErrCallbackGasTooLow      = errors.New("settlement: transaction gas below callback max_gas")
 )
 
 // Settlement parameter limits.
 const (
 	MaxTTLBlocks       uint64 = 1_000_000
 	MaxCallbackDataLen        = 32
+	MaxCallbackGas     uint64 = 500_000
+
+	// MaxLinkedCallbacks caps the on_fail callbacks linked to one receipt.
+	MaxLinkedCallbacks uint64 = 8
+	// MaxCallbacksPerBlock caps the queued callbacks handled in one block;
+	// the rest move to the next block. Together with the scheduled task
+	// budget (TaskMaxPerBlock × TaskMaxGasLimit), MaxCallbacksPerBlock ×
+	// MaxCallbackGas stays within the genesis block gas limit.
+	MaxCallbacksPerBlock = 10
 )
 
 // validCallbackTypes contains the valid callback type values.
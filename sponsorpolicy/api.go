@@ -0,0 +1,73 @@
+package sponsorpolicy
+
+import (
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/common/hexutil"
+)
+
+// PolicyResult is the JSON-friendly result for GetPolicy.
+type PolicyResult struct {
+	PolicyHash  common.Hash      `json:"policy_hash"`
+	Sponsor     common.Address   `json:"sponsor"`
+	Targets     []common.Address `json:"targets"`
+	Selectors   []hexutil.Bytes  `json:"selectors"`
+	MaxGasPerTx uint64           `json:"max_gas_per_tx"`
+	DailyBudget string           `json:"daily_budget"`
+	Expiry      uint64           `json:"expiry"`
+	Status      string           `json:"status"`
+}
+
+// PublicSponsorPolicyAPI provides RPC methods for querying sponsor policies.
+type PublicSponsorPolicyAPI struct {
+	stateReader func() StateDB
+}
+
+// NewPublicSponsorPolicyAPI creates a new sponsor policy API instance.
+func NewPublicSponsorPolicyAPI(stateReader func() StateDB) *PublicSponsorPolicyAPI {
+	return &PublicSponsorPolicyAPI{stateReader: stateReader}
+}
+
+// GetPolicy returns the policy registered under policyHash.
+func (api *PublicSponsorPolicyAPI) GetPolicy(policyHash common.Hash) (*PolicyResult, error) {
+	db := api.stateReader()
+	p := ReadPolicy(db, policyHash)
+	if p == nil {
+		return nil, ErrPolicyNotFound
+	}
+	res := &PolicyResult{
+		PolicyHash:  policyHash,
+		Sponsor:     p.Sponsor,
+		Targets:     p.Targets,
+		Selectors:   make([]hexutil.Bytes, 0, len(p.Selectors)),
+		MaxGasPerTx: p.MaxGasPerTx,
+		DailyBudget: p.DailyBudget.String(),
+		Expiry:      p.Expiry,
+		Status:      "active",
+	}
+	if res.Targets == nil {
+		res.Targets = []common.Address{}
+	}
+	for _, sel := range p.Selectors {
+		res.Selectors = append(res.Selectors, append(hexutil.Bytes(nil), sel[:]...))
+	}
+	if ReadStatus(db, policyHash) == StatusRevoked {
+		res.Status = "revoked"
+	}
+	return res, nil
+}
+
+// ComputePolicyHash returns the hash sponsor's policy would be registered
+// under, for filling in SponsorPolicyHash before the policy is on chain.
+func (api *PublicSponsorPolicyAPI) ComputePolicyHash(sponsor common.Address, args RegisterPolicyPayload) (common.Hash, error) {
+	p, err := PolicyFromPayload(sponsor, &args)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return p.Hash(), nil
+}
+
+// GetSpent returns what sender has spent under the policy in budget period
+// day, where day is a block time in milliseconds divided by DayMs.
+func (api *PublicSponsorPolicyAPI) GetSpent(policyHash common.Hash, sender common.Address, day hexutil.Uint64) (*hexutil.Big, error) {
+	return (*hexutil.Big)(ReadSpent(api.stateReader(), policyHash, sender, uint64(day))), nil
+}
@@ -0,0 +1,106 @@
+package sponsorpolicy
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/sysaction"
+)
+
+func init() {
+	sysaction.DefaultRegistry.Register(&sponsorPolicyHandler{})
+}
+
+type sponsorPolicyHandler struct{}
+
+func (h *sponsorPolicyHandler) Actions() []sysaction.ActionKind {
+	return []sysaction.ActionKind{
+		sysaction.ActionSponsorPolicyRegister,
+		sysaction.ActionSponsorPolicyRevoke,
+	}
+}
+
+func (h *sponsorPolicyHandler) Handle(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	switch sa.Action {
+	case sysaction.ActionSponsorPolicyRegister:
+		return h.handleRegister(ctx, sa)
+	case sysaction.ActionSponsorPolicyRevoke:
+		return h.handleRevoke(ctx, sa)
+	}
+	return nil
+}
+
+// PolicyFromPayload builds the policy sponsor registers with p, with its
+// targets and selectors sorted.
+func PolicyFromPayload(sponsor common.Address, p *RegisterPolicyPayload) (*Policy, error) {
+	if len(p.Targets) > MaxTargets {
+		return nil, ErrTooManyTargets
+	}
+	if len(p.Selectors) > MaxSelectors {
+		return nil, ErrTooManySelectors
+	}
+	policy := &Policy{
+		Sponsor:     sponsor,
+		Targets:     append([]common.Address(nil), p.Targets...),
+		MaxGasPerTx: p.MaxGasPerTx,
+		DailyBudget: new(big.Int),
+		Expiry:      p.Expiry,
+	}
+	for _, sel := range p.Selectors {
+		if len(sel) != SelectorLength {
+			return nil, ErrInvalidSelector
+		}
+		var s [SelectorLength]byte
+		copy(s[:], sel)
+		policy.Selectors = append(policy.Selectors, s)
+	}
+	if p.DailyBudget != "" {
+		budget, ok := new(big.Int).SetString(p.DailyBudget, 10)
+		if !ok || budget.Sign() < 0 || budget.BitLen() > 256 {
+			return nil, ErrInvalidBudget
+		}
+		policy.DailyBudget = budget
+	}
+	if !policy.normalize() {
+		return nil, ErrDuplicateEntry
+	}
+	return policy, nil
+}
+
+func (h *sponsorPolicyHandler) handleRegister(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p RegisterPolicyPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	policy, err := PolicyFromPayload(ctx.From, &p)
+	if err != nil {
+		return err
+	}
+	// A revoked policy can be registered again; it reactivates unchanged.
+	hash := policy.Hash()
+	if ReadStatus(ctx.StateDB, hash) == StatusActive {
+		return ErrPolicyExists
+	}
+	writePolicy(ctx.StateDB, hash, policy)
+	return nil
+}
+
+func (h *sponsorPolicyHandler) handleRevoke(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p RevokePolicyPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	policy := ReadPolicy(ctx.StateDB, p.PolicyHash)
+	if policy == nil {
+		return ErrPolicyNotFound
+	}
+	if policy.Sponsor != ctx.From {
+		return ErrNotSponsor
+	}
+	if ReadStatus(ctx.StateDB, p.PolicyHash) == StatusRevoked {
+		return ErrPolicyRevoked
+	}
+	writeStatus(ctx.StateDB, p.PolicyHash, StatusRevoked)
+	return nil
+}
@@ -0,0 +1,189 @@
+package sponsorpolicy
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/common/hexutil"
+	"github.com/tos-network/gtos/core/rawdb"
+	"github.com/tos-network/gtos/core/state"
+	"github.com/tos-network/gtos/sysaction"
+)
+
+func newTestState() *state.StateDB {
+	s, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	return s
+}
+
+var (
+	sponsorAddr = common.HexToAddress("0x5b0b")
+	senderAddr  = common.HexToAddress("0xa11ce")
+	targetA     = common.HexToAddress("0xc0a")
+	targetB     = common.HexToAddress("0xc0b")
+	transferSel = hexutil.Bytes{0xa9, 0x05, 0x9c, 0xbb}
+)
+
+func handle(t *testing.T, db *state.StateDB, from common.Address, kind sysaction.ActionKind, payload interface{}) error {
+	t.Helper()
+	data, err := sysaction.MakeSysAction(kind, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sysaction.ExecuteWithContext(&sysaction.Context{
+		From:        from,
+		Value:       new(big.Int),
+		BlockNumber: big.NewInt(1),
+		StateDB:     db,
+	}, data)
+}
+
+func registerPolicy(t *testing.T, db *state.StateDB, p RegisterPolicyPayload) common.Hash {
+	t.Helper()
+	if err := handle(t, db, sponsorAddr, sysaction.ActionSponsorPolicyRegister, p); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := PolicyFromPayload(sponsorAddr, &p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return policy.Hash()
+}
+
+func TestPolicyHashIgnoresOrder(t *testing.T) {
+	a, _ := PolicyFromPayload(sponsorAddr, &RegisterPolicyPayload{Targets: []common.Address{targetA, targetB}})
+	b, _ := PolicyFromPayload(sponsorAddr, &RegisterPolicyPayload{Targets: []common.Address{targetB, targetA}})
+	if a.Hash() != b.Hash() {
+		t.Fatal("target order should not change the policy hash")
+	}
+	c, _ := PolicyFromPayload(senderAddr, &RegisterPolicyPayload{Targets: []common.Address{targetA, targetB}})
+	if a.Hash() == c.Hash() {
+		t.Fatal("policies of different sponsors must not share a hash")
+	}
+	if _, err := PolicyFromPayload(sponsorAddr, &RegisterPolicyPayload{Targets: []common.Address{targetA, targetA}}); !errors.Is(err, ErrDuplicateEntry) {
+		t.Fatalf("expected ErrDuplicateEntry, got %v", err)
+	}
+	if _, err := PolicyFromPayload(sponsorAddr, &RegisterPolicyPayload{Selectors: []hexutil.Bytes{{1, 2, 3}}}); !errors.Is(err, ErrInvalidSelector) {
+		t.Fatalf("expected ErrInvalidSelector, got %v", err)
+	}
+}
+
+func TestRegisterAndRevoke(t *testing.T) {
+	db := newTestState()
+	payload := RegisterPolicyPayload{
+		Targets:     []common.Address{targetB, targetA},
+		Selectors:   []hexutil.Bytes{transferSel},
+		MaxGasPerTx: 100_000,
+		DailyBudget: "5000",
+		Expiry:      1_000_000,
+	}
+	hash := registerPolicy(t, db, payload)
+	if err := handle(t, db, sponsorAddr, sysaction.ActionSponsorPolicyRegister, payload); !errors.Is(err, ErrPolicyExists) {
+		t.Fatalf("expected ErrPolicyExists, got %v", err)
+	}
+
+	api := NewPublicSponsorPolicyAPI(func() StateDB { return db })
+	res, err := api.GetPolicy(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Sponsor != sponsorAddr || len(res.Targets) != 2 || res.Targets[0] != targetA ||
+		len(res.Selectors) != 1 || res.DailyBudget != "5000" || res.Status != "active" {
+		t.Fatalf("unexpected policy: %+v", res)
+	}
+	if computed, _ := api.ComputePolicyHash(sponsorAddr, payload); computed != hash {
+		t.Fatalf("ComputePolicyHash = %s, want %s", computed.Hex(), hash.Hex())
+	}
+
+	revoke := RevokePolicyPayload{PolicyHash: hash}
+	if err := handle(t, db, senderAddr, sysaction.ActionSponsorPolicyRevoke, revoke); !errors.Is(err, ErrNotSponsor) {
+		t.Fatalf("expected ErrNotSponsor, got %v", err)
+	}
+	if err := handle(t, db, sponsorAddr, sysaction.ActionSponsorPolicyRevoke, revoke); err != nil {
+		t.Fatal(err)
+	}
+	if err := handle(t, db, sponsorAddr, sysaction.ActionSponsorPolicyRevoke, revoke); !errors.Is(err, ErrPolicyRevoked) {
+		t.Fatalf("expected ErrPolicyRevoked, got %v", err)
+	}
+	err = Check(db, hash, sponsorAddr, senderAddr, &targetA, transferSel, 21_000, big.NewInt(1), 0)
+	if !errors.Is(err, ErrPolicyRevoked) {
+		t.Fatalf("expected ErrPolicyRevoked, got %v", err)
+	}
+
+	// Registering the same policy again reactivates it.
+	registerPolicy(t, db, payload)
+	if ReadStatus(db, hash) != StatusActive {
+		t.Fatal("policy should be active again")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	db := newTestState()
+	hash := registerPolicy(t, db, RegisterPolicyPayload{
+		Targets:     []common.Address{targetA},
+		Selectors:   []hexutil.Bytes{transferSel},
+		MaxGasPerTx: 100_000,
+		Expiry:      1_000_000,
+	})
+	data := append(hexutil.Bytes{}, transferSel...)
+	data = append(data, make([]byte, 64)...)
+	cost := big.NewInt(1)
+
+	if err := Check(db, common.Hash{}, sponsorAddr, senderAddr, nil, nil, 0, cost, 0); err != nil {
+		t.Fatalf("zero policy hash should not be checked: %v", err)
+	}
+	if err := Check(db, hash, sponsorAddr, senderAddr, &targetA, data, 50_000, cost, 500_000); err != nil {
+		t.Fatalf("expected pass, got %v", err)
+	}
+	for _, tc := range []struct {
+		name    string
+		sponsor common.Address
+		to      *common.Address
+		data    []byte
+		gas     uint64
+		now     uint64
+		want    error
+	}{
+		{"unknown", sponsorAddr, &targetA, data, 50_000, 0, ErrPolicyNotFound},
+		{"other sponsor", senderAddr, &targetA, data, 50_000, 0, ErrNotSponsor},
+		{"expired", sponsorAddr, &targetA, data, 50_000, 1_000_001, ErrPolicyExpired},
+		{"gas", sponsorAddr, &targetA, data, 100_001, 0, ErrGasAbovePolicy},
+		{"target", sponsorAddr, &targetB, data, 50_000, 0, ErrTargetNotAllowed},
+		{"create", sponsorAddr, nil, data, 50_000, 0, ErrTargetNotAllowed},
+		{"selector", sponsorAddr, &targetA, []byte{1, 2, 3, 4}, 50_000, 0, ErrSelectorForbidden},
+		{"no data", sponsorAddr, &targetA, nil, 50_000, 0, ErrSelectorForbidden},
+	} {
+		h := hash
+		if tc.name == "unknown" {
+			h = common.HexToHash("0x01")
+		}
+		if err := Check(db, h, tc.sponsor, senderAddr, tc.to, tc.data, tc.gas, cost, tc.now); !errors.Is(err, tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestDailyBudget(t *testing.T) {
+	db := newTestState()
+	hash := registerPolicy(t, db, RegisterPolicyPayload{DailyBudget: "1000"})
+	day1 := 3 * DayMs
+
+	if err := Check(db, hash, sponsorAddr, senderAddr, &targetA, nil, 21_000, big.NewInt(600), day1); err != nil {
+		t.Fatal(err)
+	}
+	RecordSpend(db, hash, senderAddr, big.NewInt(600), day1)
+	if err := Check(db, hash, sponsorAddr, senderAddr, &targetA, nil, 21_000, big.NewInt(600), day1+1); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected ErrBudgetExceeded, got %v", err)
+	}
+	// Budgets are per sender and per day.
+	if err := Check(db, hash, sponsorAddr, targetB, &targetA, nil, 21_000, big.NewInt(600), day1); err != nil {
+		t.Fatalf("other sender should have its own budget: %v", err)
+	}
+	if err := Check(db, hash, sponsorAddr, senderAddr, &targetA, nil, 21_000, big.NewInt(600), day1+DayMs); err != nil {
+		t.Fatalf("budget should reset the next day: %v", err)
+	}
+	if got := ReadSpent(db, hash, senderAddr, day1/DayMs); got.Int64() != 600 {
+		t.Fatalf("spent = %s, want 600", got)
+	}
+}
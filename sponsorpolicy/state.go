@@ -0,0 +1,222 @@
+package sponsorpolicy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+	"sort"
+
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/crypto"
+	"github.com/tos-network/gtos/params"
+	"github.com/tos-network/gtos/rlp"
+)
+
+// StateDB is the minimal storage interface required by this package.
+type StateDB interface {
+	GetState(common.Address, common.Hash) common.Hash
+	SetState(common.Address, common.Hash, common.Hash)
+}
+
+// Policies live next to sponsor nonces in the sponsor registry.
+var registry = params.SponsorRegistryAddress
+
+// ── Slot helpers ──────────────────────────────────────────────────────────────
+
+// policySlot returns the storage slot for a scalar field of a policy.
+// key = keccak256("sp\x00" || policyHash[32] || field)
+func policySlot(policyHash common.Hash, field string) common.Hash {
+	return crypto.Keccak256Hash([]byte("sp\x00"), policyHash.Bytes(), []byte(field))
+}
+
+// listSlot returns the slot of the i-th entry of a policy's target or
+// selector list.
+func listSlot(policyHash common.Hash, list string, i uint64) common.Hash {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], i)
+	return crypto.Keccak256Hash([]byte("sp\x00list\x00"), policyHash.Bytes(), []byte(list), buf[:])
+}
+
+// memberSlot flags that entry is in a policy's target or selector list.
+func memberSlot(policyHash common.Hash, list string, entry []byte) common.Hash {
+	return crypto.Keccak256Hash([]byte("sp\x00member\x00"), policyHash.Bytes(), []byte(list), entry)
+}
+
+// spentSlot holds what sender has spent under a policy in budget period day.
+func spentSlot(policyHash common.Hash, sender common.Address, day uint64) common.Hash {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], day)
+	return crypto.Keccak256Hash([]byte("sp\x00spent\x00"), policyHash.Bytes(), sender.Bytes(), buf[:])
+}
+
+func readUint64(db StateDB, slot common.Hash) uint64 {
+	raw := db.GetState(registry, slot)
+	return binary.BigEndian.Uint64(raw[24:])
+}
+
+func writeUint64(db StateDB, slot common.Hash, v uint64) {
+	var val common.Hash
+	binary.BigEndian.PutUint64(val[24:], v)
+	db.SetState(registry, slot, val)
+}
+
+// ── Hash ──────────────────────────────────────────────────────────────────────
+
+// normalize sorts the policy's targets and selectors and reports whether
+// either contains duplicates.
+func (p *Policy) normalize() bool {
+	sort.Slice(p.Targets, func(i, j int) bool { return bytes.Compare(p.Targets[i][:], p.Targets[j][:]) < 0 })
+	sort.Slice(p.Selectors, func(i, j int) bool { return bytes.Compare(p.Selectors[i][:], p.Selectors[j][:]) < 0 })
+	for i := 1; i < len(p.Targets); i++ {
+		if p.Targets[i] == p.Targets[i-1] {
+			return false
+		}
+	}
+	for i := 1; i < len(p.Selectors); i++ {
+		if p.Selectors[i] == p.Selectors[i-1] {
+			return false
+		}
+	}
+	return true
+}
+
+// Hash returns the policy hash sponsored transactions carry in
+// SponsorPolicyHash: keccak256 of the RLP encoding of the policy with its
+// targets and selectors sorted.
+func (p *Policy) Hash() common.Hash {
+	cp := *p
+	cp.Targets = append([]common.Address(nil), p.Targets...)
+	cp.Selectors = append([][SelectorLength]byte(nil), p.Selectors...)
+	cp.normalize()
+	if cp.DailyBudget == nil {
+		cp.DailyBudget = new(big.Int)
+	}
+	enc, _ := rlp.EncodeToBytes([]interface{}{
+		cp.Sponsor, cp.Targets, cp.Selectors, cp.MaxGasPerTx, cp.DailyBudget, cp.Expiry,
+	})
+	return crypto.Keccak256Hash(enc)
+}
+
+// ── Accessors ─────────────────────────────────────────────────────────────────
+
+// ReadStatus returns the status of the policy with hash policyHash.
+func ReadStatus(db StateDB, policyHash common.Hash) Status {
+	raw := db.GetState(registry, policySlot(policyHash, "status"))
+	return Status(raw[31])
+}
+
+func writeStatus(db StateDB, policyHash common.Hash, s Status) {
+	var val common.Hash
+	val[31] = byte(s)
+	db.SetState(registry, policySlot(policyHash, "status"), val)
+}
+
+// ReadPolicy returns the stored policy, or nil if none was registered.
+func ReadPolicy(db StateDB, policyHash common.Hash) *Policy {
+	if ReadStatus(db, policyHash) == StatusNone {
+		return nil
+	}
+	p := &Policy{
+		Sponsor:     common.BytesToAddress(db.GetState(registry, policySlot(policyHash, "sponsor")).Bytes()),
+		MaxGasPerTx: readUint64(db, policySlot(policyHash, "maxGas")),
+		DailyBudget: db.GetState(registry, policySlot(policyHash, "budget")).Big(),
+		Expiry:      readUint64(db, policySlot(policyHash, "expiry")),
+	}
+	for i, n := uint64(0), readUint64(db, policySlot(policyHash, "targets")); i < n; i++ {
+		p.Targets = append(p.Targets, common.BytesToAddress(db.GetState(registry, listSlot(policyHash, "targets", i)).Bytes()))
+	}
+	for i, n := uint64(0), readUint64(db, policySlot(policyHash, "selectors")); i < n; i++ {
+		var sel [SelectorLength]byte
+		copy(sel[:], db.GetState(registry, listSlot(policyHash, "selectors", i)).Bytes())
+		p.Selectors = append(p.Selectors, sel)
+	}
+	return p
+}
+
+// writePolicy stores p under its hash with status active. p must be
+// normalized.
+func writePolicy(db StateDB, policyHash common.Hash, p *Policy) {
+	db.SetState(registry, policySlot(policyHash, "sponsor"), common.BytesToHash(p.Sponsor.Bytes()))
+	writeUint64(db, policySlot(policyHash, "maxGas"), p.MaxGasPerTx)
+	db.SetState(registry, policySlot(policyHash, "budget"), common.BigToHash(p.DailyBudget))
+	writeUint64(db, policySlot(policyHash, "expiry"), p.Expiry)
+
+	member := common.BytesToHash([]byte{1})
+	writeUint64(db, policySlot(policyHash, "targets"), uint64(len(p.Targets)))
+	for i, t := range p.Targets {
+		db.SetState(registry, listSlot(policyHash, "targets", uint64(i)), common.BytesToHash(t.Bytes()))
+		db.SetState(registry, memberSlot(policyHash, "targets", t.Bytes()), member)
+	}
+	writeUint64(db, policySlot(policyHash, "selectors"), uint64(len(p.Selectors)))
+	for i, sel := range p.Selectors {
+		var val common.Hash
+		copy(val[:], sel[:])
+		db.SetState(registry, listSlot(policyHash, "selectors", uint64(i)), val)
+		db.SetState(registry, memberSlot(policyHash, "selectors", sel[:]), member)
+	}
+	writeStatus(db, policyHash, StatusActive)
+}
+
+// ReadSpent returns what sender has spent under the policy in budget period
+// day (block time / DayMs).
+func ReadSpent(db StateDB, policyHash common.Hash, sender common.Address, day uint64) *big.Int {
+	return db.GetState(registry, spentSlot(policyHash, sender, day)).Big()
+}
+
+// ── Enforcement ───────────────────────────────────────────────────────────────
+
+// Check verifies that a transaction from sender, sponsored by sponsor under
+// policyHash, complies with the policy. to is nil for contract creation, cost
+// is the most the transaction can charge the sponsor (gas * price) and
+// nowMs is the block time in milliseconds. A zero policyHash is not checked.
+func Check(db StateDB, policyHash common.Hash, sponsor, sender common.Address, to *common.Address, data []byte, gas uint64, cost *big.Int, nowMs uint64) error {
+	if policyHash == (common.Hash{}) {
+		return nil
+	}
+	switch ReadStatus(db, policyHash) {
+	case StatusNone:
+		return ErrPolicyNotFound
+	case StatusRevoked:
+		return ErrPolicyRevoked
+	}
+	if common.BytesToAddress(db.GetState(registry, policySlot(policyHash, "sponsor")).Bytes()) != sponsor {
+		return ErrNotSponsor
+	}
+	if expiry := readUint64(db, policySlot(policyHash, "expiry")); expiry != 0 && nowMs > expiry {
+		return ErrPolicyExpired
+	}
+	if max := readUint64(db, policySlot(policyHash, "maxGas")); max != 0 && gas > max {
+		return ErrGasAbovePolicy
+	}
+	if readUint64(db, policySlot(policyHash, "targets")) != 0 {
+		if to == nil || db.GetState(registry, memberSlot(policyHash, "targets", to.Bytes())) == (common.Hash{}) {
+			return ErrTargetNotAllowed
+		}
+	}
+	if readUint64(db, policySlot(policyHash, "selectors")) != 0 {
+		if len(data) < SelectorLength || db.GetState(registry, memberSlot(policyHash, "selectors", data[:SelectorLength])) == (common.Hash{}) {
+			return ErrSelectorForbidden
+		}
+	}
+	if budget := db.GetState(registry, policySlot(policyHash, "budget")).Big(); budget.Sign() > 0 {
+		spent := ReadSpent(db, policyHash, sender, nowMs/DayMs)
+		if new(big.Int).Add(spent, cost).Cmp(budget) > 0 {
+			return ErrBudgetExceeded
+		}
+	}
+	return nil
+}
+
+// RecordSpend adds the gas cost a sponsored transaction actually charged to
+// sender's spending under the policy for the current budget period.
+func RecordSpend(db StateDB, policyHash common.Hash, sender common.Address, cost *big.Int, nowMs uint64) {
+	if policyHash == (common.Hash{}) || cost.Sign() <= 0 {
+		return
+	}
+	if db.GetState(registry, policySlot(policyHash, "budget")) == (common.Hash{}) {
+		return // no budget to account against
+	}
+	day := nowMs / DayMs
+	spent := ReadSpent(db, policyHash, sender, day)
+	db.SetState(registry, spentSlot(policyHash, sender, day), common.BigToHash(spent.Add(spent, cost)))
+}
@@ -0,0 +1,84 @@
+// Package sponsorpolicy implements on-chain sponsor policies.
+//
+// A sponsor registers a machine-readable policy describing what it is willing
+// to pay gas for: the allowed call targets and selectors, a gas cap per
+// transaction, a per-sender daily budget and an expiry. A sponsored
+// transaction that carries a non-zero SponsorPolicyHash must reference an
+// active policy of its sponsor and stay inside it; the tx pool pre-checks the
+// policy and the state transition enforces it. This lets sponsors publish
+// open sponsorship offers without co-signing each transaction by hand.
+package sponsorpolicy
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/common/hexutil"
+)
+
+// Status is the on-chain lifecycle state of a policy.
+type Status uint8
+
+const (
+	StatusNone    Status = 0
+	StatusActive  Status = 1
+	StatusRevoked Status = 2
+)
+
+// Policy limits.
+const (
+	MaxTargets   = 32
+	MaxSelectors = 32
+
+	// SelectorLength is the length of a call selector, the first bytes of
+	// the transaction data.
+	SelectorLength = 4
+
+	// DayMs is the length of a daily budget period in block-time
+	// milliseconds. Periods are aligned to the unix epoch.
+	DayMs uint64 = 86_400_000
+)
+
+// Policy is a sponsor's offer. Empty Targets or Selectors allow any; a zero
+// MaxGasPerTx, DailyBudget or Expiry means no limit.
+type Policy struct {
+	Sponsor     common.Address
+	Targets     []common.Address
+	Selectors   [][SelectorLength]byte
+	MaxGasPerTx uint64
+	DailyBudget *big.Int // per sender, in tomi of gas
+	Expiry      uint64   // unix milliseconds
+}
+
+// RegisterPolicyPayload is the JSON payload for SPONSOR_POLICY_REGISTER.
+type RegisterPolicyPayload struct {
+	Targets     []common.Address `json:"targets,omitempty"`
+	Selectors   []hexutil.Bytes  `json:"selectors,omitempty"`
+	MaxGasPerTx uint64           `json:"max_gas_per_tx,omitempty"`
+	DailyBudget string           `json:"daily_budget,omitempty"`
+	Expiry      uint64           `json:"expiry,omitempty"`
+}
+
+// RevokePolicyPayload is the JSON payload for SPONSOR_POLICY_REVOKE.
+type RevokePolicyPayload struct {
+	PolicyHash common.Hash `json:"policy_hash"`
+}
+
+// Sentinel errors returned by handlers and policy checks.
+var (
+	ErrTooManyTargets    = errors.New("sponsorpolicy: too many targets")
+	ErrTooManySelectors  = errors.New("sponsorpolicy: too many selectors")
+	ErrDuplicateEntry    = errors.New("sponsorpolicy: duplicate target or selector")
+	ErrInvalidSelector   = errors.New("sponsorpolicy: selector must be 4 bytes")
+	ErrInvalidBudget     = errors.New("sponsorpolicy: invalid daily_budget")
+	ErrPolicyExists      = errors.New("sponsorpolicy: policy already active")
+	ErrPolicyNotFound    = errors.New("sponsorpolicy: policy not found")
+	ErrNotSponsor        = errors.New("sponsorpolicy: caller is not the policy sponsor")
+	ErrPolicyRevoked     = errors.New("sponsorpolicy: policy revoked")
+	ErrPolicyExpired     = errors.New("sponsorpolicy: policy expired")
+	ErrTargetNotAllowed  = errors.New("sponsorpolicy: target not allowed by policy")
+	ErrSelectorForbidden = errors.New("sponsorpolicy: selector not allowed by policy")
+	ErrGasAbovePolicy    = errors.New("sponsorpolicy: gas exceeds policy max_gas_per_tx")
+	ErrBudgetExceeded    = errors.New("sponsorpolicy: sender daily budget exceeded")
+)
@@ -14,10 +14,31 @@ type Context struct {
 	From        common.Address
 	Value       *big.Int
 	BlockNumber *big.Int
+	Time        *big.Int // block timestamp in milliseconds; nil when unset
 	StateDB     vmtypes.StateDB
 	ChainConfig *params.ChainConfig
+	Coinbase    common.Address // producer of the block being processed
+
+	// GetHash returns the hash of a recent block, as for the BLOCKHASH
+	// opcode. It is nil when no chain is wired in (e.g. in unit tests).
+	GetHash vmtypes.GetHashFunc
+
+	// Call invokes an LVM contract on behalf of a handler. It is nil when no
+	// contract runtime is wired in (e.g. in unit tests).
+	Call ContractCaller
+
+	// Gas is what the transaction has left for Call beyond SysActionGas.
+	// Execute charges the gas calls use to the transaction.
+	Gas uint64
 }
 
+// ContractCaller runs the LVM contract at target with input as calldata and
+// caller as msg.sender, under gasLimit, and returns the gas it used.
+// Handlers that must keep their own writes when the call fails snapshot
+// around it. The indirection keeps handlers free of a core/vm import, like
+// task.ExecutorFn.
+type ContractCaller func(caller, target common.Address, input []byte, gasLimit uint64) (uint64, error)
+
 // Handler is implemented by sub-systems that process system actions.
 type Handler interface {
 	// Actions returns the action kinds this handler processes.
@@ -53,7 +74,10 @@ type Msg interface {
 
 // Execute processes a system action from msg and dispatches to a registered handler.
 // Returns (gasUsed, error) — called from core/state_transition.go.
-func Execute(msg Msg, db vmtypes.StateDB, blockNumber *big.Int, chainConfig *params.ChainConfig) (uint64, error) {
+// gas is what the transaction has left; gasUsed is SysActionGas plus the gas
+// the handler's contract calls used, which never exceeds gas.
+// call may be nil, in which case handlers that need to invoke a contract fail.
+func Execute(msg Msg, db vmtypes.StateDB, blockCtx vmtypes.BlockContext, chainConfig *params.ChainConfig, gas uint64, call ContractCaller) (uint64, error) {
 	sa, err := Decode(msg.Data())
 	if err != nil {
 		return params.SysActionGas, err
@@ -62,11 +86,33 @@ func Execute(msg Msg, db vmtypes.StateDB, blockNumber *big.Int, chainConfig *par
 		From:        msg.From(),
 		Value:       msg.Value(),
 		StateDB:     db,
-		BlockNumber: blockNumber,
+		BlockNumber: blockCtx.BlockNumber,
+		Time:        blockCtx.Time,
 		ChainConfig: chainConfig,
+		Coinbase:    blockCtx.Coinbase,
+		GetHash:     blockCtx.GetHash,
+	}
+	if gas > params.SysActionGas {
+		ctx.Gas = gas - params.SysActionGas
+	}
+	var callGas uint64
+	if call != nil {
+		ctx.Call = func(caller, target common.Address, input []byte, gasLimit uint64) (uint64, error) {
+			if gasLimit > ctx.Gas {
+				gasLimit = ctx.Gas
+			}
+			used, err := call(caller, target, input, gasLimit)
+			if used > gasLimit {
+				used = gasLimit
+			}
+			ctx.Gas -= used
+			callGas += used
+			return used, err
+		}
 	}
 	if h, ok := DefaultRegistry.handlers[sa.Action]; ok {
-		return params.SysActionGas, h.Handle(ctx, sa)
+		err := h.Handle(ctx, sa)
+		return params.SysActionGas + callGas, err
 	}
 	return params.SysActionGas, fmt.Errorf("unknown system action: %q", sa.Action)
 }
@@ -123,24 +123,28 @@ func oracleTimestampSlot(oracle common.Address, dataKey string) common.Hash {
 	return common.BytesToHash(crypto.Keccak256(key))
 }
 
+// ReadOracleFeed returns the current value of an oracle feed and the time,
+// in seconds, of its last update.
+func ReadOracleFeed(state vmtypes.StateDB, oracle common.Address, dataKey string) (common.Hash, uint64, error) {
+	// Check oracle is registered (has a non-zero registration slot).
+	regVal := state.GetState(oracle, oracleSlot(oracle, "registered"))
+	if regVal[31] == 0 {
+		return common.Hash{}, 0, ErrOracleNotRegistered
+	}
+	data := state.GetState(oracle, oracleDataSlot(oracle, dataKey))
+	tsRaw := state.GetState(oracle, oracleTimestampSlot(oracle, dataKey))
+	return data, tsRaw.Big().Uint64(), nil
+}
+
 // ValidateOracleHook checks that oracle data is fresh and matches expectations.
 // It reads oracle registration, data hash, and timestamp from state, then
 // verifies age and hash constraints.
 func ValidateOracleHook(state vmtypes.StateDB, hook *OracleHook, blockTimestamp uint64) (bool, error) {
-	// Check oracle is registered (has a non-zero registration slot).
-	regSlot := oracleSlot(hook.OracleAddress, "registered")
-	regVal := state.GetState(hook.OracleAddress, regSlot)
-	if regVal[31] == 0 {
-		return false, ErrOracleNotRegistered
+	dataHash, lastUpdate, err := ReadOracleFeed(state, hook.OracleAddress, hook.DataKey)
+	if err != nil {
+		return false, err
 	}
 
-	// Read the stored data hash for the requested key.
-	dataHash := state.GetState(hook.OracleAddress, oracleDataSlot(hook.OracleAddress, hook.DataKey))
-
-	// Read the timestamp of the last update.
-	tsRaw := state.GetState(hook.OracleAddress, oracleTimestampSlot(hook.OracleAddress, hook.DataKey))
-	lastUpdate := tsRaw.Big().Uint64()
-
 	// Check data freshness.
 	if hook.MaxAge > 0 && blockTimestamp > lastUpdate+hook.MaxAge {
 		return false, ErrOracleDataStale
@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/core/rawdb"
+	"github.com/tos-network/gtos/core/state"
 	"github.com/tos-network/gtos/crypto"
 )
 
@@ -95,3 +97,23 @@ func TestValidateProofHookDispatchesRegisteredVerifierByAddress(t *testing.T) {
 		t.Fatal("expected address-based verifier to accept proof")
 	}
 }
+
+func TestReadOracleFeed(t *testing.T) {
+	db, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	oracle := common.HexToAddress("0x0ac1e")
+
+	if _, _, err := ReadOracleFeed(db, oracle, "TOS/USD"); !errors.Is(err, ErrOracleNotRegistered) {
+		t.Fatalf("expected ErrOracleNotRegistered, got %v", err)
+	}
+	db.SetState(oracle, oracleSlot(oracle, "registered"), common.BytesToHash([]byte{1}))
+	db.SetState(oracle, oracleDataSlot(oracle, "TOS/USD"), common.HexToHash("0x2a"))
+	db.SetState(oracle, oracleTimestampSlot(oracle, "TOS/USD"), common.HexToHash("0x64"))
+
+	value, updatedAt, err := ReadOracleFeed(db, oracle, "TOS/USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != common.HexToHash("0x2a") || updatedAt != 100 {
+		t.Fatalf("unexpected feed: value=%s updatedAt=%d", value.Hex(), updatedAt)
+	}
+}
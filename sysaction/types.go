@@ -40,16 +40,28 @@ const (
 	// Reputation scoring.
 	ActionReputationAuthorizeScorer ActionKind = "REPUTATION_AUTHORIZE_SCORER"
 	ActionReputationRecordScore     ActionKind = "REPUTATION_RECORD_SCORE"
+	ActionReputationFeedback        ActionKind = "REPUTATION_FEEDBACK"
 
 	// KYC lifecycle.
-	ActionKYCSet     ActionKind = "KYC_SET"
-	ActionKYCSuspend ActionKind = "KYC_SUSPEND"
+	ActionKYCSet              ActionKind = "KYC_SET"
+	ActionKYCSuspend          ActionKind = "KYC_SUSPEND"
+	ActionKYCIssuerRegister   ActionKind = "KYC_ISSUER_REGISTER"
+	ActionKYCCredentialRevoke ActionKind = "KYC_CREDENTIAL_REVOKE"
+	ActionKYCProveTier        ActionKind = "KYC_PROVE_TIER"
 
 	// TNS (TOS Name Service).
-	ActionTNSRegister ActionKind = "TNS_REGISTER"
-
-	// Referral relationship.
-	ActionReferralBind ActionKind = "REFERRAL_BIND"
+	ActionTNSRegister      ActionKind = "TNS_REGISTER"
+	ActionTNSRenew         ActionKind = "TNS_RENEW"
+	ActionTNSTransfer      ActionKind = "TNS_TRANSFER"
+	ActionTNSSubnameSet    ActionKind = "TNS_SUBNAME_SET"
+	ActionTNSSubnameRevoke ActionKind = "TNS_SUBNAME_REVOKE"
+	ActionTNSSetRecord     ActionKind = "TNS_SET_RECORD"
+
+	// Referral relationship and rewards.
+	ActionReferralBind       ActionKind = "REFERRAL_BIND"
+	ActionReferralProgramSet ActionKind = "REFERRAL_PROGRAM_SET"
+	ActionReferralPayout     ActionKind = "REFERRAL_PAYOUT"
+	ActionReferralClaim      ActionKind = "REFERRAL_CLAIM"
 
 	// Scheduled tasks.
 	ActionTaskSchedule ActionKind = "TASK_SCHEDULE"
@@ -60,33 +72,69 @@ const (
 	ActionGroupStateCommit ActionKind = "GROUP_STATE_COMMIT"
 
 	// Lease-contract lifecycle.
-	ActionLeaseDeploy ActionKind = "LEASE_DEPLOY"
-	ActionLeaseRenew  ActionKind = "LEASE_RENEW"
-	ActionLeaseClose  ActionKind = "LEASE_CLOSE"
+	ActionLeaseDeploy          ActionKind = "LEASE_DEPLOY"
+	ActionLeaseRenew           ActionKind = "LEASE_RENEW"
+	ActionLeaseClose           ActionKind = "LEASE_CLOSE"
+	ActionLeaseFundRenewal     ActionKind = "LEASE_FUND_RENEWAL"
+	ActionLeaseWithdrawRenewal ActionKind = "LEASE_WITHDRAW_RENEWAL"
+	ActionLeaseTransferOwner   ActionKind = "LEASE_TRANSFER_OWNER"
+	ActionLeaseAcceptOwner     ActionKind = "LEASE_ACCEPT_OWNER"
+	ActionLeaseMakePermanent   ActionKind = "LEASE_MAKE_PERMANENT"
 
 	// Gateway relay lifecycle.
 	ActionGatewayRegister   ActionKind = "GATEWAY_REGISTER"
 	ActionGatewayUpdate     ActionKind = "GATEWAY_UPDATE"
 	ActionGatewayDeregister ActionKind = "GATEWAY_DEREGISTER"
+	ActionGatewayHeartbeat  ActionKind = "GATEWAY_HEARTBEAT"
+
+	// Sponsor policies for sponsored transactions.
+	ActionSponsorPolicyRegister ActionKind = "SPONSOR_POLICY_REGISTER"
+	ActionSponsorPolicyRevoke   ActionKind = "SPONSOR_POLICY_REVOKE"
 
 	// Settlement callbacks and async fulfillment.
 	ActionSettlementRegisterCallback ActionKind = "SETTLEMENT_REGISTER_CALLBACK"
 	ActionSettlementExecuteCallback  ActionKind = "SETTLEMENT_EXECUTE_CALLBACK"
 	ActionSettlementFulfillAsync     ActionKind = "SETTLEMENT_FULFILL_ASYNC"
 
+	// Agent-to-agent job marketplace.
+	ActionJobPost    ActionKind = "JOB_POST"
+	ActionJobAccept  ActionKind = "JOB_ACCEPT"
+	ActionJobSubmit  ActionKind = "JOB_SUBMIT"
+	ActionJobApprove ActionKind = "JOB_APPROVE"
+	ActionJobReject  ActionKind = "JOB_REJECT"
+	ActionJobCancel  ActionKind = "JOB_CANCEL"
+	ActionJobTimeout ActionKind = "JOB_TIMEOUT"
+
+	// Dispute resolution.
+	ActionDisputeOpen    ActionKind = "DISPUTE_OPEN"
+	ActionDisputeDraw    ActionKind = "DISPUTE_DRAW"
+	ActionDisputeCommit  ActionKind = "DISPUTE_COMMIT"
+	ActionDisputeReveal  ActionKind = "DISPUTE_REVEAL"
+	ActionDisputeResolve ActionKind = "DISPUTE_RESOLVE"
+
+	// Intent pool and solver auction.
+	ActionIntentSubmit  ActionKind = "INTENT_SUBMIT"
+	ActionIntentCancel  ActionKind = "INTENT_CANCEL"
+	ActionIntentBid     ActionKind = "INTENT_BID"
+	ActionIntentReveal  ActionKind = "INTENT_REVEAL"
+	ActionIntentExecute ActionKind = "INTENT_EXECUTE"
+	ActionIntentExpire  ActionKind = "INTENT_EXPIRE"
+
 	// Policy wallet primitives.
-	ActionPolicySetSpendCaps      ActionKind = "POLICY_SET_SPEND_CAPS"
-	ActionPolicySetAllowlist      ActionKind = "POLICY_SET_ALLOWLIST"
-	ActionPolicySetTerminalPolicy ActionKind = "POLICY_SET_TERMINAL_POLICY"
-	ActionPolicyAuthorizeDelegate ActionKind = "POLICY_AUTHORIZE_DELEGATE"
-	ActionPolicyRevokeDelegate    ActionKind = "POLICY_REVOKE_DELEGATE"
-	ActionPolicySetGuardian       ActionKind = "POLICY_SET_GUARDIAN"
-	ActionPolicyInitiateRecovery  ActionKind = "POLICY_INITIATE_RECOVERY"
-	ActionPolicyCancelRecovery    ActionKind = "POLICY_CANCEL_RECOVERY"
-	ActionPolicyCompleteRecovery  ActionKind = "POLICY_COMPLETE_RECOVERY"
-	ActionPolicySuspend           ActionKind = "POLICY_SUSPEND"
-	ActionPolicyUnsuspend         ActionKind = "POLICY_UNSUSPEND"
-	ActionPolicySetAuditorKey     ActionKind = "POLICY_SET_AUDITOR_KEY"
+	ActionPolicySetSpendCaps            ActionKind = "POLICY_SET_SPEND_CAPS"
+	ActionPolicySetAllowlist            ActionKind = "POLICY_SET_ALLOWLIST"
+	ActionPolicySetTerminalPolicy       ActionKind = "POLICY_SET_TERMINAL_POLICY"
+	ActionPolicyAuthorizeDelegate       ActionKind = "POLICY_AUTHORIZE_DELEGATE"
+	ActionPolicyRevokeDelegate          ActionKind = "POLICY_REVOKE_DELEGATE"
+	ActionPolicySetGuardian             ActionKind = "POLICY_SET_GUARDIAN"
+	ActionPolicyInitiateRecovery        ActionKind = "POLICY_INITIATE_RECOVERY"
+	ActionPolicyCancelRecovery          ActionKind = "POLICY_CANCEL_RECOVERY"
+	ActionPolicyCompleteRecovery        ActionKind = "POLICY_COMPLETE_RECOVERY"
+	ActionPolicySuspend                 ActionKind = "POLICY_SUSPEND"
+	ActionPolicyUnsuspend               ActionKind = "POLICY_UNSUSPEND"
+	ActionPolicySetAuditorKey           ActionKind = "POLICY_SET_AUDITOR_KEY"
+	ActionPolicyScheduleAuditorRotation ActionKind = "POLICY_SCHEDULE_AUDITOR_ROTATION"
+	ActionPolicyCancelAuditorRotation   ActionKind = "POLICY_CANCEL_AUDITOR_ROTATION"
 
 	// Protocol registry lifecycle.
 	ActionRegistryRegisterCap         ActionKind = "REGISTRY_REGISTER_CAP"
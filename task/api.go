@@ -0,0 +1,55 @@
+package task
+
+import (
+	"github.com/tos-network/gtos/common/hexutil"
+	"github.com/tos-network/gtos/params"
+)
+
+// MaxQueueDepthBlocks caps the number of blocks one GetQueueDepth call covers.
+const MaxQueueDepthBlocks = 256
+
+// QueueDepthResult is the JSON-friendly result for GetQueueDepth.
+type QueueDepthResult struct {
+	Block   uint64 `json:"block"`
+	Depth   uint64 `json:"depth"`   // task IDs queued, including cancelled ones
+	Pending uint64 `json:"pending"` // queued tasks still pending
+	// MinPriority is the priority a task needs to run in this block rather
+	// than be deferred; "0" while the pending tasks fit in TaskMaxPerBlock.
+	MinPriority string `json:"min_priority"`
+}
+
+// PublicTaskAPI provides RPC methods for querying scheduled task state.
+type PublicTaskAPI struct {
+	stateReader func() StateDB
+}
+
+// NewPublicTaskAPI creates a new task API instance.
+func NewPublicTaskAPI(stateReader func() StateDB) *PublicTaskAPI {
+	return &PublicTaskAPI{stateReader: stateReader}
+}
+
+// GetQueueDepth returns the task queue of count blocks starting at
+// fromBlock, as it stands at the current head.
+func (api *PublicTaskAPI) GetQueueDepth(fromBlock, count hexutil.Uint64) ([]QueueDepthResult, error) {
+	if count == 0 || count > MaxQueueDepthBlocks {
+		return nil, ErrTaskQueueRange
+	}
+	db := api.stateReader()
+	res := make([]QueueDepthResult, 0, count)
+	for b := uint64(fromBlock); b < uint64(fromBlock)+uint64(count); b++ {
+		ids := ReadQueue(db, b)
+		pending := sortByPriority(db, ids)
+		entry := QueueDepthResult{
+			Block:       b,
+			Depth:       uint64(len(ids)),
+			Pending:     uint64(len(pending)),
+			MinPriority: "0",
+		}
+		if uint64(len(pending)) > params.TaskMaxPerBlock {
+			last, _ := ReadTask(db, pending[params.TaskMaxPerBlock-1])
+			entry.MinPriority = last.Priority().String()
+		}
+		res = append(res, entry)
+	}
+	return res, nil
+}
@@ -0,0 +1,74 @@
+package task
+
+import (
+	"math/big"
+
+	"github.com/tos-network/gtos/common"
+	vmtypes "github.com/tos-network/gtos/core/vmtypes"
+	"github.com/tos-network/gtos/settlement"
+	"github.com/tos-network/gtos/sysaction"
+)
+
+var (
+	conditionKinds = map[string]ConditionKind{
+		"storage": CondStorage,
+		"oracle":  CondOracle,
+		"receipt": CondReceipt,
+	}
+	compareOps = map[string]CompareOp{
+		"eq":  OpEq,
+		"ne":  OpNe,
+		"lt":  OpLt,
+		"lte": OpLe,
+		"gt":  OpGt,
+		"gte": OpGe,
+	}
+)
+
+// Holds reports whether the condition is met against db at block time nowMs
+// (milliseconds). A condition whose source is missing — an unregistered or
+// stale oracle feed, an unknown receipt — does not hold.
+func (c *Condition) Holds(db vmtypes.StateDB, nowMs uint64) bool {
+	var current common.Hash
+	switch c.Kind {
+	case CondStorage:
+		current = db.GetState(c.Account, c.Key)
+	case CondOracle:
+		value, updatedAt, err := sysaction.ReadOracleFeed(db, c.Account, c.FeedKey)
+		if err != nil {
+			return false
+		}
+		// Oracle feeds record their update time in seconds.
+		if c.MaxAge > 0 && nowMs/1000 > updatedAt+c.MaxAge {
+			return false
+		}
+		current = value
+	case CondReceipt:
+		if !settlement.ReadRuntimeReceiptExists(db, c.Key) {
+			return false
+		}
+		current[31] = settlement.ReadRuntimeReceiptStatus(db, c.Key)
+	default:
+		return false
+	}
+	return compare(current.Big(), c.Value.Big(), c.Op)
+}
+
+func compare(a, b *big.Int, op CompareOp) bool {
+	cmp := a.Cmp(b)
+	switch op {
+	case OpEq:
+		return cmp == 0
+	case OpNe:
+		return cmp != 0
+	case OpLt:
+		return cmp < 0
+	case OpLe:
+		return cmp <= 0
+	case OpGt:
+		return cmp > 0
+	case OpGe:
+		return cmp >= 0
+	}
+	return false
+}
@@ -3,12 +3,13 @@ package task
 import (
 	"encoding/binary"
 	"encoding/json"
-	"math/big"
 
 	"github.com/tos-network/gtos/common"
 	"github.com/tos-network/gtos/core/types"
+	vmtypes "github.com/tos-network/gtos/core/vmtypes"
 	"github.com/tos-network/gtos/crypto"
 	"github.com/tos-network/gtos/params"
+	"github.com/tos-network/gtos/settlement"
 	"github.com/tos-network/gtos/sysaction"
 )
 
@@ -37,13 +38,67 @@ func (h *taskHandler) Handle(ctx *sysaction.Context, sa *sysaction.SysAction) er
 }
 
 type schedulePayload struct {
-	Target         string `json:"target"`
-	Selector       string `json:"selector"`        // hex 4 bytes (optional 0x prefix)
-	TaskData       string `json:"task_data"`       // hex 32 bytes
-	GasLimit       uint64 `json:"gas_limit"`
-	DelayBlocks    uint64 `json:"delay_blocks"`
-	IntervalBlocks uint64 `json:"interval_blocks"`
-	MaxRuns        uint64 `json:"max_runs"`
+	Target         string            `json:"target"`
+	Selector       string            `json:"selector"`  // hex 4 bytes (optional 0x prefix)
+	TaskData       string            `json:"task_data"` // hex 32 bytes
+	Calldata       string            `json:"calldata"`  // hex, any length; replaces selector ++ task_data
+	GasLimit       uint64            `json:"gas_limit"`
+	Bid            uint64            `json:"bid"` // priority fee in tomi per gas
+	DelayBlocks    uint64            `json:"delay_blocks"`
+	IntervalBlocks uint64            `json:"interval_blocks"`
+	MaxRuns        uint64            `json:"max_runs"`
+	Condition      *conditionPayload `json:"condition"`     // optional trigger
+	ExpiryBlocks   uint64            `json:"expiry_blocks"` // conditional tasks only
+}
+
+// conditionPayload describes the trigger of a conditional task. Storage and
+// oracle conditions compare the watched word with value as uint256; receipt
+// conditions compare the receipt status with status.
+type conditionPayload struct {
+	Kind    string `json:"kind"`     // "storage", "oracle" or "receipt"
+	Op      string `json:"op"`       // "eq", "ne", "lt", "lte", "gt" or "gte"
+	Account string `json:"account"`  // contract (storage) or oracle (oracle)
+	Slot    string `json:"slot"`     // storage slot (storage) or receipt ref (receipt)
+	FeedKey string `json:"feed_key"` // oracle data key
+	Value   string `json:"value"`    // hex uint256 operand
+	Status  string `json:"status"`   // "open", "success" or "failure"
+	MaxAge  uint64 `json:"max_age"`  // oracle: max feed age in seconds
+}
+
+func parseCondition(p *conditionPayload) (*Condition, error) {
+	c := &Condition{
+		Kind:    conditionKinds[p.Kind],
+		Op:      compareOps[p.Op],
+		Account: common.HexToAddress(p.Account),
+		Key:     common.HexToHash(p.Slot),
+		FeedKey: p.FeedKey,
+		Value:   common.HexToHash(p.Value),
+		MaxAge:  p.MaxAge,
+	}
+	if c.Kind == CondNone || c.Op == 0 {
+		return nil, ErrTaskInvalidCondition
+	}
+	switch c.Kind {
+	case CondStorage:
+		if c.Account == (common.Address{}) {
+			return nil, ErrTaskInvalidCondition
+		}
+	case CondOracle:
+		if c.Account == (common.Address{}) || p.FeedKey == "" || len(p.FeedKey) > MaxFeedKeyLength {
+			return nil, ErrTaskInvalidCondition
+		}
+	case CondReceipt:
+		c.Account, c.Value = common.Address{}, common.Hash{}
+		for s := settlement.ReceiptStatusOpen; s <= settlement.ReceiptStatusFailure; s++ {
+			if settlement.ReceiptStatusName(s) == p.Status {
+				c.Value[31] = s
+			}
+		}
+		if c.Key == (common.Hash{}) || c.Value == (common.Hash{}) {
+			return nil, ErrTaskInvalidCondition
+		}
+	}
+	return c, nil
 }
 
 func (h *taskHandler) handleSchedule(ctx *sysaction.Context, sa *sysaction.SysAction) error {
@@ -76,25 +131,61 @@ func (h *taskHandler) handleSchedule(ctx *sysaction.Context, sa *sysaction.SysAc
 		return ErrTaskIntervalTooFar
 	}
 
-	// 4. Per-contract active limit.
+	// 4. Validate calldata and the condition. A conditional task is checked
+	// from delay_blocks on and expires after expiry_blocks.
+	var calldata []byte
+	if p.Calldata != "" {
+		if p.Selector != "" || p.TaskData != "" {
+			return ErrTaskCalldataConflict
+		}
+		calldata = common.FromHex(p.Calldata)
+		if uint64(len(calldata)) > params.TaskMaxCalldataBytes {
+			return ErrTaskCalldataTooLong
+		}
+	}
+	var cond *Condition
+	if p.Condition != nil {
+		var err error
+		if cond, err = parseCondition(p.Condition); err != nil {
+			return err
+		}
+		if p.ExpiryBlocks < p.DelayBlocks || p.ExpiryBlocks > params.TaskMaxHorizonBlocks {
+			return ErrTaskInvalidExpiry
+		}
+	} else if p.ExpiryBlocks != 0 {
+		return ErrTaskInvalidExpiry
+	}
+
+	// 5. Per-contract active limit, and room on the global watch list.
 	if ReadActiveCount(ctx.StateDB, ctx.From) >= params.TaskMaxPerContract {
 		return ErrTaskActiveLimit
 	}
+	if cond != nil && ReadWatchCount(ctx.StateDB) >= params.TaskMaxWatched {
+		if !evictLowestWatched(ctx.StateDB, p.Bid) {
+			return ErrTaskWatchListFull
+		}
+	}
 
-	// 5. Compute deposit and check balance.
-	deposit := new(big.Int).Mul(
-		new(big.Int).SetUint64(p.GasLimit),
-		big.NewInt(params.TxPriceTomi),
-	)
+	// 6. Compute deposit, plus the watch fee of a conditional task, and
+	// check balance.
+	priced := &TaskRecord{GasLimit: p.GasLimit, Bid: p.Bid}
+	deposit := priced.Deposit()
+	if cond != nil {
+		deposit.Add(deposit, priced.WatchFee())
+	}
 	if ctx.StateDB.GetBalance(ctx.From).Cmp(deposit) < 0 {
 		return ErrTaskInsufficientDeposit
 	}
 
-	// 6. Deduct deposit.
+	// 7. Deduct deposit. The watch fee is never refunded; the bid on it goes
+	// to the block producer.
 	ctx.StateDB.SubBalance(ctx.From, deposit)
 	ctx.StateDB.AddBalance(params.TaskSchedulerAddress, deposit)
+	if cond != nil {
+		payBid(ctx.StateDB, ctx.Coinbase, priced, params.TaskWatchFeeGas)
+	}
 
-	// 7. Mint task ID.
+	// 8. Mint task ID.
 	blockNum := ctx.BlockNumber.Uint64()
 	targetBlock := blockNum + p.DelayBlocks
 	nonce := IncrementContractNonce(ctx.StateDB, ctx.From)
@@ -110,26 +201,35 @@ func (h *taskHandler) handleSchedule(ctx *sysaction.Context, sa *sysaction.SysAc
 	var taskData common.Hash
 	copy(taskData[:], taskDataBytes)
 
-	// 8. Write task and enqueue.
+	// 9. Write task and enqueue or watch.
 	rec := &TaskRecord{
 		Scheduler:      ctx.From,
 		Target:         common.HexToAddress(p.Target),
 		Selector:       selector,
 		TaskData:       taskData,
+		Calldata:       calldata,
 		GasLimit:       p.GasLimit,
+		Bid:            p.Bid,
 		TargetBlock:    targetBlock,
 		IntervalBlocks: p.IntervalBlocks,
 		MaxRuns:        p.MaxRuns,
 		Runs:           0,
 		Status:         TaskPending,
+		Condition:      cond,
+	}
+	if cond != nil {
+		rec.ExpiryBlock = blockNum + p.ExpiryBlocks
+		WriteTask(ctx.StateDB, taskId, rec)
+		WatchTask(ctx.StateDB, taskId)
+	} else {
+		WriteTask(ctx.StateDB, taskId, rec)
+		EnqueueTask(ctx.StateDB, targetBlock, taskId)
 	}
-	WriteTask(ctx.StateDB, taskId, rec)
-	EnqueueTask(ctx.StateDB, targetBlock, taskId)
 
-	// 9. Track active count.
+	// 10. Track active count.
 	AdjustActiveCount(ctx.StateDB, ctx.From, +1)
 
-	// 10. Emit log: TaskScheduled(taskId, scheduler, target, targetBlock).
+	// 11. Emit log: TaskScheduled(taskId, scheduler, target, targetBlock).
 	// Topics[0] = sig, Topics[1] = taskId (indexed bytes32).
 	// Data = scheduler[32] ++ target[32] ++ nextBlock (uint64 right-aligned in 32 bytes).
 	var logData [96]byte
@@ -145,6 +245,35 @@ func (h *taskHandler) handleSchedule(ctx *sysaction.Context, sa *sysaction.SysAc
 	return nil
 }
 
+// evictLowestWatched makes room on a full watch list for a task bidding bid.
+// The entry with the lowest bid is removed if that bid is below bid; a still
+// pending task is expired and its deposit refunded. Reports whether an entry
+// was removed.
+func evictLowestWatched(db vmtypes.StateDB, bid uint64) bool {
+	n := ReadWatchCount(db)
+	if n == 0 {
+		return false
+	}
+	lowest, lowestBid := uint64(0), readTaskBid(db, readWatchEntry(db, 0))
+	for i := uint64(1); i < n; i++ {
+		if b := readTaskBid(db, readWatchEntry(db, i)); b < lowestBid {
+			lowest, lowestBid = i, b
+		}
+	}
+	if lowestBid >= bid {
+		return false
+	}
+	taskId := readWatchEntry(db, lowest)
+	if rec, ok := ReadTask(db, taskId); ok && rec.Status == TaskPending {
+		refundDeposit(db, rec)
+		rec.Status = TaskExpired
+		WriteTask(db, taskId, rec)
+		AdjustActiveCount(db, rec.Scheduler, -1)
+	}
+	unwatchAt(db, lowest)
+	return true
+}
+
 type cancelPayload struct {
 	TaskID string `json:"task_id"` // hex hash
 }
@@ -168,10 +297,7 @@ func (h *taskHandler) handleCancel(ctx *sysaction.Context, sa *sysaction.SysActi
 	}
 
 	// 3. Refund full deposit.
-	deposit := new(big.Int).Mul(
-		new(big.Int).SetUint64(rec.GasLimit),
-		big.NewInt(params.TxPriceTomi),
-	)
+	deposit := rec.Deposit()
 	ctx.StateDB.SubBalance(params.TaskSchedulerAddress, deposit)
 	ctx.StateDB.AddBalance(rec.Scheduler, deposit)
 
@@ -2,6 +2,7 @@ package task
 
 import (
 	"math/big"
+	"sort"
 
 	"github.com/tos-network/gtos/common"
 	vmtypes "github.com/tos-network/gtos/core/vmtypes"
@@ -21,7 +22,11 @@ type ExecutorFn func(
 	gasLimit uint64,
 ) ExecResult
 
-// ProcessDueTasks executes all tasks scheduled at blockNum against db.
+// ProcessDueTasks executes all tasks scheduled at blockNum against db, then
+// checks up to TaskMaxConditionChecks conditional tasks and executes those
+// whose condition holds. Due tasks run in order of Priority, ties in queue
+// order; those beyond TaskMaxPerBlock are deferred to the next block with
+// their age boost raised.
 // It is called by both Process() (block validation) and the miner (block building)
 // before user transactions, ensuring the state root is identical in both paths.
 // Returns the number of tasks processed, total callback gas consumed, and any
@@ -33,10 +38,7 @@ func ProcessDueTasks(
 	blockNum uint64,
 	exec ExecutorFn,
 ) (int, uint64, error) {
-	taskIds := DequeueTasksAt(db, blockNum)
-	if len(taskIds) == 0 {
-		return 0, 0, nil
-	}
+	taskIds := sortByPriority(db, DequeueTasksAt(db, blockNum))
 
 	processed := 0
 	totalGasUsed := uint64(0)
@@ -53,110 +55,282 @@ func ProcessDueTasks(
 		if !ok || rec.Status != TaskPending {
 			continue
 		}
+		gasUsed, err := runTask(db, blockCtx, chainCfg, blockNum, exec, taskId, rec)
+		totalGasUsed += gasUsed
+		if err != nil {
+			return processed, totalGasUsed, err
+		}
+		processed++
+	}
 
-		// Build 36-byte calldata: selector[4] ++ taskData[32].
-		calldata := make([]byte, 36)
-		copy(calldata[:4], rec.Selector[:])
-		copy(calldata[4:], rec.TaskData[:])
+	// Re-enqueue any tasks that exceeded TaskMaxPerBlock.
+	// Update TargetBlock so tos.taskinfo("nextblock") reflects the actual
+	// next execution block rather than the original (now-missed) target.
+	for _, tid := range deferred {
+		if rec, ok := ReadTask(db, tid); ok && rec.Status == TaskPending {
+			rec.TargetBlock = blockNum + 1
+			rec.Deferrals++
+			WriteTask(db, tid, rec)
+			EnqueueTask(db, blockNum+1, tid)
+		}
+	}
 
-		// Take a snapshot so that only callback writes are reverted on failure.
-		// Task framework state (WriteTask, AdjustActiveCount, refund) is applied
-		// outside the snapshot and is always committed.
-		snap := db.Snapshot()
-		preLogCount := len(db.Logs())
-		res := exec(db, blockCtx, chainCfg,
-			params.TaskSchedulerAddress, rec.Target, calldata, rec.GasLimit)
-		gasUsed := res.GasUsed
-		if gasUsed > rec.GasLimit {
-			gasUsed = rec.GasLimit
+	n, gasUsed, err := processWatchList(db, blockCtx, chainCfg, blockNum, exec, processed)
+	return processed + n, totalGasUsed + gasUsed, err
+}
+
+// sortByPriority drops tasks that are no longer pending and stably sorts the
+// rest by descending Priority, so equal priorities keep their queue order.
+func sortByPriority(db StateDB, taskIds []common.Hash) []common.Hash {
+	type due struct {
+		id       common.Hash
+		priority *big.Int
+	}
+	pending := make([]due, 0, len(taskIds))
+	for _, id := range taskIds {
+		if rec, ok := ReadTask(db, id); ok && rec.Status == TaskPending {
+			pending = append(pending, due{id, rec.Priority()})
 		}
-		totalGasUsed += gasUsed
-		if len(db.Logs()) != preLogCount {
-			db.RevertToSnapshot(snap)
-			// Treat log emission as a per-task failure: expire this task
-			// and continue processing remaining tasks instead of aborting
-			// the entire block.
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].priority.Cmp(pending[j].priority) > 0
+	})
+	sorted := make([]common.Hash, len(pending))
+	for i, d := range pending {
+		sorted[i] = d.id
+	}
+	return sorted
+}
+
+// processWatchList checks at most TaskMaxConditionChecks conditional tasks,
+// highest bid first; equal bids take turns round-robin from the stored
+// cursor. Tasks that are no longer pending are dropped from the list, expired
+// tasks are refunded, and tasks whose condition holds run while the block has
+// room under TaskMaxPerBlock (already counts the tasks run from the block
+// queue). Every check spends TaskConditionCheckGas of the task's deposit, so
+// a task that keeps a high bid on the list pays for the checks it takes; one
+// whose deposit can no longer cover a check and a run expires.
+func processWatchList(
+	db vmtypes.StateDB,
+	blockCtx vmtypes.BlockContext,
+	chainCfg *params.ChainConfig,
+	blockNum uint64,
+	exec ExecutorFn,
+	already int,
+) (int, uint64, error) {
+	n := ReadWatchCount(db)
+	if n == 0 {
+		return 0, 0, nil
+	}
+	var nowMs uint64
+	if blockCtx.Time != nil {
+		nowMs = blockCtx.Time.Uint64()
+	}
+
+	cursor := readU64(db, watchCursorSlot) % n
+	order := watchOrder(db, n, cursor)
+	if uint64(len(order)) > params.TaskMaxConditionChecks {
+		order = order[:params.TaskMaxConditionChecks]
+	}
+
+	processed := 0
+	totalGasUsed := uint64(0)
+	var drop []uint64 // entries to remove once the scan is done
+	for _, i := range order {
+		taskId := readWatchEntry(db, i)
+		rec, ok := ReadTask(db, taskId)
+		switch {
+		case !ok || rec.Status != TaskPending || rec.Condition == nil:
+			// Cancelled or finished.
+			drop = append(drop, i)
+			continue
+		case blockNum > rec.ExpiryBlock:
+			refundDeposit(db, rec)
 			rec.Status = TaskExpired
 			WriteTask(db, taskId, rec)
 			AdjustActiveCount(db, rec.Scheduler, -1)
-			processed++
+			drop = append(drop, i)
+			continue
+		case blockNum < rec.TargetBlock,
+			uint64(already+processed) >= params.TaskMaxPerBlock:
+			continue
+		case rec.RunGas() <= params.TaskConditionCheckGas:
+			refundDeposit(db, rec)
+			rec.Status = TaskExpired
+			WriteTask(db, taskId, rec)
+			AdjustActiveCount(db, rec.Scheduler, -1)
+			drop = append(drop, i)
 			continue
 		}
-		if res.Err != nil {
-			db.RevertToSnapshot(snap)
-			if res.Fatal {
-				return processed, totalGasUsed, res.Err
-			}
+		payBid(db, blockCtx.Coinbase, rec, params.TaskConditionCheckGas)
+		rec.CheckGas += params.TaskConditionCheckGas
+		if !rec.Condition.Holds(db, nowMs) {
+			WriteTask(db, taskId, rec)
+			continue
+		}
+		gasUsed, err := runTask(db, blockCtx, chainCfg, blockNum, exec, taskId, rec)
+		totalGasUsed += gasUsed
+		if err != nil {
+			return processed, totalGasUsed, err
 		}
+		processed++
+		if rec.Status != TaskPending {
+			drop = append(drop, i)
+		}
+	}
+	// unwatchAt moves the last entry into the freed index, so removing in
+	// descending index order keeps the remaining indices valid.
+	sort.Slice(drop, func(a, b int) bool { return drop[a] > drop[b] })
+	for _, i := range drop {
+		unwatchAt(db, i)
+	}
+	writeU64(db, watchCursorSlot, cursor+uint64(len(order)))
+	return processed, totalGasUsed, nil
+}
+
+// watchOrder returns the watch list indices sorted by descending bid, with
+// equal bids in list order starting at cursor.
+func watchOrder(db StateDB, n, cursor uint64) []uint64 {
+	type watched struct {
+		index uint64
+		bid   uint64
+	}
+	entries := make([]watched, n)
+	for k := uint64(0); k < n; k++ {
+		i := (cursor + k) % n
+		entries[k] = watched{i, readTaskBid(db, readWatchEntry(db, i))}
+	}
+	sort.SliceStable(entries, func(a, b int) bool {
+		return entries[a].bid > entries[b].bid
+	})
+	order := make([]uint64, n)
+	for k, e := range entries {
+		order[k] = e.index
+	}
+	return order
+}
+
+// runTask executes a due task with the gas left in its deposit, refunds what
+// it does not use and updates the record for its next run. It returns the callback gas consumed and a fatal error
+// that invalidates the block.
+func runTask(
+	db vmtypes.StateDB,
+	blockCtx vmtypes.BlockContext,
+	chainCfg *params.ChainConfig,
+	blockNum uint64,
+	exec ExecutorFn,
+	taskId common.Hash,
+	rec *TaskRecord,
+) (uint64, error) {
+	calldata := rec.Calldata
+	if len(calldata) == 0 {
+		// Build 36-byte calldata: selector[4] ++ taskData[32].
+		calldata = make([]byte, 36)
+		copy(calldata[:4], rec.Selector[:])
+		copy(calldata[4:], rec.TaskData[:])
+	}
 
-		// Refund remaining deposit to the scheduler.
-		refundGas := rec.GasLimit - gasUsed
-		if refundGas > 0 {
-			refund := new(big.Int).Mul(
-				new(big.Int).SetUint64(refundGas),
-				big.NewInt(params.TxPriceTomi),
-			)
-			db.SubBalance(params.TaskSchedulerAddress, refund)
-			db.AddBalance(rec.Scheduler, refund)
+	// Take a snapshot so that only callback writes are reverted on failure.
+	// Task framework state (WriteTask, AdjustActiveCount, refund) is applied
+	// outside the snapshot and is always committed.
+	snap := db.Snapshot()
+	preLogCount := len(db.Logs())
+	res := exec(db, blockCtx, chainCfg,
+		params.TaskSchedulerAddress, rec.Target, calldata, rec.RunGas())
+	gasUsed := res.GasUsed
+	if gasUsed > rec.RunGas() {
+		gasUsed = rec.RunGas()
+	}
+	rec.Deferrals = 0
+	if len(db.Logs()) != preLogCount {
+		db.RevertToSnapshot(snap)
+		// Treat log emission as a per-task failure: expire this task
+		// and continue processing remaining tasks instead of aborting
+		// the entire block.
+		rec.Status = TaskExpired
+		WriteTask(db, taskId, rec)
+		AdjustActiveCount(db, rec.Scheduler, -1)
+		return gasUsed, nil
+	}
+	if res.Err != nil {
+		db.RevertToSnapshot(snap)
+		if res.Fatal {
+			return gasUsed, res.Err
 		}
+	}
 
-		// Update run counter and decide next state.
-		rec.Runs++
-		exhausted := rec.MaxRuns > 0 && rec.Runs >= rec.MaxRuns
-		isOneShot := rec.IntervalBlocks == 0
-
-		if isOneShot || exhausted {
-			if exhausted && !isOneShot {
-				rec.Status = TaskExpired
-			} else {
-				rec.Status = TaskDone
-			}
-			WriteTask(db, taskId, rec)
-			AdjustActiveCount(db, rec.Scheduler, -1)
+	// Pay the bid on the gas used to the block producer and refund the
+	// remaining deposit to the scheduler.
+	payBid(db, blockCtx.Coinbase, rec, gasUsed)
+	refundGas := rec.RunGas() - gasUsed
+	if refundGas > 0 {
+		refund := new(big.Int).Mul(new(big.Int).SetUint64(refundGas), rec.GasPrice())
+		db.SubBalance(params.TaskSchedulerAddress, refund)
+		db.AddBalance(rec.Scheduler, refund)
+	}
+
+	// Update run counter and decide next state.
+	rec.Runs++
+	exhausted := rec.MaxRuns > 0 && rec.Runs >= rec.MaxRuns
+	isOneShot := rec.IntervalBlocks == 0
+
+	if isOneShot || exhausted {
+		if exhausted && !isOneShot {
+			rec.Status = TaskExpired
 		} else {
-			// Repeat: re-schedule at the next interval and re-deposit gas.
-			nextBlock := blockNum + rec.IntervalBlocks
-			if nextBlock < blockNum {
-				// uint64 overflow — expire rather than wrap around.
-				rec.Status = TaskExpired
-				WriteTask(db, taskId, rec)
-				AdjustActiveCount(db, rec.Scheduler, -1)
-				processed++
-				continue
-			}
-			rec.TargetBlock = nextBlock
-
-			// Re-deposit gas for the next run (charged from scheduler balance).
-			reDeposit := new(big.Int).Mul(
-				new(big.Int).SetUint64(rec.GasLimit),
-				big.NewInt(params.TxPriceTomi),
-			)
-			if db.GetBalance(rec.Scheduler).Cmp(reDeposit) >= 0 {
-				db.SubBalance(rec.Scheduler, reDeposit)
-				db.AddBalance(params.TaskSchedulerAddress, reDeposit)
-				WriteTask(db, taskId, rec)
-				EnqueueTask(db, nextBlock, taskId)
-			} else {
-				// Scheduler can no longer afford another run — mark expired.
-				rec.Status = TaskExpired
-				WriteTask(db, taskId, rec)
-				AdjustActiveCount(db, rec.Scheduler, -1)
-			}
+			rec.Status = TaskDone
 		}
+		WriteTask(db, taskId, rec)
+		AdjustActiveCount(db, rec.Scheduler, -1)
+		return gasUsed, nil
+	}
 
-		processed++
+	// Repeat: re-schedule at the next interval and re-deposit gas. A
+	// conditional task stays on the watch list and is checked again from
+	// the next interval on.
+	nextBlock := blockNum + rec.IntervalBlocks
+	if nextBlock < blockNum {
+		// uint64 overflow — expire rather than wrap around.
+		rec.Status = TaskExpired
+		WriteTask(db, taskId, rec)
+		AdjustActiveCount(db, rec.Scheduler, -1)
+		return gasUsed, nil
 	}
+	rec.TargetBlock = nextBlock
+	rec.CheckGas = 0
 
-	// Re-enqueue any tasks that exceeded TaskMaxPerBlock.
-	// Update TargetBlock so tos.taskinfo("nextblock") reflects the actual
-	// next execution block rather than the original (now-missed) target.
-	for _, tid := range deferred {
-		if rec, ok := ReadTask(db, tid); ok && rec.Status == TaskPending {
-			rec.TargetBlock = blockNum + 1
-			WriteTask(db, tid, rec)
-			EnqueueTask(db, blockNum+1, tid)
+	// Re-deposit gas for the next run (charged from scheduler balance).
+	reDeposit := rec.Deposit()
+	if db.GetBalance(rec.Scheduler).Cmp(reDeposit) >= 0 {
+		db.SubBalance(rec.Scheduler, reDeposit)
+		db.AddBalance(params.TaskSchedulerAddress, reDeposit)
+		WriteTask(db, taskId, rec)
+		if rec.Condition == nil {
+			EnqueueTask(db, nextBlock, taskId)
 		}
+	} else {
+		// Scheduler can no longer afford another run — mark expired.
+		rec.Status = TaskExpired
+		WriteTask(db, taskId, rec)
+		AdjustActiveCount(db, rec.Scheduler, -1)
 	}
+	return gasUsed, nil
+}
 
-	return processed, totalGasUsed, nil
+// payBid pays the bid on gas consumed by rec to the block producer, out of
+// the funds held by the task scheduler.
+func payBid(db vmtypes.StateDB, coinbase common.Address, rec *TaskRecord, gas uint64) {
+	if rec.Bid == 0 || gas == 0 {
+		return
+	}
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(gas), new(big.Int).SetUint64(rec.Bid))
+	db.SubBalance(params.TaskSchedulerAddress, fee)
+	db.AddBalance(coinbase, fee)
+}
+
+// refundDeposit returns the gas deposit still held for rec's next run.
+func refundDeposit(db vmtypes.StateDB, rec *TaskRecord) {
+	deposit := rec.Deposit()
+	db.SubBalance(params.TaskSchedulerAddress, deposit)
+	db.AddBalance(rec.Scheduler, deposit)
 }
@@ -8,9 +8,10 @@ import (
 	"github.com/tos-network/gtos/params"
 )
 
-// stateDB is the minimal storage interface required by this package.
+// StateDB is the minimal storage interface required by this package.
 // Avoids an import cycle with core/vm (which imports this package).
-type stateDB interface {
+// Exported so that the RPC registration layer can reference it.
+type StateDB interface {
 	GetState(common.Address, common.Hash) common.Hash
 	SetState(common.Address, common.Hash, common.Hash)
 }
@@ -54,6 +55,31 @@ func blockQEntrySlot(blockNum, i uint64) common.Hash {
 		append([]byte("task\x00q\x00"), buf[:]...)))
 }
 
+// taskChunkSlot returns the slot of the i-th 32-byte chunk of a
+// variable-length task field; the field's own slot holds its length.
+func taskChunkSlot(taskId common.Hash, field string, i uint64) common.Hash {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], i)
+	key := append([]byte("task\x00chunk\x00"), taskId.Bytes()...)
+	key = append(key, []byte(field)...)
+	key = append(key, buf[:]...)
+	return common.BytesToHash(crypto.Keccak256(key))
+}
+
+// watchLenSlot, watchEntrySlot and watchCursorSlot hold the list of
+// conditional tasks and the position the next block's scan starts from.
+var (
+	watchLenSlot    = common.BytesToHash(crypto.Keccak256([]byte("task\x00wlen")))
+	watchCursorSlot = common.BytesToHash(crypto.Keccak256([]byte("task\x00wcursor")))
+)
+
+func watchEntrySlot(i uint64) common.Hash {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], i)
+	return common.BytesToHash(crypto.Keccak256(
+		append([]byte("task\x00w\x00"), buf[:]...)))
+}
+
 // ── Task ID ───────────────────────────────────────────────────────────────────
 
 // NewTaskID derives a deterministic task ID from the scheduler address,
@@ -83,7 +109,7 @@ func hashToAddr(h common.Hash) common.Address {
 
 // ReadTask reads a TaskRecord from state. Returns (record, true) if found,
 // (nil, false) if the task ID has never been written.
-func ReadTask(db stateDB, taskId common.Hash) (*TaskRecord, bool) {
+func ReadTask(db StateDB, taskId common.Hash) (*TaskRecord, bool) {
 	schedulerRaw := db.GetState(params.TaskSchedulerAddress, taskFieldSlot(taskId, "scheduler"))
 	// If scheduler slot is zero the task was never written (scheduler is always a real account).
 	if schedulerRaw == (common.Hash{}) {
@@ -100,16 +126,45 @@ func ReadTask(db stateDB, taskId common.Hash) (*TaskRecord, bool) {
 	t.TaskData = db.GetState(params.TaskSchedulerAddress, taskFieldSlot(taskId, "taskdata"))
 
 	t.GasLimit = db.GetState(params.TaskSchedulerAddress, taskFieldSlot(taskId, "gaslimit")).Big().Uint64()
+	t.CheckGas = db.GetState(params.TaskSchedulerAddress, taskFieldSlot(taskId, "checkgas")).Big().Uint64()
+	t.Bid = db.GetState(params.TaskSchedulerAddress, taskFieldSlot(taskId, "bid")).Big().Uint64()
+	t.Deferrals = db.GetState(params.TaskSchedulerAddress, taskFieldSlot(taskId, "deferrals")).Big().Uint64()
 	t.TargetBlock = db.GetState(params.TaskSchedulerAddress, taskFieldSlot(taskId, "nextblock")).Big().Uint64()
 	t.IntervalBlocks = db.GetState(params.TaskSchedulerAddress, taskFieldSlot(taskId, "interval")).Big().Uint64()
 	t.MaxRuns = db.GetState(params.TaskSchedulerAddress, taskFieldSlot(taskId, "maxruns")).Big().Uint64()
 	t.Runs = db.GetState(params.TaskSchedulerAddress, taskFieldSlot(taskId, "runs")).Big().Uint64()
 	t.Status = TaskStatus(db.GetState(params.TaskSchedulerAddress, taskFieldSlot(taskId, "status")).Big().Uint64())
+	t.Calldata = readTaskBytes(db, taskId, "calldata")
+	t.Condition = ReadCondition(db, taskId)
+	if t.Condition != nil {
+		t.ExpiryBlock = db.GetState(params.TaskSchedulerAddress, taskFieldSlot(taskId, "expiry")).Big().Uint64()
+	}
 	return t, true
 }
 
+// ReadCondition returns the trigger of a conditional task, or nil for a
+// time-triggered task.
+func ReadCondition(db StateDB, taskId common.Hash) *Condition {
+	load := func(field string) common.Hash {
+		return db.GetState(params.TaskSchedulerAddress, taskFieldSlot(taskId, field))
+	}
+	kindOp := load("cond")
+	if ConditionKind(kindOp[31]) == CondNone {
+		return nil
+	}
+	return &Condition{
+		Kind:    ConditionKind(kindOp[31]),
+		Op:      CompareOp(kindOp[30]),
+		Account: hashToAddr(load("condaccount")),
+		Key:     load("condkey"),
+		FeedKey: string(readTaskBytes(db, taskId, "condfeed")),
+		Value:   load("condvalue"),
+		MaxAge:  load("condmaxage").Big().Uint64(),
+	}
+}
+
 // WriteTask persists a TaskRecord to state, one field per slot.
-func WriteTask(db stateDB, taskId common.Hash, t *TaskRecord) {
+func WriteTask(db StateDB, taskId common.Hash, t *TaskRecord) {
 	store := func(field string, val common.Hash) {
 		db.SetState(params.TaskSchedulerAddress, taskFieldSlot(taskId, field), val)
 	}
@@ -128,6 +183,15 @@ func WriteTask(db stateDB, taskId common.Hash, t *TaskRecord) {
 	binary.BigEndian.PutUint64(u64[24:], t.GasLimit)
 	store("gaslimit", u64)
 
+	binary.BigEndian.PutUint64(u64[24:], t.CheckGas)
+	store("checkgas", u64)
+
+	binary.BigEndian.PutUint64(u64[24:], t.Bid)
+	store("bid", u64)
+
+	binary.BigEndian.PutUint64(u64[24:], t.Deferrals)
+	store("deferrals", u64)
+
 	binary.BigEndian.PutUint64(u64[24:], t.TargetBlock)
 	store("nextblock", u64)
 
@@ -142,12 +206,55 @@ func WriteTask(db stateDB, taskId common.Hash, t *TaskRecord) {
 
 	binary.BigEndian.PutUint64(u64[24:], uint64(t.Status))
 	store("status", u64)
+
+	writeTaskBytes(db, taskId, "calldata", t.Calldata)
+	if c := t.Condition; c != nil {
+		var kindOp common.Hash
+		kindOp[30] = byte(c.Op)
+		kindOp[31] = byte(c.Kind)
+		store("cond", kindOp)
+		store("condaccount", addrToHash(c.Account))
+		store("condkey", c.Key)
+		writeTaskBytes(db, taskId, "condfeed", []byte(c.FeedKey))
+		store("condvalue", c.Value)
+		binary.BigEndian.PutUint64(u64[24:], c.MaxAge)
+		store("condmaxage", u64)
+		binary.BigEndian.PutUint64(u64[24:], t.ExpiryBlock)
+		store("expiry", u64)
+	}
+}
+
+// readTaskBytes reads a variable-length task field, or nil if it is empty.
+func readTaskBytes(db StateDB, taskId common.Hash, field string) []byte {
+	n := db.GetState(params.TaskSchedulerAddress, taskFieldSlot(taskId, field)).Big().Uint64()
+	if n == 0 {
+		return nil
+	}
+	out := make([]byte, 0, n+common.HashLength)
+	for i := uint64(0); uint64(len(out)) < n; i++ {
+		chunk := db.GetState(params.TaskSchedulerAddress, taskChunkSlot(taskId, field, i))
+		out = append(out, chunk[:]...)
+	}
+	return out[:n]
+}
+
+// writeTaskBytes stores data as a length slot followed by 32-byte chunks.
+// Fields are written once at schedule time, so stale chunks never remain.
+func writeTaskBytes(db StateDB, taskId common.Hash, field string, data []byte) {
+	var lenHash common.Hash
+	binary.BigEndian.PutUint64(lenHash[24:], uint64(len(data)))
+	db.SetState(params.TaskSchedulerAddress, taskFieldSlot(taskId, field), lenHash)
+	for i := 0; i*common.HashLength < len(data); i++ {
+		var chunk common.Hash
+		copy(chunk[:], data[i*common.HashLength:])
+		db.SetState(params.TaskSchedulerAddress, taskChunkSlot(taskId, field, uint64(i)), chunk)
+	}
 }
 
 // ── Queue ──────────────────────────────────────────────────────────────────────
 
 // EnqueueTask appends taskId to the block queue at blockNum.
-func EnqueueTask(db stateDB, blockNum uint64, taskId common.Hash) {
+func EnqueueTask(db StateDB, blockNum uint64, taskId common.Hash) {
 	qlenSlot := blockQlenSlot(blockNum)
 	n := db.GetState(params.TaskSchedulerAddress, qlenSlot).Big().Uint64()
 	db.SetState(params.TaskSchedulerAddress, blockQEntrySlot(blockNum, n), taskId)
@@ -156,27 +263,86 @@ func EnqueueTask(db stateDB, blockNum uint64, taskId common.Hash) {
 	db.SetState(params.TaskSchedulerAddress, qlenSlot, lenHash)
 }
 
-// DequeueTasksAt reads all task IDs scheduled at blockNum and resets the queue length to 0.
-func DequeueTasksAt(db stateDB, blockNum uint64) []common.Hash {
-	qlenSlot := blockQlenSlot(blockNum)
-	n := db.GetState(params.TaskSchedulerAddress, qlenSlot).Big().Uint64()
-	if n == 0 {
-		return nil
-	}
+// ReadQueueLength returns the number of task IDs queued at blockNum.
+func ReadQueueLength(db StateDB, blockNum uint64) uint64 {
+	return readU64(db, blockQlenSlot(blockNum))
+}
+
+// ReadQueue returns the task IDs queued at blockNum without dequeuing them.
+func ReadQueue(db StateDB, blockNum uint64) []common.Hash {
+	n := ReadQueueLength(db, blockNum)
 	ids := make([]common.Hash, n)
 	for i := uint64(0); i < n; i++ {
 		ids[i] = db.GetState(params.TaskSchedulerAddress, blockQEntrySlot(blockNum, i))
 	}
+	return ids
+}
+
+// DequeueTasksAt reads all task IDs scheduled at blockNum and resets the queue length to 0.
+func DequeueTasksAt(db StateDB, blockNum uint64) []common.Hash {
+	ids := ReadQueue(db, blockNum)
+	if len(ids) == 0 {
+		return nil
+	}
 	// Zero out queue length so the slot is clean.
-	db.SetState(params.TaskSchedulerAddress, qlenSlot, common.Hash{})
+	db.SetState(params.TaskSchedulerAddress, blockQlenSlot(blockNum), common.Hash{})
 	return ids
 }
 
+// ── Watch list ─────────────────────────────────────────────────────────────────
+
+// WatchTask adds a conditional task to the list the processor scans.
+func WatchTask(db StateDB, taskId common.Hash) {
+	n := readU64(db, watchLenSlot)
+	db.SetState(params.TaskSchedulerAddress, watchEntrySlot(n), taskId)
+	writeU64(db, watchLenSlot, n+1)
+}
+
+// ReadWatchCount returns the number of tasks on the watch list.
+func ReadWatchCount(db StateDB) uint64 {
+	return readU64(db, watchLenSlot)
+}
+
+// readWatchEntry returns the task ID at watch list entry i.
+func readWatchEntry(db StateDB, i uint64) common.Hash {
+	return db.GetState(params.TaskSchedulerAddress, watchEntrySlot(i))
+}
+
+// readTaskBid reads only the bid field of a task.
+func readTaskBid(db StateDB, taskId common.Hash) uint64 {
+	return readU64(db, taskFieldSlot(taskId, "bid"))
+}
+
+// unwatchAt removes the i-th watch list entry by moving the last entry into
+// its place.
+func unwatchAt(db StateDB, i uint64) {
+	n := readU64(db, watchLenSlot)
+	if i >= n {
+		return
+	}
+	if last := n - 1; i != last {
+		db.SetState(params.TaskSchedulerAddress, watchEntrySlot(i),
+			db.GetState(params.TaskSchedulerAddress, watchEntrySlot(last)))
+	}
+	db.SetState(params.TaskSchedulerAddress, watchEntrySlot(n-1), common.Hash{})
+	writeU64(db, watchLenSlot, n-1)
+}
+
+func readU64(db StateDB, slot common.Hash) uint64 {
+	return db.GetState(params.TaskSchedulerAddress, slot).Big().Uint64()
+}
+
+func writeU64(db StateDB, slot common.Hash, v uint64) {
+	var h common.Hash
+	binary.BigEndian.PutUint64(h[24:], v)
+	db.SetState(params.TaskSchedulerAddress, slot, h)
+}
+
 // ── Per-contract counters ─────────────────────────────────────────────────────
 
 // IncrementContractNonce atomically bumps the per-contract nonce and returns
 // the value BEFORE the increment (used as the nonce component of NewTaskID).
-func IncrementContractNonce(db stateDB, addr common.Address) uint64 {
+func IncrementContractNonce(db StateDB, addr common.Address) uint64 {
 	slot := contractNonceSlot(addr)
 	n := db.GetState(params.TaskSchedulerAddress, slot).Big().Uint64()
 	var h common.Hash
@@ -186,12 +352,12 @@ func IncrementContractNonce(db stateDB, addr common.Address) uint64 {
 }
 
 // ReadActiveCount returns the number of active (Pending) tasks for addr.
-func ReadActiveCount(db stateDB, addr common.Address) uint64 {
+func ReadActiveCount(db StateDB, addr common.Address) uint64 {
 	return db.GetState(params.TaskSchedulerAddress, contractActiveSlot(addr)).Big().Uint64()
 }
 
 // AdjustActiveCount increments (delta=+1) or decrements (delta=-1) the active count.
-func AdjustActiveCount(db stateDB, addr common.Address, delta int) {
+func AdjustActiveCount(db StateDB, addr common.Address, delta int) {
 	slot := contractActiveSlot(addr)
 	n := db.GetState(params.TaskSchedulerAddress, slot).Big().Uint64()
 	if delta > 0 {
@@ -1,16 +1,20 @@
 package task
 
 import (
+	"bytes"
 	"errors"
 	"math/big"
 	"testing"
 
 	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/common/hexutil"
 	"github.com/tos-network/gtos/core/rawdb"
 	"github.com/tos-network/gtos/core/state"
 	"github.com/tos-network/gtos/core/types"
 	vmtypes "github.com/tos-network/gtos/core/vmtypes"
 	"github.com/tos-network/gtos/params"
+	"github.com/tos-network/gtos/settlement"
+	"github.com/tos-network/gtos/sysaction"
 )
 
 // newStateDB returns a fresh in-memory StateDB for testing.
@@ -584,3 +588,571 @@ func TestProcessDueTasksCallbackLogIsFatal(t *testing.T) {
 		t.Fatal("task logs must be reverted on log emission")
 	}
 }
+
+// ── Conditional tasks ─────────────────────────────────────────────────────────
+
+// scheduleAction runs TASK_SCHEDULE through the sysaction handler at blockNum
+// and returns the new task's ID from its TaskScheduled log.
+func scheduleAction(t *testing.T, db *state.StateDB, blockNum uint64, from common.Address, p schedulePayload) (common.Hash, error) {
+	t.Helper()
+	data, err := sysaction.MakeSysAction(sysaction.ActionTaskSchedule, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sysaction.ExecuteWithContext(&sysaction.Context{
+		From:        from,
+		Value:       new(big.Int),
+		BlockNumber: new(big.Int).SetUint64(blockNum),
+		StateDB:     db,
+	}, data); err != nil {
+		return common.Hash{}, err
+	}
+	logs := db.Logs()
+	return logs[len(logs)-1].Topics[1], nil
+}
+
+// recordingExec returns an executor that records the calldata of every call.
+func recordingExec(calls *[][]byte) ExecutorFn {
+	return func(_ vmtypes.StateDB, _ vmtypes.BlockContext, _ *params.ChainConfig,
+		_, _ common.Address, calldata []byte, gasLimit uint64,
+	) ExecResult {
+		*calls = append(*calls, calldata)
+		return ExecResult{GasUsed: gasLimit / 2}
+	}
+}
+
+func TestWriteReadConditionalTaskRoundTrip(t *testing.T) {
+	db := newStateDB(t)
+	taskId := NewTaskID(common.HexToAddress("0x01"), 42, 0)
+	rec := &TaskRecord{
+		Scheduler: common.HexToAddress("0x01"),
+		Target:    common.HexToAddress("0x02"),
+		Calldata:  bytes.Repeat([]byte{0xab}, 70),
+		GasLimit:  50_000,
+		Status:    TaskPending,
+		Condition: &Condition{
+			Kind:    CondOracle,
+			Op:      OpLt,
+			Account: common.HexToAddress("0x03"),
+			FeedKey: "TOS/USD",
+			Value:   common.HexToHash("0x64"),
+			MaxAge:  30,
+		},
+		ExpiryBlock: 500,
+	}
+	WriteTask(db, taskId, rec)
+	got, ok := ReadTask(db, taskId)
+	if !ok {
+		t.Fatal("task not found")
+	}
+	if !bytes.Equal(got.Calldata, rec.Calldata) {
+		t.Errorf("calldata mismatch: %x", got.Calldata)
+	}
+	if got.Condition == nil || *got.Condition != *rec.Condition {
+		t.Errorf("condition mismatch: %+v", got.Condition)
+	}
+	if got.ExpiryBlock != 500 {
+		t.Errorf("ExpiryBlock: got %d", got.ExpiryBlock)
+	}
+}
+
+func TestConditionalStorageTaskFires(t *testing.T) {
+	db := newStateDB(t)
+	sched := common.HexToAddress("0xCAFE")
+	vault := common.HexToAddress("0x7A01")
+	fund(db, sched, 100)
+
+	calldata := append([]byte{0x12, 0x34, 0x56, 0x78}, bytes.Repeat([]byte{0x01}, 64)...)
+	taskId, err := scheduleAction(t, db, 10, sched, schedulePayload{
+		Target:       "0xBEEF",
+		Calldata:     hexutil.Encode(calldata),
+		GasLimit:     params.TaskMinGasLimit,
+		DelayBlocks:  2,
+		ExpiryBlocks: 100,
+		Condition:    &conditionPayload{Kind: "storage", Op: "gte", Account: vault.Hex(), Slot: "0x01", Value: "0x64"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ReadWatchCount(db) != 1 {
+		t.Fatalf("expected 1 watched task, got %d", ReadWatchCount(db))
+	}
+
+	var calls [][]byte
+	exec := recordingExec(&calls)
+	// The condition already holds, but checks start at TargetBlock.
+	db.SetState(vault, common.HexToHash("0x01"), common.HexToHash("0x64"))
+	if n, _, _ := ProcessDueTasks(db, noopBlockCtx(11), params.MainnetChainConfig, 11, exec); n != 0 {
+		t.Fatalf("task fired before its target block")
+	}
+	db.SetState(vault, common.HexToHash("0x01"), common.HexToHash("0x63"))
+	if n, _, _ := ProcessDueTasks(db, noopBlockCtx(12), params.MainnetChainConfig, 12, exec); n != 0 {
+		t.Fatalf("task fired while its condition did not hold")
+	}
+	db.SetState(vault, common.HexToHash("0x01"), common.HexToHash("0x65"))
+	if n, _, err := ProcessDueTasks(db, noopBlockCtx(13), params.MainnetChainConfig, 13, exec); err != nil || n != 1 {
+		t.Fatalf("expected the task to fire, got n=%d err=%v", n, err)
+	}
+	if len(calls) != 1 || !bytes.Equal(calls[0], calldata) {
+		t.Fatalf("unexpected calls: %x", calls)
+	}
+	rec, _ := ReadTask(db, taskId)
+	if rec.Status != TaskDone || rec.Runs != 1 {
+		t.Errorf("expected Done after one run, got status=%d runs=%d", rec.Status, rec.Runs)
+	}
+	if ReadWatchCount(db) != 0 || ReadActiveCount(db, sched) != 0 {
+		t.Errorf("finished task should leave the watch list and active count")
+	}
+}
+
+func TestConditionalReceiptTaskRepeats(t *testing.T) {
+	db := newStateDB(t)
+	sched := common.HexToAddress("0xCAFE")
+	fund(db, sched, 100)
+	receipt := common.HexToHash("0x5e771e")
+
+	taskId, err := scheduleAction(t, db, 1, sched, schedulePayload{
+		Target:         "0xBEEF",
+		Selector:       "0xdeadbeef",
+		GasLimit:       params.TaskMinGasLimit,
+		DelayBlocks:    1,
+		IntervalBlocks: params.TaskMinIntervalBlocks,
+		MaxRuns:        2,
+		ExpiryBlocks:   1_000,
+		Condition:      &conditionPayload{Kind: "receipt", Op: "eq", Slot: receipt.Hex(), Status: "failure"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls [][]byte
+	exec := recordingExec(&calls)
+
+	// An unknown receipt never satisfies the condition.
+	ProcessDueTasks(db, noopBlockCtx(2), params.MainnetChainConfig, 2, exec)
+	settlement.WriteRuntimeReceiptExists(db, receipt)
+	settlement.WriteRuntimeReceiptStatus(db, receipt, settlement.ReceiptStatusOpen)
+	ProcessDueTasks(db, noopBlockCtx(3), params.MainnetChainConfig, 3, exec)
+	if len(calls) != 0 {
+		t.Fatalf("task fired before the receipt failed")
+	}
+
+	settlement.WriteRuntimeReceiptStatus(db, receipt, settlement.ReceiptStatusFailure)
+	ProcessDueTasks(db, noopBlockCtx(4), params.MainnetChainConfig, 4, exec)
+	if len(calls) != 1 || len(calls[0]) != 36 {
+		t.Fatalf("expected one 36-byte call, got %x", calls)
+	}
+	rec, _ := ReadTask(db, taskId)
+	if rec.Status != TaskPending || rec.TargetBlock != 4+params.TaskMinIntervalBlocks {
+		t.Fatalf("expected pending with next check at %d, got status=%d next=%d",
+			4+params.TaskMinIntervalBlocks, rec.Status, rec.TargetBlock)
+	}
+	// The condition still holds, but the task waits out its interval.
+	for b := uint64(5); b <= 4+params.TaskMinIntervalBlocks; b++ {
+		ProcessDueTasks(db, noopBlockCtx(b), params.MainnetChainConfig, b, exec)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("expected exactly one more run after the interval, got %d runs", len(calls))
+	}
+	rec, _ = ReadTask(db, taskId)
+	if rec.Status != TaskExpired || ReadWatchCount(db) != 0 {
+		t.Errorf("expected Expired after max runs and empty watch list, got status=%d", rec.Status)
+	}
+}
+
+func TestConditionalTaskExpiryRefunds(t *testing.T) {
+	db := newStateDB(t)
+	sched := common.HexToAddress("0xCAFE")
+	fund(db, sched, 100)
+	before := new(big.Int).Set(db.GetBalance(sched))
+
+	taskId, err := scheduleAction(t, db, 1, sched, schedulePayload{
+		Target:       "0xBEEF",
+		GasLimit:     params.TaskMinGasLimit,
+		DelayBlocks:  1,
+		ExpiryBlocks: 5,
+		Condition:    &conditionPayload{Kind: "storage", Op: "ne", Account: "0x7A01", Slot: "0x01"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls [][]byte
+	for b := uint64(2); b <= 7; b++ {
+		ProcessDueTasks(db, noopBlockCtx(b), params.MainnetChainConfig, b, recordingExec(&calls))
+	}
+	rec, _ := ReadTask(db, taskId)
+	if len(calls) != 0 || rec.Status != TaskExpired {
+		t.Fatalf("expected an expired task without runs, got status=%d runs=%d", rec.Status, len(calls))
+	}
+	// The deposit is refunded less the watch fee and the checks it paid for.
+	if rec.CheckGas != 4*params.TaskConditionCheckGas {
+		t.Fatalf("check gas = %d, want %d", rec.CheckGas, 4*params.TaskConditionCheckGas)
+	}
+	spent := new(big.Int).Mul(new(big.Int).SetUint64(params.TaskWatchFeeGas+rec.CheckGas), rec.GasPrice())
+	if want := new(big.Int).Sub(before, spent); db.GetBalance(sched).Cmp(want) != 0 {
+		t.Errorf("deposit not refunded: have %s, want %s", db.GetBalance(sched), want)
+	}
+	if ReadWatchCount(db) != 0 || ReadActiveCount(db, sched) != 0 {
+		t.Errorf("expired task should leave the watch list and active count")
+	}
+}
+
+func TestConditionalTasksBoundedPerBlock(t *testing.T) {
+	db := newStateDB(t)
+	sched := common.HexToAddress("0xCAFE")
+	fund(db, sched, 10_000)
+
+	total := params.TaskMaxConditionChecks + 6
+	for i := uint64(0); i < total; i++ {
+		if _, err := scheduleAction(t, db, 1, sched, schedulePayload{
+			Target:       "0xBEEF",
+			GasLimit:     params.TaskMinGasLimit,
+			DelayBlocks:  1,
+			ExpiryBlocks: 100,
+			Condition:    &conditionPayload{Kind: "storage", Op: "eq", Account: "0x7A01", Slot: "0x01"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var calls [][]byte
+	n, _, err := ProcessDueTasks(db, noopBlockCtx(2), params.MainnetChainConfig, 2, recordingExec(&calls))
+	if err != nil || uint64(n) != params.TaskMaxPerBlock {
+		t.Fatalf("expected %d tasks in the first block, got %d (err=%v)", params.TaskMaxPerBlock, n, err)
+	}
+	n, _, _ = ProcessDueTasks(db, noopBlockCtx(3), params.MainnetChainConfig, 3, recordingExec(&calls))
+	if uint64(n) != total-params.TaskMaxPerBlock {
+		t.Fatalf("expected the remaining %d tasks in the second block, got %d", total-params.TaskMaxPerBlock, n)
+	}
+	if ReadWatchCount(db) != 0 {
+		t.Errorf("expected an empty watch list, got %d", ReadWatchCount(db))
+	}
+}
+
+func TestConditionalTasksCheckedByBid(t *testing.T) {
+	db := newStateDB(t)
+	sched := common.HexToAddress("0xCAFE")
+	bidder := common.HexToAddress("0xB1D")
+	fund(db, sched, 10_000)
+	fund(db, bidder, 10_000)
+
+	// A full round of zero-bid tasks whose condition never holds.
+	for i := uint64(0); i < params.TaskMaxConditionChecks; i++ {
+		if _, err := scheduleAction(t, db, 1, sched, schedulePayload{
+			Target:       "0xBEEF",
+			GasLimit:     params.TaskMinGasLimit,
+			DelayBlocks:  1,
+			ExpiryBlocks: 100,
+			Condition:    &conditionPayload{Kind: "storage", Op: "eq", Account: "0x7A01", Slot: "0x01", Value: "0x01"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	taskId, err := scheduleAction(t, db, 1, bidder, schedulePayload{
+		Target:       "0xBEEF",
+		GasLimit:     params.TaskMinGasLimit,
+		Bid:          1,
+		DelayBlocks:  1,
+		ExpiryBlocks: 100,
+		Condition:    &conditionPayload{Kind: "storage", Op: "eq", Account: "0x7A01", Slot: "0x01"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var calls [][]byte
+	if n, _, err := ProcessDueTasks(db, noopBlockCtx(2), params.MainnetChainConfig, 2, recordingExec(&calls)); err != nil || n != 1 {
+		t.Fatalf("expected the bidding task to be checked and run first, got n=%d err=%v", n, err)
+	}
+	if rec, _ := ReadTask(db, taskId); rec.Status != TaskDone {
+		t.Fatalf("bidding task status = %d, want Done", rec.Status)
+	}
+}
+
+func TestLowBidConditionFiresPastHighBids(t *testing.T) {
+	db := newStateDB(t)
+	whale := common.HexToAddress("0xB1D")
+	small := common.HexToAddress("0xCAFE")
+	fund(db, whale, 10_000)
+	fund(db, small, 10)
+
+	// A full round of high bids whose condition never holds.
+	var hogs []common.Hash
+	for i := uint64(0); i < params.TaskMaxConditionChecks; i++ {
+		id, err := scheduleAction(t, db, 1, whale, schedulePayload{
+			Target:       "0xBEEF",
+			GasLimit:     params.TaskMinGasLimit,
+			Bid:          1_000_000,
+			DelayBlocks:  1,
+			ExpiryBlocks: 1000,
+			Condition:    &conditionPayload{Kind: "storage", Op: "eq", Account: "0x7A01", Slot: "0x01", Value: "0x01"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hogs = append(hogs, id)
+	}
+	// A zero bid whose condition already holds.
+	taskId, err := scheduleAction(t, db, 1, small, schedulePayload{
+		Target:       "0xBEEF",
+		GasLimit:     params.TaskMinGasLimit,
+		DelayBlocks:  1,
+		ExpiryBlocks: 1000,
+		Condition:    &conditionPayload{Kind: "storage", Op: "eq", Account: "0x7A01", Slot: "0x01"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each check drains the high bids' deposits until they expire, after
+	// which the cheap task is checked and fires.
+	var calls [][]byte
+	limit := 2 + params.TaskMinGasLimit/params.TaskConditionCheckGas
+	for b := uint64(2); b <= limit; b++ {
+		if _, _, err := ProcessDueTasks(db, noopBlockCtx(b), params.MainnetChainConfig, b, recordingExec(&calls)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if rec, _ := ReadTask(db, taskId); rec.Status != TaskDone {
+		t.Fatalf("low-bid task status = %d, want Done", rec.Status)
+	}
+	if len(calls) != 1 {
+		t.Fatalf("got %d runs, want only the low-bid task", len(calls))
+	}
+	for _, id := range hogs {
+		if rec, _ := ReadTask(db, id); rec.Status != TaskExpired || rec.Runs != 0 {
+			t.Fatalf("high-bid task: status=%d runs=%d, want expired without runs", rec.Status, rec.Runs)
+		}
+	}
+}
+
+func TestWatchFeeNotRefundedOnCancel(t *testing.T) {
+	db := newStateDB(t)
+	sched := common.HexToAddress("0xCAFE")
+	fund(db, sched, 100)
+	before := new(big.Int).Set(db.GetBalance(sched))
+
+	taskId, err := scheduleAction(t, db, 1, sched, schedulePayload{
+		Target:       "0xBEEF",
+		GasLimit:     params.TaskMinGasLimit,
+		Bid:          5,
+		DelayBlocks:  1,
+		ExpiryBlocks: 100,
+		Condition:    &conditionPayload{Kind: "storage", Op: "eq", Account: "0x7A01", Slot: "0x01", Value: "0x01"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := sysaction.MakeSysAction(sysaction.ActionTaskCancel, cancelPayload{TaskID: taskId.Hex()})
+	if err := sysaction.ExecuteWithContext(&sysaction.Context{
+		From:        sched,
+		Value:       new(big.Int),
+		BlockNumber: big.NewInt(1),
+		StateDB:     db,
+	}, data); err != nil {
+		t.Fatal(err)
+	}
+	rec, _ := ReadTask(db, taskId)
+	if want := new(big.Int).Sub(before, rec.WatchFee()); db.GetBalance(sched).Cmp(want) != 0 {
+		t.Fatalf("balance after cancel: have %s, want %s", db.GetBalance(sched), want)
+	}
+}
+
+func TestWatchListFullEvictsLowestBid(t *testing.T) {
+	db := newStateDB(t)
+	cond := &conditionPayload{Kind: "storage", Op: "eq", Account: "0x7A01", Slot: "0x01", Value: "0x01"}
+	watch := func(from common.Address, bid uint64) (common.Hash, error) {
+		return scheduleAction(t, db, 1, from, schedulePayload{
+			Target:       "0xBEEF",
+			GasLimit:     params.TaskMinGasLimit,
+			Bid:          bid,
+			DelayBlocks:  1,
+			ExpiryBlocks: 100,
+			Condition:    cond,
+		})
+	}
+
+	// Fill the watch list; the first entry bids lowest.
+	cheap := common.HexToAddress("0xC0")
+	fund(db, cheap, 100)
+	cheapId, err := watch(cheap, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := uint64(1); i < params.TaskMaxWatched; i++ {
+		from := common.BigToAddress(new(big.Int).SetUint64(0x1000 + i/params.TaskMaxPerContract))
+		fund(db, from, 1)
+		if _, err := watch(from, 1); err != nil {
+			t.Fatalf("watch %d: %v", i, err)
+		}
+	}
+	latecomer := common.HexToAddress("0xDD")
+	fund(db, latecomer, 100)
+	if _, err := watch(latecomer, 0); !errors.Is(err, ErrTaskWatchListFull) {
+		t.Fatalf("equal lowest bid: want %v, got %v", ErrTaskWatchListFull, err)
+	}
+
+	before := new(big.Int).Set(db.GetBalance(cheap))
+	if _, err := watch(latecomer, 1); err != nil {
+		t.Fatalf("outbidding task: %v", err)
+	}
+	if n := ReadWatchCount(db); n != params.TaskMaxWatched {
+		t.Fatalf("watch count = %d, want %d", n, params.TaskMaxWatched)
+	}
+	rec, _ := ReadTask(db, cheapId)
+	if rec.Status != TaskExpired || ReadActiveCount(db, cheap) != 0 {
+		t.Fatalf("evicted task: status=%d active=%d", rec.Status, ReadActiveCount(db, cheap))
+	}
+	if want := new(big.Int).Add(before, rec.Deposit()); db.GetBalance(cheap).Cmp(want) != 0 {
+		t.Fatalf("evicted deposit not refunded: have %s, want %s", db.GetBalance(cheap), want)
+	}
+}
+
+func TestScheduleConditionValidation(t *testing.T) {
+	db := newStateDB(t)
+	sched := common.HexToAddress("0xCAFE")
+	fund(db, sched, 100)
+	storage := &conditionPayload{Kind: "storage", Op: "gt", Account: "0x7A01", Slot: "0x01"}
+
+	for _, tc := range []struct {
+		name string
+		p    schedulePayload
+		want error
+	}{
+		{"calldata and selector", schedulePayload{Selector: "0x01020304", Calldata: "0x01"}, ErrTaskCalldataConflict},
+		{"calldata too long", schedulePayload{Calldata: hexutil.Encode(make([]byte, params.TaskMaxCalldataBytes+1))}, ErrTaskCalldataTooLong},
+		{"unknown kind", schedulePayload{Condition: &conditionPayload{Kind: "price", Op: "gt"}, ExpiryBlocks: 10}, ErrTaskInvalidCondition},
+		{"unknown op", schedulePayload{Condition: &conditionPayload{Kind: "storage", Op: ">", Account: "0x7A01"}, ExpiryBlocks: 10}, ErrTaskInvalidCondition},
+		{"oracle without feed", schedulePayload{Condition: &conditionPayload{Kind: "oracle", Op: "lt", Account: "0x0A"}, ExpiryBlocks: 10}, ErrTaskInvalidCondition},
+		{"receipt status", schedulePayload{Condition: &conditionPayload{Kind: "receipt", Op: "eq", Slot: "0x01", Status: "done"}, ExpiryBlocks: 10}, ErrTaskInvalidCondition},
+		{"no expiry", schedulePayload{Condition: storage}, ErrTaskInvalidExpiry},
+		{"expiry too far", schedulePayload{Condition: storage, ExpiryBlocks: params.TaskMaxHorizonBlocks + 1}, ErrTaskInvalidExpiry},
+		{"expiry without condition", schedulePayload{ExpiryBlocks: 10}, ErrTaskInvalidExpiry},
+	} {
+		tc.p.Target = "0xBEEF"
+		tc.p.GasLimit = params.TaskMinGasLimit
+		tc.p.DelayBlocks = 1
+		if _, err := scheduleAction(t, db, 1, sched, tc.p); !errors.Is(err, tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+// ── Bids and priority ─────────────────────────────────────────────────────────
+
+func TestProcessDueTasksBidPriority(t *testing.T) {
+	db := newStateDB(t)
+	sched := common.HexToAddress("0xCAFE")
+	bidder := common.HexToAddress("0xB1D")
+	coinbase := common.HexToAddress("0xC01B")
+	fund(db, sched, 10_000)
+	fund(db, bidder, 100)
+
+	var lowIds []common.Hash
+	for i := uint64(0); i < params.TaskMaxPerBlock; i++ {
+		id, err := doSchedule(t, db, 10, sched, params.TaskMinGasLimit, 5, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lowIds = append(lowIds, id)
+	}
+	bid := uint64(2_000_000_000)
+	before := new(big.Int).Set(db.GetBalance(bidder))
+	bidId, err := scheduleAction(t, db, 10, bidder, schedulePayload{
+		Target:      "0xB1DB1D",
+		GasLimit:    params.TaskMinGasLimit,
+		DelayBlocks: 5,
+		Bid:         bid,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, _ := ReadTask(db, bidId)
+	if paid := new(big.Int).Sub(before, db.GetBalance(bidder)); paid.Cmp(rec.Deposit()) != 0 {
+		t.Fatalf("deposit: paid %s, want %s", paid, rec.Deposit())
+	}
+
+	var targets []common.Address
+	exec := func(_ vmtypes.StateDB, _ vmtypes.BlockContext, _ *params.ChainConfig,
+		_, target common.Address, _ []byte, gasLimit uint64,
+	) ExecResult {
+		targets = append(targets, target)
+		return ExecResult{GasUsed: gasLimit / 2}
+	}
+	blockCtx := noopBlockCtx(15)
+	blockCtx.Coinbase = coinbase
+	if _, _, err := ProcessDueTasks(db, blockCtx, params.MainnetChainConfig, 15, exec); err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) == 0 || targets[0] != common.HexToAddress("0xB1DB1D") {
+		t.Fatalf("highest bid should run first, got %v", targets)
+	}
+	// The bid is paid on the gas used; the rest of the deposit is refunded.
+	wantFee := new(big.Int).SetUint64(params.TaskMinGasLimit / 2 * bid)
+	if got := db.GetBalance(coinbase); got.Cmp(wantFee) != 0 {
+		t.Errorf("coinbase fee: got %s, want %s", got, wantFee)
+	}
+	wantCost := new(big.Int).Mul(big.NewInt(int64(params.TaskMinGasLimit/2)), rec.GasPrice())
+	if cost := new(big.Int).Sub(before, db.GetBalance(bidder)); cost.Cmp(wantCost) != 0 {
+		t.Errorf("bidder cost: got %s, want %s", cost, wantCost)
+	}
+	// The last zero-bid task in queue order is the one deferred.
+	last, _ := ReadTask(db, lowIds[len(lowIds)-1])
+	if last.Status != TaskPending || last.TargetBlock != 16 || last.Deferrals != 1 {
+		t.Errorf("expected deferral to block 16, got status=%d next=%d deferrals=%d",
+			last.Status, last.TargetBlock, last.Deferrals)
+	}
+}
+
+func TestDeferredTasksGainPriority(t *testing.T) {
+	db := newStateDB(t)
+	sched := common.HexToAddress("0xCAFE")
+	fund(db, sched, 10_000)
+
+	// One task has waited three blocks; the others outbid it but by less
+	// than its age boost.
+	oldId := NewTaskID(sched, 20, 0)
+	WriteTask(db, oldId, &TaskRecord{
+		Scheduler: sched, Target: common.HexToAddress("0x01D"),
+		GasLimit: params.TaskMinGasLimit, Deferrals: 3, Status: TaskPending,
+	})
+	for i := uint64(1); i <= params.TaskMaxPerBlock; i++ {
+		id := NewTaskID(sched, 20, i)
+		WriteTask(db, id, &TaskRecord{
+			Scheduler: sched, Target: common.HexToAddress("0xBEEF"),
+			GasLimit: params.TaskMinGasLimit, Bid: 3*params.TaskAgeBoostTomi - 1, Status: TaskPending,
+		})
+		EnqueueTask(db, 20, id)
+	}
+	EnqueueTask(db, 20, oldId)
+
+	api := NewPublicTaskAPI(func() StateDB { return db })
+	depth, err := api.GetQueueDepth(19, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(depth) != 2 || depth[0].Depth != 0 || depth[1].Depth != params.TaskMaxPerBlock+1 {
+		t.Fatalf("unexpected queue depth: %+v", depth)
+	}
+	if want := new(big.Int).SetUint64(3*params.TaskAgeBoostTomi - 1).String(); depth[1].MinPriority != want {
+		t.Errorf("min priority: got %s, want %s", depth[1].MinPriority, want)
+	}
+	if _, err := api.GetQueueDepth(0, MaxQueueDepthBlocks+1); !errors.Is(err, ErrTaskQueueRange) {
+		t.Errorf("expected ErrTaskQueueRange, got %v", err)
+	}
+
+	var targets []common.Address
+	exec := func(_ vmtypes.StateDB, _ vmtypes.BlockContext, _ *params.ChainConfig,
+		_, target common.Address, _ []byte, gasLimit uint64,
+	) ExecResult {
+		targets = append(targets, target)
+		return ExecResult{GasUsed: gasLimit}
+	}
+	if _, _, err := ProcessDueTasks(db, noopBlockCtx(20), params.MainnetChainConfig, 20, exec); err != nil {
+		t.Fatal(err)
+	}
+	if targets[0] != common.HexToAddress("0x01D") {
+		t.Fatalf("aged task should run first, got %v", targets[0])
+	}
+	if rec, _ := ReadTask(db, oldId); rec.Deferrals != 0 {
+		t.Errorf("deferrals should reset after a run, got %d", rec.Deferrals)
+	}
+}
@@ -2,8 +2,10 @@ package task
 
 import (
 	"errors"
+	"math/big"
 
 	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/params"
 )
 
 // TaskStatus is the lifecycle state of a scheduled task.
@@ -16,20 +18,95 @@ const (
 	TaskExpired   TaskStatus = 3
 )
 
+// ConditionKind selects what a conditional task watches.
+type ConditionKind uint8
+
+const (
+	CondNone    ConditionKind = 0 // time-triggered task
+	CondStorage ConditionKind = 1 // storage slot of a contract
+	CondOracle  ConditionKind = 2 // oracle feed value
+	CondReceipt ConditionKind = 3 // settlement runtime receipt status
+)
+
+// CompareOp is the comparison a condition applies between the watched value
+// and the condition operand. Values compare as unsigned 256-bit integers.
+type CompareOp uint8
+
+const (
+	OpEq CompareOp = 1
+	OpNe CompareOp = 2
+	OpLt CompareOp = 3
+	OpLe CompareOp = 4
+	OpGt CompareOp = 5
+	OpGe CompareOp = 6
+)
+
+// Condition is the trigger of a conditional task. The task fires in the first
+// block, at or after its TargetBlock, in which the processor finds the
+// condition holding.
+type Condition struct {
+	Kind    ConditionKind
+	Op      CompareOp
+	Account common.Address // contract whose slot is read, or the oracle
+	Key     common.Hash    // storage slot, or settlement receipt ref
+	FeedKey string         // oracle data key
+	Value   common.Hash    // operand; receipt status in the last byte
+	MaxAge  uint64         // oracle: max feed age in seconds; 0 = any age
+}
+
 // TaskRecord holds all on-chain state for a single scheduled task.
 type TaskRecord struct {
 	Scheduler      common.Address // address that created the task
 	Target         common.Address // contract to call when task fires
 	Selector       [4]byte        // 4-byte ABI function selector
 	TaskData       common.Hash    // 32 bytes of auxiliary call data
+	Calldata       []byte         // full calldata; replaces Selector ++ TaskData when set
 	GasLimit       uint64         // gas budget pre-deposited
+	CheckGas       uint64         // deposit gas spent on condition checks since the last run
+	Bid            uint64         // priority fee in tomi per gas, paid to the block producer
+	Deferrals      uint64         // blocks deferred under congestion since the last run
 	TargetBlock    uint64         // block number when the task is first due
 	IntervalBlocks uint64         // re-schedule interval; 0 = one-shot
 	MaxRuns        uint64         // max executions; 0 = unlimited
 	Runs           uint64         // number of completed executions so far
 	Status         TaskStatus
+	Condition      *Condition // trigger of a conditional task; nil = time-triggered
+	ExpiryBlock    uint64     // conditional tasks expire after this block
 }
 
+// GasPrice returns the price per gas the task pays: the protocol tx price
+// plus its bid.
+func (t *TaskRecord) GasPrice() *big.Int {
+	return new(big.Int).Add(big.NewInt(params.TxPriceTomi), new(big.Int).SetUint64(t.Bid))
+}
+
+// RunGas returns the gas left in the deposit for the next run: the gas limit
+// less what condition checks have used since the last run.
+func (t *TaskRecord) RunGas() uint64 {
+	return t.GasLimit - t.CheckGas
+}
+
+// Deposit returns the deposit still held for the next run of the task.
+func (t *TaskRecord) Deposit() *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(t.RunGas()), t.GasPrice())
+}
+
+// WatchFee returns the non-refundable fee a conditional task pays to join the
+// watch list.
+func (t *TaskRecord) WatchFee() *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(params.TaskWatchFeeGas), t.GasPrice())
+}
+
+// Priority orders due tasks competing for TaskMaxPerBlock: the bid plus
+// TaskAgeBoostTomi for every block the task has been deferred.
+func (t *TaskRecord) Priority() *big.Int {
+	boost := new(big.Int).Mul(new(big.Int).SetUint64(t.Deferrals), new(big.Int).SetUint64(params.TaskAgeBoostTomi))
+	return boost.Add(boost, new(big.Int).SetUint64(t.Bid))
+}
+
+// MaxFeedKeyLength caps the oracle data key stored with a condition.
+const MaxFeedKeyLength = 128
+
 var (
 	ErrTaskGasLimitTooLow      = errors.New("task: gas_limit below minimum")
 	ErrTaskGasLimitTooHigh     = errors.New("task: gas_limit above maximum")
@@ -42,6 +119,12 @@ var (
 	ErrTaskNotScheduler        = errors.New("task: caller is not the task scheduler")
 	ErrTaskLogsNotAllowed      = errors.New("task: callback emitted logs")
 	ErrTaskIntervalTooFar      = errors.New("task: interval_blocks exceeds horizon")
+	ErrTaskCalldataTooLong     = errors.New("task: calldata exceeds maximum length")
+	ErrTaskCalldataConflict    = errors.New("task: calldata excludes selector and task_data")
+	ErrTaskInvalidCondition    = errors.New("task: invalid condition")
+	ErrTaskInvalidExpiry       = errors.New("task: expiry_blocks must cover delay_blocks within horizon")
+	ErrTaskQueueRange          = errors.New("task: block count must be between 1 and MaxQueueDepthBlocks")
+	ErrTaskWatchListFull       = errors.New("task: watch list is full and bid does not exceed the lowest watched bid")
 )
 
 type ExecResult struct {
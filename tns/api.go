@@ -1,11 +1,17 @@
 package tns
 
 import (
+	"errors"
+	"math/big"
 	"strings"
 
 	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/params"
 )
 
+// ErrStateUnavailable is returned by the RPC API when no head state exists.
+var ErrStateUnavailable = errors.New("tns: state unavailable")
+
 // TNSResolveResult is the JSON-friendly result for Resolve.
 type TNSResolveResult struct {
 	Name     string         `json:"name"`
@@ -21,14 +27,37 @@ type TNSReverseResult struct {
 	Found    bool           `json:"found"`
 }
 
+// TNSNameResult is the JSON-friendly result for GetName.
+type TNSNameResult struct {
+	Name       string         `json:"name"`
+	NameHash   common.Hash    `json:"name_hash"`
+	Status     string         `json:"status"`                // available, active, grace or permanent
+	Owner      common.Address `json:"owner"`                 // top-level names only
+	Address    common.Address `json:"address"`               // resolved address at the head block
+	Parent     *common.Hash   `json:"parent,omitempty"`      // subnames only
+	Expiry     uint64         `json:"expiry"`                // zero unless active or in grace
+	GraceEnd   uint64         `json:"grace_end"`             // block after which the name is available
+	RenewalFee string         `json:"renewal_fee,omitempty"` // yearly fee in tomi; top-level names only
+}
+
+// TNSRecordsResult is the JSON-friendly result for GetRecords.
+type TNSRecordsResult struct {
+	Name     string            `json:"name"`
+	NameHash common.Hash       `json:"name_hash"`
+	Found    bool              `json:"found"`   // false if the name does not resolve
+	Records  map[string]string `json:"records"` // set records only
+}
+
 // PublicTNSAPI provides RPC methods for querying TNS state.
 type PublicTNSAPI struct {
-	stateReader func() stateDB
+	headReader func() (stateDB, uint64)
 }
 
-// NewPublicTNSAPI creates a new TNS API instance.
-func NewPublicTNSAPI(stateReader func() stateDB) *PublicTNSAPI {
-	return &PublicTNSAPI{stateReader: stateReader}
+// NewPublicTNSAPI creates a new TNS API instance. headReader must return the
+// state and number of the current head block; name expiry is evaluated at
+// that block.
+func NewPublicTNSAPI(headReader func() (stateDB, uint64)) *PublicTNSAPI {
+	return &PublicTNSAPI{headReader: headReader}
 }
 
 // NormalizeName strips the @tos.network suffix if present and lowercases.
@@ -39,14 +68,17 @@ func NormalizeName(name string) string {
 	return n
 }
 
-// Resolve resolves a TNS name to an address.
+// Resolve resolves a TNS name or subname to an address.
 // Accepts both "alice" and "alice@tos.network".
 // RPC: tns_resolve
 func (api *PublicTNSAPI) Resolve(name string) (*TNSResolveResult, error) {
-	db := api.stateReader()
+	db, head := api.headReader()
+	if db == nil {
+		return nil, ErrStateUnavailable
+	}
 	bare := NormalizeName(name)
 	nameHash := HashName(bare)
-	addr := Resolve(db, nameHash)
+	addr := Resolve(db, nameHash, head)
 	return &TNSResolveResult{
 		Name:     bare + TNSSuffix,
 		NameHash: nameHash,
@@ -58,11 +90,97 @@ func (api *PublicTNSAPI) Resolve(name string) (*TNSResolveResult, error) {
 // Reverse resolves an address to its registered TNS name hash.
 // RPC: tns_reverse
 func (api *PublicTNSAPI) Reverse(address common.Address) (*TNSReverseResult, error) {
-	db := api.stateReader()
-	nameHash := Reverse(db, address)
+	db, head := api.headReader()
+	if db == nil {
+		return nil, ErrStateUnavailable
+	}
+	nameHash := Reverse(db, address, head)
 	return &TNSReverseResult{
 		Address:  address,
 		NameHash: nameHash,
 		Found:    nameHash != (common.Hash{}),
 	}, nil
 }
+
+// GetName returns the ownership and expiry record of a name or subname.
+// A subname reports its parent's status and expiry.
+// RPC: tns_getName
+func (api *PublicTNSAPI) GetName(name string) (*TNSNameResult, error) {
+	db, head := api.headReader()
+	if db == nil {
+		return nil, ErrStateUnavailable
+	}
+	bare := NormalizeName(name)
+	nameHash := HashName(bare)
+	res := &TNSNameResult{
+		Name:     bare + TNSSuffix,
+		NameHash: nameHash,
+		Address:  Resolve(db, nameHash, head),
+	}
+	top := nameHash
+	if parent := ParentOf(db, nameHash); parent != (common.Hash{}) {
+		res.Parent = &parent
+		top = parent
+	} else {
+		res.Owner = OwnerOf(db, nameHash, head)
+		res.RenewalFee = RegistrationFee(bare).String()
+	}
+	status := StatusOf(db, top, head)
+	res.Status = status.String()
+	if status == NameActive || status == NameGrace {
+		res.Expiry = Expiry(db, top)
+		res.GraceEnd = res.Expiry + params.TNSGracePeriodBlocks
+	}
+	return res, nil
+}
+
+// Price returns the fee, in tomi, for registering or renewing name for years
+// (default 1).
+// RPC: tns_price
+func (api *PublicTNSAPI) Price(name string, years *uint64) (string, error) {
+	bare := NormalizeName(name)
+	if err := validateTopLevel(bare); err != nil {
+		return "", err
+	}
+	var n uint64
+	if years != nil {
+		n = *years
+	}
+	n, err := registrationYears(n)
+	if err != nil {
+		return "", err
+	}
+	return new(big.Int).Mul(RegistrationFee(bare), new(big.Int).SetUint64(n)).String(), nil
+}
+
+// GetRecords returns the well-known resolver records of a name or subname
+// (elgamal, agent_card, contenthash, gateway) plus the given text record
+// keys. Unset records are omitted.
+// RPC: tns_getRecords
+func (api *PublicTNSAPI) GetRecords(name string, textKeys []string) (*TNSRecordsResult, error) {
+	db, head := api.headReader()
+	if db == nil {
+		return nil, ErrStateUnavailable
+	}
+	bare := NormalizeName(name)
+	nameHash := HashName(bare)
+	res := &TNSRecordsResult{
+		Name:     bare + TNSSuffix,
+		NameHash: nameHash,
+		Found:    Resolve(db, nameHash, head) != (common.Address{}),
+		Records:  make(map[string]string),
+	}
+	if !res.Found {
+		return res, nil
+	}
+	keys := append([]string{RecordElGamal, RecordAgentCard, RecordContentHash, RecordGateway}, textKeys...)
+	for _, key := range keys {
+		if err := validateRecordKey(key); err != nil {
+			return nil, err
+		}
+		if raw := ReadRecord(db, nameHash, key, head); raw != nil {
+			res.Records[key] = FormatRecord(key, raw)
+		}
+	}
+	return res, nil
+}
@@ -2,6 +2,7 @@ package tns
 
 import (
 	"encoding/json"
+	"math/big"
 	"strings"
 	"unicode"
 
@@ -17,15 +18,58 @@ func init() {
 type tnsHandler struct{}
 
 func (h *tnsHandler) Actions() []sysaction.ActionKind {
-	return []sysaction.ActionKind{sysaction.ActionTNSRegister}
+	return []sysaction.ActionKind{
+		sysaction.ActionTNSRegister,
+		sysaction.ActionTNSRenew,
+		sysaction.ActionTNSTransfer,
+		sysaction.ActionTNSSubnameSet,
+		sysaction.ActionTNSSubnameRevoke,
+		sysaction.ActionTNSSetRecord,
+	}
 }
 
 func (h *tnsHandler) Handle(ctx *sysaction.Context, sa *sysaction.SysAction) error {
-	return h.handleRegister(ctx, sa)
+	switch sa.Action {
+	case sysaction.ActionTNSRenew:
+		return h.handleRenew(ctx, sa)
+	case sysaction.ActionTNSTransfer:
+		return h.handleTransfer(ctx, sa)
+	case sysaction.ActionTNSSubnameSet:
+		return h.handleSubnameSet(ctx, sa)
+	case sysaction.ActionTNSSubnameRevoke:
+		return h.handleSubnameRevoke(ctx, sa)
+	case sysaction.ActionTNSSetRecord:
+		return h.handleSetRecord(ctx, sa)
+	default:
+		return h.handleRegister(ctx, sa)
+	}
 }
 
 type registerPayload struct {
-	Name string `json:"name"`
+	Name  string `json:"name"`
+	Years uint64 `json:"years,omitempty"` // defaults to 1
+}
+
+type renewPayload struct {
+	Name  string `json:"name"`
+	Years uint64 `json:"years,omitempty"` // defaults to 1
+}
+
+type transferPayload struct {
+	Name string         `json:"name"`
+	To   common.Address `json:"to"`
+}
+
+type subnamePayload struct {
+	Parent  string         `json:"parent"`            // top-level name, e.g. "acme"
+	Label   string         `json:"label"`             // e.g. "pay" for "pay.acme"
+	Address common.Address `json:"address,omitempty"` // TNS_SUBNAME_SET only
+}
+
+type setRecordPayload struct {
+	Name  string `json:"name"`  // name or subname
+	Key   string `json:"key"`   // well-known key or text record key
+	Value string `json:"value"` // 0x-hex for hash records; empty clears
 }
 
 // reservedNames is the set of names that cannot be registered.
@@ -34,28 +78,26 @@ var reservedNames = map[string]struct{}{
 	"null": {}, "test": {}, "node": {}, "validator": {},
 }
 
-// validateName checks the TNS name format rules:
-//   - length 3–64
+func isSeparator(r rune) bool {
+	return r == '.' || r == '-' || r == '_'
+}
+
+// checkChars checks the character rules shared by names and subname labels:
 //   - starts with lowercase letter
 //   - only a-z, 0-9, '.', '-', '_'
 //   - does not end with separator
 //   - no consecutive separators
-//   - not a reserved word
-func validateName(name string) error {
-	if len(name) < params.TNSMinNameLen || len(name) > params.TNSMaxNameLen {
-		return ErrTNSInvalidName
-	}
-	if _, reserved := reservedNames[name]; reserved {
+func checkChars(name string) error {
+	runes := []rune(name)
+	if len(runes) == 0 {
 		return ErrTNSInvalidName
 	}
-	runes := []rune(name)
 	// Must start with lowercase letter.
 	if !unicode.IsLower(runes[0]) || !unicode.IsLetter(runes[0]) {
 		return ErrTNSInvalidName
 	}
 	// Must not end with separator.
-	last := runes[len(runes)-1]
-	if last == '.' || last == '-' || last == '_' {
+	if isSeparator(runes[len(runes)-1]) {
 		return ErrTNSInvalidName
 	}
 	// Validate each character and check consecutive separators.
@@ -63,13 +105,10 @@ func validateName(name string) error {
 		switch {
 		case r >= 'a' && r <= 'z':
 		case r >= '0' && r <= '9':
-		case r == '.', r == '-', r == '_':
+		case isSeparator(r):
 			// No consecutive separators.
-			if i > 0 {
-				prev := runes[i-1]
-				if prev == '.' || prev == '-' || prev == '_' {
-					return ErrTNSInvalidName
-				}
+			if i > 0 && isSeparator(runes[i-1]) {
+				return ErrTNSInvalidName
 			}
 		default:
 			return ErrTNSInvalidName
@@ -78,42 +117,286 @@ func validateName(name string) error {
 	return nil
 }
 
-func (h *tnsHandler) handleRegister(ctx *sysaction.Context, sa *sysaction.SysAction) error {
-	// 1. Registration fee check.
-	if ctx.Value.Cmp(params.TNSRegistrationFee) < 0 {
+// validateName checks the TNS name format rules:
+//   - length 3–64
+//   - character rules of checkChars
+//   - not a reserved word
+func validateName(name string) error {
+	if len(name) < params.TNSMinNameLen || len(name) > params.TNSMaxNameLen {
+		return ErrTNSInvalidName
+	}
+	if _, reserved := reservedNames[name]; reserved {
+		return ErrTNSInvalidName
+	}
+	return checkChars(name)
+}
+
+// validateTopLevel checks a name that can be registered directly. Dots are
+// reserved for subnames.
+func validateTopLevel(name string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+	if strings.Contains(name, ".") {
+		return ErrTNSInvalidName
+	}
+	return nil
+}
+
+// subname validates a subname payload and returns the normalized parent and
+// the full subname ("label.parent").
+func subname(p *subnamePayload) (string, string, error) {
+	parent := strings.ToLower(p.Parent)
+	label := strings.ToLower(p.Label)
+	if err := validateTopLevel(parent); err != nil {
+		return "", "", err
+	}
+	if strings.Contains(label, ".") || checkChars(label) != nil {
+		return "", "", ErrTNSInvalidName
+	}
+	full := label + "." + parent
+	if len(full) > params.TNSMaxNameLen {
+		return "", "", ErrTNSInvalidName
+	}
+	return parent, full, nil
+}
+
+// registrationYears applies the default of one year and the upper bound.
+func registrationYears(years uint64) (uint64, error) {
+	if years == 0 {
+		years = 1
+	}
+	if years > params.TNSMaxRegistrationYears {
+		return 0, ErrTNSInvalidYears
+	}
+	return years, nil
+}
+
+// expiryActive reports whether names registered at the context's block
+// expire and are priced by length.
+func expiryActive(ctx *sysaction.Context) bool {
+	return ctx.ChainConfig != nil && ctx.ChainConfig.IsTNSExpiry(ctx.BlockNumber)
+}
+
+// chargeFee moves ctx.Value to the TNS registry after checking it covers
+// fee.
+func chargeFee(ctx *sysaction.Context, fee *big.Int) error {
+	if ctx.Value.Cmp(fee) < 0 {
 		return ErrTNSInsufficientFee
 	}
-	// 2. Balance check.
 	if ctx.StateDB.GetBalance(ctx.From).Cmp(ctx.Value) < 0 {
 		return ErrTNSInsufficientFee
 	}
+	ctx.StateDB.SubBalance(ctx.From, ctx.Value)
+	ctx.StateDB.AddBalance(params.TNSRegistryAddress, ctx.Value)
+	return nil
+}
 
-	// 3. Decode and validate name.
+func (h *tnsHandler) handleRegister(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	// 1. Decode and validate name and period.
 	var p registerPayload
 	if err := json.Unmarshal(sa.Payload, &p); err != nil {
 		return err
 	}
 	name := strings.ToLower(p.Name)
-	if err := validateName(name); err != nil {
+	if err := validateTopLevel(name); err != nil {
+		return err
+	}
+	years, err := registrationYears(p.Years)
+	if err != nil {
 		return err
 	}
+	now := ctx.BlockNumber.Uint64()
 
-	// 4. One name per account.
-	if HasName(ctx.StateDB, ctx.From) {
+	// 2. One name per account, including names in their grace period.
+	if holdsName(ctx.StateDB, ctx.From, now) {
 		return ErrTNSAccountHasName
 	}
 
-	// 5. Name must not be taken.
+	// 3. Name must be available: never registered or lapsed past grace.
 	nameHash := HashName(name)
-	if Resolve(ctx.StateDB, nameHash) != (common.Address{}) {
+	if StatusOf(ctx.StateDB, nameHash, now) != NameAvailable {
 		return ErrTNSAlreadyRegistered
 	}
 
-	// 6. Deduct fee: sender → TNS registry (treasury).
-	ctx.StateDB.SubBalance(ctx.From, ctx.Value)
-	ctx.StateDB.AddBalance(params.TNSRegistryAddress, ctx.Value)
+	// 4. Before TNSExpiryBlock names are permanent at the flat fee.
+	if !expiryActive(ctx) {
+		if err := chargeFee(ctx, params.TNSRegistrationFee); err != nil {
+			return err
+		}
+		writeMapping(ctx.StateDB, nameHash, ctx.From)
+		return nil
+	}
 
-	// 7. Write both directions of the mapping.
-	writeMapping(ctx.StateDB, nameHash, ctx.From)
+	// 5. Deduct length-based fee: sender → TNS registry (treasury).
+	if err := chargeFee(ctx, yearlyFee(name, years)); err != nil {
+		return err
+	}
+
+	// 6. Write both directions of the mapping and the expiry.
+	registerName(ctx.StateDB, nameHash, ctx.From, now+years*params.TNSRegistrationPeriodBlocks)
+	return nil
+}
+
+// yearlyFee returns the length-based fee for name over years.
+func yearlyFee(name string, years uint64) *big.Int {
+	return new(big.Int).Mul(RegistrationFee(name), new(big.Int).SetUint64(years))
+}
+
+// handleRenew extends a name by whole years. Anyone may pay; an expired name
+// can be renewed during its grace period, counting from its old expiry.
+// Names are permanent, and so cannot be renewed, before TNSExpiryBlock.
+func (h *tnsHandler) handleRenew(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p renewPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	name := strings.ToLower(p.Name)
+	if err := validateTopLevel(name); err != nil {
+		return err
+	}
+	years, err := registrationYears(p.Years)
+	if err != nil {
+		return err
+	}
+	now := ctx.BlockNumber.Uint64()
+	nameHash := HashName(name)
+	switch StatusOf(ctx.StateDB, nameHash, now) {
+	case NameAvailable:
+		return ErrTNSNameNotFound
+	case NamePermanent:
+		return ErrTNSPermanentName
+	}
+	expiry := Expiry(ctx.StateDB, nameHash) + years*params.TNSRegistrationPeriodBlocks
+	if expiry-now > params.TNSMaxRegistrationYears*params.TNSRegistrationPeriodBlocks {
+		return ErrTNSInvalidYears
+	}
+	if err := chargeFee(ctx, yearlyFee(name, years)); err != nil {
+		return err
+	}
+	writeU64(ctx.StateDB, nameHash, "expiry", expiry)
+	return nil
+}
+
+// handleTransfer moves an unexpired name, with its subnames, to a new owner.
+func (h *tnsHandler) handleTransfer(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p transferPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	name := strings.ToLower(p.Name)
+	if err := validateTopLevel(name); err != nil {
+		return err
+	}
+	nameHash := HashName(name)
+	if err := ownedBy(ctx, nameHash); err != nil {
+		return err
+	}
+	if p.To == (common.Address{}) || p.To == ctx.From {
+		return ErrTNSInvalidRecipient
+	}
+	if holdsName(ctx.StateDB, p.To, ctx.BlockNumber.Uint64()) {
+		return ErrTNSAccountHasName
+	}
+	transferName(ctx.StateDB, nameHash, ctx.From, p.To)
+	return nil
+}
+
+// ownedBy checks that the top-level name nameHash is unexpired and owned by
+// the caller.
+func ownedBy(ctx *sysaction.Context, nameHash common.Hash) error {
+	status := StatusOf(ctx.StateDB, nameHash, ctx.BlockNumber.Uint64())
+	if status == NameAvailable {
+		return ErrTNSNameNotFound
+	}
+	if rawOwner(ctx.StateDB, nameHash) != ctx.From {
+		return ErrTNSNotOwner
+	}
+	if status == NameGrace {
+		return ErrTNSNameExpired
+	}
+	return nil
+}
+
+// handleSubnameSet creates a subname under a name the caller owns, or points
+// an existing one at a new address.
+func (h *tnsHandler) handleSubnameSet(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p subnamePayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	parent, full, err := subname(&p)
+	if err != nil {
+		return err
+	}
+	if p.Address == (common.Address{}) {
+		return ErrTNSInvalidAddress
+	}
+	parentHash := HashName(parent)
+	if err := ownedBy(ctx, parentHash); err != nil {
+		return err
+	}
+	nameHash := HashName(full)
+	// A dotted name registered at top level before subnames existed keeps
+	// its slot.
+	if ParentOf(ctx.StateDB, nameHash) == (common.Hash{}) && rawOwner(ctx.StateDB, nameHash) != (common.Address{}) {
+		return ErrTNSAlreadyRegistered
+	}
+	writeSubname(ctx.StateDB, nameHash, parentHash, p.Address)
+	return nil
+}
+
+// handleSubnameRevoke deletes a subname under a name the caller owns.
+func (h *tnsHandler) handleSubnameRevoke(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p subnamePayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	parent, full, err := subname(&p)
+	if err != nil {
+		return err
+	}
+	parentHash := HashName(parent)
+	if err := ownedBy(ctx, parentHash); err != nil {
+		return err
+	}
+	nameHash := HashName(full)
+	if ParentOf(ctx.StateDB, nameHash) != parentHash {
+		return ErrTNSNameNotFound
+	}
+	deleteSubname(ctx.StateDB, nameHash)
+	return nil
+}
+
+// handleSetRecord sets or clears a resolver record. Records of a top-level
+// name are set by its owner, those of a subname by the parent's owner.
+func (h *tnsHandler) handleSetRecord(ctx *sysaction.Context, sa *sysaction.SysAction) error {
+	var p setRecordPayload
+	if err := json.Unmarshal(sa.Payload, &p); err != nil {
+		return err
+	}
+	name := strings.ToLower(p.Name)
+	if err := validateName(name); err != nil {
+		return err
+	}
+	if err := validateRecordKey(p.Key); err != nil {
+		return err
+	}
+	val, err := encodeRecord(p.Key, p.Value)
+	if err != nil {
+		return err
+	}
+	nameHash := HashName(name)
+	owned := nameHash
+	if parent := ParentOf(ctx.StateDB, nameHash); parent != (common.Hash{}) {
+		if Resolve(ctx.StateDB, nameHash, ctx.BlockNumber.Uint64()) == (common.Address{}) {
+			return ErrTNSNameNotFound
+		}
+		owned = parent
+	}
+	if err := ownedBy(ctx, owned); err != nil {
+		return err
+	}
+	writeRecord(ctx.StateDB, nameHash, p.Key, val)
 	return nil
 }
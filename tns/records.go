@@ -0,0 +1,160 @@
+package tns
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/common/hexutil"
+	"github.com/tos-network/gtos/crypto"
+	"github.com/tos-network/gtos/crypto/ristretto255"
+	"github.com/tos-network/gtos/params"
+)
+
+// Well-known resolver record keys. Any other key holds a text record.
+const (
+	RecordElGamal     = "elgamal"     // Priv ElGamal public key (32 bytes) for PrivTransfer
+	RecordAgentCard   = "agent_card"  // keccak256 of the agent's published discovery card
+	RecordContentHash = "contenthash" // content hash, e.g. of a site or package
+	RecordGateway     = "gateway"     // gateway relay endpoint
+)
+
+// isHashRecord reports whether key holds a 32-byte value.
+func isHashRecord(key string) bool {
+	return key == RecordElGamal || key == RecordAgentCard || key == RecordContentHash
+}
+
+// validateRecordKey checks a record key: 1–TNSMaxRecordKeyLen characters
+// from a-z, 0-9, '.', '-', '_'.
+func validateRecordKey(key string) error {
+	if len(key) == 0 || len(key) > params.TNSMaxRecordKeyLen {
+		return ErrTNSInvalidRecordKey
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9') && !isSeparator(r) {
+			return ErrTNSInvalidRecordKey
+		}
+	}
+	return nil
+}
+
+// encodeRecord converts a payload value to its stored bytes. Hash records
+// take a 0x-prefixed 32-byte hex value; an empty value clears any record.
+func encodeRecord(key, value string) ([]byte, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if !isHashRecord(key) {
+		if len(value) > params.TNSMaxRecordLen {
+			return nil, ErrTNSInvalidRecordValue
+		}
+		return []byte(value), nil
+	}
+	raw, err := hexutil.Decode(value)
+	if err != nil || len(raw) != 32 {
+		return nil, ErrTNSInvalidRecordValue
+	}
+	if key == RecordElGamal {
+		if _, err := new(ristretto255.Element).SetCanonicalBytes(raw); err != nil {
+			return nil, ErrTNSInvalidRecordValue
+		}
+	}
+	return raw, nil
+}
+
+// FormatRecord converts the stored bytes of record key to the form returned
+// by RPC and the LVM: hex for hash records and the raw string otherwise.
+func FormatRecord(key string, raw []byte) string {
+	if isHashRecord(key) {
+		return hexutil.Encode(raw)
+	}
+	return string(raw)
+}
+
+// ── Storage ───────────────────────────────────────────────────────────────────
+
+// recordBaseSlot returns the base slot of one record. The length lives at the
+// base slot and the data in the following slots.
+// key = keccak256("tns\x00res\x00" || nameHash[32] || version[8] || recordKey)
+func recordBaseSlot(nameHash common.Hash, version uint64, key string) *big.Int {
+	var ver [8]byte
+	binary.BigEndian.PutUint64(ver[:], version)
+	buf := append([]byte("tns\x00res\x00"), nameHash.Bytes()...)
+	buf = append(buf, ver[:]...)
+	buf = append(buf, []byte(key)...)
+	return new(big.Int).SetBytes(crypto.Keccak256(buf))
+}
+
+func offsetSlot(base *big.Int, i int) common.Hash {
+	return common.BigToHash(new(big.Int).Add(base, big.NewInt(int64(i))))
+}
+
+// bumpRecords discards every resolver record of nameHash. Records are keyed
+// by a version that changes whenever the name gets a new owner, a subname
+// is pointed at a new address, or a subname is revoked.
+func bumpRecords(db stateDB, nameHash common.Hash) {
+	writeU64(db, nameHash, "rver", readU64(db, nameHash, "rver")+1)
+}
+
+// readRecordRaw returns the stored bytes of record key for nameHash,
+// ignoring whether the name resolves.
+func readRecordRaw(db stateDB, nameHash common.Hash, key string) []byte {
+	base := recordBaseSlot(nameHash, readU64(db, nameHash, "rver"), key)
+	lenRaw := db.GetState(params.TNSRegistryAddress, offsetSlot(base, 0))
+	n := int(binary.BigEndian.Uint64(lenRaw[24:]))
+	if n == 0 || n > params.TNSMaxRecordLen {
+		return nil
+	}
+	buf := make([]byte, 0, n)
+	for i := 1; len(buf) < n; i++ {
+		raw := db.GetState(params.TNSRegistryAddress, offsetSlot(base, i))
+		if remaining := n - len(buf); remaining < 32 {
+			buf = append(buf, raw[:remaining]...)
+		} else {
+			buf = append(buf, raw[:]...)
+		}
+	}
+	return buf
+}
+
+// writeRecord stores val as record key of nameHash, zeroing any slots left
+// over from a longer previous value. An empty val deletes the record.
+func writeRecord(db stateDB, nameHash common.Hash, key string, val []byte) {
+	base := recordBaseSlot(nameHash, readU64(db, nameHash, "rver"), key)
+	lenRaw := db.GetState(params.TNSRegistryAddress, offsetSlot(base, 0))
+	oldWords := (int(binary.BigEndian.Uint64(lenRaw[24:])) + 31) / 32
+
+	var lenVal common.Hash
+	binary.BigEndian.PutUint64(lenVal[24:], uint64(len(val)))
+	db.SetState(params.TNSRegistryAddress, offsetSlot(base, 0), lenVal)
+	words := (len(val) + 31) / 32
+	for i := 0; i < words; i++ {
+		var word common.Hash
+		copy(word[:], val[i*32:])
+		db.SetState(params.TNSRegistryAddress, offsetSlot(base, i+1), word)
+	}
+	for i := words; i < oldWords; i++ {
+		db.SetState(params.TNSRegistryAddress, offsetSlot(base, i+1), common.Hash{})
+	}
+}
+
+// ReadRecord returns the stored bytes of record key of nameHash at block, or
+// nil if the name does not resolve or the record is unset.
+func ReadRecord(db stateDB, nameHash common.Hash, key string, block uint64) []byte {
+	if Resolve(db, nameHash, block) == (common.Address{}) {
+		return nil
+	}
+	return readRecordRaw(db, nameHash, key)
+}
+
+// ElGamalKeyOf returns the Priv ElGamal public key published by nameHash at
+// block, so that a PrivTransfer can be addressed to a name.
+func ElGamalKeyOf(db stateDB, nameHash common.Hash, block uint64) ([32]byte, bool) {
+	var pub [32]byte
+	raw := ReadRecord(db, nameHash, RecordElGamal, block)
+	if len(raw) != 32 {
+		return pub, false
+	}
+	copy(pub[:], raw)
+	return pub, true
+}
@@ -1,6 +1,9 @@
 package tns
 
 import (
+	"encoding/binary"
+	"math/big"
+
 	"github.com/tos-network/gtos/common"
 	"github.com/tos-network/gtos/crypto"
 	"github.com/tos-network/gtos/params"
@@ -26,6 +29,14 @@ func addrToNameSlot(addr common.Address) common.Hash {
 		append([]byte("tns\x00a2n\x00"), addr.Bytes()...)))
 }
 
+// recordSlot returns the slot for one field of a name record.
+// key = keccak256("tns\x00rec\x00" || nameHash[32] || field)
+func recordSlot(nameHash common.Hash, field string) common.Hash {
+	key := append([]byte("tns\x00rec\x00"), nameHash.Bytes()...)
+	key = append(key, []byte(field)...)
+	return common.BytesToHash(crypto.Keccak256(key))
+}
+
 // TNSSuffix is the canonical domain suffix appended to bare names before hashing.
 const TNSSuffix = "@tos.network"
 
@@ -36,22 +47,151 @@ func HashName(name string) common.Hash {
 	return common.BytesToHash(crypto.Keccak256([]byte(name + TNSSuffix)))
 }
 
-// Resolve returns the address registered for nameHash, or zero address if not found.
-func Resolve(db stateDB, nameHash common.Hash) common.Address {
+// RegistrationFee returns the yearly fee for registering or renewing name.
+// Three- and four-character names are priced higher than longer ones.
+func RegistrationFee(name string) *big.Int {
+	switch len(name) {
+	case 3:
+		return new(big.Int).Set(params.TNSThreeCharFee)
+	case 4:
+		return new(big.Int).Set(params.TNSFourCharFee)
+	default:
+		return new(big.Int).Set(params.TNSRegistrationFee)
+	}
+}
+
+// ── Raw record access ─────────────────────────────────────────────────────────
+
+func readU64(db stateDB, nameHash common.Hash, field string) uint64 {
+	raw := db.GetState(params.TNSRegistryAddress, recordSlot(nameHash, field))
+	return binary.BigEndian.Uint64(raw[24:])
+}
+
+func writeU64(db stateDB, nameHash common.Hash, field string, v uint64) {
+	var val common.Hash
+	binary.BigEndian.PutUint64(val[24:], v)
+	db.SetState(params.TNSRegistryAddress, recordSlot(nameHash, field), val)
+}
+
+// rawOwner returns the address stored for nameHash, ignoring expiry.
+func rawOwner(db stateDB, nameHash common.Hash) common.Address {
 	raw := db.GetState(params.TNSRegistryAddress, nameToAddrSlot(nameHash))
 	return common.BytesToAddress(raw[:])
 }
 
-// Reverse returns the name hash registered for addr, or zero hash if none.
-func Reverse(db stateDB, addr common.Address) common.Hash {
-	return db.GetState(params.TNSRegistryAddress, addrToNameSlot(addr))
+// legacyExpirySlot holds the expiry of names registered before
+// TNSExpiryBlock. It is zero until that fork activates.
+var legacyExpirySlot = common.BytesToHash(crypto.Keccak256([]byte("tns\x00legacyExpiry")))
+
+// Expiry returns the block at which nameHash expires. A name registered
+// before TNSExpiryBlock stores no expiry of its own and takes the legacy
+// expiry once the fork is active; until then Expiry returns zero and the
+// name is permanent.
+func Expiry(db stateDB, nameHash common.Hash) uint64 {
+	if expiry := readU64(db, nameHash, "expiry"); expiry != 0 {
+		return expiry
+	}
+	raw := db.GetState(params.TNSRegistryAddress, legacyExpirySlot)
+	return binary.BigEndian.Uint64(raw[24:])
+}
+
+// ActivateExpiry sets the expiry of names registered before TNSExpiryBlock
+// to TNSGracePeriodBlocks after the fork, giving their owners that long to
+// renew before the usual grace period starts. It is called for every block
+// and writes only once, at the first block from the fork on.
+func ActivateExpiry(db stateDB, blockNum uint64, cfg *params.ChainConfig) {
+	if cfg == nil || !cfg.IsTNSExpiry(new(big.Int).SetUint64(blockNum)) {
+		return
+	}
+	if db.GetState(params.TNSRegistryAddress, legacyExpirySlot) != (common.Hash{}) {
+		return
+	}
+	var val common.Hash
+	binary.BigEndian.PutUint64(val[24:], cfg.TNSExpiryBlock.Uint64()+params.TNSGracePeriodBlocks)
+	db.SetState(params.TNSRegistryAddress, legacyExpirySlot, val)
+}
+
+// ParentOf returns the parent name hash of a subname, or zero hash for a
+// top-level name.
+func ParentOf(db stateDB, nameHash common.Hash) common.Hash {
+	return db.GetState(params.TNSRegistryAddress, recordSlot(nameHash, "parent"))
+}
+
+// ── Lifecycle ─────────────────────────────────────────────────────────────────
+
+// StatusOf returns the lifecycle status of the top-level name nameHash at block.
+func StatusOf(db stateDB, nameHash common.Hash, block uint64) NameStatus {
+	if rawOwner(db, nameHash) == (common.Address{}) {
+		return NameAvailable
+	}
+	expiry := Expiry(db, nameHash)
+	switch {
+	case expiry == 0:
+		return NamePermanent
+	case block < expiry:
+		return NameActive
+	case block < expiry+params.TNSGracePeriodBlocks:
+		return NameGrace
+	default:
+		return NameAvailable
+	}
+}
+
+// resolves reports whether a top-level name status resolves to its owner.
+func (s NameStatus) resolves() bool {
+	return s == NameActive || s == NamePermanent
+}
+
+// Resolve returns the address nameHash resolves to at block, or zero address
+// if the name is not registered, has expired, or is a subname whose parent
+// has expired or been registered anew since the subname was issued.
+func Resolve(db stateDB, nameHash common.Hash, block uint64) common.Address {
+	parent := ParentOf(db, nameHash)
+	if parent == (common.Hash{}) {
+		if !StatusOf(db, nameHash, block).resolves() {
+			return common.Address{}
+		}
+		return rawOwner(db, nameHash)
+	}
+	if !StatusOf(db, parent, block).resolves() || readU64(db, parent, "gen") != readU64(db, nameHash, "gen") {
+		return common.Address{}
+	}
+	return rawOwner(db, nameHash)
+}
+
+// OwnerOf returns the current owner of the top-level name nameHash at block,
+// including during the grace period, or zero address if it is available.
+func OwnerOf(db stateDB, nameHash common.Hash, block uint64) common.Address {
+	if ParentOf(db, nameHash) != (common.Hash{}) || StatusOf(db, nameHash, block) == NameAvailable {
+		return common.Address{}
+	}
+	return rawOwner(db, nameHash)
 }
 
-// HasName returns true if addr has a registered TNS name.
-func HasName(db stateDB, addr common.Address) bool {
-	return Reverse(db, addr) != (common.Hash{})
+// Reverse returns the name hash registered for addr at block, or zero hash if
+// none resolves.
+func Reverse(db stateDB, addr common.Address, block uint64) common.Hash {
+	nameHash := db.GetState(params.TNSRegistryAddress, addrToNameSlot(addr))
+	if nameHash == (common.Hash{}) || Resolve(db, nameHash, block) != addr {
+		return common.Hash{}
+	}
+	return nameHash
 }
 
+// HasName returns true if addr has a registered TNS name that resolves at block.
+func HasName(db stateDB, addr common.Address, block uint64) bool {
+	return Reverse(db, addr, block) != (common.Hash{})
+}
+
+// holdsName reports whether addr owns a name at block, including one in its
+// grace period. Accounts may hold a single top-level name.
+func holdsName(db stateDB, addr common.Address, block uint64) bool {
+	nameHash := db.GetState(params.TNSRegistryAddress, addrToNameSlot(addr))
+	return nameHash != (common.Hash{}) && OwnerOf(db, nameHash, block) == addr
+}
+
+// ── Write ─────────────────────────────────────────────────────────────────────
+
 func writeMapping(db stateDB, nameHash common.Hash, addr common.Address) {
 	// name_hash → address: store address bytes in slot
 	var addrVal common.Hash
@@ -60,3 +200,58 @@ func writeMapping(db stateDB, nameHash common.Hash, addr common.Address) {
 	// address → name_hash
 	db.SetState(params.TNSRegistryAddress, addrToNameSlot(addr), nameHash)
 }
+
+// clearReverse drops addr's reverse record if it still points at nameHash.
+func clearReverse(db stateDB, addr common.Address, nameHash common.Hash) {
+	slot := addrToNameSlot(addr)
+	if db.GetState(params.TNSRegistryAddress, slot) == nameHash {
+		db.SetState(params.TNSRegistryAddress, slot, common.Hash{})
+	}
+}
+
+// registerName assigns the available top-level name nameHash to owner until
+// expiry. Any previous owner's reverse and resolver records are cleared, and
+// the name's generation is bumped so that subnames issued by earlier owners
+// stop resolving.
+func registerName(db stateDB, nameHash common.Hash, owner common.Address, expiry uint64) {
+	if prev := rawOwner(db, nameHash); prev != (common.Address{}) {
+		clearReverse(db, prev, nameHash)
+		bumpRecords(db, nameHash)
+	}
+	writeU64(db, nameHash, "gen", readU64(db, nameHash, "gen")+1)
+	writeU64(db, nameHash, "expiry", expiry)
+	writeMapping(db, nameHash, owner)
+}
+
+// transferName moves the top-level name nameHash from its owner to to. The
+// resolver records are cleared so that, for example, a PrivTransfer to the
+// name never reaches the previous owner's key.
+func transferName(db stateDB, nameHash common.Hash, from, to common.Address) {
+	clearReverse(db, from, nameHash)
+	bumpRecords(db, nameHash)
+	writeMapping(db, nameHash, to)
+}
+
+// writeSubname points the subname nameHash under parent at addr. Resolver
+// records are cleared when the subname moves to a different address, and
+// when it is a stale subname left over from an earlier parent generation, so
+// records set for the previous holder never resolve to the new one.
+func writeSubname(db stateDB, nameHash, parent common.Hash, addr common.Address) {
+	if prev := rawOwner(db, nameHash); prev != (common.Address{}) &&
+		(prev != addr || readU64(db, nameHash, "gen") != readU64(db, parent, "gen")) {
+		bumpRecords(db, nameHash)
+	}
+	var addrVal common.Hash
+	copy(addrVal[:], addr.Bytes())
+	db.SetState(params.TNSRegistryAddress, nameToAddrSlot(nameHash), addrVal)
+	db.SetState(params.TNSRegistryAddress, recordSlot(nameHash, "parent"), parent)
+	writeU64(db, nameHash, "gen", readU64(db, parent, "gen"))
+}
+
+// deleteSubname removes the subname nameHash and its resolver records.
+func deleteSubname(db stateDB, nameHash common.Hash) {
+	bumpRecords(db, nameHash)
+	db.SetState(params.TNSRegistryAddress, nameToAddrSlot(nameHash), common.Hash{})
+	db.SetState(params.TNSRegistryAddress, recordSlot(nameHash, "parent"), common.Hash{})
+	db.SetState(params.TNSRegistryAddress, recordSlot(nameHash, "gen"), common.Hash{})
+}
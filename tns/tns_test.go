@@ -1,13 +1,18 @@
 package tns
 
 import (
+	"bytes"
 	"encoding/json"
 	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/tos-network/gtos/common"
+	"github.com/tos-network/gtos/common/hexutil"
 	"github.com/tos-network/gtos/core/rawdb"
 	"github.com/tos-network/gtos/core/state"
+	"github.com/tos-network/gtos/crypto"
+	"github.com/tos-network/gtos/crypto/ristretto255"
 	"github.com/tos-network/gtos/params"
 	"github.com/tos-network/gtos/sysaction"
 )
@@ -19,12 +24,16 @@ func newTestState() *state.StateDB {
 }
 
 func newCtx(st *state.StateDB, from common.Address, value *big.Int) *sysaction.Context {
+	return newCtxAt(st, from, value, 1)
+}
+
+func newCtxAt(st *state.StateDB, from common.Address, value *big.Int, block uint64) *sysaction.Context {
 	return &sysaction.Context{
 		From:        from,
 		Value:       value,
-		BlockNumber: big.NewInt(1),
+		BlockNumber: new(big.Int).SetUint64(block),
 		StateDB:     st,
-		ChainConfig: &params.ChainConfig{},
+		ChainConfig: &params.ChainConfig{TNSExpiryBlock: big.NewInt(0)},
 	}
 }
 
@@ -59,10 +68,10 @@ func TestTNSRegisterValid(t *testing.T) {
 	}
 
 	nameHash := HashName("alice")
-	if got := Resolve(st, nameHash); got != addr {
+	if got := Resolve(st, nameHash, 1); got != addr {
 		t.Errorf("Resolve: want %v, got %v", addr, got)
 	}
-	if !HasName(st, addr) {
+	if !HasName(st, addr, 1) {
 		t.Errorf("HasName: want true, got false")
 	}
 }
@@ -114,6 +123,7 @@ func TestTNSRegisterInvalidName(t *testing.T) {
 		{"admin", "reserved"},
 		{"alice.", "trailing separator"},
 		{"alice..bob", "consecutive separators"},
+		{"pay.acme", "dot reserved for subnames"},
 	}
 
 	for _, tc := range cases {
@@ -148,7 +158,401 @@ func TestTNSReverse(t *testing.T) {
 	}
 
 	want := HashName("alice")
-	if got := Reverse(st, addr); got != want {
+	if got := Reverse(st, addr, 1); got != want {
 		t.Errorf("Reverse: want %v, got %v", want, got)
 	}
 }
+
+// actSA builds a TNS SysAction of kind with the given payload.
+func actSA(kind sysaction.ActionKind, payload interface{}) *sysaction.SysAction {
+	raw, _ := json.Marshal(payload)
+	return &sysaction.SysAction{Action: kind, Payload: raw}
+}
+
+// TestTNSLengthPricing verifies that short names cost more and that the fee
+// scales with the number of years.
+func TestTNSLengthPricing(t *testing.T) {
+	st := newTestState()
+	addr := tAddr(0x40)
+	fund(st, addr, params.TNSThreeCharFee)
+
+	if err := h.Handle(newCtx(st, addr, params.TNSFourCharFee), regSA("bob")); err != ErrTNSInsufficientFee {
+		t.Fatalf("3-char name at 4-char fee: want ErrTNSInsufficientFee, got %v", err)
+	}
+	if err := h.Handle(newCtx(st, addr, params.TNSRegistrationFee), actSA(sysaction.ActionTNSRegister, map[string]interface{}{"name": "alice", "years": 2})); err != ErrTNSInsufficientFee {
+		t.Fatalf("2 years at 1-year fee: want ErrTNSInsufficientFee, got %v", err)
+	}
+	if err := h.Handle(newCtx(st, addr, params.TNSThreeCharFee), regSA("bob")); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if got, want := Expiry(st, HashName("bob")), 1+params.TNSRegistrationPeriodBlocks; got != want {
+		t.Errorf("Expiry: want %d, got %d", want, got)
+	}
+}
+
+// TestTNSExpiryGraceAndRenewal walks a name through expiry, grace period
+// renewal and finally lapse and re-registration by another account.
+func TestTNSExpiryGraceAndRenewal(t *testing.T) {
+	st := newTestState()
+	owner, other := tAddr(0x50), tAddr(0x51)
+	fund(st, owner, params.TNSRegistrationFee)
+	fund(st, other, params.TNSRegistrationFee)
+
+	if err := h.Handle(newCtx(st, owner, params.TNSRegistrationFee), regSA("alice")); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	nameHash := HashName("alice")
+	expiry := Expiry(st, nameHash)
+
+	// Expired names stop resolving but stay reserved during grace.
+	inGrace := expiry + 1
+	if got := Resolve(st, nameHash, inGrace); got != (common.Address{}) {
+		t.Errorf("Resolve in grace: want zero, got %v", got)
+	}
+	if HasName(st, owner, inGrace) {
+		t.Errorf("HasName in grace: want false")
+	}
+	if err := h.Handle(newCtxAt(st, other, params.TNSRegistrationFee, inGrace), regSA("alice")); err != ErrTNSAlreadyRegistered {
+		t.Fatalf("register in grace: want ErrTNSAlreadyRegistered, got %v", err)
+	}
+	if err := h.Handle(newCtxAt(st, owner, params.TNSRegistrationFee, inGrace), regSA("carol")); err != ErrTNSAccountHasName {
+		t.Fatalf("second name in grace: want ErrTNSAccountHasName, got %v", err)
+	}
+
+	// Renewal in grace counts from the old expiry.
+	if err := h.Handle(newCtxAt(st, owner, params.TNSRegistrationFee, inGrace), actSA(sysaction.ActionTNSRenew, map[string]string{"name": "alice"})); err != nil {
+		t.Fatalf("renew: %v", err)
+	}
+	if got, want := Expiry(st, nameHash), expiry+params.TNSRegistrationPeriodBlocks; got != want {
+		t.Errorf("renewed Expiry: want %d, got %d", want, got)
+	}
+	if got := Resolve(st, nameHash, inGrace); got != owner {
+		t.Errorf("Resolve after renew: want %v, got %v", owner, got)
+	}
+
+	// Renewing past the maximum horizon is rejected.
+	tooLong := map[string]interface{}{"name": "alice", "years": params.TNSMaxRegistrationYears}
+	fee := new(big.Int).Mul(params.TNSRegistrationFee, new(big.Int).SetUint64(params.TNSMaxRegistrationYears))
+	if err := h.Handle(newCtxAt(st, owner, fee, inGrace), actSA(sysaction.ActionTNSRenew, tooLong)); err != ErrTNSInvalidYears {
+		t.Fatalf("renew past horizon: want ErrTNSInvalidYears, got %v", err)
+	}
+
+	// After grace the name is available to anyone.
+	lapsed := Expiry(st, nameHash) + params.TNSGracePeriodBlocks
+	if err := h.Handle(newCtxAt(st, other, params.TNSRegistrationFee, lapsed), regSA("alice")); err != nil {
+		t.Fatalf("re-register lapsed name: %v", err)
+	}
+	if got := Resolve(st, nameHash, lapsed); got != other {
+		t.Errorf("Resolve after re-register: want %v, got %v", other, got)
+	}
+	if got := Reverse(st, owner, lapsed); got != (common.Hash{}) {
+		t.Errorf("old owner Reverse: want zero, got %v", got)
+	}
+}
+
+// TestTNSTransfer verifies that only the owner can transfer, that the
+// recipient must not hold a name, and that both reverse records move.
+func TestTNSTransfer(t *testing.T) {
+	st := newTestState()
+	owner, to, taken := tAddr(0x60), tAddr(0x61), tAddr(0x62)
+	fund(st, owner, params.TNSRegistrationFee)
+	fund(st, taken, params.TNSRegistrationFee)
+	if err := h.Handle(newCtx(st, owner, params.TNSRegistrationFee), regSA("alice")); err != nil {
+		t.Fatalf("register alice: %v", err)
+	}
+	if err := h.Handle(newCtx(st, taken, params.TNSRegistrationFee), regSA("carol")); err != nil {
+		t.Fatalf("register carol: %v", err)
+	}
+
+	transfer := func(from, recipient common.Address) error {
+		return h.Handle(newCtx(st, from, big.NewInt(0)), actSA(sysaction.ActionTNSTransfer, transferPayload{Name: "alice", To: recipient}))
+	}
+	if err := transfer(to, to); err != ErrTNSNotOwner {
+		t.Fatalf("non-owner transfer: want ErrTNSNotOwner, got %v", err)
+	}
+	if err := transfer(owner, taken); err != ErrTNSAccountHasName {
+		t.Fatalf("transfer to name holder: want ErrTNSAccountHasName, got %v", err)
+	}
+	if err := transfer(owner, to); err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+	nameHash := HashName("alice")
+	if got := Resolve(st, nameHash, 1); got != to {
+		t.Errorf("Resolve: want %v, got %v", to, got)
+	}
+	if HasName(st, owner, 1) {
+		t.Errorf("old owner HasName: want false")
+	}
+	if got := Reverse(st, to, 1); got != nameHash {
+		t.Errorf("new owner Reverse: want %v, got %v", nameHash, got)
+	}
+}
+
+// TestTNSSubnames verifies that the parent owner issues and revokes
+// subnames, and that subnames die with a lapsed parent.
+func TestTNSSubnames(t *testing.T) {
+	st := newTestState()
+	owner, target, other := tAddr(0x70), tAddr(0x71), tAddr(0x72)
+	fund(st, owner, params.TNSRegistrationFee)
+	fund(st, other, params.TNSRegistrationFee)
+	if err := h.Handle(newCtx(st, owner, params.TNSRegistrationFee), regSA("acme")); err != ErrTNSInsufficientFee {
+		t.Fatalf("4-char name at base fee: want ErrTNSInsufficientFee, got %v", err)
+	}
+	if err := h.Handle(newCtx(st, owner, params.TNSRegistrationFee), regSA("acmecorp")); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	set := func(from common.Address, label string, addr common.Address, block uint64) error {
+		return h.Handle(newCtxAt(st, from, big.NewInt(0), block), actSA(sysaction.ActionTNSSubnameSet, subnamePayload{Parent: "acmecorp", Label: label, Address: addr}))
+	}
+	if err := set(other, "pay", target, 1); err != ErrTNSNotOwner {
+		t.Fatalf("non-owner subname: want ErrTNSNotOwner, got %v", err)
+	}
+	if err := set(owner, "pay.x", target, 1); err != ErrTNSInvalidName {
+		t.Fatalf("dotted label: want ErrTNSInvalidName, got %v", err)
+	}
+	if err := set(owner, "pay", common.Address{}, 1); err != ErrTNSInvalidAddress {
+		t.Fatalf("zero address: want ErrTNSInvalidAddress, got %v", err)
+	}
+	if err := set(owner, "pay", target, 1); err != nil {
+		t.Fatalf("set subname: %v", err)
+	}
+	subHash := HashName("pay.acmecorp")
+	if got := Resolve(st, subHash, 1); got != target {
+		t.Errorf("Resolve subname: want %v, got %v", target, got)
+	}
+	if got := ParentOf(st, subHash); got != HashName("acmecorp") {
+		t.Errorf("ParentOf: want %v, got %v", HashName("acmecorp"), got)
+	}
+	if HasName(st, target, 1) {
+		t.Errorf("subname target HasName: want false")
+	}
+
+	// Revocation removes the subname.
+	revoke := actSA(sysaction.ActionTNSSubnameRevoke, subnamePayload{Parent: "acmecorp", Label: "pay"})
+	if err := h.Handle(newCtx(st, owner, big.NewInt(0)), revoke); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if got := Resolve(st, subHash, 1); got != (common.Address{}) {
+		t.Errorf("Resolve revoked subname: want zero, got %v", got)
+	}
+	if err := h.Handle(newCtx(st, owner, big.NewInt(0)), revoke); err != ErrTNSNameNotFound {
+		t.Fatalf("revoke twice: want ErrTNSNameNotFound, got %v", err)
+	}
+
+	// Subnames stop resolving when the parent expires and do not survive
+	// re-registration of the parent by a new owner.
+	if err := set(owner, "pay", target, 1); err != nil {
+		t.Fatalf("set subname again: %v", err)
+	}
+	expiry := Expiry(st, HashName("acmecorp"))
+	if got := Resolve(st, subHash, expiry); got != (common.Address{}) {
+		t.Errorf("Resolve with expired parent: want zero, got %v", got)
+	}
+	lapsed := expiry + params.TNSGracePeriodBlocks
+	if err := h.Handle(newCtxAt(st, other, params.TNSRegistrationFee, lapsed), regSA("acmecorp")); err != nil {
+		t.Fatalf("re-register parent: %v", err)
+	}
+	if got := Resolve(st, subHash, lapsed); got != (common.Address{}) {
+		t.Errorf("Resolve stale subname: want zero, got %v", got)
+	}
+}
+
+// TestTNSExpiryFork verifies that before TNSExpiryBlock names are permanent at
+// the flat fee, and that once the fork activates they expire
+// TNSGracePeriodBlocks after it unless renewed.
+func TestTNSExpiryFork(t *testing.T) {
+	st := newTestState()
+	addr, other := tAddr(0x80), tAddr(0x81)
+	fund(st, addr, params.TNSRegistrationFee)
+	fund(st, other, params.TNSRegistrationFee)
+	const fork = 100
+	cfg := &params.ChainConfig{TNSExpiryBlock: big.NewInt(fork)}
+	at := func(from common.Address, block uint64) *sysaction.Context {
+		ctx := newCtxAt(st, from, params.TNSRegistrationFee, block)
+		ctx.ChainConfig = cfg
+		return ctx
+	}
+
+	// A three-character name costs the flat fee and never expires.
+	if err := h.Handle(at(addr, 10), regSA("bob")); err != nil {
+		t.Fatalf("register before fork: %v", err)
+	}
+	nameHash := HashName("bob")
+	if got := StatusOf(st, nameHash, 1<<40); got != NamePermanent {
+		t.Errorf("StatusOf before fork: want %v, got %v", NamePermanent, got)
+	}
+	if err := h.Handle(at(addr, 20), actSA(sysaction.ActionTNSRenew, map[string]string{"name": "bob"})); err != ErrTNSPermanentName {
+		t.Errorf("renew before fork: want ErrTNSPermanentName, got %v", err)
+	}
+
+	// Activation gives the name an expiry, written once.
+	ActivateExpiry(st, fork-1, cfg)
+	if got := Expiry(st, nameHash); got != 0 {
+		t.Fatalf("Expiry before fork: want 0, got %d", got)
+	}
+	ActivateExpiry(st, fork, cfg)
+	ActivateExpiry(st, fork+1, cfg)
+	expiry := uint64(fork) + params.TNSGracePeriodBlocks
+	if got := Expiry(st, nameHash); got != expiry {
+		t.Fatalf("legacy Expiry: want %d, got %d", expiry, got)
+	}
+	if got := StatusOf(st, nameHash, expiry-1); got != NameActive {
+		t.Errorf("StatusOf before legacy expiry: want %v, got %v", NameActive, got)
+	}
+	if got := Resolve(st, nameHash, expiry); got != (common.Address{}) {
+		t.Errorf("Resolve after legacy expiry: want zero, got %v", got)
+	}
+
+	// Short names now cost their length-based fee.
+	if err := h.Handle(at(other, fork), regSA("eve")); err != ErrTNSInsufficientFee {
+		t.Errorf("3-char name at flat fee after fork: want ErrTNSInsufficientFee, got %v", err)
+	}
+
+	// Once past grace the name is available again.
+	lapsed := expiry + params.TNSGracePeriodBlocks
+	if got := StatusOf(st, nameHash, lapsed); got != NameAvailable {
+		t.Errorf("StatusOf after grace: want %v, got %v", NameAvailable, got)
+	}
+
+	// Renewal counts from the legacy expiry.
+	fund(st, addr, params.TNSThreeCharFee)
+	renew := at(addr, fork+1)
+	renew.Value = params.TNSThreeCharFee
+	if err := h.Handle(renew, actSA(sysaction.ActionTNSRenew, map[string]string{"name": "bob"})); err != nil {
+		t.Fatalf("renew after fork: %v", err)
+	}
+	if got, want := Expiry(st, nameHash), expiry+params.TNSRegistrationPeriodBlocks; got != want {
+		t.Errorf("renewed Expiry: want %d, got %d", want, got)
+	}
+}
+
+// setRecordSA builds a TNS_SET_RECORD SysAction.
+func setRecordSA(name, key, value string) *sysaction.SysAction {
+	return actSA(sysaction.ActionTNSSetRecord, setRecordPayload{Name: name, Key: key, Value: value})
+}
+
+// TestTNSRecords verifies setting, reading, overwriting and clearing
+// resolver records, and that only the owner may set them.
+func TestTNSRecords(t *testing.T) {
+	st := newTestState()
+	owner, other := tAddr(0x90), tAddr(0x91)
+	fund(st, owner, params.TNSRegistrationFee)
+	if err := h.Handle(newCtx(st, owner, params.TNSRegistrationFee), regSA("alice")); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	nameHash := HashName("alice")
+	set := func(from common.Address, key, value string) error {
+		return h.Handle(newCtx(st, from, big.NewInt(0)), setRecordSA("alice", key, value))
+	}
+
+	pub := ristretto255.NewGeneratorElement().Bytes()
+	if err := set(other, RecordElGamal, hexutil.Encode(pub)); err != ErrTNSNotOwner {
+		t.Fatalf("non-owner: want ErrTNSNotOwner, got %v", err)
+	}
+	if err := set(owner, RecordElGamal, "0x"+common.Bytes2Hex(make([]byte, 31))); err != ErrTNSInvalidRecordValue {
+		t.Fatalf("short key: want ErrTNSInvalidRecordValue, got %v", err)
+	}
+	bad := make([]byte, 32)
+	for i := range bad {
+		bad[i] = 0xff
+	}
+	if err := set(owner, RecordElGamal, hexutil.Encode(bad)); err != ErrTNSInvalidRecordValue {
+		t.Fatalf("non-canonical point: want ErrTNSInvalidRecordValue, got %v", err)
+	}
+	if err := set(owner, "Bad Key", "x"); err != ErrTNSInvalidRecordKey {
+		t.Fatalf("bad key: want ErrTNSInvalidRecordKey, got %v", err)
+	}
+	if err := set(owner, RecordElGamal, hexutil.Encode(pub)); err != nil {
+		t.Fatalf("set elgamal: %v", err)
+	}
+	if got, ok := ElGamalKeyOf(st, nameHash, 1); !ok || !bytes.Equal(got[:], pub) {
+		t.Errorf("ElGamalKeyOf: want %x, got %x (ok=%v)", pub, got, ok)
+	}
+
+	long := strings.Repeat("a", 70)
+	for _, v := range []string{"https://gw.example/" + long, "https://gw.example"} {
+		if err := set(owner, RecordGateway, v); err != nil {
+			t.Fatalf("set gateway: %v", err)
+		}
+		if got := FormatRecord(RecordGateway, ReadRecord(st, nameHash, RecordGateway, 1)); got != v {
+			t.Errorf("gateway: want %q, got %q", v, got)
+		}
+	}
+	if err := set(owner, "com.twitter", "@alice"); err != nil {
+		t.Fatalf("set text: %v", err)
+	}
+	if err := set(owner, "com.twitter", ""); err != nil {
+		t.Fatalf("clear text: %v", err)
+	}
+	if got := ReadRecord(st, nameHash, "com.twitter", 1); got != nil {
+		t.Errorf("cleared text: want nil, got %q", got)
+	}
+
+	// Records disappear with expiry and are cleared on transfer.
+	if got := ReadRecord(st, nameHash, RecordGateway, Expiry(st, nameHash)); got != nil {
+		t.Errorf("expired gateway: want nil, got %q", got)
+	}
+	if err := h.Handle(newCtx(st, owner, big.NewInt(0)), actSA(sysaction.ActionTNSTransfer, transferPayload{Name: "alice", To: other})); err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+	if _, ok := ElGamalKeyOf(st, nameHash, 1); ok {
+		t.Errorf("ElGamalKeyOf after transfer: want unset")
+	}
+}
+
+// TestTNSSubnameRecords verifies that the parent owner sets subname records
+// and that revoking a subname discards them.
+func TestTNSSubnameRecords(t *testing.T) {
+	st := newTestState()
+	owner, target := tAddr(0xa0), tAddr(0xa1)
+	fund(st, owner, params.TNSRegistrationFee)
+	if err := h.Handle(newCtx(st, owner, params.TNSRegistrationFee), regSA("acmecorp")); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	subSet := actSA(sysaction.ActionTNSSubnameSet, subnamePayload{Parent: "acmecorp", Label: "pay", Address: target})
+	if err := h.Handle(newCtx(st, owner, big.NewInt(0)), subSet); err != nil {
+		t.Fatalf("set subname: %v", err)
+	}
+	card := crypto.Keccak256Hash([]byte("card")).Hex()
+	if err := h.Handle(newCtx(st, target, big.NewInt(0)), setRecordSA("pay.acmecorp", RecordAgentCard, card)); err != ErrTNSNotOwner {
+		t.Fatalf("subname target: want ErrTNSNotOwner, got %v", err)
+	}
+	if err := h.Handle(newCtx(st, owner, big.NewInt(0)), setRecordSA("pay.acmecorp", RecordAgentCard, card)); err != nil {
+		t.Fatalf("set subname record: %v", err)
+	}
+	subHash := HashName("pay.acmecorp")
+	if got := FormatRecord(RecordAgentCard, ReadRecord(st, subHash, RecordAgentCard, 1)); got != card {
+		t.Errorf("agent card: want %s, got %s", card, got)
+	}
+
+	// Re-pointing at the same address keeps the records ...
+	if err := h.Handle(newCtx(st, owner, big.NewInt(0)), subSet); err != nil {
+		t.Fatalf("re-set same address: %v", err)
+	}
+	if got := FormatRecord(RecordAgentCard, ReadRecord(st, subHash, RecordAgentCard, 1)); got != card {
+		t.Errorf("agent card after same-address set: want %s, got %s", card, got)
+	}
+	// ... while moving the subname to another address clears them.
+	moved := actSA(sysaction.ActionTNSSubnameSet, subnamePayload{Parent: "acmecorp", Label: "pay", Address: tAddr(0xa2)})
+	if err := h.Handle(newCtx(st, owner, big.NewInt(0)), moved); err != nil {
+		t.Fatalf("move subname: %v", err)
+	}
+	if got := ReadRecord(st, subHash, RecordAgentCard, 1); got != nil {
+		t.Errorf("record after address change: want nil, got %x", got)
+	}
+	if err := h.Handle(newCtx(st, owner, big.NewInt(0)), setRecordSA("pay.acmecorp", RecordAgentCard, card)); err != nil {
+		t.Fatalf("set subname record: %v", err)
+	}
+
+	revoke := actSA(sysaction.ActionTNSSubnameRevoke, subnamePayload{Parent: "acmecorp", Label: "pay"})
+	if err := h.Handle(newCtx(st, owner, big.NewInt(0)), revoke); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if err := h.Handle(newCtx(st, owner, big.NewInt(0)), subSet); err != nil {
+		t.Fatalf("re-set subname: %v", err)
+	}
+	if got := ReadRecord(st, subHash, RecordAgentCard, 1); got != nil {
+		t.Errorf("record after revoke: want nil, got %x", got)
+	}
+}
@@ -2,10 +2,41 @@ package tns
 
 import "errors"
 
+// NameStatus is the lifecycle status of a top-level name.
+type NameStatus uint8
+
+const (
+	NameAvailable NameStatus = iota // never registered, or lapsed past its grace period
+	NameActive                      // registered and not yet expired
+	NameGrace                       // expired; only renewal is possible
+	NamePermanent                   // registered before TNSExpiryBlock, which is not yet active
+)
+
+func (s NameStatus) String() string {
+	switch s {
+	case NameActive:
+		return "active"
+	case NameGrace:
+		return "grace"
+	case NamePermanent:
+		return "permanent"
+	default:
+		return "available"
+	}
+}
+
 var (
-	ErrTNSAlreadyRegistered = errors.New("tns: name already registered")
-	ErrTNSAccountHasName    = errors.New("tns: account already has a registered name")
-	ErrTNSInvalidName       = errors.New("tns: invalid name format")
-	ErrTNSInsufficientFee   = errors.New("tns: registration fee not met")
-	ErrTNSNameNotFound      = errors.New("tns: name not found")
+	ErrTNSAlreadyRegistered  = errors.New("tns: name already registered")
+	ErrTNSAccountHasName     = errors.New("tns: account already has a registered name")
+	ErrTNSInvalidName        = errors.New("tns: invalid name format")
+	ErrTNSInsufficientFee    = errors.New("tns: registration fee not met")
+	ErrTNSNameNotFound       = errors.New("tns: name not found")
+	ErrTNSNotOwner           = errors.New("tns: caller is not the name owner")
+	ErrTNSNameExpired        = errors.New("tns: name has expired")
+	ErrTNSInvalidYears       = errors.New("tns: invalid registration period")
+	ErrTNSPermanentName      = errors.New("tns: permanent names cannot be renewed")
+	ErrTNSInvalidRecipient   = errors.New("tns: invalid transfer recipient")
+	ErrTNSInvalidAddress     = errors.New("tns: invalid subname address")
+	ErrTNSInvalidRecordKey   = errors.New("tns: invalid record key")
+	ErrTNSInvalidRecordValue = errors.New("tns: invalid record value")
 )
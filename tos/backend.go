@@ -24,11 +24,15 @@ import (
 	"github.com/tos-network/gtos/core/rawdb"
 	"github.com/tos-network/gtos/core/state/pruner"
 	"github.com/tos-network/gtos/core/types"
+	"github.com/tos-network/gtos/crypto/priv/ecdlptable"
 	_ "github.com/tos-network/gtos/delegation" // registers DELEGATION_* handlers via init()
+	_ "github.com/tos-network/gtos/dispute"    // registers DISPUTE_* handlers via init()
 	"github.com/tos-network/gtos/event"
-	_ "github.com/tos-network/gtos/group" // registers GROUP_* handlers via init()
+	_ "github.com/tos-network/gtos/group"  // registers GROUP_* handlers via init()
+	_ "github.com/tos-network/gtos/intent" // registers INTENT_* handlers via init()
 	"github.com/tos-network/gtos/internal/shutdowncheck"
 	"github.com/tos-network/gtos/internal/tosapi"
+	_ "github.com/tos-network/gtos/job"   // registers JOB_* handlers via init()
 	_ "github.com/tos-network/gtos/kyc"   // registers KYC_* handlers via init()
 	_ "github.com/tos-network/gtos/lease" // registers LEASE_* handlers via init()
 	"github.com/tos-network/gtos/log"
@@ -44,7 +48,7 @@ import (
 	"github.com/tos-network/gtos/rlp"
 	"github.com/tos-network/gtos/rpc"
 	_ "github.com/tos-network/gtos/task" // registers TASK_SCHEDULE/TASK_CANCEL handlers via init()
-	_ "github.com/tos-network/gtos/tns"  // registers TNS_* handlers via init()
+	"github.com/tos-network/gtos/tns"    // registers TNS_* handlers via init(); also used for RPC API registration
 	"github.com/tos-network/gtos/tos/downloader"
 	"github.com/tos-network/gtos/tos/protocols/snap"
 	"github.com/tos-network/gtos/tos/protocols/tos"
@@ -78,6 +82,8 @@ type TOS struct {
 
 	bloomRequests     chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
 	bloomIndexer      *core.ChainIndexer             // Bloom indexer operating during block imports
+	privTxIndexer     *core.ChainIndexer             // Priv tx history indexer, nil unless enabled
+	privECDLPTable    *ecdlptable.File               // BSGS table served to wallets, nil unless configured
 	closeBloomHandler chan struct{}
 
 	APIBackend *TOSAPIBackend
@@ -231,6 +237,18 @@ func New(stack *node.Node, config *tosconfig.Config) (*TOS, error) {
 		rawdb.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
 	tosNode.bloomIndexer.Start(tosNode.blockchain)
+	if config.PrivECDLPTable != "" {
+		table, err := ecdlptable.OpenFile(stack.ResolvePath(config.PrivECDLPTable))
+		if err != nil {
+			return nil, err
+		}
+		tosNode.privECDLPTable = table
+		log.Info("Serving ECDLP table", "l1", table.Manifest().L1, "chunks", len(table.Manifest().Chunks))
+	}
+	if config.PrivTxIndex {
+		tosNode.privTxIndexer = core.NewPrivTxIndexer(chainDb, params.PrivTxIndexBlocks, params.PrivTxIndexConfirms)
+		tosNode.privTxIndexer.Start(tosNode.blockchain)
+	}
 
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = stack.ResolvePath(config.TxPool.Journal)
@@ -356,6 +374,52 @@ func (s *TOS) APIs() []rpc.API {
 		return stateDB
 	})...)
 
+	// TNS queries evaluate name expiry at the head block.
+	apis = append(apis, rpc.API{
+		Namespace: "tns",
+		Service: tns.NewPublicTNSAPI(func() (tns.StateDB, uint64) {
+			head := s.blockchain.CurrentBlock()
+			if head == nil {
+				return nil, 0
+			}
+			stateDB, err := s.blockchain.StateAt(head.Root())
+			if err != nil {
+				return nil, 0
+			}
+			return stateDB, head.NumberU64()
+		}),
+	})
+
+	// Reputation queries decay capability scores to the head block.
+	apis = append(apis, rpc.API{
+		Namespace: "reputation",
+		Service: reputation.NewPublicReputationAPI(func() (reputation.StateDB, uint64) {
+			head := s.blockchain.CurrentBlock()
+			if head == nil {
+				return nil, 0
+			}
+			stateDB, err := s.blockchain.StateAt(head.Root())
+			if err != nil {
+				return nil, 0
+			}
+			return stateDB, head.NumberU64()
+		}),
+	})
+
+	// Expose the priv tx history index when it is maintained.
+	if s.privTxIndexer != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "priv",
+			Service:   tosapi.NewPrivIndexAPI(s.APIBackend, s.privTxIndexer),
+		})
+	}
+	if s.privECDLPTable != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "priv",
+			Service:   tosapi.NewPrivTableAPI(s.privECDLPTable),
+		})
+	}
+
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
 
@@ -617,6 +681,11 @@ func (s *TOS) agentDiscoveryTrustSummary(identity common.Address, capabilityName
 		summary.CapabilityRegistered = true
 		summary.CapabilityBit = &bit
 		summary.HasOnchainCapability = capability.HasCapability(stateDB, identity, bit)
+
+		capScore := reputation.ReadCapabilityScore(stateDB, identity, bit, head.NumberU64())
+		summary.CapabilityReputation = capScore.Score.String()
+		summary.CapabilityConfidence = capScore.Confidence
+		summary.CapabilityReputationBucket = capabilityReputationBucket(capScore.Score, capScore.Confidence)
 	}
 	summary.LocalRankScore, summary.LocalRankReason = providerRankSummary(summary)
 	return summary
@@ -648,6 +717,17 @@ func providerRankSummary(summary *agentdiscovery.ProviderTrustSummary) (int64, s
 		score += 100
 		reasons = append(reasons, "medium-reputation")
 	}
+	switch summary.CapabilityReputationBucket {
+	case "high":
+		score += 250
+		reasons = append(reasons, "high-capability-reputation")
+	case "medium":
+		score += 120
+		reasons = append(reasons, "medium-capability-reputation")
+	case "negative":
+		score -= 300
+		reasons = append(reasons, "negative-capability-reputation")
+	}
 	switch summary.StakeBucket {
 	case "high":
 		score += 80
@@ -692,6 +772,25 @@ func reputationBucket(score *big.Int, ratingCount *big.Int) string {
 	}
 }
 
+// capabilityReputationBucket buckets a decayed capability score by its
+// confidence (in basis points), so a few fresh ratings do not outrank a
+// long, consistent record.
+func capabilityReputationBucket(score *big.Int, confidence uint64) string {
+	if score == nil || confidence == 0 {
+		return "none"
+	}
+	switch {
+	case score.Sign() < 0:
+		return "negative"
+	case score.Sign() > 0 && confidence >= 5_000:
+		return "high"
+	case score.Sign() > 0 && confidence >= 2_000:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
 func (s *TOS) SyncMode() downloader.SyncMode {
 	mode, _ := s.handler.chainSync.modeAndLocalHead()
 	return mode
@@ -750,6 +849,12 @@ func (s *TOS) Stop() error {
 
 	// Then stop everything else.
 	s.bloomIndexer.Close()
+	if s.privTxIndexer != nil {
+		s.privTxIndexer.Close()
+	}
+	if s.privECDLPTable != nil {
+		s.privECDLPTable.Close()
+	}
 	close(s.closeBloomHandler)
 	s.txPool.Stop()
 	s.miner.Close()
@@ -65,6 +65,15 @@ type Config struct {
 
 	TxLookupLimit uint64 `toml:",omitempty"` // The maximum number of blocks from head whose tx indices are reserved.
 
+	// PrivTxIndex enables the priv transaction history index, which maps
+	// ElGamal public keys to the PrivTransfer/Shield/Unshield transactions
+	// touching them and backs the priv_getTransactionsByKey RPC.
+	PrivTxIndex bool `toml:",omitempty"`
+
+	// PrivECDLPTable is the path of a precomputed BSGS table (version 2) served
+	// to wallets in chunks through the priv_getECDLPTable* RPCs.
+	PrivECDLPTable string `toml:",omitempty"`
+
 	// RequiredBlocks is a set of block number -> hash mappings which must be in the
 	// canonical chain of all remote peers. Setting the option makes gtos verify the
 	// presence of these blocks for every new peer connection.
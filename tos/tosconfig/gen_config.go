@@ -24,6 +24,8 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		NoPruning                             bool
 		NoPrefetch                            bool
 		TxLookupLimit                         uint64                 `toml:",omitempty"`
+		PrivTxIndex                           bool                   `toml:",omitempty"`
+		PrivECDLPTable                        string                 `toml:",omitempty"`
 		RequiredBlocks                        map[uint64]common.Hash `toml:"-"`
 		LightServ                             int                    `toml:",omitempty"`
 		LightIngress                          int                    `toml:",omitempty"`
@@ -71,6 +73,8 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.NoPruning = c.NoPruning
 	enc.NoPrefetch = c.NoPrefetch
 	enc.TxLookupLimit = c.TxLookupLimit
+	enc.PrivTxIndex = c.PrivTxIndex
+	enc.PrivECDLPTable = c.PrivECDLPTable
 	enc.RequiredBlocks = c.RequiredBlocks
 	enc.LightServ = c.LightServ
 	enc.LightIngress = c.LightIngress
@@ -122,6 +126,8 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		NoPruning                             *bool
 		NoPrefetch                            *bool
 		TxLookupLimit                         *uint64                `toml:",omitempty"`
+		PrivTxIndex                           *bool                  `toml:",omitempty"`
+		PrivECDLPTable                        *string                `toml:",omitempty"`
 		RequiredBlocks                        map[uint64]common.Hash `toml:"-"`
 		LightServ                             *int                   `toml:",omitempty"`
 		LightIngress                          *int                   `toml:",omitempty"`
@@ -188,6 +194,12 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.TxLookupLimit != nil {
 		c.TxLookupLimit = *dec.TxLookupLimit
 	}
+	if dec.PrivTxIndex != nil {
+		c.PrivTxIndex = *dec.PrivTxIndex
+	}
+	if dec.PrivECDLPTable != nil {
+		c.PrivECDLPTable = *dec.PrivECDLPTable
+	}
 	if dec.RequiredBlocks != nil {
 		c.RequiredBlocks = dec.RequiredBlocks
 	}
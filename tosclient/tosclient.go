@@ -13,6 +13,7 @@ import (
 	"github.com/tos-network/gtos/common"
 	"github.com/tos-network/gtos/common/hexutil"
 	"github.com/tos-network/gtos/core/types"
+	"github.com/tos-network/gtos/gateway"
 	"github.com/tos-network/gtos/rpc"
 	tolmeta "github.com/tos-network/tolang/metadata"
 )
@@ -599,6 +600,15 @@ func (ec *Client) GetSponsorNonce(ctx context.Context, address common.Address, b
 	return uint64(raw), nil
 }
 
+// GetGatewayConfig returns the on-chain relay configuration of a gateway agent.
+func (ec *Client) GetGatewayConfig(ctx context.Context, agent common.Address) (*gateway.GatewayConfigResult, error) {
+	var out gateway.GatewayConfigResult
+	if err := ec.c.CallContext(ctx, &out, "gateway_getGatewayConfig", agent); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
 // GetLease returns lease metadata for a contract at a block, or nil when absent.
 func (ec *Client) GetLease(ctx context.Context, address common.Address, blockNumber *big.Int) (*LeaseRecord, error) {
 	var raw *struct {